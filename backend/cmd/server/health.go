@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/middleware"
+	"fund-analyzer/internal/service"
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthStatus 健康状态
+type HealthStatus struct {
+	Status   string            `json:"status"`
+	Time     string            `json:"time"`
+	Uptime   string            `json:"uptime"`
+	Version  string            `json:"version"`
+	Services map[string]string `json:"services"`
+	// Reasons 导致降级的组件，如 "redis unhealthy"、"crawler:gold open"
+	Reasons []string `json:"reasons,omitempty"`
+	// Breakers 各数据源熔断器的状态、失败次数与最近状态变化时间
+	Breakers []crawler.BreakerStatus `json:"breakers"`
+}
+
+// dbPinger 数据库连通性检查
+type dbPinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// healthDeps 健康检查依赖
+type healthDeps struct {
+	db             dbPinger
+	cache          service.CacheService
+	redisConnected bool
+	cbManager      *crawler.CircuitBreakerManager
+	emailErr       error // 启动时邮件配置校验结果
+}
+
+// healthCheck 增强版健康检查
+// Validates: Requirements 22.4
+func healthCheck(c *gin.Context, deps healthDeps) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	health := checkHealth(ctx, deps)
+
+	// 根据状态返回不同的 HTTP 状态码
+	if health.Status == "healthy" {
+		response.Success(c, health)
+	} else if health.Status == "shutting_down" {
+		c.JSON(http.StatusServiceUnavailable, response.Response{
+			Code:    503,
+			Message: "Service is shutting down",
+			Data:    health,
+		})
+	} else {
+		c.JSON(http.StatusOK, response.Response{
+			Code:    0,
+			Message: "Service is degraded but operational",
+			Data:    health,
+		})
+	}
+}
+
+// checkHealth 检查各依赖状态，降级时在 Reasons 中列出出问题的组件
+func checkHealth(ctx context.Context, deps healthDeps) HealthStatus {
+	services := make(map[string]string)
+	var reasons []string
+
+	// 检查数据库连接
+	if err := deps.db.PingContext(ctx); err != nil {
+		services["database"] = "unhealthy: " + err.Error()
+		reasons = append(reasons, "database unhealthy")
+	} else {
+		services["database"] = "healthy"
+	}
+
+	// 检查 Redis 连接
+	if deps.redisConnected {
+		// 尝试执行一个简单的缓存操作
+		testKey := "health:check"
+		testValue := []byte("ok")
+		if err := deps.cache.Set(ctx, testKey, testValue, 10*time.Second); err != nil {
+			services["redis"] = "unhealthy: " + err.Error()
+			reasons = append(reasons, "redis unhealthy")
+		} else {
+			services["redis"] = "healthy"
+		}
+	} else {
+		services["redis"] = "not_configured (using memory cache)"
+	}
+
+	// 检查邮件配置
+	if deps.emailErr != nil {
+		services["email"] = "misconfigured: " + deps.emailErr.Error()
+		reasons = append(reasons, "email misconfigured")
+	} else {
+		services["email"] = "healthy"
+	}
+
+	// 检查数据源熔断器：仅核心数据源熔断时降级
+	breakers := deps.cbManager.Snapshot()
+	for _, st := range breakers {
+		services["source:"+st.Name] = st.State.String()
+		if st.Override != crawler.OverrideAuto {
+			services["source:"+st.Name] += " (forced)"
+		}
+		if st.State == crawler.StateOpen && st.Priority == crawler.PriorityCritical {
+			reasons = append(reasons, "crawler:"+st.Name+" open")
+		}
+	}
+
+	// SSE 流统计（仅供观察，不影响健康状态）
+	streams := middleware.SSEMetrics()
+	services["sse_streams"] = fmt.Sprintf("total=%d completed=%d aborted=%d active=%d",
+		streams.Total, streams.Completed, streams.Aborted, streams.Active)
+
+	overallStatus := "healthy"
+	if len(reasons) > 0 {
+		overallStatus = "degraded"
+	}
+
+	// 检查是否正在关闭
+	if isShuttingDown.Load() {
+		overallStatus = "shutting_down"
+	}
+
+	return HealthStatus{
+		Status:   overallStatus,
+		Time:     time.Now().Format(time.RFC3339),
+		Uptime:   formatDuration(time.Since(startTime)),
+		Version:  "1.0.0",
+		Services: services,
+		Reasons:  reasons,
+		Breakers: breakers,
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakePinger 返回预设的数据库连通性结果
+type fakePinger struct{ err error }
+
+func (p fakePinger) PingContext(ctx context.Context) error { return p.err }
+
+// healthyDeps 所有依赖正常
+func healthyDeps() healthDeps {
+	cbManager := crawler.NewCircuitBreakerManager(crawler.CircuitBreakerConfig{
+		MaxFailures:     1,
+		Timeout:         time.Minute,
+		HalfOpenMaxReqs: 1,
+	})
+	cbManager.GetWithPriority("baidu", crawler.PriorityCritical)
+	cbManager.GetWithPriority("gold", crawler.PriorityCritical)
+	cbManager.GetWithPriority("duckduckgo", crawler.PriorityBestEffort)
+
+	return healthDeps{
+		db:        fakePinger{},
+		cache:     service.NewMemoryCache(),
+		cbManager: cbManager,
+	}
+}
+
+func openBreaker(m *crawler.CircuitBreakerManager, name string) {
+	_ = m.Get(name).Execute(func() error { return errors.New("upstream down") })
+}
+
+func TestCheckHealth_Healthy(t *testing.T) {
+	health := checkHealth(context.Background(), healthyDeps())
+
+	assert.Equal(t, "healthy", health.Status)
+	assert.Empty(t, health.Reasons)
+	assert.Regexp(t, `^total=\d+ completed=\d+ aborted=\d+ active=\d+$`, health.Services["sse_streams"])
+}
+
+func TestCheckHealth_DatabaseFailure(t *testing.T) {
+	deps := healthyDeps()
+	deps.db = fakePinger{err: errors.New("connection refused")}
+
+	health := checkHealth(context.Background(), deps)
+
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, []string{"database unhealthy"}, health.Reasons)
+	assert.Contains(t, health.Services["database"], "connection refused")
+}
+
+func TestCheckHealth_OpenBreaker(t *testing.T) {
+	deps := healthyDeps()
+	openBreaker(deps.cbManager, "gold")
+	openBreaker(deps.cbManager, "duckduckgo") // 尽力而为的数据源熔断不导致降级
+
+	health := checkHealth(context.Background(), deps)
+
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, []string{"crawler:gold open"}, health.Reasons)
+	assert.Equal(t, "open", health.Services["source:duckduckgo"])
+
+	require.Len(t, health.Breakers, 3)
+	assert.Equal(t, "gold", health.Breakers[2].Name)
+	assert.Equal(t, crawler.StateOpen, health.Breakers[2].State)
+	assert.Equal(t, 1, health.Breakers[2].Failures)
+}
+
+func TestCheckHealth_MisconfiguredEmail(t *testing.T) {
+	deps := healthyDeps()
+	deps.emailErr = service.ValidateEmailConfig(config.EmailConfig{Type: "smtp", SMTPPort: 465, SMTPHost: "smtp.example.com"})
+
+	health := checkHealth(context.Background(), deps)
+
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, []string{"email misconfigured"}, health.Reasons)
+	assert.Contains(t, health.Services["email"], "smtp_password")
+}
+
+func TestCheckHealth_MultipleReasons(t *testing.T) {
+	deps := healthyDeps()
+	deps.db = fakePinger{err: errors.New("timeout")}
+	deps.emailErr = service.ErrEmailMisconfigured
+	openBreaker(deps.cbManager, "baidu")
+	openBreaker(deps.cbManager, "gold")
+
+	health := checkHealth(context.Background(), deps)
+
+	assert.Equal(t, []string{
+		"database unhealthy",
+		"email misconfigured",
+		"crawler:baidu open",
+		"crawler:gold open",
+	}, health.Reasons)
+}
+
+// getReadiness 执行一次就绪检查
+func getReadiness(cbManager *crawler.CircuitBreakerManager, jobs *service.JobScheduler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/ready", nil)
+	readinessCheck(c, cbManager, jobs)
+	return w
+}
+
+func TestReadinessCheck_StaleCriticalJob(t *testing.T) {
+	cbManager := healthyDeps().cbManager
+	jobs := service.NewJobScheduler(zap.NewNop())
+	jobs.Add(service.Job{Name: "cleanup", Interval: time.Hour, Run: func(ctx context.Context) error { return nil }})
+	jobs.Add(service.Job{Name: "alerts", Interval: time.Millisecond, Critical: true, Run: func(ctx context.Context) error {
+		return errors.New("smtp unavailable")
+	}})
+
+	// 未启动的任务不影响就绪
+	assert.Equal(t, http.StatusOK, getReadiness(cbManager, jobs).Code)
+
+	jobs.Start()
+	defer jobs.Stop()
+
+	// 关键任务持续失败超过 3 个间隔后不再就绪
+	assert.Eventually(t, func() bool {
+		return getReadiness(cbManager, jobs).Code == http.StatusServiceUnavailable
+	}, time.Second, 5*time.Millisecond)
+	w := getReadiness(cbManager, jobs)
+	assert.Contains(t, w.Body.String(), "Critical background jobs stale")
+	assert.Contains(t, w.Body.String(), `"stale_jobs":["alerts"]`)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -19,19 +20,10 @@ import (
 	"fund-analyzer/pkg/response"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jmoiron/sqlx"
+	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
-// HealthStatus 健康状态
-type HealthStatus struct {
-	Status   string            `json:"status"`
-	Time     string            `json:"time"`
-	Uptime   string            `json:"uptime"`
-	Version  string            `json:"version"`
-	Services map[string]string `json:"services"`
-}
-
 // 全局变量用于跟踪服务状态
 var (
 	startTime      time.Time
@@ -39,6 +31,12 @@ var (
 	activeRequests atomic.Int64
 )
 
+// tokenBlacklistCleanupInterval 清理过期 Token 黑名单的间隔
+const tokenBlacklistCleanupInterval = time.Hour
+
+// notificationDigestInterval 检查并发送免打扰结束后通知摘要的间隔
+const notificationDigestInterval = time.Minute
+
 func main() {
 	startTime = time.Now()
 
@@ -57,8 +55,17 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// 校验配置，存在无法安全运行的配置时立即退出
+	warnings, err := cfg.Validate()
+	for _, w := range warnings {
+		logger.Warn("Insecure config", zap.String("detail", w))
+	}
+	if err != nil {
+		logger.Fatal("Invalid config", zap.Error(err))
+	}
+
 	// 设置 Gin 模式
-	if cfg.Server.Mode == "release" {
+	if cfg.Server.Mode == config.ModeRelease {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
@@ -67,53 +74,223 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
-	defer db.Close()
+	// 关闭钩子在收到退出信号后按注册的逆序执行
+	shutdown := service.NewShutdownRegistry(service.DefaultShutdownHookTimeout, logger)
+	shutdown.RegisterCloser("database", db.Close)
 	logger.Info("Database connected successfully")
 
+	// 邮件配置不完整时仍可启动，但验证码邮件无法发送，健康检查报告降级
+	emailErr := service.ValidateEmailConfig(cfg.Email)
+	if emailErr != nil {
+		logger.Warn("Email service misconfigured", zap.Error(emailErr))
+	}
+
 	// 初始化 Redis 缓存
 	var cacheService service.CacheService
 	var redisConnected bool
 	cacheService, err = service.NewCacheService(cfg.Redis)
 	if err != nil {
 		logger.Warn("Failed to connect to Redis, using memory cache", zap.Error(err))
-		cacheService = service.NewMemoryCache()
+		if cfg.Cache.SnapshotPath != "" {
+			// 内存缓存定期快照到文件，Redis 故障期间重启不丢失降级数据
+			persistentCache := service.NewPersistentMemoryCache(
+				cfg.Cache.SnapshotPath,
+				time.Duration(cfg.Cache.SnapshotInterval)*time.Second,
+				logger,
+			)
+			shutdown.RegisterCloser("cache snapshot", persistentCache.Close)
+			cacheService = persistentCache
+		} else {
+			cacheService = service.NewMemoryCache()
+		}
 		redisConnected = false
 	} else {
 		logger.Info("Redis connected successfully")
 		redisConnected = true
+		if closer, ok := cacheService.(io.Closer); ok {
+			shutdown.RegisterCloser("redis", closer.Close)
+		}
 	}
 
 	// 初始化 HTTP 客户端和熔断器
-	httpClient := crawler.NewHTTPClient(crawler.DefaultHTTPClientConfig())
-	cbManager := crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig())
-
-	// 创建各数据源的熔断器
-	baiduBreaker := cbManager.Get("baidu")
-	antBreaker := cbManager.Get("ant")
-	eastmoneyBreaker := cbManager.Get("eastmoney")
-	goldBreaker := cbManager.Get("gold")
-	ddgBreaker := cbManager.Get("duckduckgo")
-	webpageBreaker := cbManager.Get("webpage")
-
-	// 初始化爬虫
-	baiduCrawler := crawler.NewBaiduCrawler(httpClient, baiduBreaker)
-	antCrawler := crawler.NewAntCrawler(httpClient, antBreaker)
-	eastMoneyCrawler := crawler.NewEastMoneyCrawler(httpClient, eastmoneyBreaker)
-	goldCrawler := crawler.NewGoldCrawler(httpClient, goldBreaker)
-	ddgCrawler := crawler.NewDuckDuckGoCrawler(httpClient, ddgBreaker)
-	webpageFetcher := crawler.NewWebpageFetcher(httpClient, webpageBreaker)
+	// 上游请求日志按数据源记录耗时，用于定位拖慢分析的数据源
+	var crawlerRequestLogger *service.CrawlerRequestLogger
+	httpClientConfig := crawler.DefaultHTTPClientConfig()
+	if cfg.Crawler.RequestLog.Enabled {
+		crawlerRequestLogger = service.NewCrawlerRequestLogger(logger, cfg.Crawler.RequestLog)
+		httpClientConfig.OnRequest = crawlerRequestLogger.OnRequest
+	}
+	httpClient := crawler.NewHTTPClient(httpClientConfig)
+	// 网页抓取的 URL 来自模型工具调用，需限制重定向并拒绝内网地址（请求前快速校验，拨号时再按实际地址校验）
+	webpageClientConfig := httpClientConfig
+	webpageClientConfig.MaxRedirects = cfg.Crawler.WebpageMaxRedirects
+	webpageClientConfig.URLValidator = crawler.ValidatePublicURL
+	webpageClientConfig.DialControl = crawler.RejectInternalDial
+	webpageClient := crawler.NewHTTPClient(webpageClientConfig)
+	cbManager := crawler.NewCircuitBreakerManagerWithCleanup(
+		crawler.DefaultCircuitBreakerConfig(),
+		time.Duration(cfg.Crawler.BreakerCleanupInterval)*time.Second,
+		time.Duration(cfg.Crawler.BreakerIdleTimeout)*time.Second,
+	)
+	shutdown.RegisterFunc("circuit breakers", cbManager.Stop)
+
+	// 创建各数据源的熔断器（设置优先级的数据源不会被空闲回收）
+	baiduBreaker := cbManager.GetWithPriority("baidu", crawler.PriorityCritical)
+	antBreaker := cbManager.GetWithPriority("ant", crawler.PriorityCritical)
+	eastmoneyBreaker := cbManager.GetWithPriority("eastmoney", crawler.PriorityCritical)
+	goldBreaker := cbManager.GetWithPriority("gold", crawler.PriorityCritical)
+	// 搜索与网页抓取仅供 AI 深度分析使用，熔断不影响健康/就绪状态
+	ddgBreaker := cbManager.GetWithPriority("duckduckgo", crawler.PriorityBestEffort)
+	webpageBreaker := cbManager.GetWithPriority("webpage", crawler.PriorityBestEffort)
+
+	cbManager.OnStateChange(func(name string, priority crawler.SourcePriority, from, to crawler.CircuitState) {
+		fields := []zap.Field{
+			zap.String("breaker", name),
+			zap.String("priority", priority.String()),
+			zap.String("from", from.String()),
+			zap.String("to", to.String()),
+		}
+		if to == crawler.StateOpen && priority == crawler.PriorityCritical {
+			logger.Error("Critical source circuit breaker opened", fields...)
+			return
+		}
+		logger.Info("Circuit breaker state changed", fields...)
+	})
+
+	// 初始化爬虫，配置启用 Cookie 会话的数据源各自使用独立的 Cookie 存储
+	cookieJarSources := make(map[string]bool, len(cfg.Crawler.CookieJarSources))
+	for _, source := range cfg.Crawler.CookieJarSources {
+		cookieJarSources[source] = true
+	}
+	sourceClient := func(client *crawler.HTTPClient, source string) *crawler.HTTPClient {
+		client = client.WithSource(source)
+		if cookieJarSources[source] {
+			client = client.WithCookieJar(crawler.NewCookieJar())
+		}
+		return client
+	}
+	baiduCrawler := crawler.NewBaiduCrawler(sourceClient(httpClient, "baidu"), baiduBreaker)
+	antCrawler := crawler.NewAntCrawler(sourceClient(httpClient, "ant"), antBreaker)
+	eastMoneyCrawler := crawler.NewEastMoneyCrawler(sourceClient(httpClient, "eastmoney"), eastmoneyBreaker)
+	goldCrawler := crawler.NewGoldCrawler(sourceClient(httpClient, "gold"), goldBreaker)
+	// 搜索结果与网页内容均为不可信 HTML，解析时限制大小、深度和节点数
+	htmlLimits := crawler.HTMLParseLimits{
+		MaxBytes: cfg.Crawler.HTMLMaxBytes,
+		MaxDepth: cfg.Crawler.HTMLMaxDepth,
+		MaxNodes: cfg.Crawler.HTMLMaxNodes,
+	}
+	ddgCrawler := crawler.NewDuckDuckGoCrawlerWithLimits(sourceClient(httpClient, "duckduckgo"), ddgBreaker, htmlLimits)
+	webpageFetcher := crawler.NewWebpageFetcherWithLimits(sourceClient(webpageClient, "webpage"), webpageBreaker, htmlLimits)
+	if cfg.LLM.ToolCache.Enabled {
+		// 深度研究中同一网页与查询常被重复请求，缓存工具结果以减少上游访问
+		ddgCrawler = service.NewCachedSearchCrawler(ddgCrawler, cacheService, cfg.LLM.ToolCache)
+		webpageFetcher = service.NewCachedWebpageFetcher(webpageFetcher, cacheService, cfg.LLM.ToolCache)
+	}
 
 	// 初始化 Repository
 	userRepo := repository.NewUserRepository(db)
 	fundRepo := repository.NewUserFundRepository(db)
+	quietHoursRepo := repository.NewQuietHoursRepository(db)
+	conversationRepo := repository.NewConversationRepository(db)
+	fundAlertRepo := repository.NewFundAlertRepository(db)
+	pendingNotificationRepo := repository.NewPendingNotificationRepository(db)
+
+	// 后台定时任务，执行状态见 GET /api/v1/admin/jobs，关键任务停滞时就绪检查失败
+	jobs := service.NewJobScheduler(logger)
+	// 黑名单不清理会持续膨胀并拖慢每次 Token 校验，停滞时就绪检查失败
+	jobs.Add(service.Job{
+		Name:     "token_blacklist_cleanup",
+		Interval: tokenBlacklistCleanupInterval,
+		Critical: true,
+		Run:      userRepo.CleanExpiredBlacklist,
+	})
 
 	// 初始化 Service
-	authService := service.NewAuthService(userRepo, cfg.JWT, cfg.Email)
-	marketService := service.NewMarketService(baiduCrawler, goldCrawler, cacheService)
-	newsService := service.NewNewsService(baiduCrawler, cacheService)
-	sectorService := service.NewSectorService(eastMoneyCrawler, cacheService)
-	fundService := service.NewFundService(fundRepo, antCrawler, cacheService)
-	dataMatcher := service.NewDataMatcher()
+	codeFormat, err := service.NewCodeFormat(cfg.VerificationCode)
+	if err != nil {
+		logger.Fatal("Invalid verification code config", zap.Error(err))
+	}
+	emailValidator, err := service.NewEmailValidator(cfg.EmailValidation)
+	if err != nil {
+		logger.Fatal("Invalid email validation config", zap.Error(err))
+	}
+	passwordPolicy, err := service.NewPasswordPolicy(cfg.PasswordPolicy)
+	if err != nil {
+		logger.Fatal("Invalid password policy config", zap.Error(err))
+	}
+	jwtSigner, err := service.NewJWTSigner(cfg.JWT)
+	if err != nil {
+		logger.Fatal("Invalid JWT config", zap.Error(err))
+	}
+	authService := service.NewAuthService(userRepo, cfg.JWT, jwtSigner, cfg.Email, codeFormat, emailValidator,
+		service.NewCodeSendThrottle(cacheService, cfg.VerificationCode), passwordPolicy, logger)
+	// 初始化降级服务
+	degradationService := service.NewDegradationServiceWithConfig(cacheService, cbManager, logger, cfg.Degradation)
+	// 取消未完成的异步缓存刷新，需在缓存关闭前执行
+	shutdown.Register("async refreshes", degradationService.Shutdown)
+	// 行情与板块列表缓存未命中时，数据源缓慢则先返回旧数据并在后台刷新
+	marketService := service.NewMarketServiceWithDegradation(baiduCrawler, goldCrawler, cacheService, degradationService)
+	// 可热更新的内容配置（关键词、分析模板、快讯屏蔽词）
+	contentStore, err := service.NewContentStore(cfg.Content)
+	if err != nil {
+		logger.Fatal("Invalid content config", zap.Error(err))
+	}
+	if cfg.Content.HotReload {
+		config.WatchContent(func(content config.ContentConfig, err error) {
+			if err == nil {
+				err = contentStore.Update(content)
+			}
+			if err != nil {
+				logger.Warn("Failed to reload content config, keeping current config", zap.Error(err))
+				return
+			}
+			logger.Info("Content config reloaded")
+		})
+	}
+
+	newsService := service.NewNewsServiceWithContent(baiduCrawler, cacheService, contentStore)
+	sectorService := service.NewSectorServiceWithDegradation(eastMoneyCrawler, cacheService, degradationService)
+	fundService := service.NewFundService(service.FundServiceOptions{
+		FundRepo:      fundRepo,
+		FundCrawler:   antCrawler,
+		SectorService: sectorService,
+		Cache:         cacheService,
+		Policy:        service.NewFundCodePolicy(cfg.Funds.AllowList, cfg.Funds.DenyList),
+		FetchMetadata: cfg.Funds.FetchMetadata,
+		PadShortCodes: cfg.Funds.PadShortCodes,
+		Logger:        logger,
+	})
+	snapshotService := service.NewSnapshotService(cacheService)
+	dataMatcher := service.NewDataMatcherWithContent(contentStore)
+	exportService := service.NewExportService(userRepo, fundRepo)
+	quietHoursService := service.NewQuietHoursService(quietHoursRepo)
+	// 通知按用户免打扰时段发送，免打扰期间的通知持久化暂存，结束后合并为摘要发送
+	notificationScheduler := service.NewNotificationSchedulerWithStore(quietHoursService,
+		service.NewEmailNotifier(userRepo, service.NewEmailService(cfg.Email)), pendingNotificationRepo, logger)
+	if err := notificationScheduler.Restore(context.Background()); err != nil {
+		logger.Warn("Failed to restore deferred notifications", zap.Error(err))
+	}
+	jobs.Add(service.Job{
+		Name:     "notification_digests",
+		Interval: notificationDigestInterval,
+		Run: func(ctx context.Context) error {
+			notificationScheduler.Flush(ctx)
+			return nil
+		},
+	})
+	fundAlertService := service.NewFundAlertService(fundAlertRepo, fundRepo, fundService, notificationScheduler,
+		cfg.Funds.PadShortCodes, logger)
+	if cfg.Funds.Alerts.Enabled {
+		// 交易时段外的检查直接返回；用户依赖提醒，检查停滞时就绪检查失败
+		jobs.Add(service.Job{
+			Name:     "fund_alerts",
+			Interval: time.Duration(cfg.Funds.Alerts.CheckInterval) * time.Second,
+			Critical: true,
+			Run:      fundAlertService.CheckAlerts,
+		})
+	}
+	jobs.Start()
+	shutdown.RegisterFunc("background jobs", jobs.Stop)
 
 	// 初始化 AI 服务
 	var aiService service.AIService
@@ -122,11 +299,16 @@ func main() {
 			&cfg.LLM,
 			ddgCrawler,
 			webpageFetcher,
+			baiduCrawler,
 			dataMatcher,
 			marketService,
 			newsService,
 			sectorService,
 			fundService,
+			contentStore,
+			ddgBreaker,
+			webpageBreaker,
+			baiduBreaker,
 		)
 		if err != nil {
 			logger.Warn("Failed to initialize AI service", zap.Error(err))
@@ -137,18 +319,64 @@ func main() {
 		logger.Warn("LLM API key not configured, AI service disabled")
 	}
 
-	// 初始化降级服务
-	degradationService := service.NewDegradationService(cacheService, cbManager, logger)
-	_ = degradationService // 可用于后续增强
+	// 管理员批量预热缓存：逐个数据源经降级层刷新，熔断打开的数据源跳过
+	cacheRefreshService := service.NewCacheRefreshService(
+		degradationService,
+		cbManager,
+		service.DefaultRefreshSources(baiduCrawler, goldCrawler, eastMoneyCrawler, baiduCrawler),
+		service.DefaultRefreshPause,
+	)
+
+	// 部署自检：并发检查各子系统，AI 服务未启用时跳过大模型检查
+	selfTestService := service.NewSelfTestService(service.DefaultSelfTestChecks(service.SelfTestDeps{
+		DBProbe:       func(ctx context.Context) error { return repository.ProbeReadWrite(ctx, db) },
+		Cache:         cacheService,
+		MarketCrawler: baiduCrawler,
+		FundCrawler:   antCrawler,
+		SectorCrawler: eastMoneyCrawler,
+		GoldCrawler:   goldCrawler,
+		AI:            aiService,
+		Email:         cfg.Email,
+	}), cfg.SelfTest)
 
 	// 初始化限流器
-	defaultLimiter := middleware.NewTokenBucketLimiter(middleware.DefaultRateLimitConfig())
-	strictLimiter := middleware.NewTokenBucketLimiter(middleware.StrictRateLimitConfig())
-	defer defaultLimiter.Stop()
-	defer strictLimiter.Stop()
+	// 启用分布式限流且 Redis 可用时各实例共享限额，否则与缓存一样降级为本地内存
+	var limiterRedis *redis.Client
+	if cfg.RateLimit.Distributed {
+		if redisCache, ok := cacheService.(*service.RedisCache); ok {
+			limiterRedis = redisCache.Client()
+		} else {
+			logger.Warn("Redis unavailable, using in-memory rate limiters")
+		}
+	}
+	newLimiter := func(name string, limitConfig middleware.RateLimitConfig) middleware.RateLimiter {
+		if limiterRedis != nil {
+			limiter := middleware.NewRedisRateLimiter(limiterRedis, name, limitConfig)
+			shutdown.RegisterFunc(name+" limiter", limiter.Stop)
+			return limiter
+		}
+		limiter := middleware.NewTokenBucketLimiter(limitConfig)
+		shutdown.RegisterFunc(name+" limiter", limiter.Stop)
+		return limiter
+	}
+	userLimiter := newLimiter("user", middleware.RateLimitConfig{
+		RequestsPerSecond: cfg.RateLimit.User.RequestsPerSecond,
+		Burst:             cfg.RateLimit.User.Burst,
+	})
+	ipLimiter := newLimiter("ip", middleware.RateLimitConfig{
+		RequestsPerSecond: cfg.RateLimit.IP.RequestsPerSecond,
+		Burst:             cfg.RateLimit.IP.Burst,
+	})
+	strictLimiter := newLimiter("strict", middleware.StrictRateLimitConfig())
+	exportLimiter := newLimiter("export", middleware.ExportRateLimitConfig())
 
 	// 初始化 SSE 连接限制器
-	sseConnectionLimiter := middleware.NewSSEConnectionLimiter(100) // 最大 100 个 SSE 连接
+	sseConnectionLimiter := middleware.NewSSEConnectionLimiterWithUserLimit(
+		cfg.RateLimit.MaxSSEConnections,
+		cfg.RateLimit.MaxSSEConnectionsPerUser,
+	)
+	// 单用户 AI 分析并发限制器（与全局 SSE 限制独立）
+	analysisLimiter := middleware.NewUserConcurrencyLimiter(cfg.RateLimit.MaxConcurrentAnalysesPerUser)
 
 	// 创建 Gin 引擎
 	r := gin.New()
@@ -159,10 +387,34 @@ func main() {
 	r.Use(middleware.CORS())
 	r.Use(middleware.RequestID())
 	r.Use(requestTracker()) // 请求跟踪中间件
+	r.Use(middleware.RateLimitExempt(middleware.NewRateLimitExemptions(
+		cfg.RateLimit.Exempt.Roles,
+		cfg.RateLimit.Exempt.APIKeys,
+		cfg.RateLimit.Exempt.Paths,
+	)))
+	if cfg.Compression.Enabled {
+		r.Use(middleware.Compress(middleware.CompressionConfig{
+			MinSize:     cfg.Compression.MinSize,
+			GzipLevel:   cfg.Compression.GzipLevel,
+			BrotliLevel: cfg.Compression.BrotliLevel,
+			Algorithms:  cfg.Compression.Algorithms,
+		}))
+	}
 
 	// 健康检查（增强版）
 	r.GET("/health", func(c *gin.Context) {
-		healthCheck(c, db, cacheService, redisConnected)
+		healthCheck(c, healthDeps{
+			db:             db,
+			cache:          cacheService,
+			redisConnected: redisConnected,
+			cbManager:      cbManager,
+			emailErr:       emailErr,
+		})
+	})
+
+	// 就绪检查：正在关闭或核心数据源熔断时返回 503
+	r.GET("/ready", func(c *gin.Context) {
+		readinessCheck(c, cbManager, jobs)
 	})
 
 	// API v1 路由组
@@ -172,6 +424,7 @@ func main() {
 		authCtrl := controller.NewAuthController(authService, logger)
 		auth := v1.Group("/auth")
 		auth.Use(middleware.RateLimitByIP(strictLimiter)) // 认证接口使用严格限流
+		auth.Use(middleware.BodyLimit(cfg.Server.BodyLimit.Auth))
 		{
 			auth.POST("/register", authCtrl.Register)
 			auth.POST("/verify-email", authCtrl.VerifyEmail)
@@ -183,14 +436,24 @@ func main() {
 		// 需要认证的路由
 		authorized := v1.Group("")
 		authorized.Use(middleware.Auth(authService))
-		authorized.Use(middleware.RateLimitByUser(defaultLimiter)) // 使用默认限流
+		authorized.Use(middleware.RateLimitByUserAndIP(userLimiter, ipLimiter)) // 用户与 IP 限额同时生效
+		if cfg.Coalescing.Enabled {
+			// 开盘时段大量用户同时请求行情，缓存未命中时合并相同请求，只请求一次上游
+			authorized.Use(middleware.NewRequestCoalescer(cfg.Coalescing.Paths).Handler())
+		}
 		{
 			// 认证相关（需要登录）
 			authAuthorized := authorized.Group("/auth")
+			authAuthorized.Use(middleware.BodyLimit(cfg.Server.BodyLimit.Auth))
 			{
 				authAuthorized.POST("/logout", authCtrl.Logout)
 				authAuthorized.POST("/refresh", authCtrl.RefreshToken)
 				authAuthorized.GET("/me", authCtrl.GetCurrentUser)
+				authAuthorized.DELETE("/account", middleware.RateLimitByUser(strictLimiter), authCtrl.DeleteAccount)
+
+				// 数据导出（严格限流）
+				exportCtrl := controller.NewExportController(exportService, logger)
+				authAuthorized.GET("/export", middleware.RateLimitByUser(exportLimiter), exportCtrl.Export)
 			}
 
 			// 市场数据路由
@@ -217,38 +480,97 @@ func main() {
 			{
 				sectors.GET("", sectorCtrl.GetSectors)
 				sectors.GET("/categories", sectorCtrl.GetCategories)
+				sectors.GET("/heatmap", sectorCtrl.GetHeatmap)
 				sectors.GET("/:id/funds", sectorCtrl.GetSectorFunds)
 			}
 
 			// 基金路由
 			fundCtrl := controller.NewFundController(fundService, logger)
 			funds := authorized.Group("/funds")
+			funds.Use(middleware.BodyLimit(cfg.Server.BodyLimit.Default))
 			{
 				funds.GET("", fundCtrl.GetFunds)
 				funds.POST("", fundCtrl.AddFund)
 				funds.DELETE("/:code", fundCtrl.DeleteFund)
 				funds.PUT("/:code/hold", fundCtrl.UpdateHoldStatus)
 				funds.PUT("/:code/sectors", fundCtrl.UpdateSectors)
+				funds.POST("/valuations", fundCtrl.GetValuationsBatch)
 				funds.GET("/:code/valuation", fundCtrl.GetValuation)
+				funds.GET("/:code/history", fundCtrl.GetHistory)
+				funds.GET("/:code/related", fundCtrl.GetRelated)
+
+				fundAlertCtrl := controller.NewFundAlertController(fundAlertService, logger)
+				funds.GET("/:code/alerts", fundAlertCtrl.ListAlerts)
+				funds.POST("/:code/alerts", fundAlertCtrl.CreateAlert)
+				funds.PUT("/:code/alerts/:id", fundAlertCtrl.UpdateAlert)
+				funds.DELETE("/:code/alerts/:id", fundAlertCtrl.DeleteAlert)
+			}
+
+			// 通知设置路由
+			notificationCtrl := controller.NewNotificationController(quietHoursService, logger)
+			notifications := authorized.Group("/notifications")
+			notifications.Use(middleware.BodyLimit(cfg.Server.BodyLimit.Default))
+			{
+				notifications.GET("/quiet-hours", notificationCtrl.GetQuietHours)
+				notifications.PUT("/quiet-hours", notificationCtrl.UpdateQuietHours)
+			}
+
+			// 管理员路由
+			breakerCtrl := controller.NewBreakerController(cbManager, logger)
+			adminCtrl := controller.NewAdminController(cacheRefreshService, sseConnectionLimiter, crawlerRequestLogger, jobs, selfTestService, logger)
+			admin := authorized.Group("/admin")
+			admin.Use(middleware.RequireAdmin())
+			{
+				admin.POST("/refresh", middleware.RateLimitByUser(strictLimiter), adminCtrl.Refresh)
+				admin.GET("/sse-connections", adminCtrl.GetSSEConnections)
+				admin.GET("/upstream-latency", adminCtrl.GetUpstreamLatency)
+				admin.GET("/jobs", adminCtrl.GetJobs)
+				admin.GET("/selftest", adminCtrl.SelfTest)
+				admin.GET("/breakers", breakerCtrl.GetBreakers)
+				admin.POST("/breakers/:name/reset", breakerCtrl.ResetBreaker)
+				admin.POST("/breaker/:name", breakerCtrl.SetOverride)
+				admin.POST("/breakers/:name/override", breakerCtrl.SetOverride) // 与 /breakers 路径风格一致的别名
 			}
 
 			// AI 路由（如果 AI 服务可用）
 			if aiService != nil {
+				var analysisReuse service.AnalysisReuseService
+				if cfg.LLM.AnalysisReuse.Enabled {
+					analysisReuse = service.NewAnalysisReuseService(cacheService, cfg.LLM.AnalysisReuse)
+				}
+				analysisModules, err := service.NewAnalysisModules(cfg.LLM.Analysis.Modules)
+				if err != nil {
+					logger.Fatal("Invalid analysis modules config", zap.Error(err))
+				}
 				aiCtrl := controller.NewAIController(
 					aiService,
 					marketService,
 					newsService,
 					sectorService,
 					fundService,
+					snapshotService,
+					analysisReuse,
+					service.NewConversationService(conversationRepo, logger),
+					service.NewSectorSelector(cfg.LLM.Analysis.Sectors),
+					analysisModules,
 					logger,
 				)
 				ai := authorized.Group("/ai")
 				ai.Use(middleware.RateLimitByUser(strictLimiter)) // AI 接口使用严格限流
+				ai.Use(middleware.LimitConcurrentPerUser(analysisLimiter))
+				ai.Use(middleware.BodyLimit(cfg.Server.BodyLimit.AI)) // 对话包含历史消息，限制较宽
 				{
 					ai.POST("/chat", wrapSSEWithLimit(sseConnectionLimiter, aiCtrl.Chat))
 					ai.POST("/analyze/standard", wrapSSEWithLimit(sseConnectionLimiter, aiCtrl.AnalyzeStandard))
 					ai.POST("/analyze/fast", wrapSSEWithLimit(sseConnectionLimiter, aiCtrl.AnalyzeFast))
 					ai.POST("/analyze/deep", wrapSSEWithLimit(sseConnectionLimiter, aiCtrl.AnalyzeDeep))
+					ai.POST("/analyze/compare", wrapSSEWithLimit(sseConnectionLimiter, aiCtrl.AnalyzeCompare))
+				}
+
+				// 只读的会话历史不占用分析并发与严格限流额度，分析进行中也可查看
+				aiHistory := authorized.Group("/ai")
+				{
+					aiHistory.GET("/conversations/:id/messages", aiCtrl.GetConversationMessages)
 				}
 			}
 		}
@@ -271,75 +593,38 @@ func main() {
 	}()
 
 	// 优雅关闭
-	gracefulShutdown(srv, logger)
+	gracefulShutdown(srv, shutdown, logger)
 }
 
-// healthCheck 增强版健康检查
-// Validates: Requirements 22.4
-func healthCheck(c *gin.Context, db *sqlx.DB, cache service.CacheService, redisConnected bool) {
-	services := make(map[string]string)
-	overallStatus := "healthy"
-
-	// 检查数据库连接
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
-
-	if err := db.PingContext(ctx); err != nil {
-		services["database"] = "unhealthy: " + err.Error()
-		overallStatus = "degraded"
-	} else {
-		services["database"] = "healthy"
-	}
-
-	// 检查 Redis 连接
-	if redisConnected {
-		// 尝试执行一个简单的缓存操作
-		testKey := "health:check"
-		testValue := []byte("ok")
-		if err := cache.Set(ctx, testKey, testValue, 10*time.Second); err != nil {
-			services["redis"] = "unhealthy: " + err.Error()
-			overallStatus = "degraded"
-		} else {
-			services["redis"] = "healthy"
-		}
-	} else {
-		services["redis"] = "not_configured (using memory cache)"
-	}
-
-	// 检查是否正在关闭
+// readinessCheck 就绪检查
+func readinessCheck(c *gin.Context, cbManager *crawler.CircuitBreakerManager, jobs *service.JobScheduler) {
 	if isShuttingDown.Load() {
-		overallStatus = "shutting_down"
+		c.JSON(http.StatusServiceUnavailable, response.Response{
+			Code:    503,
+			Message: "Service is shutting down",
+		})
+		return
 	}
 
-	// 计算运行时间
-	uptime := time.Since(startTime)
-	uptimeStr := formatDuration(uptime)
-
-	// 构建响应
-	health := HealthStatus{
-		Status:   overallStatus,
-		Time:     time.Now().Format(time.RFC3339),
-		Uptime:   uptimeStr,
-		Version:  "1.0.0",
-		Services: services,
+	if openBreakers := cbManager.OpenCriticalBreakers(); len(openBreakers) > 0 {
+		c.JSON(http.StatusServiceUnavailable, response.Response{
+			Code:    503,
+			Message: "Critical data sources unavailable",
+			Data:    gin.H{"open_breakers": openBreakers},
+		})
+		return
 	}
 
-	// 根据状态返回不同的 HTTP 状态码
-	if overallStatus == "healthy" {
-		response.Success(c, health)
-	} else if overallStatus == "shutting_down" {
+	if staleJobs := jobs.StaleCriticalJobs(); len(staleJobs) > 0 {
 		c.JSON(http.StatusServiceUnavailable, response.Response{
 			Code:    503,
-			Message: "Service is shutting down",
-			Data:    health,
-		})
-	} else {
-		c.JSON(http.StatusOK, response.Response{
-			Code:    0,
-			Message: "Service is degraded but operational",
-			Data:    health,
+			Message: "Critical background jobs stale",
+			Data:    gin.H{"stale_jobs": staleJobs},
 		})
+		return
 	}
+
+	response.Success(c, gin.H{"status": "ready"})
 }
 
 // formatDuration 格式化持续时间
@@ -385,8 +670,9 @@ func requestTracker() gin.HandlerFunc {
 // wrapSSEWithLimit 包装 SSE 处理器，添加连接数限制
 func wrapSSEWithLimit(limiter *middleware.SSEConnectionLimiter, handler gin.HandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 尝试获取连接许可
-		if !limiter.Acquire() {
+		// 尝试获取连接许可，已登录按用户计数，未登录按 IP 计数
+		key := middleware.SSEConnectionKey(c)
+		if !limiter.AcquireFor(key) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"code":    429,
 				"message": "Too many SSE connections",
@@ -395,7 +681,7 @@ func wrapSSEWithLimit(limiter *middleware.SSEConnectionLimiter, handler gin.Hand
 		}
 
 		// 确保释放连接许可
-		defer limiter.Release()
+		defer limiter.ReleaseFor(key)
 
 		// 执行原始处理器
 		handler(c)
@@ -404,7 +690,8 @@ func wrapSSEWithLimit(limiter *middleware.SSEConnectionLimiter, handler gin.Hand
 
 // gracefulShutdown 优雅关闭
 // Validates: Requirements 22.1
-func gracefulShutdown(srv *http.Server, logger *zap.Logger) {
+// HTTP 服务器关闭后执行注册的关闭钩子（限流器、熔断器、缓存、数据库等）
+func gracefulShutdown(srv *http.Server, shutdown *service.ShutdownRegistry, logger *zap.Logger) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -438,5 +725,10 @@ func gracefulShutdown(srv *http.Server, logger *zap.Logger) {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// 按注册的逆序关闭各组件
+	if err := shutdown.Shutdown(ctx); err != nil {
+		logger.Warn("Some components did not shut down cleanly", zap.Error(err))
+	}
+
 	logger.Info("Server exited gracefully")
 }
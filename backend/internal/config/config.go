@@ -12,10 +12,24 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
+	Cache    CacheConfig    `mapstructure:"cache"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Email    EmailConfig    `mapstructure:"email"`
+
+	VerificationCode VerificationCodeConfig `mapstructure:"verification_code"`
+	EmailValidation  EmailValidationConfig  `mapstructure:"email_validation"`
+	PasswordPolicy   PasswordPolicyConfig   `mapstructure:"password_policy"`
 	LLM      LLMConfig      `mapstructure:"llm"`
 	Log      LogConfig      `mapstructure:"log"`
+
+	Degradation DegradationConfig `mapstructure:"degradation"`
+	Compression CompressionConfig `mapstructure:"compression"`
+	Coalescing  CoalescingConfig  `mapstructure:"coalescing"`
+	RateLimit   RateLimitConfig   `mapstructure:"rate_limit"`
+	Crawler     CrawlerConfig     `mapstructure:"crawler"`
+	Funds       FundsConfig       `mapstructure:"funds"`
+	Content     ContentConfig     `mapstructure:"content"`
+	SelfTest    SelfTestConfig    `mapstructure:"self_test"`
 }
 
 // ServerConfig 服务器配置
@@ -24,6 +38,16 @@ type ServerConfig struct {
 	Mode         string `mapstructure:"mode"` // debug, release
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
+
+	// BodyLimit 请求体大小限制
+	BodyLimit BodyLimitConfig `mapstructure:"body_limit"`
+}
+
+// BodyLimitConfig 按路由组的请求体大小限制（字节）
+type BodyLimitConfig struct {
+	Default int64 `mapstructure:"default"` // 未单独配置的路由组
+	Auth    int64 `mapstructure:"auth"`    // 认证接口
+	AI      int64 `mapstructure:"ai"`      // AI 对话与分析（包含对话历史）
 }
 
 // DatabaseConfig 数据库配置
@@ -59,9 +83,23 @@ func (c RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// CacheConfig 内存缓存配置（Redis 不可用时生效）
+type CacheConfig struct {
+	// SnapshotPath 内存缓存快照文件路径，为空表示不持久化
+	SnapshotPath string `mapstructure:"snapshot_path"`
+	// SnapshotInterval 写入快照的间隔（秒）
+	SnapshotInterval int `mapstructure:"snapshot_interval"`
+}
+
 // JWTConfig JWT 配置
 type JWTConfig struct {
-	Secret           string `mapstructure:"secret"`
+	// Algorithm 签名算法：HS256（共享密钥，默认）或 RS256（私钥签名、公钥校验）
+	Algorithm string `mapstructure:"algorithm"`
+	Secret    string `mapstructure:"secret"`
+	// PrivateKeyPath RS256 私钥 PEM 文件路径
+	PrivateKeyPath string `mapstructure:"private_key_path"`
+	// PublicKeyPath RS256 公钥 PEM 文件路径，为空时由私钥推导
+	PublicKeyPath    string `mapstructure:"public_key_path"`
 	AccessExpireMin  int    `mapstructure:"access_expire_min"`
 	RefreshExpireDay int    `mapstructure:"refresh_expire_day"`
 	Issuer           string `mapstructure:"issuer"`
@@ -87,12 +125,281 @@ type EmailConfig struct {
 	Type string `mapstructure:"type"`
 }
 
+// VerificationCodeConfig 邮箱验证码配置
+type VerificationCodeConfig struct {
+	Length   int    `mapstructure:"length"`   // 验证码长度（4-10）
+	Alphabet string `mapstructure:"alphabet"` // 字符集: numeric 或 alphanumeric
+
+	// 同一邮箱与验证码类型的发送限制，负数表示不限制
+	ResendCooldown int `mapstructure:"resend_cooldown"` // 两次发送的最小间隔（秒）
+	DailyLimit     int `mapstructure:"daily_limit"`     // 24 小时内最多发送次数
+}
+
+// EmailValidationConfig 注册邮箱格式校验配置
+type EmailValidationConfig struct {
+	Strictness      string `mapstructure:"strictness"`        // basic: 简单正则; standard: RFC 5322 与国际化域名; strict: standard 且域名需有 MX 记录
+	MXLookupTimeout int    `mapstructure:"mx_lookup_timeout"` // MX 查询超时（秒），仅 strict 模式
+	MXCacheTTL      int    `mapstructure:"mx_cache_ttl"`      // MX 查询结果缓存时间（秒），仅 strict 模式
+}
+
+// PasswordPolicyConfig 注册与重置密码时的密码强度策略
+type PasswordPolicyConfig struct {
+	MinLength     int      `mapstructure:"min_length"`     // 最少字符数（0-72）
+	RequireLetter bool     `mapstructure:"require_letter"` // 至少一个字母（不区分大小写）
+	RequireUpper  bool     `mapstructure:"require_upper"`  // 至少一个大写字母
+	RequireLower  bool     `mapstructure:"require_lower"`  // 至少一个小写字母
+	RequireDigit  bool     `mapstructure:"require_digit"`  // 至少一个数字
+	RequireSymbol bool     `mapstructure:"require_symbol"` // 至少一个符号
+	Blocklist     []string `mapstructure:"blocklist"`      // 禁止使用的常见密码，忽略大小写
+}
+
 // LLMConfig LLM API 配置
 type LLMConfig struct {
 	BaseURL string `mapstructure:"base_url"`
 	APIKey  string `mapstructure:"api_key"`
 	Model   string `mapstructure:"model"`
 	Timeout int    `mapstructure:"timeout"`
+	// Provider 服务商（openai、deepseek、moonshot、vllm），决定请求字段与流式响应的解析方式
+	Provider string `mapstructure:"provider"`
+
+	// ContextTokenBudget 深度分析 ReAct 循环中消息历史的 token 预算
+	ContextTokenBudget int `mapstructure:"context_token_budget"`
+	// MaxVerbatimToolResults 深度分析中保留完整内容的最近工具结果数，更早的结果会被压缩（<= 0 表示不限制）
+	MaxVerbatimToolResults int `mapstructure:"max_verbatim_tool_results"`
+	// MaxPromptFunds 分析与对话提示词中最多列出的自选基金数，按日涨跌幅绝对值选取（<= 0 表示不限制）
+	MaxPromptFunds int `mapstructure:"max_prompt_funds"`
+	// DeepFallbackToStandard 研究工具全部熔断时，深度研究自动降级为标准分析
+	DeepFallbackToStandard bool `mapstructure:"deep_fallback_to_standard"`
+	// Disclaimer 分析与对话结尾追加的风险提示
+	Disclaimer DisclaimerConfig `mapstructure:"disclaimer"`
+	// ToolCache 搜索与网页抓取工具结果缓存
+	ToolCache ToolCacheConfig `mapstructure:"tool_cache"`
+	// Retry 上游返回 429 或 5xx 时的重试策略
+	Retry LLMRetryConfig `mapstructure:"retry"`
+	// AnalysisReuse 市场数据无明显变化时复用上次深度研究报告
+	AnalysisReuse AnalysisReuseConfig `mapstructure:"analysis_reuse"`
+	// Analysis 各分析模式的温度与最大输出 token
+	Analysis AnalysisConfig `mapstructure:"analysis"`
+}
+
+// AnalysisConfig 各分析模式的生成参数，时段对比分析使用标准分析的参数
+type AnalysisConfig struct {
+	Standard AnalysisModeConfig `mapstructure:"standard"`
+	Fast     AnalysisModeConfig `mapstructure:"fast"`
+	Deep     AnalysisModeConfig `mapstructure:"deep"`
+	// FastCard 快速分析输出结构化卡片（情绪、关注板块、建议、风险），供前端组件渲染
+	FastCard bool `mapstructure:"fast_card"`
+	// Sectors 提供给模型的板块数量与分类分散
+	Sectors SectorSelectionConfig `mapstructure:"sectors"`
+	// Modules 标准与深度分析额外获取的数据模块
+	Modules AnalysisModulesConfig `mapstructure:"modules"`
+}
+
+// AnalysisModulesConfig 分析附加数据模块配置，开启后每次分析需额外请求上游，增加数据获取耗时
+type AnalysisModulesConfig struct {
+	// VolumeTrend 提供沪深京成交量趋势，用于判断量能
+	VolumeTrend bool `mapstructure:"volume_trend"`
+	// VolumeDays 提供的成交量天数
+	VolumeDays int `mapstructure:"volume_days"`
+	// MinuteData 提供上证分时数据，用于判断日内走势
+	MinuteData bool `mapstructure:"minute_data"`
+	// Minutes 提供的最近分钟数
+	Minutes int `mapstructure:"minutes"`
+	// MinuteGranularity 分时数据的聚合粒度（1m、5m、15m、30m、60m、1d）
+	MinuteGranularity string `mapstructure:"minute_granularity"`
+}
+
+// SectorSelectionConfig 分析使用的板块选取配置
+type SectorSelectionConfig struct {
+	// Count 按涨跌幅选取的板块数
+	Count int `mapstructure:"count"`
+	// MaxPerCategory 每个板块大类最多选取的数量，0 表示不限制；各大类不足以凑满 Count 时按排名补足
+	MaxPerCategory int `mapstructure:"max_per_category"`
+}
+
+// AnalysisModeConfig 单个分析模式的生成参数，0 表示使用服务商默认值
+type AnalysisModeConfig struct {
+	Temperature float64 `mapstructure:"temperature"`
+	MaxTokens   int     `mapstructure:"max_tokens"`
+}
+
+// AnalysisReuseConfig 深度研究复用配置
+type AnalysisReuseConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Window 上次报告的有效时间（秒）
+	Window int `mapstructure:"window"`
+	// ChangeThresholdPct 指数、贵金属与基金估值的相对变化（%）均不超过该值时视为无明显变化，0 表示仅数据完全相同时复用
+	ChangeThresholdPct float64 `mapstructure:"change_threshold_pct"`
+}
+
+// LLMRetryConfig LLM 请求重试配置
+type LLMRetryConfig struct {
+	// MaxRetries 首次请求失败后的最大重试次数（0 表示不重试）
+	MaxRetries int `mapstructure:"max_retries"`
+	// BaseDelayMs 首次重试前的等待时间（毫秒），之后每次翻倍
+	BaseDelayMs int `mapstructure:"base_delay_ms"`
+	// MaxDelayMs 单次等待的上限（毫秒），同样限制 Retry-After
+	MaxDelayMs int `mapstructure:"max_delay_ms"`
+}
+
+// ToolCacheConfig AI 工具结果缓存配置
+type ToolCacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SearchTTL 搜索结果缓存时间（秒）
+	SearchTTL int `mapstructure:"search_ttl"`
+	// WebpageTTL 网页正文缓存时间（秒）
+	WebpageTTL int `mapstructure:"webpage_ttl"`
+	// MaxEntryBytes 单条缓存的最大字节数，超过时不缓存
+	MaxEntryBytes int `mapstructure:"max_entry_bytes"`
+}
+
+// DisclaimerConfig 风险提示配置
+type DisclaimerConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Texts 按回复语言（zh、en）覆盖内置风险提示
+	Texts map[string]string `mapstructure:"texts"`
+}
+
+// DegradationConfig 降级配置
+type DegradationConfig struct {
+	// FastPathTimeoutMs AsyncRefresh 快速获取的默认超时（毫秒）
+	FastPathTimeoutMs int `mapstructure:"fast_path_timeout_ms"`
+	// FastPathTimeoutsMs 按缓存键前缀覆盖快速获取超时（毫秒），如分时与历史数据延迟差异较大
+	FastPathTimeoutsMs map[string]int `mapstructure:"fast_path_timeouts_ms"`
+	// AsyncRefreshTimeout 后台异步刷新超时（秒）
+	AsyncRefreshTimeout int `mapstructure:"async_refresh_timeout"`
+	// MaxStaleness 数据源失败时可返回的缓存数据最大年龄（秒），更旧的缓存视为不可用，<= 0 表示不限制
+	MaxStaleness int `mapstructure:"max_staleness"`
+}
+
+// ContentConfig 可热更新的内容配置（关键词、分析模板、快讯屏蔽词）
+type ContentConfig struct {
+	// HotReload 配置文件变更时自动重新加载本节配置
+	HotReload bool `mapstructure:"hot_reload"`
+	// MatcherKeywords 按数据模块覆盖数据匹配关键词，未配置的模块使用内置关键词
+	MatcherKeywords map[string][]string `mapstructure:"matcher_keywords"`
+	// Templates 覆盖分析系统提示词，为空时使用内置模板
+	Templates AnalysisTemplates `mapstructure:"templates"`
+	// NewsDenyList 标题或内容包含任一屏蔽词的快讯不返回
+	NewsDenyList []string `mapstructure:"news_deny_list"`
+}
+
+// AnalysisTemplates 分析系统提示词模板
+type AnalysisTemplates struct {
+	Standard string `mapstructure:"standard"`
+	Fast     string `mapstructure:"fast"`
+	Deep     string `mapstructure:"deep"`
+	Compare  string `mapstructure:"compare"`
+}
+
+// FundsConfig 自选基金配置
+type FundsConfig struct {
+	// AllowList 允许添加的基金代码，为空表示不限制
+	AllowList []string `mapstructure:"allow_list"`
+	// DenyList 禁止添加的基金代码，优先于 AllowList
+	DenyList []string `mapstructure:"deny_list"`
+	// FetchMetadata 自选列表附带基金经理、规模与成立日期（每只基金额外请求一次上游，结果按基金信息 TTL 缓存）
+	FetchMetadata bool `mapstructure:"fetch_metadata"`
+	// PadShortCodes 为丢失前导零的基金代码补零（如 "1" 补为 "000001"），关闭时不足 6 位的代码视为无效
+	PadShortCodes bool `mapstructure:"pad_short_codes"`
+	// Alerts 基金估值提醒
+	Alerts FundAlertConfig `mapstructure:"alerts"`
+}
+
+// FundAlertConfig 基金估值提醒配置
+type FundAlertConfig struct {
+	// Enabled 是否在交易时段定期检查提醒并发送邮件
+	Enabled bool `mapstructure:"enabled"`
+	// CheckInterval 检查间隔（秒）
+	CheckInterval int `mapstructure:"check_interval"`
+}
+
+// SelfTestConfig 部署自检配置
+type SelfTestConfig struct {
+	// TimeoutMs 单个子系统的自检超时（毫秒）
+	TimeoutMs int `mapstructure:"timeout_ms"`
+	// Skip 跳过的自检项，如 "llm"、"email"、"crawler:gold"
+	Skip []string `mapstructure:"skip"`
+}
+
+// CrawlerConfig 爬虫配置
+type CrawlerConfig struct {
+	// WebpageMaxRedirects 网页抓取最多跟随的重定向次数
+	WebpageMaxRedirects int `mapstructure:"webpage_max_redirects"`
+	// HTMLMaxBytes 解析 HTML 的最大字节数，超出部分被截断
+	HTMLMaxBytes int `mapstructure:"html_max_bytes"`
+	// HTMLMaxDepth HTML 最大嵌套深度，更深的内容被丢弃
+	HTMLMaxDepth int `mapstructure:"html_max_depth"`
+	// HTMLMaxNodes 解析 HTML 的最大节点数，之后的内容被丢弃
+	HTMLMaxNodes int `mapstructure:"html_max_nodes"`
+	// BreakerCleanupInterval 回收空闲熔断器的间隔（秒），<= 0 不回收
+	BreakerCleanupInterval int `mapstructure:"breaker_cleanup_interval"`
+	// BreakerIdleTimeout 按需创建的熔断器关闭且空闲超过该时长（秒）后被回收
+	BreakerIdleTimeout int `mapstructure:"breaker_idle_timeout"`
+	// RequestLog 上游请求日志与耗时统计，用于定位拖慢分析的数据源
+	RequestLog CrawlerRequestLogConfig `mapstructure:"request_log"`
+	// CookieJarSources 启用 Cookie 会话的数据源（如 duckduckgo），各数据源的 Cookie 相互独立
+	CookieJarSources []string `mapstructure:"cookie_jar_sources"`
+}
+
+// CrawlerRequestLogConfig 上游请求日志配置
+type CrawlerRequestLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SampleRate 正常请求写日志的采样比例（0-1），失败与慢请求总是记录，耗时统计不受采样影响
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// SlowThresholdMs 超过该耗时（毫秒）的请求总是记录，<= 0 表示不区分慢请求
+	SlowThresholdMs int `mapstructure:"slow_threshold_ms"`
+	// BodyPreviewBytes 日志附带的响应体前缀字节数，0 表示不记录响应体
+	BodyPreviewBytes int `mapstructure:"body_preview_bytes"`
+}
+
+// CompressionConfig 响应压缩配置
+type CompressionConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	MinSize     int      `mapstructure:"min_size"`     // 小于该字节数不压缩
+	GzipLevel   int      `mapstructure:"gzip_level"`   // 1-9
+	BrotliLevel int      `mapstructure:"brotli_level"` // 0-11
+	Algorithms  []string `mapstructure:"algorithms"`   // 按偏好排序：br, gzip
+}
+
+// CoalescingConfig 相同 GET 请求合并配置
+type CoalescingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Paths 允许合并的路由路径（如 /api/v1/market/indices），只能包含与用户无关的接口
+	Paths []string `mapstructure:"paths"`
+}
+
+// RateLimitConfig 限流配置（用户与 IP 限额分别配置，同时生效）
+type RateLimitConfig struct {
+	User RateLimitRule `mapstructure:"user"`
+	IP   RateLimitRule `mapstructure:"ip"`
+
+	// MaxConcurrentAnalysesPerUser 单个用户同时进行的 AI 分析数上限
+	MaxConcurrentAnalysesPerUser int `mapstructure:"max_concurrent_analyses_per_user"`
+
+	// MaxSSEConnections 全局 SSE 连接数上限
+	MaxSSEConnections int `mapstructure:"max_sse_connections"`
+	// MaxSSEConnectionsPerUser 单个用户的 SSE 连接数上限，<= 0 不限制
+	MaxSSEConnectionsPerUser int `mapstructure:"max_sse_connections_per_user"`
+
+	// Exempt 免于限流的请求
+	Exempt RateLimitExemptConfig `mapstructure:"exempt"`
+
+	// Distributed 限流状态保存在 Redis 中，多实例共享限额；Redis 不可用时使用本地内存限流
+	Distributed bool `mapstructure:"distributed"`
+}
+
+// RateLimitExemptConfig 限流豁免配置
+type RateLimitExemptConfig struct {
+	Roles   []string `mapstructure:"roles"`    // 免于限流的用户角色（取自已校验的 Token）
+	APIKeys []string `mapstructure:"api_keys"` // 受信任的 API Key（通过 X-API-Key 请求头传递）
+	Paths   []string `mapstructure:"paths"`    // 免于限流的请求路径
+}
+
+// RateLimitRule 单项限流规则
+type RateLimitRule struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	Burst             int     `mapstructure:"burst"`
 }
 
 // LogConfig 日志配置
@@ -137,9 +444,12 @@ func Load() (*Config, error) {
 func setDefaults() {
 	// Server
 	viper.SetDefault("server.port", 8080)
-	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.mode", ModeDebug)
 	viper.SetDefault("server.read_timeout", 30)
 	viper.SetDefault("server.write_timeout", 30)
+	viper.SetDefault("server.body_limit.default", 256<<10)
+	viper.SetDefault("server.body_limit.auth", 16<<10)
+	viper.SetDefault("server.body_limit.ai", 1<<20)
 
 	// Database
 	viper.SetDefault("database.host", "localhost")
@@ -157,8 +467,13 @@ func setDefaults() {
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
 
+	// Cache
+	viper.SetDefault("cache.snapshot_path", "")
+	viper.SetDefault("cache.snapshot_interval", 60)
+
 	// JWT
-	viper.SetDefault("jwt.secret", "your-secret-key-change-in-production")
+	viper.SetDefault("jwt.algorithm", "HS256")
+	viper.SetDefault("jwt.secret", DefaultJWTSecret)
 	viper.SetDefault("jwt.access_expire_min", 60*24)    // 1 day
 	viper.SetDefault("jwt.refresh_expire_day", 7)       // 7 days
 	viper.SetDefault("jwt.issuer", "fund-analyzer")
@@ -173,6 +488,112 @@ func setDefaults() {
 	viper.SetDefault("email.smtp_use_ssl", true)
 	viper.SetDefault("email.from_alias", "基金分析助手")
 
+	// Verification code
+	viper.SetDefault("verification_code.length", 6)
+	viper.SetDefault("verification_code.alphabet", "numeric")
+	viper.SetDefault("verification_code.resend_cooldown", 60)
+	viper.SetDefault("verification_code.daily_limit", 10)
+
+	// Email validation
+	viper.SetDefault("email_validation.strictness", "basic")
+	viper.SetDefault("email_validation.mx_lookup_timeout", 3)
+	viper.SetDefault("email_validation.mx_cache_ttl", 3600)
+
+	// Password policy
+	viper.SetDefault("password_policy.min_length", 8)
+	viper.SetDefault("password_policy.require_letter", true)
+	viper.SetDefault("password_policy.require_upper", false)
+	viper.SetDefault("password_policy.require_lower", false)
+	viper.SetDefault("password_policy.require_digit", true)
+	viper.SetDefault("password_policy.require_symbol", false)
+	viper.SetDefault("password_policy.blocklist", []string{})
+
 	// LLM
 	viper.SetDefault("llm.timeout", 120)
+	viper.SetDefault("llm.provider", "openai")
+	viper.SetDefault("llm.context_token_budget", 24000)
+	viper.SetDefault("llm.max_verbatim_tool_results", 3)
+	viper.SetDefault("llm.max_prompt_funds", 20)
+	viper.SetDefault("llm.deep_fallback_to_standard", true)
+	viper.SetDefault("llm.disclaimer.enabled", true)
+	viper.SetDefault("llm.tool_cache.enabled", true)
+	viper.SetDefault("llm.tool_cache.search_ttl", 300)
+	viper.SetDefault("llm.tool_cache.webpage_ttl", 600)
+	viper.SetDefault("llm.tool_cache.max_entry_bytes", 256*1024)
+	viper.SetDefault("llm.retry.max_retries", 2)
+	viper.SetDefault("llm.retry.base_delay_ms", 500)
+	viper.SetDefault("llm.retry.max_delay_ms", 10000)
+	viper.SetDefault("llm.analysis_reuse.enabled", true)
+	viper.SetDefault("llm.analysis_reuse.window", 1800)
+	viper.SetDefault("llm.analysis_reuse.change_threshold_pct", 0.3)
+	viper.SetDefault("llm.analysis.standard.temperature", 0.7)
+	viper.SetDefault("llm.analysis.standard.max_tokens", 3000)
+	viper.SetDefault("llm.analysis.fast.temperature", 0.3)
+	viper.SetDefault("llm.analysis.fast.max_tokens", 1500)
+	viper.SetDefault("llm.analysis.deep.temperature", 0.8)
+	viper.SetDefault("llm.analysis.deep.max_tokens", 8000)
+	viper.SetDefault("llm.analysis.fast_card", false)
+	viper.SetDefault("llm.analysis.sectors.count", 20)
+	viper.SetDefault("llm.analysis.sectors.max_per_category", 0)
+	viper.SetDefault("llm.analysis.modules.volume_trend", false)
+	viper.SetDefault("llm.analysis.modules.volume_days", 5)
+	viper.SetDefault("llm.analysis.modules.minute_data", false)
+	viper.SetDefault("llm.analysis.modules.minutes", 240)
+	viper.SetDefault("llm.analysis.modules.minute_granularity", "30m")
+	viper.SetDefault("funds.fetch_metadata", true)
+	viper.SetDefault("funds.pad_short_codes", true)
+	viper.SetDefault("funds.alerts.enabled", true)
+	viper.SetDefault("funds.alerts.check_interval", 300)
+
+	// Self-test
+	viper.SetDefault("self_test.timeout_ms", 5000)
+	viper.SetDefault("self_test.skip", []string{})
+
+	// Degradation
+	viper.SetDefault("degradation.fast_path_timeout_ms", 2000)
+	viper.SetDefault("degradation.async_refresh_timeout", 30)
+	viper.SetDefault("degradation.max_staleness", 21600)
+
+	// Rate limit
+	viper.SetDefault("rate_limit.user.requests_per_second", 10)
+	viper.SetDefault("rate_limit.user.burst", 20)
+	viper.SetDefault("rate_limit.ip.requests_per_second", 30)
+	viper.SetDefault("rate_limit.ip.burst", 60)
+	viper.SetDefault("rate_limit.max_concurrent_analyses_per_user", 1)
+	viper.SetDefault("rate_limit.max_sse_connections", 100)
+	viper.SetDefault("rate_limit.max_sse_connections_per_user", 3)
+	viper.SetDefault("rate_limit.exempt.roles", []string{"admin"})
+	viper.SetDefault("rate_limit.exempt.paths", []string{"/health", "/ready"})
+	viper.SetDefault("rate_limit.distributed", false)
+
+	// Compression
+	viper.SetDefault("compression.enabled", true)
+	viper.SetDefault("compression.min_size", 1024)
+	viper.SetDefault("compression.gzip_level", 6)
+	viper.SetDefault("compression.brotli_level", 4)
+	viper.SetDefault("compression.algorithms", []string{"br", "gzip"})
+	viper.SetDefault("coalescing.enabled", true)
+	viper.SetDefault("coalescing.paths", []string{
+		"/api/v1/market/indices",
+		"/api/v1/market/precious-metals",
+		"/api/v1/market/gold-history",
+		"/api/v1/market/volume",
+		"/api/v1/market/minute-data",
+		"/api/v1/news",
+		"/api/v1/sectors",
+		"/api/v1/sectors/heatmap",
+	})
+
+	// Crawler
+	viper.SetDefault("crawler.webpage_max_redirects", 5)
+	viper.SetDefault("crawler.html_max_bytes", 2<<20)
+	viper.SetDefault("crawler.html_max_depth", 256)
+	viper.SetDefault("crawler.html_max_nodes", 50000)
+	viper.SetDefault("crawler.breaker_cleanup_interval", 300)
+	viper.SetDefault("crawler.breaker_idle_timeout", 600)
+	viper.SetDefault("crawler.request_log.enabled", true)
+	viper.SetDefault("crawler.request_log.sample_rate", 0.1)
+	viper.SetDefault("crawler.request_log.slow_threshold_ms", 3000)
+	viper.SetDefault("crawler.request_log.body_preview_bytes", 0)
+	viper.SetDefault("crawler.cookie_jar_sources", []string{})
 }
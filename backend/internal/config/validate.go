@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 服务运行模式
+const (
+	ModeDebug   = "debug"
+	ModeRelease = "release"
+	ModeTest    = "test"
+)
+
+// DefaultJWTSecret 默认 JWT 密钥，仅用于本地开发
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// MinJWTSecretLength 建议的 JWT 密钥最小长度
+const MinJWTSecretLength = 32
+
+// insecureJWTSecrets 默认值与示例配置中的占位密钥
+var insecureJWTSecrets = map[string]bool{
+	DefaultJWTSecret: true,
+	"your-jwt-secret-key-change-in-production": true,
+}
+
+// ErrInvalidConfig 配置无效
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Validate 校验配置，返回不安全但可运行的配置警告
+// 存在无法安全运行的配置时返回 ErrInvalidConfig，错误信息包含所有问题
+func (c *Config) Validate() (warnings []string, err error) {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	release := c.Server.Mode == ModeRelease
+
+	// Server
+	switch c.Server.Mode {
+	case ModeDebug, ModeRelease, ModeTest:
+	default:
+		fail("server.mode must be one of debug, release, test, got %q", c.Server.Mode)
+	}
+	if !validPort(c.Server.Port) {
+		fail("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Server.ReadTimeout <= 0 {
+		fail("server.read_timeout must be positive, got %d", c.Server.ReadTimeout)
+	}
+	if c.Server.WriteTimeout <= 0 {
+		fail("server.write_timeout must be positive, got %d", c.Server.WriteTimeout)
+	}
+
+	if c.Server.BodyLimit.Default <= 0 || c.Server.BodyLimit.Auth <= 0 || c.Server.BodyLimit.AI <= 0 {
+		fail("server.body_limit default, auth and ai must be positive")
+	}
+
+	// Database / Redis
+	if !validPort(c.Database.Port) {
+		fail("database.port must be between 1 and 65535, got %d", c.Database.Port)
+	}
+	if !validPort(c.Redis.Port) {
+		fail("redis.port must be between 1 and 65535, got %d", c.Redis.Port)
+	}
+	if c.Cache.SnapshotPath != "" && c.Cache.SnapshotInterval <= 0 {
+		fail("cache.snapshot_interval must be positive when cache.snapshot_path is set, got %d", c.Cache.SnapshotInterval)
+	}
+	if release && c.Database.SSLMode == "disable" {
+		warn("database.sslmode is disable in release mode")
+	}
+
+	// JWT
+	switch strings.ToUpper(strings.TrimSpace(c.JWT.Algorithm)) {
+	case "", "HS256":
+		secret := strings.TrimSpace(c.JWT.Secret)
+		switch {
+		case secret == "":
+			fail("jwt.secret must not be empty")
+		case insecureJWTSecrets[secret] && release:
+			fail("jwt.secret must be changed from the default value in release mode")
+		case insecureJWTSecrets[secret]:
+			warn("jwt.secret uses the default value, do not use it in production")
+		case len(secret) < MinJWTSecretLength:
+			warn("jwt.secret is shorter than %d characters", MinJWTSecretLength)
+		}
+	case "RS256":
+		if strings.TrimSpace(c.JWT.PrivateKeyPath) == "" {
+			fail("jwt.private_key_path must be set when jwt.algorithm is RS256")
+		}
+	default:
+		fail("jwt.algorithm must be one of HS256, RS256, got %q", c.JWT.Algorithm)
+	}
+	if c.JWT.AccessExpireMin <= 0 {
+		fail("jwt.access_expire_min must be positive, got %d", c.JWT.AccessExpireMin)
+	}
+	if c.JWT.RefreshExpireDay <= 0 {
+		fail("jwt.refresh_expire_day must be positive, got %d", c.JWT.RefreshExpireDay)
+	}
+	if c.JWT.AccessExpireMin > 0 && c.JWT.RefreshExpireDay > 0 &&
+		c.JWT.AccessExpireMin >= c.JWT.RefreshExpireDay*24*60 {
+		warn("jwt.access_expire_min (%d) is not shorter than the refresh token lifetime", c.JWT.AccessExpireMin)
+	}
+
+	// LLM
+	if c.LLM.Timeout <= 0 {
+		fail("llm.timeout must be positive, got %d", c.LLM.Timeout)
+	}
+	if c.LLM.Retry.MaxRetries < 0 || c.LLM.Retry.BaseDelayMs < 0 || c.LLM.Retry.MaxDelayMs < 0 {
+		fail("llm.retry max_retries, base_delay_ms and max_delay_ms must not be negative")
+	}
+	for _, mode := range []struct {
+		name string
+		cfg  AnalysisModeConfig
+	}{
+		{"standard", c.LLM.Analysis.Standard},
+		{"fast", c.LLM.Analysis.Fast},
+		{"deep", c.LLM.Analysis.Deep},
+	} {
+		if mode.cfg.Temperature < 0 || mode.cfg.Temperature > 2 {
+			fail("llm.analysis.%s.temperature must be between 0 and 2, got %g", mode.name, mode.cfg.Temperature)
+		}
+		if mode.cfg.MaxTokens < 0 {
+			fail("llm.analysis.%s.max_tokens must not be negative, got %d", mode.name, mode.cfg.MaxTokens)
+		}
+	}
+
+	// Crawler
+	if c.Crawler.RequestLog.SampleRate < 0 || c.Crawler.RequestLog.SampleRate > 1 {
+		fail("crawler.request_log.sample_rate must be between 0 and 1, got %g", c.Crawler.RequestLog.SampleRate)
+	}
+	if c.Crawler.RequestLog.BodyPreviewBytes < 0 {
+		fail("crawler.request_log.body_preview_bytes must not be negative, got %d", c.Crawler.RequestLog.BodyPreviewBytes)
+	}
+
+	// Funds
+	if c.Funds.Alerts.Enabled && c.Funds.Alerts.CheckInterval <= 0 {
+		fail("funds.alerts.check_interval must be positive when funds.alerts.enabled is true, got %d", c.Funds.Alerts.CheckInterval)
+	}
+
+	// Rate limit
+	if c.RateLimit.User.RequestsPerSecond <= 0 || c.RateLimit.User.Burst <= 0 {
+		fail("rate_limit.user requests_per_second and burst must be positive")
+	}
+	if c.RateLimit.IP.RequestsPerSecond <= 0 || c.RateLimit.IP.Burst <= 0 {
+		fail("rate_limit.ip requests_per_second and burst must be positive")
+	}
+	if c.RateLimit.MaxSSEConnections <= 0 {
+		fail("rate_limit.max_sse_connections must be positive, got %d", c.RateLimit.MaxSSEConnections)
+	}
+	if c.RateLimit.MaxSSEConnectionsPerUser > c.RateLimit.MaxSSEConnections {
+		warn("rate_limit.max_sse_connections_per_user (%d) exceeds max_sse_connections (%d)",
+			c.RateLimit.MaxSSEConnectionsPerUser, c.RateLimit.MaxSSEConnections)
+	}
+	if release && c.Log.Level == "debug" {
+		warn("log.level is debug in release mode")
+	}
+
+	if len(problems) > 0 {
+		return warnings, fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return warnings, nil
+}
+
+// validPort 端口是否在有效范围内
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig 返回可通过校验的生产配置
+func validConfig() *Config {
+	return &Config{
+		Server:    ServerConfig{Port: 8080, Mode: ModeRelease, ReadTimeout: 30, WriteTimeout: 30, BodyLimit: BodyLimitConfig{Default: 256 << 10, Auth: 16 << 10, AI: 1 << 20}},
+		Database:  DatabaseConfig{Port: 5432, SSLMode: "require"},
+		Redis:     RedisConfig{Port: 6379},
+		JWT:       JWTConfig{Secret: "0123456789abcdef0123456789abcdef", AccessExpireMin: 60, RefreshExpireDay: 7},
+		LLM:       LLMConfig{Timeout: 120},
+		Log:       LogConfig{Level: "info"},
+		RateLimit: RateLimitConfig{User: RateLimitRule{RequestsPerSecond: 10, Burst: 20}, IP: RateLimitRule{RequestsPerSecond: 30, Burst: 60}, MaxSSEConnections: 100, MaxSSEConnectionsPerUser: 3},
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	warnings, err := validConfig().Validate()
+
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestValidate_DefaultSecretRejectedInRelease(t *testing.T) {
+	for _, secret := range []string{DefaultJWTSecret, "your-jwt-secret-key-change-in-production", "  "} {
+		cfg := validConfig()
+		cfg.JWT.Secret = secret
+
+		_, err := cfg.Validate()
+
+		assert.ErrorIs(t, err, ErrInvalidConfig, "secret %q", secret)
+		assert.Contains(t, err.Error(), "jwt.secret")
+	}
+}
+
+func TestValidate_DefaultSecretWarnsInDebug(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Mode = ModeDebug
+	cfg.JWT.Secret = DefaultJWTSecret
+
+	warnings, err := cfg.Validate()
+
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "jwt.secret")
+}
+
+func TestValidate_Ranges(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		field  string
+	}{
+		{"port zero", func(c *Config) { c.Server.Port = 0 }, "server.port"},
+		{"port too large", func(c *Config) { c.Server.Port = 70000 }, "server.port"},
+		{"unknown mode", func(c *Config) { c.Server.Mode = "prod" }, "server.mode"},
+		{"zero read timeout", func(c *Config) { c.Server.ReadTimeout = 0 }, "server.read_timeout"},
+		{"negative write timeout", func(c *Config) { c.Server.WriteTimeout = -1 }, "server.write_timeout"},
+		{"zero body limit", func(c *Config) { c.Server.BodyLimit.AI = 0 }, "server.body_limit"},
+		{"database port", func(c *Config) { c.Database.Port = 0 }, "database.port"},
+		{"redis port", func(c *Config) { c.Redis.Port = 65536 }, "redis.port"},
+		{"unknown jwt algorithm", func(c *Config) { c.JWT.Algorithm = "none" }, "jwt.algorithm"},
+		{"rs256 without private key", func(c *Config) { c.JWT.Algorithm = "RS256" }, "jwt.private_key_path"},
+		{"zero access expiry", func(c *Config) { c.JWT.AccessExpireMin = 0 }, "jwt.access_expire_min"},
+		{"zero refresh expiry", func(c *Config) { c.JWT.RefreshExpireDay = 0 }, "jwt.refresh_expire_day"},
+		{"zero snapshot interval", func(c *Config) { c.Cache = CacheConfig{SnapshotPath: "/tmp/cache.json"} }, "cache.snapshot_interval"},
+		{"zero llm timeout", func(c *Config) { c.LLM.Timeout = 0 }, "llm.timeout"},
+		{"negative llm retries", func(c *Config) { c.LLM.Retry.MaxRetries = -1 }, "llm.retry"},
+		{"deep temperature too high", func(c *Config) { c.LLM.Analysis.Deep.Temperature = 2.5 }, "llm.analysis.deep.temperature"},
+		{"negative fast max tokens", func(c *Config) { c.LLM.Analysis.Fast.MaxTokens = -1 }, "llm.analysis.fast.max_tokens"},
+		{"request log sample rate too high", func(c *Config) { c.Crawler.RequestLog.SampleRate = 1.5 }, "crawler.request_log.sample_rate"},
+		{"zero alert check interval", func(c *Config) { c.Funds.Alerts = FundAlertConfig{Enabled: true} }, "funds.alerts.check_interval"},
+		{"zero user burst", func(c *Config) { c.RateLimit.User.Burst = 0 }, "rate_limit.user"},
+		{"zero ip rate", func(c *Config) { c.RateLimit.IP.RequestsPerSecond = 0 }, "rate_limit.ip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+
+			_, err := cfg.Validate()
+
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			assert.Contains(t, err.Error(), tt.field)
+		})
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = -1
+	cfg.LLM.Timeout = 0
+
+	_, err := cfg.Validate()
+
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "server.port")
+	assert.Contains(t, err.Error(), "llm.timeout")
+}
+
+func TestValidate_InsecureCombinationsWarn(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.SSLMode = "disable"
+	cfg.Log.Level = "debug"
+	cfg.JWT.Secret = "short-secret"
+	cfg.JWT.AccessExpireMin = 8 * 24 * 60
+
+	warnings, err := cfg.Validate()
+
+	require.NoError(t, err)
+	assert.Len(t, warnings, 4)
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// WatchContent 监听配置文件变更，重新解析内容配置后回调 onChange
+// 解析失败时回调错误，调用方应保留当前配置
+func WatchContent(onChange func(ContentConfig, error)) {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		onChange(loadContent(viper.GetViper()))
+	})
+	viper.WatchConfig()
+}
+
+// loadContent 从 viper 解析内容配置
+func loadContent(v *viper.Viper) (ContentConfig, error) {
+	var content ContentConfig
+	if err := v.UnmarshalKey("content", &content); err != nil {
+		return ContentConfig{}, fmt.Errorf("failed to unmarshal content config: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadContent(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(`
+content:
+  hot_reload: true
+  matcher_keywords:
+    precious_metals: [黄金, gold]
+  templates:
+    fast: 快速模板
+  news_deny_list: [违规]
+`)))
+
+	content, err := loadContent(v)
+
+	require.NoError(t, err)
+	assert.True(t, content.HotReload)
+	assert.Equal(t, []string{"黄金", "gold"}, content.MatcherKeywords["precious_metals"])
+	assert.Equal(t, "快速模板", content.Templates.Fast)
+	assert.Equal(t, []string{"违规"}, content.NewsDenyList)
+}
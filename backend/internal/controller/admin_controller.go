@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"errors"
+
+	"fund-analyzer/internal/middleware"
+	"fund-analyzer/internal/service"
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminController 管理员操作控制器
+type AdminController struct {
+	refreshService service.CacheRefreshService
+	sseLimiter     *middleware.SSEConnectionLimiter
+	requestLogger  *service.CrawlerRequestLogger
+	jobs           *service.JobScheduler
+	selfTest       service.SelfTestService
+	logger         *zap.Logger
+}
+
+// NewAdminController 创建管理员操作控制器
+func NewAdminController(
+	refreshService service.CacheRefreshService,
+	sseLimiter *middleware.SSEConnectionLimiter,
+	requestLogger *service.CrawlerRequestLogger,
+	jobs *service.JobScheduler,
+	selfTest service.SelfTestService,
+	logger *zap.Logger,
+) *AdminController {
+	return &AdminController{
+		refreshService: refreshService,
+		sseLimiter:     sseLimiter,
+		requestLogger:  requestLogger,
+		jobs:           jobs,
+		selfTest:       selfTest,
+		logger:         logger,
+	}
+}
+
+// Refresh 依次刷新指数、贵金属、板块、快讯缓存，返回各数据源的结果与耗时
+// POST /api/v1/admin/refresh
+func (c *AdminController) Refresh(ctx *gin.Context) {
+	report, err := c.refreshService.RefreshAll(ctx.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrRefreshInProgress) {
+			response.Conflict(ctx, "Cache refresh already in progress")
+			return
+		}
+		c.logger.Error("Refresh failed", zap.Error(err))
+		response.InternalError(ctx, "Failed to refresh cache")
+		return
+	}
+
+	c.logger.Info("Cache refreshed",
+		zap.Int64("userID", middleware.GetUserID(ctx)),
+		zap.Int64("durationMs", report.DurationMs),
+	)
+	response.Success(ctx, report)
+}
+
+// GetSSEConnections 获取当前 SSE 连接数及各用户的连接数
+// GET /api/v1/admin/sse-connections
+func (c *AdminController) GetSSEConnections(ctx *gin.Context) {
+	response.Success(ctx, c.sseLimiter.Stats())
+}
+
+// GetUpstreamLatency 获取各数据源的上游请求耗时统计，未开启请求日志时返回空列表
+// GET /api/v1/admin/upstream-latency
+func (c *AdminController) GetUpstreamLatency(ctx *gin.Context) {
+	if c.requestLogger == nil {
+		response.Success(ctx, []service.UpstreamLatencyStats{})
+		return
+	}
+	response.Success(ctx, c.requestLogger.Stats())
+}
+
+// GetJobs 获取后台定时任务的最近执行时间、成功时间与错误
+// GET /api/v1/admin/jobs
+func (c *AdminController) GetJobs(ctx *gin.Context) {
+	if c.jobs == nil {
+		response.Success(ctx, []service.JobStatus{})
+		return
+	}
+	response.Success(ctx, c.jobs.Statuses())
+}
+
+// SelfTest 部署自检：并发检查数据库、缓存、各数据源、大模型与邮件，返回各子系统的结果与耗时
+// GET /api/v1/admin/selftest
+func (c *AdminController) SelfTest(ctx *gin.Context) {
+	report, err := c.selfTest.Run(ctx.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrSelfTestInProgress) {
+			response.Conflict(ctx, "Self-test already in progress")
+			return
+		}
+		c.logger.Error("SelfTest failed", zap.Error(err))
+		response.InternalError(ctx, "Failed to run self-test")
+		return
+	}
+
+	c.logger.Info("Self-test completed",
+		zap.Int64("userID", middleware.GetUserID(ctx)),
+		zap.Bool("passed", report.Passed),
+		zap.Int64("durationMs", report.DurationMs),
+	)
+	response.Success(ctx, report)
+}
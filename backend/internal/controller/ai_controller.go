@@ -2,6 +2,10 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"fund-analyzer/internal/middleware"
 	"fund-analyzer/internal/model"
@@ -12,14 +16,22 @@ import (
 	"go.uber.org/zap"
 )
 
+// sseHeartbeatInterval AI 流式响应空闲时的心跳间隔
+const sseHeartbeatInterval = 15 * time.Second
+
 // AIController AI 分析控制器
 type AIController struct {
-	aiService     service.AIService
-	marketService service.MarketService
-	newsService   service.NewsService
-	sectorService service.SectorService
-	fundService   service.FundService
-	logger        *zap.Logger
+	aiService       service.AIService
+	marketService   service.MarketService
+	newsService     service.NewsService
+	sectorService   service.SectorService
+	fundService     service.FundService
+	snapshotService service.SnapshotService
+	analysisReuse   service.AnalysisReuseService // 为 nil 时每次都重新执行深度研究
+	conversations   service.ConversationService  // 为 nil 时不保存对话，历史由客户端提供
+	sectors         *service.SectorSelector      // 为 nil 时取前 service.DefaultAnalysisSectors 个板块
+	modules         service.AnalysisModules      // 标准与深度分析额外获取的成交量、分时数据
+	logger          *zap.Logger
 }
 
 // NewAIController 创建 AI 控制器
@@ -29,20 +41,31 @@ func NewAIController(
 	newsService service.NewsService,
 	sectorService service.SectorService,
 	fundService service.FundService,
+	snapshotService service.SnapshotService,
+	analysisReuse service.AnalysisReuseService,
+	conversations service.ConversationService,
+	sectors *service.SectorSelector,
+	modules service.AnalysisModules,
 	logger *zap.Logger,
 ) *AIController {
 	return &AIController{
-		aiService:     aiService,
-		marketService: marketService,
-		newsService:   newsService,
-		sectorService: sectorService,
-		fundService:   fundService,
-		logger:        logger,
+		aiService:       aiService,
+		marketService:   marketService,
+		newsService:     newsService,
+		sectorService:   sectorService,
+		fundService:     fundService,
+		snapshotService: snapshotService,
+		analysisReuse:   analysisReuse,
+		conversations:   conversations,
+		sectors:         sectors,
+		modules:         modules,
+		logger:          logger,
 	}
 }
 
 // Chat AI 聊天 (SSE)
 // POST /api/v1/ai/chat
+// 指定 conversationId 时从服务端加载历史消息，用户消息与助手回复均保存到会话
 func (c *AIController) Chat(ctx *gin.Context) {
 	// 解析请求
 	var req model.ChatRequest
@@ -50,81 +73,77 @@ func (c *AIController) Chat(ctx *gin.Context) {
 		response.BadRequest(ctx, "Invalid request body")
 		return
 	}
+	req.UserID = middleware.GetUserID(ctx)
 
-	// 创建 SSE 写入器
-	sseWriter := middleware.NewSSEWriter(ctx)
-	if sseWriter == nil {
-		response.InternalError(ctx, "SSE not supported")
+	if c.conversations == nil {
+		c.streamAnalysis(ctx, "Chat", func(ctx context.Context, stream chan<- model.ChatChunk) error {
+			return c.aiService.Chat(ctx, &req, stream)
+		})
 		return
 	}
-	defer sseWriter.Close()
-
-	// 创建 channel 接收聊天响应
-	chunks := make(chan model.ChatChunk, 100)
 
-	// 启动 goroutine 调用 AI 服务
-	go func() {
-		err := c.aiService.Chat(sseWriter.Context(), &req, chunks)
-		if err != nil {
-			c.logger.Error("AI Chat failed", zap.Error(err))
-			// 错误已在 service 层通过 channel 发送
-		}
-	}()
-
-	// 流式发送响应
-	if err := sseWriter.StreamChatChunks(chunks); err != nil {
-		c.logger.Debug("SSE stream ended", zap.Error(err))
+	// 先加载会话，再建立 SSE 连接
+	err := c.conversations.Prepare(ctx.Request.Context(), &req)
+	if errors.Is(err, service.ErrConversationNotFound) {
+		response.NotFound(ctx, "Conversation not found")
+		return
+	}
+	if err != nil {
+		c.logger.Error("Failed to prepare conversation", zap.Error(err))
+		response.InternalError(ctx, "Failed to load conversation")
+		return
 	}
-}
 
-// AnalyzeStandard 标准分析 (SSE)
-// POST /api/v1/ai/analyze/standard
-func (c *AIController) AnalyzeStandard(ctx *gin.Context) {
-	userID := middleware.GetUserID(ctx)
+	c.streamAnalysis(ctx, "Chat", func(ctx context.Context, stream chan<- model.ChatChunk) error {
+		tee, wait := c.conversations.Capture(ctx, &req, stream)
+		err := c.aiService.Chat(ctx, &req, tee)
+		wait()
+		return err
+	})
+}
 
-	// 创建 SSE 写入器
-	sseWriter := middleware.NewSSEWriter(ctx)
-	if sseWriter == nil {
-		response.InternalError(ctx, "SSE not supported")
+// GetConversationMessages 获取会话的最近消息，用于刷新页面后恢复对话
+// GET /api/v1/ai/conversations/:id/messages
+func (c *AIController) GetConversationMessages(ctx *gin.Context) {
+	if c.conversations == nil {
+		response.NotFound(ctx, "Conversation not found")
 		return
 	}
-	defer sseWriter.Close()
 
-	// 发送状态：正在获取数据
-	if err := sseWriter.SendStatus("正在获取市场数据..."); err != nil {
-		c.logger.Debug("SSE send status failed", zap.Error(err))
+	conversationID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		response.BadRequest(ctx, "Invalid conversation id")
 		return
 	}
 
-	// 获取市场数据
-	marketData, err := c.fetchMarketData(sseWriter.Context(), userID)
-	if err != nil {
-		c.logger.Error("Failed to fetch market data", zap.Error(err))
-		_ = sseWriter.SendError("获取市场数据失败")
+	messages, err := c.conversations.Messages(ctx.Request.Context(), middleware.GetUserID(ctx), conversationID)
+	if errors.Is(err, service.ErrConversationNotFound) {
+		response.NotFound(ctx, "Conversation not found")
 		return
 	}
-
-	// 发送状态：正在生成分析
-	if err := sseWriter.SendStatus("正在生成分析报告..."); err != nil {
-		c.logger.Debug("SSE send status failed", zap.Error(err))
+	if err != nil {
+		c.logger.Error("Failed to get conversation messages", zap.Error(err))
+		response.InternalError(ctx, "Failed to get conversation messages")
 		return
 	}
 
-	// 创建 channel 接收分析内容
-	contents := make(chan string, 100)
+	response.Success(ctx, messages)
+}
+
+// AnalyzeStandard 标准分析 (SSE)
+// POST /api/v1/ai/analyze/standard
+func (c *AIController) AnalyzeStandard(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
 
-	// 启动 goroutine 调用 AI 服务
-	go func() {
-		err := c.aiService.AnalyzeStandard(sseWriter.Context(), marketData, contents)
+	c.streamAnalysis(ctx, "AnalyzeStandard", func(ctx context.Context, stream chan<- model.ChatChunk) error {
+		marketData, err := c.loadMarketData(ctx, userID, c.fetchMarketData, stream)
 		if err != nil {
-			c.logger.Error("AI AnalyzeStandard failed", zap.Error(err))
+			return err
 		}
-	}()
+		c.saveSnapshot(ctx, userID, marketData)
 
-	// 流式发送响应
-	if err := sseWriter.StreamStrings(contents); err != nil {
-		c.logger.Debug("SSE stream ended", zap.Error(err))
-	}
+		return c.aiService.AnalyzeStandard(ctx, marketData, stream)
+	})
 }
 
 // AnalyzeFast 快速分析 (SSE)
@@ -132,56 +151,114 @@ func (c *AIController) AnalyzeStandard(ctx *gin.Context) {
 func (c *AIController) AnalyzeFast(ctx *gin.Context) {
 	userID := middleware.GetUserID(ctx)
 
-	// 创建 SSE 写入器
-	sseWriter := middleware.NewSSEWriter(ctx)
-	if sseWriter == nil {
-		response.InternalError(ctx, "SSE not supported")
-		return
+	c.streamAnalysis(ctx, "AnalyzeFast", func(ctx context.Context, stream chan<- model.ChatChunk) error {
+		// 快速分析只获取核心数据
+		marketData, err := c.loadMarketData(ctx, userID, c.fetchCoreMarketData, stream)
+		if err != nil {
+			return err
+		}
+
+		return c.aiService.AnalyzeFast(ctx, marketData, stream)
+	})
+}
+
+// AnalyzeDeep 深度研究 (SSE)
+// POST /api/v1/ai/analyze/deep?force=true
+// 市场数据无明显变化时复用上次报告，force=true 时强制重新分析
+func (c *AIController) AnalyzeDeep(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+	force, _ := strconv.ParseBool(ctx.Query("force"))
+
+	c.streamAnalysis(ctx, "AnalyzeDeep", func(ctx context.Context, stream chan<- model.ChatChunk) error {
+		marketData, err := c.loadMarketData(ctx, userID, c.fetchMarketData, stream)
+		if err != nil {
+			return err
+		}
+		c.saveSnapshot(ctx, userID, marketData)
+
+		if c.analysisReuse == nil {
+			return c.aiService.AnalyzeDeep(ctx, marketData, stream)
+		}
+
+		if !force {
+			if report, ok := c.analysisReuse.Lookup(ctx, userID, marketData); ok {
+				replayReport(stream, report)
+				return nil
+			}
+		}
+
+		tee, wait := c.analysisReuse.Capture(ctx, userID, marketData, stream)
+		err = c.aiService.AnalyzeDeep(ctx, marketData, tee)
+		wait()
+		return err
+	})
+}
+
+// replayReport 发送复用提示与上次的报告，并关闭 channel
+func replayReport(stream chan<- model.ChatChunk, report string) {
+	defer close(stream)
+
+	stream <- model.ChatChunk{
+		Type:    model.ChunkTypeStatus,
+		Message: service.AnalysisReusedNote,
 	}
-	defer sseWriter.Close()
+	stream <- model.ChatChunk{
+		Type:  model.ChunkTypeContent,
+		Chunk: report,
+	}
+	stream <- model.ChatChunk{
+		Type: model.ChunkTypeDone,
+	}
+}
+
+// AnalyzeCompare 时段对比分析 (SSE)
+// POST /api/v1/ai/analyze/compare
+func (c *AIController) AnalyzeCompare(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
 
-	// 发送状态：正在获取数据
-	if err := sseWriter.SendStatus("正在获取市场数据..."); err != nil {
-		c.logger.Debug("SSE send status failed", zap.Error(err))
+	var req model.CompareAnalyzeRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(ctx, "Invalid request body")
+			return
+		}
+	}
+	if req.Days == 0 {
+		req.Days = service.DefaultCompareDays
+	}
+	if req.Days < 1 || req.Days > service.MaxCompareDays {
+		response.BadRequest(ctx, fmt.Sprintf("days must be between 1 and %d", service.MaxCompareDays))
 		return
 	}
 
-	// 获取市场数据（快速分析只获取核心数据）
-	marketData, err := c.fetchCoreMarketData(sseWriter.Context(), userID)
-	if err != nil {
-		c.logger.Error("Failed to fetch market data", zap.Error(err))
-		_ = sseWriter.SendError("获取市场数据失败")
+	// 先确认基准快照存在，再建立 SSE 连接
+	reference, err := c.snapshotService.Get(ctx.Request.Context(), userID, time.Now().AddDate(0, 0, -req.Days))
+	if errors.Is(err, service.ErrSnapshotNotFound) {
+		response.NotFound(ctx, "No market snapshot available for the reference date")
 		return
 	}
-
-	// 发送状态：正在生成分析
-	if err := sseWriter.SendStatus("正在生成快速分析..."); err != nil {
-		c.logger.Debug("SSE send status failed", zap.Error(err))
+	if err != nil {
+		c.logger.Error("Failed to load market snapshot", zap.Error(err))
+		response.InternalError(ctx, "Failed to load market snapshot")
 		return
 	}
 
-	// 创建 channel 接收分析内容
-	contents := make(chan string, 100)
-
-	// 启动 goroutine 调用 AI 服务
-	go func() {
-		err := c.aiService.AnalyzeFast(sseWriter.Context(), marketData, contents)
+	c.streamAnalysis(ctx, "AnalyzeCompare", func(ctx context.Context, stream chan<- model.ChatChunk) error {
+		// 获取当前市场数据并保存为今日快照
+		marketData, err := c.loadMarketData(ctx, userID, c.fetchMarketData, stream)
 		if err != nil {
-			c.logger.Error("AI AnalyzeFast failed", zap.Error(err))
+			return err
 		}
-	}()
+		current := c.saveSnapshot(ctx, userID, marketData)
 
-	// 流式发送响应
-	if err := sseWriter.StreamStrings(contents); err != nil {
-		c.logger.Debug("SSE stream ended", zap.Error(err))
-	}
+		return c.aiService.AnalyzeCompare(ctx, current, reference, stream)
+	})
 }
 
-// AnalyzeDeep 深度研究 (SSE)
-// POST /api/v1/ai/analyze/deep
-func (c *AIController) AnalyzeDeep(ctx *gin.Context) {
-	userID := middleware.GetUserID(ctx)
-
+// streamAnalysis 建立 SSE 连接并在后台执行对话或分析
+// 数据获取与 AI 服务的状态、内容、工具调用和结束事件都写入同一个 channel，按产生顺序发送给客户端
+// run 负责关闭 channel：调用 AI 服务后由服务关闭，数据获取失败时由 loadMarketData 关闭
+func (c *AIController) streamAnalysis(ctx *gin.Context, name string, run func(ctx context.Context, stream chan<- model.ChatChunk) error) {
 	// 创建 SSE 写入器
 	sseWriter := middleware.NewSSEWriter(ctx)
 	if sseWriter == nil {
@@ -190,41 +267,51 @@ func (c *AIController) AnalyzeDeep(ctx *gin.Context) {
 	}
 	defer sseWriter.Close()
 
-	// 发送状态：正在获取数据
-	if err := sseWriter.SendStatus("正在获取市场数据..."); err != nil {
-		c.logger.Debug("SSE send status failed", zap.Error(err))
-		return
-	}
+	// 模型思考或调用工具期间可能长时间没有输出，定期发送心跳保持连接
+	sseWriter.StartHeartbeat(sseHeartbeatInterval)
 
-	// 获取市场数据
-	marketData, err := c.fetchMarketData(sseWriter.Context(), userID)
-	if err != nil {
-		c.logger.Error("Failed to fetch market data", zap.Error(err))
-		_ = sseWriter.SendError("获取市场数据失败")
-		return
-	}
+	// 在后台获取数据并调用 AI 服务，panic 时以错误块结束流
+	chunks := c.runAIStream(sseWriter.Context(), name, run)
 
-	// 发送状态：正在进行深度研究
-	if err := sseWriter.SendStatus("正在进行深度研究，可能需要搜索相关新闻..."); err != nil {
-		c.logger.Debug("SSE send status failed", zap.Error(err))
-		return
+	// 流式发送响应
+	if err := sseWriter.StreamChatChunks(chunks); err != nil {
+		c.logger.Debug("SSE stream ended", zap.Error(err))
 	}
+}
 
-	// 创建 channel 接收分析内容
-	contents := make(chan string, 100)
+// loadMarketData 发送获取数据状态并获取市场数据，失败时发送错误并关闭 channel
+func (c *AIController) loadMarketData(
+	ctx context.Context,
+	userID int64,
+	fetch func(ctx context.Context, userID int64) (*model.MarketData, error),
+	stream chan<- model.ChatChunk,
+) (*model.MarketData, error) {
+	stream <- model.ChatChunk{
+		Type:    model.ChunkTypeStatus,
+		Message: "正在获取市场数据...",
+	}
 
-	// 启动 goroutine 调用 AI 服务
-	go func() {
-		err := c.aiService.AnalyzeDeep(sseWriter.Context(), marketData, contents)
-		if err != nil {
-			c.logger.Error("AI AnalyzeDeep failed", zap.Error(err))
+	marketData, err := fetch(ctx, userID)
+	if err != nil {
+		stream <- model.ChatChunk{
+			Type:    model.ChunkTypeError,
+			Message: "获取市场数据失败",
 		}
-	}()
+		close(stream)
+		return nil, fmt.Errorf("fetch market data failed: %w", err)
+	}
+	return marketData, nil
+}
 
-	// 流式发送响应
-	if err := sseWriter.StreamStrings(contents); err != nil {
-		c.logger.Debug("SSE stream ended", zap.Error(err))
+// saveSnapshot 保存今日市场快照，失败时仅记录日志，不影响分析
+func (c *AIController) saveSnapshot(ctx context.Context, userID int64, data *model.MarketData) *model.MarketSnapshot {
+	snapshot, err := c.snapshotService.Save(ctx, userID, data)
+	if err != nil {
+		c.logger.Warn("Failed to save market snapshot", zap.Error(err), zap.Int64("userID", userID))
+		now := time.Now()
+		return &model.MarketSnapshot{Date: now.Format("2006-01-02"), CapturedAt: now, Data: data}
 	}
+	return snapshot
 }
 
 // fetchMarketData 获取完整市场数据
@@ -244,19 +331,31 @@ func (c *AIController) fetchMarketData(ctx context.Context, userID int64) (*mode
 	}
 
 	// 获取快讯
-	news, err := c.newsService.GetNewsList(ctx, 20)
+	news, err := c.newsService.GetNews(ctx, service.NewsQuery{Limit: 20})
 	if err == nil {
-		data.News = news
+		data.News = news.Items
 	}
 
 	// 获取板块
 	sectors, err := c.sectorService.GetSectorList(ctx)
 	if err == nil {
-		// 只取前 20 个板块
-		if len(sectors) > 20 {
-			sectors = sectors[:20]
+		data.Sectors = c.sectors.Select(sectors, 0)
+	}
+
+	// 获取成交量趋势
+	if c.modules.VolumeTrend {
+		volumes, err := c.marketService.GetVolumeTrend(ctx, c.modules.VolumeDays)
+		if err == nil {
+			data.VolumeTrends = volumes
+		}
+	}
+
+	// 获取上证分时数据
+	if c.modules.MinuteData {
+		minutes, err := c.marketService.GetMinuteData(ctx, c.modules.Minutes, c.modules.MinuteGranularity)
+		if err == nil {
+			data.MinuteData = minutes
 		}
-		data.Sectors = sectors
 	}
 
 	// 获取用户自选基金
@@ -281,18 +380,15 @@ func (c *AIController) fetchCoreMarketData(ctx context.Context, userID int64) (*
 	data := &model.MarketData{}
 
 	// 获取快讯
-	news, err := c.newsService.GetNewsList(ctx, 10)
+	news, err := c.newsService.GetNews(ctx, service.NewsQuery{Limit: 10})
 	if err == nil {
-		data.News = news
+		data.News = news.Items
 	}
 
 	// 获取板块（只取前 10 个）
 	sectors, err := c.sectorService.GetSectorList(ctx)
 	if err == nil {
-		if len(sectors) > 10 {
-			sectors = sectors[:10]
-		}
-		data.Sectors = sectors
+		data.Sectors = c.sectors.Select(sectors, 10)
 	}
 
 	// 获取用户自选基金
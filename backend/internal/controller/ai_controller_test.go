@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeAIService 按消息内容决定行为的 AI 服务
+// "panic" 未关闭 channel 时 panic；"panic-after-close" 输出部分内容并在关闭 channel 后 panic；
+// "disconnect" 输出第一段后等待客户端断开，再忽略断开继续写入超出缓冲的内容，退出时关闭 exited
+type fakeAIService struct {
+	service.AIService
+	deepRuns int
+	exited   chan struct{}
+}
+
+func (s *fakeAIService) Chat(ctx context.Context, req *model.ChatRequest, stream chan<- model.ChatChunk) error {
+	switch req.Message {
+	case "panic":
+		panic("nil map in tool call")
+	case "panic-after-close":
+		defer close(stream)
+		stream <- model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "部分内容"}
+		panic("index out of range")
+	case "disconnect":
+		defer close(s.exited)
+		defer close(stream)
+		stream <- model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "第一段"}
+		<-ctx.Done()
+		for i := 0; i < 500; i++ {
+			stream <- model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "断开后的内容"}
+		}
+		return ctx.Err()
+	default:
+		defer close(stream)
+		stream <- model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "你好"}
+		stream <- model.ChatChunk{Type: model.ChunkTypeDone}
+		return nil
+	}
+}
+
+func newChatTestRouter() *gin.Engine {
+	ctrl := NewAIController(&fakeAIService{}, nil, nil, nil, nil, nil, nil, nil, nil, service.AnalysisModules{}, zap.NewNop())
+	r := gin.New()
+	r.POST("/chat", ctrl.Chat)
+	return r
+}
+
+func postChat(r *gin.Engine, message string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(`{"message":"`+message+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestChat_PanicInServiceEmitsErrorChunk(t *testing.T) {
+	r := newChatTestRouter()
+
+	for _, message := range []string{"panic", "panic-after-close"} {
+		t.Run(message, func(t *testing.T) {
+			w := postChat(r, message)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"type":"error"`)
+			assert.Contains(t, w.Body.String(), streamPanicMessage)
+		})
+	}
+
+	// 已输出的内容保留在错误块之前
+	body := postChat(r, "panic-after-close").Body.String()
+	assert.Less(t, strings.Index(body, "部分内容"), strings.Index(body, `"type":"error"`))
+
+	// 服务仍可正常处理后续请求
+	w := postChat(r, "hello")
+	assert.Contains(t, w.Body.String(), `"chunk":"你好"`)
+	assert.Contains(t, w.Body.String(), `"type":"done"`)
+	assert.NotContains(t, w.Body.String(), `"type":"error"`)
+}
+
+// disconnectingRecorder 收到第一段内容后模拟客户端断开
+type disconnectingRecorder struct {
+	*httptest.ResponseRecorder
+	disconnect context.CancelFunc
+}
+
+func (r *disconnectingRecorder) Write(b []byte) (int, error) {
+	if strings.Contains(string(b), "第一段") {
+		r.disconnect()
+	}
+	return r.ResponseRecorder.Write(b)
+}
+
+func TestChat_DisconnectMidStreamDoesNotLeakService(t *testing.T) {
+	svc := &fakeAIService{exited: make(chan struct{})}
+	ctrl := NewAIController(svc, nil, nil, nil, nil, nil, nil, nil, nil, service.AnalysisModules{}, zap.NewNop())
+	r := gin.New()
+	r.POST("/chat", ctrl.Chat)
+
+	reqCtx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	w := &disconnectingRecorder{ResponseRecorder: httptest.NewRecorder(), disconnect: disconnect}
+	req := httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(`{"message":"disconnect"}`)).WithContext(reqCtx)
+	req.Header.Set("Content-Type", "application/json")
+
+	handlerDone := make(chan struct{})
+	go func() {
+		defer close(handlerDone)
+		r.ServeHTTP(w, req)
+	}()
+
+	select {
+	case <-handlerDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not return after client disconnect")
+	}
+
+	// 断开后无人向客户端写入，服务写入的内容仍被读取丢弃，服务 goroutine 能够退出
+	select {
+	case <-svc.exited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AI service goroutine blocked after client disconnect")
+	}
+	assert.Contains(t, w.Body.String(), "第一段")
+	assert.NotContains(t, w.Body.String(), "断开后的内容")
+}
+
+func (s *fakeAIService) AnalyzeDeep(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error {
+	defer close(stream)
+	s.deepRuns++
+	stream <- model.ChatChunk{Type: model.ChunkTypeContent, Chunk: fmt.Sprintf("第%d次报告", s.deepRuns)}
+	stream <- model.ChatChunk{Type: model.ChunkTypeDone}
+	return nil
+}
+
+// fakeMarketSources 返回固定市场数据的行情、快讯与板块服务
+type fakeMarketSources struct {
+	service.MarketService
+	service.NewsService
+	service.SectorService
+	price string
+}
+
+func (f *fakeMarketSources) GetGlobalIndices(ctx context.Context) ([]model.MarketIndex, error) {
+	return []model.MarketIndex{{Name: "上证指数", Price: f.price}}, nil
+}
+
+func (f *fakeMarketSources) GetPreciousMetals(ctx context.Context) ([]model.PreciousMetal, error) {
+	return nil, nil
+}
+
+func (f *fakeMarketSources) GetNews(ctx context.Context, query service.NewsQuery) (*service.NewsPage, error) {
+	return &service.NewsPage{Items: []model.NewsItem{{ID: "1", Title: "央行降准"}}, Limit: query.Limit}, nil
+}
+
+func (f *fakeMarketSources) GetSectorList(ctx context.Context) ([]model.Sector, error) {
+	return nil, nil
+}
+
+func (f *fakeMarketSources) GetVolumeTrend(ctx context.Context, days int) ([]model.VolumeTrend, error) {
+	return []model.VolumeTrend{{Date: "2024-03-01", TotalVolume: "9876.54亿"}}, nil
+}
+
+func (f *fakeMarketSources) GetMinuteData(ctx context.Context, minutes int, granularity service.MinuteGranularity) ([]model.MinuteData, error) {
+	return []model.MinuteData{{Time: "10:00", Price: f.price}}, nil
+}
+
+func TestFetchMarketData_OptionalModules(t *testing.T) {
+	sources := &fakeMarketSources{price: "3050.12"}
+	newCtrl := func(modules service.AnalysisModules) *AIController {
+		return NewAIController(&fakeAIService{}, sources, sources, sources, nil, nil, nil, nil, nil, modules, zap.NewNop())
+	}
+
+	// 默认不获取成交量与分时数据
+	data, err := newCtrl(service.AnalysisModules{}).fetchMarketData(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Empty(t, data.VolumeTrends)
+	assert.Empty(t, data.MinuteData)
+
+	data, err = newCtrl(service.AnalysisModules{
+		VolumeTrend:       true,
+		VolumeDays:        5,
+		MinuteData:        true,
+		Minutes:           240,
+		MinuteGranularity: service.Granularity30Min,
+	}).fetchMarketData(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.VolumeTrend{{Date: "2024-03-01", TotalVolume: "9876.54亿"}}, data.VolumeTrends)
+	assert.Equal(t, []model.MinuteData{{Time: "10:00", Price: "3050.12"}}, data.MinuteData)
+}
+
+func TestAnalyzeDeep_ReusesReportUnlessChangedOrForced(t *testing.T) {
+	ai := &fakeAIService{}
+	sources := &fakeMarketSources{price: "3050.12"}
+	cache := service.NewMemoryCache()
+	reuse := service.NewAnalysisReuseService(cache, config.AnalysisReuseConfig{Window: 600, ChangeThresholdPct: 0.5})
+	ctrl := NewAIController(ai, sources, sources, sources, nil, service.NewSnapshotService(cache), reuse, nil, nil, service.AnalysisModules{}, zap.NewNop())
+	r := gin.New()
+	r.POST("/deep", ctrl.AnalyzeDeep)
+
+	analyze := func(path string) string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w.Body.String()
+	}
+
+	body := analyze("/deep")
+	assert.Contains(t, body, "第1次报告")
+	assert.NotContains(t, body, service.AnalysisReusedNote)
+
+	// 数据未变化，复用上次报告
+	body = analyze("/deep")
+	assert.Contains(t, body, service.AnalysisReusedNote)
+	assert.Contains(t, body, "第1次报告")
+	assert.Contains(t, body, `"type":"done"`)
+	assert.Equal(t, 1, ai.deepRuns)
+
+	// 强制重新分析
+	body = analyze("/deep?force=true")
+	assert.Contains(t, body, "第2次报告")
+	assert.NotContains(t, body, service.AnalysisReusedNote)
+
+	// 数据明显变化，重新分析
+	sources.price = "3100.00"
+	body = analyze("/deep")
+	assert.Contains(t, body, "第3次报告")
+	assert.Equal(t, 3, ai.deepRuns)
+}
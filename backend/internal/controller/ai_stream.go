@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"runtime/debug"
+
+	"fund-analyzer/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// streamPanicMessage 分析 goroutine panic 时发送给客户端的错误信息
+const streamPanicMessage = "分析过程出现内部错误，请稍后重试"
+
+// runAIStream 在后台 goroutine 中执行 run，返回按顺序转发其输出的 channel
+// Recovery 中间件无法捕获后台 goroutine 的 panic，这里记录堆栈并以错误块结束流，避免进程崩溃。
+// run 写入的 channel 可能已被服务在 panic 展开时关闭，也可能未关闭，
+// 因此由转发 goroutine 独占关闭返回的 channel，并在 run 退出后再结束
+func (c *AIController) runAIStream(
+	ctx context.Context,
+	name string,
+	run func(ctx context.Context, stream chan<- model.ChatChunk) error,
+) <-chan model.ChatChunk {
+	in := make(chan model.ChatChunk, 100)
+	out := make(chan model.ChatChunk, 100)
+	finished := make(chan bool, 1) // true 表示 run 发生了 panic
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				c.logger.Error("AI "+name+" panicked",
+					zap.Any("panic", r),
+					zap.String("stack", string(debug.Stack())),
+				)
+				finished <- true
+				return
+			}
+			finished <- false
+		}()
+
+		if err := run(ctx, in); err != nil {
+			c.logger.Error("AI "+name+" failed", zap.Error(err))
+		}
+	}()
+
+	go relayAIStream(ctx, in, out, finished)
+	return out
+}
+
+// relayAIStream 将 in 转发到 out，run 退出后转发剩余数据并关闭 out
+// 客户端断开后继续读取 in 并丢弃，避免服务阻塞在写入上
+func relayAIStream(ctx context.Context, in <-chan model.ChatChunk, out chan<- model.ChatChunk, finished <-chan bool) {
+	defer close(out)
+
+	send := func(chunk model.ChatChunk) {
+		// 断开后 out 可能仍有缓冲空间，优先丢弃，不再向客户端转发
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case out <- chunk:
+		case <-ctx.Done():
+		}
+	}
+
+	for {
+		select {
+		case chunk, ok := <-in:
+			if !ok {
+				// 等待 run 退出以确认是否 panic
+				in = nil
+				continue
+			}
+			send(chunk)
+
+		case panicked := <-finished:
+			// run 已退出，in 中只剩已缓冲的数据
+			for in != nil {
+				select {
+				case chunk, ok := <-in:
+					if !ok {
+						in = nil
+						continue
+					}
+					send(chunk)
+				default:
+					in = nil
+				}
+			}
+			if panicked {
+				send(model.ChatChunk{Type: model.ChunkTypeError, Message: streamPanicMessage})
+			}
+			return
+		}
+	}
+}
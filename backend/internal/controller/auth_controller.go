@@ -42,9 +42,11 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		case errors.Is(err, service.ErrInvalidEmail):
 			response.BadRequest(ctx, "Invalid email format")
 		case errors.Is(err, service.ErrWeakPassword):
-			response.BadRequest(ctx, "Password must be at least 8 characters with letters and numbers")
+			response.BadRequest(ctx, weakPasswordMessage(err))
 		case errors.Is(err, repository.ErrUserExists):
 			response.Conflict(ctx, "Email already registered")
+		case errors.Is(err, service.ErrCodeResendTooSoon):
+			response.RateLimited(ctx, "Verification code requested too frequently, please try again later")
 		default:
 			c.logger.Error("Register failed", zap.Error(err))
 			response.InternalError(ctx, "Registration failed")
@@ -63,13 +65,17 @@ func (c *AuthController) VerifyEmail(ctx *gin.Context) {
 		return
 	}
 
-	_, err := c.authService.VerifyEmail(ctx.Request.Context(), req.Email, req.Code)
+	user, err := c.authService.VerifyEmail(ctx.Request.Context(), req.Email, req.Code)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrInvalidCode):
 			response.BadRequest(ctx, "Invalid verification code")
 		case errors.Is(err, service.ErrCodeExpired):
 			response.BadRequest(ctx, "Verification code expired")
+		case errors.Is(err, service.ErrRegistrationExpired):
+			response.BadRequest(ctx, "Registration expired, please register again")
+		case errors.Is(err, repository.ErrUserExists):
+			response.Conflict(ctx, "Email already registered")
 		default:
 			c.logger.Error("VerifyEmail failed", zap.Error(err))
 			response.InternalError(ctx, "Verification failed")
@@ -77,7 +83,7 @@ func (c *AuthController) VerifyEmail(ctx *gin.Context) {
 		return
 	}
 
-	response.SuccessWithMessage(ctx, "Email verified successfully", nil)
+	response.SuccessWithMessage(ctx, "Email verified successfully", user)
 }
 
 // Login 用户登录
@@ -143,6 +149,8 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 			response.Unauthorized(ctx, "Invalid refresh token")
 		case errors.Is(err, service.ErrTokenExpired):
 			response.Unauthorized(ctx, "Refresh token expired")
+		case errors.Is(err, service.ErrTokenBlacklisted), errors.Is(err, repository.ErrUserNotFound):
+			response.Unauthorized(ctx, "Refresh token revoked")
 		default:
 			c.logger.Error("RefreshToken failed", zap.Error(err))
 			response.InternalError(ctx, "Token refresh failed")
@@ -162,6 +170,11 @@ func (c *AuthController) ForgotPassword(ctx *gin.Context) {
 	}
 
 	err := c.authService.ForgotPassword(ctx.Request.Context(), req.Email)
+	if errors.Is(err, service.ErrCodeResendTooSoon) {
+		// 限流在检查邮箱是否存在之前进行，返回 429 不会暴露邮箱是否注册
+		response.RateLimited(ctx, "Verification code requested too frequently, please try again later")
+		return
+	}
 	if err != nil {
 		c.logger.Error("ForgotPassword failed", zap.Error(err))
 		// 为了安全，不暴露具体错误
@@ -187,7 +200,7 @@ func (c *AuthController) ResetPassword(ctx *gin.Context) {
 		case errors.Is(err, service.ErrCodeExpired):
 			response.BadRequest(ctx, "Verification code expired")
 		case errors.Is(err, service.ErrWeakPassword):
-			response.BadRequest(ctx, "Password must be at least 8 characters with letters and numbers")
+			response.BadRequest(ctx, weakPasswordMessage(err))
 		default:
 			c.logger.Error("ResetPassword failed", zap.Error(err))
 			response.InternalError(ctx, "Password reset failed")
@@ -211,3 +224,40 @@ func (c *AuthController) GetCurrentUser(ctx *gin.Context) {
 
 	response.Success(ctx, user)
 }
+
+// DeleteAccount 删除账号
+// DELETE /api/v1/auth/account
+func (c *AuthController) DeleteAccount(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+
+	var req model.DeleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(ctx, "Invalid request body")
+		return
+	}
+
+	err := c.authService.DeleteAccount(ctx.Request.Context(), userID, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			response.Unauthorized(ctx, "Invalid password")
+		case errors.Is(err, repository.ErrUserNotFound):
+			response.NotFound(ctx, "User not found")
+		default:
+			c.logger.Error("DeleteAccount failed", zap.Error(err), zap.Int64("userID", userID))
+			response.InternalError(ctx, "Failed to delete account")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "Account deleted successfully", nil)
+}
+
+// weakPasswordMessage 返回密码未满足的具体规则，便于客户端提示用户
+func weakPasswordMessage(err error) string {
+	var weak *service.WeakPasswordError
+	if errors.As(err, &weak) {
+		return "Password " + weak.Reason
+	}
+	return "Password does not meet strength requirements"
+}
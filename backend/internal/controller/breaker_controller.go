@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"errors"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/middleware"
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BreakerController 数据源熔断器状态控制器
+type BreakerController struct {
+	cbManager *crawler.CircuitBreakerManager
+	logger    *zap.Logger
+}
+
+// NewBreakerController 创建熔断器状态控制器
+func NewBreakerController(cbManager *crawler.CircuitBreakerManager, logger *zap.Logger) *BreakerController {
+	return &BreakerController{
+		cbManager: cbManager,
+		logger:    logger,
+	}
+}
+
+// GetBreakers 获取所有数据源熔断器的状态、失败次数与最近状态变化时间（按名称排序）
+// GET /api/v1/admin/breakers
+func (c *BreakerController) GetBreakers(ctx *gin.Context) {
+	response.Success(ctx, c.cbManager.Snapshot())
+}
+
+// ResetBreaker 重置数据源熔断器：立即恢复为关闭状态、清空失败计数并取消人工干预
+// POST /api/v1/admin/breakers/:name/reset
+func (c *BreakerController) ResetBreaker(ctx *gin.Context) {
+	name := ctx.Param("name")
+	status, err := c.cbManager.Reset(name)
+	if err != nil {
+		if errors.Is(err, crawler.ErrBreakerNotFound) {
+			response.NotFound(ctx, "Circuit breaker not found")
+			return
+		}
+		c.logger.Error("ResetBreaker failed", zap.Error(err), zap.String("breaker", name))
+		response.InternalError(ctx, "Failed to reset breaker")
+		return
+	}
+
+	c.logger.Warn("Circuit breaker reset",
+		zap.Int64("userID", middleware.GetUserID(ctx)),
+		zap.String("breaker", name),
+	)
+	response.Success(ctx, status)
+}
+
+// SetOverride 人工干预数据源熔断器
+// POST /api/v1/admin/breaker/:name（别名 POST /api/v1/admin/breakers/:name/override）
+// state 为 open 时强制熔断（不再请求上游，使用缓存），closed 时强制放行，auto 恢复自动熔断
+func (c *BreakerController) SetOverride(ctx *gin.Context) {
+	var req struct {
+		State string `json:"state" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(ctx, "Invalid request body")
+		return
+	}
+	override, err := crawler.ParseBreakerOverride(req.State)
+	if err != nil {
+		response.BadRequest(ctx, "state must be open, closed or auto")
+		return
+	}
+
+	name := ctx.Param("name")
+	status, err := c.cbManager.SetOverride(name, override)
+	if err != nil {
+		if errors.Is(err, crawler.ErrBreakerNotFound) {
+			response.NotFound(ctx, "Circuit breaker not found")
+			return
+		}
+		c.logger.Error("SetOverride failed", zap.Error(err), zap.String("breaker", name))
+		response.InternalError(ctx, "Failed to set breaker override")
+		return
+	}
+
+	c.logger.Warn("Circuit breaker overridden",
+		zap.Int64("userID", middleware.GetUserID(ctx)),
+		zap.String("breaker", name),
+		zap.String("override", override.String()),
+	)
+	response.Success(ctx, status)
+}
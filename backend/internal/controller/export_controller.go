@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"fund-analyzer/internal/middleware"
+	"fund-analyzer/internal/service"
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ExportController 数据导出控制器
+type ExportController struct {
+	exportService service.ExportService
+	logger        *zap.Logger
+}
+
+// NewExportController 创建数据导出控制器
+func NewExportController(exportService service.ExportService, logger *zap.Logger) *ExportController {
+	return &ExportController{
+		exportService: exportService,
+		logger:        logger,
+	}
+}
+
+// Export 导出当前用户的全部数据
+// GET /api/v1/auth/export
+func (c *ExportController) Export(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+
+	filename := fmt.Sprintf("fundflow-export-%d-%s.json", userID, time.Now().Format("20060102"))
+	ctx.Header("Content-Type", "application/json; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	cw := &countingWriter{w: ctx.Writer}
+	if err := c.exportService.ExportUserData(ctx.Request.Context(), userID, cw); err != nil {
+		c.logger.Error("Export failed", zap.Error(err), zap.Int64("userID", userID))
+		if cw.n == 0 {
+			ctx.Header("Content-Disposition", "")
+			response.InternalError(ctx, "Failed to export user data")
+			return
+		}
+		// 已开始写出，无法再返回错误响应，中断连接让客户端感知不完整
+		// 附件不经压缩中间件缓冲，此前写出的内容已发送；Recovery 会放行该 panic，由 net/http 关闭连接
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// countingWriter 记录已写出的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
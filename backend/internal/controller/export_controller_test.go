@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fund-analyzer/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// failingExportService 写出部分内容后失败
+type failingExportService struct {
+	partial string
+}
+
+func (s failingExportService) ExportUserData(ctx context.Context, userID int64, w io.Writer) error {
+	if s.partial != "" {
+		if _, err := io.WriteString(w, s.partial); err != nil {
+			return err
+		}
+	}
+	return errors.New("database unavailable")
+}
+
+func newExportServer(t *testing.T, exportService failingExportService) *httptest.Server {
+	t.Helper()
+	r := gin.New()
+	r.Use(middleware.Recovery(zap.NewNop()))
+	r.Use(middleware.Compress(middleware.DefaultCompressionConfig()))
+	r.GET("/export", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, int64(1))
+	}, NewExportController(exportService, zap.NewNop()).Export)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getExport(t *testing.T, srv *httptest.Server) (*http.Response, []byte, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/export", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+func TestExport_FailureBeforeWriteReturnsError(t *testing.T) {
+	srv := newExportServer(t, failingExportService{})
+
+	resp, body, err := getExport(t, srv)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Disposition"))
+	assert.Contains(t, string(body), "Failed to export user data")
+}
+
+func TestExport_MidStreamFailureAbortsConnection(t *testing.T) {
+	srv := newExportServer(t, failingExportService{partial: `{"user":{"id":1},"funds":[`})
+
+	_, body, err := getExport(t, srv)
+
+	// 连接被中断：客户端收到连接错误（可能读到截断的正文），而不是看似完整的文件
+	assert.Error(t, err)
+	assert.NotContains(t, string(body), "Internal server error")
+}
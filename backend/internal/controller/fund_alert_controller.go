@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"fund-analyzer/internal/middleware"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/service"
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FundAlertController 基金估值提醒控制器
+type FundAlertController struct {
+	alertService service.FundAlertService
+	logger       *zap.Logger
+}
+
+// NewFundAlertController 创建基金估值提醒控制器
+func NewFundAlertController(alertService service.FundAlertService, logger *zap.Logger) *FundAlertController {
+	return &FundAlertController{
+		alertService: alertService,
+		logger:       logger,
+	}
+}
+
+// ListAlerts 获取基金的估值提醒
+// GET /api/v1/funds/:code/alerts
+func (c *FundAlertController) ListAlerts(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+	code := ctx.Param("code")
+
+	alerts, err := c.alertService.List(ctx.Request.Context(), userID, code)
+	if err != nil {
+		c.handleError(ctx, "ListAlerts", err, userID, code)
+		return
+	}
+
+	response.Success(ctx, alerts)
+}
+
+// CreateAlert 创建估值提醒
+// POST /api/v1/funds/:code/alerts
+func (c *FundAlertController) CreateAlert(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+	code := ctx.Param("code")
+
+	var req model.FundAlertRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(ctx, "Invalid request body")
+		return
+	}
+
+	alert, err := c.alertService.Create(ctx.Request.Context(), userID, code, &req)
+	if err != nil {
+		c.handleError(ctx, "CreateAlert", err, userID, code)
+		return
+	}
+
+	response.Success(ctx, alert)
+}
+
+// UpdateAlert 修改估值提醒
+// PUT /api/v1/funds/:code/alerts/:id
+func (c *FundAlertController) UpdateAlert(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+	code := ctx.Param("code")
+
+	alertID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(ctx, "Invalid alert ID")
+		return
+	}
+
+	var req model.FundAlertRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(ctx, "Invalid request body")
+		return
+	}
+
+	alert, err := c.alertService.Update(ctx.Request.Context(), userID, code, alertID, &req)
+	if err != nil {
+		c.handleError(ctx, "UpdateAlert", err, userID, code)
+		return
+	}
+
+	response.Success(ctx, alert)
+}
+
+// DeleteAlert 删除估值提醒
+// DELETE /api/v1/funds/:code/alerts/:id
+func (c *FundAlertController) DeleteAlert(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+	code := ctx.Param("code")
+
+	alertID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(ctx, "Invalid alert ID")
+		return
+	}
+
+	if err := c.alertService.Delete(ctx.Request.Context(), userID, code, alertID); err != nil {
+		c.handleError(ctx, "DeleteAlert", err, userID, code)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "Alert deleted", nil)
+}
+
+// handleError 将服务错误转换为响应
+func (c *FundAlertController) handleError(ctx *gin.Context, action string, err error, userID int64, code string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidFundCode):
+		response.BadRequest(ctx, "Invalid fund code")
+	case errors.Is(err, service.ErrInvalidFundAlert), errors.Is(err, service.ErrTooManyFundAlerts):
+		response.BadRequest(ctx, err.Error())
+	case errors.Is(err, service.ErrFundNotFound):
+		response.NotFound(ctx, "Fund not found")
+	case errors.Is(err, service.ErrFundAlertNotFound):
+		response.NotFound(ctx, "Alert not found")
+	default:
+		c.logger.Error(action+" failed", zap.Error(err), zap.Int64("userID", userID), zap.String("code", code))
+		response.InternalError(ctx, "Failed to process fund alert")
+	}
+}
@@ -2,6 +2,8 @@ package controller
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"fund-analyzer/internal/middleware"
 	"fund-analyzer/internal/repository"
@@ -59,6 +61,10 @@ func (c *FundController) AddFund(ctx *gin.Context) {
 		switch {
 		case errors.Is(err, service.ErrFundExists):
 			response.Conflict(ctx, "Fund already exists")
+		case errors.Is(err, service.ErrFundNotAllowed):
+			response.Forbidden(ctx, "Fund is not allowed")
+		case errors.Is(err, service.ErrInvalidFundCode):
+			response.BadRequest(ctx, "Invalid fund code")
 		default:
 			c.logger.Error("AddFund failed", zap.Error(err), zap.String("code", req.Code))
 			response.BadRequest(ctx, "Invalid fund code")
@@ -77,7 +83,11 @@ func (c *FundController) DeleteFund(ctx *gin.Context) {
 
 	err := c.fundService.DeleteFund(ctx.Request.Context(), userID, code)
 	if err != nil {
-		if errors.Is(err, repository.ErrFundNotFound) {
+		if errors.Is(err, service.ErrInvalidFundCode) {
+			response.BadRequest(ctx, "Invalid fund code")
+			return
+		}
+		if errors.Is(err, service.ErrFundNotFound) {
 			response.NotFound(ctx, "Fund not found")
 			return
 		}
@@ -105,7 +115,11 @@ func (c *FundController) UpdateHoldStatus(ctx *gin.Context) {
 
 	err := c.fundService.UpdateHoldStatus(ctx.Request.Context(), userID, code, req.IsHold)
 	if err != nil {
-		if errors.Is(err, repository.ErrFundNotFound) {
+		if errors.Is(err, service.ErrInvalidFundCode) {
+			response.BadRequest(ctx, "Invalid fund code")
+			return
+		}
+		if errors.Is(err, service.ErrFundNotFound) {
 			response.NotFound(ctx, "Fund not found")
 			return
 		}
@@ -133,7 +147,11 @@ func (c *FundController) UpdateSectors(ctx *gin.Context) {
 
 	err := c.fundService.UpdateSectors(ctx.Request.Context(), userID, code, req.Sectors)
 	if err != nil {
-		if errors.Is(err, repository.ErrFundNotFound) {
+		if errors.Is(err, service.ErrInvalidFundCode) {
+			response.BadRequest(ctx, "Invalid fund code")
+			return
+		}
+		if errors.Is(err, service.ErrFundNotFound) {
 			response.NotFound(ctx, "Fund not found")
 			return
 		}
@@ -152,6 +170,10 @@ func (c *FundController) GetValuation(ctx *gin.Context) {
 
 	// 先搜索基金获取 fundKey
 	fund, err := c.fundService.SearchFund(ctx.Request.Context(), code)
+	if errors.Is(err, service.ErrInvalidFundCode) {
+		response.BadRequest(ctx, "Invalid fund code")
+		return
+	}
 	if err != nil {
 		response.NotFound(ctx, "Fund not found")
 		return
@@ -166,3 +188,77 @@ func (c *FundController) GetValuation(ctx *gin.Context) {
 
 	response.Success(ctx, valuation)
 }
+
+// GetHistory 获取基金历史净值
+// GET /api/v1/funds/:code/history?period=6m
+func (c *FundController) GetHistory(ctx *gin.Context) {
+	code := ctx.Param("code")
+	period := ctx.DefaultQuery("period", service.DefaultFundHistoryPeriod)
+
+	history, err := c.fundService.GetFundHistory(ctx.Request.Context(), code, period)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidHistoryPeriod):
+			response.BadRequest(ctx, fmt.Sprintf("period must be one of %s", strings.Join(service.FundHistoryPeriods, ", ")))
+		case errors.Is(err, service.ErrInvalidFundCode):
+			response.BadRequest(ctx, "Invalid fund code")
+		case errors.Is(err, service.ErrFundNotFound):
+			response.NotFound(ctx, "Fund not found")
+		default:
+			c.logger.Error("GetHistory failed", zap.Error(err), zap.String("code", code), zap.String("period", period))
+			response.InternalError(ctx, "Failed to get fund history")
+		}
+		return
+	}
+
+	response.Success(ctx, history)
+}
+
+// GetValuationsBatch 批量获取基金估值
+// POST /api/v1/funds/valuations
+func (c *FundController) GetValuationsBatch(ctx *gin.Context) {
+	var req struct {
+		Codes []string `json:"codes" binding:"required,min=1"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(ctx, "Invalid request body")
+		return
+	}
+
+	batch, err := c.fundService.GetValuationsBatch(ctx.Request.Context(), req.Codes)
+	if err != nil {
+		if errors.Is(err, service.ErrValuationBatchTooLarge) {
+			response.BadRequest(ctx, fmt.Sprintf("At most %d fund codes per request", service.MaxValuationBatchSize))
+			return
+		}
+		c.logger.Error("GetValuationsBatch failed", zap.Error(err), zap.Int("codes", len(req.Codes)))
+		response.InternalError(ctx, "Failed to get valuations")
+		return
+	}
+
+	response.Success(ctx, batch)
+}
+
+// GetRelated 获取相关基金推荐
+// GET /api/v1/funds/:code/related
+func (c *FundController) GetRelated(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+	code := ctx.Param("code")
+
+	funds, err := c.fundService.GetRelated(ctx.Request.Context(), userID, code)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFundCode) {
+			response.BadRequest(ctx, "Invalid fund code")
+			return
+		}
+		if errors.Is(err, repository.ErrFundNotFound) {
+			response.NotFound(ctx, "Fund not found")
+			return
+		}
+		c.logger.Error("GetRelated failed", zap.Error(err), zap.String("code", code))
+		response.InternalError(ctx, "Failed to get related funds")
+		return
+	}
+
+	response.Success(ctx, funds)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeFundService 只有 000001 的历史净值，区间校验与真实服务一致
+type fakeFundService struct {
+	service.FundService
+	periods []string
+}
+
+func (s *fakeFundService) GetFundHistory(ctx context.Context, code string, period string) ([]model.FundPoint, error) {
+	s.periods = append(s.periods, period)
+	switch {
+	case period == "2y":
+		return nil, fmt.Errorf("%w: %q", service.ErrInvalidHistoryPeriod, period)
+	case code == "abc":
+		return nil, service.ErrInvalidFundCode
+	case code != "000001":
+		return nil, service.ErrFundNotFound
+	}
+	return []model.FundPoint{{Date: "2024-01-02", Value: "1.0100"}}, nil
+}
+
+func TestGetHistory(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+	}{
+		{"default period", "/funds/000001/history", http.StatusOK, `"value":"1.0100"`},
+		{"explicit period", "/funds/000001/history?period=1y", http.StatusOK, `"date":"2024-01-02"`},
+		{"unknown period", "/funds/000001/history?period=2y", http.StatusBadRequest, "period must be one of 1m, 3m, 6m, 1y, 3y, 5y, all"},
+		{"invalid code", "/funds/abc/history", http.StatusBadRequest, "Invalid fund code"},
+		{"unknown fund", "/funds/999999/history", http.StatusNotFound, "Fund not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/funds/:code/history", NewFundController(&fakeFundService{}, zap.NewNop()).GetHistory)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.body)
+		})
+	}
+}
+
+func TestGetHistory_DefaultPeriod(t *testing.T) {
+	funds := &fakeFundService{}
+	r := gin.New()
+	r.GET("/funds/:code/history", NewFundController(funds, zap.NewNop()).GetHistory)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/funds/000001/history", nil))
+
+	assert.Equal(t, []string{service.DefaultFundHistoryPeriod}, funds.periods)
+}
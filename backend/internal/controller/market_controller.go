@@ -51,11 +51,18 @@ func (c *MarketController) GetPreciousMetals(ctx *gin.Context) {
 }
 
 // GetGoldHistory 获取历史金价
-// GET /api/v1/market/gold-history?days=30
+// GET /api/v1/market/gold-history?days=30&mode=calendar
+// mode 为 trading（默认，最近 N 个交易日）或 calendar（最近 N 个自然日）
 func (c *MarketController) GetGoldHistory(ctx *gin.Context) {
 	days, _ := strconv.Atoi(ctx.DefaultQuery("days", "30"))
 
-	history, err := c.marketService.GetGoldHistory(ctx.Request.Context(), days)
+	mode, err := service.ParseGoldHistoryMode(ctx.Query("mode"))
+	if err != nil {
+		response.BadRequest(ctx, err.Error())
+		return
+	}
+
+	history, err := c.marketService.GetGoldHistory(ctx.Request.Context(), days, mode)
 	if err != nil {
 		c.logger.Error("GetGoldHistory failed", zap.Error(err))
 		response.InternalError(ctx, "Failed to get gold history")
@@ -81,11 +88,17 @@ func (c *MarketController) GetVolumeTrend(ctx *gin.Context) {
 }
 
 // GetMinuteData 获取上证分时数据
-// GET /api/v1/market/minute-data?minutes=30
+// GET /api/v1/market/minute-data?minutes=30&granularity=5m
 func (c *MarketController) GetMinuteData(ctx *gin.Context) {
 	minutes, _ := strconv.Atoi(ctx.DefaultQuery("minutes", "30"))
 
-	data, err := c.marketService.GetMinuteData(ctx.Request.Context(), minutes)
+	granularity, err := service.ParseMinuteGranularity(ctx.Query("granularity"))
+	if err != nil {
+		response.BadRequest(ctx, err.Error())
+		return
+	}
+
+	data, err := c.marketService.GetMinuteData(ctx.Request.Context(), minutes, granularity)
 	if err != nil {
 		c.logger.Error("GetMinuteData failed", zap.Error(err))
 		response.InternalError(ctx, "Failed to get minute data")
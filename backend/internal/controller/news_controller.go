@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 
 	"fund-analyzer/internal/service"
@@ -24,17 +25,35 @@ func NewNewsController(newsService service.NewsService, logger *zap.Logger) *New
 	}
 }
 
-// GetNews 获取快讯列表
-// GET /api/v1/news?count=50
+// GetNews 分页获取快讯列表
+// GET /api/v1/news?count=50&offset=0&sort=desc&evaluate=利好&entity=600519
+// 响应体保持为快讯数组，是否可能还有更早的快讯通过 X-Has-More 响应头提示
 func (c *NewsController) GetNews(ctx *gin.Context) {
-	count, _ := strconv.Atoi(ctx.DefaultQuery("count", "50"))
+	count, _ := strconv.Atoi(ctx.DefaultQuery("count", strconv.Itoa(service.DefaultNewsLimit)))
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.BadRequest(ctx, "offset must be a non-negative integer")
+		return
+	}
+
+	page, err := c.newsService.GetNews(ctx.Request.Context(), service.NewsQuery{
+		Offset: offset,
+		Limit:  count,
+		Sort:   ctx.Query("sort"),
 
-	news, err := c.newsService.GetNewsList(ctx.Request.Context(), count)
+		Evaluate: ctx.Query("evaluate"),
+		Entity:   ctx.Query("entity"),
+	})
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidNewsQuery) {
+			response.BadRequest(ctx, err.Error())
+			return
+		}
 		c.logger.Error("GetNews failed", zap.Error(err))
 		response.InternalError(ctx, "Failed to get news")
 		return
 	}
 
-	response.Success(ctx, news)
+	ctx.Header("X-Has-More", strconv.FormatBool(page.HasMore))
+	response.Success(ctx, page.Items)
 }
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeNewsService 记录收到的查询，sort 校验与真实服务一致
+type fakeNewsService struct {
+	queries []service.NewsQuery
+}
+
+func (s *fakeNewsService) GetNews(ctx context.Context, query service.NewsQuery) (*service.NewsPage, error) {
+	s.queries = append(s.queries, query)
+	if query.Sort != "" && query.Sort != service.NewsSortAsc && query.Sort != service.NewsSortDesc {
+		return nil, fmt.Errorf("%w: sort must be asc or desc", service.ErrInvalidNewsQuery)
+	}
+	return &service.NewsPage{Items: []model.NewsItem{{ID: "1"}}, HasMore: query.Offset == 0}, nil
+}
+
+func TestGetNews(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		query   *service.NewsQuery
+		hasMore string
+	}{
+		{"defaults", "/news", http.StatusOK, &service.NewsQuery{Limit: 50}, "true"},
+		{"paged and sorted", "/news?count=20&offset=40&sort=asc", http.StatusOK, &service.NewsQuery{Offset: 40, Limit: 20, Sort: "asc"}, "false"},
+		{"filters", "/news?evaluate=%E5%88%A9%E5%A5%BD&entity=600519", http.StatusOK, &service.NewsQuery{Limit: 50, Evaluate: "利好", Entity: "600519"}, "true"},
+		{"invalid offset", "/news?offset=abc", http.StatusBadRequest, nil, ""},
+		{"invalid sort", "/news?sort=newest", http.StatusBadRequest, &service.NewsQuery{Limit: 50, Sort: "newest"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news := &fakeNewsService{}
+			r := gin.New()
+			r.GET("/news", NewNewsController(news, zap.NewNop()).GetNews)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.hasMore, w.Header().Get("X-Has-More"))
+			if tt.query == nil {
+				assert.Empty(t, news.queries)
+			} else {
+				assert.Equal(t, []service.NewsQuery{*tt.query}, news.queries)
+			}
+			if tt.status == http.StatusOK {
+				assert.JSONEq(t, `{"code":0,"message":"success","data":[{"id":"1","title":"","content":"","evaluate":"","publishTime":0,"entities":null,"publishTimeText":""}]}`, w.Body.String())
+			}
+		})
+	}
+}
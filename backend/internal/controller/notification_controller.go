@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"errors"
+
+	"fund-analyzer/internal/middleware"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/service"
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// NotificationController 通知设置控制器
+type NotificationController struct {
+	quietHoursService service.QuietHoursService
+	logger            *zap.Logger
+}
+
+// NewNotificationController 创建通知设置控制器
+func NewNotificationController(quietHoursService service.QuietHoursService, logger *zap.Logger) *NotificationController {
+	return &NotificationController{
+		quietHoursService: quietHoursService,
+		logger:            logger,
+	}
+}
+
+// GetQuietHours 获取免打扰时段
+// GET /api/v1/notifications/quiet-hours
+func (c *NotificationController) GetQuietHours(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+
+	quietHours, err := c.quietHoursService.Get(ctx.Request.Context(), userID)
+	if err != nil {
+		c.logger.Error("GetQuietHours failed", zap.Error(err), zap.Int64("userID", userID))
+		response.InternalError(ctx, "Failed to get quiet hours")
+		return
+	}
+
+	response.Success(ctx, quietHours)
+}
+
+// UpdateQuietHours 更新免打扰时段
+// PUT /api/v1/notifications/quiet-hours
+func (c *NotificationController) UpdateQuietHours(ctx *gin.Context) {
+	userID := middleware.GetUserID(ctx)
+
+	var req model.UpdateQuietHoursRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(ctx, "Invalid request body")
+		return
+	}
+
+	quietHours, err := c.quietHoursService.Update(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidQuietHours) {
+			response.BadRequest(ctx, err.Error())
+			return
+		}
+		c.logger.Error("UpdateQuietHours failed", zap.Error(err), zap.Int64("userID", userID))
+		response.InternalError(ctx, "Failed to update quiet hours")
+		return
+	}
+
+	response.Success(ctx, quietHours)
+}
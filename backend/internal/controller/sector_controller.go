@@ -1,6 +1,11 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fund-analyzer/internal/crawler"
 	"fund-analyzer/internal/service"
 	"fund-analyzer/pkg/response"
 
@@ -23,10 +28,24 @@ func NewSectorController(sectorService service.SectorService, logger *zap.Logger
 }
 
 // GetSectors 获取板块列表
-// GET /api/v1/sectors?sort=changeRate&order=desc
+// GET /api/v1/sectors?sort=changeRate&order=desc&category=科技
 func (c *SectorController) GetSectors(ctx *gin.Context) {
 	sortField := ctx.DefaultQuery("sort", "changeRate")
 	order := ctx.DefaultQuery("order", "desc")
+	category := ctx.Query("category")
+
+	if !service.IsSectorSortField(sortField) {
+		response.BadRequest(ctx, fmt.Sprintf("sort must be one of %s", strings.Join(service.SectorSortFields, ", ")))
+		return
+	}
+	if order != "asc" && order != "desc" {
+		response.BadRequest(ctx, "order must be asc or desc")
+		return
+	}
+	if _, ok := c.sectorService.GetSectorCategories()[category]; category != "" && !ok {
+		response.BadRequest(ctx, fmt.Sprintf("unknown category %q, see /api/v1/sectors/categories", category))
+		return
+	}
 
 	sectors, err := c.sectorService.GetSectorList(ctx.Request.Context())
 	if err != nil {
@@ -35,6 +54,11 @@ func (c *SectorController) GetSectors(ctx *gin.Context) {
 		return
 	}
 
+	// 按大类筛选
+	if category != "" {
+		sectors = service.FilterSectorsByCategory(sectors, category)
+	}
+
 	// 排序
 	descending := order == "desc"
 	sectors = c.sectorService.SortSectors(sectors, sortField, descending)
@@ -51,6 +75,16 @@ func (c *SectorController) GetSectorFunds(ctx *gin.Context) {
 
 	funds, err := c.sectorService.GetSectorFunds(ctx.Request.Context(), sectorID)
 	if err != nil {
+		// 上游返回拦截页等非 JSON 内容属于暂时不可用，记录响应样本便于排查
+		var nonJSON *crawler.NonJSONResponseError
+		if errors.As(err, &nonJSON) {
+			c.logger.Warn("GetSectorFunds upstream returned non-JSON response",
+				zap.String("sectorID", sectorID),
+				zap.String("sample", nonJSON.Sample),
+			)
+			response.ServiceUnavailable(ctx, "Sector funds source temporarily unavailable")
+			return
+		}
 		c.logger.Error("GetSectorFunds failed", zap.Error(err), zap.String("sectorID", sectorID))
 		response.InternalError(ctx, "Failed to get sector funds")
 		return
@@ -69,3 +103,16 @@ func (c *SectorController) GetCategories(ctx *gin.Context) {
 	categories := c.sectorService.GetSectorCategories()
 	response.Success(ctx, categories)
 }
+
+// GetHeatmap 获取板块热力图
+// GET /api/v1/sectors/heatmap
+func (c *SectorController) GetHeatmap(ctx *gin.Context) {
+	heatmap, err := c.sectorService.GetHeatmap(ctx.Request.Context())
+	if err != nil {
+		c.logger.Error("GetHeatmap failed", zap.Error(err))
+		response.InternalError(ctx, "Failed to get sector heatmap")
+		return
+	}
+
+	response.Success(ctx, heatmap)
+}
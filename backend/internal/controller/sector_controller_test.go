@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeSectorList 返回预设板块列表，排序与分类使用真实服务
+type fakeSectorList struct {
+	service.SectorService
+	sectors []model.Sector
+	calls   int
+}
+
+func (s *fakeSectorList) GetSectorList(ctx context.Context) ([]model.Sector, error) {
+	s.calls++
+	return s.sectors, nil
+}
+
+func TestGetSectors(t *testing.T) {
+	sectors := []model.Sector{
+		{Name: "半导体", ChangeRate: "+1.20%", MainNetInflow: "-3.50亿", MainInflowRatio: "-2.10%"},
+		{Name: "白酒", ChangeRate: "-0.80%", MainNetInflow: "12.30亿", MainInflowRatio: "4.50%"},
+		{Name: "银行", ChangeRate: "+0.30%", MainNetInflow: "5000.00万", MainInflowRatio: "0.20%"},
+		{Name: "软件开发", ChangeRate: "+2.50%", MainNetInflow: "1.10亿", MainInflowRatio: "1.30%"},
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		names []string
+	}{
+		{"default change rate desc", "/sectors", []string{"软件开发", "半导体", "银行", "白酒"}},
+		{"change rate asc", "/sectors?order=asc", []string{"白酒", "银行", "半导体", "软件开发"}},
+		{"main net inflow desc", "/sectors?sort=mainNetInflow", []string{"白酒", "软件开发", "银行", "半导体"}},
+		{"main inflow ratio asc", "/sectors?sort=mainInflowRatio&order=asc", []string{"半导体", "银行", "软件开发", "白酒"}},
+		{"category", "/sectors?category=%E7%A7%91%E6%8A%80", []string{"软件开发", "半导体"}},
+		{"category with sort", "/sectors?category=%E7%A7%91%E6%8A%80&sort=mainNetInflow&order=asc", []string{"半导体", "软件开发"}},
+		{"category without sectors", "/sectors?category=%E5%86%9C%E4%B8%9A", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &fakeSectorList{SectorService: service.NewSectorService(nil, service.NewMemoryCache()), sectors: sectors}
+			r := gin.New()
+			r.GET("/sectors", NewSectorController(list, zap.NewNop()).GetSectors)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var body struct {
+				Data []model.Sector `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			names := make([]string, len(body.Data))
+			for i, sector := range body.Data {
+				names[i] = sector.Name
+			}
+			assert.Equal(t, tt.names, names)
+		})
+	}
+}
+
+func TestGetSectors_InvalidQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"unknown sort field", "/sectors?sort=volume", "sort must be one of changeRate, mainNetInflow, mainInflowRatio"},
+		{"unknown order", "/sectors?order=DESC", "order must be asc or desc"},
+		{"unknown category", "/sectors?category=unknown", `unknown category \"unknown\"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &fakeSectorList{SectorService: service.NewSectorService(nil, service.NewMemoryCache())}
+			r := gin.New()
+			r.GET("/sectors", NewSectorController(list, zap.NewNop()).GetSectors)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.body)
+			assert.Zero(t, list.calls, "invalid query should not load sectors")
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"fund-analyzer/internal/model"
@@ -154,6 +155,68 @@ func (c *AntCrawler) GetFundCurves(ctx context.Context, productID string, interv
 	return result, err
 }
 
+// GetFundMeta 获取基金经理、规模与成立日期
+func (c *AntCrawler) GetFundMeta(ctx context.Context, productID string) (*model.FundMeta, error) {
+	var result *model.FundMeta
+
+	err := c.breaker.Execute(func() error {
+		url := fmt.Sprintf("%s/api/fund/detail/baseInfo?productId=%s", antBaseURL, productID)
+
+		data, err := c.client.Get(ctx, url, map[string]string{
+			"Referer": "https://www.fund123.cn/",
+		})
+		if err != nil {
+			return err
+		}
+
+		result, err = parseFundMeta(data)
+		return err
+	})
+
+	return result, err
+}
+
+// parseFundMeta 解析基金基本信息，缺失的字段留空
+func parseFundMeta(data []byte) (*model.FundMeta, error) {
+	var resp antBaseInfoResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse response failed: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("get base info failed")
+	}
+
+	var names []string
+	for _, m := range resp.Data.Managers {
+		if name := strings.TrimSpace(m.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	meta := &model.FundMeta{
+		Manager:       strings.Join(names, "、"),
+		InceptionDate: placeholderToEmpty(resp.Data.EstablishDate),
+	}
+	if scale := placeholderToEmpty(strings.Trim(string(resp.Data.FundScale), `"`)); scale != "" && scale != "null" {
+		// 数值单位为亿元，带单位的文本原样保留
+		if _, err := strconv.ParseFloat(scale, 64); err == nil {
+			scale += "亿元"
+		}
+		meta.Scale = scale
+		meta.ScaleDate = placeholderToEmpty(resp.Data.ScaleDate)
+	}
+	return meta, nil
+}
+
+// placeholderToEmpty 将 "--" 等占位符转换为空字符串
+func placeholderToEmpty(s string) string {
+	s = strings.TrimSpace(s)
+	if changePlaceholders[strings.ToUpper(s)] {
+		return ""
+	}
+	return s
+}
+
 // GetFundDetail 获取基金详情（包含估值和历史数据）
 func (c *AntCrawler) GetFundDetail(ctx context.Context, code string) (*FundDetailResult, error) {
 	// 先搜索基金获取 productId
@@ -175,6 +238,16 @@ func (c *AntCrawler) GetFundDetail(ctx context.Context, code string) (*FundDetai
 		curves = nil
 	}
 
+	// 上游未返回连涨/跌天数时根据历史净值计算
+	if valuation.ConsecutiveDays == 0 {
+		valuation.ConsecutiveDays = CalculateConsecutiveDays(curves)
+	}
+
+	// 经理与规模等元数据缺失时不影响主流程
+	if meta, err := c.GetFundMeta(ctx, fundInfo.FundKey); err == nil {
+		fundInfo.FundMeta = *meta
+	}
+
 	return &FundDetailResult{
 		Info:      fundInfo,
 		Valuation: valuation,
@@ -261,6 +334,19 @@ type antValuationResponse struct {
 	} `json:"data"`
 }
 
+type antBaseInfoResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		FundCode string `json:"fundCode"`
+		Managers []struct {
+			Name string `json:"name"`
+		} `json:"managers"`
+		FundScale     json.RawMessage `json:"fundScale"` // 数值或文本
+		ScaleDate     string          `json:"scaleDate"`
+		EstablishDate string          `json:"establishDate"`
+	} `json:"data"`
+}
+
 type antCurvesResponse struct {
 	Success bool `json:"success"`
 	Data    struct {
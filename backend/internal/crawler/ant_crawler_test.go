@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"fmt"
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFundMeta_WithMetadata(t *testing.T) {
+	body := `{"success":true,"data":{"fundCode":"000001","fundName":"华夏成长",
+		"managers":[{"name":"张三"},{"name":" 李四 "}],
+		"fundScale":45.67,"scaleDate":"2024-03-31","establishDate":"2001-12-18"}}`
+
+	meta, err := parseFundMeta([]byte(body))
+
+	require.NoError(t, err)
+	assert.Equal(t, model.FundMeta{
+		Manager:       "张三、李四",
+		Scale:         "45.67亿元",
+		ScaleDate:     "2024-03-31",
+		InceptionDate: "2001-12-18",
+	}, *meta)
+}
+
+func TestParseFundMeta_ScaleAsText(t *testing.T) {
+	body := `{"success":true,"data":{"fundScale":"1.2万亿元","scaleDate":"2024-03-31"}}`
+
+	meta, err := parseFundMeta([]byte(body))
+
+	require.NoError(t, err)
+	assert.Equal(t, "1.2万亿元", meta.Scale)
+	assert.Equal(t, "2024-03-31", meta.ScaleDate)
+}
+
+func TestParseFundMeta_MissingMetadata(t *testing.T) {
+	for _, body := range []string{
+		`{"success":true,"data":{"fundCode":"000001"}}`,
+		`{"success":true,"data":{"managers":[],"fundScale":null,"scaleDate":"2024-03-31","establishDate":"--"}}`,
+		`{"success":true,"data":{"managers":[{"name":""}],"fundScale":"--","establishDate":""}}`,
+	} {
+		meta, err := parseFundMeta([]byte(body))
+
+		require.NoError(t, err, body)
+		assert.True(t, meta.IsEmpty(), body)
+	}
+}
+
+func TestParseFundMeta_Failure(t *testing.T) {
+	_, err := parseFundMeta([]byte(`{"success":false}`))
+	assert.Error(t, err)
+
+	_, err = parseFundMeta([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+// curve 按给定净值构造逐日历史曲线
+func curve(values ...string) []model.FundPoint {
+	points := make([]model.FundPoint, len(values))
+	for i, v := range values {
+		points[i] = model.FundPoint{Date: fmt.Sprintf("2024-03-%02d", i+1), Value: v}
+	}
+	return points
+}
+
+func TestCalculateConsecutiveDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []model.FundPoint
+		want    int
+	}{
+		{"three up days", curve("1.0500", "1.0000", "1.0100", "1.0200", "1.0300"), 3},
+		{"two down days after rally", curve("1.0000", "1.0100", "1.0200", "1.0150", "1.0100"), -2},
+		{"flat last day", curve("1.0000", "1.0100", "1.0100"), 0},
+		{"single point", curve("1.0000"), 0},
+		{"empty", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CalculateConsecutiveDays(tt.history))
+		})
+	}
+}
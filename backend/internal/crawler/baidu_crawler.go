@@ -3,9 +3,8 @@ package crawler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"fund-analyzer/internal/model"
@@ -15,6 +14,13 @@ const (
 	baiduBaseURL = "https://gushitong.baidu.com"
 )
 
+var (
+	// ErrMarketIndicesEmpty 上游正常返回但当前没有指数数据（如休市时段），属于合法状态
+	ErrMarketIndicesEmpty = errors.New("market indices empty")
+	// ErrUnexpectedSchema 上游响应结构与预期不符
+	ErrUnexpectedSchema = errors.New("unexpected response schema")
+)
+
 // BaiduCrawler 百度股市通爬虫
 type BaiduCrawler struct {
 	client  *HTTPClient
@@ -30,8 +36,10 @@ func NewBaiduCrawler(client *HTTPClient, breaker *CircuitBreaker) *BaiduCrawler
 }
 
 // GetMarketIndices 获取市场指数
+// 当前没有数据时返回空切片和 ErrMarketIndicesEmpty
 func (c *BaiduCrawler) GetMarketIndices(ctx context.Context, market string) ([]model.MarketIndex, error) {
 	var result []model.MarketIndex
+	var empty bool
 
 	err := c.breaker.Execute(func() error {
 		// 根据市场类型选择不同的 API
@@ -52,40 +60,73 @@ func (c *BaiduCrawler) GetMarketIndices(ctx context.Context, market string) ([]m
 			return err
 		}
 
-		var resp baiduResponse
-		if err := json.Unmarshal(data, &resp); err != nil {
-			return fmt.Errorf("parse response failed: %w", err)
+		result, err = parseMarketIndices(data)
+		if errors.Is(err, ErrMarketIndicesEmpty) {
+			// 合法的空数据不计入熔断失败
+			empty = true
+			return nil
 		}
+		return err
+	})
 
-		if resp.ResultCode != "0" {
-			return fmt.Errorf("API error: %s", resp.ResultCode)
-		}
+	if err == nil && empty {
+		return []model.MarketIndex{}, ErrMarketIndicesEmpty
+	}
+	return result, err
+}
 
-		for _, item := range resp.Result {
-			for _, stock := range item.List {
-				isUp := !strings.HasPrefix(stock.Increase, "-")
-				result = append(result, model.MarketIndex{
-					Name:      stock.Name,
-					Price:     stock.Price,
-					Change:    stock.Increase,
-					IsUp:      isUp,
-					UpdatedAt: time.Now().Format("15:04:05"),
-				})
-			}
-		}
+// parseMarketIndices 解析百度市场指数响应
+// 各区域列表都存在但为空时返回 ErrMarketIndicesEmpty，缺少 Result 或 list 字段时返回 ErrUnexpectedSchema
+func parseMarketIndices(data []byte) ([]model.MarketIndex, error) {
+	var resp baiduResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse response failed: %w", err)
+	}
 
-		return nil
-	})
+	if resp.ResultCode != "0" {
+		return nil, fmt.Errorf("API error: %s", resp.ResultCode)
+	}
 
-	return result, err
+	if resp.Result == nil {
+		return nil, fmt.Errorf("%w: missing Result", ErrUnexpectedSchema)
+	}
+
+	result := []model.MarketIndex{}
+	for i, item := range resp.Result {
+		if item.List == nil {
+			return nil, fmt.Errorf("%w: Result[%d] missing list", ErrUnexpectedSchema, i)
+		}
+		for _, stock := range *item.List {
+			// 持平与休市占位符（"--"、"—"）不视为上涨
+			_, status, _ := ParseChange(stock.Increase)
+			result = append(result, model.MarketIndex{
+				Name:      stock.Name,
+				Price:     stock.Price,
+				Change:    stock.Increase,
+				IsUp:      status == model.StatusUp,
+				Status:    status,
+				UpdatedAt: time.Now().Format("15:04:05"),
+			})
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, ErrMarketIndicesEmpty
+	}
+	return result, nil
 }
 
-// GetNewsFlash 获取 7×24 快讯
+// GetNewsFlash 获取最新的 7×24 快讯
 func (c *BaiduCrawler) GetNewsFlash(ctx context.Context, count int) ([]model.NewsItem, error) {
+	return c.GetNewsFlashPage(ctx, 0, count)
+}
+
+// GetNewsFlashPage 从第 start 条（按发布时间倒序，0 起）开始获取 count 条 7×24 快讯
+func (c *BaiduCrawler) GetNewsFlashPage(ctx context.Context, start, count int) ([]model.NewsItem, error) {
 	var result []model.NewsItem
 
 	err := c.breaker.Execute(func() error {
-		url := fmt.Sprintf("%s/opendata?resource_id=5388&query=7x24&pn=0&rn=%d&finClientType=pc", baiduBaseURL, count)
+		url := newsFlashURL(start, count)
 
 		data, err := c.client.Get(ctx, url, map[string]string{
 			"Referer": "https://gushitong.baidu.com/",
@@ -114,16 +155,23 @@ func (c *BaiduCrawler) GetNewsFlash(ctx context.Context, count int) ([]model.New
 					})
 				}
 
-				publishTime, _ := strconv.ParseInt(news.PublishTime, 10, 64)
+				newsItem := model.NewsItem{
+					ID:       news.ID,
+					Title:    news.Title,
+					Content:  news.Content,
+					Evaluate: news.Evaluate,
+					Entities: entities,
+				}
 
-				result = append(result, model.NewsItem{
-					ID:          news.ID,
-					Title:       news.Title,
-					Content:     news.Content,
-					Evaluate:    news.Evaluate,
-					PublishTime: publishTime,
-					Entities:    entities,
-				})
+				// 无法解析时显式标记，而不是静默置 0
+				if publishedAt, err := ParsePublishTime(news.PublishTime); err == nil {
+					newsItem.PublishTime = publishedAt.UnixMilli()
+					newsItem.PublishTimeText = publishedAt.Format(PublishTimeLayout)
+				} else {
+					newsItem.PublishTimeInvalid = true
+				}
+
+				result = append(result, newsItem)
 			}
 		}
 
@@ -133,6 +181,11 @@ func (c *BaiduCrawler) GetNewsFlash(ctx context.Context, count int) ([]model.New
 	return result, err
 }
 
+// newsFlashURL 快讯接口地址，pn 为起始条数，rn 为条数
+func newsFlashURL(start, count int) string {
+	return fmt.Sprintf("%s/opendata?resource_id=5388&query=7x24&pn=%d&rn=%d&finClientType=pc", baiduBaseURL, start, count)
+}
+
 // GetMinuteData 获取上证分时数据
 func (c *BaiduCrawler) GetMinuteData(ctx context.Context, code string) ([]model.MinuteData, error) {
 	var result []model.MinuteData
@@ -224,15 +277,17 @@ func (c *BaiduCrawler) GetVolumeTrend(ctx context.Context) ([]model.VolumeTrend,
 type baiduResponse struct {
 	ResultCode string `json:"ResultCode"`
 	Result     []struct {
-		List []struct {
-			Name     string `json:"name"`
-			Code     string `json:"code"`
-			Price    string `json:"price"`
-			Increase string `json:"increase"`
-		} `json:"list"`
+		List *[]baiduIndexItem `json:"list"` // 字段缺失时为 nil，用于区分空列表
 	} `json:"Result"`
 }
 
+type baiduIndexItem struct {
+	Name     string `json:"name"`
+	Code     string `json:"code"`
+	Price    string `json:"price"`
+	Increase string `json:"increase"`
+}
+
 type baiduNewsResponse struct {
 	ResultCode string `json:"ResultCode"`
 	Result     []struct {
@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarketIndices_Populated(t *testing.T) {
+	body := `{"ResultCode":"0","Result":[{"list":[
+		{"name":"上证指数","code":"000001","price":"3050.12","increase":"+0.35%"},
+		{"name":"日经225","code":"N225","price":"38000.50","increase":"-1.20%"}
+	]}]}`
+
+	indices, err := parseMarketIndices([]byte(body))
+
+	require.NoError(t, err)
+	require.Len(t, indices, 2)
+	assert.Equal(t, "上证指数", indices[0].Name)
+	assert.Equal(t, "3050.12", indices[0].Price)
+	assert.True(t, indices[0].IsUp)
+	assert.Equal(t, "日经225", indices[1].Name)
+	assert.False(t, indices[1].IsUp)
+}
+
+func TestParseMarketIndices_ChangeStatus(t *testing.T) {
+	body := `{"ResultCode":"0","Result":[{"list":[
+		{"name":"上涨","price":"1","increase":"+1.20%"},
+		{"name":"下跌","price":"1","increase":"-0.35%"},
+		{"name":"持平","price":"1","increase":"0.00%"},
+		{"name":"持平无百分号","price":"1","increase":"0.00"},
+		{"name":"休市","price":"--","increase":"--"},
+		{"name":"占位","price":"--","increase":"—"}
+	]}]}`
+
+	indices, err := parseMarketIndices([]byte(body))
+
+	require.NoError(t, err)
+	want := []model.ChangeStatus{
+		model.StatusUp, model.StatusDown, model.StatusFlat, model.StatusFlat, model.StatusFlat, model.StatusFlat,
+	}
+	require.Len(t, indices, len(want))
+	for i, idx := range indices {
+		assert.Equal(t, want[i], idx.Status, idx.Name)
+		assert.Equal(t, want[i] == model.StatusUp, idx.IsUp, idx.Name)
+	}
+}
+
+func TestParseMarketIndices_EmptyEnvelope(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty list", `{"ResultCode":"0","Result":[{"list":[]}]}`},
+		{"several empty regions", `{"ResultCode":"0","Result":[{"list":[]},{"list":[]}]}`},
+		{"no regions", `{"ResultCode":"0","Result":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indices, err := parseMarketIndices([]byte(tt.body))
+
+			assert.ErrorIs(t, err, ErrMarketIndicesEmpty)
+			assert.Empty(t, indices)
+		})
+	}
+}
+
+func TestParseMarketIndices_UnexpectedSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing Result", `{"ResultCode":"0","Data":{"list":[{"name":"上证指数"}]}}`},
+		{"null Result", `{"ResultCode":"0","Result":null}`},
+		{"renamed list", `{"ResultCode":"0","Result":[{"items":[{"name":"上证指数"}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMarketIndices([]byte(tt.body))
+
+			assert.ErrorIs(t, err, ErrUnexpectedSchema)
+			assert.NotErrorIs(t, err, ErrMarketIndicesEmpty)
+		})
+	}
+}
+
+func TestParseMarketIndices_APIError(t *testing.T) {
+	_, err := parseMarketIndices([]byte(`{"ResultCode":"1001","Result":[]}`))
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrMarketIndicesEmpty)
+	assert.Contains(t, err.Error(), "1001")
+}
+
+func TestNewsFlashURL_Paging(t *testing.T) {
+	assert.Contains(t, newsFlashURL(0, 50), "&pn=0&rn=50&")
+	assert.Contains(t, newsFlashURL(40, 20), "&pn=40&rn=20&")
+}
@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"fund-analyzer/internal/model"
+)
+
+// changePlaceholders 休市、停牌等无数据时数据源返回的涨跌占位符
+var changePlaceholders = map[string]bool{
+	"":    true,
+	"-":   true,
+	"--":  true,
+	"—":   true,
+	"——":  true,
+	"N/A": true,
+}
+
+// ParseChange 解析涨跌幅或涨跌额字符串（如 "+1.20%"、"-0.35"、"0.00%"）
+// 返回数值与涨跌状态；占位符或无法解析时 ok 为 false，状态为持平
+func ParseChange(raw string) (value float64, status model.ChangeStatus, ok bool) {
+	s := strings.TrimSpace(raw)
+	if changePlaceholders[strings.ToUpper(s)] {
+		return 0, model.StatusFlat, false
+	}
+
+	s = strings.TrimSuffix(s, "%")
+	s = strings.ReplaceAll(s, ",", "")
+	s = strings.Replace(s, "−", "-", 1) // Unicode 减号
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, model.StatusFlat, false
+	}
+
+	switch {
+	case value > 0:
+		return value, model.StatusUp, true
+	case value < 0:
+		return value, model.StatusDown, true
+	default:
+		return 0, model.StatusFlat, true
+	}
+}
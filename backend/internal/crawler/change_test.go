@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChange(t *testing.T) {
+	tests := []struct {
+		raw    string
+		value  float64
+		status model.ChangeStatus
+		ok     bool
+	}{
+		{"+1.20%", 1.2, model.StatusUp, true},
+		{"1.2", 1.2, model.StatusUp, true},
+		{"-0.35%", -0.35, model.StatusDown, true},
+		{"−0.35%", -0.35, model.StatusDown, true},
+		{" -12.50 ", -12.5, model.StatusDown, true},
+		{"1,234.5", 1234.5, model.StatusUp, true},
+		{"0.00", 0, model.StatusFlat, true},
+		{"0.00%", 0, model.StatusFlat, true},
+		{"-0.00%", 0, model.StatusFlat, true},
+		{"--", 0, model.StatusFlat, false},
+		{"—", 0, model.StatusFlat, false},
+		{"-", 0, model.StatusFlat, false},
+		{"", 0, model.StatusFlat, false},
+		{"n/a", 0, model.StatusFlat, false},
+		{"NaN", 0, model.StatusFlat, false},
+		{"abc", 0, model.StatusFlat, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			value, status, ok := ParseChange(tt.raw)
+			assert.InDelta(t, tt.value, value, 1e-9)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package crawler
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -15,8 +17,94 @@ const (
 	StateHalfOpen                     // 半开状态（探测）
 )
 
+// String 状态名称
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	}
+	return "unknown"
+}
+
+// MarshalText 以名称输出状态，如 "open"
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// SourcePriority 数据源优先级
+type SourcePriority int
+
+const (
+	// PriorityCritical 核心数据源（行情、基金等），熔断会影响健康/就绪状态
+	PriorityCritical SourcePriority = iota
+	// PriorityBestEffort 尽力而为的数据源（搜索、网页抓取），熔断只记录日志
+	PriorityBestEffort
+)
+
+// String 优先级名称
+func (p SourcePriority) String() string {
+	if p == PriorityBestEffort {
+		return "best_effort"
+	}
+	return "critical"
+}
+
+// MarshalText 以名称输出优先级，如 "critical"
+func (p SourcePriority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// BreakerOverride 熔断器的人工干预模式
+type BreakerOverride int
+
+const (
+	OverrideAuto        BreakerOverride = iota // 自动（正常按失败次数熔断与恢复）
+	OverrideForceOpen                          // 强制打开：不再请求上游，调用方使用缓存
+	OverrideForceClosed                        // 强制关闭：放行所有请求，失败不触发熔断
+)
+
+// String 干预模式名称，与 ParseBreakerOverride 接受的值一致
+func (o BreakerOverride) String() string {
+	switch o {
+	case OverrideForceOpen:
+		return "open"
+	case OverrideForceClosed:
+		return "closed"
+	}
+	return "auto"
+}
+
+// MarshalText 以名称输出人工干预模式，如 "auto"
+func (o BreakerOverride) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// ParseBreakerOverride 解析干预模式：open、closed 或 auto
+func ParseBreakerOverride(s string) (BreakerOverride, error) {
+	switch s {
+	case "auto":
+		return OverrideAuto, nil
+	case "open":
+		return OverrideForceOpen, nil
+	case "closed":
+		return OverrideForceClosed, nil
+	}
+	return OverrideAuto, fmt.Errorf("%w: %q", ErrInvalidBreakerOverride, s)
+}
+
+// StateChangeHook 熔断器状态变化回调
+type StateChangeHook func(name string, priority SourcePriority, from, to CircuitState)
+
 var (
 	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrBreakerNotFound 指定名称的熔断器不存在
+	ErrBreakerNotFound = errors.New("circuit breaker not found")
+	// ErrInvalidBreakerOverride 无效的干预模式
+	ErrInvalidBreakerOverride = errors.New("invalid breaker override")
 )
 
 // CircuitBreakerConfig 熔断器配置
@@ -45,13 +133,21 @@ type CircuitBreaker struct {
 	successes       int
 	lastFailureTime time.Time
 	halfOpenReqs    int
+	lastUsed        time.Time       // 最近一次获取或请求的时间，用于回收空闲熔断器
+	override        BreakerOverride // 人工干预模式，非 OverrideAuto 时状态不随请求结果变化
+	lastStateChange time.Time       // 最近一次状态变化的时间，未变化过时为创建时间
+
+	onStateChange func(from, to CircuitState) // 状态变化回调（在锁外调用）
 }
 
 // NewCircuitBreaker 创建熔断器
 func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	now := time.Now()
 	return &CircuitBreaker{
-		config: config,
-		state:  StateClosed,
+		config:          config,
+		state:           StateClosed,
+		lastUsed:        now,
+		lastStateChange: now,
 	}
 }
 
@@ -71,7 +167,25 @@ func (cb *CircuitBreaker) Execute(fn func() error) error {
 // allowRequest 检查是否允许请求
 func (cb *CircuitBreaker) allowRequest() bool {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lastUsed = time.Now()
+	from := cb.state
+	allowed := cb.allowRequestLocked()
+	to := cb.state
+	cb.markStateChangeLocked(from)
+	cb.mu.Unlock()
+
+	cb.notifyStateChange(from, to)
+	return allowed
+}
+
+// allowRequestLocked 检查是否允许请求（调用方需持有锁）
+func (cb *CircuitBreaker) allowRequestLocked() bool {
+	switch cb.override {
+	case OverrideForceOpen:
+		return false
+	case OverrideForceClosed:
+		return true
+	}
 
 	switch cb.state {
 	case StateClosed:
@@ -102,13 +216,34 @@ func (cb *CircuitBreaker) allowRequest() bool {
 // recordResult 记录请求结果
 func (cb *CircuitBreaker) recordResult(err error) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	if err != nil {
+	from := cb.state
+	switch {
+	case cb.override != OverrideAuto:
+		// 人工干预期间不改变状态
+	case err != nil:
 		cb.onFailure()
-	} else {
+	default:
 		cb.onSuccess()
 	}
+	to := cb.state
+	cb.markStateChangeLocked(from)
+	cb.mu.Unlock()
+
+	cb.notifyStateChange(from, to)
+}
+
+// markStateChangeLocked 状态与 from 不同时记录变化时间（调用方需持有锁）
+func (cb *CircuitBreaker) markStateChangeLocked(from CircuitState) {
+	if cb.state != from {
+		cb.lastStateChange = time.Now()
+	}
+}
+
+// notifyStateChange 状态变化时触发回调
+func (cb *CircuitBreaker) notifyStateChange(from, to CircuitState) {
+	if from != to && cb.onStateChange != nil {
+		cb.onStateChange(from, to)
+	}
 }
 
 // onSuccess 成功处理
@@ -153,6 +288,30 @@ func (cb *CircuitBreaker) State() CircuitState {
 	return cb.state
 }
 
+// Available 是否会放行下一个请求（不改变熔断器状态）
+// 打开状态且已超过恢复等待时间时视为可用，下一个请求将进入半开探测
+func (cb *CircuitBreaker) Available() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	switch cb.override {
+	case OverrideForceOpen:
+		return false
+	case OverrideForceClosed:
+		return true
+	}
+	if cb.state != StateOpen {
+		return true
+	}
+	return time.Since(cb.lastFailureTime) > cb.config.Timeout
+}
+
+// LastStateChange 获取最近一次状态变化的时间，未变化过时为创建时间
+func (cb *CircuitBreaker) LastStateChange() time.Time {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.lastStateChange
+}
+
 // Failures 获取失败次数
 func (cb *CircuitBreaker) Failures() int {
 	cb.mu.RLock()
@@ -160,35 +319,126 @@ func (cb *CircuitBreaker) Failures() int {
 	return cb.failures
 }
 
-// Reset 重置熔断器
+// touch 记录使用时间
+func (cb *CircuitBreaker) touch() {
+	cb.mu.Lock()
+	cb.lastUsed = time.Now()
+	cb.mu.Unlock()
+}
+
+// idle 是否处于关闭状态且自 now 起已空闲超过 timeout，人工干预中的熔断器不视为空闲
+func (cb *CircuitBreaker) idle(now time.Time, timeout time.Duration) bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.override == OverrideAuto && cb.state == StateClosed && now.Sub(cb.lastUsed) > timeout
+}
+
+// Override 获取人工干预模式
+func (cb *CircuitBreaker) Override() BreakerOverride {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.override
+}
+
+// SetOverride 设置人工干预模式
+// 强制打开立即熔断，强制关闭立即恢复并清空失败计数；
+// 恢复自动时保留当前状态：打开状态在超时后进入半开探测，关闭状态按失败次数重新计算
+func (cb *CircuitBreaker) SetOverride(override BreakerOverride) {
+	cb.mu.Lock()
+	from := cb.state
+	cb.override = override
+	switch override {
+	case OverrideForceOpen:
+		cb.state = StateOpen
+		cb.lastFailureTime = time.Now()
+	case OverrideForceClosed:
+		cb.state = StateClosed
+		cb.failures = 0
+	}
+	cb.successes = 0
+	cb.halfOpenReqs = 0
+	to := cb.state
+	cb.markStateChangeLocked(from)
+	cb.mu.Unlock()
+
+	cb.notifyStateChange(from, to)
+}
+
+// Reset 重置熔断器：立即恢复为关闭状态，清空失败计数并取消人工干预
+// 用于数据源恢复后无需等待半开探测即可放行请求
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	from := cb.state
 	cb.state = StateClosed
+	cb.override = OverrideAuto
 	cb.failures = 0
 	cb.successes = 0
 	cb.halfOpenReqs = 0
+	cb.markStateChangeLocked(from)
+	cb.mu.Unlock()
+
+	cb.notifyStateChange(from, StateClosed)
 }
 
 // CircuitBreakerManager 熔断器管理器
+// 设置了优先级的数据源视为固定注册的数据源，不会被回收；
+// 其余按需创建的熔断器（如按主机名创建）在关闭且空闲超时后被回收
 type CircuitBreakerManager struct {
-	breakers map[string]*CircuitBreaker
-	config   CircuitBreakerConfig
-	mu       sync.RWMutex
+	breakers   map[string]*CircuitBreaker
+	priorities map[string]SourcePriority
+	config     CircuitBreakerConfig
+	hook       StateChangeHook
+	mu         sync.RWMutex
+
+	idleTimeout time.Duration
+	stopCleanup chan struct{}
+	stopOnce    sync.Once
+}
+
+// BreakerStatus 熔断器状态快照
+type BreakerStatus struct {
+	Name            string          `json:"name"`
+	Priority        SourcePriority  `json:"priority"`
+	State           CircuitState    `json:"state"`
+	Failures        int             `json:"failures"`
+	Override        BreakerOverride `json:"override"`
+	LastStateChange time.Time       `json:"lastStateChange"`
 }
 
 // NewCircuitBreakerManager 创建熔断器管理器
 func NewCircuitBreakerManager(config CircuitBreakerConfig) *CircuitBreakerManager {
 	return &CircuitBreakerManager{
-		breakers: make(map[string]*CircuitBreaker),
-		config:   config,
+		breakers:   make(map[string]*CircuitBreaker),
+		priorities: make(map[string]SourcePriority),
+		config:     config,
+	}
+}
+
+// NewCircuitBreakerManagerWithCleanup 创建定期回收空闲熔断器的管理器
+// 每隔 interval 回收处于关闭状态且空闲超过 idleTimeout 的按需创建的熔断器，任一参数 <= 0 时不回收
+func NewCircuitBreakerManagerWithCleanup(config CircuitBreakerConfig, interval, idleTimeout time.Duration) *CircuitBreakerManager {
+	m := NewCircuitBreakerManager(config)
+	if interval <= 0 || idleTimeout <= 0 {
+		return m
 	}
+
+	m.idleTimeout = idleTimeout
+	m.stopCleanup = make(chan struct{})
+
+	// 启动清理协程
+	go m.cleanup(interval)
+
+	return m
 }
 
-// Get 获取或创建熔断器
+// Get 获取或创建熔断器（未设置优先级时视为核心数据源）
 func (m *CircuitBreakerManager) Get(name string) *CircuitBreaker {
 	m.mu.RLock()
 	cb, ok := m.breakers[name]
+	if ok {
+		// 持有读锁时记录使用时间，与清理（持有写锁）互斥，刚取出的熔断器不会被回收
+		cb.touch()
+	}
 	m.mu.RUnlock()
 
 	if ok {
@@ -204,6 +454,165 @@ func (m *CircuitBreakerManager) Get(name string) *CircuitBreaker {
 	}
 
 	cb = NewCircuitBreaker(m.config)
+	cb.onStateChange = func(from, to CircuitState) {
+		m.mu.RLock()
+		hook := m.hook
+		priority := m.priorities[name]
+		m.mu.RUnlock()
+		if hook != nil {
+			hook(name, priority, from, to)
+		}
+	}
 	m.breakers[name] = cb
 	return cb
 }
+
+// cleanup 定期回收空闲熔断器
+func (m *CircuitBreakerManager) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.doCleanup(now)
+		case <-m.stopCleanup:
+			return
+		}
+	}
+}
+
+// doCleanup 回收按需创建、处于关闭状态且空闲超时的熔断器，返回回收数量
+func (m *CircuitBreakerManager) doCleanup(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for name, cb := range m.breakers {
+		if _, registered := m.priorities[name]; registered {
+			continue
+		}
+		if cb.idle(now, m.idleTimeout) {
+			delete(m.breakers, name)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Stop 停止清理协程
+func (m *CircuitBreakerManager) Stop() {
+	if m.stopCleanup == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.stopCleanup)
+	})
+}
+
+// Count 获取当前熔断器数量（用于监控）
+func (m *CircuitBreakerManager) Count() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.breakers)
+}
+
+// GetWithPriority 获取或创建熔断器并设置数据源优先级
+func (m *CircuitBreakerManager) GetWithPriority(name string, priority SourcePriority) *CircuitBreaker {
+	m.SetPriority(name, priority)
+	return m.Get(name)
+}
+
+// SetPriority 设置数据源优先级
+func (m *CircuitBreakerManager) SetPriority(name string, priority SourcePriority) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.priorities[name] = priority
+}
+
+// Priority 获取数据源优先级
+func (m *CircuitBreakerManager) Priority(name string) SourcePriority {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.priorities[name]
+}
+
+// OnStateChange 设置状态变化回调
+func (m *CircuitBreakerManager) OnStateChange(hook StateChangeHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hook = hook
+}
+
+// SetOverride 设置已存在的熔断器的人工干预模式，熔断器不存在时返回 ErrBreakerNotFound
+func (m *CircuitBreakerManager) SetOverride(name string, override BreakerOverride) (BreakerStatus, error) {
+	m.mu.RLock()
+	cb, ok := m.breakers[name]
+	priority := m.priorities[name]
+	m.mu.RUnlock()
+	if !ok {
+		return BreakerStatus{}, fmt.Errorf("%w: %s", ErrBreakerNotFound, name)
+	}
+
+	cb.SetOverride(override)
+	return cb.status(name, priority), nil
+}
+
+// Reset 重置已存在的熔断器，熔断器不存在时返回 ErrBreakerNotFound
+func (m *CircuitBreakerManager) Reset(name string) (BreakerStatus, error) {
+	m.mu.RLock()
+	cb, ok := m.breakers[name]
+	priority := m.priorities[name]
+	m.mu.RUnlock()
+	if !ok {
+		return BreakerStatus{}, fmt.Errorf("%w: %s", ErrBreakerNotFound, name)
+	}
+
+	cb.Reset()
+	return cb.status(name, priority), nil
+}
+
+// status 获取熔断器的状态快照
+func (cb *CircuitBreaker) status(name string, priority SourcePriority) BreakerStatus {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return BreakerStatus{
+		Name:            name,
+		Priority:        priority,
+		State:           cb.state,
+		Failures:        cb.failures,
+		Override:        cb.override,
+		LastStateChange: cb.lastStateChange,
+	}
+}
+
+// Snapshot 获取所有熔断器的状态快照（按名称排序）
+func (m *CircuitBreakerManager) Snapshot() []BreakerStatus {
+	m.mu.RLock()
+	statuses := make([]BreakerStatus, 0, len(m.breakers))
+	for name, cb := range m.breakers {
+		statuses = append(statuses, cb.status(name, m.priorities[name]))
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// OpenCriticalBreakers 获取处于熔断状态的核心数据源
+func (m *CircuitBreakerManager) OpenCriticalBreakers() []string {
+	var names []string
+	for _, st := range m.Snapshot() {
+		if st.Priority == PriorityCritical && st.State == StateOpen {
+			names = append(names, st.Name)
+		}
+	}
+	return names
+}
+
+// Ready 核心数据源均未熔断时返回 true，尽力而为的数据源不影响就绪状态
+func (m *CircuitBreakerManager) Ready() bool {
+	return len(m.OpenCriticalBreakers()) == 0
+}
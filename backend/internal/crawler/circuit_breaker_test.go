@@ -0,0 +1,492 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func tripBreaker(cb *CircuitBreaker, failures int) {
+	errFail := errors.New("source down")
+	for i := 0; i < failures; i++ {
+		_ = cb.Execute(func() error { return errFail })
+	}
+}
+
+func TestCircuitBreakerManager_BestEffortDoesNotAffectReadiness(t *testing.T) {
+	m := NewCircuitBreakerManager(CircuitBreakerConfig{
+		MaxFailures:     2,
+		Timeout:         time.Minute,
+		HalfOpenMaxReqs: 1,
+	})
+
+	m.Get("baidu")
+	search := m.GetWithPriority("duckduckgo", PriorityBestEffort)
+
+	tripBreaker(search, 2)
+	if search.State() != StateOpen {
+		t.Fatalf("expected best-effort breaker to be open, got %s", search.State())
+	}
+	if !m.Ready() {
+		t.Error("best-effort breaker open should not flip readiness")
+	}
+	if open := m.OpenCriticalBreakers(); len(open) != 0 {
+		t.Errorf("expected no open critical breakers, got %v", open)
+	}
+}
+
+func TestCircuitBreakerManager_CriticalAffectsReadiness(t *testing.T) {
+	m := NewCircuitBreakerManager(CircuitBreakerConfig{
+		MaxFailures:     2,
+		Timeout:         time.Minute,
+		HalfOpenMaxReqs: 1,
+	})
+
+	baidu := m.Get("baidu")
+	m.GetWithPriority("webpage", PriorityBestEffort)
+
+	if m.Priority("baidu") != PriorityCritical {
+		t.Errorf("expected default priority to be critical, got %s", m.Priority("baidu"))
+	}
+
+	tripBreaker(baidu, 2)
+	if m.Ready() {
+		t.Error("critical breaker open should flip readiness")
+	}
+	open := m.OpenCriticalBreakers()
+	if len(open) != 1 || open[0] != "baidu" {
+		t.Errorf("expected [baidu], got %v", open)
+	}
+
+	baidu.Reset()
+	if !m.Ready() {
+		t.Error("expected readiness to recover after reset")
+	}
+}
+
+func TestCircuitBreakerManager_OnStateChange(t *testing.T) {
+	m := NewCircuitBreakerManager(CircuitBreakerConfig{
+		MaxFailures:     1,
+		Timeout:         time.Minute,
+		HalfOpenMaxReqs: 1,
+	})
+
+	type change struct {
+		name     string
+		priority SourcePriority
+		to       CircuitState
+	}
+	var changes []change
+	m.OnStateChange(func(name string, priority SourcePriority, from, to CircuitState) {
+		changes = append(changes, change{name, priority, to})
+	})
+
+	tripBreaker(m.GetWithPriority("duckduckgo", PriorityBestEffort), 1)
+
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 state change, got %d", len(changes))
+	}
+	if changes[0].name != "duckduckgo" || changes[0].priority != PriorityBestEffort || changes[0].to != StateOpen {
+		t.Errorf("unexpected state change: %+v", changes[0])
+	}
+}
+
+func TestCircuitBreakerManager_Snapshot(t *testing.T) {
+	m := NewCircuitBreakerManager(DefaultCircuitBreakerConfig())
+	m.Get("gold")
+	m.GetWithPriority("duckduckgo", PriorityBestEffort)
+	m.Get("ant")
+
+	statuses := m.Snapshot()
+	if len(statuses) != 3 {
+		t.Fatalf("expected 3 statuses, got %d", len(statuses))
+	}
+
+	expected := []string{"ant", "duckduckgo", "gold"}
+	for i, name := range expected {
+		if statuses[i].Name != name {
+			t.Errorf("statuses[%d]: expected %s, got %s", i, name, statuses[i].Name)
+		}
+		if statuses[i].State != StateClosed {
+			t.Errorf("statuses[%d]: expected closed, got %s", i, statuses[i].State)
+		}
+	}
+	if statuses[1].Priority != PriorityBestEffort {
+		t.Errorf("expected duckduckgo to be best-effort, got %s", statuses[1].Priority)
+	}
+}
+
+func TestCircuitBreakerManager_SnapshotAfterForcedOpen(t *testing.T) {
+	m := NewCircuitBreakerManager(CircuitBreakerConfig{MaxFailures: 3, Timeout: time.Hour, HalfOpenMaxReqs: 1})
+	tripBreaker(m.GetWithPriority("gold", PriorityCritical), 1)
+	m.GetWithPriority("duckduckgo", PriorityBestEffort)
+
+	before := time.Now()
+	if _, err := m.SetOverride("gold", OverrideForceOpen); err != nil {
+		t.Fatalf("SetOverride: %v", err)
+	}
+
+	snapshot := m.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].Name != "duckduckgo" || snapshot[1].Name != "gold" {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	gold := snapshot[1]
+	if gold.State != StateOpen || gold.Override != OverrideForceOpen || gold.Failures != 1 {
+		t.Errorf("expected gold forced open with 1 failure, got %+v", gold)
+	}
+	if gold.LastStateChange.Before(before) {
+		t.Errorf("expected last state change at or after %v, got %v", before, gold.LastStateChange)
+	}
+	if ddg := snapshot[0]; ddg.State != StateClosed || ddg.LastStateChange.After(before) {
+		t.Errorf("expected duckduckgo closed and unchanged, got %+v", ddg)
+	}
+
+	raw, err := json.Marshal(gold)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for key, want := range map[string]interface{}{"name": "gold", "priority": "critical", "state": "open", "failures": float64(1), "override": "open"} {
+		if decoded[key] != want {
+			t.Errorf("json %s: expected %v, got %v", key, want, decoded[key])
+		}
+	}
+	if _, ok := decoded["lastStateChange"]; !ok {
+		t.Errorf("expected lastStateChange in %s", raw)
+	}
+}
+
+func TestCircuitBreaker_Available(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		MaxFailures:     1,
+		Timeout:         20 * time.Millisecond,
+		HalfOpenMaxReqs: 1,
+	})
+	if !cb.Available() {
+		t.Fatal("closed breaker should be available")
+	}
+
+	tripBreaker(cb, 1)
+	if cb.Available() {
+		t.Fatal("open breaker should not be available before timeout")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !cb.Available() {
+		t.Error("open breaker should be available once a half-open probe is allowed")
+	}
+	if cb.State() != StateOpen {
+		t.Errorf("Available should not change state, got %s", cb.State())
+	}
+}
+
+func newCleanupTestManager(t *testing.T) *CircuitBreakerManager {
+	m := NewCircuitBreakerManagerWithCleanup(CircuitBreakerConfig{
+		MaxFailures:     2,
+		Timeout:         time.Minute,
+		HalfOpenMaxReqs: 1,
+	}, time.Hour, time.Minute)
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func TestCircuitBreakerManager_CleanupReclaimsIdleClosedBreaker(t *testing.T) {
+	m := newCleanupTestManager(t)
+
+	m.Get("news.example.com")
+	if m.Count() != 1 {
+		t.Fatalf("expected 1 breaker, got %d", m.Count())
+	}
+
+	if removed := m.doCleanup(time.Now().Add(30 * time.Second)); removed != 0 {
+		t.Errorf("breaker idle below threshold should be kept, removed %d", removed)
+	}
+	if removed := m.doCleanup(time.Now().Add(2 * time.Minute)); removed != 1 {
+		t.Errorf("expected idle breaker to be reclaimed, removed %d", removed)
+	}
+	if m.Count() != 0 {
+		t.Errorf("expected 0 breakers after cleanup, got %d", m.Count())
+	}
+
+	// 回收后再次获取会创建新的熔断器
+	if cb := m.Get("news.example.com"); cb.State() != StateClosed {
+		t.Errorf("expected recreated breaker to be closed, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreakerManager_CleanupKeepsActiveAndOpenBreakers(t *testing.T) {
+	m := newCleanupTestManager(t)
+
+	idle := m.Get("idle.example.com")
+	open := m.Get("down.example.com")
+	tripBreaker(open, 2)
+	m.GetWithPriority("baidu", PriorityCritical)
+	m.GetWithPriority("duckduckgo", PriorityBestEffort)
+
+	// 空闲熔断器的最近使用时间回拨，其余熔断器在清理前刚被使用
+	idle.mu.Lock()
+	idle.lastUsed = time.Now().Add(-2 * time.Minute)
+	idle.mu.Unlock()
+	m.Get("active.example.com")
+
+	now := time.Now().Add(10 * time.Second)
+	open.mu.Lock()
+	open.lastUsed = time.Now().Add(-2 * time.Minute)
+	open.mu.Unlock()
+
+	if removed := m.doCleanup(now); removed != 1 {
+		t.Fatalf("expected only the idle breaker to be reclaimed, removed %d", removed)
+	}
+
+	statuses := make(map[string]bool)
+	for _, status := range m.Snapshot() {
+		statuses[status.Name] = true
+	}
+	if statuses["idle.example.com"] {
+		t.Error("idle closed breaker should be reclaimed")
+	}
+	for _, name := range []string{"down.example.com", "active.example.com", "baidu", "duckduckgo"} {
+		if !statuses[name] {
+			t.Errorf("breaker %s should be retained", name)
+		}
+	}
+
+	// 注册了优先级的数据源即使长时间空闲也不回收
+	if removed := m.doCleanup(time.Now().Add(time.Hour)); removed != 1 {
+		t.Errorf("expected only active.example.com to be reclaimed, removed %d", removed)
+	}
+	if m.Count() != 3 {
+		t.Errorf("expected open and registered breakers to remain, got %d", m.Count())
+	}
+}
+
+func TestCircuitBreakerManager_CleanupConcurrentWithGet(t *testing.T) {
+	m := newCleanupTestManager(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				cb := m.Get(fmt.Sprintf("host-%d", j%10))
+				_ = cb.Execute(func() error { return nil })
+			}
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 200; j++ {
+			m.doCleanup(time.Now().Add(2 * time.Minute))
+		}
+	}()
+	wg.Wait()
+
+	if m.Count() > 10 {
+		t.Errorf("expected at most 10 breakers, got %d", m.Count())
+	}
+}
+
+func TestCircuitBreakerManager_StopWithoutCleanup(t *testing.T) {
+	m := NewCircuitBreakerManager(DefaultCircuitBreakerConfig())
+	m.Stop()
+
+	m = NewCircuitBreakerManagerWithCleanup(DefaultCircuitBreakerConfig(), time.Millisecond, time.Minute)
+	m.Stop()
+	m.Stop()
+}
+
+func TestCircuitBreaker_ForceOpen(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MaxFailures: 2, Timeout: time.Nanosecond, HalfOpenMaxReqs: 1})
+	cb.SetOverride(OverrideForceOpen)
+
+	// 即使超过恢复等待时间也不会进入半开探测
+	time.Sleep(time.Millisecond)
+	called := false
+	err := cb.Execute(func() error { called = true; return nil })
+	if !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("expected forced-open breaker to reject without calling, got err=%v called=%v", err, called)
+	}
+	if cb.State() != StateOpen || cb.Available() {
+		t.Fatalf("expected forced-open breaker to be open and unavailable, got %s", cb.State())
+	}
+
+	// 恢复自动后按正常流程半开探测
+	cb.SetOverride(OverrideAuto)
+	time.Sleep(time.Millisecond)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("expected probe after returning to auto, got %v", err)
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected breaker to close after successful probe, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreaker_ForceClosed(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MaxFailures: 2, Timeout: time.Hour, HalfOpenMaxReqs: 1})
+	tripBreaker(cb, 2)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected breaker to be open, got %s", cb.State())
+	}
+
+	// 无需等待半开探测，立即放行；失败也不会重新熔断
+	cb.SetOverride(OverrideForceClosed)
+	if cb.State() != StateClosed || !cb.Available() {
+		t.Fatalf("expected forced-closed breaker to be closed, got %s", cb.State())
+	}
+	tripBreaker(cb, 5)
+	called := false
+	if err := cb.Execute(func() error { called = true; return nil }); err != nil || !called {
+		t.Fatalf("expected forced-closed breaker to allow request, got err=%v called=%v", err, called)
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected breaker to stay closed, got %s", cb.State())
+	}
+
+	// 恢复自动后重新按失败次数熔断
+	cb.SetOverride(OverrideAuto)
+	tripBreaker(cb, 2)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected breaker to open again in auto mode, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreakerManager_SetOverride(t *testing.T) {
+	m := NewCircuitBreakerManager(DefaultCircuitBreakerConfig())
+	m.GetWithPriority("baidu", PriorityCritical)
+
+	var changes []string
+	m.OnStateChange(func(name string, priority SourcePriority, from, to CircuitState) {
+		changes = append(changes, fmt.Sprintf("%s:%s->%s", name, from, to))
+	})
+
+	status, err := m.SetOverride("baidu", OverrideForceOpen)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.State != StateOpen || status.Override != OverrideForceOpen {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if m.Ready() {
+		t.Fatal("expected forced-open critical source to affect readiness")
+	}
+	if len(changes) != 1 || changes[0] != "baidu:closed->open" {
+		t.Fatalf("unexpected state changes: %v", changes)
+	}
+
+	if _, err := m.SetOverride("unknown", OverrideForceOpen); !errors.Is(err, ErrBreakerNotFound) {
+		t.Fatalf("expected ErrBreakerNotFound, got %v", err)
+	}
+	if m.Count() != 1 {
+		t.Fatalf("override must not create breakers, got %d", m.Count())
+	}
+}
+
+func TestCircuitBreaker_ResetAllowsNextCall(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MaxFailures: 2, Timeout: time.Hour, HalfOpenMaxReqs: 1})
+	tripBreaker(cb, 2)
+
+	called := false
+	if err := cb.Execute(func() error { called = true; return nil }); !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("expected tripped breaker to reject, got err=%v called=%v", err, called)
+	}
+
+	// 无需等待恢复超时
+	cb.Reset()
+	if cb.State() != StateClosed || cb.Failures() != 0 {
+		t.Fatalf("expected closed breaker without failures after reset, got %s/%d", cb.State(), cb.Failures())
+	}
+	if err := cb.Execute(func() error { called = true; return nil }); err != nil || !called {
+		t.Fatalf("expected fetcher to run after reset, got err=%v called=%v", err, called)
+	}
+}
+
+func TestCircuitBreakerManager_Reset(t *testing.T) {
+	m := NewCircuitBreakerManager(CircuitBreakerConfig{MaxFailures: 1, Timeout: time.Hour, HalfOpenMaxReqs: 1})
+	tripBreaker(m.GetWithPriority("gold", PriorityCritical), 1)
+	m.GetWithPriority("baidu", PriorityCritical)
+	if _, err := m.SetOverride("baidu", OverrideForceOpen); err != nil {
+		t.Fatalf("SetOverride: %v", err)
+	}
+
+	var changes []string
+	m.OnStateChange(func(name string, priority SourcePriority, from, to CircuitState) {
+		changes = append(changes, fmt.Sprintf("%s:%s->%s", name, from, to))
+	})
+
+	for _, name := range []string{"gold", "baidu"} {
+		status, err := m.Reset(name)
+		if err != nil {
+			t.Fatalf("Reset(%s): %v", name, err)
+		}
+		if status.State != StateClosed || status.Failures != 0 || status.Override != OverrideAuto {
+			t.Fatalf("unexpected status after reset: %+v", status)
+		}
+	}
+	if !m.Ready() {
+		t.Fatal("expected readiness to recover after reset")
+	}
+	if len(changes) != 2 || changes[0] != "gold:open->closed" || changes[1] != "baidu:open->closed" {
+		t.Fatalf("unexpected state changes: %v", changes)
+	}
+
+	called := false
+	if err := m.Get("gold").Execute(func() error { called = true; return nil }); err != nil || !called {
+		t.Fatalf("expected fetcher to run after reset, got err=%v called=%v", err, called)
+	}
+
+	if _, err := m.Reset("unknown"); !errors.Is(err, ErrBreakerNotFound) {
+		t.Fatalf("expected ErrBreakerNotFound, got %v", err)
+	}
+	if m.Count() != 2 {
+		t.Fatalf("reset must not create breakers, got %d", m.Count())
+	}
+}
+
+func TestCircuitBreaker_ResetConcurrentWithExecute(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MaxFailures: 1, Timeout: time.Hour, HalfOpenMaxReqs: 1})
+	errFail := errors.New("source down")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = cb.Execute(func() error { return errFail })
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cb.Reset()
+			}
+		}()
+	}
+	wg.Wait()
+
+	cb.Reset()
+	if cb.State() != StateClosed || cb.Failures() != 0 {
+		t.Fatalf("expected closed breaker after final reset, got %s/%d", cb.State(), cb.Failures())
+	}
+}
+
+func TestParseBreakerOverride(t *testing.T) {
+	for _, o := range []BreakerOverride{OverrideAuto, OverrideForceOpen, OverrideForceClosed} {
+		parsed, err := ParseBreakerOverride(o.String())
+		if err != nil || parsed != o {
+			t.Fatalf("round trip %s: got %v, %v", o, parsed, err)
+		}
+	}
+	if _, err := ParseBreakerOverride("half_open"); !errors.Is(err, ErrInvalidBreakerOverride) {
+		t.Fatalf("expected ErrInvalidBreakerOverride, got %v", err)
+	}
+}
@@ -25,13 +25,20 @@ type DuckDuckGoCrawler interface {
 type duckDuckGoCrawlerImpl struct {
 	client  *HTTPClient
 	breaker *CircuitBreaker
+	limits  HTMLParseLimits
 }
 
-// NewDuckDuckGoCrawler 创建 DuckDuckGo 搜索爬虫
+// NewDuckDuckGoCrawler 创建 DuckDuckGo 搜索爬虫，使用默认 HTML 解析限制
 func NewDuckDuckGoCrawler(client *HTTPClient, breaker *CircuitBreaker) DuckDuckGoCrawler {
+	return NewDuckDuckGoCrawlerWithLimits(client, breaker, DefaultHTMLParseLimits())
+}
+
+// NewDuckDuckGoCrawlerWithLimits 创建使用指定 HTML 解析限制的 DuckDuckGo 搜索爬虫
+func NewDuckDuckGoCrawlerWithLimits(client *HTTPClient, breaker *CircuitBreaker, limits HTMLParseLimits) DuckDuckGoCrawler {
 	return &duckDuckGoCrawlerImpl{
 		client:  client,
 		breaker: breaker,
+		limits:  limits.withDefaults(),
 	}
 }
 
@@ -68,7 +75,7 @@ func (c *duckDuckGoCrawlerImpl) Search(ctx context.Context, query string, count
 		}
 
 		// 解析 HTML 响应
-		results, err = parseSearchResults(string(data), count)
+		results, err = parseSearchResultsWithLimits(string(data), count, c.limits)
 		if err != nil {
 			return fmt.Errorf("parse search results failed: %w", err)
 		}
@@ -79,13 +86,18 @@ func (c *duckDuckGoCrawlerImpl) Search(ctx context.Context, query string, count
 	return results, err
 }
 
-// parseSearchResults 解析 DuckDuckGo HTML 搜索结果
+// parseSearchResults 解析 DuckDuckGo HTML 搜索结果，使用默认解析限制
 func parseSearchResults(htmlContent string, maxCount int) ([]model.SearchResult, error) {
+	return parseSearchResultsWithLimits(htmlContent, maxCount, DefaultHTMLParseLimits())
+}
+
+// parseSearchResultsWithLimits 在解析限制内解析 DuckDuckGo HTML 搜索结果
+func parseSearchResultsWithLimits(htmlContent string, maxCount int, limits HTMLParseLimits) ([]model.SearchResult, error) {
 	var results []model.SearchResult
 
-	doc, err := html.Parse(strings.NewReader(htmlContent))
+	doc, err := parseHTMLWithLimits(htmlContent, limits)
 	if err != nil {
-		return nil, fmt.Errorf("parse HTML failed: %w", err)
+		return nil, err
 	}
 
 	// 查找所有搜索结果
@@ -84,36 +84,43 @@ func (c *EastMoneyCrawler) GetSectorFunds(ctx context.Context, sectorCode string
 			return err
 		}
 
-		var resp eastmoneyFundResponse
-		if err := json.Unmarshal(data, &resp); err != nil {
-			return fmt.Errorf("parse response failed: %w", err)
-		}
-
-		for _, item := range resp.Datas {
-			result = append(result, model.SectorFund{
-				Code:       item.FCODE,
-				Name:       item.SHORTNAME,
-				Type:       item.FTYPE,
-				Date:       item.FSRQ,
-				NetValue:   item.NAV,
-				Week1:      item.SYL_Z,
-				Month1:     item.SYL_Y,
-				Month3:     item.SYL_3Y,
-				Month6:     item.SYL_6Y,
-				YearToDate: item.SYL_JN,
-				Year1:      item.SYL_1N,
-				Year2:      item.SYL_2N,
-				Year3:      item.SYL_3N,
-				SinceStart: item.SYL_LN,
-			})
-		}
-
-		return nil
+		result, err = parseSectorFunds(data)
+		return err
 	})
 
 	return result, err
 }
 
+// parseSectorFunds 解析板块基金响应
+// 上游偶尔返回 JSONP 包装或 HTML 拦截页，前者去除包装后解析，后者返回 *NonJSONResponseError
+func parseSectorFunds(data []byte) ([]model.SectorFund, error) {
+	var resp eastmoneyFundResponse
+	if err := decodeJSONResponse(data, &resp); err != nil {
+		return nil, err
+	}
+
+	result := make([]model.SectorFund, 0, len(resp.Datas))
+	for _, item := range resp.Datas {
+		result = append(result, model.SectorFund{
+			Code:       item.FCODE,
+			Name:       item.SHORTNAME,
+			Type:       item.FTYPE,
+			Date:       item.FSRQ,
+			NetValue:   item.NAV,
+			Week1:      item.SYL_Z,
+			Month1:     item.SYL_Y,
+			Month3:     item.SYL_3Y,
+			Month6:     item.SYL_6Y,
+			YearToDate: item.SYL_JN,
+			Year1:      item.SYL_1N,
+			Year2:      item.SYL_2N,
+			Year3:      item.SYL_3N,
+			SinceStart: item.SYL_LN,
+		})
+	}
+	return result, nil
+}
+
 // SectorCategories 板块分类映射
 var SectorCategories = map[string][]string{
 	"科技": {
@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sectorFundsJSON = `{"Datas":[{"FCODE":"012345","SHORTNAME":"半导体ETF联接A","FTYPE":"指数型","FSRQ":"2024-03-01","NAV":"1.2345","SYL_1N":"12.34"}],"ErrCode":0}`
+
+func TestParseSectorFunds_PlainJSON(t *testing.T) {
+	funds, err := parseSectorFunds([]byte(sectorFundsJSON))
+
+	require.NoError(t, err)
+	require.Len(t, funds, 1)
+	assert.Equal(t, "012345", funds[0].Code)
+	assert.Equal(t, "12.34", funds[0].Year1)
+}
+
+func TestParseSectorFunds_JSONPStripped(t *testing.T) {
+	for _, body := range []string{
+		"jQuery18307419_1709280000000(" + sectorFundsJSON + ");",
+		"\ufeff callback(\n" + sectorFundsJSON + "\n)\n",
+	} {
+		funds, err := parseSectorFunds([]byte(body))
+
+		require.NoError(t, err, body)
+		require.Len(t, funds, 1)
+		assert.Equal(t, "半导体ETF联接A", funds[0].Name)
+	}
+}
+
+func TestParseSectorFunds_HTMLErrorPage(t *testing.T) {
+	body := "<!DOCTYPE html>\n<html><head><title>访问受限</title></head>\n<body>" +
+		strings.Repeat("您的访问过于频繁，请稍后再试。", 20) + "</body></html>"
+
+	_, err := parseSectorFunds([]byte(body))
+
+	require.ErrorIs(t, err, ErrNonJSONResponse)
+	var nonJSON *NonJSONResponseError
+	require.True(t, errors.As(err, &nonJSON))
+	assert.True(t, strings.HasPrefix(nonJSON.Sample, "<!DOCTYPE html> <html><head><title>访问受限"))
+	assert.LessOrEqual(t, len(nonJSON.Sample), responseSampleBytes)
+	assert.True(t, utf8.ValidString(nonJSON.Sample), "sample must not split multi-byte characters")
+}
+
+func TestParseSectorFunds_MalformedJSONNotTypedAsNonJSON(t *testing.T) {
+	_, err := parseSectorFunds([]byte(`{"Datas":[`))
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNonJSONResponse)
+}
@@ -0,0 +1,183 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLParseLimits 解析不可信 HTML 的资源限制
+// 超出限制时截断文档，只处理限制范围内的内容
+type HTMLParseLimits struct {
+	MaxBytes int // 参与解析的最大字节数
+	MaxDepth int // DOM 树最大深度，更深的子树被丢弃
+	MaxNodes int // 最多处理的节点数，之后的节点被丢弃
+}
+
+// DefaultHTMLParseLimits 默认 HTML 解析限制
+func DefaultHTMLParseLimits() HTMLParseLimits {
+	return HTMLParseLimits{
+		MaxBytes: 2 << 20, // 2MB
+		MaxDepth: 256,
+		MaxNodes: 50000,
+	}
+}
+
+// withDefaults 未设置（<= 0）的项使用默认值
+func (l HTMLParseLimits) withDefaults() HTMLParseLimits {
+	def := DefaultHTMLParseLimits()
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = def.MaxBytes
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = def.MaxDepth
+	}
+	if l.MaxNodes <= 0 {
+		l.MaxNodes = def.MaxNodes
+	}
+	return l
+}
+
+// parseHTMLWithLimits 在限制范围内解析 HTML
+// html.Parse 处理深层嵌套时耗时随深度平方增长，因此先用线性的词法扫描
+// 找到超出深度或节点数限制的位置并截断输入，解析后再修剪 DOM 树，
+// 保证之后的递归遍历有界
+func parseHTMLWithLimits(content string, limits HTMLParseLimits) (*html.Node, error) {
+	limits = limits.withDefaults()
+
+	if len(content) > limits.MaxBytes {
+		content = content[:limits.MaxBytes]
+	}
+	content = strings.ToValidUTF8(content[:scanHTMLLimit(content, limits)], "")
+
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("parse HTML failed: %w", err)
+	}
+
+	pruneHTMLTree(doc, limits)
+	return doc, nil
+}
+
+// optionalEndTags 结束标签可省略或无结束标签的元素，不计入嵌套深度
+var optionalEndTags = map[string]bool{
+	// 空元素
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+	// 结束标签可省略的元素
+	"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true,
+	"dd": true, "option": true, "optgroup": true, "tr": true, "td": true,
+	"th": true, "thead": true, "tbody": true, "tfoot": true, "colgroup": true,
+	"rb": true, "rt": true, "rp": true,
+}
+
+// scanHTMLLimit 词法扫描 HTML，返回不超出限制的前缀长度
+func scanHTMLLimit(content string, limits HTMLParseLimits) int {
+	z := html.NewTokenizer(strings.NewReader(content))
+	offset, depth, tokens := 0, 0, 0
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return len(content)
+		}
+
+		tokens++
+		if tokens > limits.MaxNodes {
+			return offset
+		}
+
+		switch tt {
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if !optionalEndTags[string(name)] {
+				depth++
+				if depth > limits.MaxDepth {
+					return offset
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if !optionalEndTags[string(name)] && depth > 0 {
+				depth--
+			}
+		}
+
+		offset += len(z.Raw())
+	}
+}
+
+// pruneHTMLTree 以非递归方式遍历 DOM 树，丢弃超出深度或节点数限制的部分
+// 返回是否发生了截断
+func pruneHTMLTree(root *html.Node, limits HTMLParseLimits) bool {
+	type frame struct {
+		node  *html.Node
+		depth int
+	}
+
+	truncated := false
+	visited := 0
+	stack := []frame{{root, 0}}
+
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		visited++
+		if visited > limits.MaxNodes {
+			// 按文档顺序丢弃当前节点及其后的所有节点
+			truncateFrom(f.node)
+			return true
+		}
+
+		if f.depth >= limits.MaxDepth {
+			if f.node.FirstChild != nil {
+				removeChildren(f.node)
+				truncated = true
+			}
+			continue
+		}
+
+		// 逆序入栈，保证按文档顺序访问
+		for c := f.node.LastChild; c != nil; c = c.PrevSibling {
+			stack = append(stack, frame{c, f.depth + 1})
+		}
+	}
+
+	return truncated
+}
+
+// truncateFrom 删除节点 n 以及文档顺序中位于其后的所有节点
+func truncateFrom(n *html.Node) {
+	parent := n.Parent
+	if parent == nil {
+		return
+	}
+
+	// 删除 n 及其后的兄弟节点
+	for c := n; c != nil; {
+		next := c.NextSibling
+		parent.RemoveChild(c)
+		c = next
+	}
+
+	// 删除各级祖先之后的兄弟节点
+	for a := parent; a.Parent != nil; a = a.Parent {
+		for c := a.NextSibling; c != nil; {
+			next := c.NextSibling
+			a.Parent.RemoveChild(c)
+			c = next
+		}
+	}
+}
+
+// removeChildren 删除节点的全部子节点
+func removeChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		n.RemoveChild(c)
+		c = next
+	}
+}
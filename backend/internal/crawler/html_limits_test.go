@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+// treeDepth 返回 DOM 树的最大深度
+func treeDepth(n *html.Node) int {
+	max := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if d := treeDepth(c); d > max {
+			max = d
+		}
+	}
+	return max + 1
+}
+
+func TestExtractMainContent_DeeplyNestedBounded(t *testing.T) {
+	const depth = 100000
+	page := "<html><body><p>开头的正文内容</p>" +
+		strings.Repeat("<div>", depth) + "深处的内容" + strings.Repeat("</div>", depth) +
+		"</body></html>"
+
+	start := time.Now()
+	content, err := extractMainContent(page)
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.Less(t, elapsed, 2*time.Second, "parsing should be bounded, took %s", elapsed)
+	assert.Contains(t, content, "开头的正文内容")
+	assert.NotContains(t, content, "深处的内容")
+}
+
+func TestParseHTMLWithLimits_DepthLimit(t *testing.T) {
+	page := "<p>浅层</p>" + strings.Repeat("<div>", 50) + "深层" + strings.Repeat("</div>", 50)
+
+	doc, err := parseHTMLWithLimits(page, HTMLParseLimits{MaxDepth: 10})
+
+	require.NoError(t, err)
+	assert.LessOrEqual(t, treeDepth(doc), 11)
+}
+
+func TestParseHTMLWithLimits_OptionalEndTagsDoNotCountAsDepth(t *testing.T) {
+	// 未闭合的 li/p 不应被视为嵌套
+	var sb strings.Builder
+	sb.WriteString("<ul>")
+	for i := 0; i < 100; i++ {
+		sb.WriteString("<li>条目")
+	}
+	sb.WriteString("</ul><p>结尾段落")
+
+	content, err := extractMainContentWithLimits(sb.String(), HTMLParseLimits{MaxDepth: 20})
+
+	require.NoError(t, err)
+	assert.Contains(t, content, "结尾段落")
+}
+
+func TestParseHTMLWithLimits_NodeLimit(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("<html><body>")
+	for i := 0; i < 200000; i++ {
+		sb.WriteString("<span>x</span>")
+	}
+	sb.WriteString("<p>末尾</p></body></html>")
+
+	start := time.Now()
+	content, err := extractMainContentWithLimits(sb.String(), HTMLParseLimits{MaxNodes: 1000})
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.Less(t, elapsed, 2*time.Second)
+	assert.Contains(t, content, "x")
+	assert.NotContains(t, content, "末尾")
+	assert.LessOrEqual(t, strings.Count(content, "x"), 1000)
+}
+
+func TestParseHTMLWithLimits_SizeLimit(t *testing.T) {
+	page := "<p>前半部分</p>" + strings.Repeat("填充", 1000) + "<p>后半部分</p>"
+
+	content, err := extractMainContentWithLimits(page, HTMLParseLimits{MaxBytes: 100})
+
+	require.NoError(t, err)
+	assert.Contains(t, content, "前半部分")
+	assert.NotContains(t, content, "后半部分")
+}
+
+func TestParseSearchResultsWithLimits_DeeplyNestedBounded(t *testing.T) {
+	const depth = 100000
+	page := `<div class="result"><a class="result__a" href="https://example.com/a">第一条</a>` +
+		`<a class="result__snippet">摘要</a></div>` +
+		strings.Repeat("<div>", depth) + strings.Repeat("</div>", depth)
+
+	start := time.Now()
+	results, err := parseSearchResults(page, 10)
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.Less(t, elapsed, 2*time.Second)
+	require.Len(t, results, 1)
+	assert.Equal(t, "第一条", results[0].Title)
+}
+
+func TestHTMLParseLimits_WithDefaults(t *testing.T) {
+	limits := HTMLParseLimits{MaxDepth: 32}.withDefaults()
+
+	def := DefaultHTMLParseLimits()
+	assert.Equal(t, 32, limits.MaxDepth)
+	assert.Equal(t, def.MaxBytes, limits.MaxBytes)
+	assert.Equal(t, def.MaxNodes, limits.MaxNodes)
+}
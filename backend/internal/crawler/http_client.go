@@ -2,11 +2,16 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"math/rand"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"syscall"
 	"time"
+
+	"fund-analyzer/pkg/random"
 )
 
 // HTTPClient HTTP 客户端配置
@@ -15,8 +20,35 @@ type HTTPClientConfig struct {
 	MaxRetries    int
 	RetryBaseWait time.Duration
 	RetryMaxWait  time.Duration
+
+	// MaxRedirects 最多跟随的重定向次数，<= 0 时使用 DefaultMaxRedirects
+	MaxRedirects int
+	// URLValidator 对请求 URL 及每一跳重定向目标进行校验，为 nil 时不校验
+	URLValidator URLValidator
+	// DialControl 建立连接前以实际拨号地址调用，返回错误时拒绝连接，为 nil 时不校验
+	// 与 URLValidator 配合使用，防止校验后 DNS 解析结果变化（DNS 重绑定）绕过校验
+	DialControl func(network, address string, c syscall.RawConn) error
+	// OnRequest 每次请求尝试结束后回调（重试分别回调），用于记录上游耗时，为 nil 时不记录
+	OnRequest func(ctx context.Context, record RequestRecord)
+	// Random 重试抖动与 User-Agent 选择使用的随机源，为 nil 时使用 random.Default()
+	Random random.Source
 }
 
+// RequestRecord 单次上游请求尝试的记录
+type RequestRecord struct {
+	Source  string        // 数据源名称，未通过 WithSource 指定时为请求的主机名
+	Method  string        // 请求方法
+	URL     string        // 请求 URL（隐去密码）
+	Status  int           // HTTP 状态码，未收到响应时为 0
+	Bytes   int           // 响应体字节数
+	Latency time.Duration // 从发出请求到读完响应体的耗时
+	Err     error         // 请求失败原因
+	Body    []byte        // 响应体，回调只能读取且不可持有
+}
+
+// DefaultMaxRedirects 默认最多跟随的重定向次数（与 net/http 默认值一致）
+const DefaultMaxRedirects = 10
+
 // DefaultHTTPClientConfig 默认配置
 func DefaultHTTPClientConfig() HTTPClientConfig {
 	return HTTPClientConfig{
@@ -31,16 +63,78 @@ func DefaultHTTPClientConfig() HTTPClientConfig {
 type HTTPClient struct {
 	client *http.Client
 	config HTTPClientConfig
+	source string
 }
 
 // NewHTTPClient 创建 HTTP 客户端
 func NewHTTPClient(config HTTPClientConfig) *HTTPClient {
-	return &HTTPClient{
-		client: &http.Client{
-			Timeout: config.Timeout,
-		},
-		config: config,
+	if config.MaxRedirects <= 0 {
+		config.MaxRedirects = DefaultMaxRedirects
+	}
+	if config.Random == nil {
+		config.Random = random.Default()
+	}
+
+	c := &HTTPClient{config: config}
+	c.client = &http.Client{
+		Timeout:       config.Timeout,
+		CheckRedirect: c.checkRedirect,
+	}
+	if config.DialControl != nil {
+		c.client.Transport = newDialControlTransport(config.DialControl)
+	}
+	return c
+}
+
+// newDialControlTransport 创建拨号前经 control 校验目标地址的 Transport
+// 不使用环境变量中的代理：经代理连接时拨号的是代理地址，无法校验实际目标
+func newDialControlTransport(control func(network, address string, c syscall.RawConn) error) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// WithSource 返回共享连接与配置、以 source 标记请求来源的客户端
+func (c *HTTPClient) WithSource(source string) *HTTPClient {
+	clone := *c
+	clone.source = source
+	return &clone
+}
+
+// WithCookieJar 返回共享连接与配置、使用独立 Cookie 存储的客户端
+// 用于需要会话的数据源：首次响应下发的 Cookie 会在后续对同一站点的请求中携带，jar 为 nil 时不保存 Cookie
+func (c *HTTPClient) WithCookieJar(jar http.CookieJar) *HTTPClient {
+	clone := *c
+	client := *c.client
+	client.Jar = jar
+	clone.client = &client
+	return &clone
+}
+
+// NewCookieJar 创建内存 Cookie 存储，供 WithCookieJar 使用
+func NewCookieJar() http.CookieJar {
+	// cookiejar.New 仅在 PublicSuffixList 返回错误时失败，nil 选项不会出错
+	jar, _ := cookiejar.New(nil)
+	return jar
+}
+
+// checkRedirect 重定向策略：限制跳数，并对每一跳重新校验目标 URL
+func (c *HTTPClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > c.config.MaxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, c.config.MaxRedirects)
 	}
+	if c.config.URLValidator != nil {
+		if err := c.config.URLValidator(req.Context(), req.URL); err != nil {
+			return fmt.Errorf("redirect to %s rejected: %w", req.URL.Redacted(), err)
+		}
+	}
+	return nil
 }
 
 // UserAgents 常用 User-Agent 列表
@@ -54,7 +148,12 @@ var UserAgents = []string{
 
 // RandomUserAgent 随机获取 User-Agent
 func RandomUserAgent() string {
-	return UserAgents[rand.Intn(len(UserAgents))]
+	return pickUserAgent(random.Default())
+}
+
+// pickUserAgent 从指定随机源选取 User-Agent
+func pickUserAgent(src random.Source) string {
+	return UserAgents[src.Int63n(int64(len(UserAgents)))]
 }
 
 // Get 发送 GET 请求（带重试）
@@ -99,31 +198,53 @@ func (c *HTTPClient) doWithRetry(ctx context.Context, method, url string, body i
 }
 
 // do 执行单次请求
-func (c *HTTPClient) do(ctx context.Context, method, url string, body io.Reader, headers map[string]string) ([]byte, error) {
+func (c *HTTPClient) do(ctx context.Context, method, url string, body io.Reader, headers map[string]string) (data []byte, err error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
 
+	if c.config.URLValidator != nil {
+		if err := c.config.URLValidator(ctx, req.URL); err != nil {
+			return nil, err
+		}
+	}
+
 	// 设置默认 User-Agent
-	req.Header.Set("User-Agent", RandomUserAgent())
+	req.Header.Set("User-Agent", pickUserAgent(c.config.Random))
 
 	// 设置自定义 headers
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
 
+	record := RequestRecord{Source: c.source, Method: method, URL: req.URL.Redacted()}
+	if record.Source == "" {
+		record.Source = req.URL.Hostname()
+	}
+	start := time.Now()
+	if c.config.OnRequest != nil {
+		defer func() {
+			record.Latency = time.Since(start)
+			record.Bytes = len(data)
+			record.Body = data
+			record.Err = err
+			c.config.OnRequest(ctx, record)
+		}()
+	}
+
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	record.Status = resp.StatusCode
 
 	if resp.StatusCode >= 400 {
 		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	data, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("read response failed: %w", err)
 	}
@@ -139,8 +260,7 @@ func (c *HTTPClient) calculateBackoff(attempt int) time.Duration {
 		wait = c.config.RetryMaxWait
 	}
 	// 添加 0-25% 的随机抖动
-	jitter := time.Duration(rand.Int63n(int64(wait / 4)))
-	return wait + jitter
+	return wait + random.Jitter(c.config.Random, wait/4)
 }
 
 // shouldRetry 判断是否应该重试
@@ -150,6 +270,10 @@ func (c *HTTPClient) shouldRetry(err error) bool {
 	if err == nil {
 		return false
 	}
+	// URL 被拒绝或重定向超限，重试结果相同
+	if errors.Is(err, ErrURLNotAllowed) || errors.Is(err, ErrTooManyRedirects) {
+		return false
+	}
 	errStr := err.Error()
 	// 不重试 4xx 错误
 	if len(errStr) > 5 && errStr[:5] == "HTTP " {
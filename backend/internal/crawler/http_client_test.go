@@ -0,0 +1,252 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fund-analyzer/pkg/random"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allowHostsOrPublic 测试用校验器：放行指定的本地测试服务器，其余地址按公网规则校验
+func allowHostsOrPublic(servers ...*httptest.Server) URLValidator {
+	allowed := make(map[string]bool, len(servers))
+	for _, srv := range servers {
+		u, _ := url.Parse(srv.URL)
+		allowed[u.Host] = true
+	}
+	return func(ctx context.Context, u *url.URL) error {
+		if allowed[u.Host] {
+			return nil
+		}
+		return ValidatePublicURL(ctx, u)
+	}
+}
+
+func newRedirectTestClient(maxRedirects int, validator URLValidator) *HTTPClient {
+	return NewHTTPClient(HTTPClientConfig{
+		Timeout:       5 * time.Second,
+		MaxRetries:    2,
+		RetryBaseWait: time.Millisecond,
+		RetryMaxWait:  time.Millisecond,
+		MaxRedirects:  maxRedirects,
+		URLValidator:  validator,
+	})
+}
+
+func TestHTTPClient_RedirectToPrivateIPBlocked(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Redirect(w, r, "http://10.0.0.1/admin", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	client := newRedirectTestClient(5, allowHostsOrPublic(srv))
+
+	_, err := client.Get(context.Background(), srv.URL, nil)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrURLNotAllowed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits), "blocked redirect should not be retried")
+}
+
+func TestHTTPClient_AllowedRedirectChainFollowed(t *testing.T) {
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("final page"))
+	}))
+	defer final.Close()
+
+	hop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, final.URL+"/article", http.StatusMovedPermanently)
+	}))
+	defer hop.Close()
+
+	start := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, hop.URL, http.StatusFound)
+	}))
+	defer start.Close()
+
+	client := newRedirectTestClient(5, allowHostsOrPublic(start, hop, final))
+
+	data, err := client.Get(context.Background(), start.URL, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "final page", string(data))
+}
+
+func TestHTTPClient_RedirectLoopCapped(t *testing.T) {
+	var hits int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Redirect(w, r, srv.URL+"/loop", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	client := newRedirectTestClient(3, allowHostsOrPublic(srv))
+
+	_, err := client.Get(context.Background(), srv.URL, nil)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+	// 初始请求 + 3 次重定向，且不重试
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+}
+
+func TestHTTPClient_InitialPrivateURLBlocked(t *testing.T) {
+	client := newRedirectTestClient(5, ValidatePublicURL)
+
+	_, err := client.Get(context.Background(), "http://127.0.0.1:1/", nil)
+
+	assert.ErrorIs(t, err, ErrURLNotAllowed)
+}
+
+func TestHTTPClient_DialControlBlocksRebindingToInternalAddress(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer srv.Close()
+
+	// 校验器放行（模拟校验时解析到公网地址），实际拨号指向回环地址
+	client := NewHTTPClient(HTTPClientConfig{
+		Timeout:       5 * time.Second,
+		MaxRetries:    2,
+		RetryBaseWait: time.Millisecond,
+		RetryMaxWait:  time.Millisecond,
+		URLValidator:  allowHostsOrPublic(srv),
+		DialControl:   RejectInternalDial,
+	})
+
+	_, err := client.Get(context.Background(), srv.URL, nil)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrURLNotAllowed)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits), "connection to internal address should never be made")
+}
+
+func TestRejectInternalDial(t *testing.T) {
+	assert.NoError(t, RejectInternalDial("tcp4", "8.8.8.8:443", nil))
+	assert.NoError(t, RejectInternalDial("tcp6", "[2001:4860:4860::8888]:443", nil))
+	assert.ErrorIs(t, RejectInternalDial("tcp4", "127.0.0.1:80", nil), ErrURLNotAllowed)
+	assert.ErrorIs(t, RejectInternalDial("tcp4", "169.254.169.254:80", nil), ErrURLNotAllowed)
+	assert.ErrorIs(t, RejectInternalDial("tcp6", "[::1]:80", nil), ErrURLNotAllowed)
+	assert.ErrorIs(t, RejectInternalDial("tcp4", "not-an-address", nil), ErrURLNotAllowed)
+}
+
+func TestValidatePublicURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://8.8.8.8/", true},
+		{"http://[2001:4860:4860::8888]/", true},
+		{"http://127.0.0.1/", false},
+		{"http://localhost/", false},
+		{"http://10.1.2.3/", false},
+		{"http://172.16.0.1/", false},
+		{"http://192.168.1.1/", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/", false},
+		{"http://0.0.0.0/", false},
+		{"http://[::1]/", false},
+		{"http://[fd00::1]/", false},
+		{"http://[::ffff:127.0.0.1]/", false},
+		{"ftp://8.8.8.8/", false},
+		{"file:///etc/passwd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			err = ValidatePublicURL(context.Background(), u)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrURLNotAllowed), "expected ErrURLNotAllowed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_BackoffJitterReproducibleWithSeed(t *testing.T) {
+	newClient := func() *HTTPClient {
+		return NewHTTPClient(HTTPClientConfig{
+			RetryBaseWait: 100 * time.Millisecond,
+			RetryMaxWait:  time.Second,
+			Random:        random.NewSeeded(42),
+		})
+	}
+	a, b := newClient(), newClient()
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		wait := a.calculateBackoff(attempt)
+		assert.Equal(t, wait, b.calculateBackoff(attempt), "attempt %d", attempt)
+
+		base := min(100*time.Millisecond*time.Duration(1<<uint(attempt-1)), time.Second)
+		assert.GreaterOrEqual(t, wait, base)
+		assert.Less(t, wait, base+base/4)
+	}
+}
+
+func TestHTTPClient_BackoffWithoutWaitDoesNotPanic(t *testing.T) {
+	client := NewHTTPClient(HTTPClientConfig{})
+	assert.Equal(t, time.Duration(0), client.calculateBackoff(1))
+}
+
+// newCookieTestServer 首次请求下发会话 Cookie，并记录每次请求携带的 Cookie
+func newCookieTestServer(t *testing.T, received *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*received = append(*received, r.Header.Get("Cookie"))
+		if _, err := r.Cookie("session"); err != nil {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/"})
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPClient_CookieJarReusesSession(t *testing.T) {
+	var received []string
+	srv := newCookieTestServer(t, &received)
+	client := NewHTTPClient(HTTPClientConfig{Timeout: 5 * time.Second}).WithSource("duckduckgo").WithCookieJar(NewCookieJar())
+
+	_, err := client.Get(context.Background(), srv.URL+"/html", nil)
+	require.NoError(t, err)
+	_, err = client.Get(context.Background(), srv.URL+"/html?q=fund", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "session=abc123"}, received)
+}
+
+func TestHTTPClient_CookieJarOptIn(t *testing.T) {
+	var received []string
+	srv := newCookieTestServer(t, &received)
+	base := NewHTTPClient(HTTPClientConfig{Timeout: 5 * time.Second})
+	withJar := base.WithSource("duckduckgo").WithCookieJar(NewCookieJar())
+	withoutJar := base.WithSource("eastmoney")
+
+	_, err := withJar.Get(context.Background(), srv.URL, nil)
+	require.NoError(t, err)
+
+	// 未启用 Cookie 的数据源不保存、也不共享其他数据源的会话
+	_, err = withoutJar.Get(context.Background(), srv.URL, nil)
+	require.NoError(t, err)
+	_, err = withoutJar.Get(context.Background(), srv.URL, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "", ""}, received)
+}
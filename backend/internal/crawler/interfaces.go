@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"context"
+
+	"fund-analyzer/internal/model"
+)
+
+// MarketDataCrawler 市场行情数据源（指数、分时、成交量）
+type MarketDataCrawler interface {
+	GetMarketIndices(ctx context.Context, market string) ([]model.MarketIndex, error)
+	GetMinuteData(ctx context.Context, code string) ([]model.MinuteData, error)
+	GetVolumeTrend(ctx context.Context) ([]model.VolumeTrend, error)
+}
+
+// NewsCrawler 快讯数据源
+type NewsCrawler interface {
+	GetNewsFlash(ctx context.Context, count int) ([]model.NewsItem, error)
+}
+
+// NewsPageCrawler 支持按起始位置分页的快讯数据源
+type NewsPageCrawler interface {
+	// GetNewsFlashPage 从第 start 条（按发布时间倒序，0 起）开始获取 count 条快讯
+	GetNewsFlashPage(ctx context.Context, start, count int) ([]model.NewsItem, error)
+}
+
+// GoldDataCrawler 贵金属数据源
+type GoldDataCrawler interface {
+	GetRealTimeGold(ctx context.Context) ([]model.PreciousMetal, error)
+	GetGoldHistory(ctx context.Context, days int) ([]model.GoldPrice, error)
+}
+
+// SectorDataCrawler 行业板块数据源
+type SectorDataCrawler interface {
+	GetSectorList(ctx context.Context) ([]model.Sector, error)
+	GetSectorFunds(ctx context.Context, sectorCode string) ([]model.SectorFund, error)
+}
+
+// FundDataCrawler 基金数据源
+type FundDataCrawler interface {
+	SearchFund(ctx context.Context, code string) (*model.FundInfo, error)
+	GetFundValuation(ctx context.Context, productID string) (*model.FundValuation, error)
+}
+
+// FundMetaCrawler 基金经理、规模等元数据数据源
+type FundMetaCrawler interface {
+	GetFundMeta(ctx context.Context, productID string) (*model.FundMeta, error)
+}
+
+// FundHistoryCrawler 基金历史净值曲线数据源
+type FundHistoryCrawler interface {
+	// GetFundCurves 获取指定区间（1m/3m/6m/1y/3y/5y/all）的历史净值
+	GetFundCurves(ctx context.Context, productID string, interval string) ([]model.FundPoint, error)
+}
+
+// 编译期检查现有爬虫实现了对应接口
+var (
+	_ MarketDataCrawler  = (*BaiduCrawler)(nil)
+	_ NewsCrawler        = (*BaiduCrawler)(nil)
+	_ NewsPageCrawler    = (*BaiduCrawler)(nil)
+	_ GoldDataCrawler    = (*GoldCrawler)(nil)
+	_ SectorDataCrawler  = (*EastMoneyCrawler)(nil)
+	_ FundDataCrawler    = (*AntCrawler)(nil)
+	_ FundMetaCrawler    = (*AntCrawler)(nil)
+	_ FundHistoryCrawler = (*AntCrawler)(nil)
+)
@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PublishTimeLayout 发布时间展示格式
+const PublishTimeLayout = "2006-01-02 15:04:05"
+
+// ErrInvalidPublishTime 发布时间无法解析
+var ErrInvalidPublishTime = errors.New("invalid publish time")
+
+// epochMillisThreshold 大于等于该值的纯数字按毫秒时间戳处理
+// 1e12 毫秒对应 2001 年，而 1e12 秒已远超合理年份
+const epochMillisThreshold = 1_000_000_000_000
+
+// ShanghaiLocation 数据源所在时区，系统缺少时区数据时回退为固定 UTC+8
+var ShanghaiLocation = loadShanghaiLocation()
+
+func loadShanghaiLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}
+
+// publishTimeLayouts 字符串形式发布时间的候选格式（无时区的按北京时间解析）
+var publishTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// ParsePublishTime 解析发布时间，返回北京时间
+// 支持秒级/毫秒级时间戳以及常见日期字符串，无法解析时返回 ErrInvalidPublishTime
+func ParsePublishTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("%w: empty value", ErrInvalidPublishTime)
+	}
+
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if n <= 0 {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidPublishTime, raw)
+		}
+		if n >= epochMillisThreshold {
+			return time.UnixMilli(n).In(ShanghaiLocation), nil
+		}
+		return time.Unix(n, 0).In(ShanghaiLocation), nil
+	}
+
+	for _, layout := range publishTimeLayouts {
+		if t, err := time.ParseInLocation(layout, raw, ShanghaiLocation); err == nil {
+			return t.In(ShanghaiLocation), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidPublishTime, raw)
+}
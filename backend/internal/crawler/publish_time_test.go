@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublishTime(t *testing.T) {
+	// 2024-03-01 09:30:00 北京时间
+	want := time.Date(2024, 3, 1, 1, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"epoch seconds", "1709256600"},
+		{"epoch millis", "1709256600000"},
+		{"padded seconds", " 1709256600 "},
+		{"RFC3339", "2024-03-01T09:30:00+08:00"},
+		{"RFC3339 UTC", "2024-03-01T01:30:00Z"},
+		{"local datetime", "2024-03-01 09:30:00"},
+		{"slash datetime", "2024/03/01 09:30:00"},
+		{"minutes only", "2024-03-01 09:30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePublishTime(tt.raw)
+			require.NoError(t, err)
+			assert.True(t, got.Equal(want), "got %v", got)
+			assert.Equal(t, "2024-03-01 09:30:00", got.Format(PublishTimeLayout))
+			assert.Equal(t, int64(1709256600000), got.UnixMilli())
+		})
+	}
+}
+
+func TestParsePublishTime_Malformed(t *testing.T) {
+	for _, raw := range []string{"", "   ", "yesterday", "0", "-5", "2024-13-45 99:99:99", "17092566xx"} {
+		t.Run(raw, func(t *testing.T) {
+			got, err := ParsePublishTime(raw)
+			assert.ErrorIs(t, err, ErrInvalidPublishTime)
+			assert.True(t, got.IsZero())
+		})
+	}
+}
+
+func TestParsePublishTime_ShanghaiZone(t *testing.T) {
+	got, err := ParsePublishTime("1709256600")
+	require.NoError(t, err)
+
+	_, offset := got.Zone()
+	assert.Equal(t, 8*60*60, offset)
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNonJSONResponse 上游返回了 HTML 错误页、拦截页等非 JSON 内容
+var ErrNonJSONResponse = errors.New("upstream returned non-JSON response")
+
+// responseSampleBytes 错误中保留的响应样本最大字节数
+const responseSampleBytes = 200
+
+// jsonpPattern JSONP 包装，如 jQuery123_456({...}); 或 callback({...})
+var jsonpPattern = regexp.MustCompile(`^[A-Za-z_$][\w$.]*\s*\(([\s\S]*)\)\s*;?$`)
+
+// NonJSONResponseError 上游响应不是 JSON，Sample 为截断后的响应开头便于排查
+type NonJSONResponseError struct {
+	Sample string
+}
+
+func (e *NonJSONResponseError) Error() string {
+	return fmt.Sprintf("%v: %q", ErrNonJSONResponse, e.Sample)
+}
+
+func (e *NonJSONResponseError) Unwrap() error {
+	return ErrNonJSONResponse
+}
+
+// decodeJSONResponse 解析上游 JSON 响应，自动去除 JSONP 包装
+// 响应为 HTML 或其他非 JSON 内容时返回 *NonJSONResponseError
+func decodeJSONResponse(data []byte, v interface{}) error {
+	body := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if m := jsonpPattern.FindSubmatch(body); m != nil {
+		body = bytes.TrimSpace(m[1])
+	}
+
+	if len(body) == 0 || (body[0] != '{' && body[0] != '[') {
+		return &NonJSONResponseError{Sample: responseSample(data)}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parse response failed: %w", err)
+	}
+	return nil
+}
+
+// responseSample 截取响应开头并合并空白，保证不截断多字节字符
+func responseSample(data []byte) string {
+	if len(data) > responseSampleBytes {
+		data = data[:responseSampleBytes]
+		for len(data) > 0 && !utf8.Valid(data) {
+			data = data[:len(data)-1]
+		}
+	}
+	return strings.Join(strings.Fields(string(data)), " ")
+}
@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+var (
+	// ErrURLNotAllowed URL 不在允许范围内（非 http/https 或指向内网地址）
+	ErrURLNotAllowed = errors.New("url not allowed")
+	// ErrTooManyRedirects 重定向次数超过上限
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// URLValidator 校验请求目标 URL，返回错误时拒绝请求
+type URLValidator func(ctx context.Context, u *url.URL) error
+
+// carrierGradeNAT 运营商级 NAT 地址段（100.64.0.0/10），同样视为内网
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// ValidatePublicURL 仅允许指向公网地址的 http/https URL
+// 主机名会被解析，任一解析结果为内网地址即拒绝，防止 SSRF
+// 拨号时的解析结果可能与此处不同（DNS 重绑定），此处仅用于快速失败，实际连接由 RejectInternalDial 校验
+func ValidatePublicURL(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrURLNotAllowed, u.Scheme)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrURLNotAllowed)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if isInternalIP(ip) {
+			return fmt.Errorf("%w: internal address %s", ErrURLNotAllowed, ip)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolve host %s failed: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if isInternalIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to internal address %s", ErrURLNotAllowed, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// RejectInternalDial 拨号前校验实际连接的地址，作为 net.Dialer.Control 使用
+// address 为解析后的 IP:端口，指向内网地址时拒绝连接
+func RejectInternalDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid dial address %s", ErrURLNotAllowed, address)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isInternalIP(ip) {
+		return fmt.Errorf("%w: dial to internal address %s", ErrURLNotAllowed, host)
+	}
+	return nil
+}
+
+// isInternalIP 判断是否为回环、私有、链路本地等非公网地址
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		carrierGradeNAT.Contains(ip)
+}
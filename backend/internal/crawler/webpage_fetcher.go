@@ -28,13 +28,20 @@ type WebpageFetcher interface {
 type webpageFetcherImpl struct {
 	client  *HTTPClient
 	breaker *CircuitBreaker
+	limits  HTMLParseLimits
 }
 
-// NewWebpageFetcher 创建网页内容获取器
+// NewWebpageFetcher 创建网页内容获取器，使用默认 HTML 解析限制
 func NewWebpageFetcher(client *HTTPClient, breaker *CircuitBreaker) WebpageFetcher {
+	return NewWebpageFetcherWithLimits(client, breaker, DefaultHTMLParseLimits())
+}
+
+// NewWebpageFetcherWithLimits 创建使用指定 HTML 解析限制的网页内容获取器
+func NewWebpageFetcherWithLimits(client *HTTPClient, breaker *CircuitBreaker, limits HTMLParseLimits) WebpageFetcher {
 	return &webpageFetcherImpl{
 		client:  client,
 		breaker: breaker,
+		limits:  limits.withDefaults(),
 	}
 }
 
@@ -62,7 +69,7 @@ func (f *webpageFetcherImpl) Fetch(ctx context.Context, url string) (string, err
 		}
 
 		// 提取主要文本内容
-		content, err = extractMainContent(string(utf8Data))
+		content, err = extractMainContentWithLimits(string(utf8Data), f.limits)
 		if err != nil {
 			return fmt.Errorf("extract content failed: %w", err)
 		}
@@ -108,11 +115,16 @@ func convertToUTF8(data []byte) ([]byte, error) {
 	return data, nil
 }
 
-// extractMainContent 从 HTML 中提取主要文本内容
+// extractMainContent 从 HTML 中提取主要文本内容，使用默认解析限制
 func extractMainContent(htmlContent string) (string, error) {
-	doc, err := html.Parse(strings.NewReader(htmlContent))
+	return extractMainContentWithLimits(htmlContent, DefaultHTMLParseLimits())
+}
+
+// extractMainContentWithLimits 在解析限制内从 HTML 中提取主要文本内容
+func extractMainContentWithLimits(htmlContent string, limits HTMLParseLimits) (string, error) {
+	doc, err := parseHTMLWithLimits(htmlContent, limits)
 	if err != nil {
-		return "", fmt.Errorf("parse HTML failed: %w", err)
+		return "", err
 	}
 
 	var textBuilder strings.Builder
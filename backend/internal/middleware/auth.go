@@ -3,6 +3,7 @@ package middleware
 import (
 	"strings"
 
+	"fund-analyzer/internal/model"
 	"fund-analyzer/internal/service"
 	"fund-analyzer/pkg/response"
 
@@ -15,6 +16,9 @@ const ContextKeyUserID = "user_id"
 // ContextKeyUserEmail 用户邮箱上下文键
 const ContextKeyUserEmail = "user_email"
 
+// ContextKeyUserRole 用户角色上下文键
+const ContextKeyUserRole = "user_role"
+
 // Auth 认证中间件
 func Auth(authService service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -47,6 +51,7 @@ func Auth(authService service.AuthService) gin.HandlerFunc {
 		// 将用户信息存入 Context
 		c.Set(ContextKeyUserID, claims.UserID)
 		c.Set(ContextKeyUserEmail, claims.Email)
+		c.Set(ContextKeyUserRole, claims.Role)
 
 		c.Next()
 	}
@@ -69,3 +74,24 @@ func GetUserEmail(c *gin.Context) string {
 	}
 	return ""
 }
+
+// GetUserRole 从 Context 获取已认证用户的角色
+func GetUserRole(c *gin.Context) model.UserRole {
+	role, _ := c.Get(ContextKeyUserRole)
+	if r, ok := role.(model.UserRole); ok {
+		return r
+	}
+	return ""
+}
+
+// RequireAdmin 仅允许管理员访问，需在 Auth 之后使用
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserRole(c) != model.UserRoleAdmin {
+			response.Forbidden(c, "Admin permission required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAdminTestRouter(role model.UserRole) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if role != "" {
+			c.Set(ContextKeyUserID, int64(1))
+			c.Set(ContextKeyUserRole, role)
+		}
+		c.Next()
+	})
+	r.Use(RequireAdmin())
+	r.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name string
+		role model.UserRole
+		want int
+	}{
+		{"admin", model.UserRoleAdmin, http.StatusOK},
+		{"normal user", model.UserRoleUser, http.StatusForbidden},
+		{"missing role", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := sendRequests(newAdminTestRouter(tt.role), "/admin", 1, nil)
+			assert.Equal(t, []int{tt.want}, codes)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit 限制请求体大小，超出时返回 413
+// 请求体在进入业务处理前读入内存（最多 maxBytes），因此无论是否声明 Content-Length，
+// 超限请求都会被拒绝，且不会在参数校验前耗尽内存；maxBytes <= 0 时不限制
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		// 声明的长度已超限时直接拒绝，无需读取
+		if c.Request.ContentLength > maxBytes {
+			rejectOversizedBody(c, maxBytes)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				rejectOversizedBody(c, maxBytes)
+				return
+			}
+			response.BadRequest(c, "Failed to read request body")
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// rejectOversizedBody 返回 413 并终止请求
+func rejectOversizedBody(c *gin.Context, maxBytes int64) {
+	response.PayloadTooLarge(c, fmt.Sprintf("Request body too large, limit is %d bytes", maxBytes))
+	c.Abort()
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBodyLimitTestRouter 创建测试路由，/auth 限制 32 字节，/chat 限制 1KB，处理函数回显请求体
+func newBodyLimitTestRouter() *gin.Engine {
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	}
+
+	r := gin.New()
+	r.POST("/auth", BodyLimit(32), echo)
+	r.POST("/chat", BodyLimit(1024), echo)
+	return r
+}
+
+func postBody(r *gin.Engine, path string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestBodyLimit_NormalBodyPassesThrough(t *testing.T) {
+	r := newBodyLimitTestRouter()
+	body := `{"email":"a@b.com"}`
+
+	w := postBody(r, "/auth", strings.NewReader(body))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+}
+
+func TestBodyLimit_OversizedBodyRejected(t *testing.T) {
+	r := newBodyLimitTestRouter()
+	body := `{"message":"` + strings.Repeat("x", 64) + `"}`
+
+	w := postBody(r, "/auth", strings.NewReader(body))
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp response.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, response.CodePayloadTooLarge, resp.Code)
+}
+
+func TestBodyLimit_OversizedBodyWithoutContentLength(t *testing.T) {
+	r := newBodyLimitTestRouter()
+
+	// io.MultiReader 不是 httptest 能识别长度的类型，模拟分块传输
+	body := io.MultiReader(strings.NewReader(strings.Repeat("x", 40)))
+	w := postBody(r, "/auth", body)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestBodyLimit_PerRouteLimits(t *testing.T) {
+	r := newBodyLimitTestRouter()
+	body := `{"message":"` + strings.Repeat("x", 200) + `"}`
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, postBody(r, "/auth", strings.NewReader(body)).Code)
+	assert.Equal(t, http.StatusOK, postBody(r, "/chat", strings.NewReader(body)).Code)
+}
+
+func TestBodyLimit_ExactLimitAllowed(t *testing.T) {
+	r := newBodyLimitTestRouter()
+
+	w := postBody(r, "/auth", strings.NewReader(strings.Repeat("x", 32)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errCoalescedPanic 共享执行的处理函数发生 panic
+var errCoalescedPanic = errors.New("coalesced handler panicked")
+
+// RequestCoalescer 合并并发的相同 GET 请求
+// 白名单内的接口在同一时刻只执行一次处理函数，其余请求等待并复用同一份响应，
+// 用于开盘时段缓存未命中引起的瞬时并发。白名单只能包含与用户无关的接口
+type RequestCoalescer struct {
+	paths   map[string]bool
+	waiting atomic.Int64 // 正在执行或等待合并结果的请求数，在 mu 下与加入合并同时递增
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall // 正在执行的合并键，创建与删除都在 mu 下完成
+}
+
+// coalescedCall 一次正在执行的共享处理，done 关闭后 resp 与 err 可读
+type coalescedCall struct {
+	done chan struct{}
+	resp *coalescedResponse
+	err  error
+}
+
+// coalescedResponse 被合并请求共享的响应
+type coalescedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewRequestCoalescer 创建请求合并器，paths 为路由路径（如 /api/v1/market/indices）
+func NewRequestCoalescer(paths []string) *RequestCoalescer {
+	set := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		set[path] = true
+	}
+	return &RequestCoalescer{paths: set, calls: make(map[string]*coalescedCall)}
+}
+
+// Handler 请求合并中间件
+// 按请求方法、路由路径与排序后的查询参数合并，需放在认证与限流之后
+// 首个请求在自己的 gin.Context 上执行处理函数，处理函数使用不随请求取消的 context，
+// 首个请求断开不会使其余请求失败；其余请求断开时立即返回，不再等待共享结果
+func (rc *RequestCoalescer) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || !rc.paths[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		key := c.Request.Method + " " + c.Request.URL.Path + "?" + c.Request.URL.Query().Encode()
+
+		defer rc.waiting.Add(-1)
+
+		rc.mu.Lock()
+		rc.waiting.Add(1)
+		call, shared := rc.calls[key]
+		if !shared {
+			call = &coalescedCall{done: make(chan struct{})}
+			rc.calls[key] = call
+		}
+		rc.mu.Unlock()
+
+		if shared {
+			select {
+			case <-call.done:
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+		} else {
+			// 先移除再唤醒等待者，之后到达的请求会发起新的执行而不是加入已结束的执行
+			call.resp, call.err = rc.execute(c)
+			rc.mu.Lock()
+			delete(rc.calls, key)
+			rc.mu.Unlock()
+			close(call.done)
+		}
+		if call.err != nil {
+			// 在各自的请求 goroutine 中重新 panic，交给 Recovery 处理
+			panic(call.err)
+		}
+
+		resp := call.resp
+		for k, values := range resp.header {
+			c.Writer.Header()[k] = values
+		}
+		c.Writer.WriteHeader(resp.status)
+		_, _ = c.Writer.Write(resp.body)
+		c.Abort()
+	}
+}
+
+// execute 在不随请求取消的 context 上执行后续处理函数并缓冲其响应
+// 处理函数发生 panic 时转换为错误，由执行的请求与每个等待的请求各自重新 panic
+func (rc *RequestCoalescer) execute(c *gin.Context) (resp *coalescedResponse, err error) {
+	original := c.Writer
+	request := c.Request
+	rw := &coalesceWriter{
+		ResponseWriter: original,
+		header:         make(http.Header),
+		status:         http.StatusOK,
+	}
+	c.Writer = rw
+	c.Request = request.WithContext(context.WithoutCancel(request.Context()))
+	defer func() {
+		c.Writer = original
+		c.Request = request
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errCoalescedPanic, r)
+		}
+	}()
+
+	c.Next()
+
+	return &coalescedResponse{
+		status: rw.status,
+		header: rw.header,
+		body:   rw.buf.Bytes(),
+	}, nil
+}
+
+// coalesceWriter 将响应头、状态码与响应体写入缓冲，由中间件统一写给每个请求
+type coalesceWriter struct {
+	gin.ResponseWriter
+	header  http.Header
+	status  int
+	written bool
+	buf     bytes.Buffer
+}
+
+// Header 返回缓冲的响应头，避免并发请求共享同一个 map
+func (w *coalesceWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader 记录状态码
+func (w *coalesceWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow 标记已写入，实际写出由中间件完成
+func (w *coalesceWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+// Write 写入响应体缓冲
+func (w *coalesceWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.buf.Write(data)
+}
+
+// WriteString 写入字符串响应体缓冲
+func (w *coalesceWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.buf.WriteString(s)
+}
+
+// Status 返回状态码
+func (w *coalesceWriter) Status() int {
+	return w.status
+}
+
+// Size 返回已缓冲的响应体字节数，未写入时为 -1
+func (w *coalesceWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+// Written 是否已写入响应
+func (w *coalesceWriter) Written() bool {
+	return w.written
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// newCoalesceTestRouter 创建测试路由，/market/indices 阻塞直到 release 被关闭
+func newCoalesceTestRouter(coalescer *RequestCoalescer, calls *int32, release <-chan struct{}) *gin.Engine {
+	r := gin.New()
+	r.Use(coalescer.Handler())
+	r.GET("/market/indices", func(c *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		<-release
+		c.Header("X-Computed", "true")
+		c.JSON(http.StatusOK, gin.H{"call": n, "range": c.Query("range")})
+	})
+	r.GET("/funds", func(c *gin.Context) {
+		atomic.AddInt32(calls, 1)
+		c.String(http.StatusOK, "funds")
+	})
+	return r
+}
+
+func getPath(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// TestRequestCoalescer_ConcurrentRequestsShareOneExecution tests that concurrent identical requests run the handler once
+func TestRequestCoalescer_ConcurrentRequestsShareOneExecution(t *testing.T) {
+	coalescer := NewRequestCoalescer([]string{"/market/indices"})
+	var calls int32
+	release := make(chan struct{})
+	r := newCoalesceTestRouter(coalescer, &calls, release)
+
+	const concurrent = 20
+	responses := make([]*httptest.ResponseRecorder, concurrent)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = getPath(r, "/market/indices?range=1d")
+		}(i)
+	}
+
+	// 所有请求都进入合并后再返回结果
+	assert.Eventually(t, func() bool {
+		return coalescer.waiting.Load() == concurrent
+	}, 2*time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, w := range responses {
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get("X-Computed"))
+		assert.JSONEq(t, `{"call":1,"range":"1d"}`, w.Body.String())
+	}
+}
+
+// TestRequestCoalescer_KeysAndWhitelist tests that different queries and non-whitelisted paths are not merged
+func TestRequestCoalescer_KeysAndWhitelist(t *testing.T) {
+	coalescer := NewRequestCoalescer([]string{"/market/indices"})
+	var calls int32
+	release := make(chan struct{})
+	close(release)
+	r := newCoalesceTestRouter(coalescer, &calls, release)
+
+	// 依次请求不会合并，查询参数不同的结果互不影响
+	assert.JSONEq(t, `{"call":1,"range":"1d"}`, getPath(r, "/market/indices?range=1d").Body.String())
+	assert.JSONEq(t, `{"call":2,"range":"5d"}`, getPath(r, "/market/indices?range=5d").Body.String())
+
+	// 不在白名单的接口直接执行
+	w := getPath(r, "/funds")
+	assert.Equal(t, "funds", w.Body.String())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// TestRequestCoalescer_WaiterCancelReturnsEarly tests that a waiting request leaves as soon as its client disconnects
+func TestRequestCoalescer_WaiterCancelReturnsEarly(t *testing.T) {
+	coalescer := NewRequestCoalescer([]string{"/market/indices"})
+	var calls int32
+	release := make(chan struct{})
+	r := newCoalesceTestRouter(coalescer, &calls, release)
+
+	leaderDone := make(chan *httptest.ResponseRecorder)
+	go func() { leaderDone <- getPath(r, "/market/indices") }()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, 2*time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiterDone := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/market/indices", nil).WithContext(ctx))
+		waiterDone <- w
+	}()
+	assert.Eventually(t, func() bool { return coalescer.waiting.Load() == 2 }, 2*time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case w := <-waiterDone:
+		assert.Empty(t, w.Body.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled waiter should not wait for the shared result")
+	}
+
+	close(release)
+	w := <-leaderDone
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestRequestCoalescer_LeaderCancelDoesNotCancelSharedFetch tests that the first request's disconnect does not fail the others
+func TestRequestCoalescer_LeaderCancelDoesNotCancelSharedFetch(t *testing.T) {
+	coalescer := NewRequestCoalescer([]string{"/market/indices"})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handlerErr atomic.Value
+	r := gin.New()
+	r.Use(coalescer.Handler())
+	r.GET("/market/indices", func(c *gin.Context) {
+		close(started)
+		<-release
+		if err := c.Request.Context().Err(); err != nil {
+			handlerErr.Store(err)
+		}
+		c.String(http.StatusOK, "indices")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/market/indices", nil).WithContext(ctx))
+	}()
+	<-started
+
+	waiterDone := make(chan *httptest.ResponseRecorder)
+	go func() { waiterDone <- getPath(r, "/market/indices") }()
+	assert.Eventually(t, func() bool { return coalescer.waiting.Load() == 2 }, 2*time.Second, time.Millisecond)
+
+	cancel()
+	close(release)
+
+	w := <-waiterDone
+	<-leaderDone
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "indices", w.Body.String())
+	assert.Nil(t, handlerErr.Load(), "shared fetch should not see the first request's cancellation")
+}
+
+// TestRequestCoalescer_PanicRecovered tests that a panic in the shared handler reaches Recovery instead of crashing the process
+func TestRequestCoalescer_PanicRecovered(t *testing.T) {
+	coalescer := NewRequestCoalescer([]string{"/market/indices"})
+	r := gin.New()
+	r.Use(Recovery(zap.NewNop()), coalescer.Handler())
+	r.GET("/market/indices", func(c *gin.Context) {
+		panic("upstream parser bug")
+	})
+
+	w := getPath(r, "/market/indices")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+// TestRequestCoalescer_RequestsAtCallBoundary tests requests arriving while a shared call finishes:
+// one that joins before the call is released shares its result, and one that arrives afterwards
+// runs the handler on its own context and waits for it even when cancelled
+func TestRequestCoalescer_RequestsAtCallBoundary(t *testing.T) {
+	coalescer := NewRequestCoalescer([]string{"/market/indices"})
+	var calls int32
+	release := make(chan struct{})
+	lateDone := make(chan *httptest.ResponseRecorder, 1)
+	r := gin.New()
+	r.Use(coalescer.Handler())
+	r.GET("/market/indices", func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.String(http.StatusOK, "call %d", n)
+		switch n {
+		case 1:
+			// 处理函数已写完响应、共享执行尚未结束时到达的请求
+			go func() { lateDone <- getPath(r, "/market/indices") }()
+			assert.Eventually(t, func() bool { return coalescer.waiting.Load() == 2 }, 2*time.Second, time.Millisecond)
+		case 2:
+			<-release
+		}
+	})
+
+	w := getPath(r, "/market/indices")
+	assert.Equal(t, "call 1", w.Body.String())
+	late := <-lateDone
+	assert.Equal(t, "call 1", late.Body.String(), "request joining a finishing call should share its result")
+	assert.Empty(t, coalescer.calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nextDone := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/market/indices", nil).WithContext(ctx))
+		nextDone <- w
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, 2*time.Second, time.Millisecond)
+
+	select {
+	case <-nextDone:
+		t.Fatal("request must not return while the handler still runs on its gin.Context")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	next := <-nextDone
+	assert.Equal(t, "call 2", next.Body.String())
+}
@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的压缩算法
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// CompressionConfig 响应压缩配置
+type CompressionConfig struct {
+	MinSize     int      // 响应体小于该字节数时不压缩
+	GzipLevel   int      // gzip 压缩级别（1-9）
+	BrotliLevel int      // brotli 压缩级别（0-11）
+	Algorithms  []string // 服务端支持的算法，按偏好排序
+}
+
+// DefaultCompressionConfig 默认压缩配置
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		MinSize:     1024,
+		GzipLevel:   gzip.DefaultCompression,
+		BrotliLevel: 4,
+		Algorithms:  []string{EncodingBrotli, EncodingGzip},
+	}
+}
+
+// Compress 响应压缩中间件
+// 根据 Accept-Encoding 协商算法；SSE、调用 Flush 的流式响应与附件下载保持不压缩，直接写出
+func Compress(config CompressionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := NegotiateEncoding(c.GetHeader("Accept-Encoding"), config.Algorithms)
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: c.Writer,
+			status:         http.StatusOK,
+		}
+		c.Writer = cw
+		defer func() {
+			c.Writer = cw.ResponseWriter
+		}()
+
+		c.Next()
+
+		cw.finish(encoding, config)
+	}
+}
+
+// NegotiateEncoding 根据 Accept-Encoding 选择客户端接受的最佳算法
+// q 值高者优先，q 值相同时按服务端偏好顺序选择；未匹配返回空字符串
+func NegotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseEncodingQuality(part)
+		if name == "" {
+			continue
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		qualities[name] = q
+	}
+
+	best := ""
+	bestQ := 0.0
+	for _, enc := range supported {
+		q, ok := qualities[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best = enc
+			bestQ = q
+		}
+	}
+	return best
+}
+
+// parseEncodingQuality 解析单个编码项，如 "gzip;q=0.8"
+func parseEncodingQuality(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	name := strings.ToLower(strings.TrimSpace(fields[0]))
+	q := 1.0
+	for _, param := range fields[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return name, q
+}
+
+// compressWriter 缓冲响应体，结束时决定是否压缩
+// 遇到 SSE、附件或已编码的响应时切换为直接写入
+type compressWriter struct {
+	gin.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	passthrough bool
+}
+
+// WriteHeader 记录状态码，直到确定是否压缩再写出
+func (w *compressWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+// WriteHeaderNow 缓冲期间延迟到 finish 时写出
+func (w *compressWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Write 写入响应体
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.passthrough && w.shouldPassthrough() {
+		w.startPassthrough()
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+// WriteString 写入字符串响应体
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Status 返回状态码
+func (w *compressWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Written 是否已写入响应，缓冲中已有内容也视为已写入
+func (w *compressWriter) Written() bool {
+	if w.passthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.buf.Len() > 0
+}
+
+// Flush 流式输出时放弃压缩，先写出已缓冲的内容
+func (w *compressWriter) Flush() {
+	if !w.passthrough {
+		w.startPassthrough()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// shouldPassthrough 判断响应是否不应压缩
+func (w *compressWriter) shouldPassthrough() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return true
+	}
+	// 附件可能较大且逐段写出，缓冲会占用内存并在出错时无法中断连接
+	if strings.HasPrefix(strings.ToLower(header.Get("Content-Disposition")), "attachment") {
+		return true
+	}
+	return strings.HasPrefix(header.Get("Content-Type"), "text/event-stream")
+}
+
+// startPassthrough 切换为直接写入，并写出已缓冲的内容
+func (w *compressWriter) startPassthrough() {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// finish 请求处理完成后写出响应
+func (w *compressWriter) finish(encoding string, config CompressionConfig) {
+	if w.passthrough {
+		return
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	if w.buf.Len() < config.MinSize || w.buf.Len() == 0 || w.shouldPassthrough() {
+		w.writeRaw()
+		return
+	}
+
+	var compressed bytes.Buffer
+	var zw io.WriteCloser
+	switch encoding {
+	case EncodingBrotli:
+		zw = brotli.NewWriterLevel(&compressed, config.BrotliLevel)
+	case EncodingGzip:
+		gw, err := gzip.NewWriterLevel(&compressed, config.GzipLevel)
+		if err != nil {
+			w.writeRaw()
+			return
+		}
+		zw = gw
+	default:
+		w.writeRaw()
+		return
+	}
+
+	if _, err := zw.Write(w.buf.Bytes()); err != nil {
+		w.writeRaw()
+		return
+	}
+	if err := zw.Close(); err != nil {
+		w.writeRaw()
+		return
+	}
+
+	header.Set("Content-Encoding", encoding)
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(compressed.Bytes())
+}
+
+// writeRaw 写出未压缩的响应
+func (w *compressWriter) writeRaw() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
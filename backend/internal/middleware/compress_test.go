@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompressRouter(config CompressionConfig, body string) *gin.Engine {
+	r := gin.New()
+	r.Use(Compress(config))
+	r.GET("/data", func(c *gin.Context) {
+		c.String(http.StatusOK, body)
+	})
+	r.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/sse", func(c *gin.Context) {
+		sse := NewSSEWriter(c)
+		_ = sse.SendContent(body)
+		_ = sse.SendDone()
+	})
+	return r
+}
+
+func doCompressRequest(r *gin.Engine, path, acceptEncoding string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompress_PrefersBrotli(t *testing.T) {
+	body := strings.Repeat("板块数据", 1000)
+	r := newCompressRouter(DefaultCompressionConfig(), body)
+
+	w := doCompressRequest(r, "/data", "gzip, deflate, br")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Get("Vary"), "Accept-Encoding")
+
+	decoded, err := io.ReadAll(brotli.NewReader(w.Body))
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+}
+
+func TestCompress_GzipFallback(t *testing.T) {
+	body := strings.Repeat("新闻快讯", 1000)
+	r := newCompressRouter(DefaultCompressionConfig(), body)
+
+	w := doCompressRequest(r, "/data", "gzip, deflate")
+
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+}
+
+func TestCompress_BelowThreshold(t *testing.T) {
+	config := DefaultCompressionConfig()
+	config.MinSize = 4096
+	r := newCompressRouter(config, "small payload")
+
+	w := doCompressRequest(r, "/data", "br, gzip")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "small payload", w.Body.String())
+}
+
+func TestCompress_NoAcceptEncoding(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	r := newCompressRouter(DefaultCompressionConfig(), body)
+
+	w := doCompressRequest(r, "/data", "")
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, w.Body.String())
+}
+
+func TestCompress_NoBodyStatus(t *testing.T) {
+	r := newCompressRouter(DefaultCompressionConfig(), "")
+
+	w := doCompressRequest(r, "/empty", "gzip")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func TestCompress_SSEUncompressed(t *testing.T) {
+	body := strings.Repeat("分析内容", 1000)
+	r := newCompressRouter(DefaultCompressionConfig(), body)
+
+	w := doCompressRequest(r, "/sse", "br, gzip")
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.True(t, bytes.Contains(w.Body.Bytes(), []byte("data: ")))
+	assert.Contains(t, w.Body.String(), "分析内容")
+}
+
+func TestCompress_WrittenOnceBuffered(t *testing.T) {
+	var before, after bool
+	r := gin.New()
+	r.Use(Compress(DefaultCompressionConfig()))
+	r.GET("/data", func(c *gin.Context) {
+		before = c.Writer.Written()
+		c.String(http.StatusOK, "buffered")
+		after = c.Writer.Written()
+	})
+
+	doCompressRequest(r, "/data", "gzip")
+
+	assert.False(t, before)
+	assert.True(t, after, "buffered body should count as written")
+}
+
+func TestCompress_AttachmentWrittenThrough(t *testing.T) {
+	body := strings.Repeat("导出数据", 1000)
+	w := httptest.NewRecorder()
+	var streamed bool
+	r := gin.New()
+	r.Use(Compress(DefaultCompressionConfig()))
+	r.GET("/export", func(c *gin.Context) {
+		c.Header("Content-Disposition", `attachment; filename="export.json"`)
+		_, _ = c.Writer.WriteString(body)
+		// 附件不经缓冲，写出即到达客户端
+		streamed = w.Body.Len() > 0
+	})
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+
+	r.ServeHTTP(w, req)
+
+	assert.True(t, streamed)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, w.Body.String())
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	supported := []string{EncodingBrotli, EncodingGzip}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{name: "empty", accept: "", want: ""},
+		{name: "both accepted", accept: "gzip, br", want: "br"},
+		{name: "gzip only", accept: "gzip", want: "gzip"},
+		{name: "higher q wins", accept: "br;q=0.5, gzip;q=0.9", want: "gzip"},
+		{name: "explicitly refused", accept: "br;q=0, gzip;q=0", want: ""},
+		{name: "wildcard", accept: "*", want: "br"},
+		{name: "wildcard with refusal", accept: "br;q=0, *", want: "gzip"},
+		{name: "unsupported", accept: "deflate, identity", want: ""},
+		{name: "case insensitive", accept: "GZIP", want: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NegotiateEncoding(tt.accept, supported))
+		})
+	}
+}
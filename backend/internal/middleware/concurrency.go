@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserConcurrencyLimiter 按用户限制同时进行的请求数
+// 与全局 SSE 连接数限制相互独立，防止单个用户多开标签页占满 SSE 和 LLM 连接
+type UserConcurrencyLimiter struct {
+	maxPerUser int
+	active     map[int64]int
+	mu         sync.Mutex
+}
+
+// NewUserConcurrencyLimiter 创建按用户的并发限制器
+// maxPerUser <= 0 时按 1 处理
+func NewUserConcurrencyLimiter(maxPerUser int) *UserConcurrencyLimiter {
+	if maxPerUser <= 0 {
+		maxPerUser = 1
+	}
+	return &UserConcurrencyLimiter{
+		maxPerUser: maxPerUser,
+		active:     make(map[int64]int),
+	}
+}
+
+// Acquire 为用户获取一个并发名额
+func (l *UserConcurrencyLimiter) Acquire(userID int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active[userID] >= l.maxPerUser {
+		return false
+	}
+
+	l.active[userID]++
+	return true
+}
+
+// Release 释放用户的并发名额，计数归零时删除条目避免 map 无限增长
+func (l *UserConcurrencyLimiter) Release(userID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active[userID] <= 1 {
+		delete(l.active, userID)
+		return
+	}
+	l.active[userID]--
+}
+
+// Active 获取用户当前进行中的请求数
+func (l *UserConcurrencyLimiter) Active(userID int64) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[userID]
+}
+
+// LimitConcurrentPerUser 按用户限制并发的中间件
+// 处理函数返回（正常完成或客户端断开）后释放名额；未登录请求不受限制
+func LimitConcurrentPerUser(limiter *UserConcurrencyLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := GetUserID(c)
+		if userID <= 0 {
+			c.Next()
+			return
+		}
+
+		if !limiter.Acquire(userID) {
+			response.Error(c, http.StatusTooManyRequests, response.CodeAnalysisInProgress,
+				"You already have an analysis running, please wait for it to finish")
+			c.Abort()
+			return
+		}
+		defer limiter.Release(userID)
+
+		c.Next()
+	}
+}
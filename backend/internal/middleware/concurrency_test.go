@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"fund-analyzer/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConcurrencyTestRouter 创建测试路由，/analyze 阻塞直到 release 被关闭
+func newConcurrencyTestRouter(limiter *UserConcurrencyLimiter, started chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if id, err := strconv.ParseInt(c.GetHeader("X-User-ID"), 10, 64); err == nil {
+			c.Set(ContextKeyUserID, id)
+		}
+		c.Next()
+	})
+	r.POST("/analyze", LimitConcurrentPerUser(limiter), func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	return r
+}
+
+func doAnalyze(r *gin.Engine, userID int64) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/analyze", nil)
+	req.Header.Set("X-User-ID", strconv.FormatInt(userID, 10))
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLimitConcurrentPerUser_SecondAnalysisRejected(t *testing.T) {
+	limiter := NewUserConcurrencyLimiter(1)
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	r := newConcurrencyTestRouter(limiter, started, release)
+
+	// 用户 1 的第一个分析进行中
+	firstDone := make(chan *httptest.ResponseRecorder, 1)
+	go func() { firstDone <- doAnalyze(r, 1) }()
+	<-started
+
+	// 同一用户的第二个分析被拒绝
+	w := doAnalyze(r, 1)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	var resp response.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, response.CodeAnalysisInProgress, resp.Code)
+	assert.Contains(t, resp.Message, "already have an analysis running")
+
+	// 其他用户不受影响
+	otherDone := make(chan *httptest.ResponseRecorder, 1)
+	go func() { otherDone <- doAnalyze(r, 2) }()
+	<-started
+	assert.Equal(t, 1, limiter.Active(2))
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-firstDone).Code)
+	assert.Equal(t, http.StatusOK, (<-otherDone).Code)
+
+	// 完成后释放名额
+	assert.Equal(t, 0, limiter.Active(1))
+	assert.Equal(t, 0, limiter.Active(2))
+}
+
+func TestLimitConcurrentPerUser_ReleasedAfterCompletion(t *testing.T) {
+	limiter := NewUserConcurrencyLimiter(1)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	close(release)
+	r := newConcurrencyTestRouter(limiter, started, release)
+
+	for i := 0; i < 3; i++ {
+		w := doAnalyze(r, 1)
+		<-started
+		assert.Equal(t, http.StatusOK, w.Code, "sequential analyses should be allowed")
+	}
+}
+
+func TestUserConcurrencyLimiter_AcquireRelease(t *testing.T) {
+	limiter := NewUserConcurrencyLimiter(2)
+
+	assert.True(t, limiter.Acquire(1))
+	assert.True(t, limiter.Acquire(1))
+	assert.False(t, limiter.Acquire(1))
+	assert.True(t, limiter.Acquire(2))
+
+	limiter.Release(1)
+	assert.Equal(t, 1, limiter.Active(1))
+	assert.True(t, limiter.Acquire(1))
+
+	limiter.Release(1)
+	limiter.Release(1)
+	limiter.Release(1) // 多余的释放不应导致负数
+	assert.Equal(t, 0, limiter.Active(1))
+}
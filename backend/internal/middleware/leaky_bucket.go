@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLeakyBucketFull 漏桶排队已满
+var ErrLeakyBucketFull = errors.New("leaky bucket queue is full")
+
+// LeakyBucketLimiter 基于漏桶的限流器，桶中的请求以恒定速率漏出
+// RequestsPerSecond 为漏出速率，Burst 为排队深度（桶容量）：
+// Allow 按计量方式判断，桶中水位加上本次请求不超过 Burst 时放行并计入水位，长期速率不超过 RequestsPerSecond；
+// Wait 在排队未满时等待漏到自己再放行，相邻请求间隔不小于 1/RequestsPerSecond，排队已满时返回 ErrLeakyBucketFull
+type LeakyBucketLimiter struct {
+	buckets         map[string]*leakyBucket
+	config          RateLimitConfig
+	interval        time.Duration // 相邻请求的最小间隔
+	mu              sync.RWMutex
+	cleanupInterval time.Duration
+	stopCleanup     chan struct{}
+	now             func() time.Time
+}
+
+// leakyBucket 单个 key 的漏桶
+type leakyBucket struct {
+	next time.Time // 下一个请求可以放行的时间，早于当前时间表示桶已漏空
+	mu   sync.Mutex
+}
+
+// NewLeakyBucketLimiter 创建漏桶限流器
+func NewLeakyBucketLimiter(config RateLimitConfig) *LeakyBucketLimiter {
+	interval := time.Duration(0)
+	if config.RequestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / config.RequestsPerSecond)
+	}
+
+	limiter := &LeakyBucketLimiter{
+		buckets:         make(map[string]*leakyBucket),
+		config:          config,
+		interval:        interval,
+		cleanupInterval: 5 * time.Minute,
+		stopCleanup:     make(chan struct{}),
+		now:             time.Now,
+	}
+
+	// 启动清理协程
+	go limiter.cleanup()
+
+	return limiter
+}
+
+// Allow 检查是否允许一个请求
+func (l *LeakyBucketLimiter) Allow(key string) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN 检查是否允许 n 个请求，桶中水位加上 n 不超过 Burst 时放行并占用 n 个间隔
+func (l *LeakyBucketLimiter) AllowN(key string, n int) bool {
+	_, ok := l.reserve(key, n)
+	return ok
+}
+
+// Reserve 为一个请求预留放行时间，返回需要等待的时长；排队已满时 ok 为 false
+func (l *LeakyBucketLimiter) Reserve(key string) (delay time.Duration, ok bool) {
+	return l.reserve(key, 1)
+}
+
+// Wait 排队等待放行，排队已满时返回 ErrLeakyBucketFull，等待期间 ctx 取消时返回 ctx.Err()
+func (l *LeakyBucketLimiter) Wait(ctx context.Context, key string) error {
+	delay, ok := l.Reserve(key)
+	if !ok {
+		return ErrLeakyBucketFull
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve 在桶未满时预留 n 个间隔，返回第一个间隔开始前需要等待的时长
+func (l *LeakyBucketLimiter) reserve(key string, n int) (time.Duration, bool) {
+	if l.config.RequestsPerSecond <= 0 || l.config.Burst <= 0 || n > l.config.Burst {
+		return 0, false
+	}
+
+	bucket := l.getBucket(key)
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	now := l.now()
+	start := bucket.next
+	if start.Before(now) {
+		start = now
+	}
+	delay := start.Sub(now)
+
+	// 桶中水位即排队中的请求数（向上取整），加上本次请求不能超过排队深度
+	queued := int((delay + l.interval - 1) / l.interval)
+	if queued+n > l.config.Burst {
+		return delay, false
+	}
+
+	bucket.next = start.Add(time.Duration(n) * l.interval)
+	return delay, true
+}
+
+// getBucket 获取或创建漏桶
+func (l *LeakyBucketLimiter) getBucket(key string) *leakyBucket {
+	l.mu.RLock()
+	bucket, exists := l.buckets[key]
+	l.mu.RUnlock()
+
+	if exists {
+		return bucket
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if bucket, exists = l.buckets[key]; exists {
+		return bucket
+	}
+
+	bucket = &leakyBucket{}
+	l.buckets[key] = bucket
+	return bucket
+}
+
+// cleanup 定期清理空闲的漏桶
+func (l *LeakyBucketLimiter) cleanup() {
+	ticker := time.NewTicker(l.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.doCleanup()
+		case <-l.stopCleanup:
+			return
+		}
+	}
+}
+
+// doCleanup 执行清理
+func (l *LeakyBucketLimiter) doCleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	expireThreshold := 10 * time.Minute
+
+	for key, bucket := range l.buckets {
+		bucket.mu.Lock()
+		// 漏空且超过阈值时间未使用，则删除
+		if now.Sub(bucket.next) > expireThreshold {
+			delete(l.buckets, key)
+		}
+		bucket.mu.Unlock()
+	}
+}
+
+// Stop 停止限流器（停止清理协程）
+func (l *LeakyBucketLimiter) Stop() {
+	close(l.stopCleanup)
+}
+
+// GetBucketCount 获取当前漏桶数量（用于监控）
+func (l *LeakyBucketLimiter) GetBucketCount() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.buckets)
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLeakyBucket(config RateLimitConfig, now *time.Time) *LeakyBucketLimiter {
+	limiter := NewLeakyBucketLimiter(config)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLeakyBucketLimiter_SteadyRate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLeakyBucket(RateLimitConfig{RequestsPerSecond: 10, Burst: 1}, &now)
+	defer limiter.Stop()
+
+	// 排队深度 1：同一时刻只放行一个请求
+	assert.True(t, limiter.Allow("crawler"))
+	assert.False(t, limiter.Allow("crawler"))
+
+	// 每 100ms 漏出一个
+	for i := 0; i < 10; i++ {
+		now = now.Add(50 * time.Millisecond)
+		assert.False(t, limiter.Allow("crawler"), "tick %d: too early", i)
+		now = now.Add(50 * time.Millisecond)
+		assert.True(t, limiter.Allow("crawler"), "tick %d: on schedule", i)
+	}
+
+	// 不同 key 互不影响
+	assert.True(t, limiter.Allow("other"))
+}
+
+func TestLeakyBucketLimiter_AllowMetersUpToBurst(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLeakyBucket(RateLimitConfig{RequestsPerSecond: 10, Burst: 5}, &now)
+	defer limiter.Stop()
+
+	// 桶未满时放行，水位达到 Burst 后拒绝
+	for i := 0; i < 5; i++ {
+		assert.True(t, limiter.Allow("crawler"), "request %d", i)
+	}
+	assert.False(t, limiter.Allow("crawler"))
+
+	// 漏出一个间隔后腾出一个位置
+	now = now.Add(100 * time.Millisecond)
+	assert.True(t, limiter.Allow("crawler"))
+	assert.False(t, limiter.Allow("crawler"))
+}
+
+func TestLeakyBucketLimiter_AllowNConsumesIntervals(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLeakyBucket(RateLimitConfig{RequestsPerSecond: 10, Burst: 5}, &now)
+	defer limiter.Stop()
+
+	// 水位 3，再放入 2 个恰好装满
+	assert.True(t, limiter.AllowN("crawler", 3))
+	assert.False(t, limiter.AllowN("crawler", 3))
+	assert.True(t, limiter.AllowN("crawler", 2))
+	assert.False(t, limiter.Allow("crawler"))
+
+	// 漏出 2 个间隔后水位 3
+	now = now.Add(200 * time.Millisecond)
+	assert.False(t, limiter.AllowN("crawler", 3))
+	assert.True(t, limiter.AllowN("crawler", 2))
+
+	// 超过排队深度的请求永远不会放行
+	now = now.Add(time.Hour)
+	assert.False(t, limiter.AllowN("crawler", 6))
+}
+
+func TestLeakyBucketLimiter_RejectsWhenQueueFull(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLeakyBucket(RateLimitConfig{RequestsPerSecond: 10, Burst: 3}, &now)
+	defer limiter.Stop()
+
+	// 排队深度 3：按 100ms 间隔依次安排
+	for i := 0; i < 3; i++ {
+		delay, ok := limiter.Reserve("crawler")
+		require.True(t, ok, "reservation %d", i)
+		assert.Equal(t, time.Duration(i)*100*time.Millisecond, delay)
+	}
+	_, ok := limiter.Reserve("crawler")
+	assert.False(t, ok, "queue should be saturated")
+
+	// 漏出一个后可以再排队
+	now = now.Add(100 * time.Millisecond)
+	delay, ok := limiter.Reserve("crawler")
+	require.True(t, ok)
+	assert.Equal(t, 200*time.Millisecond, delay)
+}
+
+func TestLeakyBucketLimiter_Wait(t *testing.T) {
+	limiter := NewLeakyBucketLimiter(RateLimitConfig{RequestsPerSecond: 50, Burst: 2})
+	defer limiter.Stop()
+	ctx := context.Background()
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx, "crawler"))
+	require.NoError(t, limiter.Wait(ctx, "crawler"))
+	// 第二个请求等待一个间隔（20ms）后放行
+	assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+
+	// 排队已满
+	for i := 0; i < 2; i++ {
+		_, ok := limiter.Reserve("full")
+		require.True(t, ok)
+	}
+	assert.ErrorIs(t, limiter.Wait(ctx, "full"), ErrLeakyBucketFull)
+
+	// 等待期间取消
+	_, ok := limiter.Reserve("cancel")
+	require.True(t, ok)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, limiter.Wait(canceled, "cancel"), context.Canceled)
+}
+
+func TestLeakyBucketLimiter_Cleanup(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLeakyBucket(RateLimitConfig{RequestsPerSecond: 10, Burst: 1}, &now)
+	defer limiter.Stop()
+
+	limiter.Allow("a")
+	limiter.Allow("b")
+	assert.Equal(t, 2, limiter.GetBucketCount())
+
+	now = now.Add(11 * time.Minute)
+	limiter.Allow("b")
+	limiter.doCleanup()
+	assert.Equal(t, 1, limiter.GetBucketCount())
+}
+
+func TestLeakyBucketLimiter_ImplementsRateLimiter(t *testing.T) {
+	var limiter RateLimiter = NewLeakyBucketLimiter(DefaultRateLimitConfig())
+	defer limiter.(*LeakyBucketLimiter).Stop()
+	assert.True(t, limiter.Allow("key"))
+}
@@ -69,6 +69,15 @@ func (tb *tokenBucket) take(n int) bool {
 	return false
 }
 
+// peek 填充后返回当前令牌数，不消耗令牌
+func (tb *tokenBucket) peek() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	return tb.tokens
+}
+
 // TokenBucketLimiter 基于令牌桶的限流器
 type TokenBucketLimiter struct {
 	buckets  map[string]*tokenBucket
@@ -104,6 +113,17 @@ func (l *TokenBucketLimiter) AllowN(key string, n int) bool {
 	return bucket.take(n)
 }
 
+// Remaining 获取 key 当前剩余令牌数及令牌桶恢复满额的时间
+func (l *TokenBucketLimiter) Remaining(key string) (tokens float64, resetAt time.Time) {
+	tokens = l.getBucket(key).peek()
+	return tokens, bucketResetAt(time.Now(), tokens, l.config)
+}
+
+// Config 获取限流配置
+func (l *TokenBucketLimiter) Config() RateLimitConfig {
+	return l.config
+}
+
 // getBucket 获取或创建令牌桶
 func (l *TokenBucketLimiter) getBucket(key string) *tokenBucket {
 	// 先尝试读取
@@ -353,7 +373,7 @@ func IPKeyExtractor(c *gin.Context) string {
 func UserIDKeyExtractor(c *gin.Context) string {
 	userID := GetUserID(c)
 	if userID > 0 {
-		return "user:" + string(rune(userID))
+		return "user:" + formatInt64(userID)
 	}
 	return "ip:" + IPKeyExtractor(c)
 }
@@ -396,17 +416,24 @@ func formatInt64(n int64) string {
 }
 
 // RateLimit 限流中间件
-// 使用提供的限流器和 key 提取器进行限流
+// 使用提供的限流器和 key 提取器进行限流，豁免的请求不受限制
 func RateLimit(limiter RateLimiter, keyExtractor KeyExtractor) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if isRateLimitExempt(c) {
+			c.Next()
+			return
+		}
+
 		key := keyExtractor(c)
 
 		if !limiter.Allow(key) {
+			setRateLimitHeaders(c, true, rateLimitCheck{limiter, key})
 			response.RateLimited(c, "Too many requests, please try again later")
 			c.Abort()
 			return
 		}
 
+		setRateLimitHeaders(c, false, rateLimitCheck{limiter, key})
 		c.Next()
 	}
 }
@@ -421,6 +448,53 @@ func RateLimitByUser(limiter RateLimiter) gin.HandlerFunc {
 	return RateLimit(limiter, CombinedKeyExtractor)
 }
 
+// RateLimitByUserAndIP 同时按用户和 IP 限流的中间件
+// 已登录用户需同时满足用户限额和 IP 限额，任一超限即拒绝；未登录请求仅受 IP 限额约束
+// 防止单个账号滥用，同时 NAT 后的多个正常用户只共享较宽松的 IP 限额
+func RateLimitByUserAndIP(userLimiter, ipLimiter RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isRateLimitExempt(c) {
+			c.Next()
+			return
+		}
+
+		var checks []rateLimitCheck
+		if userID := GetUserID(c); userID > 0 {
+			checks = append(checks, rateLimitCheck{userLimiter, "user:" + formatInt64(userID)})
+		}
+		ipCheck := rateLimitCheck{ipLimiter, "ip:" + IPKeyExtractor(c)}
+		checks = append(checks, ipCheck)
+
+		reject := func(check rateLimitCheck) {
+			setRateLimitHeaders(c, true, check)
+			if check.key == ipCheck.key {
+				response.RateLimited(c, "Too many requests from this IP, please try again later")
+			} else {
+				response.RateLimited(c, "Too many requests, please try again later")
+			}
+			c.Abort()
+		}
+
+		// 先确认各限额都有余量再扣减，避免一个限额拒绝时另一个限额的令牌被白白消耗
+		for _, check := range checks {
+			if !check.available() {
+				reject(check)
+				return
+			}
+		}
+		for _, check := range checks {
+			if !check.limiter.Allow(check.key) {
+				reject(check)
+				return
+			}
+		}
+
+		// 放行时报告剩余令牌较少的限额
+		setRateLimitHeaders(c, false, checks...)
+		c.Next()
+	}
+}
+
 // DefaultRateLimitConfig 默认限流配置
 func DefaultRateLimitConfig() RateLimitConfig {
 	return RateLimitConfig{
@@ -437,6 +511,14 @@ func StrictRateLimitConfig() RateLimitConfig {
 	}
 }
 
+// ExportRateLimitConfig 数据导出限流配置（每分钟 1 次）
+func ExportRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		RequestsPerSecond: 1.0 / 60,
+		Burst:             1,
+	}
+}
+
 // RelaxedRateLimitConfig 宽松限流配置（用于普通接口）
 func RelaxedRateLimitConfig() RateLimitConfig {
 	return RateLimitConfig{
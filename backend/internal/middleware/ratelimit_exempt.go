@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderAPIKey 受信任 API Key 请求头
+const HeaderAPIKey = "X-API-Key"
+
+// contextKeyRateLimitExemptions 限流豁免规则上下文键
+const contextKeyRateLimitExemptions = "rate_limit_exemptions"
+
+// RateLimitExemptions 限流豁免规则
+// 角色只取自认证中间件校验过的 Token，API Key 需与配置完全一致，
+// 因此伪造请求头无法绕过限流
+type RateLimitExemptions struct {
+	roles map[model.UserRole]bool
+	keys  [][sha256.Size]byte
+	paths map[string]bool
+}
+
+// NewRateLimitExemptions 创建限流豁免规则，全部为空时返回 nil（不豁免任何请求）
+func NewRateLimitExemptions(roles, apiKeys, paths []string) *RateLimitExemptions {
+	e := &RateLimitExemptions{
+		roles: make(map[model.UserRole]bool),
+		paths: make(map[string]bool),
+	}
+	for _, role := range roles {
+		if role = strings.TrimSpace(role); role != "" {
+			e.roles[model.UserRole(role)] = true
+		}
+	}
+	for _, key := range apiKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			e.keys = append(e.keys, sha256.Sum256([]byte(key)))
+		}
+	}
+	for _, path := range paths {
+		if path = strings.TrimSpace(path); path != "" {
+			e.paths[path] = true
+		}
+	}
+
+	if len(e.roles) == 0 && len(e.keys) == 0 && len(e.paths) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Exempt 判断请求是否免于限流，nil 规则不豁免任何请求
+func (e *RateLimitExemptions) Exempt(c *gin.Context) bool {
+	if e == nil {
+		return false
+	}
+
+	if e.paths[c.Request.URL.Path] {
+		return true
+	}
+
+	if role := GetUserRole(c); role != "" && e.roles[role] {
+		return true
+	}
+
+	if key := c.GetHeader(HeaderAPIKey); key != "" && e.trustedKey(key) {
+		return true
+	}
+
+	return false
+}
+
+// trustedKey 以常量时间比较 API Key 的摘要，避免通过响应时间猜测 Key
+func (e *RateLimitExemptions) trustedKey(key string) bool {
+	sum := sha256.Sum256([]byte(key))
+	trusted := 0
+	for _, k := range e.keys {
+		trusted |= subtle.ConstantTimeCompare(sum[:], k[:])
+	}
+	return trusted == 1
+}
+
+// RateLimitExempt 将限流豁免规则放入 Context，需注册在所有限流中间件之前
+// 豁免判断在限流时进行，因此认证中间件写入的角色同样生效
+func RateLimitExempt(exemptions *RateLimitExemptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if exemptions != nil {
+			c.Set(contextKeyRateLimitExemptions, exemptions)
+		}
+		c.Next()
+	}
+}
+
+// isRateLimitExempt 判断当前请求是否免于限流
+func isRateLimitExempt(c *gin.Context) bool {
+	v, ok := c.Get(contextKeyRateLimitExemptions)
+	if !ok {
+		return false
+	}
+	exemptions, _ := v.(*RateLimitExemptions)
+	return exemptions.Exempt(c)
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newExemptTestRouter 模拟认证后按用户和 IP 限流的路由，每个用户与 IP 只允许 1 个请求
+// role 为认证中间件从已校验 Token 中写入的角色
+func newExemptTestRouter(t *testing.T, exemptions *RateLimitExemptions, userID int64, role model.UserRole) *gin.Engine {
+	userLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 1})
+	ipLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 1})
+	t.Cleanup(userLimiter.Stop)
+	t.Cleanup(ipLimiter.Stop)
+
+	r := gin.New()
+	r.Use(RateLimitExempt(exemptions))
+	r.Use(func(c *gin.Context) {
+		if userID > 0 {
+			c.Set(ContextKeyUserID, userID)
+			c.Set(ContextKeyUserRole, role)
+		}
+		c.Next()
+	})
+	r.Use(RateLimitByUserAndIP(userLimiter, ipLimiter))
+	r.GET("/api/v1/funds", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+// sendRequests 连续发送 n 个请求，返回各请求的状态码
+func sendRequests(r *gin.Engine, path string, n int, headers map[string]string) []int {
+	codes := make([]int, n)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+	return codes
+}
+
+func TestRateLimitExempt_AdminRoleBypassesLimit(t *testing.T) {
+	exemptions := NewRateLimitExemptions([]string{"admin"}, nil, nil)
+	r := newExemptTestRouter(t, exemptions, 1, model.UserRoleAdmin)
+
+	codes := sendRequests(r, "/api/v1/funds", 5, nil)
+
+	assert.Equal(t, []int{200, 200, 200, 200, 200}, codes)
+}
+
+func TestRateLimitExempt_NormalUserStillLimited(t *testing.T) {
+	exemptions := NewRateLimitExemptions([]string{"admin"}, []string{"secret-key"}, []string{"/health"})
+	r := newExemptTestRouter(t, exemptions, 2, model.UserRoleUser)
+
+	codes := sendRequests(r, "/api/v1/funds", 3, nil)
+
+	assert.Equal(t, []int{200, 429, 429}, codes)
+}
+
+func TestRateLimitExempt_SpoofedHeadersIgnored(t *testing.T) {
+	exemptions := NewRateLimitExemptions([]string{"admin"}, []string{"secret-key"}, nil)
+	r := newExemptTestRouter(t, exemptions, 0, "")
+
+	// 角色只来自已校验的 Token，错误的 API Key 同样无效
+	codes := sendRequests(r, "/api/v1/funds", 2, map[string]string{
+		"X-User-Role": "admin",
+		"X-Role":      "admin",
+		HeaderAPIKey:  "wrong-key",
+	})
+
+	assert.Equal(t, []int{200, 429}, codes)
+}
+
+func TestRateLimitExempt_TrustedAPIKey(t *testing.T) {
+	exemptions := NewRateLimitExemptions(nil, []string{"secret-key"}, nil)
+	r := newExemptTestRouter(t, exemptions, 0, "")
+
+	codes := sendRequests(r, "/api/v1/funds", 3, map[string]string{HeaderAPIKey: "secret-key"})
+
+	assert.Equal(t, []int{200, 200, 200}, codes)
+}
+
+func TestRateLimitExempt_Path(t *testing.T) {
+	exemptions := NewRateLimitExemptions(nil, nil, []string{"/health"})
+	r := newExemptTestRouter(t, exemptions, 0, "")
+
+	assert.Equal(t, []int{200, 200, 200}, sendRequests(r, "/health", 3, nil))
+	assert.Equal(t, []int{200, 429}, sendRequests(r, "/api/v1/funds", 2, nil))
+}
+
+func TestNewRateLimitExemptions_EmptyIsNil(t *testing.T) {
+	exemptions := NewRateLimitExemptions(nil, []string{" "}, []string{""})
+
+	assert.Nil(t, exemptions)
+	assert.False(t, exemptions.Exempt(&gin.Context{}))
+}
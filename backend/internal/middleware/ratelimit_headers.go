@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 限流响应头
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"     // 令牌桶容量
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining" // 剩余可用请求数
+	HeaderRateLimitReset     = "X-RateLimit-Reset"     // 恢复满额所需秒数
+	HeaderRetryAfter         = "Retry-After"           // 被限流时，下一个请求可用前需等待的秒数
+)
+
+// RateLimitReporter 可报告令牌桶状态的限流器，实现该接口的限流器会在响应中附带限流响应头
+type RateLimitReporter interface {
+	// Config 限流配置
+	Config() RateLimitConfig
+	// Remaining 当前剩余令牌数及令牌桶恢复满额的时间
+	Remaining(key string) (tokens float64, resetAt time.Time)
+}
+
+// rateLimitCheck 一次限流检查使用的限流器和 key
+type rateLimitCheck struct {
+	limiter RateLimiter
+	key     string
+}
+
+// available 不消耗令牌地检查 key 是否还有余量，限流器不支持查询余量时视为有余量
+func (rc rateLimitCheck) available() bool {
+	reporter, ok := rc.limiter.(RateLimitReporter)
+	if !ok {
+		return true
+	}
+	tokens, _ := reporter.Remaining(rc.key)
+	return tokens >= 1
+}
+
+// bucketResetAt 令牌桶从 tokens 恢复满额的时间
+func bucketResetAt(now time.Time, tokens float64, config RateLimitConfig) time.Time {
+	missing := float64(config.Burst) - tokens
+	if missing <= 0 || config.RequestsPerSecond <= 0 {
+		return now
+	}
+	return now.Add(time.Duration(missing / config.RequestsPerSecond * float64(time.Second)))
+}
+
+// ceilSeconds 向上取整的秒数，不小于 0
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// setRateLimitHeaders 设置限流响应头
+// 有多项限额时报告剩余令牌最少的一项；被限流时附带 Retry-After；限流器不支持报告时不设置
+func setRateLimitHeaders(c *gin.Context, denied bool, checks ...rateLimitCheck) {
+	var (
+		found   bool
+		config  RateLimitConfig
+		tokens  float64
+		resetAt time.Time
+	)
+	for _, check := range checks {
+		reporter, ok := check.limiter.(RateLimitReporter)
+		if !ok {
+			continue
+		}
+		t, r := reporter.Remaining(check.key)
+		if !found || t < tokens {
+			found, config, tokens, resetAt = true, reporter.Config(), t, r
+		}
+	}
+	if !found {
+		return
+	}
+
+	now := time.Now()
+	header := c.Writer.Header()
+	header.Set(HeaderRateLimitLimit, strconv.Itoa(config.Burst))
+	header.Set(HeaderRateLimitRemaining, strconv.Itoa(int(math.Max(math.Floor(tokens), 0))))
+	header.Set(HeaderRateLimitReset, strconv.FormatInt(ceilSeconds(resetAt.Sub(now)), 10))
+
+	if denied && config.RequestsPerSecond > 0 {
+		wait := time.Duration((1 - tokens) / config.RequestsPerSecond * float64(time.Second))
+		header.Set(HeaderRetryAfter, strconv.FormatInt(max(ceilSeconds(wait), 1), 10))
+	}
+}
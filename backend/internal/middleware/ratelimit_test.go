@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -193,13 +194,17 @@ func TestRateLimitMiddleware_Allow(t *testing.T) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
-	// 前 5 个请求应该成功
+	// 前 5 个请求应该成功，响应头报告剩余请求数
 	for i := 0; i < 5; i++ {
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/test", nil)
 		req.RemoteAddr = "192.168.1.1:12345"
 		router.ServeHTTP(w, req)
 		assert.Equal(t, http.StatusOK, w.Code, "Request %d should succeed", i+1)
+		assert.Equal(t, "5", w.Header().Get(HeaderRateLimitLimit))
+		assert.Equal(t, strconv.Itoa(4-i), w.Header().Get(HeaderRateLimitRemaining))
+		assert.Equal(t, "1", w.Header().Get(HeaderRateLimitReset))
+		assert.Empty(t, w.Header().Get(HeaderRetryAfter))
 	}
 
 	// 第 6 个请求应该被限流
@@ -208,6 +213,38 @@ func TestRateLimitMiddleware_Allow(t *testing.T) {
 	req.RemoteAddr = "192.168.1.1:12345"
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusTooManyRequests, w.Code, "Request 6 should be rate limited")
+	assert.Equal(t, "5", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal(t, "1", w.Header().Get(HeaderRateLimitReset))
+	assert.Equal(t, "1", w.Header().Get(HeaderRetryAfter))
+}
+
+func TestRateLimitMiddleware_RetryAfterReflectsRefillRate(t *testing.T) {
+	// 每 10 秒补充 1 个令牌
+	limiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.1, Burst: 2})
+	defer limiter.Stop()
+
+	router := gin.New()
+	router.Use(RateLimitByIP(limiter))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		router.ServeHTTP(w, req)
+	}
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get(HeaderRetryAfter))
+	assert.Equal(t, "20", w.Header().Get(HeaderRateLimitReset))
+
+	tokens, resetAt := limiter.Remaining("192.168.1.1")
+	assert.Less(t, tokens, 0.01)
+	assert.WithinDuration(t, time.Now().Add(20*time.Second), resetAt, time.Second)
 }
 
 func TestRateLimitMiddleware_DifferentIPs(t *testing.T) {
@@ -318,6 +355,26 @@ func TestCombinedKeyExtractor(t *testing.T) {
 	})
 }
 
+// TestUserIDKeyExtractor tests that user keys are rendered as decimal IDs
+func TestUserIDKeyExtractor(t *testing.T) {
+	keyFor := func(userID int64) string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		c.Request.RemoteAddr = "192.168.1.1:12345"
+		if userID > 0 {
+			c.Set(ContextKeyUserID, userID)
+		}
+		return UserIDKeyExtractor(c)
+	}
+
+	assert.Equal(t, "user:65", keyFor(65))
+	assert.Equal(t, "user:97", keyFor(97))
+	assert.NotEqual(t, keyFor(65), keyFor(97))
+	assert.Equal(t, "user:9999999999", keyFor(9999999999))
+	assert.Contains(t, keyFor(0), "ip:")
+}
+
 func TestDefaultConfigs(t *testing.T) {
 	t.Run("DefaultRateLimitConfig", func(t *testing.T) {
 		config := DefaultRateLimitConfig()
@@ -411,3 +468,134 @@ func TestRateLimitMiddleware_ResponseFormat(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "429")
 	assert.Contains(t, w.Body.String(), "Too many requests")
 }
+
+// newUserAndIPRouter 创建同时按用户和 IP 限流的测试路由，用户 ID 从 X-Test-User 头读取
+func newUserAndIPRouter(userLimiter, ipLimiter RateLimiter) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if uid := c.GetHeader("X-Test-User"); uid != "" {
+			id, _ := strconv.ParseInt(uid, 10, 64)
+			c.Set(ContextKeyUserID, id)
+		}
+		c.Next()
+	})
+	router.Use(RateLimitByUserAndIP(userLimiter, ipLimiter))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return router
+}
+
+func doUserAndIPRequest(router *gin.Engine, userID, remoteAddr string) int {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = remoteAddr
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitByUserAndIP_AbusiveUserThrottled(t *testing.T) {
+	userLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 3})
+	ipLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 100})
+	defer userLimiter.Stop()
+	defer ipLimiter.Stop()
+
+	router := newUserAndIPRouter(userLimiter, ipLimiter)
+
+	// 同一用户从不同 IP 请求，也受用户限额约束
+	for i := 0; i < 3; i++ {
+		addr := "10.0.0." + strconv.Itoa(i+1) + ":12345"
+		assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "1", addr), "Request %d should succeed", i+1)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, doUserAndIPRequest(router, "1", "10.0.0.9:12345"))
+
+	// 其他用户不受影响
+	assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "2", "10.0.0.9:12345"))
+}
+
+func TestRateLimitByUserAndIP_HeadersReportTighterLimit(t *testing.T) {
+	userLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 3})
+	ipLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 100})
+	defer userLimiter.Stop()
+	defer ipLimiter.Stop()
+
+	router := newUserAndIPRouter(userLimiter, ipLimiter)
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "10.0.0.1:12345"
+		req.Header.Set("X-Test-User", "1")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 放行时报告剩余较少的用户限额
+	w := request()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "2", w.Header().Get(HeaderRateLimitRemaining))
+
+	request()
+	request()
+	w = request()
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+	assert.NotEmpty(t, w.Header().Get(HeaderRetryAfter))
+}
+
+func TestRateLimitByUserAndIP_SharedIPThrottled(t *testing.T) {
+	userLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 3})
+	ipLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 5})
+	defer userLimiter.Stop()
+	defer ipLimiter.Stop()
+
+	router := newUserAndIPRouter(userLimiter, ipLimiter)
+
+	// NAT 后的多个用户各自只请求 1 次，均低于用户限额，但共享 IP 限额
+	for i := 1; i <= 5; i++ {
+		assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, strconv.Itoa(i), "203.0.113.1:12345"), "User %d should succeed", i)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, doUserAndIPRequest(router, "6", "203.0.113.1:12345"))
+
+	// 其他 IP 不受影响
+	assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "6", "203.0.113.2:12345"))
+}
+
+func TestRateLimitByUserAndIP_RejectionDoesNotConsumeOtherLimit(t *testing.T) {
+	userLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 2})
+	ipLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 1})
+	defer userLimiter.Stop()
+	defer ipLimiter.Stop()
+
+	router := newUserAndIPRouter(userLimiter, ipLimiter)
+
+	assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "1", "203.0.113.1:12345"))
+	// 被 IP 限额拒绝的请求不扣减用户限额
+	assert.Equal(t, http.StatusTooManyRequests, doUserAndIPRequest(router, "1", "203.0.113.1:12345"))
+	tokens, _ := userLimiter.Remaining("user:1")
+	assert.InDelta(t, 1, tokens, 0.01)
+	assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "1", "203.0.113.2:12345"))
+
+	// 被用户限额拒绝的请求不扣减 IP 限额
+	assert.Equal(t, http.StatusTooManyRequests, doUserAndIPRequest(router, "1", "203.0.113.3:12345"))
+	assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "2", "203.0.113.3:12345"))
+}
+
+func TestRateLimitByUserAndIP_Anonymous(t *testing.T) {
+	userLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 1})
+	ipLimiter := NewTokenBucketLimiter(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 2})
+	defer userLimiter.Stop()
+	defer ipLimiter.Stop()
+
+	router := newUserAndIPRouter(userLimiter, ipLimiter)
+
+	// 未登录请求仅受 IP 限额约束
+	assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "", "192.168.1.1:12345"))
+	assert.Equal(t, http.StatusOK, doUserAndIPRequest(router, "", "192.168.1.1:12345"))
+	assert.Equal(t, http.StatusTooManyRequests, doUserAndIPRequest(router, "", "192.168.1.1:12345"))
+	assert.Equal(t, 0, userLimiter.GetBucketCount())
+}
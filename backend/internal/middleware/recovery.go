@@ -15,6 +15,11 @@ func Recovery(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				// 处理器主动中断响应（如流式输出中途失败），交给 net/http 关闭连接
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
 				// 记录堆栈信息
 				logger.Error("Panic recovered",
 					zap.Any("error", err),
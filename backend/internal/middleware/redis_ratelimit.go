@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisRateLimitTimeout 单次 Redis 限流调用的超时，超时后使用本地限流器
+const redisRateLimitTimeout = 200 * time.Millisecond
+
+// tokenBucketScript 原子地填充并扣减令牌桶
+// KEYS[1] 桶键；ARGV: 每秒速率、容量、当前时间（毫秒）、请求令牌数、过期时间（毫秒）
+// 返回 1 表示允许，0 表示拒绝
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local ttl = tonumber(ARGV[5])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) / 1000 * rate)
+	ts = now
+end
+
+local allowed = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return allowed
+`)
+
+// RedisRateLimiter 基于 Redis 的令牌桶限流器
+// 限流状态保存在 Redis 中，多个实例共享限额且重启后不重置；Redis 不可用时使用本地令牌桶限流
+type RedisRateLimiter struct {
+	client   *redis.Client
+	prefix   string
+	config   RateLimitConfig
+	fallback *TokenBucketLimiter
+	now      func() time.Time
+}
+
+// NewRedisRateLimiter 创建 Redis 限流器，prefix 区分不同用途的限流器（如 user、ip）
+func NewRedisRateLimiter(client *redis.Client, prefix string, config RateLimitConfig) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client:   client,
+		prefix:   "ratelimit:" + prefix + ":",
+		config:   config,
+		fallback: NewTokenBucketLimiter(config),
+		now:      time.Now,
+	}
+}
+
+// Allow 检查是否允许一个请求
+func (l *RedisRateLimiter) Allow(key string) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN 检查是否允许 n 个请求，Redis 调用失败时由本地限流器判断
+func (l *RedisRateLimiter) AllowN(key string, n int) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), redisRateLimitTimeout)
+	defer cancel()
+
+	allowed, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key},
+		l.config.RequestsPerSecond,
+		l.config.Burst,
+		l.now().UnixMilli(),
+		n,
+		l.ttl().Milliseconds(),
+	).Int()
+	if err != nil {
+		return l.fallback.AllowN(key, n)
+	}
+	return allowed == 1
+}
+
+// Remaining 获取 key 当前剩余令牌数及令牌桶恢复满额的时间，Redis 调用失败时返回本地限流器的状态
+func (l *RedisRateLimiter) Remaining(key string) (tokens float64, resetAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisRateLimitTimeout)
+	defer cancel()
+
+	state, err := l.client.HMGet(ctx, l.prefix+key, "tokens", "ts").Result()
+	if err != nil {
+		return l.fallback.Remaining(key)
+	}
+
+	now := l.now()
+	tokens = float64(l.config.Burst)
+	rawTokens, ok1 := state[0].(string)
+	rawTS, ok2 := state[1].(string)
+	if ok1 && ok2 {
+		stored, err1 := strconv.ParseFloat(rawTokens, 64)
+		ts, err2 := strconv.ParseFloat(rawTS, 64)
+		if err1 == nil && err2 == nil {
+			elapsed := math.Max(float64(now.UnixMilli())-ts, 0) / 1000
+			tokens = math.Min(float64(l.config.Burst), stored+elapsed*l.config.RequestsPerSecond)
+		}
+	}
+	return tokens, bucketResetAt(now, tokens, l.config)
+}
+
+// Config 获取限流配置
+func (l *RedisRateLimiter) Config() RateLimitConfig {
+	return l.config
+}
+
+// ttl 令牌桶键的过期时间：桶从空到满所需时间，至少 1 秒
+// 过期后重新创建的桶为满桶，与自然填充的结果一致
+func (l *RedisRateLimiter) ttl() time.Duration {
+	if l.config.RequestsPerSecond <= 0 {
+		return time.Hour
+	}
+	seconds := math.Ceil(float64(l.config.Burst) / l.config.RequestsPerSecond)
+	return time.Duration(math.Max(seconds, 1)) * time.Second
+}
+
+// Stop 停止限流器（停止本地限流器的清理协程），不关闭共享的 Redis 连接
+func (l *RedisRateLimiter) Stop() {
+	l.fallback.Stop()
+}
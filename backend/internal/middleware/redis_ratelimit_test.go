@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisLimiter(t *testing.T, server *miniredis.Miniredis, config RateLimitConfig, now *time.Time) *RedisRateLimiter {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	limiter := NewRedisRateLimiter(client, "user", config)
+	limiter.now = func() time.Time { return *now }
+	t.Cleanup(limiter.Stop)
+	return limiter
+}
+
+// TestRedisRateLimiter_SharedAcrossInstances tests that two instances share one bucket
+func TestRedisRateLimiter_SharedAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	config := RateLimitConfig{RequestsPerSecond: 1, Burst: 5}
+	a := newTestRedisLimiter(t, server, config, &now)
+	b := newTestRedisLimiter(t, server, config, &now)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, a.Allow("42"), "request %d on instance A", i+1)
+	}
+	assert.True(t, b.Allow("42"))
+	assert.True(t, b.Allow("42"))
+
+	// 两个实例共享 5 个令牌
+	assert.False(t, a.Allow("42"))
+	assert.False(t, b.Allow("42"))
+
+	// 其他用户不受影响
+	assert.True(t, b.Allow("7"))
+
+	// 2 秒后补充 2 个令牌
+	now = now.Add(2 * time.Second)
+	assert.True(t, a.Allow("42"))
+	assert.True(t, b.Allow("42"))
+	assert.False(t, a.Allow("42"))
+
+	// 令牌桶键带过期时间
+	assert.True(t, server.Exists("ratelimit:user:42"))
+	assert.Greater(t, server.TTL("ratelimit:user:42"), time.Duration(0))
+}
+
+// TestRedisRateLimiter_Remaining tests that remaining tokens are read from the shared bucket
+func TestRedisRateLimiter_Remaining(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newTestRedisLimiter(t, server, RateLimitConfig{RequestsPerSecond: 1, Burst: 5}, &now)
+
+	tokens, resetAt := limiter.Remaining("42")
+	assert.Equal(t, 5.0, tokens)
+	assert.Equal(t, now, resetAt)
+
+	require.True(t, limiter.AllowN("42", 3))
+	now = now.Add(time.Second)
+	tokens, resetAt = limiter.Remaining("42")
+	assert.InDelta(t, 3.0, tokens, 0.001)
+	assert.Equal(t, now.Add(2*time.Second), resetAt)
+}
+
+// TestRedisRateLimiter_AllowN tests requesting more tokens than remain
+func TestRedisRateLimiter_AllowN(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Now()
+	limiter := newTestRedisLimiter(t, server, RateLimitConfig{RequestsPerSecond: 1, Burst: 5}, &now)
+
+	assert.True(t, limiter.AllowN("42", 4))
+	assert.False(t, limiter.AllowN("42", 2))
+	assert.True(t, limiter.AllowN("42", 1))
+}
+
+// TestRedisRateLimiter_FallsBackWhenRedisUnavailable tests the in-memory fallback still limits
+func TestRedisRateLimiter_FallsBackWhenRedisUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Now()
+	limiter := newTestRedisLimiter(t, server, RateLimitConfig{RequestsPerSecond: 0.001, Burst: 3}, &now)
+	server.Close()
+
+	for i := 0; i < 3; i++ {
+		require.True(t, limiter.Allow("42"), "request %d", i+1)
+	}
+	assert.False(t, limiter.Allow("42"))
+}
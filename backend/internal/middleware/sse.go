@@ -3,15 +3,63 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"fund-analyzer/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ErrClientDisconnected 客户端在流式响应结束前断开连接
+var ErrClientDisconnected = errors.New("client disconnected")
+
+// sseStreamMetrics 进程内 SSE 流统计
+type sseStreamMetrics struct {
+	total     atomic.Int64
+	completed atomic.Int64
+	aborted   atomic.Int64
+}
+
+var sseMetrics sseStreamMetrics
+
+// SSEStreamStats SSE 流统计，Active 为仍在进行中的流
+type SSEStreamStats struct {
+	Total     int64 `json:"total"`
+	Completed int64 `json:"completed"`
+	Aborted   int64 `json:"aborted"` // 客户端中途断开或写入失败
+	Active    int64 `json:"active"`
+}
+
+// SSEMetrics 获取自进程启动以来的 SSE 流统计
+func SSEMetrics() SSEStreamStats {
+	// 先读结束计数再读总数，保证 Active 不为负
+	completed := sseMetrics.completed.Load()
+	aborted := sseMetrics.aborted.Load()
+	total := sseMetrics.total.Load()
+	return SSEStreamStats{
+		Total:     total,
+		Completed: completed,
+		Aborted:   aborted,
+		Active:    total - completed - aborted,
+	}
+}
+
+// recordStreamEnd 按流的结束方式计数
+func recordStreamEnd(err error) {
+	if err != nil {
+		sseMetrics.aborted.Add(1)
+	} else {
+		sseMetrics.completed.Add(1)
+	}
+}
+
 // SSEWriter SSE 流式响应写入器
 type SSEWriter struct {
 	ctx        context.Context
@@ -21,6 +69,8 @@ type SSEWriter struct {
 	mu         sync.Mutex
 	closed     bool
 	closedOnce sync.Once
+	lastWrite  time.Time // 最近一次写入时间，心跳据此判断是否空闲
+	heartbeat  bool      // 心跳是否已启动
 }
 
 // NewSSEWriter 创建 SSE 写入器
@@ -77,7 +127,7 @@ func (w *SSEWriter) SendEvent(eventType string, data string) error {
 	select {
 	case <-w.ctx.Done():
 		w.closed = true
-		return fmt.Errorf("client disconnected")
+		return ErrClientDisconnected
 	default:
 	}
 
@@ -97,10 +147,64 @@ func (w *SSEWriter) SendEvent(eventType string, data string) error {
 
 	// 立即刷新
 	w.flusher.Flush()
+	w.lastWrite = time.Now()
 
 	return nil
 }
 
+// StartHeartbeat 启动心跳，连接空闲达到 interval 时发送注释行 ": ping"，防止中间代理断开空闲连接
+// 心跳在 context 取消或 Close 后停止，重复调用或 interval <= 0 时不做任何事
+func (w *SSEWriter) StartHeartbeat(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	w.mu.Lock()
+	if w.heartbeat || w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.heartbeat = true
+	w.lastWrite = time.Now()
+	w.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-ticker.C:
+				if !w.ping(interval) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// ping 连接空闲达到 interval 时写入心跳，连接已关闭或写入失败时返回 false
+func (w *SSEWriter) ping(interval time.Duration) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return false
+	}
+	if time.Since(w.lastWrite) < interval {
+		return true
+	}
+	if _, err := fmt.Fprint(w.writer, ": ping\n\n"); err != nil {
+		w.closed = true
+		return false
+	}
+	w.flusher.Flush()
+	w.lastWrite = time.Now()
+	return true
+}
+
 // SendJSON 发送 JSON 格式的 SSE 事件
 func (w *SSEWriter) SendJSON(data interface{}) error {
 	jsonData, err := json.Marshal(data)
@@ -165,19 +269,26 @@ func (w *SSEWriter) Close() {
 }
 
 // StreamChatChunks 从 channel 流式发送 ChatChunk
-// 自动处理客户端断开和 channel 关闭
-func (w *SSEWriter) StreamChatChunks(chunks <-chan model.ChatChunk) error {
+// 自动处理客户端断开和 channel 关闭，结束方式计入 SSEMetrics
+func (w *SSEWriter) StreamChatChunks(chunks <-chan model.ChatChunk) (err error) {
+	sseMetrics.total.Add(1)
+	defer func() { recordStreamEnd(err) }()
+
 	for {
 		select {
 		case <-w.ctx.Done():
 			// 客户端断开连接
-			return fmt.Errorf("client disconnected")
+			return ErrClientDisconnected
 
 		case chunk, ok := <-chunks:
 			if !ok {
 				// channel 已关闭
 				return nil
 			}
+			// 断开与新数据同时就绪时 select 随机选择，断开后不再写入
+			if w.ctx.Err() != nil {
+				return ErrClientDisconnected
+			}
 
 			if err := w.SendChatChunk(chunk); err != nil {
 				return err
@@ -192,13 +303,16 @@ func (w *SSEWriter) StreamChatChunks(chunks <-chan model.ChatChunk) error {
 }
 
 // StreamStrings 从 channel 流式发送字符串内容
-// 自动处理客户端断开和 channel 关闭，最后发送 done 消息
-func (w *SSEWriter) StreamStrings(contents <-chan string) error {
+// 自动处理客户端断开和 channel 关闭，最后发送 done 消息，结束方式计入 SSEMetrics
+func (w *SSEWriter) StreamStrings(contents <-chan string) (err error) {
+	sseMetrics.total.Add(1)
+	defer func() { recordStreamEnd(err) }()
+
 	for {
 		select {
 		case <-w.ctx.Done():
 			// 客户端断开连接
-			return fmt.Errorf("client disconnected")
+			return ErrClientDisconnected
 
 		case content, ok := <-contents:
 			if !ok {
@@ -250,41 +364,117 @@ func SSE(handler SSEHandler) gin.HandlerFunc {
 }
 
 // SSEConnectionLimiter SSE 连接数限制器
+// 同时限制全局连接数与单个连接方（用户或 IP）的连接数，防止单个用户占满全局名额
 type SSEConnectionLimiter struct {
 	maxConnections int
+	maxPerUser     int // <= 0 表示不限制单个连接方
 	current        int
+	perKey         map[string]int
 	mu             sync.Mutex
 }
 
-// NewSSEConnectionLimiter 创建 SSE 连接数限制器
+// SSEConnectionStats SSE 连接数统计
+type SSEConnectionStats struct {
+	Current        int            `json:"current"`
+	MaxConnections int            `json:"max_connections"`
+	MaxPerUser     int            `json:"max_per_user"`
+	Users          map[int64]int  `json:"users"` // 用户 ID -> 当前连接数，仅包含有连接的用户
+	Keys           map[string]int `json:"keys"`  // 连接方标识 -> 当前连接数，包含按 IP 计数的未登录连接
+}
+
+// sseUserKeyPrefix 已登录用户的连接方标识前缀
+const sseUserKeyPrefix = "user:"
+
+// SSEUserKey 已登录用户的连接方标识，userID <= 0 时返回空字符串（只受全局限制）
+func SSEUserKey(userID int64) string {
+	if userID <= 0 {
+		return ""
+	}
+	return sseUserKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// SSEConnectionKey 获取请求的连接方标识：已登录时为用户 ID，未登录时为客户端 IP
+func SSEConnectionKey(c *gin.Context) string {
+	if key := SSEUserKey(GetUserID(c)); key != "" {
+		return key
+	}
+	if ip := c.ClientIP(); ip != "" {
+		return "ip:" + ip
+	}
+	return ""
+}
+
+// NewSSEConnectionLimiter 创建 SSE 连接数限制器（不限制单个用户）
 func NewSSEConnectionLimiter(maxConnections int) *SSEConnectionLimiter {
+	return NewSSEConnectionLimiterWithUserLimit(maxConnections, 0)
+}
+
+// NewSSEConnectionLimiterWithUserLimit 创建同时限制单个连接方连接数的 SSE 连接数限制器
+func NewSSEConnectionLimiterWithUserLimit(maxConnections, maxPerUser int) *SSEConnectionLimiter {
 	return &SSEConnectionLimiter{
 		maxConnections: maxConnections,
+		maxPerUser:     maxPerUser,
 		current:        0,
+		perKey:         make(map[string]int),
 	}
 }
 
 // Acquire 获取连接许可
 func (l *SSEConnectionLimiter) Acquire() bool {
+	return l.AcquireFor("")
+}
+
+// AcquireForUser 为用户获取连接许可，用户已达上限时即使全局仍有名额也拒绝
+// userID <= 0（未登录）时只受全局限制
+func (l *SSEConnectionLimiter) AcquireForUser(userID int64) bool {
+	return l.AcquireFor(SSEUserKey(userID))
+}
+
+// AcquireFor 为连接方获取连接许可，连接方已达上限时即使全局仍有名额也拒绝
+// key 为空时只受全局限制
+func (l *SSEConnectionLimiter) AcquireFor(key string) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	if l.current >= l.maxConnections {
 		return false
 	}
+	if key != "" && l.maxPerUser > 0 && l.perKey[key] >= l.maxPerUser {
+		return false
+	}
 
 	l.current++
+	if key != "" {
+		l.perKey[key]++
+	}
 	return true
 }
 
 // Release 释放连接许可
 func (l *SSEConnectionLimiter) Release() {
+	l.ReleaseFor("")
+}
+
+// ReleaseForUser 释放用户的连接许可
+func (l *SSEConnectionLimiter) ReleaseForUser(userID int64) {
+	l.ReleaseFor(SSEUserKey(userID))
+}
+
+// ReleaseFor 释放连接方的连接许可，计数归零时删除条目避免 map 无限增长
+func (l *SSEConnectionLimiter) ReleaseFor(key string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	if l.current > 0 {
 		l.current--
 	}
+	if key != "" {
+		if l.perKey[key] <= 1 {
+			delete(l.perKey, key)
+		} else {
+			l.perKey[key]--
+		}
+	}
 }
 
 // Current 获取当前连接数
@@ -294,11 +484,48 @@ func (l *SSEConnectionLimiter) Current() int {
 	return l.current
 }
 
+// UserCurrent 获取用户当前连接数
+func (l *SSEConnectionLimiter) UserCurrent(userID int64) int {
+	return l.KeyCurrent(SSEUserKey(userID))
+}
+
+// KeyCurrent 获取连接方当前连接数
+func (l *SSEConnectionLimiter) KeyCurrent(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.perKey[key]
+}
+
+// Stats 获取连接数统计，返回的 map 为副本
+func (l *SSEConnectionLimiter) Stats() SSEConnectionStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	users := make(map[int64]int)
+	keys := make(map[string]int, len(l.perKey))
+	for key, n := range l.perKey {
+		keys[key] = n
+		if raw, ok := strings.CutPrefix(key, sseUserKeyPrefix); ok {
+			if id, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				users[id] = n
+			}
+		}
+	}
+	return SSEConnectionStats{
+		Current:        l.current,
+		MaxConnections: l.maxConnections,
+		MaxPerUser:     l.maxPerUser,
+		Users:          users,
+		Keys:           keys,
+	}
+}
+
 // SSEWithLimit 带连接数限制的 SSE 中间件
 func SSEWithLimit(limiter *SSEConnectionLimiter, handler SSEHandler) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 尝试获取连接许可
-		if !limiter.Acquire() {
+		key := SSEConnectionKey(c)
+		if !limiter.AcquireFor(key) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"code":    429,
 				"message": "Too many SSE connections",
@@ -307,7 +534,7 @@ func SSEWithLimit(limiter *SSEConnectionLimiter, handler SSEHandler) gin.Handler
 		}
 
 		// 确保释放连接许可
-		defer limiter.Release()
+		defer limiter.ReleaseFor(key)
 
 		// 创建 SSE 写入器
 		w := NewSSEWriter(c)
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -466,3 +467,290 @@ func TestSSEWriter_Context(t *testing.T) {
 	err := sseWriter.SendContent("test")
 	assert.Error(t, err)
 }
+
+// metricsDelta 返回两次 SSEMetrics 之间的增量
+func metricsDelta(before, after SSEStreamStats) SSEStreamStats {
+	return SSEStreamStats{
+		Total:     after.Total - before.Total,
+		Completed: after.Completed - before.Completed,
+		Aborted:   after.Aborted - before.Aborted,
+		Active:    after.Active - before.Active,
+	}
+}
+
+// TestSSEMetrics_ClientAbortMidStream tests that cancelling mid-stream counts as aborted
+func TestSSEMetrics_ClientAbortMidStream(t *testing.T) {
+	streams := map[string]func(w *SSEWriter) error{
+		"chat chunks": func(w *SSEWriter) error {
+			chunks := make(chan model.ChatChunk)
+			go func() { chunks <- model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "部分内容"} }()
+			return w.StreamChatChunks(chunks)
+		},
+		"strings": func(w *SSEWriter) error {
+			contents := make(chan string)
+			go func() { contents <- "部分内容" }()
+			return w.StreamStrings(contents)
+		},
+	}
+
+	for name, stream := range streams {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			ctx, cancel := context.WithCancel(context.Background())
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", nil).WithContext(ctx)
+
+			sseWriter := NewSSEWriter(c)
+			require.NotNil(t, sseWriter)
+
+			before := SSEMetrics()
+			errCh := make(chan error, 1)
+			go func() { errCh <- stream(sseWriter) }()
+
+			// 收到第一块内容后客户端断开
+			require.Eventually(t, func() bool {
+				sseWriter.mu.Lock()
+				defer sseWriter.mu.Unlock()
+				return strings.Contains(rec.Body.String(), "部分内容")
+			}, time.Second, 5*time.Millisecond)
+			cancel()
+
+			select {
+			case err := <-errCh:
+				assert.ErrorIs(t, err, ErrClientDisconnected)
+			case <-time.After(time.Second):
+				t.Fatal("stream did not stop after client disconnected")
+			}
+
+			assert.Equal(t, SSEStreamStats{Total: 1, Aborted: 1}, metricsDelta(before, SSEMetrics()))
+		})
+	}
+}
+
+// TestSSEMetrics_CompletedStream tests that a stream ending normally counts as completed
+func TestSSEMetrics_CompletedStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	sseWriter := NewSSEWriter(c)
+	require.NotNil(t, sseWriter)
+
+	chunks := make(chan model.ChatChunk, 2)
+	chunks <- model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "Hello"}
+	chunks <- model.ChatChunk{Type: model.ChunkTypeDone}
+
+	before := SSEMetrics()
+	require.NoError(t, sseWriter.StreamChatChunks(chunks))
+
+	assert.Equal(t, SSEStreamStats{Total: 1, Completed: 1}, metricsDelta(before, SSEMetrics()))
+}
+
+// TestSSEWriter_HeartbeatWhileIdle tests that pings are written during idle periods and stop after Close
+func TestSSEWriter_HeartbeatWhileIdle(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	sseWriter := NewSSEWriter(c)
+	require.NotNil(t, sseWriter)
+
+	body := func() string {
+		sseWriter.mu.Lock()
+		defer sseWriter.mu.Unlock()
+		return w.Body.String()
+	}
+
+	sseWriter.StartHeartbeat(10 * time.Millisecond)
+	require.Eventually(t, func() bool {
+		return strings.Contains(body(), ": ping\n\n")
+	}, time.Second, 5*time.Millisecond)
+
+	// 心跳不会与事件交错
+	require.NoError(t, sseWriter.SendContent("Hello"))
+	assert.Contains(t, body(), `data: {"type":"content","chunk":"Hello"}`+"\n\n")
+
+	sseWriter.Close()
+	time.Sleep(20 * time.Millisecond)
+	closed := body()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, closed, body())
+}
+
+// TestSSEWriter_HeartbeatSkippedWhenActive tests that no ping is sent while data keeps flowing
+func TestSSEWriter_HeartbeatSkippedWhenActive(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	sseWriter := NewSSEWriter(c)
+	require.NotNil(t, sseWriter)
+	defer sseWriter.Close()
+
+	sseWriter.StartHeartbeat(50 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, sseWriter.SendContent("x"))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sseWriter.mu.Lock()
+	defer sseWriter.mu.Unlock()
+	assert.NotContains(t, w.Body.String(), ": ping")
+}
+
+// TestSSEConnectionLimiter_PerUserCap tests that one user is capped while others still connect
+func TestSSEConnectionLimiter_PerUserCap(t *testing.T) {
+	limiter := NewSSEConnectionLimiterWithUserLimit(10, 2)
+
+	// User 1 is capped at the sub-limit even though global slots remain
+	assert.True(t, limiter.AcquireForUser(1))
+	assert.True(t, limiter.AcquireForUser(1))
+	assert.False(t, limiter.AcquireForUser(1))
+	assert.Equal(t, 2, limiter.UserCurrent(1))
+	assert.Equal(t, 2, limiter.Current())
+
+	// Other users and anonymous connections still use the global pool
+	assert.True(t, limiter.AcquireForUser(2))
+	assert.True(t, limiter.Acquire())
+	assert.Equal(t, 4, limiter.Current())
+
+	stats := limiter.Stats()
+	assert.Equal(t, map[int64]int{1: 2, 2: 1}, stats.Users)
+	assert.Equal(t, 10, stats.MaxConnections)
+	assert.Equal(t, 2, stats.MaxPerUser)
+
+	// Releasing frees the user's slot and drops empty entries
+	limiter.ReleaseForUser(1)
+	assert.True(t, limiter.AcquireForUser(1))
+	limiter.ReleaseForUser(2)
+	assert.NotContains(t, limiter.Stats().Users, int64(2))
+}
+
+// TestSSEConnectionLimiter_GlobalCapAppliesToUsers tests the global cap still applies under the sub-limit
+func TestSSEConnectionLimiter_GlobalCapAppliesToUsers(t *testing.T) {
+	limiter := NewSSEConnectionLimiterWithUserLimit(2, 2)
+
+	assert.True(t, limiter.AcquireForUser(1))
+	assert.True(t, limiter.AcquireForUser(2))
+	assert.False(t, limiter.AcquireForUser(3))
+	assert.Equal(t, 0, limiter.UserCurrent(3))
+}
+
+// TestSSEConnectionLimiter_PerUserConcurrent tests per-user accounting under concurrent access
+func TestSSEConnectionLimiter_PerUserConcurrent(t *testing.T) {
+	limiter := NewSSEConnectionLimiterWithUserLimit(100, 3)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	acquired := make(map[int64]int)
+
+	// Five users each try to open ten connections concurrently
+	for user := int64(1); user <= 5; user++ {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(userID int64) {
+				defer wg.Done()
+				if limiter.AcquireForUser(userID) {
+					mu.Lock()
+					acquired[userID]++
+					mu.Unlock()
+				}
+			}(user)
+		}
+	}
+	wg.Wait()
+
+	for user := int64(1); user <= 5; user++ {
+		assert.Equal(t, 3, acquired[user])
+	}
+	assert.Equal(t, 15, limiter.Current())
+}
+
+// TestSSEConnectionLimiter_KeyedConcurrent tests that one key hitting its cap does not block another key
+func TestSSEConnectionLimiter_KeyedConcurrent(t *testing.T) {
+	limiter := NewSSEConnectionLimiterWithUserLimit(20, 2)
+	userA, userB := SSEUserKey(1), "ip:203.0.113.7"
+
+	// User A floods the limiter concurrently
+	var wg sync.WaitGroup
+	var acquiredA atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.AcquireFor(userA) {
+				acquiredA.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), acquiredA.Load())
+
+	// User B is unaffected while A is at its cap
+	var acquiredB atomic.Int32
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.AcquireFor(userB) {
+				acquiredB.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), acquiredB.Load())
+	assert.False(t, limiter.AcquireFor(userB))
+
+	stats := limiter.Stats()
+	assert.Equal(t, map[string]int{userA: 2, userB: 2}, stats.Keys)
+	assert.Equal(t, map[int64]int{1: 2}, stats.Users)
+
+	limiter.ReleaseFor(userB)
+	assert.Equal(t, 1, limiter.KeyCurrent(userB))
+	assert.Equal(t, 3, limiter.Current())
+}
+
+// TestSSEConnectionKey tests that authenticated requests are keyed by user and anonymous ones by IP
+func TestSSEConnectionKey(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/sse", nil)
+	c.Request.RemoteAddr = "203.0.113.7:1234"
+	assert.Equal(t, "ip:203.0.113.7", SSEConnectionKey(c))
+
+	c.Set(ContextKeyUserID, int64(42))
+	assert.Equal(t, "user:42", SSEConnectionKey(c))
+}
+
+// TestSSEWithLimit_PerUser tests the middleware rejects a user over the sub-limit
+func TestSSEWithLimit_PerUser(t *testing.T) {
+	limiter := NewSSEConnectionLimiterWithUserLimit(10, 1)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(ContextKeyUserID, int64(1))
+		c.Next()
+	})
+	router.GET("/sse", SSEWithLimit(limiter, func(w *SSEWriter) error {
+		close(started)
+		<-release
+		return w.SendDone()
+	}))
+
+	done := make(chan int, 1)
+	go func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sse", nil))
+		done <- w.Code
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sse", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, 0, limiter.UserCurrent(1))
+}
@@ -1,9 +1,18 @@
 package model
 
+import "time"
+
 // ChatRequest 聊天请求
 type ChatRequest struct {
-	Message string        `json:"message" binding:"required"`
-	History []ChatMessage `json:"history"`
+	Message  string        `json:"message" binding:"required"`
+	History  []ChatMessage `json:"history"`
+	Language string        `json:"language" binding:"omitempty,oneof=zh en"` // 回复语言，为空时根据消息自动检测
+
+	// ConversationID 服务端保存的会话，指定时以服务端消息作为历史，忽略 History；为空时创建新会话
+	ConversationID int64 `json:"conversationId"`
+
+	// UserID 当前登录用户，由控制器设置，用于在对话中加载用户自选基金
+	UserID int64 `json:"-"`
 }
 
 // ChatMessage 聊天消息
@@ -19,16 +28,42 @@ const (
 	ChunkTypeStatus   ChatChunkType = "status"
 	ChunkTypeContent  ChatChunkType = "content"
 	ChunkTypeToolCall ChatChunkType = "tool_call"
+	ChunkTypeCard     ChatChunkType = "card" // 快速分析的结构化卡片
 	ChunkTypeDone     ChatChunkType = "done"
 	ChunkTypeError    ChatChunkType = "error"
 )
 
 // ChatChunk 聊天响应块
 type ChatChunk struct {
-	Type    ChatChunkType `json:"type"`
-	Message string        `json:"message,omitempty"`
-	Chunk   string        `json:"chunk,omitempty"`
-	Tools   []string      `json:"tools,omitempty"`
+	Type    ChatChunkType     `json:"type"`
+	Message string            `json:"message,omitempty"`
+	Chunk   string            `json:"chunk,omitempty"`
+	Tools   []string          `json:"tools,omitempty"`
+	Card    *FastAnalysisCard `json:"card,omitempty"`
+
+	// ConversationID 对话所属会话，仅在首个块与 done 块中返回
+	ConversationID int64 `json:"conversationId,omitempty"`
+}
+
+// 市场情绪
+const (
+	SentimentBullish = "bullish"
+	SentimentNeutral = "neutral"
+	SentimentBearish = "bearish"
+)
+
+// FastAnalysisCard 快速分析结构化卡片，供前端组件渲染
+type FastAnalysisCard struct {
+	Sentiment  string       `json:"sentiment"`  // bullish/neutral/bearish
+	TopSectors []CardSector `json:"topSectors"` // 最值得关注的板块，最多 3 个
+	Advice     string       `json:"advice"`     // 一句话投资建议
+	Risk       string       `json:"risk"`       // 主要风险点
+}
+
+// CardSector 卡片中的关注板块
+type CardSector struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
 }
 
 // MarketData 市场数据（用于 AI 分析）
@@ -38,6 +73,20 @@ type MarketData struct {
 	News          []NewsItem      `json:"news"`
 	Sectors       []Sector        `json:"sectors"`
 	Funds         []FundValuation `json:"funds"`
+	VolumeTrends  []VolumeTrend   `json:"volumeTrends,omitempty"`
+	MinuteData    []MinuteData    `json:"minuteData,omitempty"`
+}
+
+// MarketSnapshot 某日的市场数据快照（用于时段对比分析）
+type MarketSnapshot struct {
+	Date       string      `json:"date"` // 2006-01-02
+	CapturedAt time.Time   `json:"capturedAt"`
+	Data       *MarketData `json:"data"`
+}
+
+// CompareAnalyzeRequest 时段对比分析请求
+type CompareAnalyzeRequest struct {
+	Days int `json:"days"` // 对比基准距今天数，默认 7（周环比）
 }
 
 // SearchResult 搜索结果
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// 估值提醒方向
+const (
+	AlertDirectionUp   = "up"   // 涨幅达到阈值
+	AlertDirectionDown = "down" // 跌幅达到阈值
+)
+
+// FundAlert 基金估值提醒
+// 盘中估值涨跌幅越过 PercentThreshold 时发送邮件，同一提醒每天最多触发一次
+type FundAlert struct {
+	ID               int64      `json:"id" db:"id"`
+	UserID           int64      `json:"-" db:"user_id"`
+	FundCode         string     `json:"fundCode" db:"fund_code"`
+	Direction        string     `json:"direction" db:"direction"`
+	PercentThreshold float64    `json:"percentThreshold" db:"percent_threshold"` // 涨跌幅阈值（%），正数
+	Active           bool       `json:"active" db:"active"`
+	LastTriggeredAt  *time.Time `json:"lastTriggeredAt,omitempty" db:"last_triggered_at"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// FundAlertRequest 创建或更新估值提醒请求
+type FundAlertRequest struct {
+	Direction        string  `json:"direction" binding:"required"`
+	PercentThreshold float64 `json:"percentThreshold" binding:"required"`
+	Active           *bool   `json:"active"` // 为空时启用
+}
@@ -12,7 +12,7 @@ type RegisterRequest struct {
 // VerifyEmailRequest 验证邮箱请求
 type VerifyEmailRequest struct {
 	Email string `json:"email" binding:"required,email"`
-	Code  string `json:"code" binding:"required,len=6"`
+	Code  string `json:"code" binding:"required,min=4,max=10"`
 }
 
 // LoginRequest 登录请求
@@ -42,10 +42,15 @@ type ForgotPasswordRequest struct {
 // ResetPasswordRequest 重置密码请求
 type ResetPasswordRequest struct {
 	Email       string `json:"email" binding:"required,email"`
-	Code        string `json:"code" binding:"required,len=6"`
+	Code        string `json:"code" binding:"required,min=4,max=10"`
 	NewPassword string `json:"newPassword" binding:"required,min=8"`
 }
 
+// DeleteAccountRequest 删除账号请求
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
 // TokenPair Token 对
 type TokenPair struct {
 	AccessToken  string `json:"accessToken"`
@@ -55,13 +60,16 @@ type TokenPair struct {
 
 // Claims JWT Claims
 type Claims struct {
-	UserID int64  `json:"userId"`
-	Email  string `json:"email"`
+	UserID       int64    `json:"userId"`
+	Email        string   `json:"email"`
+	Role         UserRole `json:"role,omitempty"`
+	TokenVersion int      `json:"tokenVersion,omitempty"` // 签发时用户的 Token 版本
 	jwt.RegisteredClaims
 }
 
 // RefreshClaims 刷新 Token Claims
 type RefreshClaims struct {
-	UserID int64 `json:"userId"`
+	UserID       int64 `json:"userId"`
+	TokenVersion int   `json:"tokenVersion,omitempty"` // 签发时用户的 Token 版本
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Conversation AI 对话会话
+type Conversation struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"-" db:"user_id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// ConversationMessage 会话中的一条消息
+type ConversationMessage struct {
+	ID             int64     `json:"id" db:"id"`
+	ConversationID int64     `json:"conversationId" db:"conversation_id"`
+	Role           string    `json:"role" db:"role"` // user/assistant
+	Content        string    `json:"content" db:"content"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
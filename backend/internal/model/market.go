@@ -11,11 +11,12 @@ const (
 
 // MarketIndex 市场指数
 type MarketIndex struct {
-	Name      string `json:"name"`
-	Price     string `json:"price"`
-	Change    string `json:"change"`
-	IsUp      bool   `json:"isUp"`
-	UpdatedAt string `json:"updatedAt"`
+	Name      string       `json:"name"`
+	Price     string       `json:"price"`
+	Change    string       `json:"change"`
+	IsUp      bool         `json:"isUp"`   // 仅上涨时为 true，持平与无数据时为 false
+	Status    ChangeStatus `json:"status"` // 1 上涨，0 持平或无数据，-1 下跌
+	UpdatedAt string       `json:"updatedAt"`
 }
 
 // PreciousMetal 贵金属
@@ -51,6 +52,7 @@ type VolumeTrend struct {
 }
 
 // MinuteData 分时数据
+// 按大于 1 分钟的粒度聚合时，Price 为收盘价，并附带区间的开盘、最高、最低价
 type MinuteData struct {
 	Time       string `json:"time"`
 	Price      string `json:"price"`
@@ -58,6 +60,9 @@ type MinuteData struct {
 	ChangeRate string `json:"changeRate"`
 	Volume     string `json:"volume"`
 	Amount     string `json:"amount"`
+	Open       string `json:"open,omitempty"`
+	High       string `json:"high,omitempty"`
+	Low        string `json:"low,omitempty"`
 }
 
 // Sector 行业板块
@@ -71,6 +76,21 @@ type Sector struct {
 	SmallInflowRatio string `json:"smallInflowRatio"`
 }
 
+// SectorHeatmap 板块热力图（按大类分组）
+type SectorHeatmap struct {
+	Categories []SectorHeatmapCategory `json:"categories"`
+}
+
+// SectorHeatmapCategory 热力图中的板块大类
+type SectorHeatmapCategory struct {
+	Name          string   `json:"name"`
+	ChangeRate    float64  `json:"changeRate"`    // 大类内板块平均涨跌幅（%）
+	MainNetInflow float64  `json:"mainNetInflow"` // 大类内主力净流入合计（元）
+	UpCount       int      `json:"upCount"`
+	DownCount     int      `json:"downCount"`
+	Sectors       []Sector `json:"sectors"`
+}
+
 // SectorFund 板块基金
 type SectorFund struct {
 	Code       string `json:"code"`
@@ -94,9 +114,12 @@ type NewsItem struct {
 	ID          string       `json:"id"`
 	Title       string       `json:"title"`
 	Content     string       `json:"content"`
-	Evaluate    string       `json:"evaluate"` // 利好/利空/空
-	PublishTime int64        `json:"publishTime"`
+	Evaluate    string       `json:"evaluate"`    // 利好/利空/空
+	PublishTime int64        `json:"publishTime"` // 毫秒时间戳
 	Entities    []NewsEntity `json:"entities"`
+
+	PublishTimeText    string `json:"publishTimeText"`              // 北京时间，如 2024-03-01 09:30:00
+	PublishTimeInvalid bool   `json:"publishTimeInvalid,omitempty"` // 发布时间无法解析
 }
 
 // NewsEntity 快讯关联股票
@@ -113,6 +136,20 @@ type FundInfo struct {
 	FundKey string   `json:"fundKey"`
 	IsHold  bool     `json:"isHold"`
 	Sectors []string `json:"sectors"`
+	FundMeta
+}
+
+// FundMeta 基金经理、规模与成立日期，数据源缺失的字段为空
+type FundMeta struct {
+	Manager       string `json:"manager,omitempty"`       // 多位经理以 "、" 分隔
+	Scale         string `json:"scale,omitempty"`         // 基金规模，如 "45.67亿元"
+	ScaleDate     string `json:"scaleDate,omitempty"`     // 规模截止日期
+	InceptionDate string `json:"inceptionDate,omitempty"` // 成立日期
+}
+
+// IsEmpty 是否没有任何元数据
+func (m FundMeta) IsEmpty() bool {
+	return m == FundMeta{}
 }
 
 // FundValuation 基金估值
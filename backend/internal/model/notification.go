@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// QuietHours 通知免打扰时段
+// Start 晚于 End 表示跨午夜（如 22:00-07:00），时间按 Timezone 解析
+type QuietHours struct {
+	UserID    int64     `json:"-" db:"user_id"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	Start     string    `json:"start" db:"start_time"` // HH:MM
+	End       string    `json:"end" db:"end_time"`     // HH:MM
+	Timezone  string    `json:"timezone" db:"timezone"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// UpdateQuietHoursRequest 更新免打扰时段请求
+type UpdateQuietHoursRequest struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start" binding:"required"`
+	End      string `json:"end" binding:"required"`
+	Timezone string `json:"timezone"` // 为空时使用北京时间
+}
+
+// Notification 发送给用户的通知
+type Notification struct {
+	UserID    int64     `json:"userId"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PendingNotification 免打扰期间暂存、尚未发送的通知，服务重启后据此恢复摘要
+type PendingNotification struct {
+	ID        int64     `db:"id"`
+	UserID    int64     `db:"user_id"`
+	Title     string    `db:"title"`
+	Body      string    `db:"body"`
+	CreatedAt time.Time `db:"created_at"`
+	DeliverAt time.Time `db:"deliver_at"` // 免打扰结束时刻
+}
@@ -15,6 +15,14 @@ const (
 	UserStatusLocked   UserStatus = 2 // 锁定
 )
 
+// UserRole 用户角色
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "user"  // 普通用户
+	UserRoleAdmin UserRole = "admin" // 管理员
+)
+
 // User 用户模型
 type User struct {
 	ID            int64      `json:"id" db:"id"`
@@ -23,8 +31,10 @@ type User struct {
 	Nickname      string     `json:"nickname" db:"nickname"`
 	AvatarURL     string     `json:"avatarUrl" db:"avatar_url"`
 	Status        UserStatus `json:"status" db:"status"`
+	Role          UserRole   `json:"role" db:"role"`
 	LoginAttempts int        `json:"-" db:"login_attempts"`
 	LockedUntil   *time.Time `json:"-" db:"locked_until"`
+	TokenVersion  int        `json:"-" db:"token_version"` // 重置密码时递增，版本更早的 Token 失效
 	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
 	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
 }
@@ -61,6 +71,20 @@ func (v *VerificationCode) IsExpired() bool {
 	return time.Now().After(v.ExpiresAt)
 }
 
+// PendingRegistration 待验证的注册信息，邮箱验证通过后据此创建用户
+type PendingRegistration struct {
+	Email        string    `db:"email"`
+	PasswordHash string    `db:"password_hash"`
+	Nickname     string    `db:"nickname"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// IsExpired 检查注册信息是否过期
+func (p *PendingRegistration) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
 // TokenBlacklist Token 黑名单
 type TokenBlacklist struct {
 	ID        int64     `db:"id"`
@@ -82,3 +106,27 @@ type UserFund struct {
 	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time      `json:"updatedAt" db:"updated_at"`
 }
+
+// UserExportProfile 导出用的用户资料（不含密码哈希、登录失败次数等敏感字段）
+type UserExportProfile struct {
+	ID        int64      `json:"id"`
+	Email     string     `json:"email"`
+	Nickname  string     `json:"nickname"`
+	AvatarURL string     `json:"avatarUrl"`
+	Status    UserStatus `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// NewUserExportProfile 从用户模型构建导出资料
+func NewUserExportProfile(u *User) UserExportProfile {
+	return UserExportProfile{
+		ID:        u.ID,
+		Email:     u.Email,
+		Nickname:  u.Nickname,
+		AvatarURL: u.AvatarURL,
+		Status:    u.Status,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrConversationNotFound 会话不存在
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationRepository AI 对话会话仓库接口
+type ConversationRepository interface {
+	CreateConversation(ctx context.Context, conversation *model.Conversation) error
+	GetConversation(ctx context.Context, conversationID int64) (*model.Conversation, error)
+	AppendMessage(ctx context.Context, message *model.ConversationMessage) error
+	// GetMessages 获取会话最近 limit 条消息，按时间正序返回
+	GetMessages(ctx context.Context, conversationID int64, limit int) ([]model.ConversationMessage, error)
+}
+
+type conversationRepository struct {
+	db *sqlx.DB
+}
+
+// NewConversationRepository 创建 AI 对话会话仓库
+func NewConversationRepository(db *sqlx.DB) ConversationRepository {
+	return &conversationRepository{db: db}
+}
+
+func (r *conversationRepository) CreateConversation(ctx context.Context, conversation *model.Conversation) error {
+	query := `
+		INSERT INTO conversations (user_id, created_at, updated_at)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	now := time.Now()
+	conversation.CreatedAt = now
+	conversation.UpdatedAt = now
+
+	return r.db.QueryRowContext(ctx, query, conversation.UserID, conversation.CreatedAt, conversation.UpdatedAt).Scan(&conversation.ID)
+}
+
+func (r *conversationRepository) GetConversation(ctx context.Context, conversationID int64) (*model.Conversation, error) {
+	var conversation model.Conversation
+	query := `SELECT id, user_id, created_at, updated_at FROM conversations WHERE id = $1`
+	err := r.db.GetContext(ctx, &conversation, query, conversationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrConversationNotFound
+		}
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// AppendMessage 追加消息并更新会话的最后活跃时间
+func (r *conversationRepository) AppendMessage(ctx context.Context, message *model.ConversationMessage) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	message.CreatedAt = time.Now()
+	if err = tx.QueryRowContext(ctx, `
+		INSERT INTO conversation_messages (conversation_id, role, content, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		message.ConversationID, message.Role, message.Content, message.CreatedAt,
+	).Scan(&message.ID); err != nil {
+		return fmt.Errorf("insert message: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE conversations SET updated_at = $1 WHERE id = $2`,
+		message.CreatedAt, message.ConversationID,
+	); err != nil {
+		return fmt.Errorf("touch conversation: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *conversationRepository) GetMessages(ctx context.Context, conversationID int64, limit int) ([]model.ConversationMessage, error) {
+	messages := []model.ConversationMessage{}
+	query := `
+		SELECT id, conversation_id, role, content, created_at FROM (
+			SELECT id, conversation_id, role, content, created_at FROM conversation_messages
+			WHERE conversation_id = $1
+			ORDER BY id DESC
+			LIMIT $2
+		) recent
+		ORDER BY id ASC`
+	if err := r.db.SelectContext(ctx, &messages, query, conversationID, limit); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockConversationRepository(t *testing.T) (ConversationRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewConversationRepository(sqlx.NewDb(db, "postgres")), mock
+}
+
+func TestCreateConversation(t *testing.T) {
+	repo, mock := newMockConversationRepository(t)
+
+	mock.ExpectQuery(`INSERT INTO conversations`).
+		WithArgs(int64(7), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+
+	conversation := &model.Conversation{UserID: 7}
+	err := repo.CreateConversation(context.Background(), conversation)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), conversation.ID)
+	assert.False(t, conversation.CreatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetConversation_NotFound(t *testing.T) {
+	repo, mock := newMockConversationRepository(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, user_id, created_at, updated_at FROM conversations WHERE id = $1`)).
+		WithArgs(int64(11)).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetConversation(context.Background(), 11)
+
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAppendMessage_InsertsAndTouchesConversation(t *testing.T) {
+	repo, mock := newMockConversationRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO conversation_messages`).
+		WithArgs(int64(11), "user", "今天适合加仓吗", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE conversations SET updated_at = $1 WHERE id = $2`)).
+		WithArgs(sqlmock.AnyArg(), int64(11)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	message := &model.ConversationMessage{ConversationID: 11, Role: "user", Content: "今天适合加仓吗"}
+	err := repo.AppendMessage(context.Background(), message)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), message.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAppendMessage_FailureRollsBack(t *testing.T) {
+	repo, mock := newMockConversationRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO conversation_messages`).WillReturnError(errors.New("foreign key violation"))
+	mock.ExpectRollback()
+
+	err := repo.AppendMessage(context.Background(), &model.ConversationMessage{ConversationID: 11, Role: "user", Content: "你好"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "insert message")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessages_RecentInChronologicalOrder(t *testing.T) {
+	repo, mock := newMockConversationRepository(t)
+	now := time.Now()
+
+	mock.ExpectQuery(`ORDER BY id DESC\s+LIMIT \$2\s+\) recent\s+ORDER BY id ASC`).
+		WithArgs(int64(11), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "conversation_id", "role", "content", "created_at"}).
+			AddRow(1, 11, "user", "你好", now).
+			AddRow(2, 11, "assistant", "你好，有什么可以帮你？", now))
+
+	messages, err := repo.GetMessages(context.Background(), 11, 20)
+
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "user", messages[0].Role)
+	assert.Equal(t, "你好，有什么可以帮你？", messages[1].Content)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrFundAlertNotFound 估值提醒不存在或不属于该用户
+var ErrFundAlertNotFound = errors.New("fund alert not found")
+
+// fundAlertColumns 查询估值提醒的字段
+const fundAlertColumns = `id, user_id, fund_code, direction, percent_threshold, active, last_triggered_at, created_at, updated_at`
+
+// FundAlertRepository 基金估值提醒仓库接口
+type FundAlertRepository interface {
+	CreateAlert(ctx context.Context, alert *model.FundAlert) error
+	// ListAlerts 获取用户在某只基金上的提醒，按创建顺序排列
+	ListAlerts(ctx context.Context, userID int64, fundCode string) ([]model.FundAlert, error)
+	GetAlert(ctx context.Context, userID, alertID int64) (*model.FundAlert, error)
+	UpdateAlert(ctx context.Context, alert *model.FundAlert) error
+	DeleteAlert(ctx context.Context, userID, alertID int64) error
+	// ListActiveAlerts 获取所有用户已启用的提醒
+	ListActiveAlerts(ctx context.Context) ([]model.FundAlert, error)
+	// MarkTriggered 记录提醒的触发时间
+	MarkTriggered(ctx context.Context, alertID int64, at time.Time) error
+}
+
+type fundAlertRepository struct {
+	db *sqlx.DB
+}
+
+// NewFundAlertRepository 创建基金估值提醒仓库
+func NewFundAlertRepository(db *sqlx.DB) FundAlertRepository {
+	return &fundAlertRepository{db: db}
+}
+
+func (r *fundAlertRepository) CreateAlert(ctx context.Context, alert *model.FundAlert) error {
+	query := `
+		INSERT INTO fund_alerts (user_id, fund_code, direction, percent_threshold, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	now := time.Now()
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+
+	return r.db.QueryRowContext(ctx, query,
+		alert.UserID, alert.FundCode, alert.Direction, alert.PercentThreshold, alert.Active, alert.CreatedAt, alert.UpdatedAt,
+	).Scan(&alert.ID)
+}
+
+func (r *fundAlertRepository) ListAlerts(ctx context.Context, userID int64, fundCode string) ([]model.FundAlert, error) {
+	alerts := make([]model.FundAlert, 0)
+	query := `SELECT ` + fundAlertColumns + ` FROM fund_alerts WHERE user_id = $1 AND fund_code = $2 ORDER BY id`
+	if err := r.db.SelectContext(ctx, &alerts, query, userID, fundCode); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *fundAlertRepository) GetAlert(ctx context.Context, userID, alertID int64) (*model.FundAlert, error) {
+	var alert model.FundAlert
+	query := `SELECT ` + fundAlertColumns + ` FROM fund_alerts WHERE id = $1 AND user_id = $2`
+	err := r.db.GetContext(ctx, &alert, query, alertID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFundAlertNotFound
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *fundAlertRepository) UpdateAlert(ctx context.Context, alert *model.FundAlert) error {
+	query := `
+		UPDATE fund_alerts SET direction = $1, percent_threshold = $2, active = $3, updated_at = $4
+		WHERE id = $5 AND user_id = $6`
+
+	alert.UpdatedAt = time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		alert.Direction, alert.PercentThreshold, alert.Active, alert.UpdatedAt, alert.ID, alert.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrFundAlertNotFound
+	}
+	return nil
+}
+
+func (r *fundAlertRepository) DeleteAlert(ctx context.Context, userID, alertID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM fund_alerts WHERE id = $1 AND user_id = $2`, alertID, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrFundAlertNotFound
+	}
+	return nil
+}
+
+func (r *fundAlertRepository) ListActiveAlerts(ctx context.Context) ([]model.FundAlert, error) {
+	alerts := make([]model.FundAlert, 0)
+	query := `SELECT ` + fundAlertColumns + ` FROM fund_alerts WHERE active ORDER BY id`
+	if err := r.db.SelectContext(ctx, &alerts, query); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *fundAlertRepository) MarkTriggered(ctx context.Context, alertID int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE fund_alerts SET last_triggered_at = $1 WHERE id = $2`, at, alertID)
+	return err
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PendingNotificationRepository 免打扰期间暂存通知的仓库接口
+type PendingNotificationRepository interface {
+	AddPendingNotification(ctx context.Context, notification *model.PendingNotification) error
+	ListPendingNotifications(ctx context.Context) ([]model.PendingNotification, error)
+	DeletePendingNotifications(ctx context.Context, ids []int64) error
+}
+
+type pendingNotificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewPendingNotificationRepository 创建暂存通知仓库
+func NewPendingNotificationRepository(db *sqlx.DB) PendingNotificationRepository {
+	return &pendingNotificationRepository{db: db}
+}
+
+func (r *pendingNotificationRepository) AddPendingNotification(ctx context.Context, notification *model.PendingNotification) error {
+	query := `
+		INSERT INTO pending_notifications (user_id, title, body, created_at, deliver_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	return r.db.QueryRowContext(ctx, query,
+		notification.UserID, notification.Title, notification.Body, notification.CreatedAt, notification.DeliverAt,
+	).Scan(&notification.ID)
+}
+
+// ListPendingNotifications 按用户与暂存顺序列出所有暂存的通知
+func (r *pendingNotificationRepository) ListPendingNotifications(ctx context.Context) ([]model.PendingNotification, error) {
+	notifications := make([]model.PendingNotification, 0)
+	query := `SELECT id, user_id, title, body, created_at, deliver_at FROM pending_notifications ORDER BY user_id, id`
+	if err := r.db.SelectContext(ctx, &notifications, query); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *pendingNotificationRepository) DeletePendingNotifications(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM pending_notifications WHERE id = ANY($1)`, pq.Array(ids))
+	return err
+}
@@ -1,6 +1,11 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
 	"fund-analyzer/internal/config"
 
 	"github.com/jmoiron/sqlx"
@@ -25,3 +30,31 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*sqlx.DB, error) {
 
 	return db, nil
 }
+
+// ProbeReadWrite 在事务内写入并读回临时表，检查数据库可读写
+// 事务最终回滚，临时表随之删除，不修改任何业务数据
+func ProbeReadWrite(ctx context.Context, db *sqlx.DB) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `CREATE TEMP TABLE selftest_probe (value TEXT) ON COMMIT DROP`); err != nil {
+		return fmt.Errorf("create temp table: %w", err)
+	}
+
+	token := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if _, err := tx.ExecContext(ctx, `INSERT INTO selftest_probe (value) VALUES ($1)`, token); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM selftest_probe WHERE value = $1`, token); err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if count != 1 {
+		return fmt.Errorf("read back %d rows, want 1", count)
+	}
+	return nil
+}
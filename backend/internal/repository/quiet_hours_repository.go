@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrQuietHoursNotFound 用户未设置免打扰时段
+var ErrQuietHoursNotFound = errors.New("quiet hours not found")
+
+// QuietHoursRepository 免打扰时段仓库接口
+type QuietHoursRepository interface {
+	GetQuietHours(ctx context.Context, userID int64) (*model.QuietHours, error)
+	UpsertQuietHours(ctx context.Context, quietHours *model.QuietHours) error
+}
+
+type quietHoursRepository struct {
+	db *sqlx.DB
+}
+
+// NewQuietHoursRepository 创建免打扰时段仓库
+func NewQuietHoursRepository(db *sqlx.DB) QuietHoursRepository {
+	return &quietHoursRepository{db: db}
+}
+
+func (r *quietHoursRepository) GetQuietHours(ctx context.Context, userID int64) (*model.QuietHours, error) {
+	var quietHours model.QuietHours
+	query := `SELECT user_id, enabled, start_time, end_time, timezone, updated_at FROM user_quiet_hours WHERE user_id = $1`
+	err := r.db.GetContext(ctx, &quietHours, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrQuietHoursNotFound
+		}
+		return nil, err
+	}
+	return &quietHours, nil
+}
+
+func (r *quietHoursRepository) UpsertQuietHours(ctx context.Context, quietHours *model.QuietHours) error {
+	query := `
+		INSERT INTO user_quiet_hours (user_id, enabled, start_time, end_time, timezone, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			timezone = EXCLUDED.timezone,
+			updated_at = EXCLUDED.updated_at`
+
+	quietHours.UpdatedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, query,
+		quietHours.UserID, quietHours.Enabled, quietHours.Start, quietHours.End, quietHours.Timezone, quietHours.UpdatedAt,
+	)
+	return err
+}
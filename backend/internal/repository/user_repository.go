@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"fund-analyzer/internal/model"
@@ -12,8 +13,9 @@ import (
 )
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrUserExists   = errors.New("user already exists")
+	ErrUserNotFound                = errors.New("user not found")
+	ErrUserExists                  = errors.New("user already exists")
+	ErrPendingRegistrationNotFound = errors.New("pending registration not found")
 )
 
 // UserRepository 用户仓库接口
@@ -24,15 +26,27 @@ type UserRepository interface {
 	UpdateUser(ctx context.Context, user *model.User) error
 	UpdateLoginAttempts(ctx context.Context, userID int64, attempts int, lockedUntil *time.Time) error
 
+	// Token 版本（重置密码时递增，使之前签发的 Token 失效）
+	IncrementTokenVersion(ctx context.Context, userID int64) error
+
 	// 验证码相关
 	CreateVerificationCode(ctx context.Context, code *model.VerificationCode) error
 	GetVerificationCode(ctx context.Context, email string, codeType model.VerificationCodeType) (*model.VerificationCode, error)
 	MarkVerificationCodeUsed(ctx context.Context, id int64) error
 
+	// 待验证的注册信息（按邮箱保存，重复注册时覆盖）
+	SavePendingRegistration(ctx context.Context, pending *model.PendingRegistration) error
+	GetPendingRegistration(ctx context.Context, email string) (*model.PendingRegistration, error)
+	DeletePendingRegistration(ctx context.Context, email string) error
+
 	// Token 黑名单
 	AddToBlacklist(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error
 	IsTokenBlacklisted(ctx context.Context, tokenHash string) (bool, error)
 	CleanExpiredBlacklist(ctx context.Context) error
+
+	// 账号删除与用户级 Token 吊销
+	DeleteUser(ctx context.Context, userID int64, email string) error
+	IsUserTokenRevoked(ctx context.Context, userID int64, issuedAt time.Time, tokenVersion int) (bool, error)
 }
 
 type userRepository struct {
@@ -115,13 +129,52 @@ func (r *userRepository) UpdateLoginAttempts(ctx context.Context, userID int64,
 	return err
 }
 
+// IncrementTokenVersion 递增用户的 Token 版本，之前签发的 Token 全部失效
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, userID int64) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET token_version = token_version + 1, updated_at = $1 WHERE id = $2`,
+		time.Now(), userID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 // 验证码相关方法
-func (r *userRepository) CreateVerificationCode(ctx context.Context, code *model.VerificationCode) error {
-	// 先使之前的验证码失效
-	_, _ = r.db.ExecContext(ctx,
+func (r *userRepository) CreateVerificationCode(ctx context.Context, code *model.VerificationCode) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// 按邮箱+类型加事务级锁，串行化并发创建，保证只有一个有效验证码
+	if _, err = tx.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext($1), $2)`,
+		code.Email, code.Type,
+	); err != nil {
+		return fmt.Errorf("lock verification codes: %w", err)
+	}
+
+	// 使之前的验证码失效
+	if _, err = tx.ExecContext(ctx,
 		`UPDATE verification_codes SET used = true WHERE email = $1 AND type = $2 AND used = false`,
 		code.Email, code.Type,
-	)
+	); err != nil {
+		return fmt.Errorf("invalidate verification codes: %w", err)
+	}
 
 	query := `
 		INSERT INTO verification_codes (email, code, type, expires_at, created_at)
@@ -129,9 +182,16 @@ func (r *userRepository) CreateVerificationCode(ctx context.Context, code *model
 		RETURNING id`
 
 	code.CreatedAt = time.Now()
-	return r.db.QueryRowContext(ctx, query,
+	if err = tx.QueryRowContext(ctx, query,
 		code.Email, code.Code, code.Type, code.ExpiresAt, code.CreatedAt,
-	).Scan(&code.ID)
+	).Scan(&code.ID); err != nil {
+		return fmt.Errorf("insert verification code: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit verification code: %w", err)
+	}
+	return nil
 }
 
 func (r *userRepository) GetVerificationCode(ctx context.Context, email string, codeType model.VerificationCodeType) (*model.VerificationCode, error) {
@@ -157,6 +217,41 @@ func (r *userRepository) MarkVerificationCodeUsed(ctx context.Context, id int64)
 	return err
 }
 
+// 待验证注册信息方法
+func (r *userRepository) SavePendingRegistration(ctx context.Context, pending *model.PendingRegistration) error {
+	query := `
+		INSERT INTO pending_registrations (email, password_hash, nickname, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (email) DO UPDATE SET
+			password_hash = EXCLUDED.password_hash,
+			nickname = EXCLUDED.nickname,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at`
+
+	pending.CreatedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, query,
+		pending.Email, pending.PasswordHash, pending.Nickname, pending.ExpiresAt, pending.CreatedAt,
+	)
+	return err
+}
+
+func (r *userRepository) GetPendingRegistration(ctx context.Context, email string) (*model.PendingRegistration, error) {
+	var pending model.PendingRegistration
+	err := r.db.GetContext(ctx, &pending, `SELECT * FROM pending_registrations WHERE email = $1`, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPendingRegistrationNotFound
+		}
+		return nil, err
+	}
+	return &pending, nil
+}
+
+func (r *userRepository) DeletePendingRegistration(ctx context.Context, email string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM pending_registrations WHERE email = $1`, email)
+	return err
+}
+
 // Token 黑名单方法
 func (r *userRepository) AddToBlacklist(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error {
 	query := `
@@ -181,3 +276,77 @@ func (r *userRepository) CleanExpiredBlacklist(ctx context.Context) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM token_blacklist WHERE expires_at < $1`, time.Now())
 	return err
 }
+
+// DeleteUser 删除账号及其全部数据，并吊销该用户删除前签发的所有 Token
+// 所有操作在同一事务中完成
+func (r *userRepository) DeleteUser(ctx context.Context, userID int64, email string) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO user_token_revocations (user_id, revoked_before, created_at)
+		VALUES ($1, $2, $2)
+		ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before`,
+		userID, now,
+	); err != nil {
+		return fmt.Errorf("revoke tokens: %w", err)
+	}
+
+	statements := []struct {
+		name  string
+		query string
+		arg   interface{}
+	}{
+		{"user_funds", `DELETE FROM user_funds WHERE user_id = $1`, userID},
+		{"token_blacklist", `DELETE FROM token_blacklist WHERE user_id = $1`, userID},
+		{"verification_codes", `DELETE FROM verification_codes WHERE email = $1`, email},
+	}
+	for _, stmt := range statements {
+		if _, err = tx.ExecContext(ctx, stmt.query, stmt.arg); err != nil {
+			return fmt.Errorf("delete %s: %w", stmt.name, err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		err = ErrUserNotFound
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete user: %w", err)
+	}
+	return nil
+}
+
+// IsUserTokenRevoked 检查用户在 issuedAt 以 tokenVersion 签发的 Token 是否已被吊销
+// 账号删除（吊销记录）与重置密码（Token 版本）在同一次查询中检查
+func (r *userRepository) IsUserTokenRevoked(ctx context.Context, userID int64, issuedAt time.Time, tokenVersion int) (bool, error) {
+	var revoked bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_token_revocations WHERE user_id = $1 AND revoked_before >= $2
+		) OR EXISTS (
+			SELECT 1 FROM users WHERE id = $1 AND token_version > $3
+		)`
+	err := r.db.GetContext(ctx, &revoked, query, userID, issuedAt, tokenVersion)
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
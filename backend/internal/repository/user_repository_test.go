@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockUserRepository(t *testing.T) (UserRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewUserRepository(sqlx.NewDb(db, "postgres")), mock
+}
+
+var (
+	lockCodesSQL       = regexp.QuoteMeta(`SELECT pg_advisory_xact_lock(hashtext($1), $2)`)
+	invalidateCodesSQL = regexp.QuoteMeta(`UPDATE verification_codes SET used = true WHERE email = $1 AND type = $2 AND used = false`)
+	insertCodeSQL      = `INSERT INTO verification_codes`
+)
+
+func newTestVerificationCode() *model.VerificationCode {
+	return &model.VerificationCode{
+		Email:     "user@example.com",
+		Code:      "123456",
+		Type:      model.VerificationCodeTypeRegister,
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}
+}
+
+func TestCreateVerificationCode_Success(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+	code := newTestVerificationCode()
+
+	// 加锁 → 失效旧验证码 → 插入新验证码，全部在同一事务内
+	mock.ExpectBegin()
+	mock.ExpectExec(lockCodesSQL).
+		WithArgs(code.Email, code.Type).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(invalidateCodesSQL).
+		WithArgs(code.Email, code.Type).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(insertCodeSQL).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+	mock.ExpectCommit()
+
+	err := repo.CreateVerificationCode(context.Background(), code)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), code.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateVerificationCode_InvalidateFails_RollsBack(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+	code := newTestVerificationCode()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(lockCodesSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(invalidateCodesSQL).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	err := repo.CreateVerificationCode(context.Background(), code)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalidate verification codes")
+	assert.Zero(t, code.ID, "no code should be created when invalidation fails")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateVerificationCode_InsertFails_RollsBack(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+	code := newTestVerificationCode()
+
+	// 插入失败时回滚，之前的验证码不会被标记为已使用
+	mock.ExpectBegin()
+	mock.ExpectExec(lockCodesSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(invalidateCodesSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(insertCodeSQL).WillReturnError(errors.New("unique violation"))
+	mock.ExpectRollback()
+
+	err := repo.CreateVerificationCode(context.Background(), code)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "insert verification code")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateVerificationCode_LockFails(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(lockCodesSQL).WillReturnError(errors.New("lock timeout"))
+	mock.ExpectRollback()
+
+	err := repo.CreateVerificationCode(context.Background(), newTestVerificationCode())
+
+	require.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUser_CascadesInTransaction(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO user_token_revocations`).
+		WithArgs(int64(7), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM user_funds WHERE user_id = $1`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM token_blacklist WHERE user_id = $1`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM verification_codes WHERE email = $1`)).
+		WithArgs("user@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM users WHERE id = $1`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.DeleteUser(context.Background(), 7, "user@example.com")
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUser_FailureRollsBack(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO user_token_revocations`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM user_funds WHERE user_id = $1`)).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	err := repo.DeleteUser(context.Background(), 7, "user@example.com")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "delete user_funds")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUser_NotFoundRollsBack(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO user_token_revocations`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM user_funds`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM token_blacklist`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM verification_codes`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.DeleteUser(context.Background(), 7, "user@example.com")
+
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsUserTokenRevoked(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+	issuedAt := time.Now().Add(-time.Hour)
+
+	mock.ExpectQuery(`SELECT EXISTS \(\s*SELECT 1 FROM user_token_revocations .*\) OR EXISTS \(\s*SELECT 1 FROM users WHERE id = \$1 AND token_version > \$3`).
+		WithArgs(int64(7), issuedAt, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"revoked"}).AddRow(true))
+
+	revoked, err := repo.IsUserTokenRevoked(context.Background(), 7, issuedAt, 2)
+
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIncrementTokenVersion(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+
+	mock.ExpectExec(`UPDATE users SET token_version = token_version \+ 1`).
+		WithArgs(sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.IncrementTokenVersion(context.Background(), 7))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIncrementTokenVersion_UserNotFound(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+
+	mock.ExpectExec(`UPDATE users SET token_version`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.IncrementTokenVersion(context.Background(), 7), ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPendingRegistration_SaveUpsertsAndGetNotFound(t *testing.T) {
+	repo, mock := newMockUserRepository(t)
+	pending := &model.PendingRegistration{
+		Email:        "new@example.com",
+		PasswordHash: "hash",
+		Nickname:     "小明",
+		ExpiresAt:    time.Now().Add(10 * time.Minute),
+	}
+
+	// 重复注册时覆盖之前保存的信息
+	mock.ExpectExec(`INSERT INTO pending_registrations .* ON CONFLICT \(email\) DO UPDATE`).
+		WithArgs(pending.Email, pending.PasswordHash, pending.Nickname, pending.ExpiresAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM pending_registrations WHERE email = \$1`).
+		WithArgs("missing@example.com").
+		WillReturnError(sql.ErrNoRows)
+
+	require.NoError(t, repo.SavePendingRegistration(context.Background(), pending))
+	assert.False(t, pending.CreatedAt.IsZero())
+
+	_, err := repo.GetPendingRegistration(context.Background(), "missing@example.com")
+	assert.ErrorIs(t, err, ErrPendingRegistrationNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,8 +3,12 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"fund-analyzer/internal/config"
@@ -16,35 +20,71 @@ import (
 // AIService AI 分析服务接口
 type AIService interface {
 	Chat(ctx context.Context, req *model.ChatRequest, stream chan<- model.ChatChunk) error
-	AnalyzeStandard(ctx context.Context, data *model.MarketData, stream chan<- string) error
-	AnalyzeFast(ctx context.Context, data *model.MarketData, stream chan<- string) error
-	AnalyzeDeep(ctx context.Context, data *model.MarketData, stream chan<- string) error
+	AnalyzeStandard(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error
+	AnalyzeFast(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error
+	AnalyzeDeep(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error
+	AnalyzeCompare(ctx context.Context, current, reference *model.MarketSnapshot, stream chan<- model.ChatChunk) error
+	// AnalyzeSync 非流式分析，返回完整报告文本与 token 用量
+	AnalyzeSync(ctx context.Context, mode AnalysisMode, data *model.MarketData) (string, *llm.Usage, error)
 	SearchNews(ctx context.Context, query string) ([]model.SearchResult, error)
 	FetchWebpage(ctx context.Context, url string) (string, error)
+	// Ping 以极短的提示词调用一次大模型，用于部署自检
+	Ping(ctx context.Context) error
 }
 
+// ErrUnexpectedToolCalls 未提供工具时模型返回了工具调用
+var ErrUnexpectedToolCalls = errors.New("llm returned tool calls but no tools were provided")
+
 // aiService AI 服务实现
 type aiService struct {
 	llmClient       *llm.Client
 	ddgCrawler      crawler.DuckDuckGoCrawler
 	webpageFetcher  crawler.WebpageFetcher
+	// cnNewsCrawler 国内快讯源（search_cn_news 工具），DuckDuckGo 在国内常被屏蔽时使用，为 nil 时不提供该工具
+	cnNewsCrawler crawler.NewsCrawler
 	dataMatcher     DataMatcher
 	marketService   MarketService
 	newsService     NewsService
 	sectorService   SectorService
 	fundService     FundService
+
+	contextTokenBudget     int // ReAct 消息历史 token 预算
+	maxVerbatimToolResults int // 保留完整内容的最近工具结果数，<= 0 不限制
+	maxPromptFunds         int // 提示词中最多列出的自选基金数，<= 0 不限制
+
+	// toolBreakers 深度研究工具对应的熔断器，全部不可用时降级为标准分析
+	toolBreakers           []*crawler.CircuitBreaker
+	deepFallbackToStandard bool
+
+	// disclaimer 分析与对话结尾追加的风险提示
+	disclaimer disclaimer
+	// content 可热更新的分析模板，为 nil 时使用内置模板
+	content *ContentStore
+	// modes 各分析模式的温度与最大输出 token，以及快速分析是否输出结构化卡片
+	modes config.AnalysisConfig
+	// sectors 选取提供给模型的板块，为 nil 时取前 DefaultAnalysisSectors 个
+	sectors *SectorSelector
 }
 
+// DeepAnalysisDowngradeNotice 研究工具不可用时的降级提示
+const DeepAnalysisDowngradeNotice = "研究工具暂不可用，已切换为标准分析"
+
+// DefaultContextTokenBudget 默认消息历史 token 预算
+const DefaultContextTokenBudget = 24000
+
 // NewAIService 创建 AI 服务
 func NewAIService(
 	cfg *config.LLMConfig,
 	ddgCrawler crawler.DuckDuckGoCrawler,
 	webpageFetcher crawler.WebpageFetcher,
+	cnNewsCrawler crawler.NewsCrawler,
 	dataMatcher DataMatcher,
 	marketService MarketService,
 	newsService NewsService,
 	sectorService SectorService,
 	fundService FundService,
+	content *ContentStore,
+	toolBreakers ...*crawler.CircuitBreaker,
 ) (AIService, error) {
 	// 创建 LLM 客户端
 	timeout := time.Duration(cfg.Timeout) * time.Second
@@ -52,25 +92,54 @@ func NewAIService(
 		timeout = 120 * time.Second
 	}
 
+	provider, err := llm.LookupProvider(cfg.Provider)
+	if err != nil {
+		return nil, err
+	}
+
 	llmClient, err := llm.NewClient(llm.Config{
 		BaseURL: cfg.BaseURL,
 		APIKey:  cfg.APIKey,
 		Model:   cfg.Model,
 		Timeout: timeout,
+		Retry: llm.RetryConfig{
+			MaxRetries: cfg.Retry.MaxRetries,
+			BaseDelay:  time.Duration(cfg.Retry.BaseDelayMs) * time.Millisecond,
+			MaxDelay:   time.Duration(cfg.Retry.MaxDelayMs) * time.Millisecond,
+		},
+		Provider: provider,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create LLM client: %w", err)
 	}
 
+	budget := cfg.ContextTokenBudget
+	if budget <= 0 {
+		budget = DefaultContextTokenBudget
+	}
+
 	return &aiService{
 		llmClient:      llmClient,
 		ddgCrawler:     ddgCrawler,
 		webpageFetcher: webpageFetcher,
+		cnNewsCrawler:  cnNewsCrawler,
 		dataMatcher:    dataMatcher,
 		marketService:  marketService,
 		newsService:    newsService,
 		sectorService:  sectorService,
 		fundService:    fundService,
+
+		contextTokenBudget:     budget,
+		maxVerbatimToolResults: cfg.MaxVerbatimToolResults,
+		maxPromptFunds:         cfg.MaxPromptFunds,
+
+		toolBreakers:           toolBreakers,
+		deepFallbackToStandard: cfg.DeepFallbackToStandard,
+
+		disclaimer: newDisclaimer(cfg.Disclaimer),
+		content:    content,
+		modes:      cfg.Analysis,
+		sectors:    NewSectorSelector(cfg.Analysis.Sectors),
 	}, nil
 }
 
@@ -99,8 +168,8 @@ func (s *aiService) Chat(ctx context.Context, req *model.ChatRequest, stream cha
 		}
 	}
 
-	// 获取相关数据
-	marketData, err := s.fetchMarketData(ctx, modules, 0)
+	// 获取相关数据（自选基金需要用户 ID）
+	marketData, err := s.fetchMarketData(ctx, modules, req.UserID)
 	if err != nil {
 		stream <- model.ChatChunk{
 			Type:    model.ChunkTypeError,
@@ -110,11 +179,15 @@ func (s *aiService) Chat(ctx context.Context, req *model.ChatRequest, stream cha
 	}
 
 	// 构建系统提示词
-	systemPrompt := buildChatSystemPrompt(marketData)
+	systemPrompt := buildChatSystemPrompt(marketData, s.maxPromptFunds)
 
 	// 构建消息列表
+	// 回复语言：显式指定优先，否则跟随用户消息的主要语言
+	language := resolveLanguage(req.Language, req.Message)
+
 	messages := []llm.Message{
 		{Role: "system", Content: systemPrompt},
+		{Role: "system", Content: languageDirective(language)},
 	}
 
 	// 添加历史消息
@@ -138,177 +211,227 @@ func (s *aiService) Chat(ctx context.Context, req *model.ChatRequest, stream cha
 	}
 
 	// 调用 LLM 流式生成
-	eventChan, err := s.llmClient.ChatStream(ctx, messages)
-	if err != nil {
+	// 对话未提供工具，若模型仍只返回工具调用，追加指令重试一次，仍失败则明确报错
+	for attempt := 0; ; attempt++ {
+		eventChan, err := s.llmClient.ChatStream(ctx, messages)
+		if err != nil {
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeError,
+				Message: fmt.Sprintf("AI 服务调用失败: %v", err),
+			}
+			return err
+		}
+
+		content, gotToolCalls, err := forwardChatStream(eventChan, stream)
+		if err != nil {
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeError,
+				Message: err.Error(),
+			}
+			return err
+		}
+
+		if gotToolCalls && content == "" {
+			if attempt == 0 {
+				messages = append(messages, llm.Message{
+					Role:    "system",
+					Content: "当前对话不支持工具调用，请直接用文字回答用户的问题。",
+				})
+				stream <- model.ChatChunk{
+					Type:    model.ChunkTypeStatus,
+					Message: "正在重新生成回复...",
+				}
+				continue
+			}
+
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeError,
+				Message: "AI 服务返回了无法处理的工具调用，请稍后重试",
+			}
+			return ErrUnexpectedToolCalls
+		}
+
+		s.disclaimer.append(stream, language, content)
+
 		stream <- model.ChatChunk{
-			Type:    model.ChunkTypeError,
-			Message: fmt.Sprintf("AI 服务调用失败: %v", err),
+			Type: model.ChunkTypeDone,
 		}
-		return err
+		return nil
 	}
+}
+
+// forwardChatStream 转发流式内容，返回收到的全部内容和是否收到工具调用
+func forwardChatStream(eventChan <-chan llm.StreamEvent, stream chan<- model.ChatChunk) (string, bool, error) {
+	var content strings.Builder
+	gotToolCalls := false
 
-	// 处理流式响应
 	for event := range eventChan {
 		if event.Error != nil {
-			stream <- model.ChatChunk{
-				Type:    model.ChunkTypeError,
-				Message: event.Error.Error(),
-			}
-			return event.Error
+			return content.String(), gotToolCalls, event.Error
 		}
 
 		if event.Content != "" {
+			content.WriteString(event.Content)
 			stream <- model.ChatChunk{
 				Type:  model.ChunkTypeContent,
 				Chunk: event.Content,
 			}
 		}
 
+		if len(event.ToolCalls) > 0 {
+			gotToolCalls = true
+		}
+
 		if event.Done {
-			stream <- model.ChatChunk{
-				Type: model.ChunkTypeDone,
-			}
 			break
 		}
 	}
 
-	return nil
+	return content.String(), gotToolCalls, nil
 }
 
 // AnalyzeStandard 标准分析
-func (s *aiService) AnalyzeStandard(ctx context.Context, data *model.MarketData, stream chan<- string) error {
+func (s *aiService) AnalyzeStandard(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error {
 	defer close(stream)
 
-	// 构建标准分析提示词
-	systemPrompt := buildStandardAnalysisPrompt()
-	userPrompt := buildMarketDataPrompt(data)
+	stream <- model.ChatChunk{
+		Type:    model.ChunkTypeStatus,
+		Message: "正在生成分析报告...",
+	}
 
+	// 构建标准分析提示词
 	messages := []llm.Message{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userPrompt},
+		{Role: "system", Content: s.content.Load().Template(TemplateStandard, buildStandardAnalysisPrompt)},
+		{Role: "user", Content: buildMarketDataPrompt(data, s.maxPromptFunds)},
 	}
 
-	// 调用 LLM 流式生成
-	eventChan, err := s.llmClient.ChatStream(ctx, messages)
-	if err != nil {
-		return err
-	}
+	return s.streamAnalysis(ctx, messages, modeOptions(s.modes.Standard), stream)
+}
 
-	// 处理流式响应
-	for event := range eventChan {
-		if event.Error != nil {
-			return event.Error
-		}
+// AnalyzeFast 快速分析
+func (s *aiService) AnalyzeFast(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error {
+	defer close(stream)
 
-		if event.Content != "" {
-			stream <- event.Content
-		}
+	stream <- model.ChatChunk{
+		Type:    model.ChunkTypeStatus,
+		Message: "正在生成快速分析...",
+	}
 
-		if event.Done {
-			break
-		}
+	if s.modes.FastCard {
+		return s.analyzeFastCard(ctx, data, stream)
 	}
 
-	return nil
+	return s.streamAnalysis(ctx, s.fastMessages(data), modeOptions(s.modes.Fast), stream)
 }
 
-// AnalyzeFast 快速分析
-func (s *aiService) AnalyzeFast(ctx context.Context, data *model.MarketData, stream chan<- string) error {
+// fastMessages 构建快速分析消息（提示词更简洁）
+func (s *aiService) fastMessages(data *model.MarketData) []llm.Message {
+	return []llm.Message{
+		{Role: "system", Content: s.content.Load().Template(TemplateFast, buildFastAnalysisPrompt)},
+		{Role: "user", Content: buildMarketDataPrompt(data, s.maxPromptFunds)},
+	}
+}
+
+// AnalyzeCompare 时段对比分析（如周环比）
+func (s *aiService) AnalyzeCompare(ctx context.Context, current, reference *model.MarketSnapshot, stream chan<- model.ChatChunk) error {
 	defer close(stream)
 
-	// 构建快速分析提示词（更简洁）
-	systemPrompt := buildFastAnalysisPrompt()
-	userPrompt := buildMarketDataPrompt(data)
+	stream <- model.ChatChunk{
+		Type:    model.ChunkTypeStatus,
+		Message: fmt.Sprintf("正在对比 %s 与 %s 的市场变化...", reference.Date, current.Date),
+	}
 
+	// 构建对比分析提示词（包含两份快照及变化）
 	messages := []llm.Message{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userPrompt},
+		{Role: "system", Content: s.content.Load().Template(TemplateCompare, buildComparisonAnalysisPrompt)},
+		{Role: "user", Content: buildComparisonDataPrompt(current, reference, s.maxPromptFunds)},
 	}
 
-	// 调用 LLM 流式生成
-	eventChan, err := s.llmClient.ChatStream(ctx, messages)
+	return s.streamAnalysis(ctx, messages, modeOptions(s.modes.Standard), stream)
+}
+
+// streamAnalysis 流式生成不带工具的分析，转发内容并追加风险提示后发送 done，失败时发送 error
+func (s *aiService) streamAnalysis(ctx context.Context, messages []llm.Message, opts *llm.ChatOptions, stream chan<- model.ChatChunk) error {
+	eventChan, err := s.llmClient.ChatStreamWithOptions(ctx, messages, opts)
 	if err != nil {
+		stream <- model.ChatChunk{
+			Type:    model.ChunkTypeError,
+			Message: fmt.Sprintf("AI 服务调用失败: %v", err),
+		}
 		return err
 	}
 
-	// 处理流式响应
-	for event := range eventChan {
-		if event.Error != nil {
-			return event.Error
+	content, _, err := forwardChatStream(eventChan, stream)
+	if err != nil {
+		stream <- model.ChatChunk{
+			Type:    model.ChunkTypeError,
+			Message: err.Error(),
 		}
+		return err
+	}
 
-		if event.Content != "" {
-			stream <- event.Content
-		}
+	// 分析提示词均为中文，风险提示使用中文
+	s.disclaimer.append(stream, LanguageChinese, content)
 
-		if event.Done {
-			break
-		}
+	stream <- model.ChatChunk{
+		Type: model.ChunkTypeDone,
 	}
-
 	return nil
 }
 
+// modeOptions 将分析模式配置转换为 LLM 请求参数，0 值字段使用服务商默认值
+func modeOptions(mode config.AnalysisModeConfig) *llm.ChatOptions {
+	return &llm.ChatOptions{
+		Temperature: mode.Temperature,
+		MaxTokens:   mode.MaxTokens,
+	}
+}
+
 // AnalyzeDeep 深度研究（ReAct Agent）
-func (s *aiService) AnalyzeDeep(ctx context.Context, data *model.MarketData, stream chan<- string) error {
+// 模型输出作为 content 发送，工具调用过程以 tool_call 和 status 穿插其中
+func (s *aiService) AnalyzeDeep(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error {
+	// 研究工具全部熔断时无法进行研究，直接降级为标准分析，节省 ReAct 循环的 LLM 调用
+	if s.deepFallbackToStandard && !s.toolsAvailable() {
+		stream <- model.ChatChunk{
+			Type:    model.ChunkTypeStatus,
+			Message: DeepAnalysisDowngradeNotice,
+		}
+		return s.AnalyzeStandard(ctx, data, stream)
+	}
+
 	defer close(stream)
 
-	// 定义可用工具
-	tools := []llm.Tool{
-		{
-			Type: "function",
-			Function: llm.Function{
-				Name:        "search_news",
-				Description: "搜索最近一周的相关新闻，用于获取更多市场信息和背景资料",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"query": map[string]interface{}{
-							"type":        "string",
-							"description": "搜索关键词，如'A股市场'、'科技板块'等",
-						},
-					},
-					"required": []string{"query"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: llm.Function{
-				Name:        "fetch_webpage",
-				Description: "获取网页内容，用于深入了解某个新闻或文章的详细信息",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"url": map[string]interface{}{
-							"type":        "string",
-							"description": "要获取的网页 URL",
-						},
-					},
-					"required": []string{"url"},
-				},
-			},
-		},
+	stream <- model.ChatChunk{
+		Type:    model.ChunkTypeStatus,
+		Message: "正在进行深度研究，可能需要搜索相关新闻...",
 	}
 
-	// 构建深度分析提示词
-	systemPrompt := buildDeepAnalysisPrompt()
-	userPrompt := buildMarketDataPrompt(data)
+	tools := s.deepTools()
 
+	// 构建深度分析提示词
 	messages := []llm.Message{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userPrompt},
+		{Role: "system", Content: s.content.Load().Template(TemplateDeep, buildDeepAnalysisPrompt)},
+		{Role: "user", Content: buildMarketDataPrompt(data, s.maxPromptFunds)},
 	}
 
 	// ReAct 循环
+	var fullContent strings.Builder
 	maxIterations := 5
 	for i := 0; i < maxIterations; i++ {
+		// 只保留最近若干条工具结果的完整内容，再控制消息历史在 token 预算内，避免后期迭代超出上下文窗口
+		messages = limitVerbatimToolResults(messages, s.maxVerbatimToolResults)
+		messages = trimToolHistory(messages, s.contextTokenBudget)
+
 		// 调用 LLM（带工具）
-		eventChan, err := s.llmClient.ChatStreamWithOptions(ctx, messages, &llm.ChatOptions{
-			Tools:      tools,
-			ToolChoice: "auto",
-		})
+		opts := modeOptions(s.modes.Deep)
+		opts.Tools = tools
+		opts.ToolChoice = "auto"
+		eventChan, err := s.llmClient.ChatStreamWithOptions(ctx, messages, opts)
 		if err != nil {
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeError,
+				Message: fmt.Sprintf("AI 服务调用失败: %v", err),
+			}
 			return err
 		}
 
@@ -319,12 +442,20 @@ func (s *aiService) AnalyzeDeep(ctx context.Context, data *model.MarketData, str
 
 		for event := range eventChan {
 			if event.Error != nil {
+				stream <- model.ChatChunk{
+					Type:    model.ChunkTypeError,
+					Message: event.Error.Error(),
+				}
 				return event.Error
 			}
 
 			if event.Content != "" {
 				contentBuilder.WriteString(event.Content)
-				stream <- event.Content
+				fullContent.WriteString(event.Content)
+				stream <- model.ChatChunk{
+					Type:  model.ChunkTypeContent,
+					Chunk: event.Content,
+				}
 			}
 
 			if len(event.ToolCalls) > 0 {
@@ -348,40 +479,146 @@ func (s *aiService) AnalyzeDeep(ctx context.Context, data *model.MarketData, str
 		// 添加助手消息
 		assistantContent := contentBuilder.String()
 		messages = append(messages, llm.Message{
-			Role:    "assistant",
-			Content: assistantContent,
+			Role:      "assistant",
+			Content:   assistantContent,
+			ToolCalls: toolCalls,
 		})
 
-		// 处理工具调用
-		for _, tc := range toolCalls {
-			// 发送工具调用状态
-			stream <- fmt.Sprintf("\n\n🔧 正在调用工具: %s\n", tc.Function.Name)
+		// 发送本轮调用的工具
+		toolNames := make([]string, len(toolCalls))
+		for j, tc := range toolCalls {
+			toolNames[j] = tc.Function.Name
+		}
+		stream <- model.ChatChunk{
+			Type:  model.ChunkTypeToolCall,
+			Tools: toolNames,
+		}
 
-			// 执行工具
-			result, err := s.executeToolCall(ctx, tc)
-			if err != nil {
-				result = fmt.Sprintf("工具调用失败: %v", err)
+		// 发送工具调用状态
+		if len(toolCalls) > 1 {
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeStatus,
+				Message: fmt.Sprintf("正在并行调用 %d 个工具: %s", len(toolCalls), strings.Join(toolNames, ", ")),
+			}
+		} else {
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeStatus,
+				Message: fmt.Sprintf("正在调用工具: %s", toolNames[0]),
 			}
+		}
+
+		// 并行执行工具，结果按调用顺序追加
+		results := s.executeToolCalls(ctx, toolCalls)
+		for j, tc := range toolCalls {
+			result := results[j]
 
 			// 发送工具结果摘要
 			resultSummary := result
 			if len(resultSummary) > 200 {
 				resultSummary = resultSummary[:200] + "..."
 			}
-			stream <- fmt.Sprintf("📋 工具结果: %s\n\n", resultSummary)
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeStatus,
+				Message: fmt.Sprintf("工具结果: %s", resultSummary),
+			}
 
 			// 添加工具结果消息
 			messages = append(messages, llm.Message{
-				Role:    "tool",
-				Content: result,
-				Name:    tc.Function.Name,
+				Role:       "tool",
+				Content:    result,
+				Name:       tc.Function.Name,
+				ToolCallID: tc.ID,
 			})
 		}
 	}
 
+	s.disclaimer.append(stream, LanguageChinese, fullContent.String())
+
+	stream <- model.ChatChunk{
+		Type: model.ChunkTypeDone,
+	}
 	return nil
 }
 
+// deepTools 深度研究可用的工具，国内快讯源未配置时不提供 search_cn_news
+func (s *aiService) deepTools() []llm.Tool {
+	tools := []llm.Tool{
+		{
+			Type: "function",
+			Function: llm.Function{
+				Name:        "search_news",
+				Description: "搜索最近一周的相关新闻，用于获取更多市场信息和背景资料",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "搜索关键词，如'A股市场'、'科技板块'等",
+						},
+					},
+					"required": []string{"query"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.Function{
+				Name:        "fetch_webpage",
+				Description: "获取网页内容，用于深入了解某个新闻或文章的详细信息",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"url": map[string]interface{}{
+							"type":        "string",
+							"description": "要获取的网页 URL",
+						},
+					},
+					"required": []string{"url"},
+				},
+			},
+		},
+	}
+	if s.cnNewsCrawler != nil {
+		tools = append(tools, llm.Tool{
+			Type: "function",
+			Function: llm.Function{
+				Name:        "search_cn_news",
+				Description: "搜索国内 7×24 财经快讯，A 股、政策、行业等国内话题优先使用",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "搜索关键词，多个关键词用空格分隔，如'半导体 国产替代'",
+						},
+					},
+					"required": []string{"query"},
+				},
+			},
+		})
+	}
+	return tools
+}
+
+// toolsAvailable 是否至少有一个研究工具可用，未配置熔断器时视为可用
+func (s *aiService) toolsAvailable() bool {
+	if len(s.toolBreakers) == 0 {
+		return true
+	}
+	for _, breaker := range s.toolBreakers {
+		if breaker.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping 以极短的提示词调用一次大模型，检查服务商连通性与密钥
+func (s *aiService) Ping(ctx context.Context) error {
+	_, err := s.llmClient.ChatWithOptions(ctx, []llm.Message{{Role: "user", Content: "ping"}}, &llm.ChatOptions{MaxTokens: 1})
+	return err
+}
+
 // SearchNews 搜索新闻
 func (s *aiService) SearchNews(ctx context.Context, query string) ([]model.SearchResult, error) {
 	return s.ddgCrawler.Search(ctx, query, 10)
@@ -392,6 +629,44 @@ func (s *aiService) FetchWebpage(ctx context.Context, url string) (string, error
 	return s.webpageFetcher.Fetch(ctx, url)
 }
 
+// maxParallelToolCalls 同一轮中同时执行的工具调用数上限
+const maxParallelToolCalls = 3
+
+// executeToolCalls 以有限并发执行一轮中的全部工具调用，返回与 toolCalls 顺序一致的结果
+// 失败的调用以说明文字作为结果交给模型；ctx 取消（如客户端断开）时停止进行中与未开始的调用
+func (s *aiService) executeToolCalls(ctx context.Context, toolCalls []llm.ToolCall) []string {
+	results := make([]string, len(toolCalls))
+	sem := make(chan struct{}, maxParallelToolCalls)
+	var wg sync.WaitGroup
+
+	for i, tc := range toolCalls {
+		wg.Add(1)
+		go func(i int, tc llm.ToolCall) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = fmt.Sprintf("工具调用失败: %v", ctx.Err())
+				return
+			}
+
+			result, err := s.executeToolCall(ctx, tc)
+			if errors.Is(err, crawler.ErrCircuitOpen) {
+				// 尽力而为的数据源熔断，告知模型不再依赖该工具
+				result = fmt.Sprintf("工具 %s 暂时不可用，请基于已有数据继续分析", tc.Function.Name)
+			} else if err != nil {
+				result = fmt.Sprintf("工具调用失败: %v", err)
+			}
+			results[i] = result
+		}(i, tc)
+	}
+
+	wg.Wait()
+	return results
+}
+
 // executeToolCall 执行工具调用
 func (s *aiService) executeToolCall(ctx context.Context, tc llm.ToolCall) (string, error) {
 	switch tc.Function.Name {
@@ -408,15 +683,25 @@ func (s *aiService) executeToolCall(ctx context.Context, tc llm.ToolCall) (strin
 			return "", err
 		}
 
-		// 格式化搜索结果
-		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("搜索 \"%s\" 的结果:\n\n", args.Query))
-		for i, r := range results {
-			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, r.Title))
-			sb.WriteString(fmt.Sprintf("   URL: %s\n", r.URL))
-			sb.WriteString(fmt.Sprintf("   摘要: %s\n\n", r.Snippet))
+		return formatSearchResults(args.Query, results), nil
+
+	case "search_cn_news":
+		var args struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		if s.cnNewsCrawler == nil {
+			return "", fmt.Errorf("unknown tool: %s", tc.Function.Name)
 		}
-		return sb.String(), nil
+
+		news, err := s.cnNewsCrawler.GetNewsFlash(ctx, cnNewsSearchPool)
+		if err != nil {
+			return "", err
+		}
+
+		return formatSearchResults(args.Query, matchNewsFlash(args.Query, news)), nil
 
 	case "fetch_webpage":
 		var args struct {
@@ -443,6 +728,117 @@ func (s *aiService) executeToolCall(ctx context.Context, tc llm.ToolCall) (strin
 	}
 }
 
+// formatSearchResults 格式化搜索结果，转载导致的近似重复结果只保留一条
+func formatSearchResults(query string, results []model.SearchResult) string {
+	results, merged := dedupeSearchResults(results)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("搜索 \"%s\" 的结果:\n\n", query))
+	for i, r := range results {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, r.Title))
+		if r.URL != "" {
+			sb.WriteString(fmt.Sprintf("   URL: %s\n", r.URL))
+		}
+		sb.WriteString(fmt.Sprintf("   摘要: %s\n\n", r.Snippet))
+	}
+	if merged > 0 {
+		sb.WriteString(fmt.Sprintf("（已合并 %d 条转载的重复结果）\n", merged))
+	}
+	return sb.String()
+}
+
+// 工具结果压缩后保留的最大字符数
+const compactedToolResultRunes = 300
+
+// trimToolHistory 控制 ReAct 消息历史在 token 预算内
+// 依次：压缩较早的工具结果 → 压缩最近一轮的工具结果 → 按轮丢弃最早的工具往返
+// system 与首条 user 消息始终保留
+func trimToolHistory(messages []llm.Message, budget int) []llm.Message {
+	if budget <= 0 || llm.EstimateMessagesTokens(messages) <= budget {
+		return messages
+	}
+
+	// 最近一轮工具结果从最后一条 assistant 消息之后开始
+	latestStart := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			latestStart = i + 1
+			break
+		}
+	}
+
+	compact := func(from, to int) bool {
+		for i := from; i < to; i++ {
+			if messages[i].Role != "tool" {
+				continue
+			}
+			messages[i].Content = compactToolResult(messages[i].Content)
+			if llm.EstimateMessagesTokens(messages) <= budget {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 1. 从最早开始压缩旧的工具结果
+	if compact(0, latestStart) {
+		return messages
+	}
+	// 2. 压缩最近一轮的工具结果
+	if compact(latestStart, len(messages)) {
+		return messages
+	}
+
+	// 3. 按轮丢弃最早的工具往返（assistant 消息及其后的全部 tool 消息），保留 system 与首条 user 消息
+	// 整轮丢弃，避免留下找不到对应 tool_calls 的 tool 消息；最近一轮始终保留
+	head := 0
+	for head < len(messages) && (messages[head].Role == "system" || (messages[head].Role == "user" && head <= 1)) {
+		head++
+	}
+	for llm.EstimateMessagesTokens(messages) > budget {
+		end := head + 1
+		for end < len(messages) && messages[end].Role == "tool" {
+			end++
+		}
+		if end >= len(messages) {
+			break
+		}
+		messages = append(messages[:head], messages[end:]...)
+	}
+
+	return messages
+}
+
+// limitVerbatimToolResults 仅保留最近 max 条工具结果的完整内容，更早的结果被压缩
+// max <= 0 时不做处理
+func limitVerbatimToolResults(messages []llm.Message, max int) []llm.Message {
+	if max <= 0 {
+		return messages
+	}
+
+	kept := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "tool" {
+			continue
+		}
+		if kept < max {
+			kept++
+			continue
+		}
+		messages[i].Content = compactToolResult(messages[i].Content)
+	}
+	return messages
+}
+
+// compactToolResult 压缩工具结果，仅保留开头部分
+func compactToolResult(content string) string {
+	runes := []rune(content)
+	if len(runes) <= compactedToolResultRunes {
+		return content
+	}
+	return string(runes[:compactedToolResultRunes]) + "\n\n[工具结果已压缩...]"
+}
+
 // fetchMarketData 获取市场数据
 func (s *aiService) fetchMarketData(ctx context.Context, modules []DataModule, userID int64) (*model.MarketData, error) {
 	data := &model.MarketData{}
@@ -462,19 +858,15 @@ func (s *aiService) fetchMarketData(ctx context.Context, modules []DataModule, u
 			}
 
 		case ModuleNews:
-			news, err := s.newsService.GetNewsList(ctx, 20)
+			news, err := s.newsService.GetNews(ctx, NewsQuery{Limit: 20})
 			if err == nil {
-				data.News = news
+				data.News = news.Items
 			}
 
 		case ModuleSectors:
 			sectors, err := s.sectorService.GetSectorList(ctx)
 			if err == nil {
-				// 只取前 20 个板块
-				if len(sectors) > 20 {
-					sectors = sectors[:20]
-				}
-				data.Sectors = sectors
+				data.Sectors = s.sectors.Select(sectors, 0)
 			}
 
 		case ModuleFunds:
@@ -497,7 +889,7 @@ func (s *aiService) fetchMarketData(ctx context.Context, modules []DataModule, u
 }
 
 // buildChatSystemPrompt 构建聊天系统提示词
-func buildChatSystemPrompt(data *model.MarketData) string {
+func buildChatSystemPrompt(data *model.MarketData, maxFunds int) string {
 	var sb strings.Builder
 
 	sb.WriteString(`你是一个专业的基金投资分析助手，名叫"小基"。你的职责是帮助用户分析市场行情、解答投资问题、提供投资建议。
@@ -516,8 +908,11 @@ func buildChatSystemPrompt(data *model.MarketData) string {
 		sb.WriteString("\n### 市场指数\n")
 		for _, idx := range data.Indices {
 			status := "📈"
-			if !idx.IsUp {
+			switch idx.Status {
+			case model.StatusDown:
 				status = "📉"
+			case model.StatusFlat:
+				status = "➖"
 			}
 			sb.WriteString(fmt.Sprintf("- %s %s: %s (%s)\n", status, idx.Name, idx.Price, idx.Change))
 		}
@@ -570,7 +965,11 @@ func buildChatSystemPrompt(data *model.MarketData) string {
 	// 添加基金数据
 	if len(data.Funds) > 0 {
 		sb.WriteString("\n### 用户自选基金\n")
-		for _, fund := range data.Funds {
+		funds, note := limitPromptFunds(data.Funds, maxFunds)
+		if note != "" {
+			sb.WriteString(note + "\n")
+		}
+		for _, fund := range funds {
 			status := "📈"
 			if strings.HasPrefix(fund.DayGrowth, "-") {
 				status = "📉"
@@ -648,11 +1047,12 @@ func buildDeepAnalysisPrompt() string {
 
 ## 可用工具
 1. search_news: 搜索最近的相关新闻
-2. fetch_webpage: 获取网页详细内容
+2. search_cn_news: 搜索国内财经快讯（A 股、政策等国内话题优先使用）
+3. fetch_webpage: 获取网页详细内容
 
 ## 研究流程
 1. 首先分析提供的市场数据
-2. 根据数据中的热点，使用 search_news 搜索相关新闻
+2. 根据数据中的热点，使用 search_news 或 search_cn_news 搜索相关新闻
 3. 如果需要深入了解某个新闻，使用 fetch_webpage 获取详情
 4. 综合所有信息，生成深度研究报告
 
@@ -683,12 +1083,44 @@ func buildDeepAnalysisPrompt() string {
 4. 引用新闻时要注明来源`
 }
 
-// buildMarketDataPrompt 构建市场数据提示词
-func buildMarketDataPrompt(data *model.MarketData) string {
+// buildMarketDataPrompt 构建市场数据提示词，自选基金最多列出 maxFunds 只（<= 0 不限制）
+func buildMarketDataPrompt(data *model.MarketData, maxFunds int) string {
 	var sb strings.Builder
 
 	sb.WriteString("# 当前市场数据\n\n")
+	writeMarketDataTables(&sb, data, maxFunds)
+
+	sb.WriteString("\n请根据以上数据进行分析。")
+
+	return sb.String()
+}
+
+// limitPromptFunds 按日涨跌幅绝对值选取前 maxFunds 只基金（<= 0 不限制），保持原有顺序
+// 发生截断时返回"展示 N / M 只"的说明，否则说明为空
+func limitPromptFunds(funds []model.FundValuation, maxFunds int) ([]model.FundValuation, string) {
+	if maxFunds <= 0 || len(funds) <= maxFunds {
+		return funds, ""
+	}
 
+	order := make([]int, len(funds))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return math.Abs(parsePercentage(funds[order[i]].DayGrowth)) > math.Abs(parsePercentage(funds[order[j]].DayGrowth))
+	})
+	order = order[:maxFunds]
+	sort.Ints(order)
+
+	limited := make([]model.FundValuation, 0, maxFunds)
+	for _, i := range order {
+		limited = append(limited, funds[i])
+	}
+	return limited, fmt.Sprintf("（共 %d 只自选基金，按日涨跌幅绝对值展示前 %d 只）", len(funds), maxFunds)
+}
+
+// writeMarketDataTables 写入市场数据表格（指数、贵金属、快讯、板块、成交量、分时、基金）
+func writeMarketDataTables(sb *strings.Builder, data *model.MarketData, maxFunds int) {
 	// 市场指数
 	if len(data.Indices) > 0 {
 		sb.WriteString("## 市场指数\n")
@@ -733,7 +1165,7 @@ func buildMarketDataPrompt(data *model.MarketData) string {
 		sb.WriteString("| 板块名称 | 涨跌幅 | 主力净流入 | 主力占比 |\n")
 		sb.WriteString("|---------|--------|-----------|----------|\n")
 		for i, sector := range data.Sectors {
-			if i >= 20 {
+			if i >= MaxAnalysisSectors {
 				break
 			}
 			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
@@ -742,12 +1174,39 @@ func buildMarketDataPrompt(data *model.MarketData) string {
 		sb.WriteString("\n")
 	}
 
+	// 成交量
+	if len(data.VolumeTrends) > 0 {
+		sb.WriteString("## 两市成交量\n")
+		sb.WriteString("| 日期 | 总成交额 | 沪市 | 深市 | 北交所 |\n")
+		sb.WriteString("|------|---------|------|------|--------|\n")
+		for _, v := range data.VolumeTrends {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+				v.Date, v.TotalVolume, v.Shanghai, v.Shenzhen, v.Beijing))
+		}
+		sb.WriteString("\n")
+	}
+
+	// 分时
+	if len(data.MinuteData) > 0 {
+		sb.WriteString("## 上证指数分时走势\n")
+		sb.WriteString("| 时间 | 价格 | 涨跌幅 | 成交量 |\n")
+		sb.WriteString("|------|------|--------|--------|\n")
+		for _, m := range data.MinuteData {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", m.Time, m.Price, m.ChangeRate, m.Volume))
+		}
+		sb.WriteString("\n")
+	}
+
 	// 基金
 	if len(data.Funds) > 0 {
 		sb.WriteString("## 用户自选基金\n")
+		funds, note := limitPromptFunds(data.Funds, maxFunds)
+		if note != "" {
+			sb.WriteString(note + "\n\n")
+		}
 		sb.WriteString("| 基金名称 | 估值 | 日涨幅 | 连涨/跌 |\n")
 		sb.WriteString("|---------|------|--------|--------|\n")
-		for _, fund := range data.Funds {
+		for _, fund := range funds {
 			consecutive := fmt.Sprintf("%d天", fund.ConsecutiveDays)
 			if fund.ConsecutiveDays > 0 {
 				consecutive = fmt.Sprintf("连涨%d天", fund.ConsecutiveDays)
@@ -759,8 +1218,5 @@ func buildMarketDataPrompt(data *model.MarketData) string {
 		}
 		sb.WriteString("\n")
 	}
-
-	sb.WriteString("\n请根据以上数据进行分析。")
-
-	return sb.String()
 }
+
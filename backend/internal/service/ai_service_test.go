@@ -0,0 +1,839 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/pkg/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrimToolHistory_UnderBudgetUnchanged(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "system", Content: "系统提示"},
+		{Role: "user", Content: "市场数据"},
+		{Role: "assistant", Content: ""},
+		{Role: "tool", Name: "search_news", Content: "搜索结果"},
+	}
+
+	trimmed := trimToolHistory(messages, 1000)
+	if len(trimmed) != 4 || trimmed[3].Content != "搜索结果" {
+		t.Errorf("messages under budget should be unchanged, got %+v", trimmed)
+	}
+}
+
+func TestTrimToolHistory_StaysUnderBudgetAcrossIterations(t *testing.T) {
+	const budget = 8000
+	largeResult := strings.Repeat("新闻内容", 1250) // 约 5000 tokens
+
+	messages := []llm.Message{
+		{Role: "system", Content: "系统提示"},
+		{Role: "user", Content: "市场数据"},
+	}
+
+	for i := 0; i < 5; i++ {
+		messages = trimToolHistory(messages, budget)
+		if got := llm.EstimateMessagesTokens(messages); got > budget {
+			t.Fatalf("iteration %d: history has %d tokens, budget %d", i, got, budget)
+		}
+
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: "调用工具"},
+			llm.Message{Role: "tool", Name: "fetch_webpage", Content: largeResult},
+		)
+	}
+
+	messages = trimToolHistory(messages, budget)
+	if got := llm.EstimateMessagesTokens(messages); got > budget {
+		t.Fatalf("final history has %d tokens, budget %d", got, budget)
+	}
+
+	// system 与 user 消息保留
+	if messages[0].Role != "system" || messages[1].Role != "user" {
+		t.Errorf("system and user messages should be kept, got %s, %s", messages[0].Role, messages[1].Role)
+	}
+
+	// 最近一轮的工具结果保留完整内容，较早的被压缩
+	last := messages[len(messages)-1]
+	if last.Role != "tool" || last.Content != largeResult {
+		t.Error("most recent tool result should be kept intact")
+	}
+	for _, m := range messages[:len(messages)-1] {
+		if m.Role == "tool" && m.Content == largeResult {
+			t.Error("older tool results should be compacted")
+		}
+	}
+}
+
+func TestTrimToolHistory_DropsOldestWhenCompactionInsufficient(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "system", Content: "系统提示"},
+		{Role: "user", Content: "市场数据"},
+	}
+	for i := 0; i < 10; i++ {
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: "调用工具"},
+			llm.Message{Role: "tool", Name: "search_news", Content: strings.Repeat("搜", 1000)},
+		)
+	}
+
+	const budget = 600
+	trimmed := trimToolHistory(messages, budget)
+
+	if got := llm.EstimateMessagesTokens(trimmed); got > budget {
+		t.Fatalf("history has %d tokens, budget %d", got, budget)
+	}
+	if trimmed[0].Role != "system" || trimmed[1].Role != "user" {
+		t.Error("system and user messages should be kept")
+	}
+	if len(trimmed) >= len(messages) {
+		t.Error("oldest tool exchanges should be dropped")
+	}
+}
+
+func TestTrimToolHistory_DropsWholeRounds(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "system", Content: "系统提示"},
+		{Role: "user", Content: "市场数据"},
+	}
+	for i := 0; i < 6; i++ {
+		calls := []llm.ToolCall{
+			{ID: fmt.Sprintf("call_%d_a", i), Type: "function", Function: llm.FunctionCall{Name: "search_news"}},
+			{ID: fmt.Sprintf("call_%d_b", i), Type: "function", Function: llm.FunctionCall{Name: "fetch_webpage"}},
+		}
+		messages = append(messages, llm.Message{Role: "assistant", Content: "调用工具", ToolCalls: calls})
+		for _, tc := range calls {
+			messages = append(messages, llm.Message{Role: "tool", Name: tc.Function.Name, ToolCallID: tc.ID, Content: strings.Repeat("搜", 1000)})
+		}
+	}
+
+	const budget = 700
+	trimmed := trimToolHistory(messages, budget)
+
+	require.Less(t, len(trimmed), len(messages), "oldest rounds should be dropped")
+	assert.Equal(t, "system", trimmed[0].Role)
+	assert.Equal(t, "user", trimmed[1].Role)
+
+	// 每条 tool 消息都能在其之前最近的 assistant 消息中找到对应的工具调用
+	var pending map[string]bool
+	for i, m := range trimmed[2:] {
+		switch m.Role {
+		case "assistant":
+			pending = make(map[string]bool, len(m.ToolCalls))
+			for _, tc := range m.ToolCalls {
+				pending[tc.ID] = true
+			}
+		case "tool":
+			require.True(t, pending[m.ToolCallID], "message %d: orphaned tool result %s", i+2, m.ToolCallID)
+			delete(pending, m.ToolCallID)
+		}
+	}
+	assert.Empty(t, pending, "every kept tool call should keep its result")
+}
+
+func TestLimitVerbatimToolResults_KeepsConfiguredNumber(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "system", Content: "系统提示"},
+		{Role: "user", Content: strings.Repeat("市场数据", 200)},
+	}
+	results := make([]string, 5)
+	for i := range results {
+		results[i] = fmt.Sprintf("结果%d:", i) + strings.Repeat("新闻", 500)
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: "调用工具"},
+			llm.Message{Role: "tool", Name: "search_news", Content: results[i]},
+		)
+	}
+
+	limited := limitVerbatimToolResults(messages, 2)
+
+	var verbatim, compacted []int
+	n := 0
+	for _, m := range limited {
+		if m.Role != "tool" {
+			continue
+		}
+		if m.Content == results[n] {
+			verbatim = append(verbatim, n)
+		} else {
+			assert.Equal(t, compactToolResult(results[n]), m.Content)
+			compacted = append(compacted, n)
+		}
+		n++
+	}
+	assert.Equal(t, []int{3, 4}, verbatim, "only the most recent results should stay verbatim")
+	assert.Equal(t, []int{0, 1, 2}, compacted, "older results should be compacted")
+
+	// 非工具消息不受影响
+	assert.Equal(t, strings.Repeat("市场数据", 200), limited[1].Content)
+}
+
+func TestLimitVerbatimToolResults_Disabled(t *testing.T) {
+	large := strings.Repeat("新闻", 500)
+	messages := []llm.Message{
+		{Role: "assistant", Content: "调用工具"},
+		{Role: "tool", Content: large},
+		{Role: "tool", Content: large},
+	}
+
+	limited := limitVerbatimToolResults(messages, 0)
+
+	assert.Equal(t, large, limited[1].Content)
+	assert.Equal(t, large, limited[2].Content)
+}
+
+func TestLimitVerbatimToolResults_RepeatedIterationsStable(t *testing.T) {
+	messages := []llm.Message{{Role: "system", Content: "系统提示"}}
+	for i := 0; i < 4; i++ {
+		messages = limitVerbatimToolResults(messages, 1)
+		messages = append(messages, llm.Message{Role: "tool", Content: strings.Repeat("内容", 400)})
+	}
+	messages = limitVerbatimToolResults(messages, 1)
+
+	// 重复压缩不会继续改变已压缩的结果
+	compactedOnce := compactToolResult(strings.Repeat("内容", 400))
+	for _, m := range messages[1 : len(messages)-1] {
+		assert.Equal(t, compactedOnce, m.Content)
+	}
+	assert.Equal(t, strings.Repeat("内容", 400), messages[len(messages)-1].Content)
+}
+
+// noDataMatcher 不匹配任何数据模块
+type noDataMatcher struct{}
+
+func (noDataMatcher) Match(question string) []DataModule { return nil }
+
+// newStreamingAIService 创建指向模拟 LLM 服务器的 AI 服务，responses 依次作为每次请求的 SSE 响应
+func newStreamingAIService(t *testing.T, responses ...[]string) (*aiService, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if n >= len(responses) {
+			n = len(responses) - 1
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, line := range responses[n] {
+			fmt.Fprintf(w, "data: %s\n\n", line)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := llm.NewClient(llm.Config{BaseURL: server.URL, APIKey: "test-key", Model: "test-model"})
+	require.NoError(t, err)
+
+	return &aiService{llmClient: client, dataMatcher: noDataMatcher{}}, &calls
+}
+
+func collectChatChunks(t *testing.T, svc *aiService) ([]model.ChatChunk, error) {
+	t.Helper()
+	stream := make(chan model.ChatChunk, 100)
+	err := svc.Chat(context.Background(), &model.ChatRequest{Message: "你好"}, stream)
+
+	var chunks []model.ChatChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, err
+}
+
+const (
+	toolCallChunk = `{"choices":[{"delta":{"tool_calls":[{"id":"call_1","type":"function","function":{"name":"search_news","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`
+	contentChunk  = `{"choices":[{"delta":{"content":"今天市场平稳"}}]}`
+)
+
+func TestAIService_Chat_UnexpectedToolCalls_FallsBackToPlainAnswer(t *testing.T) {
+	svc, calls := newStreamingAIService(t, []string{toolCallChunk}, []string{contentChunk})
+
+	chunks, err := collectChatChunks(t, svc)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls), "should retry once with a plain completion")
+
+	var content strings.Builder
+	for _, c := range chunks {
+		if c.Type == model.ChunkTypeContent {
+			content.WriteString(c.Chunk)
+		}
+	}
+	assert.Equal(t, "今天市场平稳", content.String())
+	assert.Equal(t, model.ChunkTypeDone, chunks[len(chunks)-1].Type)
+}
+
+func TestAIService_Chat_UnexpectedToolCalls_SurfacesError(t *testing.T) {
+	svc, calls := newStreamingAIService(t, []string{toolCallChunk})
+
+	chunks, err := collectChatChunks(t, svc)
+
+	assert.ErrorIs(t, err, ErrUnexpectedToolCalls)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	require.NotEmpty(t, chunks)
+	last := chunks[len(chunks)-1]
+	assert.Equal(t, model.ChunkTypeError, last.Type)
+	assert.NotEmpty(t, last.Message)
+}
+
+func TestAIService_Chat_ContentWithToolCalls_IgnoresToolCalls(t *testing.T) {
+	svc, calls := newStreamingAIService(t, []string{contentChunk, toolCallChunk})
+
+	chunks, err := collectChatChunks(t, svc)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	assert.Equal(t, model.ChunkTypeDone, chunks[len(chunks)-1].Type)
+}
+
+// newOpenBreaker 创建已打开的熔断器
+func newOpenBreaker(t *testing.T) *crawler.CircuitBreaker {
+	t.Helper()
+	breaker := crawler.NewCircuitBreaker(crawler.CircuitBreakerConfig{MaxFailures: 1, Timeout: time.Minute, HalfOpenMaxReqs: 1})
+	_ = breaker.Execute(func() error { return errors.New("upstream down") })
+	require.Equal(t, crawler.StateOpen, breaker.State())
+	return breaker
+}
+
+func collectAnalysis(t *testing.T, analyze func(stream chan<- model.ChatChunk) error) ([]model.ChatChunk, error) {
+	t.Helper()
+	stream := make(chan model.ChatChunk, 100)
+	err := analyze(stream)
+
+	var chunks []model.ChatChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, err
+}
+
+// chunkContent 拼接所有 content 块
+func chunkContent(chunks []model.ChatChunk) string {
+	var sb strings.Builder
+	for _, c := range chunks {
+		if c.Type == model.ChunkTypeContent {
+			sb.WriteString(c.Chunk)
+		}
+	}
+	return sb.String()
+}
+
+// chunkTypes 返回各块的类型序列
+func chunkTypes(chunks []model.ChatChunk) []model.ChatChunkType {
+	types := make([]model.ChatChunkType, len(chunks))
+	for i, c := range chunks {
+		types[i] = c.Type
+	}
+	return types
+}
+
+// fakeSearchCrawler 返回固定搜索结果
+type fakeSearchCrawler struct{}
+
+func (fakeSearchCrawler) Search(ctx context.Context, query string, count int) ([]model.SearchResult, error) {
+	return []model.SearchResult{{Title: "市场快讯", URL: "https://example.com/news", Snippet: "指数小幅上涨"}}, nil
+}
+
+func TestAIService_AnalyzeStandard_StatusThenContentThenDone(t *testing.T) {
+	svc, _ := newStreamingAIService(t, []string{contentChunk})
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeStandard(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ChatChunkType{
+		model.ChunkTypeStatus,
+		model.ChunkTypeContent,
+		model.ChunkTypeDone,
+	}, chunkTypes(chunks))
+	assert.Equal(t, "今天市场平稳", chunks[1].Chunk)
+}
+
+func TestAIService_AnalyzeDeep_InterleavesToolStatusAndContent(t *testing.T) {
+	svc, calls := newStreamingAIService(t, []string{toolCallChunk}, []string{contentChunk})
+	svc.ddgCrawler = fakeSearchCrawler{}
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	require.Equal(t, []model.ChatChunkType{
+		model.ChunkTypeStatus,   // 正在进行深度研究
+		model.ChunkTypeToolCall, // 本轮调用的工具
+		model.ChunkTypeStatus,   // 正在调用工具
+		model.ChunkTypeStatus,   // 工具结果
+		model.ChunkTypeContent,  // 最终分析
+		model.ChunkTypeDone,
+	}, chunkTypes(chunks))
+	assert.Equal(t, []string{"search_news"}, chunks[1].Tools)
+	assert.Contains(t, chunks[2].Message, "search_news")
+	assert.Contains(t, chunks[3].Message, "市场快讯")
+	assert.Equal(t, "今天市场平稳", chunkContent(chunks))
+}
+
+func TestAIService_AnalyzeDeep_ToolResultsReferenceAssistantToolCalls(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		n := len(bodies)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if n == 1 {
+			fmt.Fprintf(w, "data: %s\n\n", toolCallChunk)
+		} else {
+			fmt.Fprintf(w, "data: %s\n\n", contentChunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := llm.NewClient(llm.Config{BaseURL: server.URL, APIKey: "test-key", Model: "test-model"})
+	require.NoError(t, err)
+	svc := &aiService{llmClient: client, ddgCrawler: fakeSearchCrawler{}}
+
+	_, err = collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, bodies, 2)
+	var req struct {
+		Messages []llm.Message `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(bodies[1], &req))
+
+	// 工具结果紧跟在携带 tool_calls 的 assistant 消息之后，并以 tool_call_id 对应
+	n := len(req.Messages)
+	require.GreaterOrEqual(t, n, 2)
+	assistant, tool := req.Messages[n-2], req.Messages[n-1]
+	assert.Equal(t, "assistant", assistant.Role)
+	require.Len(t, assistant.ToolCalls, 1)
+	assert.Equal(t, "call_1", assistant.ToolCalls[0].ID)
+	assert.Equal(t, "tool", tool.Role)
+	assert.Equal(t, "call_1", tool.ToolCallID)
+}
+
+func TestAIService_AnalyzeDeep_AllToolsOpen_DowngradesToStandard(t *testing.T) {
+	var requests []string
+	svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk)}
+	svc.toolBreakers = []*crawler.CircuitBreaker{newOpenBreaker(t), newOpenBreaker(t)}
+	svc.deepFallbackToStandard = true
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	require.NotEmpty(t, chunks)
+	assert.Equal(t, model.ChatChunk{Type: model.ChunkTypeStatus, Message: DeepAnalysisDowngradeNotice}, chunks[0])
+	assert.Equal(t, "今天市场平稳", chunkContent(chunks))
+	assert.NotContains(t, chunkTypes(chunks), model.ChunkTypeToolCall)
+	assert.Equal(t, model.ChunkTypeDone, chunks[len(chunks)-1].Type)
+
+	// 只发起一次不带工具的标准分析请求
+	require.Len(t, requests, 1)
+	assert.NotContains(t, requests[0], `"tools"`)
+	assert.Contains(t, requests[0], "全面的市场分析报告")
+}
+
+func TestAIService_AnalyzeDeep_OneToolAvailable_RunsReAct(t *testing.T) {
+	var requests []string
+	svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk)}
+	healthy := crawler.NewCircuitBreaker(crawler.DefaultCircuitBreakerConfig())
+	svc.toolBreakers = []*crawler.CircuitBreaker{newOpenBreaker(t), healthy}
+	svc.deepFallbackToStandard = true
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	for _, c := range chunks {
+		assert.NotEqual(t, DeepAnalysisDowngradeNotice, c.Message)
+	}
+	require.NotEmpty(t, requests)
+	assert.Contains(t, requests[0], `"tools"`)
+}
+
+func TestAIService_AnalyzeDeep_FallbackDisabled(t *testing.T) {
+	var requests []string
+	svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk)}
+	svc.toolBreakers = []*crawler.CircuitBreaker{newOpenBreaker(t), newOpenBreaker(t)}
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	for _, c := range chunks {
+		assert.NotEqual(t, DeepAnalysisDowngradeNotice, c.Message)
+	}
+	require.NotEmpty(t, requests)
+	assert.Contains(t, requests[0], `"tools"`)
+}
+
+func TestAIService_AnalysisModesUseConfiguredOptions(t *testing.T) {
+	modes := config.AnalysisConfig{
+		Standard: config.AnalysisModeConfig{Temperature: 0.7, MaxTokens: 3000},
+		Fast:     config.AnalysisModeConfig{Temperature: 0.3, MaxTokens: 1500},
+		Deep:     config.AnalysisModeConfig{Temperature: 0.8, MaxTokens: 8000},
+	}
+
+	tests := []struct {
+		name            string
+		analyze         func(svc *aiService, stream chan<- model.ChatChunk) error
+		wantTemperature float64
+		wantMaxTokens   int
+		wantTools       bool
+	}{
+		{"standard", func(svc *aiService, stream chan<- model.ChatChunk) error {
+			return svc.AnalyzeStandard(context.Background(), &model.MarketData{}, stream)
+		}, 0.7, 3000, false},
+		{"fast", func(svc *aiService, stream chan<- model.ChatChunk) error {
+			return svc.AnalyzeFast(context.Background(), &model.MarketData{}, stream)
+		}, 0.3, 1500, false},
+		{"deep", func(svc *aiService, stream chan<- model.ChatChunk) error {
+			return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+		}, 0.8, 8000, true},
+		{"compare uses standard", func(svc *aiService, stream chan<- model.ChatChunk) error {
+			snapshot := &model.MarketSnapshot{Date: "2024-03-01", Data: &model.MarketData{}}
+			return svc.AnalyzeCompare(context.Background(), snapshot, snapshot, stream)
+		}, 0.7, 3000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk), modes: modes}
+
+			_, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+				return tt.analyze(svc, stream)
+			})
+			require.NoError(t, err)
+			require.Len(t, requests, 1)
+
+			var req llm.ChatRequest
+			require.NoError(t, json.Unmarshal([]byte(requests[0]), &req))
+			assert.Equal(t, tt.wantTemperature, req.Temperature)
+			assert.Equal(t, tt.wantMaxTokens, req.MaxTokens)
+			assert.Equal(t, tt.wantTools, len(req.Tools) > 0)
+		})
+	}
+}
+
+func TestAIService_AnalysisModesDefaultToProvider(t *testing.T) {
+	var requests []string
+	svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk)}
+
+	_, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeFast(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.NotContains(t, requests[0], `"temperature"`)
+	assert.NotContains(t, requests[0], `"max_tokens"`)
+}
+
+// newRecordingLLMClient 创建记录请求体的模拟 LLM 客户端，每次请求都返回同样的 SSE 数据
+func newRecordingLLMClient(t *testing.T, requests *[]string, lines ...string) *llm.Client {
+	t.Helper()
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		*requests = append(*requests, string(body))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, line := range lines {
+			fmt.Fprintf(w, "data: %s\n\n", line)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := llm.NewClient(llm.Config{BaseURL: server.URL, APIKey: "test-key", Model: "test-model"})
+	require.NoError(t, err)
+	return client
+}
+
+// barrierTools 搜索与网页获取都在另一个工具开始后才返回，顺序执行时超时失败
+type barrierTools struct {
+	arrived *int32
+	all     chan struct{}
+}
+
+func newBarrierTools() barrierTools {
+	return barrierTools{arrived: new(int32), all: make(chan struct{})}
+}
+
+func (b barrierTools) wait(ctx context.Context) error {
+	if atomic.AddInt32(b.arrived, 1) == 2 {
+		close(b.all)
+	}
+	timer := time.NewTimer(2 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-b.all:
+		return nil
+	case <-timer.C:
+		return errors.New("tools did not run concurrently")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b barrierTools) Search(ctx context.Context, query string, count int) ([]model.SearchResult, error) {
+	if err := b.wait(ctx); err != nil {
+		return nil, err
+	}
+	return []model.SearchResult{{Title: "市场快讯", URL: "https://example.com/news", Snippet: "指数小幅上涨"}}, nil
+}
+
+func (b barrierTools) Fetch(ctx context.Context, url string) (string, error) {
+	if err := b.wait(ctx); err != nil {
+		return "", err
+	}
+	return "央行宣布降准", nil
+}
+
+func TestAIService_AnalyzeDeep_ParallelToolCalls(t *testing.T) {
+	parallelToolCalls := `{"choices":[{"delta":{"tool_calls":[` +
+		`{"index":0,"id":"call_1","type":"function","function":{"name":"search_news","arguments":"{\"query\":\"A股\"}"}},` +
+		`{"index":1,"id":"call_2","type":"function","function":{"name":"fetch_webpage","arguments":"{\"url\":\"https://example.com/a\"}"}}` +
+		`]},"finish_reason":"tool_calls"}]}`
+
+	var mu sync.Mutex
+	var requests []llm.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		n := len(requests)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if n == 1 {
+			fmt.Fprintf(w, "data: %s\n\n", parallelToolCalls)
+		} else {
+			fmt.Fprintf(w, "data: %s\n\n", contentChunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	client, err := llm.NewClient(llm.Config{BaseURL: server.URL, APIKey: "test-key", Model: "test-model"})
+	require.NoError(t, err)
+
+	tools := newBarrierTools()
+	svc := &aiService{llmClient: client, ddgCrawler: tools, webpageFetcher: tools}
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, chunks, model.ChatChunk{Type: model.ChunkTypeStatus, Message: "正在并行调用 2 个工具: search_news, fetch_webpage"})
+
+	// 两个工具结果按调用顺序追加到第二轮请求
+	require.Len(t, requests, 2)
+	var toolMessages []llm.Message
+	for _, m := range requests[1].Messages {
+		if m.Role == "tool" {
+			toolMessages = append(toolMessages, m)
+		}
+	}
+	require.Len(t, toolMessages, 2)
+	assert.Equal(t, "search_news", toolMessages[0].Name)
+	assert.Contains(t, toolMessages[0].Content, "市场快讯")
+	assert.Equal(t, "fetch_webpage", toolMessages[1].Name)
+	assert.Contains(t, toolMessages[1].Content, "央行宣布降准")
+}
+
+// blockingFetcher 阻塞直到 ctx 取消
+type blockingFetcher struct{}
+
+func (blockingFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestAIService_ExecuteToolCalls_StopsOnCancel(t *testing.T) {
+	svc := &aiService{webpageFetcher: blockingFetcher{}}
+	toolCalls := make([]llm.ToolCall, maxParallelToolCalls+2)
+	for i := range toolCalls {
+		toolCalls[i] = llm.ToolCall{Function: llm.FunctionCall{Name: "fetch_webpage", Arguments: `{"url":"https://example.com"}`}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	results := svc.executeToolCalls(ctx, toolCalls)
+
+	assert.Less(t, time.Since(start), 2*time.Second)
+	require.Len(t, results, len(toolCalls))
+	for _, result := range results {
+		assert.Contains(t, result, "工具调用失败")
+	}
+}
+
+// fixedDataMatcher 总是返回指定的数据模块
+type fixedDataMatcher []DataModule
+
+func (m fixedDataMatcher) Match(question string) []DataModule { return m }
+
+// stubFundService 返回预设自选基金，并记录查询的用户
+type stubFundService struct {
+	FundService
+	funds   []FundWithValuation
+	userIDs []int64
+}
+
+func (s *stubFundService) GetFundList(ctx context.Context, userID int64) ([]FundWithValuation, error) {
+	s.userIDs = append(s.userIDs, userID)
+	return s.funds, nil
+}
+
+func TestAIService_Chat_IncludesUserFunds(t *testing.T) {
+	funds := &stubFundService{funds: []FundWithValuation{
+		{Valuation: &model.FundValuation{Code: "000001", Name: "华夏成长", Valuation: "1.2345", DayGrowth: "+1.20%"}},
+		{Valuation: &model.FundValuation{Code: "110022", Name: "易方达消费行业", Valuation: "3.4567", DayGrowth: "-0.50%"}},
+	}}
+	var requests []string
+	svc := &aiService{
+		llmClient:   newRecordingLLMClient(t, &requests, contentChunk),
+		dataMatcher: fixedDataMatcher{ModuleFunds},
+		fundService: funds,
+	}
+
+	stream := make(chan model.ChatChunk, 100)
+	err := svc.Chat(context.Background(), &model.ChatRequest{Message: "我的基金今天怎么样", UserID: 42}, stream)
+	for range stream {
+	}
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{42}, funds.userIDs)
+	require.Len(t, requests, 1)
+	var req llm.ChatRequest
+	require.NoError(t, json.Unmarshal([]byte(requests[0]), &req))
+	systemPrompt := req.Messages[0].Content
+	assert.Contains(t, systemPrompt, "### 用户自选基金")
+	assert.Contains(t, systemPrompt, "华夏成长: 估值 1.2345 (+1.20%)")
+	assert.Contains(t, systemPrompt, "易方达消费行业: 估值 3.4567 (-0.50%)")
+}
+
+func TestAIService_Chat_AnonymousSkipsUserFunds(t *testing.T) {
+	funds := &stubFundService{}
+	var requests []string
+	svc := &aiService{
+		llmClient:   newRecordingLLMClient(t, &requests, contentChunk),
+		dataMatcher: fixedDataMatcher{ModuleFunds},
+		fundService: funds,
+	}
+
+	stream := make(chan model.ChatChunk, 100)
+	err := svc.Chat(context.Background(), &model.ChatRequest{Message: "我的基金今天怎么样"}, stream)
+	for range stream {
+	}
+
+	require.NoError(t, err)
+	assert.Empty(t, funds.userIDs)
+	assert.NotContains(t, requests[0], "用户自选基金")
+}
+
+func promptTestFunds() []model.FundValuation {
+	return []model.FundValuation{
+		{Code: "000001", Name: "华夏成长", Valuation: "1.2345", DayGrowth: "+0.10%"},
+		{Code: "110022", Name: "易方达消费行业", Valuation: "3.4567", DayGrowth: "-2.50%"},
+		{Code: "161725", Name: "招商中证白酒", Valuation: "1.0123", DayGrowth: "+0.80%"},
+		{Code: "005827", Name: "易方达蓝筹精选", Valuation: "2.1000", DayGrowth: "+3.10%"},
+	}
+}
+
+func TestLimitPromptFunds(t *testing.T) {
+	funds := promptTestFunds()
+
+	limited, note := limitPromptFunds(funds, 2)
+	require.Len(t, limited, 2)
+	// 按日涨跌幅绝对值选取，保持原有顺序
+	assert.Equal(t, "110022", limited[0].Code)
+	assert.Equal(t, "005827", limited[1].Code)
+	assert.Equal(t, "（共 4 只自选基金，按日涨跌幅绝对值展示前 2 只）", note)
+
+	for _, max := range []int{0, 4, 10} {
+		limited, note = limitPromptFunds(funds, max)
+		assert.Equal(t, funds, limited)
+		assert.Empty(t, note)
+	}
+}
+
+func TestBuildMarketDataPrompt_CapsFunds(t *testing.T) {
+	data := &model.MarketData{Funds: promptTestFunds()}
+
+	prompt := buildMarketDataPrompt(data, 2)
+	assert.Contains(t, prompt, "（共 4 只自选基金，按日涨跌幅绝对值展示前 2 只）")
+	assert.Contains(t, prompt, "| 易方达消费行业 |")
+	assert.Contains(t, prompt, "| 易方达蓝筹精选 |")
+	assert.NotContains(t, prompt, "华夏成长")
+	assert.NotContains(t, prompt, "招商中证白酒")
+
+	prompt = buildMarketDataPrompt(data, 0)
+	assert.NotContains(t, prompt, "只自选基金")
+	assert.Contains(t, prompt, "华夏成长")
+	assert.Contains(t, prompt, "招商中证白酒")
+}
+
+func TestBuildMarketDataPrompt_VolumeAndMinuteData(t *testing.T) {
+	data := &model.MarketData{
+		VolumeTrends: []model.VolumeTrend{
+			{Date: "2024-03-01", TotalVolume: "9876.54亿", Shanghai: "4123.45亿", Shenzhen: "5600.10亿", Beijing: "152.99亿"},
+		},
+		MinuteData: []model.MinuteData{
+			{Time: "09:30", Price: "3050.12", ChangeRate: "+0.12%", Volume: "1234567"},
+			{Time: "10:00", Price: "3061.80", ChangeRate: "+0.50%", Volume: "2345678"},
+		},
+	}
+
+	prompt := buildMarketDataPrompt(data, 0)
+	assert.Contains(t, prompt, "## 两市成交量")
+	assert.Contains(t, prompt, "| 2024-03-01 | 9876.54亿 | 4123.45亿 | 5600.10亿 | 152.99亿 |")
+	assert.Contains(t, prompt, "## 上证指数分时走势")
+	assert.Contains(t, prompt, "| 10:00 | 3061.80 | +0.50% | 2345678 |")
+
+	// 未获取时不输出对应章节
+	prompt = buildMarketDataPrompt(&model.MarketData{}, 0)
+	assert.NotContains(t, prompt, "## 两市成交量")
+	assert.NotContains(t, prompt, "## 上证指数分时走势")
+}
+
+func TestBuildChatSystemPrompt_CapsFunds(t *testing.T) {
+	prompt := buildChatSystemPrompt(&model.MarketData{Funds: promptTestFunds()}, 3)
+
+	assert.Contains(t, prompt, "（共 4 只自选基金，按日涨跌幅绝对值展示前 3 只）")
+	assert.Contains(t, prompt, "招商中证白酒: 估值 1.0123 (+0.80%)")
+	assert.NotContains(t, prompt, "华夏成长")
+}
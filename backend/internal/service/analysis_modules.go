@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fund-analyzer/internal/config"
+)
+
+// 附加数据模块的默认参数
+const (
+	// DefaultAnalysisVolumeDays 默认提供给模型的成交量天数
+	DefaultAnalysisVolumeDays = 5
+	// DefaultAnalysisMinutes 默认提供给模型的分时数据分钟数（一个完整交易日）
+	DefaultAnalysisMinutes = 240
+)
+
+// AnalysisModules 标准与深度分析额外获取的数据模块
+// 成交量与分时数据需要额外请求上游，默认不获取；零值表示均不获取
+type AnalysisModules struct {
+	VolumeTrend       bool
+	VolumeDays        int
+	MinuteData        bool
+	Minutes           int
+	MinuteGranularity MinuteGranularity
+}
+
+// NewAnalysisModules 根据配置创建附加数据模块设置，粒度不受支持时返回 ErrInvalidGranularity
+func NewAnalysisModules(cfg config.AnalysisModulesConfig) (AnalysisModules, error) {
+	granularity, err := ParseMinuteGranularity(cfg.MinuteGranularity)
+	if err != nil {
+		return AnalysisModules{}, err
+	}
+
+	modules := AnalysisModules{
+		VolumeTrend:       cfg.VolumeTrend,
+		VolumeDays:        cfg.VolumeDays,
+		MinuteData:        cfg.MinuteData,
+		Minutes:           cfg.Minutes,
+		MinuteGranularity: granularity,
+	}
+	if modules.VolumeDays <= 0 {
+		modules.VolumeDays = DefaultAnalysisVolumeDays
+	}
+	if modules.Minutes <= 0 {
+		modules.Minutes = DefaultAnalysisMinutes
+	}
+	return modules, nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAnalysisModules(t *testing.T) {
+	modules, err := NewAnalysisModules(config.AnalysisModulesConfig{
+		VolumeTrend:       true,
+		MinuteData:        true,
+		Minutes:           60,
+		MinuteGranularity: "15m",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, AnalysisModules{
+		VolumeTrend:       true,
+		VolumeDays:        DefaultAnalysisVolumeDays,
+		MinuteData:        true,
+		Minutes:           60,
+		MinuteGranularity: Granularity15Min,
+	}, modules)
+
+	_, err = NewAnalysisModules(config.AnalysisModulesConfig{MinuteGranularity: "2m"})
+	assert.ErrorIs(t, err, ErrInvalidGranularity)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+)
+
+// CacheKeyLastDeepAnalysis 用户最近一次深度研究报告，%d = 用户 ID
+const CacheKeyLastDeepAnalysis = "ai:deep:last:%d"
+
+// 分析复用默认配置
+const (
+	DefaultAnalysisReuseWindow    = 30 * time.Minute
+	DefaultAnalysisReuseThreshold = 0.3 // 价格相对变化（%）
+)
+
+// AnalysisReusedNote 复用上次报告时发送的提示
+const AnalysisReusedNote = "数据无明显变化，复用上次分析"
+
+// fingerprintHeadlines 参与比较的快讯与板块条数
+const fingerprintHeadlines = 5
+
+// AnalysisReuseService 深度研究复用服务接口
+// 在时效窗口内市场数据无明显变化时复用上次报告，避免重复执行昂贵的深度研究
+type AnalysisReuseService interface {
+	// Lookup 获取可复用的上次报告，数据有明显变化或超出时效窗口时 ok 为 false
+	Lookup(ctx context.Context, userID int64, data *model.MarketData) (report string, ok bool)
+	// Capture 返回转发到 stream 的 channel，分析正常结束时记录报告
+	// 写入方关闭返回的 channel 后 stream 随之关闭；wait 等待转发完成
+	Capture(ctx context.Context, userID int64, data *model.MarketData, stream chan<- model.ChatChunk) (tee chan<- model.ChatChunk, wait func())
+}
+
+// lastAnalysis 缓存的上次分析
+type lastAnalysis struct {
+	Fingerprint marketFingerprint `json:"fingerprint"`
+	Report      string            `json:"report"`
+	CreatedAt   time.Time         `json:"createdAt"`
+}
+
+// marketFingerprint 市场数据指纹
+type marketFingerprint struct {
+	Hash      string             `json:"hash"`      // 完整数据的哈希
+	Prices    map[string]float64 `json:"prices"`    // 指数、贵金属与基金估值
+	Headlines string             `json:"headlines"` // 最新快讯与领涨板块的哈希
+}
+
+type analysisReuseService struct {
+	cache     CacheService
+	window    time.Duration
+	threshold float64
+	now       func() time.Time
+}
+
+// NewAnalysisReuseService 创建深度研究复用服务
+func NewAnalysisReuseService(cache CacheService, cfg config.AnalysisReuseConfig) AnalysisReuseService {
+	window := time.Duration(cfg.Window) * time.Second
+	if window <= 0 {
+		window = DefaultAnalysisReuseWindow
+	}
+	threshold := cfg.ChangeThresholdPct
+	if threshold < 0 {
+		threshold = 0
+	}
+	return &analysisReuseService{
+		cache:     cache,
+		window:    window,
+		threshold: threshold,
+		now:       time.Now,
+	}
+}
+
+// Lookup 获取可复用的上次报告
+func (s *analysisReuseService) Lookup(ctx context.Context, userID int64, data *model.MarketData) (string, bool) {
+	var last lastAnalysis
+	if err := s.cache.GetJSON(ctx, fmt.Sprintf(CacheKeyLastDeepAnalysis, userID), &last); err != nil {
+		return "", false
+	}
+	if last.Report == "" || s.now().Sub(last.CreatedAt) > s.window {
+		return "", false
+	}
+	if !s.unchanged(last.Fingerprint, fingerprintMarketData(data)) {
+		return "", false
+	}
+	return last.Report, true
+}
+
+// unchanged 判断市场数据是否无明显变化
+// 数据完全相同，或快讯与板块排名不变且所有价格的相对变化都不超过阈值
+func (s *analysisReuseService) unchanged(prev, cur marketFingerprint) bool {
+	if prev.Hash == cur.Hash {
+		return true
+	}
+	if s.threshold == 0 || prev.Headlines != cur.Headlines || len(prev.Prices) != len(cur.Prices) {
+		return false
+	}
+	for key, before := range prev.Prices {
+		after, ok := cur.Prices[key]
+		if !ok {
+			return false
+		}
+		if before == 0 {
+			if after != 0 {
+				return false
+			}
+			continue
+		}
+		if math.Abs(after-before)/math.Abs(before)*100 > s.threshold {
+			return false
+		}
+	}
+	return true
+}
+
+// Capture 转发分析输出并在正常结束时记录报告
+func (s *analysisReuseService) Capture(ctx context.Context, userID int64, data *model.MarketData, stream chan<- model.ChatChunk) (chan<- model.ChatChunk, func()) {
+	tee := make(chan model.ChatChunk, 100)
+	done := make(chan struct{})
+	fingerprint := fingerprintMarketData(data)
+
+	go func() {
+		defer close(done)
+		defer close(stream)
+
+		var report strings.Builder
+		failed := false
+		for chunk := range tee {
+			switch chunk.Type {
+			case model.ChunkTypeContent:
+				report.WriteString(chunk.Chunk)
+			case model.ChunkTypeError:
+				failed = true
+			case model.ChunkTypeDone:
+				if !failed && report.Len() > 0 {
+					s.record(ctx, userID, fingerprint, report.String())
+				}
+			}
+			stream <- chunk
+		}
+	}()
+
+	return tee, func() { <-done }
+}
+
+// record 保存报告，失败时下次重新分析即可
+func (s *analysisReuseService) record(ctx context.Context, userID int64, fingerprint marketFingerprint, report string) {
+	last := lastAnalysis{Fingerprint: fingerprint, Report: report, CreatedAt: s.now()}
+	// 客户端断开不影响保存已完成的报告
+	_ = s.cache.SetJSON(context.WithoutCancel(ctx), fmt.Sprintf(CacheKeyLastDeepAnalysis, userID), last, s.window)
+}
+
+// fingerprintMarketData 计算市场数据指纹
+func fingerprintMarketData(data *model.MarketData) marketFingerprint {
+	fp := marketFingerprint{Prices: make(map[string]float64)}
+	if data == nil {
+		return fp
+	}
+
+	raw, _ := json.Marshal(data)
+	fp.Hash = hashBytes(raw)
+
+	for _, idx := range data.Indices {
+		if price, ok := parsePrice(idx.Price); ok {
+			fp.Prices["index:"+idx.Name] = price
+		}
+	}
+	for _, metal := range data.PreciousMetals {
+		fp.Prices["metal:"+metal.Name] = metal.Price
+	}
+	for _, fund := range data.Funds {
+		if price, ok := parsePrice(fund.Valuation); ok {
+			fp.Prices["fund:"+fund.Code] = price
+		}
+	}
+
+	var headlines strings.Builder
+	for i, news := range data.News {
+		if i == fingerprintHeadlines {
+			break
+		}
+		headlines.WriteString("news:" + news.Title + "\n")
+	}
+	for i, sector := range data.Sectors {
+		if i == fingerprintHeadlines {
+			break
+		}
+		headlines.WriteString("sector:" + sector.Name + "\n")
+	}
+	fp.Headlines = hashBytes([]byte(headlines.String()))
+	return fp
+}
+
+// parsePrice 解析价格字符串，支持千分位
+func parsePrice(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
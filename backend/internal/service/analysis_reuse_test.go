@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reuseTestData(shanghai string, news string) *model.MarketData {
+	return &model.MarketData{
+		Indices:        []model.MarketIndex{{Name: "上证指数", Price: shanghai, Change: "+0.35%"}},
+		PreciousMetals: []model.PreciousMetal{{Name: "现货黄金", Price: 2150.5}},
+		News:           []model.NewsItem{{ID: "1", Title: news}},
+		Sectors:        []model.Sector{{ID: "BK1", Name: "半导体", ChangeRate: "1.20%"}},
+		Funds:          []model.FundValuation{{Code: "000001", Valuation: "1.2345"}},
+	}
+}
+
+func newTestAnalysisReuse(now *time.Time) *analysisReuseService {
+	svc := NewAnalysisReuseService(NewMemoryCache(), config.AnalysisReuseConfig{
+		Window:             600,
+		ChangeThresholdPct: 0.5,
+	}).(*analysisReuseService)
+	svc.now = func() time.Time { return *now }
+	return svc
+}
+
+// runCaptured 模拟一次深度研究并通过 Capture 记录报告
+func runCaptured(t *testing.T, svc AnalysisReuseService, userID int64, data *model.MarketData, chunks ...model.ChatChunk) []model.ChatChunk {
+	t.Helper()
+	stream := make(chan model.ChatChunk, 10)
+	tee, wait := svc.Capture(context.Background(), userID, data, stream)
+	for _, chunk := range chunks {
+		tee <- chunk
+	}
+	close(tee)
+	wait()
+
+	var forwarded []model.ChatChunk
+	for chunk := range stream {
+		forwarded = append(forwarded, chunk)
+	}
+	return forwarded
+}
+
+var completedReport = []model.ChatChunk{
+	{Type: model.ChunkTypeStatus, Message: "正在分析..."},
+	{Type: model.ChunkTypeContent, Chunk: "## 市场概览\n"},
+	{Type: model.ChunkTypeContent, Chunk: "震荡整理"},
+	{Type: model.ChunkTypeDone},
+}
+
+func TestAnalysisReuse_ReusedWhenUnchanged(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc := newTestAnalysisReuse(&now)
+	ctx := context.Background()
+
+	forwarded := runCaptured(t, svc, 1, reuseTestData("3050.12", "央行降准"), completedReport...)
+	assert.Equal(t, completedReport, forwarded)
+
+	now = now.Add(5 * time.Minute)
+
+	// 数据完全相同
+	report, ok := svc.Lookup(ctx, 1, reuseTestData("3050.12", "央行降准"))
+	require.True(t, ok)
+	assert.Equal(t, "## 市场概览\n震荡整理", report)
+
+	// 价格小幅波动（约 0.03%），未超过阈值
+	_, ok = svc.Lookup(ctx, 1, reuseTestData("3051.00", "央行降准"))
+	assert.True(t, ok)
+
+	// 其他用户没有可复用的报告
+	_, ok = svc.Lookup(ctx, 2, reuseTestData("3050.12", "央行降准"))
+	assert.False(t, ok)
+}
+
+func TestAnalysisReuse_FreshRunWhenChangedOrExpired(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc := newTestAnalysisReuse(&now)
+	ctx := context.Background()
+
+	runCaptured(t, svc, 1, reuseTestData("3050.12", "央行降准"), completedReport...)
+
+	// 价格变化约 1%，超过阈值
+	_, ok := svc.Lookup(ctx, 1, reuseTestData("3080.00", "央行降准"))
+	assert.False(t, ok)
+
+	// 出现新的快讯
+	_, ok = svc.Lookup(ctx, 1, reuseTestData("3050.12", "美联储维持利率不变"))
+	assert.False(t, ok)
+
+	// 超出时效窗口
+	now = now.Add(11 * time.Minute)
+	_, ok = svc.Lookup(ctx, 1, reuseTestData("3050.12", "央行降准"))
+	assert.False(t, ok)
+}
+
+func TestAnalysisReuse_ZeroThresholdRequiresIdenticalData(t *testing.T) {
+	svc := NewAnalysisReuseService(NewMemoryCache(), config.AnalysisReuseConfig{Window: 600})
+	ctx := context.Background()
+
+	runCaptured(t, svc, 1, reuseTestData("3050.12", "央行降准"), completedReport...)
+
+	_, ok := svc.Lookup(ctx, 1, reuseTestData("3050.12", "央行降准"))
+	assert.True(t, ok)
+	_, ok = svc.Lookup(ctx, 1, reuseTestData("3050.13", "央行降准"))
+	assert.False(t, ok)
+}
+
+func TestAnalysisReuse_FailedRunNotRecorded(t *testing.T) {
+	svc := NewAnalysisReuseService(NewMemoryCache(), config.AnalysisReuseConfig{Window: 600, ChangeThresholdPct: 0.5})
+	data := reuseTestData("3050.12", "央行降准")
+
+	runCaptured(t, svc, 1, data,
+		model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "部分内容"},
+		model.ChatChunk{Type: model.ChunkTypeError, Message: "AI 服务调用失败"},
+	)
+	_, ok := svc.Lookup(context.Background(), 1, data)
+	assert.False(t, ok)
+
+	// 没有内容的报告也不记录
+	runCaptured(t, svc, 1, data, model.ChatChunk{Type: model.ChunkTypeDone})
+	_, ok = svc.Lookup(context.Background(), 1, data)
+	assert.False(t, ok)
+}
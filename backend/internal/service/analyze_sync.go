@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/pkg/llm"
+)
+
+// AnalysisMode 分析模式
+type AnalysisMode string
+
+const (
+	AnalysisModeStandard AnalysisMode = "standard"
+	AnalysisModeFast     AnalysisMode = "fast"
+	AnalysisModeDeep     AnalysisMode = "deep"
+)
+
+var (
+	// ErrUnknownAnalysisMode 不支持的分析模式
+	ErrUnknownAnalysisMode = errors.New("unknown analysis mode")
+	// ErrEmptyCompletion 模型响应中没有可用的回复
+	ErrEmptyCompletion = errors.New("llm returned no choices")
+)
+
+// AnalyzeSync 非流式分析，返回完整报告文本与累计 token 用量
+// 供定时报告、导出等不需要 SSE 的场景使用；深度模式会运行完整的 ReAct 循环
+func (s *aiService) AnalyzeSync(ctx context.Context, mode AnalysisMode, data *model.MarketData) (string, *llm.Usage, error) {
+	var (
+		content string
+		usage   llm.Usage
+		err     error
+	)
+
+	switch mode {
+	case AnalysisModeStandard:
+		messages := []llm.Message{
+			{Role: "system", Content: s.content.Load().Template(TemplateStandard, buildStandardAnalysisPrompt)},
+			{Role: "user", Content: buildMarketDataPrompt(data, s.maxPromptFunds)},
+		}
+		content, err = s.completeSync(ctx, messages, modeOptions(s.modes.Standard), &usage)
+	case AnalysisModeFast:
+		// 报告需要完整文本，不使用结构化卡片
+		content, err = s.completeSync(ctx, s.fastMessages(data), modeOptions(s.modes.Fast), &usage)
+	case AnalysisModeDeep:
+		content, err = s.analyzeDeepSync(ctx, data, &usage)
+	default:
+		return "", nil, fmt.Errorf("%w: %q", ErrUnknownAnalysisMode, mode)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 分析提示词均为中文，风险提示使用中文
+	content += s.disclaimer.suffix(LanguageChinese, content)
+	return content, &usage, nil
+}
+
+// completeSync 调用非流式接口生成不带工具的回复，用量累加到 usage
+func (s *aiService) completeSync(ctx context.Context, messages []llm.Message, opts *llm.ChatOptions, usage *llm.Usage) (string, error) {
+	message, err := s.chatSync(ctx, messages, opts, usage)
+	if err != nil {
+		return "", err
+	}
+	return message.Content, nil
+}
+
+// chatSync 调用非流式接口，返回第一个回复消息，用量累加到 usage
+func (s *aiService) chatSync(ctx context.Context, messages []llm.Message, opts *llm.ChatOptions, usage *llm.Usage) (llm.Message, error) {
+	resp, err := s.llmClient.ChatWithOptions(ctx, messages, opts)
+	if err != nil {
+		return llm.Message{}, err
+	}
+
+	usage.PromptTokens += resp.Usage.PromptTokens
+	usage.CompletionTokens += resp.Usage.CompletionTokens
+	usage.TotalTokens += resp.Usage.TotalTokens
+
+	if len(resp.Choices) == 0 {
+		return llm.Message{}, ErrEmptyCompletion
+	}
+	choice := resp.Choices[0]
+	message := choice.Message
+	// 兼容将 tool_calls 放在 choice 上的服务商
+	if len(message.ToolCalls) == 0 {
+		message.ToolCalls = choice.ToolCalls
+	}
+	return message, nil
+}
+
+// analyzeDeepSync 非流式深度研究，ReAct 循环结束后返回各轮助手内容的拼接
+func (s *aiService) analyzeDeepSync(ctx context.Context, data *model.MarketData, usage *llm.Usage) (string, error) {
+	// 与流式深度研究一致：研究工具全部熔断时降级为标准分析
+	if s.deepFallbackToStandard && !s.toolsAvailable() {
+		messages := []llm.Message{
+			{Role: "system", Content: s.content.Load().Template(TemplateStandard, buildStandardAnalysisPrompt)},
+			{Role: "user", Content: buildMarketDataPrompt(data, s.maxPromptFunds)},
+		}
+		return s.completeSync(ctx, messages, modeOptions(s.modes.Standard), usage)
+	}
+
+	messages := []llm.Message{
+		{Role: "system", Content: s.content.Load().Template(TemplateDeep, buildDeepAnalysisPrompt)},
+		{Role: "user", Content: buildMarketDataPrompt(data, s.maxPromptFunds)},
+	}
+	tools := s.deepTools()
+
+	var fullContent strings.Builder
+	maxIterations := 5
+	for i := 0; i < maxIterations; i++ {
+		messages = limitVerbatimToolResults(messages, s.maxVerbatimToolResults)
+		messages = trimToolHistory(messages, s.contextTokenBudget)
+
+		opts := modeOptions(s.modes.Deep)
+		opts.Tools = tools
+		opts.ToolChoice = "auto"
+		message, err := s.chatSync(ctx, messages, opts, usage)
+		if err != nil {
+			return "", err
+		}
+		fullContent.WriteString(message.Content)
+
+		if len(message.ToolCalls) == 0 {
+			break
+		}
+
+		messages = append(messages, llm.Message{
+			Role:      "assistant",
+			Content:   message.Content,
+			ToolCalls: message.ToolCalls,
+		})
+
+		results := s.executeToolCalls(ctx, message.ToolCalls)
+		for j, tc := range message.ToolCalls {
+			messages = append(messages, llm.Message{
+				Role:       "tool",
+				Content:    results[j],
+				Name:       tc.Function.Name,
+				ToolCallID: tc.ID,
+			})
+		}
+	}
+
+	return fullContent.String(), nil
+}
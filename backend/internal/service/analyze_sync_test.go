@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/pkg/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSyncAIService 创建指向模拟 LLM 服务器的 AI 服务，responses 依次作为每次非流式请求的 JSON 响应
+// 返回的函数获取已收到的请求
+func newSyncAIService(t *testing.T, responses ...string) (*aiService, func() []llm.ChatRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []llm.ChatRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		mu.Lock()
+		n := min(len(requests), len(responses)-1)
+		requests = append(requests, req)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, responses[n])
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := llm.NewClient(llm.Config{BaseURL: server.URL, APIKey: "test-key", Model: "test-model"})
+	require.NoError(t, err)
+
+	return &aiService{llmClient: client, dataMatcher: noDataMatcher{}}, func() []llm.ChatRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]llm.ChatRequest(nil), requests...)
+	}
+}
+
+const (
+	syncContentResponse  = `{"choices":[{"message":{"role":"assistant","content":"今天市场平稳"},"finish_reason":"stop"}],"usage":{"prompt_tokens":100,"completion_tokens":20,"total_tokens":120}}`
+	syncToolCallResponse = `{"choices":[{"message":{"role":"assistant","content":"先搜索一下。","tool_calls":[{"id":"call_1","type":"function","function":{"name":"search_news","arguments":"{\"query\":\"A股\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":80,"completion_tokens":10,"total_tokens":90}}`
+)
+
+func TestAIService_AnalyzeSync_Standard(t *testing.T) {
+	svc, requests := newSyncAIService(t, syncContentResponse)
+	svc.modes = config.AnalysisConfig{Standard: config.AnalysisModeConfig{Temperature: 0.3}}
+
+	content, usage, err := svc.AnalyzeSync(context.Background(), AnalysisModeStandard, &model.MarketData{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "今天市场平稳", content)
+	assert.Equal(t, &llm.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, usage)
+
+	reqs := requests()
+	require.Len(t, reqs, 1)
+	assert.False(t, reqs[0].Stream)
+	assert.Empty(t, reqs[0].Tools)
+	assert.Equal(t, 0.3, reqs[0].Temperature)
+	assert.Equal(t, buildStandardAnalysisPrompt(), reqs[0].Messages[0].Content)
+}
+
+func TestAIService_AnalyzeSync_FastIgnoresCard(t *testing.T) {
+	svc, requests := newSyncAIService(t, syncContentResponse)
+	svc.modes = config.AnalysisConfig{FastCard: true}
+
+	content, _, err := svc.AnalyzeSync(context.Background(), AnalysisModeFast, &model.MarketData{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "今天市场平稳", content)
+	reqs := requests()
+	require.Len(t, reqs, 1)
+	assert.Nil(t, reqs[0].ResponseFormat)
+	assert.Equal(t, buildFastAnalysisPrompt(), reqs[0].Messages[0].Content)
+}
+
+func TestAIService_AnalyzeSync_DeepRunsToolLoop(t *testing.T) {
+	svc, requests := newSyncAIService(t, syncToolCallResponse, syncContentResponse)
+	svc.ddgCrawler = fakeSearchCrawler{}
+
+	content, usage, err := svc.AnalyzeSync(context.Background(), AnalysisModeDeep, &model.MarketData{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "先搜索一下。今天市场平稳", content)
+	assert.Equal(t, &llm.Usage{PromptTokens: 180, CompletionTokens: 30, TotalTokens: 210}, usage)
+
+	reqs := requests()
+	require.Len(t, reqs, 2)
+	assert.NotEmpty(t, reqs[0].Tools)
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	assert.Equal(t, "tool", last.Role)
+	assert.Equal(t, "search_news", last.Name)
+	assert.Contains(t, last.Content, "市场快讯")
+}
+
+func TestAIService_AnalyzeSync_AppendsDisclaimer(t *testing.T) {
+	svc, _ := newSyncAIService(t, syncContentResponse)
+	svc.disclaimer = newDisclaimer(config.DisclaimerConfig{Enabled: true, Texts: map[string]string{"zh": testDisclaimer}})
+
+	content, _, err := svc.AnalyzeSync(context.Background(), AnalysisModeStandard, &model.MarketData{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "今天市场平稳\n\n"+testDisclaimer, content)
+}
+
+func TestAIService_AnalyzeSync_Errors(t *testing.T) {
+	svc, requests := newSyncAIService(t, `{"choices":[]}`)
+
+	_, _, err := svc.AnalyzeSync(context.Background(), AnalysisMode("weekly"), &model.MarketData{})
+	assert.ErrorIs(t, err, ErrUnknownAnalysisMode)
+	assert.Empty(t, requests())
+
+	_, usage, err := svc.AnalyzeSync(context.Background(), AnalysisModeStandard, &model.MarketData{})
+	assert.ErrorIs(t, err, ErrEmptyCompletion)
+	assert.Nil(t, usage)
+}
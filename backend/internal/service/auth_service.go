@@ -6,8 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"math/rand"
-	"regexp"
+	"strings"
 	"time"
 
 	"fund-analyzer/internal/config"
@@ -15,25 +14,30 @@ import (
 	"fund-analyzer/internal/repository"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserLocked         = errors.New("user account is locked")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrTokenExpired       = errors.New("token expired")
-	ErrTokenBlacklisted   = errors.New("token is blacklisted")
-	ErrInvalidCode        = errors.New("invalid verification code")
-	ErrCodeExpired        = errors.New("verification code expired")
-	ErrWeakPassword       = errors.New("password does not meet strength requirements")
-	ErrInvalidEmail       = errors.New("invalid email format")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrUserLocked          = errors.New("user account is locked")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrTokenExpired        = errors.New("token expired")
+	ErrTokenBlacklisted    = errors.New("token is blacklisted")
+	ErrInvalidCode         = errors.New("invalid verification code")
+	ErrCodeExpired         = errors.New("verification code expired")
+	ErrWeakPassword        = errors.New("password does not meet strength requirements")
+	ErrInvalidEmail        = errors.New("invalid email format")
+	ErrRegistrationExpired = errors.New("pending registration expired")
 )
 
 const (
 	MaxLoginAttempts = 5
 	LockDuration     = 15 * time.Minute
 	CodeExpiration   = 10 * time.Minute
+	// PendingRegistrationExpiration 待验证注册信息的有效期，与验证码一致
+	PendingRegistrationExpiration = CodeExpiration
 )
 
 // AuthService 认证服务接口
@@ -48,40 +52,62 @@ type AuthService interface {
 	ResetPassword(ctx context.Context, email, code, newPassword string) error
 	GetUserByID(ctx context.Context, userID int64) (*model.User, error)
 	ValidateToken(ctx context.Context, token string) (*model.Claims, error)
+	DeleteAccount(ctx context.Context, userID int64, password string) error
 }
 
 type authService struct {
-	userRepo     repository.UserRepository
-	jwtConfig    config.JWTConfig
-	emailConfig  config.EmailConfig
-	emailService EmailService
+	userRepo       repository.UserRepository
+	jwtConfig      config.JWTConfig
+	jwtSigner      *JWTSigner
+	emailConfig    config.EmailConfig
+	emailService   EmailService
+	codeFormat     CodeFormat
+	emailValidator *EmailValidator
+	// codeThrottle 限制同一邮箱的验证码发送频率，为 nil 时不限制
+	codeThrottle   *CodeSendThrottle
+	passwordPolicy *PasswordPolicy
+	logger         *zap.Logger
+
+	// registerGroup 合并同一邮箱的并发注册请求
+	registerGroup singleflight.Group
 }
 
 // NewAuthService 创建认证服务
-func NewAuthService(userRepo repository.UserRepository, jwtConfig config.JWTConfig, emailConfig config.EmailConfig) AuthService {
-	return &authService{
-		userRepo:     userRepo,
-		jwtConfig:    jwtConfig,
-		emailConfig:  emailConfig,
-		emailService: NewEmailService(emailConfig),
+func NewAuthService(
+	userRepo repository.UserRepository,
+	jwtConfig config.JWTConfig,
+	jwtSigner *JWTSigner,
+	emailConfig config.EmailConfig,
+	codeFormat CodeFormat,
+	emailValidator *EmailValidator,
+	codeThrottle *CodeSendThrottle,
+	passwordPolicy *PasswordPolicy,
+	logger *zap.Logger,
+) AuthService {
+	if emailValidator == nil {
+		emailValidator = DefaultEmailValidator()
+	}
+	if jwtSigner == nil {
+		jwtSigner = newHMACSigner(jwtConfig.Secret)
+	}
+	if passwordPolicy == nil {
+		passwordPolicy = DefaultPasswordPolicy()
+	}
+	if logger == nil {
+		logger = zap.NewNop()
 	}
-}
-
-// ValidateEmail 验证邮箱格式
-func ValidateEmail(email string) bool {
-	pattern := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
-	re := regexp.MustCompile(pattern)
-	return re.MatchString(email)
-}
-
-// ValidatePassword 验证密码强度
-func ValidatePassword(password string) bool {
-	if len(password) < 8 {
-		return false
+	return &authService{
+		userRepo:       userRepo,
+		jwtConfig:      jwtConfig,
+		jwtSigner:      jwtSigner,
+		emailConfig:    emailConfig,
+		emailService:   NewEmailService(emailConfig),
+		codeFormat:     codeFormat,
+		emailValidator: emailValidator,
+		codeThrottle:   codeThrottle,
+		passwordPolicy: passwordPolicy,
+		logger:         logger,
 	}
-	hasLetter := regexp.MustCompile(`[a-zA-Z]`).MatchString(password)
-	hasNumber := regexp.MustCompile(`[0-9]`).MatchString(password)
-	return hasLetter && hasNumber
 }
 
 // HashPassword 加密密码
@@ -96,11 +122,6 @@ func CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateCode 生成 6 位数字验证码
-func GenerateCode() string {
-	return fmt.Sprintf("%06d", rand.Intn(1000000))
-}
-
 // HashToken 计算 Token 哈希
 func HashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
@@ -109,30 +130,86 @@ func HashToken(token string) string {
 
 func (s *authService) Register(ctx context.Context, req *model.RegisterRequest) error {
 	// 验证邮箱格式
-	if !ValidateEmail(req.Email) {
-		return ErrInvalidEmail
+	if err := s.emailValidator.Validate(ctx, req.Email); err != nil {
+		return err
 	}
 
 	// 验证密码强度
-	if !ValidatePassword(req.Password) {
-		return ErrWeakPassword
+	if err := s.passwordPolicy.ValidatePassword(req.Password); err != nil {
+		return err
 	}
 
-	// 检查邮箱是否已注册
-	_, err := s.userRepo.GetUserByEmail(ctx, req.Email)
-	if err == nil {
-		return repository.ErrUserExists
-	}
-	if !errors.Is(err, repository.ErrUserNotFound) {
+	// 同一邮箱的并发注册合并为一次检查和发送，只产生一个待验证的验证码；
+	// 多实例部署时由仓库按邮箱加锁保证只有一个有效验证码
+	// 共享的调用不随单个请求取消，避免一个客户端断开导致其他请求一起失败
+	// 只有注册信息与正在进行的注册一致的请求共享其结果，不一致的请求视为冷却期内的重复注册，
+	// 避免以其他请求的密码与昵称创建账号却向调用方返回成功
+	fingerprint := registrationFingerprint(req)
+	sendCtx := context.WithoutCancel(ctx)
+	v, err, _ := s.registerGroup.Do(strings.ToLower(req.Email), func() (interface{}, error) {
+		// 检查邮箱是否已注册
+		_, err := s.userRepo.GetUserByEmail(sendCtx, req.Email)
+		if err == nil {
+			return nil, repository.ErrUserExists
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, err
+		}
+
+		// 冷却期内的重复注册不覆盖已保存的信息
+		if err := s.codeThrottle.Reserve(sendCtx, req.Email, model.VerificationCodeTypeRegister); err != nil {
+			return nil, err
+		}
+
+		// 保存注册信息，验证邮箱后据此创建用户；重复注册时覆盖之前的信息
+		passwordHash, err := HashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.userRepo.SavePendingRegistration(sendCtx, &model.PendingRegistration{
+			Email:        req.Email,
+			PasswordHash: passwordHash,
+			Nickname:     req.Nickname,
+			ExpiresAt:    time.Now().Add(PendingRegistrationExpiration),
+		}); err != nil {
+			return nil, err
+		}
+
+		// 发送验证码
+		if err := s.sendCode(sendCtx, req.Email, model.VerificationCodeTypeRegister); err != nil {
+			return nil, err
+		}
+		return fingerprint, nil
+	})
+	if err != nil {
 		return err
 	}
+	if v != fingerprint {
+		return fmt.Errorf("%w: registration for this email already in progress", ErrCodeResendTooSoon)
+	}
+	return nil
+}
 
-	// 发送验证码
-	return s.SendVerificationCode(ctx, req.Email, model.VerificationCodeTypeRegister)
+// registrationFingerprint 注册信息摘要，用于判断并发注册请求的密码与昵称是否一致，只在内存中比较
+func registrationFingerprint(req *model.RegisterRequest) string {
+	hash := sha256.Sum256([]byte(req.Password + "\x00" + req.Nickname))
+	return hex.EncodeToString(hash[:])
 }
 
+// SendVerificationCode 发送验证码，同一邮箱发送过于频繁时返回 ErrCodeResendTooSoon
 func (s *authService) SendVerificationCode(ctx context.Context, email string, codeType model.VerificationCodeType) error {
-	code := GenerateCode()
+	if err := s.codeThrottle.Reserve(ctx, email, codeType); err != nil {
+		return err
+	}
+	return s.sendCode(ctx, email, codeType)
+}
+
+// sendCode 生成、保存并发送验证码，调用方负责发送频率检查
+func (s *authService) sendCode(ctx context.Context, email string, codeType model.VerificationCodeType) error {
+	code, err := GenerateCode(s.codeFormat)
+	if err != nil {
+		return err
+	}
 
 	// 保存验证码
 	verificationCode := &model.VerificationCode{
@@ -166,18 +243,48 @@ func (s *authService) VerifyEmail(ctx context.Context, email, code string) (*mod
 	}
 
 	// 检查验证码是否正确
-	if verificationCode.Code != code {
+	if !codeMatches(verificationCode.Code, code) {
 		return nil, ErrInvalidCode
 	}
 
+	// 获取注册时保存的信息，缺失或过期时需重新注册
+	pending, err := s.userRepo.GetPendingRegistration(ctx, email)
+	if errors.Is(err, repository.ErrPendingRegistrationNotFound) {
+		return nil, ErrRegistrationExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pending.IsExpired() {
+		_ = s.userRepo.DeletePendingRegistration(ctx, email)
+		return nil, ErrRegistrationExpired
+	}
+
 	// 标记验证码已使用
 	if err := s.userRepo.MarkVerificationCodeUsed(ctx, verificationCode.ID); err != nil {
 		return nil, err
 	}
 
-	// 这里需要从临时存储获取注册信息，简化处理：返回 nil 表示验证成功
-	// 实际实现中应该在 Register 时临时存储用户信息
-	return nil, nil
+	// 注册后到验证前邮箱可能已被注册
+	if _, err := s.userRepo.GetUserByEmail(ctx, email); err == nil {
+		return nil, repository.ErrUserExists
+	} else if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+
+	user := &model.User{
+		Email:        pending.Email,
+		PasswordHash: pending.PasswordHash,
+		Nickname:     pending.Nickname,
+		Role:         model.UserRoleUser,
+	}
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// 用户已创建，注册信息删除失败不影响结果，过期后也不会再被使用
+	_ = s.userRepo.DeletePendingRegistration(ctx, email)
+	return user, nil
 }
 
 func (s *authService) Login(ctx context.Context, email, password string) (*model.LoginResponse, error) {
@@ -200,11 +307,20 @@ func (s *authService) Login(ctx context.Context, email, password string) (*model
 		// 增加失败次数
 		attempts := user.LoginAttempts + 1
 		var lockedUntil *time.Time
+		now := time.Now()
 		if attempts >= MaxLoginAttempts {
-			t := time.Now().Add(LockDuration)
+			t := now.Add(LockDuration)
 			lockedUntil = &t
 		}
-		_ = s.userRepo.UpdateLoginAttempts(ctx, user.ID, attempts, lockedUntil)
+		if err := s.userRepo.UpdateLoginAttempts(ctx, user.ID, attempts, lockedUntil); err == nil && lockedUntil != nil {
+			// 本次失败触发锁定时提醒账号所有者
+			s.sendSecurityAlert(ctx, user.Email, SecurityEvent{
+				Type:        SecurityEventAccountLocked,
+				OccurredAt:  now,
+				Attempts:    attempts,
+				LockedUntil: *lockedUntil,
+			})
+		}
 		return nil, ErrInvalidCredentials
 	}
 
@@ -245,6 +361,11 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, err
 	}
 
+	// 检查用户级吊销（账号删除或重置密码前签发的刷新 Token 不能换取新 Token）
+	if err := s.checkUserRevocation(ctx, claims.UserID, claims.IssuedAt, claims.TokenVersion); err != nil {
+		return nil, err
+	}
+
 	// 获取用户
 	user, err := s.userRepo.GetUserByID(ctx, claims.UserID)
 	if err != nil {
@@ -256,6 +377,11 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*m
 }
 
 func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	// 先检查发送频率，无论邮箱是否注册都计数，避免通过限流结果判断邮箱是否存在
+	if err := s.codeThrottle.Reserve(ctx, email, model.VerificationCodeTypeResetPassword); err != nil {
+		return err
+	}
+
 	// 检查用户是否存在
 	_, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
@@ -267,13 +393,13 @@ func (s *authService) ForgotPassword(ctx context.Context, email string) error {
 	}
 
 	// 发送重置密码验证码
-	return s.SendVerificationCode(ctx, email, model.VerificationCodeTypeResetPassword)
+	return s.sendCode(ctx, email, model.VerificationCodeTypeResetPassword)
 }
 
 func (s *authService) ResetPassword(ctx context.Context, email, code, newPassword string) error {
 	// 验证密码强度
-	if !ValidatePassword(newPassword) {
-		return ErrWeakPassword
+	if err := s.passwordPolicy.ValidatePassword(newPassword); err != nil {
+		return err
 	}
 
 	// 获取验证码
@@ -288,7 +414,7 @@ func (s *authService) ResetPassword(ctx context.Context, email, code, newPasswor
 	}
 
 	// 检查验证码是否正确
-	if verificationCode.Code != code {
+	if !codeMatches(verificationCode.Code, code) {
 		return ErrInvalidCode
 	}
 
@@ -310,6 +436,11 @@ func (s *authService) ResetPassword(ctx context.Context, email, code, newPasswor
 		return err
 	}
 
+	// 递增 Token 版本，使重置前签发的所有 Token 失效，避免账号被盗用时攻击者继续访问
+	if err := s.userRepo.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return err
+	}
+
 	// 标记验证码已使用
 	return s.userRepo.MarkVerificationCodeUsed(ctx, verificationCode.ID)
 }
@@ -334,9 +465,59 @@ func (s *authService) ValidateToken(ctx context.Context, token string) (*model.C
 		return nil, ErrTokenBlacklisted
 	}
 
+	// 检查用户级吊销（如账号已删除、密码已重置）
+	if err := s.checkUserRevocation(ctx, claims.UserID, claims.IssuedAt, claims.TokenVersion); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
+// DeleteAccount 校验密码后删除账号及其全部数据，并吊销所有已签发的 Token
+func (s *authService) DeleteAccount(ctx context.Context, userID int64, password string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !CheckPassword(password, user.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+
+	return s.userRepo.DeleteUser(ctx, user.ID, user.Email)
+}
+
+// sendSecurityAlert 异步发送安全提醒邮件，不阻塞当前请求，发送失败只记录日志
+func (s *authService) sendSecurityAlert(ctx context.Context, email string, event SecurityEvent) {
+	alertCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), securityAlertTimeout)
+	go func() {
+		defer cancel()
+		if err := s.emailService.SendSecurityAlert(alertCtx, email, event); err != nil {
+			s.logger.Warn("Failed to send security alert",
+				zap.String("email", email),
+				zap.String("event", string(event.Type)),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// checkUserRevocation 检查 Token 是否在用户级吊销时间之前签发，或其版本早于用户当前的 Token 版本
+func (s *authService) checkUserRevocation(ctx context.Context, userID int64, issuedAt *jwt.NumericDate, tokenVersion int) error {
+	var issued time.Time
+	if issuedAt != nil {
+		issued = issuedAt.Time
+	}
+	revoked, err := s.userRepo.IsUserTokenRevoked(ctx, userID, issued, tokenVersion)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenBlacklisted
+	}
+	return nil
+}
+
 // generateTokenPair 生成 Token 对
 func (s *authService) generateTokenPair(user *model.User) (*model.TokenPair, error) {
 	now := time.Now()
@@ -345,8 +526,10 @@ func (s *authService) generateTokenPair(user *model.User) (*model.TokenPair, err
 
 	// 生成 Access Token
 	accessClaims := &model.Claims{
-		UserID: user.ID,
-		Email:  user.Email,
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(accessExpire),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -354,15 +537,15 @@ func (s *authService) generateTokenPair(user *model.User) (*model.TokenPair, err
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString([]byte(s.jwtConfig.Secret))
+	accessTokenString, err := s.jwtSigner.Sign(accessClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	// 生成 Refresh Token
 	refreshClaims := &model.RefreshClaims{
-		UserID: user.ID,
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(refreshExpire),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -370,8 +553,7 @@ func (s *authService) generateTokenPair(user *model.User) (*model.TokenPair, err
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(s.jwtConfig.Secret))
+	refreshTokenString, err := s.jwtSigner.Sign(refreshClaims)
 	if err != nil {
 		return nil, err
 	}
@@ -385,12 +567,7 @@ func (s *authService) generateTokenPair(user *model.User) (*model.TokenPair, err
 
 // parseToken 解析 Access Token
 func (s *authService) parseToken(tokenString string) (*model.Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &model.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(s.jwtConfig.Secret), nil
-	})
+	token, err := s.jwtSigner.Parse(tokenString, &model.Claims{})
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -409,12 +586,7 @@ func (s *authService) parseToken(tokenString string) (*model.Claims, error) {
 
 // parseRefreshToken 解析 Refresh Token
 func (s *authService) parseRefreshToken(tokenString string) (*model.RefreshClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &model.RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(s.jwtConfig.Secret), nil
-	})
+	token, err := s.jwtSigner.Parse(tokenString, &model.RefreshClaims{})
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
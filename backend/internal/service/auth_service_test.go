@@ -0,0 +1,523 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserRepo 内存用户仓库
+type fakeUserRepo struct {
+	repository.UserRepository
+	users       map[int64]*model.User
+	funds       map[int64][]model.UserFund
+	blacklist   map[string]bool
+	revocations map[int64]time.Time
+	codes       []model.VerificationCode
+	pending     map[string]*model.PendingRegistration
+	nextID      int64
+}
+
+func newFakeUserRepo(users ...*model.User) *fakeUserRepo {
+	r := &fakeUserRepo{
+		users:       make(map[int64]*model.User),
+		funds:       make(map[int64][]model.UserFund),
+		blacklist:   make(map[string]bool),
+		revocations: make(map[int64]time.Time),
+		pending:     make(map[string]*model.PendingRegistration),
+		nextID:      100,
+	}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (r *fakeUserRepo) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) UpdateLoginAttempts(ctx context.Context, userID int64, attempts int, lockedUntil *time.Time) error {
+	if u, ok := r.users[userID]; ok {
+		u.LoginAttempts = attempts
+		u.LockedUntil = lockedUntil
+		u.Status = model.UserStatusActive
+		if lockedUntil != nil {
+			u.Status = model.UserStatusLocked
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateUser(ctx context.Context, user *model.User) error {
+	if _, ok := r.users[user.ID]; !ok {
+		return repository.ErrUserNotFound
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) AddToBlacklist(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error {
+	r.blacklist[tokenHash] = true
+	return nil
+}
+
+func (r *fakeUserRepo) IsTokenBlacklisted(ctx context.Context, tokenHash string) (bool, error) {
+	return r.blacklist[tokenHash], nil
+}
+
+func (r *fakeUserRepo) DeleteUser(ctx context.Context, userID int64, email string) error {
+	if _, ok := r.users[userID]; !ok {
+		return repository.ErrUserNotFound
+	}
+	r.revocations[userID] = time.Now()
+	delete(r.users, userID)
+	delete(r.funds, userID)
+	return nil
+}
+
+func (r *fakeUserRepo) IncrementTokenVersion(ctx context.Context, userID int64) error {
+	u, ok := r.users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	u.TokenVersion++
+	return nil
+}
+
+func (r *fakeUserRepo) IsUserTokenRevoked(ctx context.Context, userID int64, issuedAt time.Time, tokenVersion int) (bool, error) {
+	if revokedBefore, ok := r.revocations[userID]; ok && !issuedAt.After(revokedBefore) {
+		return true, nil
+	}
+	u, ok := r.users[userID]
+	return ok && u.TokenVersion > tokenVersion, nil
+}
+
+func (r *fakeUserRepo) CreateVerificationCode(ctx context.Context, code *model.VerificationCode) error {
+	r.codes = append(r.codes, *code)
+	return nil
+}
+
+func (r *fakeUserRepo) GetVerificationCode(ctx context.Context, email string, codeType model.VerificationCodeType) (*model.VerificationCode, error) {
+	for i := len(r.codes) - 1; i >= 0; i-- {
+		if c := r.codes[i]; c.Email == email && c.Type == codeType && !c.Used {
+			return &c, nil
+		}
+	}
+	return nil, errors.New("verification code not found")
+}
+
+func (r *fakeUserRepo) MarkVerificationCodeUsed(ctx context.Context, id int64) error {
+	for i := range r.codes {
+		if r.codes[i].ID == id {
+			r.codes[i].Used = true
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) CreateUser(ctx context.Context, user *model.User) error {
+	r.nextID++
+	user.ID = r.nextID
+	user.Status = model.UserStatusActive
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) SavePendingRegistration(ctx context.Context, pending *model.PendingRegistration) error {
+	saved := *pending
+	r.pending[pending.Email] = &saved
+	return nil
+}
+
+func (r *fakeUserRepo) GetPendingRegistration(ctx context.Context, email string) (*model.PendingRegistration, error) {
+	pending, ok := r.pending[email]
+	if !ok {
+		return nil, repository.ErrPendingRegistrationNotFound
+	}
+	return pending, nil
+}
+
+func (r *fakeUserRepo) DeletePendingRegistration(ctx context.Context, email string) error {
+	delete(r.pending, email)
+	return nil
+}
+
+// blockingEmailService 记录发送次数，发送在 release 关闭前阻塞
+type blockingEmailService struct {
+	sent    int32
+	release chan struct{}
+}
+
+func (s *blockingEmailService) SendVerificationCode(ctx context.Context, email, code string) error {
+	atomic.AddInt32(&s.sent, 1)
+	<-s.release
+	return nil
+}
+
+func (s *blockingEmailService) SendPasswordResetCode(ctx context.Context, email, code string) error {
+	return s.SendVerificationCode(ctx, email, code)
+}
+
+func (s *blockingEmailService) SendSecurityAlert(ctx context.Context, email string, event SecurityEvent) error {
+	return nil
+}
+
+func (s *blockingEmailService) SendNotification(ctx context.Context, email string, notification model.Notification) error {
+	return nil
+}
+
+// alertEmailService 将安全提醒转发到 alerts，发送在 release 关闭前阻塞
+type alertEmailService struct {
+	blockingEmailService
+	alerts chan SecurityEvent
+}
+
+func (s *alertEmailService) SendSecurityAlert(ctx context.Context, email string, event SecurityEvent) error {
+	<-s.release
+	s.alerts <- event
+	return nil
+}
+
+func newTestAuthService(t *testing.T, password string) (AuthService, *fakeUserRepo) {
+	t.Helper()
+	hash, err := HashPassword(password)
+	require.NoError(t, err)
+
+	repo := newFakeUserRepo(&model.User{
+		ID:           1,
+		Email:        "user@example.com",
+		PasswordHash: hash,
+		Status:       model.UserStatusActive,
+	})
+	repo.funds[1] = []model.UserFund{{UserID: 1, FundCode: "000001"}}
+
+	svc := NewAuthService(repo, config.JWTConfig{
+		Secret:           "test-secret",
+		AccessExpireMin:  60,
+		RefreshExpireDay: 7,
+		Issuer:           "test",
+	}, nil, config.EmailConfig{}, DefaultCodeFormat(), nil, nil, nil, nil)
+	return svc, repo
+}
+
+func TestAuthService_DeleteAccount_WrongPassword(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+
+	err := svc.DeleteAccount(context.Background(), 1, "wrong-password1")
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Contains(t, repo.users, int64(1), "user should not be deleted")
+}
+
+func TestAuthService_DeleteAccount_PurgesDataAndRevokesTokens(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	ctx := context.Background()
+
+	login, err := svc.Login(ctx, "user@example.com", "password123")
+	require.NoError(t, err)
+
+	_, err = svc.ValidateToken(ctx, login.AccessToken)
+	require.NoError(t, err, "token should be valid before deletion")
+
+	require.NoError(t, svc.DeleteAccount(ctx, 1, "password123"))
+
+	assert.NotContains(t, repo.users, int64(1))
+	assert.NotContains(t, repo.funds, int64(1), "watchlist should be purged")
+
+	_, err = svc.ValidateToken(ctx, login.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenBlacklisted, "access token should be rejected after deletion")
+
+	_, err = svc.RefreshToken(ctx, login.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenBlacklisted, "refresh token should be rejected after deletion")
+
+	_, err = svc.Login(ctx, "user@example.com", "password123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "deleted user should not be able to log in")
+}
+
+func TestAuthService_DeleteAccount_UserNotFound(t *testing.T) {
+	svc, _ := newTestAuthService(t, "password123")
+
+	err := svc.DeleteAccount(context.Background(), 99, "password123")
+
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func TestAuthService_ResetPassword_InvalidatesExistingTokens(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	ctx := context.Background()
+
+	before, err := svc.Login(ctx, "user@example.com", "password123")
+	require.NoError(t, err)
+	_, err = svc.ValidateToken(ctx, before.AccessToken)
+	require.NoError(t, err, "token should be valid before reset")
+
+	repo.codes = append(repo.codes, model.VerificationCode{
+		ID:        1,
+		Email:     "user@example.com",
+		Code:      "123456",
+		Type:      model.VerificationCodeTypeResetPassword,
+		ExpiresAt: time.Now().Add(CodeExpiration),
+	})
+	require.NoError(t, svc.ResetPassword(ctx, "user@example.com", "123456", "newpass456"))
+	assert.Equal(t, 1, repo.users[1].TokenVersion)
+
+	_, err = svc.ValidateToken(ctx, before.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenBlacklisted, "access token minted before reset should be rejected")
+	_, err = svc.RefreshToken(ctx, before.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenBlacklisted, "refresh token minted before reset should be rejected")
+
+	// 重置后立即使用新密码登录，签发的 Token 有效（与重置发生在同一秒内也不受影响）
+	after, err := svc.Login(ctx, "user@example.com", "newpass456")
+	require.NoError(t, err)
+	claims, err := svc.ValidateToken(ctx, after.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claims.TokenVersion)
+	_, err = svc.RefreshToken(ctx, after.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_Login_LockoutSendsSecurityAlertOnce(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	sender := &alertEmailService{
+		blockingEmailService: blockingEmailService{release: make(chan struct{})},
+		alerts:               make(chan SecurityEvent, MaxLoginAttempts),
+	}
+	svc.(*authService).emailService = sender
+	ctx := context.Background()
+
+	// 邮件发送阻塞时登录请求照常返回
+	for i := 0; i < MaxLoginAttempts; i++ {
+		_, err := svc.Login(ctx, "user@example.com", "wrong-password1")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err := svc.Login(ctx, "user@example.com", "wrong-password1")
+	assert.ErrorIs(t, err, ErrUserLocked)
+	require.True(t, repo.users[1].IsLocked())
+
+	close(sender.release)
+	select {
+	case event := <-sender.alerts:
+		assert.Equal(t, SecurityEventAccountLocked, event.Type)
+		assert.Equal(t, MaxLoginAttempts, event.Attempts)
+		assert.Equal(t, LockDuration, event.LockedUntil.Sub(event.OccurredAt))
+	case <-time.After(time.Second):
+		t.Fatal("security alert not sent")
+	}
+
+	// 锁定前的失败与锁定期间的尝试都不发送提醒
+	select {
+	case event := <-sender.alerts:
+		t.Fatalf("unexpected extra alert: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBuildSecurityAlertEmail_AccountLocked(t *testing.T) {
+	occurred := time.Date(2024, 3, 1, 2, 30, 0, 0, time.UTC)
+	subject, body := buildSecurityAlertEmail(SecurityEvent{
+		Type:        SecurityEventAccountLocked,
+		OccurredAt:  occurred,
+		Attempts:    5,
+		LockedUntil: occurred.Add(LockDuration),
+	})
+
+	assert.Contains(t, subject, "账号安全提醒")
+	assert.Contains(t, body, "2024-03-01 10:30（北京时间）")
+	assert.Contains(t, body, "<strong>5</strong> 次登录失败")
+	assert.Contains(t, body, "2024-03-01 10:45（北京时间）")
+}
+
+func TestAuthService_Register_ConcurrentSameEmailSendsOneCode(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	email := &blockingEmailService{release: make(chan struct{})}
+	svc.(*authService).emailService = email
+
+	const attempts = 5
+	var wg sync.WaitGroup
+	errs := make([]error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 大小写不同的同一邮箱也视为同一次注册
+			addr := "new@example.com"
+			if i%2 == 1 {
+				addr = "New@Example.com"
+			}
+			errs[i] = svc.Register(context.Background(), &model.RegisterRequest{Email: addr, Password: "password123"})
+		}(i)
+	}
+
+	// 等待首个请求进入发送，其余请求加入合并；开启 -race 时 bcrypt 明显变慢，留足等待时间
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&email.sent) == 1 }, 10*time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(email.release)
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&email.sent))
+	assert.Len(t, repo.codes, 1)
+}
+
+func TestAuthService_Register_ConcurrentDifferentCredentialsRejected(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	email := &blockingEmailService{release: make(chan struct{})}
+	svc.(*authService).emailService = email
+
+	first := make(chan error, 1)
+	go func() {
+		first <- svc.Register(context.Background(), &model.RegisterRequest{Email: "new@example.com", Password: "password123", Nickname: "小明"})
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&email.sent) == 1 }, 10*time.Second, time.Millisecond)
+
+	// 首个注册仍在发送时，以不同密码或昵称注册同一邮箱不共享其成功结果
+	second := make(chan error, 2)
+	for _, req := range []*model.RegisterRequest{
+		{Email: "new@example.com", Password: "another-pass1", Nickname: "小明"},
+		{Email: "New@Example.com", Password: "password123", Nickname: "小红"},
+	} {
+		go func(req *model.RegisterRequest) { second <- svc.Register(context.Background(), req) }(req)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(email.release)
+
+	require.NoError(t, <-first)
+	assert.ErrorIs(t, <-second, ErrCodeResendTooSoon)
+	assert.ErrorIs(t, <-second, ErrCodeResendTooSoon)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&email.sent))
+	require.Contains(t, repo.pending, "new@example.com")
+	assert.Equal(t, "小明", repo.pending["new@example.com"].Nickname)
+	assert.True(t, CheckPassword("password123", repo.pending["new@example.com"].PasswordHash))
+}
+
+func TestAuthService_Register_ExistingEmailStillRejected(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	email := &blockingEmailService{release: make(chan struct{})}
+	close(email.release)
+	svc.(*authService).emailService = email
+
+	err := svc.Register(context.Background(), &model.RegisterRequest{Email: "user@example.com", Password: "password123"})
+
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+	assert.Empty(t, repo.codes)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&email.sent))
+}
+
+// registerForVerification 完成注册并返回发出的验证码
+func registerForVerification(t *testing.T, svc AuthService, repo *fakeUserRepo, email string) string {
+	t.Helper()
+	sender := &blockingEmailService{release: make(chan struct{})}
+	close(sender.release)
+	svc.(*authService).emailService = sender
+
+	require.NoError(t, svc.Register(context.Background(), &model.RegisterRequest{
+		Email:    email,
+		Password: "newpass123",
+		Nickname: "小明",
+	}))
+	require.Len(t, repo.codes, 1)
+	repo.codes[0].ID = 7
+	return repo.codes[0].Code
+}
+
+func TestAuthService_VerifyEmail_CreatesPendingUser(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	code := registerForVerification(t, svc, repo, "new@example.com")
+
+	// 注册信息中只保存密码哈希
+	require.Contains(t, repo.pending, "new@example.com")
+	assert.NotEqual(t, "newpass123", repo.pending["new@example.com"].PasswordHash)
+
+	user, err := svc.VerifyEmail(context.Background(), "new@example.com", code)
+
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	assert.NotZero(t, user.ID)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, "小明", user.Nickname)
+	assert.True(t, CheckPassword("newpass123", user.PasswordHash))
+	assert.Same(t, user, repo.users[user.ID])
+	assert.True(t, repo.codes[0].Used)
+	assert.NotContains(t, repo.pending, "new@example.com")
+
+	// 新用户可以直接登录
+	resp, err := svc.Login(context.Background(), "new@example.com", "newpass123")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, resp.User.ID)
+}
+
+func TestAuthService_VerifyEmail_ExpiredPendingRejected(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	code := registerForVerification(t, svc, repo, "new@example.com")
+	repo.pending["new@example.com"].ExpiresAt = time.Now().Add(-time.Minute)
+
+	user, err := svc.VerifyEmail(context.Background(), "new@example.com", code)
+
+	assert.ErrorIs(t, err, ErrRegistrationExpired)
+	assert.Nil(t, user)
+	assert.Len(t, repo.users, 1, "no user should be created")
+	assert.False(t, repo.codes[0].Used)
+	assert.NotContains(t, repo.pending, "new@example.com")
+
+	// 没有注册信息时同样需要重新注册
+	_, err = svc.VerifyEmail(context.Background(), "new@example.com", code)
+	assert.ErrorIs(t, err, ErrRegistrationExpired)
+}
+
+func TestAuthService_SendVerificationCode_ResendCooldown(t *testing.T) {
+	svc, repo := newTestAuthService(t, "password123")
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	throttle := newTestCodeSendThrottle(config.VerificationCodeConfig{}, &now)
+	sender := &blockingEmailService{release: make(chan struct{})}
+	close(sender.release)
+	svc.(*authService).codeThrottle = throttle
+	svc.(*authService).emailService = sender
+	ctx := context.Background()
+
+	req := &model.RegisterRequest{Email: "new@example.com", Password: "password123"}
+	require.NoError(t, svc.Register(ctx, req))
+
+	// 立即再次注册被拒绝，不发送邮件也不覆盖注册信息
+	repo.pending["new@example.com"].Nickname = "first"
+	err := svc.Register(ctx, &model.RegisterRequest{Email: "new@example.com", Password: "password456", Nickname: "second"})
+	assert.ErrorIs(t, err, ErrCodeResendTooSoon)
+	assert.Equal(t, "first", repo.pending["new@example.com"].Nickname)
+	assert.ErrorIs(t, svc.SendVerificationCode(ctx, "new@example.com", model.VerificationCodeTypeRegister), ErrCodeResendTooSoon)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sender.sent))
+
+	// 找回密码对未注册邮箱同样计数
+	require.NoError(t, svc.ForgotPassword(ctx, "nobody@example.com"))
+	assert.ErrorIs(t, svc.ForgotPassword(ctx, "nobody@example.com"), ErrCodeResendTooSoon)
+
+	// 冷却期结束后可以重新发送
+	now = now.Add(DefaultCodeResendCooldown)
+	require.NoError(t, svc.Register(ctx, req))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&sender.sent))
+	assert.Len(t, repo.codes, 2)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+)
+
+// DefaultRefreshPause 相邻数据源刷新之间的默认间隔，避免集中访问上游
+const DefaultRefreshPause = 500 * time.Millisecond
+
+// refreshNewsCount 预热的快讯数量，与快讯接口默认数量一致
+const refreshNewsCount = 50
+
+// ErrRefreshInProgress 已有刷新任务在执行
+var ErrRefreshInProgress = errors.New("cache refresh already in progress")
+
+// RefreshStatus 单个数据源的刷新结果
+type RefreshStatus string
+
+const (
+	RefreshStatusOK      RefreshStatus = "ok"
+	RefreshStatusFailed  RefreshStatus = "failed"
+	RefreshStatusSkipped RefreshStatus = "skipped" // 熔断器打开，未访问上游
+)
+
+// RefreshSource 可预热的数据源
+type RefreshSource struct {
+	Name     string        // 报告中的数据源名称
+	Breaker  string        // 上游熔断器名称，熔断打开时跳过
+	CacheKey string        // 写入的缓存键，与对应 Service 读取的键一致
+	TTL      time.Duration // 缓存过期时间
+	Fetch    func(ctx context.Context) (interface{}, error)
+}
+
+// RefreshResult 单个数据源的刷新结果
+type RefreshResult struct {
+	Source     string        `json:"source"`
+	Status     RefreshStatus `json:"status"`
+	DurationMs int64         `json:"duration_ms"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// RefreshReport 一次批量刷新的结果
+type RefreshReport struct {
+	Results    []RefreshResult `json:"results"`
+	DurationMs int64           `json:"duration_ms"`
+}
+
+// CacheRefreshService 缓存预热服务接口
+type CacheRefreshService interface {
+	// RefreshAll 依次刷新所有数据源，同一时间只允许一个刷新任务
+	RefreshAll(ctx context.Context) (*RefreshReport, error)
+}
+
+type cacheRefreshService struct {
+	degradation DegradationService
+	cbManager   *crawler.CircuitBreakerManager
+	sources     []RefreshSource
+	pause       time.Duration
+
+	running sync.Mutex
+}
+
+// NewCacheRefreshService 创建缓存预热服务
+// 数据源按顺序逐个刷新，相邻两次之间等待 pause，熔断器打开的数据源直接跳过
+func NewCacheRefreshService(
+	degradation DegradationService,
+	cbManager *crawler.CircuitBreakerManager,
+	sources []RefreshSource,
+	pause time.Duration,
+) CacheRefreshService {
+	return &cacheRefreshService{
+		degradation: degradation,
+		cbManager:   cbManager,
+		sources:     sources,
+		pause:       pause,
+	}
+}
+
+// DefaultRefreshSources 默认预热的数据源：各地区指数、贵金属、板块列表、快讯
+// 熔断器名称与 main 中为各爬虫注册的名称一致
+func DefaultRefreshSources(
+	marketCrawler crawler.MarketDataCrawler,
+	goldCrawler crawler.GoldDataCrawler,
+	sectorCrawler crawler.SectorDataCrawler,
+	newsCrawler crawler.NewsCrawler,
+) []RefreshSource {
+	sources := make([]RefreshSource, 0, len(globalIndexRegions)+3)
+	for _, region := range globalIndexRegions {
+		region := region.name
+		sources = append(sources, RefreshSource{
+			Name:     "indices:" + region,
+			Breaker:  "baidu",
+			CacheKey: fmt.Sprintf(CacheKeyMarketIndicesRegion, region),
+			TTL:      TTLMarketIndices,
+			Fetch: func(ctx context.Context) (interface{}, error) {
+				indices, err := marketCrawler.GetMarketIndices(ctx, region)
+				if err != nil && !errors.Is(err, crawler.ErrMarketIndicesEmpty) {
+					return nil, err
+				}
+				if indices == nil {
+					indices = []model.MarketIndex{}
+				}
+				return indices, nil
+			},
+		})
+	}
+
+	return append(sources,
+		RefreshSource{
+			Name:     "metals",
+			Breaker:  "gold",
+			CacheKey: CacheKeyPreciousMetals,
+			TTL:      TTLPreciousMetals,
+			Fetch: func(ctx context.Context) (interface{}, error) {
+				return goldCrawler.GetRealTimeGold(ctx)
+			},
+		},
+		RefreshSource{
+			Name:     "sectors",
+			Breaker:  "eastmoney",
+			CacheKey: CacheKeySectorList,
+			TTL:      TTLSectorList,
+			Fetch: func(ctx context.Context) (interface{}, error) {
+				return sectorCrawler.GetSectorList(ctx)
+			},
+		},
+		RefreshSource{
+			Name:     "news",
+			Breaker:  "baidu",
+			CacheKey: CacheKeyNews,
+			TTL:      TTLNews,
+			Fetch: func(ctx context.Context) (interface{}, error) {
+				return newsCrawler.GetNewsFlash(ctx, refreshNewsCount)
+			},
+		},
+	)
+}
+
+// RefreshAll 依次刷新所有数据源
+// 每个数据源通过降级层获取并写入缓存；获取失败时保留原缓存并记为失败
+func (s *cacheRefreshService) RefreshAll(ctx context.Context) (*RefreshReport, error) {
+	if !s.running.TryLock() {
+		return nil, ErrRefreshInProgress
+	}
+	defer s.running.Unlock()
+
+	start := time.Now()
+	report := &RefreshReport{Results: make([]RefreshResult, 0, len(s.sources))}
+
+	for i, source := range s.sources {
+		if i > 0 && s.pause > 0 {
+			select {
+			case <-time.After(s.pause):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		report.Results = append(report.Results, s.refresh(ctx, source))
+	}
+
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// refresh 刷新单个数据源
+func (s *cacheRefreshService) refresh(ctx context.Context, source RefreshSource) RefreshResult {
+	result := RefreshResult{Source: source.Name}
+
+	// 熔断器打开时不访问上游，前一个数据源失败导致熔断时同样生效
+	if source.Breaker != "" && s.cbManager.Get(source.Breaker).State() == crawler.StateOpen {
+		result.Status = RefreshStatusSkipped
+		result.Error = crawler.ErrCircuitOpen.Error()
+		return result
+	}
+
+	start := time.Now()
+	var fetchErr error
+	_, degraded, _ := s.degradation.WithFallback(ctx, func() (interface{}, error) {
+		data, err := source.Fetch(ctx)
+		fetchErr = err
+		return data, err
+	}, source.CacheKey, source.TTL)
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	if degraded {
+		result.Status = RefreshStatusFailed
+		result.Error = fetchErr.Error()
+	} else {
+		result.Status = RefreshStatusOK
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type refreshFixture struct {
+	market    *mockMarketCrawler
+	gold      *mockGoldCrawler
+	sector    *mockSectorCrawler
+	news      *mockNewsCrawler
+	cache     CacheService
+	cbManager *crawler.CircuitBreakerManager
+	svc       CacheRefreshService
+}
+
+func newRefreshFixture() *refreshFixture {
+	f := &refreshFixture{
+		market: newMockMarketCrawler(),
+		gold:   &mockGoldCrawler{metals: []model.PreciousMetal{{Name: "现货黄金"}}},
+		sector: &mockSectorCrawler{sectors: []model.Sector{{Name: "半导体"}}},
+		news:   &mockNewsCrawler{news: []model.NewsItem{{Title: "央行公开市场操作"}}},
+		cache:  NewMemoryCache(),
+		cbManager: crawler.NewCircuitBreakerManager(crawler.CircuitBreakerConfig{
+			MaxFailures:     1,
+			Timeout:         time.Minute,
+			HalfOpenMaxReqs: 1,
+		}),
+	}
+	degradation := NewDegradationService(f.cache, f.cbManager, zap.NewNop())
+	sources := DefaultRefreshSources(f.market, f.gold, f.sector, f.news)
+	f.svc = NewCacheRefreshService(degradation, f.cbManager, sources, 0)
+	return f
+}
+
+func resultsBySource(report *RefreshReport) map[string]RefreshResult {
+	results := make(map[string]RefreshResult, len(report.Results))
+	for _, r := range report.Results {
+		results[r.Source] = r
+	}
+	return results
+}
+
+func TestCacheRefresh_RefreshesEverySourceIntoServiceCache(t *testing.T) {
+	ctx := context.Background()
+	f := newRefreshFixture()
+
+	report, err := f.svc.RefreshAll(ctx)
+	require.NoError(t, err)
+
+	var sources []string
+	for _, r := range report.Results {
+		sources = append(sources, r.Source)
+		assert.Equal(t, RefreshStatusOK, r.Status, r.Source)
+		assert.Empty(t, r.Error, r.Source)
+	}
+	assert.Equal(t, []string{"indices:asia", "indices:america", "metals", "sectors", "news"}, sources)
+
+	// 预热后各 Service 直接命中缓存，不再访问上游
+	indices, err := NewMarketService(f.market, f.gold, f.cache).GetGlobalIndices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"上证指数", "道琼斯"}, indexNames(indices))
+	_, err = NewMarketService(f.market, f.gold, f.cache).GetPreciousMetals(ctx)
+	require.NoError(t, err)
+	_, err = NewSectorService(f.sector, f.cache).GetSectorList(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, f.market.count("GetMarketIndices:asia"))
+	assert.Equal(t, 1, f.market.count("GetMarketIndices:america"))
+	assert.Equal(t, 1, f.gold.count("GetRealTimeGold"))
+	assert.Equal(t, 1, f.sector.count("GetSectorList"))
+	assert.Equal(t, 1, f.news.count("GetNewsFlash"))
+}
+
+func TestCacheRefresh_SkipsSourcesWithOpenBreaker(t *testing.T) {
+	f := newRefreshFixture()
+	_ = f.cbManager.Get("gold").Execute(func() error { return errors.New("upstream down") })
+	require.Equal(t, crawler.StateOpen, f.cbManager.Get("gold").State())
+
+	report, err := f.svc.RefreshAll(context.Background())
+	require.NoError(t, err)
+
+	results := resultsBySource(report)
+	assert.Equal(t, RefreshStatusSkipped, results["metals"].Status)
+	assert.Equal(t, 0, f.gold.count("GetRealTimeGold"), "open breaker must not hit upstream")
+	assert.Equal(t, RefreshStatusOK, results["sectors"].Status)
+	assert.Equal(t, RefreshStatusOK, results["news"].Status)
+}
+
+func TestCacheRefresh_ReportsFailureAndKeepsPreviousCache(t *testing.T) {
+	ctx := context.Background()
+	f := newRefreshFixture()
+	stale := []model.Sector{{Name: "旧板块"}}
+	require.NoError(t, f.cache.SetJSON(ctx, CacheKeySectorList, stale, TTLSectorList))
+	f.sector.err = errors.New("eastmoney timeout")
+	f.market.errs["GetMarketIndices:america"] = errors.New("america upstream timeout")
+
+	report, err := f.svc.RefreshAll(ctx)
+	require.NoError(t, err)
+
+	results := resultsBySource(report)
+	assert.Equal(t, RefreshStatusFailed, results["sectors"].Status)
+	assert.Equal(t, "eastmoney timeout", results["sectors"].Error)
+	assert.Equal(t, RefreshStatusFailed, results["indices:america"].Status)
+	assert.Equal(t, RefreshStatusOK, results["indices:asia"].Status)
+	assert.Equal(t, RefreshStatusOK, results["metals"].Status)
+
+	var cached []model.Sector
+	require.NoError(t, f.cache.GetJSON(ctx, CacheKeySectorList, &cached))
+	assert.Equal(t, stale, cached)
+}
+
+func TestCacheRefresh_RejectsConcurrentRun(t *testing.T) {
+	block := make(chan struct{})
+	started := make(chan struct{})
+	f := newRefreshFixture()
+	svc := NewCacheRefreshService(
+		NewDegradationService(f.cache, f.cbManager, zap.NewNop()),
+		f.cbManager,
+		[]RefreshSource{{
+			Name:     "slow",
+			CacheKey: "slow",
+			TTL:      time.Minute,
+			Fetch: func(ctx context.Context) (interface{}, error) {
+				close(started)
+				<-block
+				return "ok", nil
+			},
+		}},
+		0,
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.RefreshAll(context.Background())
+		done <- err
+	}()
+	<-started
+
+	_, err := svc.RefreshAll(context.Background())
+	assert.ErrorIs(t, err, ErrRefreshInProgress)
+
+	close(block)
+	require.NoError(t, <-done)
+}
@@ -14,22 +14,24 @@ import (
 
 // 缓存 Key 常量
 const (
-	CacheKeyMarketIndices  = "market:indices"
-	CacheKeyPreciousMetals = "market:precious_metals"
-	CacheKeySectorList     = "sector:list"
-	CacheKeyNews           = "news:list"
-	CacheKeyFundInfo       = "fund:info:%s"      // %s = fund code
-	CacheKeyFundValuation  = "fund:valuation:%s" // %s = fund code
+	CacheKeyMarketIndices       = "market:indices"
+	CacheKeyMarketIndicesRegion = "market:indices:%s" // %s = region
+	CacheKeyPreciousMetals      = "market:precious_metals"
+	CacheKeySectorList          = "sector:list"
+	CacheKeyNews                = "news:list"
+	CacheKeyFundInfo            = "fund:info:%s"      // %s = fund code
+	CacheKeyFundValuation       = "fund:valuation:%s" // %s = fund code
 )
 
 // 缓存 TTL 配置
 const (
-	TTLMarketIndices  = 30 * time.Second
-	TTLPreciousMetals = 30 * time.Second
-	TTLSectorList     = 5 * time.Minute
-	TTLNews           = 1 * time.Minute
-	TTLFundInfo       = 1 * time.Hour
-	TTLFundValuation  = 30 * time.Second
+	TTLMarketIndices      = 30 * time.Second
+	TTLMarketIndicesEmpty = 5 * time.Minute // 休市等无数据时段，避免每次请求都访问上游
+	TTLPreciousMetals     = 30 * time.Second
+	TTLSectorList         = 5 * time.Minute
+	TTLNews               = 1 * time.Minute
+	TTLFundInfo           = 1 * time.Hour
+	TTLFundValuation      = 30 * time.Second
 )
 
 var (
@@ -70,6 +72,16 @@ func NewCacheService(cfg config.RedisConfig) (CacheService, error) {
 	return &RedisCache{client: client}, nil
 }
 
+// Client 获取底层 Redis 客户端，供限流等需要共享连接的组件使用
+func (c *RedisCache) Client() *redis.Client {
+	return c.client
+}
+
+// Close 关闭 Redis 连接
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
 func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	val, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// cacheSnapshotVersion 快照文件格式版本
+const cacheSnapshotVersion = 1
+
+// DefaultCacheSnapshotInterval 默认快照间隔
+const DefaultCacheSnapshotInterval = time.Minute
+
+// ErrCacheSnapshotCorrupt 快照文件损坏或格式不兼容
+var ErrCacheSnapshotCorrupt = errors.New("cache snapshot corrupt")
+
+// cacheSnapshot 快照文件内容
+type cacheSnapshot struct {
+	Version int                 `json:"version"`
+	SavedAt time.Time           `json:"saved_at"`
+	Items   []cacheSnapshotItem `json:"items"`
+}
+
+// cacheSnapshotItem 快照中的单个缓存项，value 以 base64 编码
+type cacheSnapshotItem struct {
+	Key       string    `json:"key"`
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PersistentCache 可持久化到磁盘的缓存
+type PersistentCache interface {
+	CacheService
+	// Close 停止定期快照并写入最后一次快照
+	Close() error
+}
+
+// persistentMemoryCache 定期将内存缓存快照写入文件，启动时重新加载
+// 用于 Redis 不可用期间重启时保留降级数据
+type persistentMemoryCache struct {
+	*MemoryCache
+	path   string
+	logger *zap.Logger
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPersistentMemoryCache 创建持久化内存缓存
+// 启动时从 path 加载未过期的缓存项，快照文件损坏时记录警告并以空缓存启动；
+// 之后每隔 interval 写入一次快照（<= 0 时使用默认间隔）
+func NewPersistentMemoryCache(path string, interval time.Duration, logger *zap.Logger) PersistentCache {
+	if interval <= 0 {
+		interval = DefaultCacheSnapshotInterval
+	}
+
+	c := &persistentMemoryCache{
+		MemoryCache: NewMemoryCache().(*MemoryCache),
+		path:        path,
+		logger:      logger,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	loaded, err := c.LoadSnapshot(path)
+	switch {
+	case err != nil:
+		logger.Warn("Failed to load cache snapshot, starting with empty cache",
+			zap.String("path", path), zap.Error(err))
+	case loaded > 0:
+		logger.Info("Cache snapshot loaded", zap.String("path", path), zap.Int("items", loaded))
+	}
+
+	go c.snapshotLoop(interval)
+	return c
+}
+
+// snapshotLoop 定期写入快照
+func (c *persistentMemoryCache) snapshotLoop(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.SaveSnapshot(c.path); err != nil {
+				c.logger.Warn("Failed to save cache snapshot", zap.String("path", c.path), zap.Error(err))
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Close 停止定期快照并写入最后一次快照
+func (c *persistentMemoryCache) Close() error {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	<-c.done
+	return c.SaveSnapshot(c.path)
+}
+
+// SaveSnapshot 将未过期的缓存项写入文件
+// 先写入临时文件再重命名，写入过程中崩溃不会破坏已有快照
+func (c *MemoryCache) SaveSnapshot(path string) error {
+	now := time.Now()
+	snapshot := cacheSnapshot{Version: cacheSnapshotVersion, SavedAt: now}
+
+	c.mutex.RLock()
+	for key, item := range c.data {
+		if now.After(item.expiresAt) {
+			continue
+		}
+		snapshot.Items = append(snapshot.Items, cacheSnapshotItem{
+			Key:       key,
+			Value:     item.value,
+			ExpiresAt: item.expiresAt,
+		})
+	}
+	c.mutex.RUnlock()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal cache snapshot failed: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create snapshot dir failed: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create snapshot file failed: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot file failed: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync snapshot file failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot file failed: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot 从文件加载未过期的缓存项，返回加载数量
+// 文件不存在时返回 0；文件损坏时返回 ErrCacheSnapshotCorrupt，缓存保持不变
+func (c *MemoryCache) LoadSnapshot(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("read cache snapshot failed: %w", err)
+	}
+
+	var snapshot cacheSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrCacheSnapshotCorrupt, err)
+	}
+	if snapshot.Version != cacheSnapshotVersion {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrCacheSnapshotCorrupt, snapshot.Version)
+	}
+
+	now := time.Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	loaded := 0
+	for _, item := range snapshot.Items {
+		if item.Key == "" || now.After(item.ExpiresAt) {
+			continue
+		}
+		// 重启期间写入的新数据优先
+		if existing, ok := c.data[item.Key]; ok && !now.After(existing.expiresAt) {
+			continue
+		}
+		c.data[item.Key] = cacheItem{value: item.Value, expiresAt: item.ExpiresAt}
+		loaded++
+	}
+	return loaded, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPersistentMemoryCache_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	cache := NewPersistentMemoryCache(path, time.Hour, zap.NewNop())
+	require.NoError(t, cache.Set(ctx, CacheKeyMarketIndices, []byte(`[{"name":"上证指数"}]`), time.Hour))
+	require.NoError(t, cache.SetJSON(ctx, "fund:info:000001", map[string]string{"name": "华夏成长"}, time.Hour))
+	require.NoError(t, cache.Set(ctx, "short-lived", []byte("x"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	// 模拟重启：关闭时写入快照，新实例从快照加载
+	require.NoError(t, cache.Close())
+	restarted := NewPersistentMemoryCache(path, time.Hour, zap.NewNop())
+	t.Cleanup(func() { _ = restarted.Close() })
+
+	val, err := restarted.Get(ctx, CacheKeyMarketIndices)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"name":"上证指数"}]`, string(val))
+
+	var info map[string]string
+	require.NoError(t, restarted.GetJSON(ctx, "fund:info:000001", &info))
+	assert.Equal(t, "华夏成长", info["name"])
+
+	// 过期数据不会被恢复
+	_, err = restarted.Get(ctx, "short-lived")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestMemoryCache_SnapshotKeepsExpiry(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "cache.json")
+
+	cache := NewMemoryCache().(*MemoryCache)
+	require.NoError(t, cache.Set(ctx, "key", []byte("value"), 50*time.Millisecond))
+	require.NoError(t, cache.SaveSnapshot(path))
+
+	reloaded := NewMemoryCache().(*MemoryCache)
+	loaded, err := reloaded.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = reloaded.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestMemoryCache_LoadSnapshotCorrupt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"truncated json", `{"version":1,"items":[{"key":"a"`},
+		{"not json", "\x00\x01garbage"},
+		{"unknown version", `{"version":99,"items":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			cache := NewMemoryCache().(*MemoryCache)
+			require.NoError(t, cache.Set(context.Background(), "existing", []byte("v"), time.Hour))
+
+			loaded, err := cache.LoadSnapshot(path)
+			assert.ErrorIs(t, err, ErrCacheSnapshotCorrupt)
+			assert.Zero(t, loaded)
+
+			// 已有数据不受影响
+			val, err := cache.Get(context.Background(), "existing")
+			require.NoError(t, err)
+			assert.Equal(t, "v", string(val))
+		})
+	}
+}
+
+func TestPersistentMemoryCache_CorruptSnapshotStartsEmpty(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+	cache := NewPersistentMemoryCache(path, time.Hour, zap.NewNop())
+	_, err := cache.Get(ctx, CacheKeyMarketIndices)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	// 损坏的快照在下次保存时被覆盖
+	require.NoError(t, cache.Set(ctx, CacheKeyMarketIndices, []byte("[]"), time.Hour))
+	require.NoError(t, cache.Close())
+
+	reloaded := NewMemoryCache().(*MemoryCache)
+	loaded, err := reloaded.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded)
+}
+
+func TestMemoryCache_LoadSnapshotMissingFile(t *testing.T) {
+	cache := NewMemoryCache().(*MemoryCache)
+
+	loaded, err := cache.LoadSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+
+	require.NoError(t, err)
+	assert.Zero(t, loaded)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"fund-analyzer/internal/model"
+)
+
+// search_cn_news 工具参数
+const (
+	// cnNewsSearchPool 每次检索的最新快讯条数
+	cnNewsSearchPool = 50
+	// cnNewsMaxResults 返回给模型的最大快讯条数
+	cnNewsMaxResults = 10
+	// cnNewsSnippetRunes 快讯摘要的最大字符数
+	cnNewsSnippetRunes = 200
+)
+
+// matchNewsFlash 按关键词筛选快讯并转换为搜索结果
+// 任一关键词出现在标题或正文中即视为匹配，无匹配时返回最新快讯作为背景
+func matchNewsFlash(query string, news []model.NewsItem) []model.SearchResult {
+	keywords := strings.Fields(strings.ToLower(query))
+
+	var matched []model.NewsItem
+	for _, item := range news {
+		text := strings.ToLower(item.Title + " " + item.Content)
+		for _, kw := range keywords {
+			if strings.Contains(text, kw) {
+				matched = append(matched, item)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = news
+	}
+	if len(matched) > cnNewsMaxResults {
+		matched = matched[:cnNewsMaxResults]
+	}
+
+	results := make([]model.SearchResult, 0, len(matched))
+	for _, item := range matched {
+		results = append(results, newsFlashResult(item))
+	}
+	return results
+}
+
+// newsFlashResult 将快讯转换为搜索结果，快讯没有链接，标题为空时取正文开头
+func newsFlashResult(item model.NewsItem) model.SearchResult {
+	content := []rune(strings.TrimSpace(item.Content))
+	title := strings.TrimSpace(item.Title)
+	if title == "" {
+		title = string(content[:min(len(content), 40)])
+	}
+
+	snippet := string(content)
+	if len(content) > cnNewsSnippetRunes {
+		snippet = string(content[:cnNewsSnippetRunes]) + "..."
+	}
+	if item.PublishTimeText != "" {
+		snippet = fmt.Sprintf("[%s] %s", item.PublishTimeText, snippet)
+	}
+	if item.Evaluate != "" {
+		snippet += fmt.Sprintf("（%s）", item.Evaluate)
+	}
+
+	return model.SearchResult{Title: title, Snippet: snippet}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/pkg/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cnNewsFixture = []model.NewsItem{
+	{ID: "1", Title: "半导体板块午后拉升", Content: "多只芯片股涨停，国产替代逻辑持续强化。", PublishTimeText: "2024-03-01 13:30:00", Evaluate: "利好"},
+	{ID: "2", Content: "央行今日开展1000亿元逆回购操作，维护银行体系流动性合理充裕。", PublishTimeText: "2024-03-01 09:20:00"},
+	{ID: "3", Title: "白酒板块震荡走低", Content: "消费板块资金流出。", PublishTimeText: "2024-03-01 10:05:00", Evaluate: "利空"},
+}
+
+func cnNewsToolCall(query string) llm.ToolCall {
+	args, _ := json.Marshal(map[string]string{"query": query})
+	return llm.ToolCall{Function: llm.FunctionCall{Name: "search_cn_news", Arguments: string(args)}}
+}
+
+func TestAIService_ExecuteToolCall_SearchCNNews(t *testing.T) {
+	newsCrawler := &mockNewsCrawler{news: cnNewsFixture}
+	svc := &aiService{cnNewsCrawler: newsCrawler}
+
+	result, err := svc.executeToolCall(context.Background(), cnNewsToolCall("芯片 逆回购"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, newsCrawler.count("GetNewsFlash"))
+	assert.Contains(t, result, `搜索 "芯片 逆回购" 的结果`)
+	assert.Contains(t, result, "1. 半导体板块午后拉升")
+	assert.Contains(t, result, "[2024-03-01 13:30:00] 多只芯片股涨停")
+	assert.Contains(t, result, "（利好）")
+	// 无标题的快讯取正文开头作为标题
+	assert.Contains(t, result, "2. 央行今日开展1000亿元逆回购操作")
+	assert.NotContains(t, result, "白酒")
+	// 快讯没有链接
+	assert.NotContains(t, result, "URL:")
+}
+
+func TestAIService_ExecuteToolCall_SearchCNNews_NoMatchReturnsLatest(t *testing.T) {
+	svc := &aiService{cnNewsCrawler: &mockNewsCrawler{news: cnNewsFixture}}
+
+	result, err := svc.executeToolCall(context.Background(), cnNewsToolCall("原油"))
+
+	require.NoError(t, err)
+	assert.Contains(t, result, "半导体板块午后拉升")
+	assert.Contains(t, result, "白酒板块震荡走低")
+}
+
+func TestAIService_ExecuteToolCall_SearchCNNews_Errors(t *testing.T) {
+	// 未配置国内快讯源
+	svc := &aiService{}
+	_, err := svc.executeToolCall(context.Background(), cnNewsToolCall("芯片"))
+	assert.ErrorContains(t, err, "unknown tool")
+
+	// 参数无效
+	svc = &aiService{cnNewsCrawler: &mockNewsCrawler{news: cnNewsFixture}}
+	_, err = svc.executeToolCall(context.Background(), llm.ToolCall{Function: llm.FunctionCall{Name: "search_cn_news", Arguments: "{"}})
+	assert.ErrorContains(t, err, "invalid arguments")
+
+	// 数据源失败
+	svc = &aiService{cnNewsCrawler: &mockNewsCrawler{err: assert.AnError}}
+	_, err = svc.executeToolCall(context.Background(), cnNewsToolCall("芯片"))
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestAIService_AnalyzeDeep_RegistersCNNewsTool(t *testing.T) {
+	var requests []string
+	svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk)}
+	_, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+	require.NoError(t, err)
+
+	svc.cnNewsCrawler = &mockNewsCrawler{}
+	_, err = collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	assert.False(t, strings.Contains(requests[0], `"search_cn_news"`))
+	assert.True(t, strings.Contains(requests[1], `"search_cn_news"`))
+}
+
+func TestMatchNewsFlash_LimitsResults(t *testing.T) {
+	news := make([]model.NewsItem, cnNewsMaxResults+5)
+	for i := range news {
+		news[i] = model.NewsItem{Title: "半导体快讯", Content: strings.Repeat("内容", cnNewsSnippetRunes)}
+	}
+
+	results := matchNewsFlash("半导体", news)
+
+	require.Len(t, results, cnNewsMaxResults)
+	assert.Equal(t, cnNewsSnippetRunes+len([]rune("...")), len([]rune(results[0].Snippet)))
+}
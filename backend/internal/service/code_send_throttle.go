@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+)
+
+// ErrCodeResendTooSoon 同一邮箱发送验证码过于频繁（冷却期内或超出每日上限）
+var ErrCodeResendTooSoon = errors.New("verification code requested too soon")
+
+// 验证码发送限制默认值
+const (
+	DefaultCodeResendCooldown = 60 * time.Second
+	DefaultCodeDailyLimit     = 10
+	// codeSendWindow 每日上限的统计窗口，从窗口内首次发送开始计算
+	codeSendWindow = 24 * time.Hour
+)
+
+// codeSendRecord 单个邮箱与验证码类型的发送记录
+type codeSendRecord struct {
+	WindowStart time.Time `json:"windowStart"`
+	LastSentAt  time.Time `json:"lastSentAt"`
+	Count       int       `json:"count"`
+}
+
+// CodeSendThrottle 按邮箱与验证码类型限制验证码发送频率，记录保存在缓存中
+// 防止反复请求验证码轰炸受害者邮箱并消耗发信额度
+type CodeSendThrottle struct {
+	cache      CacheService
+	cooldown   time.Duration
+	dailyLimit int
+	now        func() time.Time
+
+	// mu 串行化本实例内的检查与记录；多实例部署时为尽力而为的限制
+	mu sync.Mutex
+}
+
+// NewCodeSendThrottle 创建验证码发送限制，未设置的项使用默认值，设为负数表示不限制
+func NewCodeSendThrottle(cache CacheService, cfg config.VerificationCodeConfig) *CodeSendThrottle {
+	cooldown := DefaultCodeResendCooldown
+	if cfg.ResendCooldown != 0 {
+		cooldown = time.Duration(cfg.ResendCooldown) * time.Second
+	}
+	dailyLimit := DefaultCodeDailyLimit
+	if cfg.DailyLimit != 0 {
+		dailyLimit = cfg.DailyLimit
+	}
+	return &CodeSendThrottle{
+		cache:      cache,
+		cooldown:   cooldown,
+		dailyLimit: dailyLimit,
+		now:        time.Now,
+	}
+}
+
+// Reserve 检查并记录一次发送，冷却期内或超出每日上限时返回 ErrCodeResendTooSoon
+// 缓存读写失败时放行，不因缓存故障阻止注册与找回密码；throttle 为 nil 时不限制
+func (t *CodeSendThrottle) Reserve(ctx context.Context, email string, codeType model.VerificationCodeType) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := fmt.Sprintf("verify:send:%d:%s", codeType, strings.ToLower(strings.TrimSpace(email)))
+	now := t.now()
+
+	var record codeSendRecord
+	if err := t.cache.GetJSON(ctx, key, &record); err != nil || now.Sub(record.WindowStart) >= codeSendWindow {
+		record = codeSendRecord{WindowStart: now}
+	}
+
+	if t.cooldown > 0 && record.Count > 0 {
+		if wait := record.LastSentAt.Add(t.cooldown).Sub(now); wait > 0 {
+			return fmt.Errorf("%w: retry after %ds", ErrCodeResendTooSoon, int(math.Ceil(wait.Seconds())))
+		}
+	}
+	if t.dailyLimit > 0 && record.Count >= t.dailyLimit {
+		return fmt.Errorf("%w: daily limit of %d reached", ErrCodeResendTooSoon, t.dailyLimit)
+	}
+
+	record.Count++
+	record.LastSentAt = now
+	_ = t.cache.SetJSON(ctx, key, record, record.WindowStart.Add(codeSendWindow).Sub(now))
+	return nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCodeSendThrottle(cfg config.VerificationCodeConfig, now *time.Time) *CodeSendThrottle {
+	throttle := NewCodeSendThrottle(NewMemoryCache(), cfg)
+	throttle.now = func() time.Time { return *now }
+	return throttle
+}
+
+func TestCodeSendThrottle_Cooldown(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	throttle := newTestCodeSendThrottle(config.VerificationCodeConfig{}, &now)
+	ctx := context.Background()
+
+	require.NoError(t, throttle.Reserve(ctx, "user@example.com", model.VerificationCodeTypeRegister))
+
+	// 立即重发被拒绝，大小写不同的同一邮箱同样计入
+	err := throttle.Reserve(ctx, "User@Example.com", model.VerificationCodeTypeRegister)
+	assert.ErrorIs(t, err, ErrCodeResendTooSoon)
+	assert.Contains(t, err.Error(), "retry after 60s")
+
+	// 其他验证码类型与其他邮箱互不影响
+	assert.NoError(t, throttle.Reserve(ctx, "user@example.com", model.VerificationCodeTypeResetPassword))
+	assert.NoError(t, throttle.Reserve(ctx, "other@example.com", model.VerificationCodeTypeRegister))
+
+	now = now.Add(59 * time.Second)
+	assert.ErrorIs(t, throttle.Reserve(ctx, "user@example.com", model.VerificationCodeTypeRegister), ErrCodeResendTooSoon)
+
+	// 冷却期结束后可以再次发送
+	now = now.Add(time.Second)
+	assert.NoError(t, throttle.Reserve(ctx, "user@example.com", model.VerificationCodeTypeRegister))
+}
+
+func TestCodeSendThrottle_DailyLimit(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	throttle := newTestCodeSendThrottle(config.VerificationCodeConfig{ResendCooldown: 1, DailyLimit: 3}, &now)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, throttle.Reserve(ctx, "user@example.com", model.VerificationCodeTypeRegister))
+		now = now.Add(time.Minute)
+	}
+	err := throttle.Reserve(ctx, "user@example.com", model.VerificationCodeTypeRegister)
+	assert.ErrorIs(t, err, ErrCodeResendTooSoon)
+	assert.Contains(t, err.Error(), "daily limit")
+
+	// 统计窗口从首次发送开始，24 小时后重新计数
+	now = time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, throttle.Reserve(ctx, "user@example.com", model.VerificationCodeTypeRegister))
+}
+
+func TestCodeSendThrottle_Disabled(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	throttle := newTestCodeSendThrottle(config.VerificationCodeConfig{ResendCooldown: -1, DailyLimit: -1}, &now)
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, throttle.Reserve(context.Background(), "user@example.com", model.VerificationCodeTypeRegister))
+	}
+
+	var nilThrottle *CodeSendThrottle
+	assert.NoError(t, nilThrottle.Reserve(context.Background(), "user@example.com", model.VerificationCodeTypeRegister))
+}
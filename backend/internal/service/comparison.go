@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fund-analyzer/internal/model"
+)
+
+// valueDelta 同一标的在两个时点的数值变化
+type valueDelta struct {
+	Name   string
+	Before float64
+	After  float64
+}
+
+// Change 绝对变化
+func (d valueDelta) Change() float64 {
+	return d.After - d.Before
+}
+
+// ChangePercent 相对变化（%），基准为 0 时返回 0
+func (d valueDelta) ChangePercent() float64 {
+	if d.Before == 0 {
+		return 0
+	}
+	return (d.After - d.Before) / d.Before * 100
+}
+
+// sectorDelta 板块涨跌幅与主力资金的变化
+type sectorDelta struct {
+	Name   string
+	Rate   valueDelta // 涨跌幅（%），变化单位为百分点
+	Inflow valueDelta // 主力净流入（元）
+}
+
+// marketComparison 两个快照之间的变化
+type marketComparison struct {
+	Indices        []valueDelta // 指数点位
+	Metals         []valueDelta // 贵金属价格
+	Sectors        []sectorDelta
+	Funds          []valueDelta // 基金估值
+	NewSectors     []string     // 新进入热门列表的板块
+	DroppedSectors []string     // 退出热门列表的板块
+}
+
+// compareMarketData 按名称（基金按代码）匹配两份数据并计算变化
+// 只在一侧出现的指数、贵金属、基金不参与对比
+func compareMarketData(before, after *model.MarketData) *marketComparison {
+	cmp := &marketComparison{}
+
+	beforeIndices := make(map[string]model.MarketIndex, len(before.Indices))
+	for _, idx := range before.Indices {
+		beforeIndices[idx.Name] = idx
+	}
+	for _, idx := range after.Indices {
+		if prev, ok := beforeIndices[idx.Name]; ok {
+			cmp.Indices = append(cmp.Indices, valueDelta{
+				Name:   idx.Name,
+				Before: parseNumber(prev.Price),
+				After:  parseNumber(idx.Price),
+			})
+		}
+	}
+
+	beforeMetals := make(map[string]model.PreciousMetal, len(before.PreciousMetals))
+	for _, m := range before.PreciousMetals {
+		beforeMetals[m.Name] = m
+	}
+	for _, m := range after.PreciousMetals {
+		if prev, ok := beforeMetals[m.Name]; ok {
+			cmp.Metals = append(cmp.Metals, valueDelta{Name: m.Name, Before: prev.Price, After: m.Price})
+		}
+	}
+
+	beforeSectors := make(map[string]model.Sector, len(before.Sectors))
+	for _, sector := range before.Sectors {
+		beforeSectors[sector.Name] = sector
+	}
+	afterSectors := make(map[string]bool, len(after.Sectors))
+	for _, sector := range after.Sectors {
+		afterSectors[sector.Name] = true
+		prev, ok := beforeSectors[sector.Name]
+		if !ok {
+			cmp.NewSectors = append(cmp.NewSectors, sector.Name)
+			continue
+		}
+		cmp.Sectors = append(cmp.Sectors, sectorDelta{
+			Name:   sector.Name,
+			Rate:   valueDelta{Name: sector.Name, Before: parsePercentage(prev.ChangeRate), After: parsePercentage(sector.ChangeRate)},
+			Inflow: valueDelta{Name: sector.Name, Before: parseMoney(prev.MainNetInflow), After: parseMoney(sector.MainNetInflow)},
+		})
+	}
+	for _, sector := range before.Sectors {
+		if !afterSectors[sector.Name] {
+			cmp.DroppedSectors = append(cmp.DroppedSectors, sector.Name)
+		}
+	}
+
+	beforeFunds := make(map[string]model.FundValuation, len(before.Funds))
+	for _, f := range before.Funds {
+		beforeFunds[f.Code] = f
+	}
+	for _, f := range after.Funds {
+		if prev, ok := beforeFunds[f.Code]; ok {
+			cmp.Funds = append(cmp.Funds, valueDelta{
+				Name:   f.Name,
+				Before: parseNumber(prev.Valuation),
+				After:  parseNumber(f.Valuation),
+			})
+		}
+	}
+
+	return cmp
+}
+
+// parseNumber 解析带千分位的数值字符串
+func parseNumber(s string) float64 {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// buildComparisonAnalysisPrompt 构建时段对比分析提示词
+func buildComparisonAnalysisPrompt() string {
+	return `你是一个专业的基金投资分析师。请对比提供的"对比基准"和"当前"两份市场数据，生成一份时段对比分析报告。
+
+## 报告结构要求
+
+### 一、整体变化概述
+- 用 3-5 句话概括两个时点之间市场的主要变化
+
+### 二、指数与贵金属变化
+- 分析主要指数和贵金属的涨跌变化及其含义
+
+### 三、板块轮动分析
+- 分析涨跌幅和主力资金变化最大的板块
+- 解读新进入和退出热门列表的板块
+
+### 四、自选基金表现
+- 分析用户自选基金估值的变化
+- 指出表现最好和最差的基金
+
+### 五、策略调整建议
+- 根据变化趋势给出仓位和配置调整建议
+- 提示需要关注的风险
+
+## 输出要求
+1. 使用 Markdown 格式
+2. 引用"变化对比"中的具体数据
+3. 重点分析变化，而不是复述单个时点的数据
+4. 总字数控制在 1000-1500 字`
+}
+
+// buildComparisonDataPrompt 构建时段对比数据提示词
+// 包含两份快照的完整数据以及计算后的变化，每份快照的自选基金最多列出 maxFunds 只
+func buildComparisonDataPrompt(current, reference *model.MarketSnapshot, maxFunds int) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# 对比基准数据（%s）\n\n", reference.Date))
+	writeMarketDataTables(&sb, reference.Data, maxFunds)
+
+	sb.WriteString(fmt.Sprintf("# 当前市场数据（%s）\n\n", current.Date))
+	writeMarketDataTables(&sb, current.Data, maxFunds)
+
+	sb.WriteString(fmt.Sprintf("# 变化对比（%s → %s）\n\n", reference.Date, current.Date))
+	writeComparisonTables(&sb, compareMarketData(reference.Data, current.Data))
+
+	sb.WriteString("\n请根据以上数据进行对比分析。")
+
+	return sb.String()
+}
+
+// writeComparisonTables 写入变化对比表格
+func writeComparisonTables(sb *strings.Builder, cmp *marketComparison) {
+	if len(cmp.Indices) > 0 {
+		sb.WriteString("## 指数变化\n")
+		sb.WriteString("| 指数名称 | 基准 | 当前 | 变化 |\n")
+		sb.WriteString("|---------|------|------|------|\n")
+		for _, d := range cmp.Indices {
+			sb.WriteString(fmt.Sprintf("| %s | %.2f | %.2f | %+.2f%% |\n", d.Name, d.Before, d.After, d.ChangePercent()))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(cmp.Metals) > 0 {
+		sb.WriteString("## 贵金属变化\n")
+		sb.WriteString("| 品种 | 基准 | 当前 | 变化 |\n")
+		sb.WriteString("|------|------|------|------|\n")
+		for _, d := range cmp.Metals {
+			sb.WriteString(fmt.Sprintf("| %s | %.2f | %.2f | %+.2f%% |\n", d.Name, d.Before, d.After, d.ChangePercent()))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(cmp.Sectors) > 0 || len(cmp.NewSectors) > 0 || len(cmp.DroppedSectors) > 0 {
+		sb.WriteString("## 板块变化\n")
+		if len(cmp.Sectors) > 0 {
+			sb.WriteString("| 板块名称 | 基准涨跌幅 | 当前涨跌幅 | 变化（百分点） | 主力净流入变化 |\n")
+			sb.WriteString("|---------|-----------|-----------|---------------|---------------|\n")
+			for _, d := range cmp.Sectors {
+				sb.WriteString(fmt.Sprintf("| %s | %.2f%% | %.2f%% | %+.2f | %+.2f亿 |\n",
+					d.Name, d.Rate.Before, d.Rate.After, d.Rate.Change(), d.Inflow.Change()/1e8))
+			}
+		}
+		if len(cmp.NewSectors) > 0 {
+			sb.WriteString(fmt.Sprintf("- 新进热门板块: %s\n", strings.Join(cmp.NewSectors, "、")))
+		}
+		if len(cmp.DroppedSectors) > 0 {
+			sb.WriteString(fmt.Sprintf("- 退出热门板块: %s\n", strings.Join(cmp.DroppedSectors, "、")))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(cmp.Funds) > 0 {
+		sb.WriteString("## 自选基金变化\n")
+		sb.WriteString("| 基金名称 | 基准估值 | 当前估值 | 变化 |\n")
+		sb.WriteString("|---------|---------|---------|------|\n")
+		for _, d := range cmp.Funds {
+			sb.WriteString(fmt.Sprintf("| %s | %.4f | %.4f | %+.2f%% |\n", d.Name, d.Before, d.After, d.ChangePercent()))
+		}
+		sb.WriteString("\n")
+	}
+}
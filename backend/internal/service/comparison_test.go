@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newComparisonSnapshots() (current, reference *model.MarketSnapshot) {
+	reference = &model.MarketSnapshot{
+		Date: "2024-03-01",
+		Data: &model.MarketData{
+			Indices: []model.MarketIndex{{Name: "上证指数", Price: "3,000.00", Change: "+0.10%"}},
+			PreciousMetals: []model.PreciousMetal{
+				{Name: "黄金", Price: 480, Unit: "元/克", ChangeRate: "0.20%"},
+			},
+			Sectors: []model.Sector{
+				{Name: "半导体", ChangeRate: "1.00%", MainNetInflow: "2亿"},
+				{Name: "银行", ChangeRate: "0.50%", MainNetInflow: "1亿"},
+			},
+			Funds: []model.FundValuation{{Code: "000001", Name: "华夏成长", Valuation: "1.0000"}},
+		},
+	}
+	current = &model.MarketSnapshot{
+		Date: "2024-03-08",
+		Data: &model.MarketData{
+			Indices: []model.MarketIndex{{Name: "上证指数", Price: "3,150.00", Change: "+0.80%"}},
+			PreciousMetals: []model.PreciousMetal{
+				{Name: "黄金", Price: 504, Unit: "元/克", ChangeRate: "1.10%"},
+			},
+			Sectors: []model.Sector{
+				{Name: "半导体", ChangeRate: "3.50%", MainNetInflow: "5亿"},
+				{Name: "白酒", ChangeRate: "2.00%", MainNetInflow: "3亿"},
+			},
+			Funds: []model.FundValuation{{Code: "000001", Name: "华夏成长", Valuation: "0.9500"}},
+		},
+	}
+	return current, reference
+}
+
+func TestCompareMarketData_Deltas(t *testing.T) {
+	current, reference := newComparisonSnapshots()
+
+	cmp := compareMarketData(reference.Data, current.Data)
+
+	require.Len(t, cmp.Indices, 1)
+	assert.InDelta(t, 5.0, cmp.Indices[0].ChangePercent(), 1e-9)
+
+	require.Len(t, cmp.Metals, 1)
+	assert.InDelta(t, 5.0, cmp.Metals[0].ChangePercent(), 1e-9)
+
+	require.Len(t, cmp.Sectors, 1)
+	assert.Equal(t, "半导体", cmp.Sectors[0].Name)
+	assert.InDelta(t, 2.5, cmp.Sectors[0].Rate.Change(), 1e-9)
+	assert.InDelta(t, 3e8, cmp.Sectors[0].Inflow.Change(), 1e-3)
+	assert.Equal(t, []string{"白酒"}, cmp.NewSectors)
+	assert.Equal(t, []string{"银行"}, cmp.DroppedSectors)
+
+	require.Len(t, cmp.Funds, 1)
+	assert.InDelta(t, -5.0, cmp.Funds[0].ChangePercent(), 1e-9)
+}
+
+func TestBuildComparisonDataPrompt_IncludesBothSnapshotsAndDeltas(t *testing.T) {
+	current, reference := newComparisonSnapshots()
+
+	prompt := buildComparisonDataPrompt(current, reference, 0)
+
+	// 两份快照都完整出现
+	assert.Contains(t, prompt, "# 对比基准数据（2024-03-01）")
+	assert.Contains(t, prompt, "# 当前市场数据（2024-03-08）")
+	assert.Contains(t, prompt, "| 上证指数 | 3,000.00 | +0.10% |")
+	assert.Contains(t, prompt, "| 上证指数 | 3,150.00 | +0.80% |")
+	assert.Contains(t, prompt, "| 银行 | 0.50% | 1亿 |")
+
+	// 基准快照在前，当前快照在后，变化对比最后
+	refIdx := strings.Index(prompt, "# 对比基准数据")
+	curIdx := strings.Index(prompt, "# 当前市场数据")
+	deltaIdx := strings.Index(prompt, "# 变化对比（2024-03-01 → 2024-03-08）")
+	assert.True(t, refIdx < curIdx && curIdx < deltaIdx)
+
+	// 计算后的变化
+	assert.Contains(t, prompt, "| 上证指数 | 3000.00 | 3150.00 | +5.00% |")
+	assert.Contains(t, prompt, "| 黄金 | 480.00 | 504.00 | +5.00% |")
+	assert.Contains(t, prompt, "| 半导体 | 1.00% | 3.50% | +2.50 | +3.00亿 |")
+	assert.Contains(t, prompt, "| 华夏成长 | 1.0000 | 0.9500 | -5.00% |")
+	assert.Contains(t, prompt, "新进热门板块: 白酒")
+	assert.Contains(t, prompt, "退出热门板块: 银行")
+}
+
+func TestBuildComparisonDataPrompt_NoOverlap(t *testing.T) {
+	current := &model.MarketSnapshot{Date: "2024-03-08", Data: &model.MarketData{}}
+	reference := &model.MarketSnapshot{Date: "2024-03-01", Data: &model.MarketData{}}
+
+	prompt := buildComparisonDataPrompt(current, reference, 0)
+
+	assert.Contains(t, prompt, "# 变化对比")
+	assert.NotContains(t, prompt, "## 指数变化")
+	assert.NotContains(t, prompt, "## 板块变化")
+}
+
+func TestSnapshotService_SaveAndGet(t *testing.T) {
+	svc := NewSnapshotService(NewMemoryCache()).(*snapshotService)
+	day := time.Date(2024, 3, 1, 15, 0, 0, 0, crawler.ShanghaiLocation)
+	svc.now = func() time.Time { return day }
+	ctx := context.Background()
+
+	_, reference := newComparisonSnapshots()
+	saved, err := svc.Save(ctx, 7, reference.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-01", saved.Date)
+
+	got, err := svc.Get(ctx, 7, day.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-01", got.Date)
+	assert.Equal(t, reference.Data.Indices, got.Data.Indices)
+
+	// 其他用户或更早的日期没有快照
+	_, err = svc.Get(ctx, 8, day)
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+	_, err = svc.Get(ctx, 7, day.AddDate(0, 0, -7))
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
+
+func TestSnapshotService_GetFallsBackToEarlierSnapshot(t *testing.T) {
+	svc := NewSnapshotService(NewMemoryCache()).(*snapshotService)
+	ctx := context.Background()
+	_, reference := newComparisonSnapshots()
+
+	// 周五保存快照，周末没有分析
+	friday := time.Date(2024, 3, 1, 15, 0, 0, 0, crawler.ShanghaiLocation)
+	svc.now = func() time.Time { return friday }
+	_, err := svc.Save(ctx, 7, reference.Data)
+	require.NoError(t, err)
+
+	svc.now = func() time.Time { return friday.AddDate(0, 0, 7) }
+	got, err := svc.Get(ctx, 7, friday.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-01", got.Date)
+
+	// 超出保留时长的日期不再回溯
+	svc.now = func() time.Time { return friday.Add(TTLMarketSnapshot).AddDate(0, 0, 1) }
+	_, err = svc.Get(ctx, 7, friday.AddDate(0, 0, 2))
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
+
+func TestSnapshotService_DatesInShanghai(t *testing.T) {
+	svc := NewSnapshotService(NewMemoryCache()).(*snapshotService)
+	ctx := context.Background()
+	_, reference := newComparisonSnapshots()
+
+	// UTC 3 月 1 日 17:00 已是北京时间 3 月 2 日
+	svc.now = func() time.Time { return time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC) }
+	saved, err := svc.Save(ctx, 7, reference.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-02", saved.Date)
+
+	got, err := svc.Get(ctx, 7, time.Date(2024, 3, 2, 1, 0, 0, 0, crawler.ShanghaiLocation))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-02", got.Date)
+}
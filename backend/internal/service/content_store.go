@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+)
+
+// ErrInvalidContentConfig 内容配置无效
+var ErrInvalidContentConfig = errors.New("invalid content config")
+
+// AnalysisTemplate 分析系统提示词类型
+type AnalysisTemplate string
+
+const (
+	TemplateStandard AnalysisTemplate = "standard"
+	TemplateFast     AnalysisTemplate = "fast"
+	TemplateDeep     AnalysisTemplate = "deep"
+	TemplateCompare  AnalysisTemplate = "compare"
+)
+
+// ContentSnapshot 某一时刻的内容配置，创建后不再修改
+// 请求开始时取一次快照并在整个请求中使用，热更新不会让请求看到新旧混合的配置
+type ContentSnapshot struct {
+	keywordMap []moduleKeywords
+	templates  map[AnalysisTemplate]string
+	newsDeny   []string // 小写
+}
+
+// ContentStore 可热更新的内容配置（关键词、分析模板、快讯屏蔽词）
+// 更新时整体替换快照，nil 表示使用内置配置
+type ContentStore struct {
+	snapshot atomic.Pointer[ContentSnapshot]
+}
+
+// NewContentStore 根据配置创建内容配置
+func NewContentStore(cfg config.ContentConfig) (*ContentStore, error) {
+	store := &ContentStore{}
+	if err := store.Update(cfg); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Update 校验并整体替换内容配置，校验失败时保留当前配置
+func (s *ContentStore) Update(cfg config.ContentConfig) error {
+	snapshot, err := newContentSnapshot(cfg)
+	if err != nil {
+		return err
+	}
+	s.snapshot.Store(snapshot)
+	return nil
+}
+
+// Load 获取当前内容配置快照，nil store 返回内置配置
+func (s *ContentStore) Load() *ContentSnapshot {
+	if s != nil {
+		if snapshot := s.snapshot.Load(); snapshot != nil {
+			return snapshot
+		}
+	}
+	return defaultContentSnapshot
+}
+
+// defaultContentSnapshot 内置配置
+var defaultContentSnapshot = &ContentSnapshot{keywordMap: initKeywordMap()}
+
+// newContentSnapshot 根据配置构建快照，配置的模块关键词替换内置关键词
+func newContentSnapshot(cfg config.ContentConfig) (*ContentSnapshot, error) {
+	overrides := make(map[DataModule][]string, len(cfg.MatcherKeywords))
+	for name, keywords := range cfg.MatcherKeywords {
+		module := DataModule(strings.ToLower(strings.TrimSpace(name)))
+		if !isKnownModule(module) {
+			return nil, fmt.Errorf("%w: unknown data module %q", ErrInvalidContentConfig, name)
+		}
+		overrides[module] = nonEmptyStrings(keywords, strings.TrimSpace)
+	}
+
+	keywordMap := initKeywordMap()
+	for i, mk := range keywordMap {
+		if keywords, ok := overrides[mk.module]; ok {
+			keywordMap[i].keywords = keywords
+		}
+	}
+
+	templates := make(map[AnalysisTemplate]string)
+	for name, text := range map[AnalysisTemplate]string{
+		TemplateStandard: cfg.Templates.Standard,
+		TemplateFast:     cfg.Templates.Fast,
+		TemplateDeep:     cfg.Templates.Deep,
+		TemplateCompare:  cfg.Templates.Compare,
+	} {
+		if text = strings.TrimSpace(text); text != "" {
+			templates[name] = text
+		}
+	}
+
+	return &ContentSnapshot{
+		keywordMap: keywordMap,
+		templates:  templates,
+		newsDeny: nonEmptyStrings(cfg.NewsDenyList, func(s string) string {
+			return strings.ToLower(strings.TrimSpace(s))
+		}),
+	}, nil
+}
+
+// Template 获取分析系统提示词，未配置时使用 fallback 生成的内置模板
+func (c *ContentSnapshot) Template(name AnalysisTemplate, fallback func() string) string {
+	if text, ok := c.templates[name]; ok {
+		return text
+	}
+	return fallback()
+}
+
+// FilterNews 过滤标题或内容包含屏蔽词的快讯，返回新切片
+func (c *ContentSnapshot) FilterNews(news []model.NewsItem) []model.NewsItem {
+	if len(c.newsDeny) == 0 {
+		return news
+	}
+
+	filtered := make([]model.NewsItem, 0, len(news))
+	for _, item := range news {
+		if !c.newsDenied(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// newsDenied 快讯是否包含屏蔽词
+func (c *ContentSnapshot) newsDenied(item model.NewsItem) bool {
+	text := strings.ToLower(item.Title + "\n" + item.Content)
+	for _, word := range c.newsDeny {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownModule 是否为已知数据模块
+func isKnownModule(module DataModule) bool {
+	for _, m := range AllDataModules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+// nonEmptyStrings 规范化字符串并去除空值
+func nonEmptyStrings(values []string, normalize func(string) string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = normalize(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
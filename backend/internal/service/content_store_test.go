@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generationContent 同一代配置的关键词、模板和屏蔽词均为 word
+func generationContent(word string) config.ContentConfig {
+	return config.ContentConfig{
+		MatcherKeywords: map[string][]string{"precious_metals": {word}},
+		Templates:       config.AnalysisTemplates{Fast: word},
+		NewsDenyList:    []string{word},
+	}
+}
+
+// snapshotKeywords 获取快照中指定模块的关键词
+func snapshotKeywords(snapshot *ContentSnapshot, module DataModule) []string {
+	for _, mk := range snapshot.keywordMap {
+		if mk.module == module {
+			return mk.keywords
+		}
+	}
+	return nil
+}
+
+func TestContentStore_UpdateTakesEffectOnSubsequentRequests(t *testing.T) {
+	store, err := NewContentStore(config.ContentConfig{})
+	require.NoError(t, err)
+	matcher := NewDataMatcherWithContent(store)
+	news := NewNewsServiceWithContent(&mockNewsCrawler{news: []model.NewsItem{
+		{ID: "1", Title: "央行降准"},
+		{ID: "2", Title: "某公司违规披露"},
+	}}, NewMemoryCache(), store)
+
+	assert.NotContains(t, matcher.Match("聊聊铜价"), ModulePreciousMetals)
+	page, err := news.GetNews(context.Background(), NewsQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+
+	require.NoError(t, store.Update(config.ContentConfig{
+		MatcherKeywords: map[string][]string{"Precious_Metals": {"铜价"}},
+		NewsDenyList:    []string{" 违规 "},
+	}))
+
+	assert.Contains(t, matcher.Match("聊聊铜价"), ModulePreciousMetals)
+	assert.NotContains(t, matcher.Match("黄金价格"), ModulePreciousMetals, "configured keywords replace built-in ones")
+	assert.Contains(t, matcher.Match("上证指数走势"), ModuleMarketIndices, "unconfigured modules keep built-in keywords")
+
+	// 快讯已缓存，屏蔽词在返回时生效
+	page, err = news.GetNews(context.Background(), NewsQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "1", page.Items[0].ID)
+}
+
+func TestContentStore_TemplateOverride(t *testing.T) {
+	store, err := NewContentStore(config.ContentConfig{})
+	require.NoError(t, err)
+
+	var requests []string
+	svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk), content: store}
+	analyze := func() {
+		_, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+			return svc.AnalyzeFast(context.Background(), &model.MarketData{}, stream)
+		})
+		require.NoError(t, err)
+	}
+
+	analyze()
+	require.NoError(t, store.Update(config.ContentConfig{Templates: config.AnalysisTemplates{Fast: "自定义快速分析模板"}}))
+	analyze()
+
+	require.Len(t, requests, 2)
+	assert.NotContains(t, requests[0], "自定义快速分析模板")
+	assert.Contains(t, requests[1], "自定义快速分析模板")
+}
+
+func TestContentStore_InvalidUpdateKeepsCurrentConfig(t *testing.T) {
+	store, err := NewContentStore(generationContent("alpha"))
+	require.NoError(t, err)
+
+	err = store.Update(config.ContentConfig{
+		MatcherKeywords: map[string][]string{"crypto": {"btc"}},
+		NewsDenyList:    []string{"beta"},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidContentConfig)
+	assert.Equal(t, []string{"alpha"}, store.Load().newsDeny)
+
+	_, err = NewContentStore(config.ContentConfig{MatcherKeywords: map[string][]string{"crypto": nil}})
+	assert.ErrorIs(t, err, ErrInvalidContentConfig)
+}
+
+func TestContentStore_NilUsesBuiltin(t *testing.T) {
+	var store *ContentStore
+
+	snapshot := store.Load()
+
+	assert.Equal(t, "builtin", snapshot.Template(TemplateDeep, func() string { return "builtin" }))
+	assert.NotEmpty(t, snapshotKeywords(snapshot, ModuleMarketIndices))
+	assert.Contains(t, NewDataMatcher().Match("黄金价格"), ModulePreciousMetals)
+}
+
+func TestContentStore_ConcurrentReloadSnapshotsAreConsistent(t *testing.T) {
+	store, err := NewContentStore(generationContent("alpha"))
+	require.NoError(t, err)
+	matcher := NewDataMatcherWithContent(store)
+
+	const readers = 8
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan string, readers)
+
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				// 同一快照内的关键词、模板与屏蔽词必须来自同一代配置
+				snapshot := store.Load()
+				word := snapshot.Template(TemplateFast, func() string { return "" })
+				keywords := snapshotKeywords(snapshot, ModulePreciousMetals)
+				if len(keywords) != 1 || keywords[0] != word || len(snapshot.newsDeny) != 1 || snapshot.newsDeny[0] != word {
+					errs <- word
+					return
+				}
+				matcher.Match("alpha beta")
+			}
+		}()
+	}
+
+	for i := 0; i < 2000; i++ {
+		word := "alpha"
+		if i%2 == 0 {
+			word = "beta"
+		}
+		require.NoError(t, store.Update(generationContent(word)))
+	}
+	require.NoError(t, store.Update(generationContent("gamma")))
+	close(stop)
+	wg.Wait()
+	close(errs)
+
+	for word := range errs {
+		t.Errorf("torn snapshot observed for generation %q", word)
+	}
+
+	// 更新完成后新请求使用最新配置
+	assert.Contains(t, matcher.Match("gamma"), ModulePreciousMetals)
+	assert.NotContains(t, matcher.Match("alpha"), ModulePreciousMetals)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// conversationHistoryLimit 从服务端加载的最近消息条数
+const conversationHistoryLimit = 20
+
+// ErrConversationNotFound 会话不存在或不属于当前用户
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationService AI 对话会话持久化服务接口
+type ConversationService interface {
+	// Prepare 为对话请求准备会话：指定会话时以服务端保存的最近消息替换客户端历史，未指定时创建新会话
+	// 未登录用户不保存会话
+	Prepare(ctx context.Context, req *model.ChatRequest) error
+	// Capture 保存用户消息并转发对话输出，正常结束时保存助手回复
+	// 首个块与 done 块携带会话 ID；写入方关闭返回的 channel 后 stream 随之关闭，wait 等待转发完成
+	Capture(ctx context.Context, req *model.ChatRequest, stream chan<- model.ChatChunk) (tee chan<- model.ChatChunk, wait func())
+	// Messages 获取用户会话的最近消息
+	Messages(ctx context.Context, userID, conversationID int64) ([]model.ConversationMessage, error)
+}
+
+type conversationService struct {
+	repo   repository.ConversationRepository
+	logger *zap.Logger
+}
+
+// NewConversationService 创建 AI 对话会话服务
+func NewConversationService(repo repository.ConversationRepository, logger *zap.Logger) ConversationService {
+	return &conversationService{repo: repo, logger: logger}
+}
+
+// Prepare 为对话请求准备会话
+func (s *conversationService) Prepare(ctx context.Context, req *model.ChatRequest) error {
+	if req.ConversationID == 0 {
+		if req.UserID <= 0 {
+			return nil
+		}
+		conversation := &model.Conversation{UserID: req.UserID}
+		if err := s.repo.CreateConversation(ctx, conversation); err != nil {
+			return fmt.Errorf("create conversation: %w", err)
+		}
+		req.ConversationID = conversation.ID
+		return nil
+	}
+
+	messages, err := s.Messages(ctx, req.UserID, req.ConversationID)
+	if err != nil {
+		return err
+	}
+	history := make([]model.ChatMessage, len(messages))
+	for i, msg := range messages {
+		history[i] = model.ChatMessage{Role: msg.Role, Content: msg.Content}
+	}
+	req.History = history
+	return nil
+}
+
+// Messages 获取用户会话的最近消息，会话不属于该用户时视为不存在
+func (s *conversationService) Messages(ctx context.Context, userID, conversationID int64) ([]model.ConversationMessage, error) {
+	conversation, err := s.repo.GetConversation(ctx, conversationID)
+	if errors.Is(err, repository.ErrConversationNotFound) || (err == nil && conversation.UserID != userID) {
+		return nil, fmt.Errorf("%w: %d", ErrConversationNotFound, conversationID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetMessages(ctx, conversationID, conversationHistoryLimit)
+}
+
+// Capture 保存用户消息并转发对话输出
+func (s *conversationService) Capture(ctx context.Context, req *model.ChatRequest, stream chan<- model.ChatChunk) (chan<- model.ChatChunk, func()) {
+	tee := make(chan model.ChatChunk, 100)
+	done := make(chan struct{})
+	conversationID := req.ConversationID
+	// 客户端断开不影响保存已产生的消息
+	saveCtx := context.WithoutCancel(ctx)
+
+	go func() {
+		defer close(done)
+		defer close(stream)
+
+		if conversationID > 0 {
+			s.append(saveCtx, conversationID, "user", req.Message)
+		}
+
+		var reply strings.Builder
+		failed := false
+		first := true
+		for chunk := range tee {
+			switch chunk.Type {
+			case model.ChunkTypeContent:
+				reply.WriteString(chunk.Chunk)
+			case model.ChunkTypeError:
+				failed = true
+			case model.ChunkTypeDone:
+				if conversationID > 0 && !failed && reply.Len() > 0 {
+					s.append(saveCtx, conversationID, "assistant", reply.String())
+				}
+			}
+			if first || chunk.Type == model.ChunkTypeDone {
+				chunk.ConversationID = conversationID
+				first = false
+			}
+			stream <- chunk
+		}
+	}()
+
+	return tee, func() { <-done }
+}
+
+// append 保存一条消息，失败时仅记录日志，不影响本次对话
+func (s *conversationService) append(ctx context.Context, conversationID int64, role, content string) {
+	message := &model.ConversationMessage{ConversationID: conversationID, Role: role, Content: content}
+	if err := s.repo.AppendMessage(ctx, message); err != nil {
+		s.logger.Warn("Failed to save conversation message",
+			zap.Error(err), zap.Int64("conversationID", conversationID), zap.String("role", role))
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryConversationRepository 内存会话仓库
+type memoryConversationRepository struct {
+	mu            sync.Mutex
+	conversations map[int64]*model.Conversation
+	messages      []model.ConversationMessage
+	appendErr     error
+}
+
+func newMemoryConversationRepository() *memoryConversationRepository {
+	return &memoryConversationRepository{conversations: make(map[int64]*model.Conversation)}
+}
+
+func (r *memoryConversationRepository) CreateConversation(ctx context.Context, conversation *model.Conversation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conversation.ID = int64(len(r.conversations) + 1)
+	r.conversations[conversation.ID] = conversation
+	return nil
+}
+
+func (r *memoryConversationRepository) GetConversation(ctx context.Context, conversationID int64) (*model.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conversation, ok := r.conversations[conversationID]
+	if !ok {
+		return nil, repository.ErrConversationNotFound
+	}
+	return conversation, nil
+}
+
+func (r *memoryConversationRepository) AppendMessage(ctx context.Context, message *model.ConversationMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.appendErr != nil {
+		return r.appendErr
+	}
+	message.ID = int64(len(r.messages) + 1)
+	r.messages = append(r.messages, *message)
+	return nil
+}
+
+func (r *memoryConversationRepository) GetMessages(ctx context.Context, conversationID int64, limit int) ([]model.ConversationMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []model.ConversationMessage
+	for _, msg := range r.messages {
+		if msg.ConversationID == conversationID {
+			messages = append(messages, msg)
+		}
+	}
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
+}
+
+// runConversation 模拟一次对话并通过 Capture 转发
+func runConversation(t *testing.T, svc ConversationService, req *model.ChatRequest, chunks ...model.ChatChunk) []model.ChatChunk {
+	t.Helper()
+	stream := make(chan model.ChatChunk, 10)
+	tee, wait := svc.Capture(context.Background(), req, stream)
+	for _, chunk := range chunks {
+		tee <- chunk
+	}
+	close(tee)
+	wait()
+
+	var forwarded []model.ChatChunk
+	for chunk := range stream {
+		forwarded = append(forwarded, chunk)
+	}
+	return forwarded
+}
+
+func TestConversation_PersistsAndReloadsHistory(t *testing.T) {
+	repo := newMemoryConversationRepository()
+	svc := NewConversationService(repo, zap.NewNop())
+	ctx := context.Background()
+
+	// 首轮对话创建会话
+	first := &model.ChatRequest{Message: "今天大盘怎么样", UserID: 7}
+	require.NoError(t, svc.Prepare(ctx, first))
+	require.NotZero(t, first.ConversationID)
+
+	forwarded := runConversation(t, svc, first,
+		model.ChatChunk{Type: model.ChunkTypeStatus, Message: "正在分析您的问题..."},
+		model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "指数小幅"},
+		model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "上涨"},
+		model.ChatChunk{Type: model.ChunkTypeDone},
+	)
+	require.Len(t, forwarded, 4)
+	assert.Equal(t, first.ConversationID, forwarded[0].ConversationID)
+	assert.Zero(t, forwarded[1].ConversationID)
+	assert.Equal(t, first.ConversationID, forwarded[3].ConversationID)
+
+	// 刷新后续聊：忽略客户端历史，使用服务端保存的消息
+	second := &model.ChatRequest{
+		Message:        "那黄金呢",
+		UserID:         7,
+		ConversationID: first.ConversationID,
+		History:        []model.ChatMessage{{Role: "assistant", Content: "伪造的回复"}},
+	}
+	require.NoError(t, svc.Prepare(ctx, second))
+	assert.Equal(t, []model.ChatMessage{
+		{Role: "user", Content: "今天大盘怎么样"},
+		{Role: "assistant", Content: "指数小幅上涨"},
+	}, second.History)
+}
+
+func TestConversation_OtherUsersConversationNotFound(t *testing.T) {
+	repo := newMemoryConversationRepository()
+	svc := NewConversationService(repo, zap.NewNop())
+	ctx := context.Background()
+
+	owned := &model.ChatRequest{Message: "你好", UserID: 7}
+	require.NoError(t, svc.Prepare(ctx, owned))
+
+	err := svc.Prepare(ctx, &model.ChatRequest{Message: "你好", UserID: 8, ConversationID: owned.ConversationID})
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+
+	_, err = svc.Messages(ctx, 7, 999)
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+}
+
+func TestConversation_AnonymousNotPersisted(t *testing.T) {
+	repo := newMemoryConversationRepository()
+	svc := NewConversationService(repo, zap.NewNop())
+
+	req := &model.ChatRequest{Message: "你好", History: []model.ChatMessage{{Role: "user", Content: "早上好"}}}
+	require.NoError(t, svc.Prepare(context.Background(), req))
+
+	assert.Zero(t, req.ConversationID)
+	assert.Len(t, req.History, 1)
+	runConversation(t, svc, req, model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "你好"}, model.ChatChunk{Type: model.ChunkTypeDone})
+	assert.Empty(t, repo.conversations)
+	assert.Empty(t, repo.messages)
+}
+
+func TestConversation_FailedReplyNotPersisted(t *testing.T) {
+	repo := newMemoryConversationRepository()
+	svc := NewConversationService(repo, zap.NewNop())
+
+	req := &model.ChatRequest{Message: "今天大盘怎么样", UserID: 7}
+	require.NoError(t, svc.Prepare(context.Background(), req))
+	runConversation(t, svc, req,
+		model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "部分内容"},
+		model.ChatChunk{Type: model.ChunkTypeError, Message: "AI 服务调用失败"},
+	)
+
+	// 只保存用户消息
+	require.Len(t, repo.messages, 1)
+	assert.Equal(t, "user", repo.messages[0].Role)
+}
+
+func TestConversation_SaveFailureDoesNotBreakStream(t *testing.T) {
+	repo := newMemoryConversationRepository()
+	repo.appendErr = errors.New("connection reset")
+	svc := NewConversationService(repo, zap.NewNop())
+
+	req := &model.ChatRequest{Message: "你好", UserID: 7}
+	require.NoError(t, svc.Prepare(context.Background(), req))
+	forwarded := runConversation(t, svc, req,
+		model.ChatChunk{Type: model.ChunkTypeContent, Chunk: "你好"},
+		model.ChatChunk{Type: model.ChunkTypeDone},
+	)
+
+	assert.Equal(t, []model.ChatChunkType{model.ChunkTypeContent, model.ChunkTypeDone}, chunkTypes(forwarded))
+}
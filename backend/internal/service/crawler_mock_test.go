@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+)
+
+// crawlerCalls 按方法名（及参数）记录调用次数
+type crawlerCalls struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *crawlerCalls) record(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[name]++
+}
+
+// count 获取调用次数
+func (c *crawlerCalls) count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[name]
+}
+
+// mockMarketCrawler 返回预设行情数据，errs 按方法名（指数为 "GetMarketIndices:地区"）注入错误
+type mockMarketCrawler struct {
+	crawlerCalls
+	indices map[string][]model.MarketIndex
+	minute  []model.MinuteData
+	volumes []model.VolumeTrend
+	errs    map[string]error
+}
+
+var _ crawler.MarketDataCrawler = (*mockMarketCrawler)(nil)
+
+func (m *mockMarketCrawler) GetMarketIndices(ctx context.Context, market string) ([]model.MarketIndex, error) {
+	key := "GetMarketIndices:" + market
+	m.record(key)
+	if err := m.errs[key]; err != nil {
+		return nil, err
+	}
+	return m.indices[market], nil
+}
+
+func (m *mockMarketCrawler) GetMinuteData(ctx context.Context, code string) ([]model.MinuteData, error) {
+	m.record("GetMinuteData")
+	if err := m.errs["GetMinuteData"]; err != nil {
+		return nil, err
+	}
+	return m.minute, nil
+}
+
+func (m *mockMarketCrawler) GetVolumeTrend(ctx context.Context) ([]model.VolumeTrend, error) {
+	m.record("GetVolumeTrend")
+	if err := m.errs["GetVolumeTrend"]; err != nil {
+		return nil, err
+	}
+	return m.volumes, nil
+}
+
+// mockNewsCrawler 返回预设快讯
+type mockNewsCrawler struct {
+	crawlerCalls
+	news []model.NewsItem
+	err  error
+}
+
+var _ crawler.NewsCrawler = (*mockNewsCrawler)(nil)
+
+func (m *mockNewsCrawler) GetNewsFlash(ctx context.Context, count int) ([]model.NewsItem, error) {
+	m.record("GetNewsFlash")
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.news, nil
+}
+
+// mockGoldCrawler 返回预设贵金属数据
+type mockGoldCrawler struct {
+	crawlerCalls
+	metals  []model.PreciousMetal
+	history []model.GoldPrice
+	err     error
+}
+
+var _ crawler.GoldDataCrawler = (*mockGoldCrawler)(nil)
+
+func (m *mockGoldCrawler) GetRealTimeGold(ctx context.Context) ([]model.PreciousMetal, error) {
+	m.record("GetRealTimeGold")
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.metals, nil
+}
+
+func (m *mockGoldCrawler) GetGoldHistory(ctx context.Context, days int) ([]model.GoldPrice, error) {
+	m.record("GetGoldHistory")
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.history, nil
+}
+
+// mockSectorCrawler 返回预设板块数据
+type mockSectorCrawler struct {
+	crawlerCalls
+	sectors []model.Sector
+	funds   map[string][]model.SectorFund
+	err     error
+}
+
+var _ crawler.SectorDataCrawler = (*mockSectorCrawler)(nil)
+
+func (m *mockSectorCrawler) GetSectorList(ctx context.Context) ([]model.Sector, error) {
+	m.record("GetSectorList")
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.sectors, nil
+}
+
+func (m *mockSectorCrawler) GetSectorFunds(ctx context.Context, sectorCode string) ([]model.SectorFund, error) {
+	m.record("GetSectorFunds:" + sectorCode)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.funds[sectorCode], nil
+}
+
+// mockFundCrawler 返回预设基金数据
+type mockFundCrawler struct {
+	crawlerCalls
+	funds      map[string]*model.FundInfo
+	valuations map[string]*model.FundValuation
+	metas      map[string]*model.FundMeta
+	curves     map[string][]model.FundPoint // 按 "productID:period" 索引
+	err        error
+}
+
+var (
+	_ crawler.FundDataCrawler    = (*mockFundCrawler)(nil)
+	_ crawler.FundMetaCrawler    = (*mockFundCrawler)(nil)
+	_ crawler.FundHistoryCrawler = (*mockFundCrawler)(nil)
+)
+
+func (m *mockFundCrawler) SearchFund(ctx context.Context, code string) (*model.FundInfo, error) {
+	m.record("SearchFund:" + code)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.funds[code], nil
+}
+
+func (m *mockFundCrawler) GetFundValuation(ctx context.Context, productID string) (*model.FundValuation, error) {
+	m.record("GetFundValuation:" + productID)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.valuations[productID], nil
+}
+
+func (m *mockFundCrawler) GetFundMeta(ctx context.Context, productID string) (*model.FundMeta, error) {
+	m.record("GetFundMeta:" + productID)
+	if m.err != nil {
+		return nil, m.err
+	}
+	meta, ok := m.metas[productID]
+	if !ok {
+		return nil, errors.New("base info not found")
+	}
+	return meta, nil
+}
+
+func (m *mockFundCrawler) GetFundCurves(ctx context.Context, productID string, interval string) ([]model.FundPoint, error) {
+	m.record("GetFundCurves:" + productID + ":" + interval)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.curves[productID+":"+interval], nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/pkg/random"
+
+	"go.uber.org/zap"
+)
+
+// upstreamLatencyBuckets 上游耗时直方图的桶上界（毫秒）
+var upstreamLatencyBuckets = []int64{50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// LatencyBucket 直方图的桶，Count 为耗时不超过 LeMs 的请求数（累计）
+type LatencyBucket struct {
+	LeMs  int64 `json:"leMs"`
+	Count int64 `json:"count"`
+}
+
+// UpstreamLatencyStats 单个数据源的请求耗时统计，超过最大桶上界的请求只计入 Count
+type UpstreamLatencyStats struct {
+	Source  string          `json:"source"`
+	Count   int64           `json:"count"`
+	Errors  int64           `json:"errors"`
+	AvgMs   float64         `json:"avgMs"`
+	MaxMs   int64           `json:"maxMs"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// latencyHistogram 单个数据源的耗时直方图
+type latencyHistogram struct {
+	count   int64
+	errors  int64
+	sumMs   int64
+	maxMs   int64
+	buckets []int64 // 与 upstreamLatencyBuckets 对应，非累计
+}
+
+// CrawlerRequestLogger 记录上游请求日志并按数据源统计耗时
+// 作为 crawler.HTTPClientConfig.OnRequest 使用
+type CrawlerRequestLogger struct {
+	logger *zap.Logger
+	config config.CrawlerRequestLogConfig
+	random random.Source // 日志采样使用的随机源
+
+	mu         sync.Mutex
+	histograms map[string]*latencyHistogram
+}
+
+// NewCrawlerRequestLogger 创建上游请求日志记录器
+func NewCrawlerRequestLogger(logger *zap.Logger, cfg config.CrawlerRequestLogConfig) *CrawlerRequestLogger {
+	return &CrawlerRequestLogger{
+		logger:     logger,
+		config:     cfg,
+		random:     random.Default(),
+		histograms: make(map[string]*latencyHistogram),
+	}
+}
+
+// OnRequest 记录一次上游请求：所有请求计入耗时统计，日志按采样写入，失败与慢请求总是写入
+func (l *CrawlerRequestLogger) OnRequest(ctx context.Context, record crawler.RequestRecord) {
+	l.observe(record)
+
+	slow := l.config.SlowThresholdMs > 0 && record.Latency >= time.Duration(l.config.SlowThresholdMs)*time.Millisecond
+	if record.Err == nil && !slow && !random.Chance(l.random, l.config.SampleRate) {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("source", record.Source),
+		zap.String("method", record.Method),
+		zap.String("url", record.URL),
+		zap.Int("status", record.Status),
+		zap.Int("bytes", record.Bytes),
+		zap.Int64("latencyMs", record.Latency.Milliseconds()),
+	}
+	if n := l.config.BodyPreviewBytes; n > 0 && len(record.Body) > 0 {
+		fields = append(fields, zap.ByteString("bodyPreview", record.Body[:min(n, len(record.Body))]))
+	}
+
+	switch {
+	case record.Err != nil:
+		l.logger.Warn("Upstream request failed", append(fields, zap.Error(record.Err))...)
+	case slow:
+		l.logger.Warn("Slow upstream request", fields...)
+	default:
+		l.logger.Info("Upstream request", fields...)
+	}
+}
+
+// observe 计入耗时直方图
+func (l *CrawlerRequestLogger) observe(record crawler.RequestRecord) {
+	ms := record.Latency.Milliseconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.histograms[record.Source]
+	if !ok {
+		h = &latencyHistogram{buckets: make([]int64, len(upstreamLatencyBuckets))}
+		l.histograms[record.Source] = h
+	}
+	h.count++
+	h.sumMs += ms
+	if ms > h.maxMs {
+		h.maxMs = ms
+	}
+	if record.Err != nil {
+		h.errors++
+	}
+	for i, le := range upstreamLatencyBuckets {
+		if ms <= le {
+			h.buckets[i]++
+			break
+		}
+	}
+}
+
+// Stats 获取各数据源的耗时统计，按数据源名称排序
+func (l *CrawlerRequestLogger) Stats() []UpstreamLatencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make([]UpstreamLatencyStats, 0, len(l.histograms))
+	for source, h := range l.histograms {
+		s := UpstreamLatencyStats{
+			Source:  source,
+			Count:   h.count,
+			Errors:  h.errors,
+			MaxMs:   h.maxMs,
+			Buckets: make([]LatencyBucket, len(upstreamLatencyBuckets)),
+		}
+		if h.count > 0 {
+			s.AvgMs = float64(h.sumMs) / float64(h.count)
+		}
+		var cumulative int64
+		for i, le := range upstreamLatencyBuckets {
+			cumulative += h.buckets[i]
+			s.Buckets[i] = LatencyBucket{LeMs: le, Count: cumulative}
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Source < stats[j].Source })
+	return stats
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedRequestLogger(cfg config.CrawlerRequestLogConfig) (*CrawlerRequestLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	return NewCrawlerRequestLogger(zap.New(core), cfg), logs
+}
+
+func TestCrawlerRequestLogger_LogsSourceAndLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"data":"secret payload"}`))
+	}))
+	defer server.Close()
+
+	requestLogger, logs := newObservedRequestLogger(config.CrawlerRequestLogConfig{SampleRate: 1})
+	clientConfig := crawler.DefaultHTTPClientConfig()
+	clientConfig.OnRequest = requestLogger.OnRequest
+	client := crawler.NewHTTPClient(clientConfig).WithSource("eastmoney")
+
+	_, err := client.Get(context.Background(), server.URL+"/api/sectors?page=1", nil)
+	require.NoError(t, err)
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "eastmoney", fields["source"])
+	assert.Equal(t, server.URL+"/api/sectors?page=1", fields["url"])
+	assert.Equal(t, int64(http.StatusOK), fields["status"])
+	assert.Equal(t, int64(len(`{"data":"secret payload"}`)), fields["bytes"])
+	latency := fields["latencyMs"].(int64)
+	assert.GreaterOrEqual(t, latency, int64(20))
+	assert.Less(t, latency, int64(2000))
+	// 默认不记录响应体
+	assert.NotContains(t, fields, "bodyPreview")
+
+	stats := requestLogger.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "eastmoney", stats[0].Source)
+	assert.Equal(t, int64(1), stats[0].Count)
+	assert.GreaterOrEqual(t, stats[0].AvgMs, float64(20))
+	// 桶为累计计数，约 20ms 的请求计入 250ms 桶
+	assert.Equal(t, int64(1), stats[0].Buckets[2].Count)
+}
+
+// cyclicSource 测试用随机源，Float64 依次返回 0.1, 0.2, ..., 0.9, 0
+type cyclicSource struct{ n int }
+
+func (s *cyclicSource) Int63n(n int64) int64 { s.n++; return int64(s.n) % n }
+func (s *cyclicSource) Float64() float64     { s.n++; return float64(s.n%10) / 10 }
+
+func TestCrawlerRequestLogger_SamplingReducesLogVolume(t *testing.T) {
+	requestLogger, logs := newObservedRequestLogger(config.CrawlerRequestLogConfig{SampleRate: 0.2})
+	requestLogger.random = &cyclicSource{}
+
+	for i := 0; i < 100; i++ {
+		requestLogger.OnRequest(context.Background(), crawler.RequestRecord{Source: "ant", Status: 200, Latency: 10 * time.Millisecond})
+	}
+
+	assert.Equal(t, 20, logs.Len())
+	// 耗时统计不受采样影响
+	stats := requestLogger.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(100), stats[0].Count)
+	assert.Equal(t, int64(100), stats[0].Buckets[0].Count)
+}
+
+func TestCrawlerRequestLogger_FailuresAndSlowRequestsAlwaysLogged(t *testing.T) {
+	requestLogger, logs := newObservedRequestLogger(config.CrawlerRequestLogConfig{
+		SlowThresholdMs:  1000,
+		BodyPreviewBytes: 8,
+	})
+
+	requestLogger.OnRequest(context.Background(), crawler.RequestRecord{Source: "gold", Latency: 5 * time.Millisecond})
+	requestLogger.OnRequest(context.Background(), crawler.RequestRecord{Source: "gold", Latency: 30 * time.Second, Err: errors.New("timeout")})
+	requestLogger.OnRequest(context.Background(), crawler.RequestRecord{Source: "baidu", Status: 200, Latency: 1500 * time.Millisecond, Body: []byte("0123456789abcdef")})
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Upstream request failed", entries[0].Message)
+	assert.Equal(t, "Slow upstream request", entries[1].Message)
+	assert.Equal(t, "01234567", entries[1].ContextMap()["bodyPreview"])
+
+	stats := requestLogger.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "baidu", stats[0].Source)
+	assert.Equal(t, "gold", stats[1].Source)
+	assert.Equal(t, int64(2), stats[1].Count)
+	assert.Equal(t, int64(1), stats[1].Errors)
+	assert.Equal(t, int64(30000), stats[1].MaxMs)
+	// 超过最大桶上界的请求只计入 Count
+	assert.Equal(t, int64(1), stats[1].Buckets[len(stats[1].Buckets)-1].Count)
+}
@@ -45,14 +45,17 @@ type moduleKeywords struct {
 
 // dataMatcher 数据模块匹配器实现
 type dataMatcher struct {
-	keywordMap []moduleKeywords
+	content *ContentStore // 为 nil 时使用内置关键词
 }
 
-// NewDataMatcher 创建数据模块匹配器
+// NewDataMatcher 创建使用内置关键词的数据模块匹配器
 func NewDataMatcher() DataMatcher {
-	return &dataMatcher{
-		keywordMap: initKeywordMap(),
-	}
+	return &dataMatcher{}
+}
+
+// NewDataMatcherWithContent 创建使用可热更新关键词的数据模块匹配器
+func NewDataMatcherWithContent(content *ContentStore) DataMatcher {
+	return &dataMatcher{content: content}
 }
 
 // initKeywordMap 初始化关键词映射
@@ -159,8 +162,8 @@ func (m *dataMatcher) Match(question string) []DataModule {
 	// 存储匹配结果和匹配分数
 	matchScores := make(map[DataModule]int)
 
-	// 遍历所有模块的关键词
-	for _, mk := range m.keywordMap {
+	// 遍历所有模块的关键词（整个匹配过程使用同一份关键词快照）
+	for _, mk := range m.content.Load().keywordMap {
 		score := 0
 		for _, keyword := range mk.keywords {
 			if containsKeyword(lowerQuestion, strings.ToLower(keyword)) {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"fund-analyzer/internal/config"
 	"fund-analyzer/internal/crawler"
 
 	"go.uber.org/zap"
@@ -47,23 +50,92 @@ type DegradationService interface {
 	// AsyncRefresh 异步刷新缓存
 	// 当数据源响应缓慢时，先返回缓存数据，然后异步刷新
 	AsyncRefresh(ctx context.Context, fetcher func() (interface{}, error), cacheKey string, ttl time.Duration) (interface{}, bool, error)
+
+	// Shutdown 取消所有进行中的异步刷新，并等待其退出（最长等待到 ctx 结束）
+	Shutdown(ctx context.Context) error
 }
 
+const (
+	// DefaultFastPathTimeout AsyncRefresh 快速获取的默认超时
+	DefaultFastPathTimeout = 2 * time.Second
+	// DefaultAsyncRefreshTimeout 后台异步刷新的默认超时
+	DefaultAsyncRefreshTimeout = 30 * time.Second
+
+	// cachedAtSuffix 记录缓存写入时间的伴随键后缀，缓存值本身保持原格式，直接读取缓存的调用方不受影响
+	cachedAtSuffix = ":cached_at"
+)
+
 // degradationService 降级服务实现
 type degradationService struct {
 	cache          CacheService
 	cbManager      *crawler.CircuitBreakerManager
 	logger         *zap.Logger
 	asyncRefreshMu sync.Map // 用于防止重复的异步刷新
+
+	fastPathTimeout     time.Duration
+	fastPathTimeouts    map[string]time.Duration // 按缓存键前缀覆盖快速获取超时
+	asyncRefreshTimeout time.Duration
+
+	// maxStaleness 降级时可返回的缓存数据最大年龄，<= 0 表示不限制
+	maxStaleness time.Duration
+	now          func() time.Time
+
+	// 异步刷新的根 context，Shutdown 时取消
+	baseCtx    context.Context
+	baseCancel context.CancelFunc
+	refreshWg  sync.WaitGroup
 }
 
-// NewDegradationService 创建降级服务
+// NewDegradationService 创建降级服务（使用默认超时）
 func NewDegradationService(cache CacheService, cbManager *crawler.CircuitBreakerManager, logger *zap.Logger) DegradationService {
-	return &degradationService{
-		cache:     cache,
-		cbManager: cbManager,
-		logger:    logger,
+	return NewDegradationServiceWithConfig(cache, cbManager, logger, config.DegradationConfig{})
+}
+
+// NewDegradationServiceWithConfig 根据配置创建降级服务，未配置的超时使用默认值
+func NewDegradationServiceWithConfig(cache CacheService, cbManager *crawler.CircuitBreakerManager, logger *zap.Logger, cfg config.DegradationConfig) DegradationService {
+	baseCtx, baseCancel := context.WithCancel(context.Background())
+
+	s := &degradationService{
+		cache:               cache,
+		cbManager:           cbManager,
+		logger:              logger,
+		fastPathTimeout:     DefaultFastPathTimeout,
+		fastPathTimeouts:    make(map[string]time.Duration),
+		asyncRefreshTimeout: DefaultAsyncRefreshTimeout,
+		now:                 time.Now,
+		baseCtx:             baseCtx,
+		baseCancel:          baseCancel,
+	}
+
+	if cfg.FastPathTimeoutMs > 0 {
+		s.fastPathTimeout = time.Duration(cfg.FastPathTimeoutMs) * time.Millisecond
+	}
+	for prefix, ms := range cfg.FastPathTimeoutsMs {
+		if ms > 0 {
+			s.fastPathTimeouts[prefix] = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if cfg.AsyncRefreshTimeout > 0 {
+		s.asyncRefreshTimeout = time.Duration(cfg.AsyncRefreshTimeout) * time.Second
 	}
+	if cfg.MaxStaleness > 0 {
+		s.maxStaleness = time.Duration(cfg.MaxStaleness) * time.Second
+	}
+
+	return s
+}
+
+// fastPathTimeoutFor 获取缓存键对应的快速获取超时（最长前缀匹配）
+func (s *degradationService) fastPathTimeoutFor(cacheKey string) time.Duration {
+	timeout := s.fastPathTimeout
+	matched := -1
+	for prefix, d := range s.fastPathTimeouts {
+		if strings.HasPrefix(cacheKey, prefix) && len(prefix) > matched {
+			timeout = d
+			matched = len(prefix)
+		}
+	}
+	return timeout
 }
 
 // WithFallback 带降级的数据获取
@@ -88,7 +160,7 @@ func (s *degradationService) WithFallback(ctx context.Context, fetcher func() (i
 	)
 
 	// 3. 尝试从缓存获取降级数据
-	cachedData, cacheErr := s.getCachedData(ctx, cacheKey)
+	cachedData, cacheErr := s.getFallbackData(ctx, cacheKey)
 	if cacheErr == nil && cachedData != nil {
 		s.logger.Info("Degradation: returning cached data",
 			zap.String("cacheKey", cacheKey),
@@ -135,7 +207,7 @@ func (s *degradationService) WithCircuitBreaker(ctx context.Context, breakerName
 			zap.String("cacheKey", cacheKey),
 		)
 		// 熔断器打开，直接返回缓存数据
-		cachedData, err := s.getCachedData(ctx, cacheKey)
+		cachedData, err := s.getFallbackData(ctx, cacheKey)
 		if err == nil && cachedData != nil {
 			return cachedData, true, nil
 		}
@@ -177,7 +249,7 @@ func (s *degradationService) WithCircuitBreaker(ctx context.Context, breakerName
 	}
 
 	// 尝试返回缓存数据
-	cachedData, cacheErr := s.getCachedData(ctx, cacheKey)
+	cachedData, cacheErr := s.getFallbackData(ctx, cacheKey)
 	if cacheErr == nil && cachedData != nil {
 		s.logger.Info("Degradation: returning cached data after circuit breaker failure",
 			zap.String("breakerName", breakerName),
@@ -192,12 +264,12 @@ func (s *degradationService) WithCircuitBreaker(ctx context.Context, breakerName
 // AsyncRefresh 异步刷新缓存
 // 当数据源响应缓慢时，先返回缓存数据，然后异步刷新
 func (s *degradationService) AsyncRefresh(ctx context.Context, fetcher func() (interface{}, error), cacheKey string, ttl time.Duration) (interface{}, bool, error) {
-	// 1. 先尝试从缓存获取数据
-	cachedData, cacheErr := s.getCachedData(ctx, cacheKey)
+	// 1. 先尝试从缓存获取数据，过旧的缓存不直接返回，而是等待数据源
+	cachedData, cacheErr := s.getFallbackData(ctx, cacheKey)
 	hasCachedData := cacheErr == nil && cachedData != nil
 
 	// 2. 创建一个带超时的 context 用于快速获取
-	fastCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	fastCtx, cancel := context.WithTimeout(ctx, s.fastPathTimeoutFor(cacheKey))
 	defer cancel()
 
 	// 3. 尝试快速获取新数据
@@ -233,7 +305,7 @@ func (s *degradationService) AsyncRefresh(ctx context.Context, fetcher func() (i
 		if hasCachedData {
 			return cachedData, true, nil
 		}
-		return nil, true, ErrNoFallbackData
+		return nil, true, fmt.Errorf("%w: %w", ErrNoFallbackData, err)
 
 	case <-fastCtx.Done():
 		// 超时，返回缓存数据并启动异步刷新
@@ -266,29 +338,57 @@ func (s *degradationService) AsyncRefresh(ctx context.Context, fetcher func() (i
 
 // startAsyncRefresh 启动异步刷新
 func (s *degradationService) startAsyncRefresh(ctx context.Context, fetcher func() (interface{}, error), cacheKey string, ttl time.Duration) {
+	// 已关闭时不再启动新的刷新
+	if s.baseCtx.Err() != nil {
+		return
+	}
+
 	// 使用 sync.Map 防止重复刷新
 	if _, loaded := s.asyncRefreshMu.LoadOrStore(cacheKey, true); loaded {
 		// 已经有刷新任务在进行
 		return
 	}
 
+	s.refreshWg.Add(1)
 	go func() {
+		defer s.refreshWg.Done()
 		defer s.asyncRefreshMu.Delete(cacheKey)
 
-		// 创建新的 context，不受原 context 取消影响
-		refreshCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// 不受请求 context 取消影响，但受 Shutdown 控制
+		refreshCtx, cancel := context.WithTimeout(s.baseCtx, s.asyncRefreshTimeout)
 		defer cancel()
 
-		data, err := fetcher()
-		if err != nil {
+		type fetchResult struct {
+			data interface{}
+			err  error
+		}
+		resultChan := make(chan fetchResult, 1)
+		go func() {
+			data, err := fetcher()
+			resultChan <- fetchResult{data: data, err: err}
+		}()
+
+		var result fetchResult
+		select {
+		case result = <-resultChan:
+		case <-refreshCtx.Done():
+			// 超时或服务关闭，丢弃结果
+			s.logger.Warn("Async refresh cancelled",
+				zap.String("cacheKey", cacheKey),
+				zap.Error(refreshCtx.Err()),
+			)
+			return
+		}
+
+		if result.err != nil {
 			s.logger.Warn("Async refresh failed",
 				zap.String("cacheKey", cacheKey),
-				zap.Error(err),
+				zap.Error(result.err),
 			)
 			return
 		}
 
-		if cacheErr := s.cacheData(refreshCtx, cacheKey, data, ttl); cacheErr != nil {
+		if cacheErr := s.cacheData(refreshCtx, cacheKey, result.data, ttl); cacheErr != nil {
 			s.logger.Warn("Failed to cache async refreshed data",
 				zap.String("cacheKey", cacheKey),
 				zap.Error(cacheErr),
@@ -302,13 +402,65 @@ func (s *degradationService) startAsyncRefresh(ctx context.Context, fetcher func
 	}()
 }
 
-// cacheData 缓存数据
+// Shutdown 取消所有进行中的异步刷新
+func (s *degradationService) Shutdown(ctx context.Context) error {
+	s.baseCancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.refreshWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cacheData 缓存数据，限制降级数据年龄时同时记录写入时间
 func (s *degradationService) cacheData(ctx context.Context, key string, data interface{}, ttl time.Duration) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
-	return s.cache.Set(ctx, key, jsonData, ttl)
+	if err := s.cache.Set(ctx, key, jsonData, ttl); err != nil {
+		return err
+	}
+	if s.maxStaleness <= 0 {
+		return nil
+	}
+	return s.cache.Set(ctx, key+cachedAtSuffix, []byte(s.now().Format(time.RFC3339Nano)), ttl)
+}
+
+// getFallbackData 获取用于降级的缓存数据
+// 限制数据年龄时，超过 maxStaleness 或无法确定写入时间的缓存不可用，返回 ErrNoFallbackData
+func (s *degradationService) getFallbackData(ctx context.Context, key string) (interface{}, error) {
+	data, err := s.getCachedData(ctx, key)
+	if err != nil || s.maxStaleness <= 0 {
+		return data, err
+	}
+
+	raw, err := s.cache.Get(ctx, key+cachedAtSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cache write time unknown", ErrNoFallbackData)
+	}
+	cachedAt, err := time.Parse(time.RFC3339Nano, string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cache write time %q", ErrNoFallbackData, raw)
+	}
+
+	if age := s.now().Sub(cachedAt); age > s.maxStaleness {
+		s.logger.Warn("Cached data too stale for fallback",
+			zap.String("cacheKey", key),
+			zap.Duration("age", age),
+			zap.Duration("maxStaleness", s.maxStaleness),
+		)
+		return nil, fmt.Errorf("%w: cached data is %s old", ErrNoFallbackData, age.Round(time.Second))
+	}
+	return data, nil
 }
 
 // getCachedData 获取缓存数据
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"fund-analyzer/internal/config"
 	"fund-analyzer/internal/crawler"
 
 	"github.com/stretchr/testify/assert"
@@ -177,6 +178,59 @@ func TestDegradationService_WithCircuitBreaker_Open(t *testing.T) {
 	assert.NotNil(t, data, "should return cached data")
 }
 
+func TestDegradationService_WithCircuitBreaker_ForceOpenServesCache(t *testing.T) {
+	cache := newMockCacheService()
+	cache.data["test:key"] = []byte(`{"key":"cached_value"}`)
+	cbManager := crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig())
+	svc := NewDegradationService(cache, cbManager, zap.NewNop())
+
+	// 上游健康，但熔断器被强制打开
+	cbManager.Get("test-breaker")
+	_, err := cbManager.SetOverride("test-breaker", crawler.OverrideForceOpen)
+	require.NoError(t, err)
+
+	var calls int
+	fetcher := func() (interface{}, error) {
+		calls++
+		return map[string]string{"key": "fresh_value"}, nil
+	}
+
+	data, degraded, err := svc.WithCircuitBreaker(context.Background(), "test-breaker", fetcher, "test:key", time.Minute)
+
+	require.NoError(t, err)
+	assert.True(t, degraded)
+	assert.Equal(t, map[string]interface{}{"key": "cached_value"}, data)
+	assert.Equal(t, 0, calls, "forced-open breaker must not hit upstream")
+}
+
+func TestDegradationService_WithCircuitBreaker_ForceClosedFetchesImmediately(t *testing.T) {
+	cache := newMockCacheService()
+	cache.data["test:key"] = []byte(`{"key":"cached_value"}`)
+	cbManager := crawler.NewCircuitBreakerManager(crawler.CircuitBreakerConfig{
+		MaxFailures:     1,
+		Timeout:         time.Hour,
+		HalfOpenMaxReqs: 1,
+	})
+	svc := NewDegradationService(cache, cbManager, zap.NewNop())
+
+	cb := cbManager.Get("test-breaker")
+	_ = cb.Execute(func() error { return errors.New("failure") })
+	require.Equal(t, crawler.StateOpen, cb.State())
+
+	// 上游已恢复，无需等待半开探测
+	_, err := cbManager.SetOverride("test-breaker", crawler.OverrideForceClosed)
+	require.NoError(t, err)
+
+	fresh := map[string]string{"key": "fresh_value"}
+	data, degraded, err := svc.WithCircuitBreaker(context.Background(), "test-breaker", func() (interface{}, error) {
+		return fresh, nil
+	}, "test:key", time.Minute)
+
+	require.NoError(t, err)
+	assert.False(t, degraded)
+	assert.Equal(t, fresh, data)
+}
+
 func TestDegradationService_WithCircuitBreaker_Closed(t *testing.T) {
 	// 测试熔断器关闭时正常获取数据
 	cache := newMockCacheService()
@@ -321,3 +375,209 @@ func TestDegradationService_ConcurrentAsyncRefresh(t *testing.T) {
 	// 但可以确保不会被调用太多次
 	assert.LessOrEqual(t, atomic.LoadInt32(&fetcherCallCount), int32(5))
 }
+
+func TestDegradationService_FastPathTimeoutFor(t *testing.T) {
+	// 测试按缓存键前缀的快速获取超时配置
+	cache := newMockCacheService()
+	cbManager := crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig())
+
+	svc := NewDegradationServiceWithConfig(cache, cbManager, zap.NewNop(), config.DegradationConfig{
+		FastPathTimeoutMs: 500,
+		FastPathTimeoutsMs: map[string]int{
+			"market:":        300,
+			"market:minute:": 100,
+		},
+		AsyncRefreshTimeout: 10,
+	}).(*degradationService)
+
+	assert.Equal(t, 500*time.Millisecond, svc.fastPathTimeoutFor("fund:info:000001"))
+	assert.Equal(t, 300*time.Millisecond, svc.fastPathTimeoutFor("market:gold:history"))
+	assert.Equal(t, 100*time.Millisecond, svc.fastPathTimeoutFor("market:minute:sh"))
+	assert.Equal(t, 10*time.Second, svc.asyncRefreshTimeout)
+
+	defaults := NewDegradationService(cache, cbManager, zap.NewNop()).(*degradationService)
+	assert.Equal(t, DefaultFastPathTimeout, defaults.fastPathTimeoutFor("market:indices"))
+	assert.Equal(t, DefaultAsyncRefreshTimeout, defaults.asyncRefreshTimeout)
+}
+
+func TestDegradationService_AsyncRefresh_ConfiguredFastPathTimeout(t *testing.T) {
+	// 测试配置的快速获取超时生效：慢于配置超时即返回缓存
+	cache := newMockCacheService()
+	cache.data["market:minute:sh"] = []byte(`{"key":"cached_value"}`)
+
+	cbManager := crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig())
+	svc := NewDegradationServiceWithConfig(cache, cbManager, zap.NewNop(), config.DegradationConfig{
+		FastPathTimeoutsMs: map[string]int{"market:minute:": 50},
+	})
+	defer func() { _ = svc.Shutdown(context.Background()) }()
+
+	fetcher := func() (interface{}, error) {
+		time.Sleep(300 * time.Millisecond)
+		return map[string]string{"key": "fresh_value"}, nil
+	}
+
+	start := time.Now()
+	data, degraded, err := svc.AsyncRefresh(context.Background(), fetcher, "market:minute:sh", time.Minute)
+
+	require.NoError(t, err)
+	assert.True(t, degraded)
+	assert.Equal(t, map[string]interface{}{"key": "cached_value"}, data)
+	assert.Less(t, time.Since(start), 250*time.Millisecond, "should not wait for the default 2s fast path")
+}
+
+func TestDegradationService_AsyncRefresh_RefreshTimeoutDiscardsResult(t *testing.T) {
+	// 测试异步刷新超时后丢弃结果，不写入缓存
+	cache := newMockCacheService()
+	cache.data["test:key"] = []byte(`{"key":"cached_value"}`)
+
+	cbManager := crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig())
+	svc := NewDegradationServiceWithConfig(cache, cbManager, zap.NewNop(), config.DegradationConfig{
+		FastPathTimeoutMs: 20,
+	}).(*degradationService)
+	svc.asyncRefreshTimeout = 50 * time.Millisecond
+
+	fetcher := func() (interface{}, error) {
+		time.Sleep(300 * time.Millisecond)
+		return map[string]string{"key": "fresh_value"}, nil
+	}
+
+	_, degraded, err := svc.AsyncRefresh(context.Background(), fetcher, "test:key", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, degraded)
+
+	// 刷新超时后 goroutine 应退出
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.NoError(t, svc.Shutdown(ctx))
+
+	assert.Equal(t, 0, cache.setCalled, "timed out refresh should not update cache")
+	assert.JSONEq(t, `{"key":"cached_value"}`, string(cache.data["test:key"]))
+}
+
+func TestDegradationService_Shutdown_CancelsPendingRefresh(t *testing.T) {
+	// 测试关闭时取消进行中的异步刷新
+	cache := newMockCacheService()
+	cache.data["test:key"] = []byte(`{"key":"cached_value"}`)
+
+	cbManager := crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig())
+	svc := NewDegradationServiceWithConfig(cache, cbManager, zap.NewNop(), config.DegradationConfig{
+		FastPathTimeoutMs: 20,
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	fetcher := func() (interface{}, error) {
+		<-release
+		return map[string]string{"key": "fresh_value"}, nil
+	}
+
+	_, degraded, err := svc.AsyncRefresh(context.Background(), fetcher, "test:key", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, degraded)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	require.NoError(t, svc.Shutdown(ctx))
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "shutdown should cancel refresh promptly")
+	assert.Equal(t, 0, cache.setCalled)
+
+	// 关闭后不再启动新的异步刷新
+	var calls int32
+	slow := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil, nil
+	}
+	_, _, _ = svc.AsyncRefresh(context.Background(), slow, "test:key", time.Minute)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "only the fast-path fetch should run after shutdown")
+}
+
+// newStalenessTestService 创建限制降级数据年龄为 10 分钟的降级服务，时间由 now 控制
+func newStalenessTestService(cache CacheService, now *time.Time) *degradationService {
+	cbManager := crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig())
+	svc := NewDegradationServiceWithConfig(cache, cbManager, zap.NewNop(), config.DegradationConfig{
+		MaxStaleness: 600,
+	}).(*degradationService)
+	svc.now = func() time.Time { return *now }
+	return svc
+}
+
+func TestDegradationService_WithFallback_ServesCacheWithinMaxStaleness(t *testing.T) {
+	cache := newMockCacheService()
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc := newStalenessTestService(cache, &now)
+	ctx := context.Background()
+
+	_, _, err := svc.WithFallback(ctx, func() (interface{}, error) {
+		return map[string]string{"key": "cached_value"}, nil
+	}, "test:key", time.Hour)
+	require.NoError(t, err)
+	assert.Contains(t, cache.data, "test:key"+cachedAtSuffix)
+
+	now = now.Add(9 * time.Minute)
+	data, degraded, err := svc.WithFallback(ctx, func() (interface{}, error) {
+		return nil, errors.New("data source unavailable")
+	}, "test:key", time.Hour)
+
+	require.NoError(t, err)
+	assert.True(t, degraded)
+	assert.Equal(t, map[string]interface{}{"key": "cached_value"}, data)
+}
+
+func TestDegradationService_WithFallback_RejectsTooStaleCache(t *testing.T) {
+	cache := newMockCacheService()
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc := newStalenessTestService(cache, &now)
+	ctx := context.Background()
+	failing := func() (interface{}, error) {
+		return nil, errors.New("data source unavailable")
+	}
+
+	_, _, err := svc.WithFallback(ctx, func() (interface{}, error) {
+		return map[string]string{"key": "cached_value"}, nil
+	}, "test:key", time.Hour)
+	require.NoError(t, err)
+
+	now = now.Add(11 * time.Minute)
+	data, degraded, err := svc.WithFallback(ctx, failing, "test:key", time.Hour)
+	assert.ErrorIs(t, err, ErrNoFallbackData)
+	assert.True(t, degraded)
+	assert.Nil(t, data)
+
+	// 熔断打开时同样不返回过旧的缓存
+	svc.cbManager.Get("test")
+	_, err = svc.cbManager.SetOverride("test", crawler.OverrideForceOpen)
+	require.NoError(t, err)
+	_, _, err = svc.WithCircuitBreaker(ctx, "test", failing, "test:key", time.Hour)
+	assert.ErrorIs(t, err, ErrNoFallbackData)
+
+	// 没有写入时间的缓存年龄未知，视为不可用
+	cache.data["legacy:key"] = []byte(`{"key":"legacy_value"}`)
+	_, _, err = svc.WithFallback(ctx, failing, "legacy:key", time.Hour)
+	assert.ErrorIs(t, err, ErrNoFallbackData)
+}
+
+func TestDegradationService_AsyncRefresh_WaitsForSourceWhenCacheTooStale(t *testing.T) {
+	cache := newMockCacheService()
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc := newStalenessTestService(cache, &now)
+	svc.fastPathTimeout = 10 * time.Millisecond
+	ctx := context.Background()
+
+	_, _, err := svc.WithFallback(ctx, func() (interface{}, error) {
+		return map[string]string{"key": "cached_value"}, nil
+	}, "test:key", time.Hour)
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	data, degraded, err := svc.AsyncRefresh(ctx, func() (interface{}, error) {
+		time.Sleep(50 * time.Millisecond)
+		return map[string]string{"key": "fresh_value"}, nil
+	}, "test:key", time.Hour)
+
+	require.NoError(t, err)
+	assert.False(t, degraded)
+	assert.Equal(t, map[string]string{"key": "fresh_value"}, data)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+)
+
+// defaultDisclaimers 内置风险提示，按回复语言选择
+var defaultDisclaimers = map[Language]string{
+	LanguageChinese: "以上内容由 AI 生成，仅供参考，不构成投资建议。市场有风险，投资需谨慎。",
+	LanguageEnglish: "This content is AI-generated for reference only and does not constitute investment advice. Investing involves risk.",
+}
+
+// disclaimerMarkers 模型回复中表明已包含风险提示的关键语句
+var disclaimerMarkers = []string{
+	"不构成投资建议",
+	"不构成任何投资建议",
+	"投资需谨慎",
+	"入市需谨慎",
+	"not investment advice",
+	"does not constitute investment advice",
+	"not constitute financial advice",
+}
+
+// disclaimer AI 输出结尾追加的风险提示
+// 零值表示不追加
+type disclaimer struct {
+	texts map[Language]string
+}
+
+// newDisclaimer 根据配置创建风险提示，配置的文本覆盖同语言的内置文本
+func newDisclaimer(cfg config.DisclaimerConfig) disclaimer {
+	if !cfg.Enabled {
+		return disclaimer{}
+	}
+
+	texts := make(map[Language]string, len(defaultDisclaimers))
+	for lang, text := range defaultDisclaimers {
+		texts[lang] = text
+	}
+	for lang, text := range cfg.Texts {
+		if text = strings.TrimSpace(text); text != "" {
+			texts[Language(strings.ToLower(lang))] = text
+		}
+	}
+	return disclaimer{texts: texts}
+}
+
+// footer 获取指定语言的风险提示，未配置的语言使用中文
+func (d disclaimer) footer(lang Language) string {
+	if text, ok := d.texts[lang]; ok {
+		return text
+	}
+	return d.texts[LanguageChinese]
+}
+
+// append 模型输出未包含风险提示时，追加风险提示作为最后一个内容块
+func (d disclaimer) append(stream chan<- model.ChatChunk, lang Language, content string) {
+	if suffix := d.suffix(lang, content); suffix != "" {
+		stream <- model.ChatChunk{
+			Type:  model.ChunkTypeContent,
+			Chunk: suffix,
+		}
+	}
+}
+
+// suffix 获取需追加在模型输出之后的风险提示，输出已包含或未配置时为空
+func (d disclaimer) suffix(lang Language, content string) string {
+	footer := d.footer(lang)
+	if footer == "" || containsDisclaimer(content, footer) {
+		return ""
+	}
+	return "\n\n" + footer
+}
+
+// containsDisclaimer 判断内容是否已包含风险提示（忽略空白、标点与大小写）
+func containsDisclaimer(content, footer string) bool {
+	normalized := normalizeDisclaimerText(content)
+	if normalized == "" {
+		return false
+	}
+	if strings.Contains(normalized, normalizeDisclaimerText(footer)) {
+		return true
+	}
+	for _, marker := range disclaimerMarkers {
+		if strings.Contains(normalized, normalizeDisclaimerText(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDisclaimerText 去除空白与标点并转为小写
+func normalizeDisclaimerText(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
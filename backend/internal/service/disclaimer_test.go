@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDisclaimer = "测试风险提示：本分析仅供参考。"
+
+// newDisclaimerAIService 创建配置了风险提示的 AI 服务
+func newDisclaimerAIService(t *testing.T, responses ...[]string) *aiService {
+	t.Helper()
+	svc, _ := newStreamingAIService(t, responses...)
+	svc.ddgCrawler = fakeSearchCrawler{}
+	svc.disclaimer = newDisclaimer(config.DisclaimerConfig{
+		Enabled: true,
+		Texts:   map[string]string{"zh": testDisclaimer, "EN": "Test disclaimer: for reference only."},
+	})
+	return svc
+}
+
+// lastContent 返回最后一个 content 块，并校验其后只有 done
+func lastContent(t *testing.T, chunks []model.ChatChunk) string {
+	t.Helper()
+	require.GreaterOrEqual(t, len(chunks), 2)
+	require.Equal(t, model.ChunkTypeDone, chunks[len(chunks)-1].Type)
+	last := chunks[len(chunks)-2]
+	require.Equal(t, model.ChunkTypeContent, last.Type)
+	return strings.TrimSpace(last.Chunk)
+}
+
+func TestDisclaimer_AppendedAsFinalContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses [][]string
+		run       func(svc *aiService, stream chan<- model.ChatChunk) error
+	}{
+		{"standard", [][]string{{contentChunk}}, func(svc *aiService, stream chan<- model.ChatChunk) error {
+			return svc.AnalyzeStandard(context.Background(), &model.MarketData{}, stream)
+		}},
+		{"fast", [][]string{{contentChunk}}, func(svc *aiService, stream chan<- model.ChatChunk) error {
+			return svc.AnalyzeFast(context.Background(), &model.MarketData{}, stream)
+		}},
+		{"deep", [][]string{{toolCallChunk}, {contentChunk}}, func(svc *aiService, stream chan<- model.ChatChunk) error {
+			return svc.AnalyzeDeep(context.Background(), &model.MarketData{}, stream)
+		}},
+		{"chat", [][]string{{contentChunk}}, func(svc *aiService, stream chan<- model.ChatChunk) error {
+			return svc.Chat(context.Background(), &model.ChatRequest{Message: "今天大盘怎么样"}, stream)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newDisclaimerAIService(t, tt.responses...)
+
+			chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+				return tt.run(svc, stream)
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, testDisclaimer, lastContent(t, chunks))
+			assert.Equal(t, 1, strings.Count(chunkContent(chunks), testDisclaimer))
+		})
+	}
+}
+
+func TestDisclaimer_ChatUsesReplyLanguage(t *testing.T) {
+	svc := newDisclaimerAIService(t, []string{contentChunk})
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.Chat(context.Background(), &model.ChatRequest{Message: "How is the market today?"}, stream)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Test disclaimer: for reference only.", lastContent(t, chunks))
+}
+
+func TestDisclaimer_NotDuplicatedWhenModelIncludesIt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"exact footer", "今天市场平稳。\n\n" + testDisclaimer},
+		{"footer with different punctuation", "今天市场平稳。测试风险提示 本分析仅供参考"},
+		{"similar wording", "今天市场平稳。以上分析不构成投资建议，请注意风险。"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newDisclaimerAIService(t, []string{`{"choices":[{"delta":{"content":` + quoteJSON(tt.content) + `}}]}`})
+
+			chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+				return svc.AnalyzeStandard(context.Background(), &model.MarketData{}, stream)
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, chunkContent(chunks))
+		})
+	}
+}
+
+func TestDisclaimer_NotAppendedOnError(t *testing.T) {
+	svc := newDisclaimerAIService(t, []string{toolCallChunk})
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.Chat(context.Background(), &model.ChatRequest{Message: "你好"}, stream)
+	})
+
+	assert.ErrorIs(t, err, ErrUnexpectedToolCalls)
+	assert.NotContains(t, chunkContent(chunks), testDisclaimer)
+}
+
+func TestNewDisclaimer(t *testing.T) {
+	disabled := newDisclaimer(config.DisclaimerConfig{Texts: map[string]string{"zh": testDisclaimer}})
+	assert.Empty(t, disabled.footer(LanguageChinese))
+
+	builtin := newDisclaimer(config.DisclaimerConfig{Enabled: true})
+	assert.Equal(t, defaultDisclaimers[LanguageChinese], builtin.footer(LanguageChinese))
+	assert.Equal(t, defaultDisclaimers[LanguageEnglish], builtin.footer(LanguageEnglish))
+	assert.Equal(t, defaultDisclaimers[LanguageChinese], builtin.footer(Language("fr")), "unknown language falls back to chinese")
+}
+
+func quoteJSON(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -1,11 +1,18 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"fund-analyzer/internal/config"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
+// ErrEmailMisconfigured 邮件服务配置不完整，验证码邮件无法发送
+var ErrEmailMisconfigured = errors.New("email misconfigured")
+
 // NewEmailService 根据配置创建邮件服务
 // 支持两种类型:
 // - "smtp": 使用 SMTP 协议（推荐，适用于阿里云邮件推送）
@@ -35,3 +42,39 @@ func newDirectMailService(cfg config.EmailConfig) EmailService {
 		},
 	}
 }
+
+// ValidateEmailConfig 检查所选邮件服务类型的必填配置是否完整
+func ValidateEmailConfig(cfg config.EmailConfig) error {
+	var required map[string]string
+	switch cfg.Type {
+	case "api":
+		required = map[string]string{
+			"access_key_id":     cfg.AccessKeyID,
+			"access_key_secret": cfg.AccessKeySecret,
+			"account_name":      cfg.AccountName,
+		}
+	case "smtp", "":
+		required = map[string]string{
+			"smtp_host":     cfg.SMTPHost,
+			"smtp_username": cfg.SMTPUsername,
+			"smtp_password": cfg.SMTPPassword,
+		}
+		if cfg.SMTPPort <= 0 || cfg.SMTPPort > 65535 {
+			return fmt.Errorf("%w: invalid smtp_port %d", ErrEmailMisconfigured, cfg.SMTPPort)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrEmailMisconfigured, cfg.Type)
+	}
+
+	var missing []string
+	for name, value := range required {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: missing %s", ErrEmailMisconfigured, strings.Join(missing, ", "))
+	}
+	return nil
+}
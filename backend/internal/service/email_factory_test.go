@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEmailConfig(t *testing.T) {
+	smtp := config.EmailConfig{Type: "smtp", SMTPHost: "smtp.example.com", SMTPPort: 465, SMTPUsername: "bot@example.com", SMTPPassword: "secret"}
+	api := config.EmailConfig{Type: "api", AccessKeyID: "id", AccessKeySecret: "secret", AccountName: "bot@example.com"}
+
+	tests := []struct {
+		name    string
+		mutate  func(c config.EmailConfig) config.EmailConfig
+		base    config.EmailConfig
+		missing string
+	}{
+		{"valid smtp", func(c config.EmailConfig) config.EmailConfig { return c }, smtp, ""},
+		{"default type is smtp", func(c config.EmailConfig) config.EmailConfig { c.Type = ""; return c }, smtp, ""},
+		{"valid api", func(c config.EmailConfig) config.EmailConfig { return c }, api, ""},
+		{"smtp without password", func(c config.EmailConfig) config.EmailConfig { c.SMTPPassword = " "; return c }, smtp, "smtp_password"},
+		{"smtp invalid port", func(c config.EmailConfig) config.EmailConfig { c.SMTPPort = 0; return c }, smtp, "smtp_port"},
+		{"api without account", func(c config.EmailConfig) config.EmailConfig { c.AccountName = ""; return c }, api, "account_name"},
+		{"unknown type", func(c config.EmailConfig) config.EmailConfig { c.Type = "sendgrid"; return c }, smtp, "sendgrid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEmailConfig(tt.mutate(tt.base))
+			if tt.missing == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrEmailMisconfigured)
+			assert.Contains(t, err.Error(), tt.missing)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+)
+
+// buildNotificationEmail 构建通用通知邮件的标题与 HTML 正文，正文按纯文本转义并保留换行
+func buildNotificationEmail(notification model.Notification) (subject, body string) {
+	subject = fmt.Sprintf("%s - 基金分析助手", notification.Title)
+	text := strings.ReplaceAll(html.EscapeString(notification.Body), "\n", "<br>")
+
+	body = fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px;">
+	<h2 style="color: #333;">%s</h2>
+	<p style="color: #333; line-height: 1.6;">%s</p>
+	<p style="color: #999; font-size: 12px;">可在应用的通知设置中调整免打扰时段，免打扰期间的通知将在结束后合并发送。</p>
+</body>
+</html>`, html.EscapeString(notification.Title), text)
+	return subject, body
+}
+
+// emailNotifier 通过邮件发送通知，收件地址为用户的注册邮箱
+type emailNotifier struct {
+	userRepo     repository.UserRepository
+	emailService EmailService
+}
+
+// NewEmailNotifier 创建邮件通知渠道
+func NewEmailNotifier(userRepo repository.UserRepository, emailService EmailService) Notifier {
+	return &emailNotifier{
+		userRepo:     userRepo,
+		emailService: emailService,
+	}
+}
+
+func (n *emailNotifier) Send(ctx context.Context, notification model.Notification) error {
+	user, err := n.userRepo.GetUserByID(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+	return n.emailService.SendNotification(ctx, user.Email, notification)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+)
+
+// SecurityEventType 安全提醒事件类型
+type SecurityEventType string
+
+const (
+	// SecurityEventAccountLocked 连续登录失败导致账号被临时锁定
+	SecurityEventAccountLocked SecurityEventType = "account_locked"
+)
+
+// securityAlertTimeout 异步发送安全提醒邮件的超时
+const securityAlertTimeout = 30 * time.Second
+
+// SecurityEvent 安全提醒邮件的内容
+type SecurityEvent struct {
+	Type        SecurityEventType
+	OccurredAt  time.Time // 事件发生时间
+	Attempts    int       // 连续登录失败次数
+	LockedUntil time.Time // 锁定截止时间
+}
+
+// buildSecurityAlertEmail 构建安全提醒邮件的标题与 HTML 正文
+func buildSecurityAlertEmail(event SecurityEvent) (subject, body string) {
+	subject = "账号安全提醒 - 基金分析助手"
+
+	var message string
+	switch event.Type {
+	case SecurityEventAccountLocked:
+		message = fmt.Sprintf("您的账号在 <strong>%s</strong> 前后连续 <strong>%d</strong> 次登录失败，已被临时锁定至 <strong>%s</strong>。",
+			formatAlertTime(event.OccurredAt), event.Attempts, formatAlertTime(event.LockedUntil))
+	default:
+		message = fmt.Sprintf("您的账号在 <strong>%s</strong> 前后出现异常活动。", formatAlertTime(event.OccurredAt))
+	}
+
+	body = fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px;">
+	<h2 style="color: #333;">账号安全提醒</h2>
+	<p>%s</p>
+	<p>如果这不是您本人的操作，说明可能有人在尝试登录您的账号，建议尽快通过"忘记密码"重置密码。</p>
+	<p style="color: #999; font-size: 12px;">如果是您本人多次输错密码，可忽略此邮件，锁定到期后即可重新登录。</p>
+</body>
+</html>`, message)
+	return subject, body
+}
+
+// formatAlertTime 格式化邮件中的时间（北京时间，精确到分钟）
+func formatAlertTime(t time.Time) string {
+	return t.In(crawler.ShanghaiLocation).Format("2006-01-02 15:04") + "（北京时间）"
+}
@@ -16,12 +16,17 @@ import (
 	"time"
 
 	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
 )
 
 // EmailService 邮件服务接口
 type EmailService interface {
 	SendVerificationCode(ctx context.Context, email, code string) error
 	SendPasswordResetCode(ctx context.Context, email, code string) error
+	// SendSecurityAlert 发送账号安全提醒，如连续登录失败导致账号被锁定
+	SendSecurityAlert(ctx context.Context, email string, event SecurityEvent) error
+	// SendNotification 发送通用通知，如免打扰结束后的通知摘要
+	SendNotification(ctx context.Context, email string, notification model.Notification) error
 }
 
 type emailService struct {
@@ -79,6 +84,16 @@ func (s *emailService) SendPasswordResetCode(ctx context.Context, email, code st
 	return s.sendEmail(ctx, email, subject, body)
 }
 
+func (s *emailService) SendSecurityAlert(ctx context.Context, email string, event SecurityEvent) error {
+	subject, body := buildSecurityAlertEmail(event)
+	return s.sendEmail(ctx, email, subject, body)
+}
+
+func (s *emailService) SendNotification(ctx context.Context, email string, notification model.Notification) error {
+	subject, body := buildNotificationEmail(notification)
+	return s.sendEmail(ctx, email, subject, body)
+}
+
 // sendEmail 发送邮件（阿里云邮件推送服务）
 func (s *emailService) sendEmail(ctx context.Context, to, subject, body string) error {
 	// 如果未配置阿里云，使用开发模式
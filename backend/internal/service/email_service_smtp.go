@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
 )
 
 // SMTPEmailService SMTP 邮件服务实现
@@ -62,6 +63,16 @@ func (s *SMTPEmailService) SendPasswordResetCode(ctx context.Context, email, cod
 	return s.sendEmail(ctx, email, subject, body)
 }
 
+func (s *SMTPEmailService) SendSecurityAlert(ctx context.Context, email string, event SecurityEvent) error {
+	subject, body := buildSecurityAlertEmail(event)
+	return s.sendEmail(ctx, email, subject, body)
+}
+
+func (s *SMTPEmailService) SendNotification(ctx context.Context, email string, notification model.Notification) error {
+	subject, body := buildNotificationEmail(notification)
+	return s.sendEmail(ctx, email, subject, body)
+}
+
 // sendEmail 通过 SMTP 发送邮件
 func (s *SMTPEmailService) sendEmail(ctx context.Context, to, subject, htmlBody string) error {
 	// 开发模式：如果未配置 SMTP，只打印日志
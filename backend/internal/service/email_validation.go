@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"fund-analyzer/internal/config"
+
+	"golang.org/x/net/idna"
+)
+
+// 邮箱格式校验严格程度
+const (
+	EmailStrictnessBasic    = "basic"    // 简单正则，只接受 ASCII 地址
+	EmailStrictnessStandard = "standard" // 按 RFC 5322 解析，支持国际化域名
+	EmailStrictnessStrict   = "strict"   // 在 standard 基础上要求域名存在 MX 记录
+)
+
+// MX 查询默认参数
+const (
+	DefaultMXLookupTimeout = 3 * time.Second
+	DefaultMXCacheTTL      = time.Hour
+)
+
+// 邮箱长度限制（RFC 5321）
+const (
+	maxEmailLocalLength = 64
+	maxEmailLength      = 254
+)
+
+// basicEmailPattern basic 模式使用的正则
+var basicEmailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// domainLabelPattern 转换为 ASCII 后的域名标签
+var domainLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// mxResult 缓存的 MX 查询结果
+type mxResult struct {
+	ok        bool
+	expiresAt time.Time
+}
+
+// EmailValidator 按配置的严格程度校验邮箱格式，strict 模式查询并缓存域名的 MX 记录
+type EmailValidator struct {
+	strictness string
+	timeout    time.Duration
+	cacheTTL   time.Duration
+	lookupMX   func(ctx context.Context, domain string) ([]*net.MX, error)
+	now        func() time.Time
+
+	mu      sync.Mutex
+	mxCache map[string]mxResult
+}
+
+// DefaultEmailValidator 默认邮箱校验：basic 模式
+func DefaultEmailValidator() *EmailValidator {
+	return newEmailValidator(EmailStrictnessBasic, DefaultMXLookupTimeout, DefaultMXCacheTTL)
+}
+
+// NewEmailValidator 根据配置创建邮箱校验器，未设置的项使用默认值
+func NewEmailValidator(cfg config.EmailValidationConfig) (*EmailValidator, error) {
+	strictness := strings.ToLower(strings.TrimSpace(cfg.Strictness))
+	switch strictness {
+	case "":
+		strictness = EmailStrictnessBasic
+	case EmailStrictnessBasic, EmailStrictnessStandard, EmailStrictnessStrict:
+	default:
+		return nil, fmt.Errorf("unknown email validation strictness %q, supported: basic, standard, strict", cfg.Strictness)
+	}
+
+	timeout := time.Duration(cfg.MXLookupTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultMXLookupTimeout
+	}
+	cacheTTL := time.Duration(cfg.MXCacheTTL) * time.Second
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultMXCacheTTL
+	}
+	return newEmailValidator(strictness, timeout, cacheTTL), nil
+}
+
+func newEmailValidator(strictness string, timeout, cacheTTL time.Duration) *EmailValidator {
+	return &EmailValidator{
+		strictness: strictness,
+		timeout:    timeout,
+		cacheTTL:   cacheTTL,
+		lookupMX:   net.DefaultResolver.LookupMX,
+		now:        time.Now,
+		mxCache:    make(map[string]mxResult),
+	}
+}
+
+// ValidateEmail 验证邮箱格式（basic 模式）
+func ValidateEmail(email string) bool {
+	return basicEmailPattern.MatchString(email)
+}
+
+// Validate 校验邮箱，无效时返回包装了原因的 ErrInvalidEmail
+func (v *EmailValidator) Validate(ctx context.Context, email string) error {
+	if email == "" {
+		return fmt.Errorf("%w: empty address", ErrInvalidEmail)
+	}
+	if v.strictness == EmailStrictnessBasic {
+		if !ValidateEmail(email) {
+			return fmt.Errorf("%w: %q does not match the allowed pattern", ErrInvalidEmail, email)
+		}
+		return nil
+	}
+
+	domain, err := parseEmailDomain(email)
+	if err != nil {
+		return err
+	}
+	if v.strictness == EmailStrictnessStrict && !v.hasMX(ctx, domain) {
+		return fmt.Errorf("%w: domain %q has no MX record", ErrInvalidEmail, domain)
+	}
+	return nil
+}
+
+// parseEmailDomain 按 RFC 5322 解析邮箱并校验长度与域名，返回 ASCII 形式的域名
+func parseEmailDomain(email string) (string, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", fmt.Errorf("%w: malformed address %q", ErrInvalidEmail, email)
+	}
+
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], email[at+1:]
+	if len(local) > maxEmailLocalLength {
+		return "", fmt.Errorf("%w: local part longer than %d bytes", ErrInvalidEmail, maxEmailLocalLength)
+	}
+
+	// 国际化域名转换为 Punycode 后校验
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid domain %q", ErrInvalidEmail, domain)
+	}
+	if len(local)+1+len(ascii) > maxEmailLength {
+		return "", fmt.Errorf("%w: address longer than %d bytes", ErrInvalidEmail, maxEmailLength)
+	}
+
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("%w: domain %q has no top-level domain", ErrInvalidEmail, domain)
+	}
+	for _, label := range labels {
+		if len(label) > 63 || !domainLabelPattern.MatchString(label) {
+			return "", fmt.Errorf("%w: invalid domain %q", ErrInvalidEmail, domain)
+		}
+	}
+	if tld := labels[len(labels)-1]; len(tld) < 2 || strings.Trim(tld, "0123456789") == "" {
+		return "", fmt.Errorf("%w: invalid top-level domain %q", ErrInvalidEmail, tld)
+	}
+	return ascii, nil
+}
+
+// hasMX 域名是否存在可用的 MX 记录，结果按域名缓存
+// 域名不存在或没有 MX（含 RFC 7505 空 MX）视为无效；超时等临时错误不拒绝注册，也不缓存
+func (v *EmailValidator) hasMX(ctx context.Context, domain string) bool {
+	now := v.now()
+	v.mu.Lock()
+	cached, ok := v.mxCache[domain]
+	v.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.ok
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+	records, err := v.lookupMX(lookupCtx, domain)
+
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return true
+	}
+	valid := err == nil && len(records) > 0 && !(len(records) == 1 && records[0].Host == ".")
+
+	v.mu.Lock()
+	v.mxCache[domain] = mxResult{ok: valid, expiresAt: now.Add(v.cacheTTL)}
+	v.mu.Unlock()
+	return valid
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMXLookup 按域名返回预设的 MX 记录，记录查询次数
+type fakeMXLookup struct {
+	records map[string][]*net.MX
+	err     error
+	calls   map[string]int
+}
+
+func (f *fakeMXLookup) lookup(ctx context.Context, domain string) ([]*net.MX, error) {
+	f.calls[domain]++
+	if f.err != nil {
+		return nil, f.err
+	}
+	records, ok := f.records[domain]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func newTestEmailValidator(t *testing.T, strictness string) *EmailValidator {
+	t.Helper()
+	v, err := NewEmailValidator(config.EmailValidationConfig{Strictness: strictness})
+	require.NoError(t, err)
+	return v
+}
+
+func TestEmailValidator_Basic(t *testing.T) {
+	v := DefaultEmailValidator()
+	ctx := context.Background()
+
+	assert.NoError(t, v.Validate(ctx, "user+fund@example.com"))
+	assert.ErrorIs(t, v.Validate(ctx, "用户@例子.中国"), ErrInvalidEmail, "basic mode only accepts ASCII addresses")
+	assert.ErrorIs(t, v.Validate(ctx, "not-an-email"), ErrInvalidEmail)
+	assert.ErrorIs(t, v.Validate(ctx, ""), ErrInvalidEmail)
+}
+
+func TestEmailValidator_Standard(t *testing.T) {
+	v := newTestEmailValidator(t, EmailStrictnessStandard)
+	ctx := context.Background()
+
+	for _, email := range []string{
+		"user+fund@example.com",
+		"first.last@mail.example.co",
+		"user@例子.中国",
+		"user@xn--fsqu00a.xn--fiqs8s",
+	} {
+		assert.NoError(t, v.Validate(ctx, email), email)
+	}
+
+	tests := []struct {
+		email  string
+		reason string
+	}{
+		{"not-an-email", "malformed address"},
+		{"User <user@example.com>", "malformed address"},
+		{"user@@example.com", "malformed address"},
+		{"user@localhost", "no top-level domain"},
+		{"user@-example.com", "invalid domain"},
+		{"user@example.123", "invalid top-level domain"},
+		{"user@192.168.1.1", "invalid top-level domain"},
+		{"this-local-part-is-way-too-long-to-be-accepted-by-any-mail-server-x@example.com", "local part longer than 64 bytes"},
+	}
+	for _, tt := range tests {
+		err := v.Validate(ctx, tt.email)
+		require.ErrorIs(t, err, ErrInvalidEmail, tt.email)
+		assert.Contains(t, err.Error(), tt.reason, tt.email)
+	}
+}
+
+func TestEmailValidator_StrictChecksAndCachesMX(t *testing.T) {
+	v := newTestEmailValidator(t, EmailStrictnessStrict)
+	lookup := &fakeMXLookup{
+		records: map[string][]*net.MX{
+			"example.com":            {{Host: "mx.example.com.", Pref: 10}},
+			"xn--fsqu00a.xn--fiqs8s": {{Host: "mx.xn--fsqu00a.xn--fiqs8s.", Pref: 10}},
+			"nullmx.example":         {{Host: ".", Pref: 0}},
+		},
+		calls: make(map[string]int),
+	}
+	v.lookupMX = lookup.lookup
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	v.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.NoError(t, v.Validate(ctx, "user+fund@example.com"))
+	assert.NoError(t, v.Validate(ctx, "user@例子.中国"))
+
+	err := v.Validate(ctx, "user@no-mail.example")
+	require.ErrorIs(t, err, ErrInvalidEmail)
+	assert.Contains(t, err.Error(), "no MX record")
+	assert.ErrorIs(t, v.Validate(ctx, "user@nullmx.example"), ErrInvalidEmail, "null MX accepts no mail")
+
+	// 格式无效时不查询 MX
+	assert.ErrorIs(t, v.Validate(ctx, "not-an-email"), ErrInvalidEmail)
+
+	// 缓存有效期内不重复查询，过期后重新查询
+	assert.NoError(t, v.Validate(ctx, "other@example.com"))
+	assert.ErrorIs(t, v.Validate(ctx, "other@no-mail.example"), ErrInvalidEmail)
+	assert.Equal(t, 1, lookup.calls["example.com"])
+	assert.Equal(t, 1, lookup.calls["no-mail.example"])
+
+	now = now.Add(DefaultMXCacheTTL + time.Second)
+	assert.NoError(t, v.Validate(ctx, "user@example.com"))
+	assert.Equal(t, 2, lookup.calls["example.com"])
+}
+
+func TestEmailValidator_StrictToleratesLookupFailure(t *testing.T) {
+	v := newTestEmailValidator(t, EmailStrictnessStrict)
+	lookup := &fakeMXLookup{err: errors.New("i/o timeout"), calls: make(map[string]int)}
+	v.lookupMX = lookup.lookup
+	ctx := context.Background()
+
+	// DNS 暂时不可用时不拒绝注册，也不缓存结果
+	assert.NoError(t, v.Validate(ctx, "user@example.com"))
+	assert.NoError(t, v.Validate(ctx, "user@example.com"))
+	assert.Equal(t, 2, lookup.calls["example.com"])
+}
+
+func TestNewEmailValidator_UnknownStrictness(t *testing.T) {
+	_, err := NewEmailValidator(config.EmailValidationConfig{Strictness: "paranoid"})
+	assert.Error(t, err)
+
+	v, err := NewEmailValidator(config.EmailValidationConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, EmailStrictnessBasic, v.strictness)
+}
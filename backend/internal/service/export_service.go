@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+)
+
+// 导出数据分区名称
+const (
+	ExportSectionProfile   = "profile"
+	ExportSectionWatchlist = "watchlist"
+)
+
+// ExportService 用户数据导出服务接口
+type ExportService interface {
+	// ExportUserData 将用户的全部数据以 JSON 流式写入 w
+	ExportUserData(ctx context.Context, userID int64, w io.Writer) error
+}
+
+// exportSection 导出分区，逐个写入以避免整体加载到内存
+type exportSection struct {
+	name  string
+	write func(ctx context.Context, user *model.User, w io.Writer) error
+}
+
+type exportService struct {
+	userRepo repository.UserRepository
+	fundRepo repository.UserFundRepository
+	sections []exportSection
+}
+
+// NewExportService 创建用户数据导出服务
+func NewExportService(userRepo repository.UserRepository, fundRepo repository.UserFundRepository) ExportService {
+	s := &exportService{
+		userRepo: userRepo,
+		fundRepo: fundRepo,
+	}
+	s.sections = []exportSection{
+		{name: ExportSectionProfile, write: s.writeProfile},
+		{name: ExportSectionWatchlist, write: s.writeWatchlist},
+	}
+	return s
+}
+
+// ExportUserData 流式导出用户数据
+// 格式: {"exportedAt": ..., "profile": {...}, "watchlist": [...]}
+func (s *exportService) ExportUserData(ctx context.Context, userID int64, w io.Writer) error {
+	// 写出前先确认用户存在，便于调用方返回正常的错误响应
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	exportedAt, _ := json.Marshal(time.Now().Format(time.RFC3339))
+	if _, err := fmt.Fprintf(w, `{"exportedAt":%s`, exportedAt); err != nil {
+		return err
+	}
+
+	for _, section := range s.sections {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, `,%q:`, section.name); err != nil {
+			return err
+		}
+		if err := section.write(ctx, user, w); err != nil {
+			return fmt.Errorf("export %s: %w", section.name, err)
+		}
+	}
+
+	_, err = io.WriteString(w, "}\n")
+	return err
+}
+
+// writeProfile 写入用户资料（排除敏感字段）
+func (s *exportService) writeProfile(ctx context.Context, user *model.User, w io.Writer) error {
+	return writeJSON(w, model.NewUserExportProfile(user))
+}
+
+// writeWatchlist 写入自选基金，逐条编码
+func (s *exportService) writeWatchlist(ctx context.Context, user *model.User, w io.Writer) error {
+	funds, err := s.fundRepo.GetFundsByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	return writeJSONArray(w, len(funds), func(i int) interface{} {
+		return funds[i]
+	})
+}
+
+// writeJSON 写入单个 JSON 值
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writeJSONArray 逐个元素写入 JSON 数组
+func writeJSONArray(w io.Writer, n int, item func(i int) interface{}) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := writeJSON(w, item(i)); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExportUserRepo 仅实现导出所需方法的用户仓库
+type fakeExportUserRepo struct {
+	repository.UserRepository
+	user *model.User
+}
+
+func (r *fakeExportUserRepo) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, repository.ErrUserNotFound
+	}
+	return r.user, nil
+}
+
+// fakeExportFundRepo 仅实现导出所需方法的基金仓库
+type fakeExportFundRepo struct {
+	repository.UserFundRepository
+	funds []model.UserFund
+	err   error
+}
+
+func (r *fakeExportFundRepo) GetFundsByUserID(ctx context.Context, userID int64) ([]model.UserFund, error) {
+	return r.funds, r.err
+}
+
+func newTestExportUser() *model.User {
+	lockedUntil := time.Now().Add(time.Hour)
+	return &model.User{
+		ID:            7,
+		Email:         "user@example.com",
+		PasswordHash:  "$2a$10$secrethashvalue",
+		Nickname:      "小明",
+		Status:        model.UserStatusActive,
+		LoginAttempts: 3,
+		LockedUntil:   &lockedUntil,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+}
+
+func TestExportService_ExportUserData_Sections(t *testing.T) {
+	userRepo := &fakeExportUserRepo{user: newTestExportUser()}
+	fundRepo := &fakeExportFundRepo{funds: []model.UserFund{
+		{ID: 1, UserID: 7, FundCode: "000001", FundName: "华夏成长", IsHold: true, Sectors: []string{"科技"}},
+		{ID: 2, UserID: 7, FundCode: "110022", FundName: "易方达消费"},
+	}}
+	svc := NewExportService(userRepo, fundRepo)
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.ExportUserData(context.Background(), 7, &buf))
+
+	var bundle map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &bundle), "export should be valid JSON")
+
+	assert.Contains(t, bundle, "exportedAt")
+	assert.Contains(t, bundle, ExportSectionProfile)
+	assert.Contains(t, bundle, ExportSectionWatchlist)
+
+	var profile model.UserExportProfile
+	require.NoError(t, json.Unmarshal(bundle[ExportSectionProfile], &profile))
+	assert.Equal(t, "user@example.com", profile.Email)
+	assert.Equal(t, "小明", profile.Nickname)
+
+	var watchlist []model.UserFund
+	require.NoError(t, json.Unmarshal(bundle[ExportSectionWatchlist], &watchlist))
+	require.Len(t, watchlist, 2)
+	assert.Equal(t, "000001", watchlist[0].FundCode)
+	assert.True(t, watchlist[0].IsHold)
+	assert.Equal(t, []string{"科技"}, []string(watchlist[0].Sectors))
+}
+
+func TestExportService_ExportUserData_ExcludesSecrets(t *testing.T) {
+	user := newTestExportUser()
+	svc := NewExportService(&fakeExportUserRepo{user: user}, &fakeExportFundRepo{})
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.ExportUserData(context.Background(), 7, &buf))
+
+	out := buf.String()
+	assert.NotContains(t, out, user.PasswordHash)
+	assert.NotContains(t, out, "password")
+	assert.NotContains(t, out, "loginAttempts")
+	assert.NotContains(t, out, "lockedUntil")
+}
+
+func TestExportService_ExportUserData_EmptyWatchlist(t *testing.T) {
+	svc := NewExportService(&fakeExportUserRepo{user: newTestExportUser()}, &fakeExportFundRepo{})
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.ExportUserData(context.Background(), 7, &buf))
+
+	var bundle map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &bundle))
+	assert.JSONEq(t, `[]`, string(bundle[ExportSectionWatchlist]))
+}
+
+func TestExportService_ExportUserData_UserNotFound_WritesNothing(t *testing.T) {
+	svc := NewExportService(&fakeExportUserRepo{}, &fakeExportFundRepo{})
+
+	var buf bytes.Buffer
+	err := svc.ExportUserData(context.Background(), 7, &buf)
+
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	assert.Zero(t, buf.Len())
+}
+
+func TestExportService_ExportUserData_SectionError(t *testing.T) {
+	fundRepo := &fakeExportFundRepo{err: errors.New("db down")}
+	svc := NewExportService(&fakeExportUserRepo{user: newTestExportUser()}, fundRepo)
+
+	var buf bytes.Buffer
+	err := svc.ExportUserData(context.Background(), 7, &buf)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ExportSectionWatchlist)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/pkg/llm"
+)
+
+// ErrInvalidFastCard 模型输出的结构化卡片无法解析或不符合约定
+var ErrInvalidFastCard = errors.New("invalid fast analysis card")
+
+// maxCardSectors 卡片中最多展示的板块数
+const maxCardSectors = 3
+
+// FastCardFallbackNotice 结构化输出解析失败、改为文本分析时的提示
+const FastCardFallbackNotice = "结构化结果解析失败，改为文本分析"
+
+// sentimentLabels 情绪的中文展示
+var sentimentLabels = map[string]string{
+	model.SentimentBullish: "偏多",
+	model.SentimentNeutral: "中性",
+	model.SentimentBearish: "偏空",
+}
+
+// analyzeFastCard 以 JSON 输出生成快速分析卡片
+// 成功时发送 card 块及其文本渲染；卡片无效时回退为普通文本快速分析
+func (s *aiService) analyzeFastCard(ctx context.Context, data *model.MarketData, stream chan<- model.ChatChunk) error {
+	messages := []llm.Message{
+		{Role: "system", Content: buildFastCardPrompt()},
+		{Role: "user", Content: buildMarketDataPrompt(data, s.maxPromptFunds)},
+	}
+	opts := modeOptions(s.modes.Fast)
+	opts.ResponseFormat = &llm.ResponseFormat{Type: llm.ResponseFormatJSONObject}
+
+	eventChan, err := s.llmClient.ChatStreamWithOptions(ctx, messages, opts)
+	if err != nil {
+		stream <- model.ChatChunk{
+			Type:    model.ChunkTypeError,
+			Message: fmt.Sprintf("AI 服务调用失败: %v", err),
+		}
+		return err
+	}
+
+	// JSON 需完整后才能解析，不转发中间内容
+	var content strings.Builder
+	for event := range eventChan {
+		if event.Error != nil {
+			stream <- model.ChatChunk{
+				Type:    model.ChunkTypeError,
+				Message: event.Error.Error(),
+			}
+			return event.Error
+		}
+		content.WriteString(event.Content)
+		if event.Done {
+			break
+		}
+	}
+
+	card, err := parseFastCard(content.String())
+	if err != nil {
+		stream <- model.ChatChunk{
+			Type:    model.ChunkTypeStatus,
+			Message: FastCardFallbackNotice,
+		}
+		return s.streamAnalysis(ctx, s.fastMessages(data), modeOptions(s.modes.Fast), stream)
+	}
+
+	stream <- model.ChatChunk{
+		Type: model.ChunkTypeCard,
+		Card: card,
+	}
+
+	// 同时发送文本，兼容只渲染 content 的客户端
+	text := renderFastCard(card)
+	stream <- model.ChatChunk{
+		Type:  model.ChunkTypeContent,
+		Chunk: text,
+	}
+	s.disclaimer.append(stream, LanguageChinese, text)
+
+	stream <- model.ChatChunk{
+		Type: model.ChunkTypeDone,
+	}
+	return nil
+}
+
+// parseFastCard 解析并校验模型输出的卡片 JSON
+// 容忍 Markdown 代码块包裹，板块超过上限时截断
+func parseFastCard(content string) (*model.FastAnalysisCard, error) {
+	raw := strings.TrimSpace(content)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+	raw = strings.TrimSpace(raw)
+
+	var card model.FastAnalysisCard
+	if err := json.Unmarshal([]byte(raw), &card); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFastCard, err)
+	}
+
+	card.Sentiment = strings.ToLower(strings.TrimSpace(card.Sentiment))
+	if _, ok := sentimentLabels[card.Sentiment]; !ok {
+		return nil, fmt.Errorf("%w: unknown sentiment %q", ErrInvalidFastCard, card.Sentiment)
+	}
+
+	sectors := make([]model.CardSector, 0, maxCardSectors)
+	for _, sector := range card.TopSectors {
+		sector.Name = strings.TrimSpace(sector.Name)
+		sector.Reason = strings.TrimSpace(sector.Reason)
+		if sector.Name == "" {
+			continue
+		}
+		sectors = append(sectors, sector)
+		if len(sectors) == maxCardSectors {
+			break
+		}
+	}
+	if len(sectors) == 0 {
+		return nil, fmt.Errorf("%w: no sectors", ErrInvalidFastCard)
+	}
+	card.TopSectors = sectors
+
+	card.Advice = strings.TrimSpace(card.Advice)
+	card.Risk = strings.TrimSpace(card.Risk)
+	if card.Advice == "" || card.Risk == "" {
+		return nil, fmt.Errorf("%w: missing advice or risk", ErrInvalidFastCard)
+	}
+	return &card, nil
+}
+
+// renderFastCard 将卡片渲染为 Markdown 文本
+func renderFastCard(card *model.FastAnalysisCard) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**市场情绪**：%s\n\n", sentimentLabels[card.Sentiment]))
+	sb.WriteString("**关注板块**\n")
+	for i, sector := range card.TopSectors {
+		if sector.Reason != "" {
+			sb.WriteString(fmt.Sprintf("%d. %s：%s\n", i+1, sector.Name, sector.Reason))
+		} else {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, sector.Name))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n**投资建议**：%s\n\n", card.Advice))
+	sb.WriteString(fmt.Sprintf("**风险提示**：%s", card.Risk))
+	return sb.String()
+}
+
+// buildFastCardPrompt 构建结构化快速分析提示词
+func buildFastCardPrompt() string {
+	return `你是一个专业的基金投资分析师。请根据提供的市场数据生成一张简明的市场分析卡片。
+
+## 输出要求
+只输出一个 JSON 对象，不要输出任何其他内容，字段如下：
+{
+  "sentiment": "bullish | neutral | bearish，今日市场整体情绪",
+  "topSectors": [{"name": "板块名称", "reason": "一句话说明关注原因"}],
+  "advice": "一句话投资建议",
+  "risk": "一个主要风险点"
+}
+
+## 注意事项
+1. topSectors 列出 3 个最值得关注的板块
+2. 每个文本字段不超过 50 字
+3. 数据引用要准确`
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/pkg/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validCardJSON = `{"sentiment":"Bullish","topSectors":[{"name":"半导体","reason":"主力资金大幅流入"},{"name":"券商","reason":"成交额放大"},{"name":" ","reason":"无名称"},{"name":"黄金","reason":"避险需求"},{"name":"白酒","reason":"超出上限"}],"advice":"逢低布局科技主题基金","risk":"海外加息预期升温"}`
+
+// sseContent 构建携带指定内容的 SSE 数据块
+func sseContent(t *testing.T, content string) string {
+	t.Helper()
+	raw, err := json.Marshal(content)
+	require.NoError(t, err)
+	return `{"choices":[{"delta":{"content":` + string(raw) + `}}]}`
+}
+
+func TestParseFastCard_Valid(t *testing.T) {
+	for _, content := range []string{validCardJSON, "```json\n" + validCardJSON + "\n```"} {
+		card, err := parseFastCard(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, &model.FastAnalysisCard{
+			Sentiment: model.SentimentBullish,
+			TopSectors: []model.CardSector{
+				{Name: "半导体", Reason: "主力资金大幅流入"},
+				{Name: "券商", Reason: "成交额放大"},
+				{Name: "黄金", Reason: "避险需求"},
+			},
+			Advice: "逢低布局科技主题基金",
+			Risk:   "海外加息预期升温",
+		}, card)
+	}
+}
+
+func TestParseFastCard_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"prose", "今日市场整体偏强，半导体板块领涨。"},
+		{"truncated", `{"sentiment":"bullish","topSectors":[{"name":"半导体"`},
+		{"unknown sentiment", `{"sentiment":"very good","topSectors":[{"name":"半导体"}],"advice":"持有","risk":"波动"}`},
+		{"no sectors", `{"sentiment":"neutral","topSectors":[],"advice":"持有","risk":"波动"}`},
+		{"missing advice", `{"sentiment":"neutral","topSectors":[{"name":"半导体"}],"risk":"波动"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFastCard(tt.content)
+			assert.ErrorIs(t, err, ErrInvalidFastCard)
+		})
+	}
+}
+
+func TestAIService_AnalyzeFast_Card(t *testing.T) {
+	// JSON 分两块到达
+	split := strings.Index(validCardJSON, `"advice"`)
+	var requests []string
+	svc := &aiService{
+		llmClient: newRecordingLLMClient(t, &requests, sseContent(t, validCardJSON[:split]), sseContent(t, validCardJSON[split:])),
+		modes:     config.AnalysisConfig{FastCard: true},
+	}
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeFast(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	var req llm.ChatRequest
+	require.NoError(t, json.Unmarshal([]byte(requests[0]), &req))
+	require.NotNil(t, req.ResponseFormat)
+	assert.Equal(t, llm.ResponseFormatJSONObject, req.ResponseFormat.Type)
+
+	var card *model.FastAnalysisCard
+	for _, c := range chunks {
+		if c.Type == model.ChunkTypeCard {
+			card = c.Card
+		}
+	}
+	require.NotNil(t, card)
+	assert.Equal(t, model.SentimentBullish, card.Sentiment)
+	assert.Len(t, card.TopSectors, 3)
+
+	// 原始 JSON 不转发，文本由卡片渲染
+	content := chunkContent(chunks)
+	assert.NotContains(t, content, `"sentiment"`)
+	assert.Contains(t, content, "**市场情绪**：偏多")
+	assert.Contains(t, content, "1. 半导体：主力资金大幅流入")
+	assert.Equal(t, model.ChunkTypeDone, chunks[len(chunks)-1].Type)
+}
+
+func TestAIService_AnalyzeFast_MalformedCardFallsBackToProse(t *testing.T) {
+	svc, calls := newStreamingAIService(t,
+		[]string{sseContent(t, `{"sentiment":"bullish","topSectors":[`)},
+		[]string{contentChunk},
+	)
+	svc.modes = config.AnalysisConfig{FastCard: true}
+
+	chunks, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeFast(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	assert.Contains(t, chunks, model.ChatChunk{Type: model.ChunkTypeStatus, Message: FastCardFallbackNotice})
+	assert.NotContains(t, chunkTypes(chunks), model.ChunkTypeCard)
+	assert.Equal(t, "今天市场平稳", chunkContent(chunks))
+	assert.Equal(t, model.ChunkTypeDone, chunks[len(chunks)-1].Type)
+}
+
+func TestAIService_AnalyzeFast_CardDisabledByDefault(t *testing.T) {
+	var requests []string
+	svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk)}
+
+	_, err := collectAnalysis(t, func(stream chan<- model.ChatChunk) error {
+		return svc.AnalyzeFast(context.Background(), &model.MarketData{}, stream)
+	})
+
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.NotContains(t, requests[0], `"response_format"`)
+}
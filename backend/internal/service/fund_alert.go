@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxAlertsPerFund 每位用户在单只基金上最多设置的提醒数
+	maxAlertsPerFund = 10
+	// maxAlertThreshold 涨跌幅阈值上限（%）
+	maxAlertThreshold = 100
+)
+
+var (
+	// ErrInvalidFundAlert 提醒方向或阈值无效
+	ErrInvalidFundAlert = errors.New("invalid fund alert")
+	// ErrFundAlertNotFound 提醒不存在或不属于该基金
+	ErrFundAlertNotFound = errors.New("fund alert not found")
+	// ErrTooManyFundAlerts 单只基金的提醒数达到上限
+	ErrTooManyFundAlerts = errors.New("too many fund alerts")
+)
+
+// FundAlertService 基金估值提醒服务接口
+type FundAlertService interface {
+	List(ctx context.Context, userID int64, code string) ([]model.FundAlert, error)
+	Create(ctx context.Context, userID int64, code string, req *model.FundAlertRequest) (*model.FundAlert, error)
+	Update(ctx context.Context, userID int64, code string, alertID int64, req *model.FundAlertRequest) (*model.FundAlert, error)
+	Delete(ctx context.Context, userID int64, code string, alertID int64) error
+	// CheckAlerts 检查已启用的提醒，估值越过阈值时发送通知，供后台任务定期调用
+	CheckAlerts(ctx context.Context) error
+}
+
+type fundAlertService struct {
+	alertRepo     repository.FundAlertRepository
+	fundRepo      repository.UserFundRepository
+	fundService   FundService
+	notifications *NotificationScheduler
+	logger        *zap.Logger
+
+	// padShortCodes 与基金服务一致，为丢失前导零的基金代码补零
+	padShortCodes bool
+	now           func() time.Time
+}
+
+// NewFundAlertService 创建基金估值提醒服务
+// 只能为自选列表中的基金设置提醒；估值通过 fundService 批量获取，提醒经 notifications 发送，免打扰期间合并为摘要
+func NewFundAlertService(
+	alertRepo repository.FundAlertRepository,
+	fundRepo repository.UserFundRepository,
+	fundService FundService,
+	notifications *NotificationScheduler,
+	padShortCodes bool,
+	logger *zap.Logger,
+) FundAlertService {
+	return &fundAlertService{
+		alertRepo:     alertRepo,
+		fundRepo:      fundRepo,
+		fundService:   fundService,
+		notifications: notifications,
+		logger:        logger,
+		padShortCodes: padShortCodes,
+		now:           time.Now,
+	}
+}
+
+// List 获取用户在某只基金上的提醒
+func (s *fundAlertService) List(ctx context.Context, userID int64, code string) ([]model.FundAlert, error) {
+	code, err := s.ownedFundCode(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.alertRepo.ListAlerts(ctx, userID, code)
+}
+
+// Create 为自选基金创建提醒
+func (s *fundAlertService) Create(ctx context.Context, userID int64, code string, req *model.FundAlertRequest) (*model.FundAlert, error) {
+	if err := validateFundAlert(req); err != nil {
+		return nil, err
+	}
+	code, err := s.ownedFundCode(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.alertRepo.ListAlerts(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAlertsPerFund {
+		return nil, fmt.Errorf("%w: at most %d per fund", ErrTooManyFundAlerts, maxAlertsPerFund)
+	}
+
+	alert := &model.FundAlert{
+		UserID:           userID,
+		FundCode:         code,
+		Direction:        req.Direction,
+		PercentThreshold: req.PercentThreshold,
+		Active:           req.Active == nil || *req.Active,
+	}
+	if err := s.alertRepo.CreateAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// Update 修改提醒的方向、阈值与启用状态
+func (s *fundAlertService) Update(ctx context.Context, userID int64, code string, alertID int64, req *model.FundAlertRequest) (*model.FundAlert, error) {
+	if err := validateFundAlert(req); err != nil {
+		return nil, err
+	}
+	alert, err := s.getAlert(ctx, userID, code, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	alert.Direction = req.Direction
+	alert.PercentThreshold = req.PercentThreshold
+	alert.Active = req.Active == nil || *req.Active
+	if err := s.alertRepo.UpdateAlert(ctx, alert); err != nil {
+		if errors.Is(err, repository.ErrFundAlertNotFound) {
+			return nil, ErrFundAlertNotFound
+		}
+		return nil, err
+	}
+	return alert, nil
+}
+
+// Delete 删除提醒
+func (s *fundAlertService) Delete(ctx context.Context, userID int64, code string, alertID int64) error {
+	if _, err := s.getAlert(ctx, userID, code, alertID); err != nil {
+		return err
+	}
+	err := s.alertRepo.DeleteAlert(ctx, userID, alertID)
+	if errors.Is(err, repository.ErrFundAlertNotFound) {
+		return ErrFundAlertNotFound
+	}
+	return err
+}
+
+// ownedFundCode 规范化基金代码并确认基金在用户的自选列表中
+func (s *fundAlertService) ownedFundCode(ctx context.Context, userID int64, code string) (string, error) {
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.fundRepo.GetFundByCode(ctx, userID, code); err != nil {
+		if errors.Is(err, repository.ErrFundNotFound) {
+			return "", ErrFundNotFound
+		}
+		return "", err
+	}
+	return code, nil
+}
+
+// getAlert 获取用户在该基金上的提醒，提醒属于其他基金时视为不存在
+func (s *fundAlertService) getAlert(ctx context.Context, userID int64, code string, alertID int64) (*model.FundAlert, error) {
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return nil, err
+	}
+	alert, err := s.alertRepo.GetAlert(ctx, userID, alertID)
+	if err != nil {
+		if errors.Is(err, repository.ErrFundAlertNotFound) {
+			return nil, ErrFundAlertNotFound
+		}
+		return nil, err
+	}
+	if alert.FundCode != code {
+		return nil, ErrFundAlertNotFound
+	}
+	return alert, nil
+}
+
+// validateFundAlert 校验提醒方向与阈值
+func validateFundAlert(req *model.FundAlertRequest) error {
+	if req.Direction != model.AlertDirectionUp && req.Direction != model.AlertDirectionDown {
+		return fmt.Errorf("%w: direction must be %q or %q", ErrInvalidFundAlert, model.AlertDirectionUp, model.AlertDirectionDown)
+	}
+	if req.PercentThreshold <= 0 || req.PercentThreshold > maxAlertThreshold {
+		return fmt.Errorf("%w: percentThreshold must be in (0, %d]", ErrInvalidFundAlert, maxAlertThreshold)
+	}
+	return nil
+}
+
+// CheckAlerts 检查已启用的提醒
+// 仅在交易时段执行；今天已触发过的提醒跳过，其余按基金批量获取估值，越过阈值时发送通知并记录触发时间
+// 用户处于免打扰时段时通知暂存，结束后合并为摘要发送，同样记录为已触发
+// 部分通知发送失败时返回错误，未记录触发时间的提醒在下次检查时重试
+func (s *fundAlertService) CheckAlerts(ctx context.Context) error {
+	now := s.now()
+	if !isTradingTime(now) {
+		return nil
+	}
+
+	alerts, err := s.alertRepo.ListActiveAlerts(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending := make([]model.FundAlert, 0, len(alerts))
+	var codes []string
+	seen := make(map[string]bool)
+	for _, alert := range alerts {
+		if triggeredToday(alert, now) {
+			continue
+		}
+		pending = append(pending, alert)
+		if !seen[alert.FundCode] {
+			seen[alert.FundCode] = true
+			codes = append(codes, alert.FundCode)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	valuations, err := s.fetchValuations(ctx, codes)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, alert := range pending {
+		valuation, ok := valuations[alert.FundCode]
+		if !ok || !valuationIsToday(valuation, now) {
+			continue
+		}
+		growth, ok := parseDayGrowth(valuation.DayGrowth)
+		if !ok || !alertCrossed(alert, growth) {
+			continue
+		}
+
+		if _, err := s.notifications.Dispatch(ctx, fundAlertNotification(alert, valuation)); err != nil {
+			s.logger.Warn("Failed to send fund alert",
+				zap.Int64("alertID", alert.ID), zap.Int64("userID", alert.UserID), zap.String("code", alert.FundCode), zap.Error(err))
+			failed++
+			continue
+		}
+		if err := s.alertRepo.MarkTriggered(ctx, alert.ID, now); err != nil {
+			s.logger.Warn("Failed to record fund alert trigger", zap.Int64("alertID", alert.ID), zap.Error(err))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d fund alert notifications failed", failed)
+	}
+	return nil
+}
+
+// fetchValuations 按批量上限分批获取估值，获取失败的基金不出现在结果中
+func (s *fundAlertService) fetchValuations(ctx context.Context, codes []string) (map[string]*model.FundValuation, error) {
+	valuations := make(map[string]*model.FundValuation, len(codes))
+	for start := 0; start < len(codes); start += MaxValuationBatchSize {
+		end := min(start+MaxValuationBatchSize, len(codes))
+		batch, err := s.fundService.GetValuationsBatch(ctx, codes[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for code, valuation := range batch.Valuations {
+			valuations[code] = valuation
+		}
+	}
+	return valuations, nil
+}
+
+// fundAlertNotification 构建估值提醒通知
+func fundAlertNotification(alert model.FundAlert, valuation *model.FundValuation) model.Notification {
+	name := valuation.Name
+	if name == "" {
+		name = alert.FundCode
+	}
+	action := "涨幅"
+	if alert.Direction == model.AlertDirectionDown {
+		action = "跌幅"
+	}
+
+	return model.Notification{
+		UserID: alert.UserID,
+		Title:  fmt.Sprintf("估值提醒：%s %s达到 %.2f%%", name, action, alert.PercentThreshold),
+		Body: fmt.Sprintf("%s（%s）盘中估算%s %s，已达到您设置的 %.2f%%。\n估算净值 %s · %s\n估值仅供参考，以基金公司公布的净值为准。同一提醒每天最多发送一次。",
+			name, alert.FundCode, action, valuation.DayGrowth, alert.PercentThreshold, valuation.Valuation, valuation.ValuationTime),
+	}
+}
+
+// alertCrossed 估算涨跌幅（%）是否越过提醒阈值，恰好等于阈值视为越过
+func alertCrossed(alert model.FundAlert, growth float64) bool {
+	switch alert.Direction {
+	case model.AlertDirectionUp:
+		return growth >= alert.PercentThreshold
+	case model.AlertDirectionDown:
+		return growth <= -alert.PercentThreshold
+	default:
+		return false
+	}
+}
+
+// triggeredToday 提醒今天（北京时间）是否已触发过
+func triggeredToday(alert model.FundAlert, now time.Time) bool {
+	if alert.LastTriggeredAt == nil {
+		return false
+	}
+	return sameDay(alert.LastTriggeredAt.In(crawler.ShanghaiLocation), now.In(crawler.ShanghaiLocation))
+}
+
+// valuationIsToday 估值时间以日期开头时，要求为今天，避免节假日用上一交易日的估值重复提醒
+// 无法解析日期时不做限制
+func valuationIsToday(valuation *model.FundValuation, now time.Time) bool {
+	if len(valuation.ValuationTime) < len(time.DateOnly) {
+		return true
+	}
+	date, err := time.ParseInLocation(time.DateOnly, valuation.ValuationTime[:len(time.DateOnly)], crawler.ShanghaiLocation)
+	if err != nil {
+		return true
+	}
+	return sameDay(date, now.In(crawler.ShanghaiLocation))
+}
+
+// sameDay 两个时间是否为同一自然日（按各自时区）
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// isTradingTime 是否处于 A 股交易时段（北京时间工作日 9:30-11:30、13:00-15:00，不含节假日）
+func isTradingTime(t time.Time) bool {
+	t = t.In(crawler.ShanghaiLocation)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	return (minutes >= 9*60+30 && minutes <= 11*60+30) || (minutes >= 13*60 && minutes <= 15*60)
+}
+
+// parseDayGrowth 解析估算涨跌幅，如 "+1.20%"、"-0.5"，"--" 等占位符返回 false
+func parseDayGrowth(s string) (float64, bool) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "%")
+	growth, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return growth, true
+}
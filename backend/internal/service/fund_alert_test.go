@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeFundAlertRepo 内存中的估值提醒仓库
+type fakeFundAlertRepo struct {
+	alerts []*model.FundAlert
+	nextID int64
+}
+
+func (r *fakeFundAlertRepo) CreateAlert(ctx context.Context, alert *model.FundAlert) error {
+	r.nextID++
+	alert.ID = r.nextID
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func (r *fakeFundAlertRepo) ListAlerts(ctx context.Context, userID int64, fundCode string) ([]model.FundAlert, error) {
+	alerts := make([]model.FundAlert, 0)
+	for _, a := range r.alerts {
+		if a.UserID == userID && a.FundCode == fundCode {
+			alerts = append(alerts, *a)
+		}
+	}
+	return alerts, nil
+}
+
+func (r *fakeFundAlertRepo) GetAlert(ctx context.Context, userID, alertID int64) (*model.FundAlert, error) {
+	for _, a := range r.alerts {
+		if a.ID == alertID && a.UserID == userID {
+			alert := *a
+			return &alert, nil
+		}
+	}
+	return nil, repository.ErrFundAlertNotFound
+}
+
+func (r *fakeFundAlertRepo) UpdateAlert(ctx context.Context, alert *model.FundAlert) error {
+	for i, a := range r.alerts {
+		if a.ID == alert.ID && a.UserID == alert.UserID {
+			updated := *alert
+			r.alerts[i] = &updated
+			return nil
+		}
+	}
+	return repository.ErrFundAlertNotFound
+}
+
+func (r *fakeFundAlertRepo) DeleteAlert(ctx context.Context, userID, alertID int64) error {
+	for i, a := range r.alerts {
+		if a.ID == alertID && a.UserID == userID {
+			r.alerts = append(r.alerts[:i], r.alerts[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrFundAlertNotFound
+}
+
+func (r *fakeFundAlertRepo) ListActiveAlerts(ctx context.Context) ([]model.FundAlert, error) {
+	alerts := make([]model.FundAlert, 0)
+	for _, a := range r.alerts {
+		if a.Active {
+			alerts = append(alerts, *a)
+		}
+	}
+	return alerts, nil
+}
+
+func (r *fakeFundAlertRepo) MarkTriggered(ctx context.Context, alertID int64, at time.Time) error {
+	for _, a := range r.alerts {
+		if a.ID == alertID {
+			a.LastTriggeredAt = &at
+		}
+	}
+	return nil
+}
+
+// fixedValuationService 返回预设估值，缺少的基金计入 Failed
+type fixedValuationService struct {
+	FundService
+	valuations map[string]*model.FundValuation
+	batches    [][]string
+}
+
+func (s *fixedValuationService) GetValuationsBatch(ctx context.Context, codes []string) (*ValuationBatch, error) {
+	s.batches = append(s.batches, codes)
+	batch := &ValuationBatch{Valuations: make(map[string]*model.FundValuation)}
+	for _, code := range codes {
+		if v, ok := s.valuations[code]; ok {
+			batch.Valuations[code] = v
+		} else {
+			batch.Failed = append(batch.Failed, code)
+		}
+	}
+	return batch, nil
+}
+
+// tradingNow 2024-03-04（周一）10:00 北京时间
+var tradingNow = time.Date(2024, 3, 4, 10, 0, 0, 0, crawler.ShanghaiLocation)
+
+// newTestFundAlertService 用户 7 的自选基金为 000001 与 110022，用户 8 设置了 09:00-11:00（北京时间）免打扰
+func newTestFundAlertService(now *time.Time, valuations map[string]*model.FundValuation) (*fundAlertService, *fakeFundAlertRepo, *recordingNotifier, *NotificationScheduler) {
+	alertRepo := &fakeFundAlertRepo{}
+	fundRepo := &fakeFundRepo{funds: map[string]*model.UserFund{
+		"000001": {UserID: 7, FundCode: "000001"},
+		"110022": {UserID: 7, FundCode: "110022"},
+	}}
+	quietHours := NewQuietHoursService(&fakeQuietHoursRepo{items: map[int64]*model.QuietHours{
+		8: {UserID: 8, Enabled: true, Start: "09:00", End: "11:00", Timezone: "Asia/Shanghai"},
+	}})
+	notifier := &recordingNotifier{}
+	notifications := NewNotificationScheduler(quietHours, notifier, zap.NewNop())
+	notifications.now = func() time.Time { return *now }
+
+	svc := NewFundAlertService(alertRepo, fundRepo, &fixedValuationService{valuations: valuations},
+		notifications, true, zap.NewNop()).(*fundAlertService)
+	svc.now = func() time.Time { return *now }
+	return svc, alertRepo, notifier, notifications
+}
+
+func TestAlertCrossed(t *testing.T) {
+	up := model.FundAlert{Direction: model.AlertDirectionUp, PercentThreshold: 2}
+	down := model.FundAlert{Direction: model.AlertDirectionDown, PercentThreshold: 1.5}
+
+	tests := []struct {
+		name    string
+		alert   model.FundAlert
+		growth  float64
+		crossed bool
+	}{
+		{"up above threshold", up, 2.35, true},
+		{"up exactly at threshold", up, 2, true},
+		{"up below threshold", up, 1.99, false},
+		{"up ignores large drop", up, -3, false},
+		{"down below negative threshold", down, -1.8, true},
+		{"down exactly at threshold", down, -1.5, true},
+		{"down small drop", down, -1.2, false},
+		{"down ignores rise", down, 1.8, false},
+		{"unknown direction", model.FundAlert{Direction: "sideways", PercentThreshold: 1}, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.crossed, alertCrossed(tt.alert, tt.growth))
+		})
+	}
+}
+
+func TestParseDayGrowth(t *testing.T) {
+	for input, want := range map[string]float64{"+2.35%": 2.35, "-1.20%": -1.2, " 0.5 ": 0.5} {
+		growth, ok := parseDayGrowth(input)
+		assert.True(t, ok, input)
+		assert.InDelta(t, want, growth, 1e-9, input)
+	}
+	for _, input := range []string{"--", "", "N/A"} {
+		_, ok := parseDayGrowth(input)
+		assert.False(t, ok, input)
+	}
+}
+
+func TestIsTradingTime(t *testing.T) {
+	day := func(d, h, m int) time.Time { return time.Date(2024, 3, d, h, m, 0, 0, crawler.ShanghaiLocation) }
+
+	assert.True(t, isTradingTime(day(4, 9, 30)))
+	assert.True(t, isTradingTime(day(4, 14, 59)))
+	assert.False(t, isTradingTime(day(4, 9, 29)))
+	assert.False(t, isTradingTime(day(4, 12, 0)))
+	assert.False(t, isTradingTime(day(4, 15, 1)))
+	assert.False(t, isTradingTime(day(2, 10, 0)), "saturday")
+	// 按北京时间判断：UTC 02:00 为北京时间 10:00
+	assert.True(t, isTradingTime(time.Date(2024, 3, 4, 2, 0, 0, 0, time.UTC)))
+}
+
+func TestFundAlertService_CheckAlerts_NotifiesOncePerDay(t *testing.T) {
+	now := tradingNow
+	svc, repo, notifier, _ := newTestFundAlertService(&now, map[string]*model.FundValuation{
+		"000001": {Code: "000001", Name: "华夏成长", DayGrowth: "+2.35%", Valuation: "1.2345", ValuationTime: "2024-03-04 09:55"},
+		"110022": {Code: "110022", Name: "易方达消费行业", DayGrowth: "-0.80%", ValuationTime: "2024-03-04 09:55"},
+	})
+	ctx := context.Background()
+	active := true
+	_, err := svc.Create(ctx, 7, "000001", &model.FundAlertRequest{Direction: model.AlertDirectionUp, PercentThreshold: 2})
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, 7, "110022", &model.FundAlertRequest{Direction: model.AlertDirectionDown, PercentThreshold: 1, Active: &active})
+	require.NoError(t, err)
+	inactive := false
+	_, err = svc.Create(ctx, 7, "000001", &model.FundAlertRequest{Direction: model.AlertDirectionUp, PercentThreshold: 1, Active: &inactive})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.CheckAlerts(ctx))
+
+	// 只有越过阈值且启用的提醒发送通知
+	require.Len(t, notifier.sent, 1)
+	sent := notifier.sent[0]
+	assert.Equal(t, int64(7), sent.UserID)
+	assert.Equal(t, "估值提醒：华夏成长 涨幅达到 2.00%", sent.Title)
+	assert.Contains(t, sent.Body, "华夏成长（000001）盘中估算涨幅 +2.35%")
+	assert.Contains(t, sent.Body, "估算净值 1.2345 · 2024-03-04 09:55")
+	require.NotNil(t, repo.alerts[0].LastTriggeredAt)
+	assert.Nil(t, repo.alerts[1].LastTriggeredAt)
+
+	// 同一天再次检查不重复发送
+	now = now.Add(time.Hour)
+	require.NoError(t, svc.CheckAlerts(ctx))
+	assert.Len(t, notifier.sent, 1)
+
+	// 第二天再次越过阈值时重新提醒
+	now = tradingNow.AddDate(0, 0, 1)
+	svc.fundService.(*fixedValuationService).valuations["000001"].ValuationTime = "2024-03-05 09:55"
+	require.NoError(t, svc.CheckAlerts(ctx))
+	assert.Len(t, notifier.sent, 2)
+}
+
+func TestFundAlertService_CheckAlerts_SkipsStaleOrOffHours(t *testing.T) {
+	now := tradingNow
+	valuations := map[string]*model.FundValuation{
+		// 节假日后首个交易日开盘前的估值仍是上一交易日的
+		"000001": {Code: "000001", DayGrowth: "+3.00%", ValuationTime: "2024-03-01 15:00"},
+	}
+	svc, _, notifier, _ := newTestFundAlertService(&now, valuations)
+	ctx := context.Background()
+	_, err := svc.Create(ctx, 7, "000001", &model.FundAlertRequest{Direction: model.AlertDirectionUp, PercentThreshold: 2})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.CheckAlerts(ctx))
+	assert.Empty(t, notifier.sent)
+
+	// 非交易时段不获取估值
+	now = time.Date(2024, 3, 4, 20, 0, 0, 0, crawler.ShanghaiLocation)
+	valuations["000001"].ValuationTime = "2024-03-04 15:00"
+	require.NoError(t, svc.CheckAlerts(ctx))
+	assert.Empty(t, notifier.sent)
+	assert.Len(t, svc.fundService.(*fixedValuationService).batches, 1)
+}
+
+func TestFundAlertService_CheckAlerts_DefersDuringQuietHours(t *testing.T) {
+	now := tradingNow
+	svc, repo, notifier, notifications := newTestFundAlertService(&now, map[string]*model.FundValuation{
+		"000001": {Code: "000001", Name: "华夏成长", DayGrowth: "+2.35%", ValuationTime: "2024-03-04 09:55"},
+	})
+	ctx := context.Background()
+	// 用户 8 的 10:00 处于免打扰时段
+	require.NoError(t, repo.CreateAlert(ctx, &model.FundAlert{
+		UserID: 8, FundCode: "000001", Direction: model.AlertDirectionUp, PercentThreshold: 2, Active: true,
+	}))
+
+	require.NoError(t, svc.CheckAlerts(ctx))
+	assert.Empty(t, notifier.sent)
+	assert.Equal(t, 1, notifications.Pending(8))
+	require.NotNil(t, repo.alerts[0].LastTriggeredAt, "deferred alert counts as triggered")
+
+	// 同一天不再重复暂存，免打扰结束后作为摘要发送
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, svc.CheckAlerts(ctx))
+	assert.Equal(t, 1, notifications.Pending(8))
+
+	now = time.Date(2024, 3, 4, 11, 0, 0, 0, crawler.ShanghaiLocation)
+	assert.Equal(t, 1, notifications.Flush(ctx))
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, int64(8), notifier.sent[0].UserID)
+	assert.Equal(t, "估值提醒：华夏成长 涨幅达到 2.00%", notifier.sent[0].Title)
+}
+
+func TestFundAlertService_CRUDValidation(t *testing.T) {
+	now := tradingNow
+	svc, _, _, _ := newTestFundAlertService(&now, nil)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, 7, "000001", &model.FundAlertRequest{Direction: "sideways", PercentThreshold: 2})
+	assert.ErrorIs(t, err, ErrInvalidFundAlert)
+	_, err = svc.Create(ctx, 7, "000001", &model.FundAlertRequest{Direction: model.AlertDirectionUp, PercentThreshold: -1})
+	assert.ErrorIs(t, err, ErrInvalidFundAlert)
+	_, err = svc.Create(ctx, 7, "161725", &model.FundAlertRequest{Direction: model.AlertDirectionUp, PercentThreshold: 2})
+	assert.ErrorIs(t, err, ErrFundNotFound, "fund not in watchlist")
+
+	alert, err := svc.Create(ctx, 7, "000001", &model.FundAlertRequest{Direction: model.AlertDirectionUp, PercentThreshold: 2})
+	require.NoError(t, err)
+	assert.True(t, alert.Active)
+
+	// 提醒只能通过所属基金修改或删除
+	_, err = svc.Update(ctx, 7, "110022", alert.ID, &model.FundAlertRequest{Direction: model.AlertDirectionDown, PercentThreshold: 1})
+	assert.ErrorIs(t, err, ErrFundAlertNotFound)
+	assert.ErrorIs(t, svc.Delete(ctx, 8, "000001", alert.ID), ErrFundAlertNotFound)
+
+	updated, err := svc.Update(ctx, 7, "000001", alert.ID, &model.FundAlertRequest{Direction: model.AlertDirectionDown, PercentThreshold: 1})
+	require.NoError(t, err)
+	assert.Equal(t, model.AlertDirectionDown, updated.Direction)
+
+	require.NoError(t, svc.Delete(ctx, 7, "000001", alert.ID))
+	alerts, err := svc.List(ctx, 7, "000001")
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidFundCode 基金代码格式无效
+var ErrInvalidFundCode = errors.New("invalid fund code")
+
+// fundCodeLength 基金代码位数
+const fundCodeLength = 6
+
+// fundCodeMarkers 用户可能随代码一起粘贴的交易所或场外标识，如 SH000001、sz.159915、000001.OF
+var fundCodeMarkers = []string{"SH", "SZ", "BJ", "OF"}
+
+// normalizeFundCode 规范化用户输入的基金代码：去除首尾空白与交易所标识后校验为 6 位数字
+// padShort 为 true 时为丢失前导零的代码补零（如 "1" 补为 "000001"），否则视为无效
+// 格式无效时返回 ErrInvalidFundCode，不请求上游
+func normalizeFundCode(code string, padShort bool) (string, error) {
+	raw := strings.TrimSpace(code)
+	digits := stripFundCodeMarker(strings.ToUpper(raw))
+	if digits == "" || len(digits) > fundCodeLength || strings.Trim(digits, "0123456789") != "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidFundCode, raw)
+	}
+	if len(digits) < fundCodeLength {
+		if !padShort {
+			return "", fmt.Errorf("%w: %q", ErrInvalidFundCode, raw)
+		}
+		digits = strings.Repeat("0", fundCodeLength-len(digits)) + digits
+	}
+	return digits, nil
+}
+
+// stripFundCodeMarker 去除代码前后的交易所标识及分隔用的点号，最多去除一个
+func stripFundCodeMarker(code string) string {
+	for _, marker := range fundCodeMarkers {
+		if rest, ok := strings.CutPrefix(code, marker); ok {
+			return strings.TrimPrefix(rest, ".")
+		}
+		if rest, ok := strings.CutSuffix(code, marker); ok {
+			return strings.TrimSuffix(rest, ".")
+		}
+	}
+	return code
+}
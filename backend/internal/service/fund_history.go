@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fund-analyzer/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// DefaultFundHistoryPeriod 未指定区间时返回的历史净值区间
+const DefaultFundHistoryPeriod = "6m"
+
+// cacheKeyFundHistory 基金历史净值缓存键，%s = fund code, period
+const cacheKeyFundHistory = "fund:history:%s:%s"
+
+// FundHistoryPeriods 支持的历史净值区间，与蚂蚁财富曲线接口的 period 参数一致
+var FundHistoryPeriods = []string{"1m", "3m", "6m", "1y", "3y", "5y", "all"}
+
+var (
+	// ErrInvalidHistoryPeriod 不支持的历史净值区间
+	ErrInvalidHistoryPeriod = errors.New("invalid history period")
+	// ErrFundHistoryUnsupported 数据源不支持历史净值
+	ErrFundHistoryUnsupported = errors.New("fund history not supported")
+)
+
+// GetFundHistory 获取基金历史净值
+// 按基金代码与区间缓存，净值每日更新一次，使用基金信息的缓存时间
+func (s *fundService) GetFundHistory(ctx context.Context, code string, period string) ([]model.FundPoint, error) {
+	if !isFundHistoryPeriod(period) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHistoryPeriod, period)
+	}
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return nil, err
+	}
+	if s.fetchHistory == nil {
+		return nil, ErrFundHistoryUnsupported
+	}
+
+	return s.loadFundHistory(ctx, code, period, func() (string, error) {
+		return s.resolveFundKey(ctx, code)
+	})
+}
+
+// loadFundHistory 获取规范化代码 code 在 period 区间内的历史净值，缓存未命中时才通过 fundKey 解析上游 ID
+func (s *fundService) loadFundHistory(ctx context.Context, code, period string, fundKey func() (string, error)) ([]model.FundPoint, error) {
+	cacheKey := fmt.Sprintf(cacheKeyFundHistory, code, period)
+
+	// 尝试从缓存获取
+	var history []model.FundPoint
+	if err := s.cache.GetJSON(ctx, cacheKey, &history); err == nil && history != nil {
+		return history, nil
+	}
+
+	key, err := fundKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// 从蚂蚁财富获取
+	history, err = s.fetchHistory(ctx, key, period)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []model.FundPoint{}
+	}
+
+	// 缓存结果
+	_ = s.cache.SetJSON(ctx, cacheKey, history, TTLFundInfo)
+
+	return history, nil
+}
+
+// consecutiveDaysPeriod 计算连涨/跌天数使用的历史净值区间
+const consecutiveDaysPeriod = "1m"
+
+// fillConsecutiveDays 上游未返回连涨/跌天数时，根据近一个月净值计算
+// 历史净值与历史接口共用缓存；获取失败时保留原值
+func (s *fundService) fillConsecutiveDays(ctx context.Context, fundKey string, val *model.FundValuation) {
+	if val.ConsecutiveDays != 0 || s.fetchHistory == nil {
+		return
+	}
+	code, err := normalizeFundCode(val.Code, false)
+	if err != nil {
+		return
+	}
+
+	history, err := s.loadFundHistory(ctx, code, consecutiveDaysPeriod, func() (string, error) {
+		return fundKey, nil
+	})
+	if err != nil {
+		s.logger.Debug("Failed to load history for consecutive days", zap.String("code", code), zap.Error(err))
+		return
+	}
+	val.ConsecutiveDays = CalculateConsecutiveDays(history)
+}
+
+// isFundHistoryPeriod 判断是否为支持的历史净值区间
+func isFundHistoryPeriod(period string) bool {
+	for _, p := range FundHistoryPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
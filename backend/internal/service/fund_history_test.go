@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFundService_GetFundHistory_CachedPerCodeAndPeriod(t *testing.T) {
+	funds := newBatchFundCrawler("000001")
+	funds.curves = map[string][]model.FundPoint{
+		"key-000001:6m": {{Date: "2024-01-02", Value: "1.0100"}, {Date: "2024-01-03", Value: "1.0200"}},
+		"key-000001:1y": {{Date: "2023-01-03", Value: "0.9800"}},
+	}
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: funds, Cache: NewMemoryCache()})
+	ctx := context.Background()
+
+	history, err := svc.GetFundHistory(ctx, "000001", "6m")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	// 同一代码与区间命中缓存，代码规范化后共用
+	history, err = svc.GetFundHistory(ctx, "OF000001", "6m")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, 1, funds.count("GetFundCurves:key-000001:6m"))
+
+	// 不同区间分别缓存，fundKey 复用
+	history, err = svc.GetFundHistory(ctx, "000001", "1y")
+	require.NoError(t, err)
+	assert.Equal(t, "0.9800", history[0].Value)
+	assert.Equal(t, 1, funds.count("GetFundCurves:key-000001:1y"))
+	assert.Equal(t, 1, funds.count("SearchFund:000001"))
+}
+
+func TestFundService_GetFundHistory_InvalidInput(t *testing.T) {
+	funds := newBatchFundCrawler("000001")
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: funds, Cache: NewMemoryCache()})
+	ctx := context.Background()
+
+	for _, period := range []string{"", "2y", "6M"} {
+		_, err := svc.GetFundHistory(ctx, "000001", period)
+		assert.ErrorIs(t, err, ErrInvalidHistoryPeriod, period)
+	}
+
+	_, err := svc.GetFundHistory(ctx, "abc", "6m")
+	assert.ErrorIs(t, err, ErrInvalidFundCode)
+
+	_, err = svc.GetFundHistory(ctx, "999999", "6m")
+	assert.ErrorIs(t, err, ErrFundNotFound)
+
+	assert.Equal(t, map[string]int{"SearchFund:999999": 1}, funds.counts)
+}
+
+func TestFundService_GetFundHistory_EmptyCurve(t *testing.T) {
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: newBatchFundCrawler("000001"), Cache: NewMemoryCache()})
+
+	history, err := svc.GetFundHistory(context.Background(), "000001", "all")
+
+	require.NoError(t, err)
+	assert.NotNil(t, history)
+	assert.Empty(t, history)
+}
+
+func TestFundService_GetFundValuation_ConsecutiveDaysFromHistory(t *testing.T) {
+	funds := newBatchFundCrawler("000001", "110022")
+	funds.valuations["key-110022"].ConsecutiveDays = 5
+	funds.curves = map[string][]model.FundPoint{
+		"key-000001:1m": {
+			{Date: "2024-03-01", Value: "1.0300"},
+			{Date: "2024-03-04", Value: "1.0200"},
+			{Date: "2024-03-05", Value: "1.0100"},
+			{Date: "2024-03-06", Value: "1.0000"},
+		},
+	}
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: funds, Cache: NewMemoryCache()})
+	ctx := context.Background()
+
+	// 上游未返回时根据近一个月净值计算：连跌 3 天
+	valuation, err := svc.GetFundValuation(ctx, "key-000001")
+	require.NoError(t, err)
+	assert.Equal(t, -3, valuation.ConsecutiveDays)
+	assert.Zero(t, funds.valuations["key-000001"].ConsecutiveDays, "upstream result should not be modified")
+
+	// 历史净值与历史接口共用缓存
+	_, err = svc.GetFundHistory(ctx, "000001", "1m")
+	require.NoError(t, err)
+	assert.Equal(t, 1, funds.count("GetFundCurves:key-000001:1m"))
+
+	// 上游已返回时不请求历史净值
+	valuation, err = svc.GetFundValuation(ctx, "key-110022")
+	require.NoError(t, err)
+	assert.Equal(t, 5, valuation.ConsecutiveDays)
+	assert.Zero(t, funds.count("GetFundCurves:key-110022:1m"))
+}
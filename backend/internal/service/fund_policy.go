@@ -0,0 +1,48 @@
+package service
+
+import "strings"
+
+// FundCodePolicy 基金代码允许/禁止名单
+// 禁止名单优先；允许名单为空时不限制。nil 表示不做任何限制
+type FundCodePolicy struct {
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+// NewFundCodePolicy 创建基金代码名单策略
+// 两个名单都为空时返回 nil，即允许所有基金
+func NewFundCodePolicy(allowList, denyList []string) *FundCodePolicy {
+	allow := toCodeSet(allowList)
+	deny := toCodeSet(denyList)
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+	return &FundCodePolicy{allow: allow, deny: deny}
+}
+
+// Allowed 判断基金代码是否允许添加
+func (p *FundCodePolicy) Allowed(code string) bool {
+	if p == nil {
+		return true
+	}
+	code = strings.TrimSpace(code)
+	if _, denied := p.deny[code]; denied {
+		return false
+	}
+	if len(p.allow) == 0 {
+		return true
+	}
+	_, allowed := p.allow[code]
+	return allowed
+}
+
+// toCodeSet 将代码列表转换为集合，忽略空白项
+func toCodeSet(codes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		if code = strings.TrimSpace(code); code != "" {
+			set[code] = struct{}{}
+		}
+	}
+	return set
+}
@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"fund-analyzer/internal/crawler"
 	"fund-analyzer/internal/model"
 	"fund-analyzer/internal/repository"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	ErrFundNotFound = errors.New("fund not found")
 	ErrFundExists   = errors.New("fund already exists")
+	// ErrFundNotAllowed 基金代码不在允许范围内
+	ErrFundNotAllowed = errors.New("fund not allowed")
+	// ErrFundMetaDisabled 未启用基金元数据获取
+	ErrFundMetaDisabled = errors.New("fund metadata disabled")
 )
 
 // FundService 基金服务接口
@@ -24,31 +32,87 @@ type FundService interface {
 	UpdateSectors(ctx context.Context, userID int64, code string, sectors []string) error
 	SearchFund(ctx context.Context, code string) (*model.FundInfo, error)
 	GetFundValuation(ctx context.Context, code string) (*model.FundValuation, error)
+	// GetValuationsBatch 按基金代码批量获取估值，单个基金失败不影响其他基金
+	GetValuationsBatch(ctx context.Context, codes []string) (*ValuationBatch, error)
+	// GetFundHistory 获取基金在 period 区间内的历史净值，period 不在 FundHistoryPeriods 中时返回 ErrInvalidHistoryPeriod
+	GetFundHistory(ctx context.Context, code string, period string) ([]model.FundPoint, error)
+	// GetFundMeta 获取基金经理、规模与成立日期，未启用元数据获取时返回 ErrFundMetaDisabled
+	GetFundMeta(ctx context.Context, fundKey string) (*model.FundMeta, error)
+	GetRelated(ctx context.Context, userID int64, code string) ([]model.SectorFund, error)
 }
 
 // FundWithValuation 带估值的基金信息
 type FundWithValuation struct {
 	model.UserFund
 	Valuation *model.FundValuation `json:"valuation,omitempty"`
+	Meta      *model.FundMeta      `json:"meta,omitempty"`
 }
 
 type fundService struct {
-	fundRepo   repository.UserFundRepository
-	antCrawler *crawler.AntCrawler
-	cache      CacheService
+	fundRepo      repository.UserFundRepository
+	fundCrawler   crawler.FundDataCrawler
+	sectorService SectorService
+	cache         CacheService
+	policy        *FundCodePolicy
+	logger        *zap.Logger
+
+	// padShortCodes 为丢失前导零的基金代码补零
+	padShortCodes bool
+
+	// fetchValuation 从上游获取估值，valuationGroup 合并同一基金的并发请求
+	fetchValuation func(ctx context.Context, fundKey string) (*model.FundValuation, error)
+	valuationGroup singleflight.Group
+
+	// fetchMeta 从上游获取基金元数据，为 nil 表示未启用
+	fetchMeta func(ctx context.Context, fundKey string) (*model.FundMeta, error)
+
+	// fetchHistory 从上游获取历史净值，为 nil 表示数据源不支持
+	fetchHistory func(ctx context.Context, fundKey string, period string) ([]model.FundPoint, error)
+}
+
+// FundServiceOptions 基金服务的依赖与可选行为
+type FundServiceOptions struct {
+	FundRepo      repository.UserFundRepository
+	FundCrawler   crawler.FundDataCrawler
+	SectorService SectorService
+	Cache         CacheService
+
+	// Policy 基金代码允许/禁止名单，为 nil 时不限制可添加的基金
+	Policy *FundCodePolicy
+	// FetchMetadata 为 true 且数据源支持时在自选列表中附带经理与规模信息
+	FetchMetadata bool
+	// PadShortCodes 为丢失前导零的基金代码补零
+	PadShortCodes bool
+	// Logger 记录疑似越权修改他人自选基金的请求，为 nil 时不记录
+	Logger *zap.Logger
 }
 
 // NewFundService 创建基金服务
-func NewFundService(
-	fundRepo repository.UserFundRepository,
-	antCrawler *crawler.AntCrawler,
-	cache CacheService,
-) FundService {
-	return &fundService{
-		fundRepo:   fundRepo,
-		antCrawler: antCrawler,
-		cache:      cache,
+func NewFundService(opts FundServiceOptions) FundService {
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	s := &fundService{
+		fundRepo:      opts.FundRepo,
+		fundCrawler:   opts.FundCrawler,
+		sectorService: opts.SectorService,
+		cache:         opts.Cache,
+		policy:        opts.Policy,
+		logger:        logger,
+		padShortCodes: opts.PadShortCodes,
+	}
+	if opts.FundCrawler != nil {
+		s.fetchValuation = opts.FundCrawler.GetFundValuation
+	}
+	if metaCrawler, ok := opts.FundCrawler.(crawler.FundMetaCrawler); ok && opts.FetchMetadata {
+		s.fetchMeta = metaCrawler.GetFundMeta
 	}
+	if historyCrawler, ok := opts.FundCrawler.(crawler.FundHistoryCrawler); ok {
+		s.fetchHistory = historyCrawler.GetFundCurves
+	}
+	return s
 }
 
 // GetFundList 获取用户自选基金列表
@@ -71,6 +135,13 @@ func (s *fundService) GetFundList(ctx context.Context, userID int64) ([]FundWith
 		if err == nil {
 			result[i].Valuation = valuation
 		}
+
+		// 元数据缺失或获取失败时省略
+		if s.fetchMeta != nil {
+			if meta, err := s.GetFundMeta(ctx, fund.FundKey); err == nil && !meta.IsEmpty() {
+				result[i].Meta = meta
+			}
+		}
 	}
 
 	return result, nil
@@ -78,8 +149,18 @@ func (s *fundService) GetFundList(ctx context.Context, userID int64) ([]FundWith
 
 // AddFund 添加基金
 func (s *fundService) AddFund(ctx context.Context, userID int64, code string) (*model.FundInfo, error) {
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查是否允许添加
+	if !s.policy.Allowed(code) {
+		return nil, ErrFundNotAllowed
+	}
+
 	// 检查是否已存在
-	_, err := s.fundRepo.GetFundByCode(ctx, userID, code)
+	_, err = s.fundRepo.GetFundByCode(ctx, userID, code)
 	if err == nil {
 		return nil, ErrFundExists
 	}
@@ -88,11 +169,16 @@ func (s *fundService) AddFund(ctx context.Context, userID int64, code string) (*
 	}
 
 	// 搜索基金信息
-	fundInfo, err := s.antCrawler.SearchFund(ctx, code)
+	fundInfo, err := s.fundCrawler.SearchFund(ctx, code)
 	if err != nil {
 		return nil, fmt.Errorf("invalid fund code: %w", err)
 	}
 
+	// 搜索结果可能不是精确匹配，需再次检查实际添加的代码
+	if !s.policy.Allowed(fundInfo.Code) {
+		return nil, ErrFundNotAllowed
+	}
+
 	// 添加到数据库
 	userFund := &model.UserFund{
 		UserID:   userID,
@@ -110,27 +196,85 @@ func (s *fundService) AddFund(ctx context.Context, userID int64, code string) (*
 	return fundInfo, nil
 }
 
-// DeleteFund 删除基金
+// DeleteFund 删除基金，基金不在用户自选列表中时返回 ErrFundNotFound
 func (s *fundService) DeleteFund(ctx context.Context, userID int64, code string) error {
-	return s.fundRepo.DeleteFund(ctx, userID, code)
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyOwnership(ctx, userID, code, "delete"); err != nil {
+		return err
+	}
+	return fundNotFound(s.fundRepo.DeleteFund(ctx, userID, code))
 }
 
-// UpdateHoldStatus 更新持有状态
+// UpdateHoldStatus 更新持有状态，基金不在用户自选列表中时返回 ErrFundNotFound
 func (s *fundService) UpdateHoldStatus(ctx context.Context, userID int64, code string, isHold bool) error {
-	return s.fundRepo.UpdateHoldStatus(ctx, userID, code, isHold)
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyOwnership(ctx, userID, code, "update_hold"); err != nil {
+		return err
+	}
+	return fundNotFound(s.fundRepo.UpdateHoldStatus(ctx, userID, code, isHold))
 }
 
-// UpdateSectors 更新板块标记
+// UpdateSectors 更新板块标记，基金不在用户自选列表中时返回 ErrFundNotFound
 func (s *fundService) UpdateSectors(ctx context.Context, userID int64, code string, sectors []string) error {
-	return s.fundRepo.UpdateSectors(ctx, userID, code, sectors)
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyOwnership(ctx, userID, code, "update_sectors"); err != nil {
+		return err
+	}
+	return fundNotFound(s.fundRepo.UpdateSectors(ctx, userID, code, sectors))
+}
+
+// verifyOwnership 修改前确认 (userID, code) 记录存在且属于该用户，不只依赖修改语句的 WHERE 条件
+// 记录不存在或归属不符时返回 ErrFundNotFound，不向调用方暴露基金是否属于其他用户
+func (s *fundService) verifyOwnership(ctx context.Context, userID int64, code, action string) error {
+	fields := []zap.Field{
+		zap.String("action", action),
+		zap.Int64("userID", userID),
+		zap.String("code", code),
+	}
+
+	fund, err := s.fundRepo.GetFundByCode(ctx, userID, code)
+	if errors.Is(err, repository.ErrFundNotFound) {
+		s.logger.Warn("Fund mutation rejected: fund not in user's list", fields...)
+		return ErrFundNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if fund.UserID != userID {
+		s.logger.Error("Suspected cross-tenant fund access", append(fields, zap.Int64("ownerID", fund.UserID))...)
+		return ErrFundNotFound
+	}
+	return nil
+}
+
+// fundNotFound 将仓库层的基金不存在错误转换为 ErrFundNotFound
+func fundNotFound(err error) error {
+	if errors.Is(err, repository.ErrFundNotFound) {
+		return ErrFundNotFound
+	}
+	return err
 }
 
 // SearchFund 搜索基金
 func (s *fundService) SearchFund(ctx context.Context, code string) (*model.FundInfo, error) {
-	return s.antCrawler.SearchFund(ctx, code)
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return nil, err
+	}
+	return s.fundCrawler.SearchFund(ctx, code)
 }
 
 // GetFundValuation 获取基金估值
+// 缓存未命中时，同一基金的并发请求合并为一次上游调用
 func (s *fundService) GetFundValuation(ctx context.Context, fundKey string) (*model.FundValuation, error) {
 	cacheKey := fmt.Sprintf(CacheKeyFundValuation, fundKey)
 
@@ -141,16 +285,153 @@ func (s *fundService) GetFundValuation(ctx context.Context, fundKey string) (*mo
 		return &valuation, nil
 	}
 
-	// 从蚂蚁财富获取
-	val, err := s.antCrawler.GetFundValuation(ctx, fundKey)
+	// 共享的上游调用不随单个请求取消，避免一个客户端断开导致其他等待者一起失败
+	fetchCtx := context.WithoutCancel(ctx)
+	ch := s.valuationGroup.DoChan(fundKey, func() (interface{}, error) {
+		// 等待期间其他请求可能已写入缓存
+		var cached model.FundValuation
+		if err := s.cache.GetJSON(fetchCtx, cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+
+		// 从蚂蚁财富获取
+		val, err := s.fetchValuation(fetchCtx, fundKey)
+		if err != nil {
+			return nil, err
+		}
+		if val.ConsecutiveDays == 0 {
+			// 复制后补全，不修改上游返回的对象
+			filled := *val
+			s.fillConsecutiveDays(fetchCtx, fundKey, &filled)
+			val = &filled
+		}
+
+		// 缓存结果
+		_ = s.cache.SetJSON(fetchCtx, cacheKey, val, TTLFundValuation)
+
+		return val, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// 复制一份，调用方修改结果不会影响其他请求
+		val := *res.Val.(*model.FundValuation)
+		return &val, nil
+	}
+}
+
+// GetFundMeta 获取基金经理、规模与成立日期
+// 元数据变化缓慢，使用基金信息的缓存时间
+func (s *fundService) GetFundMeta(ctx context.Context, fundKey string) (*model.FundMeta, error) {
+	if s.fetchMeta == nil {
+		return nil, ErrFundMetaDisabled
+	}
+
+	cacheKey := fmt.Sprintf(CacheKeyFundInfo, fundKey)
+	var meta model.FundMeta
+	if err := s.cache.GetJSON(ctx, cacheKey, &meta); err == nil {
+		return &meta, nil
+	}
+
+	fetched, err := s.fetchMeta(ctx, fundKey)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.cache.SetJSON(ctx, cacheKey, fetched, TTLFundInfo)
+	return fetched, nil
+}
+
+// MaxRelatedFunds 相关基金推荐的最大数量
+const MaxRelatedFunds = 20
+
+// GetRelated 根据用户为基金标记的板块推荐同板块的其他基金，按近一年收益降序排列
+// 基金未标记板块或标记的板块无法识别时返回空列表
+func (s *fundService) GetRelated(ctx context.Context, userID int64, code string) ([]model.SectorFund, error) {
+	code, err := normalizeFundCode(code, s.padShortCodes)
+	if err != nil {
+		return nil, err
+	}
+	fund, err := s.fundRepo.GetFundByCode(ctx, userID, code)
 	if err != nil {
 		return nil, err
 	}
 
-	// 缓存结果
-	_ = s.cache.SetJSON(ctx, cacheKey, val, TTLFundValuation)
+	related := []model.SectorFund{}
+	if len(fund.Sectors) == 0 || s.sectorService == nil {
+		return related, nil
+	}
 
-	return val, nil
+	sectorIDs, err := s.resolveSectorIDs(ctx, fund.Sectors)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{fund.FundCode: true}
+	var lastErr error
+	fetched := 0
+	for _, sectorID := range sectorIDs {
+		funds, err := s.sectorService.GetSectorFunds(ctx, sectorID)
+		if err != nil {
+			// 单个板块失败不影响其他板块
+			lastErr = err
+			continue
+		}
+		fetched++
+
+		for _, f := range funds {
+			if seen[f.Code] {
+				continue
+			}
+			seen[f.Code] = true
+			related = append(related, f)
+		}
+	}
+
+	if fetched == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	related = SortSectorFunds(related, "year1", true)
+	if len(related) > MaxRelatedFunds {
+		related = related[:MaxRelatedFunds]
+	}
+	return related, nil
+}
+
+// resolveSectorIDs 将用户标记的板块（名称或 ID）转换为板块 ID，无法识别的标记被忽略
+func (s *fundService) resolveSectorIDs(ctx context.Context, marks []string) ([]string, error) {
+	sectors, err := s.sectorService.GetSectorList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]string, len(sectors))
+	byID := make(map[string]bool, len(sectors))
+	for _, sector := range sectors {
+		byName[sector.Name] = sector.ID
+		byID[sector.ID] = true
+	}
+
+	var ids []string
+	added := make(map[string]bool)
+	for _, mark := range marks {
+		mark = strings.TrimSpace(mark)
+		id, ok := byName[mark]
+		if !ok && byID[mark] {
+			id, ok = mark, true
+		}
+		if !ok || added[id] {
+			continue
+		}
+		added[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
 }
 
 // CalculateConsecutiveDays 计算连涨/跌天数
@@ -0,0 +1,521 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeFundRepo 记录查询过的基金代码
+// 未设置 funds 与 userFunds 时所有基金均视为已存在；设置 funds 时只返回 funds 中的基金；
+// 设置 userFunds 时按用户查找和修改自选基金
+type fakeFundRepo struct {
+	repository.UserFundRepository
+	lookedUp  []string
+	funds     map[string]*model.UserFund
+	userFunds map[int64]map[string]*model.UserFund
+	// ignoreUserID 模拟修改语句遗漏 user_id 条件的仓库缺陷：按代码修改任意用户的记录
+	ignoreUserID bool
+	// crossTenantLookup 模拟查询语句遗漏 user_id 条件的仓库缺陷：按代码返回任意用户的记录
+	crossTenantLookup bool
+	mutations         int
+}
+
+func (r *fakeFundRepo) GetFundByCode(ctx context.Context, userID int64, fundCode string) (*model.UserFund, error) {
+	r.lookedUp = append(r.lookedUp, fundCode)
+	if r.userFunds != nil {
+		for owner, funds := range r.userFunds {
+			if fund, ok := funds[fundCode]; ok && (owner == userID || r.crossTenantLookup) {
+				return fund, nil
+			}
+		}
+		return nil, repository.ErrFundNotFound
+	}
+	if r.funds != nil {
+		fund, ok := r.funds[fundCode]
+		if !ok {
+			return nil, repository.ErrFundNotFound
+		}
+		return fund, nil
+	}
+	return &model.UserFund{UserID: userID, FundCode: fundCode}, nil
+}
+
+// fakeSectorService 返回固定的板块列表和板块基金
+type fakeSectorService struct {
+	SectorService
+	sectors []model.Sector
+	funds   map[string][]model.SectorFund
+	queried []string
+}
+
+func (s *fakeSectorService) GetSectorList(ctx context.Context) ([]model.Sector, error) {
+	return s.sectors, nil
+}
+
+func (s *fakeSectorService) GetSectorFunds(ctx context.Context, sectorID string) ([]model.SectorFund, error) {
+	s.queried = append(s.queried, sectorID)
+	funds, ok := s.funds[sectorID]
+	if !ok {
+		return nil, errors.New("sector not found")
+	}
+	return funds, nil
+}
+
+func TestFundCodePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		allow   []string
+		deny    []string
+		code    string
+		allowed bool
+	}{
+		{"unconfigured allows all", nil, nil, "000001", true},
+		{"in allow list", []string{"000001", "110022"}, nil, "110022", true},
+		{"not in allow list", []string{"000001"}, nil, "110022", false},
+		{"in deny list", nil, []string{"110022"}, "110022", false},
+		{"not in deny list", nil, []string{"110022"}, "000001", true},
+		{"deny overrides allow", []string{"110022"}, []string{"110022"}, "110022", false},
+		{"whitespace trimmed", []string{" 000001 "}, nil, "000001 ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewFundCodePolicy(tt.allow, tt.deny)
+			assert.Equal(t, tt.allowed, policy.Allowed(tt.code))
+		})
+	}
+}
+
+func TestNewFundCodePolicy_EmptyListsIsNil(t *testing.T) {
+	assert.Nil(t, NewFundCodePolicy(nil, []string{"", "  "}))
+}
+
+func TestFundService_AddFund_Denied(t *testing.T) {
+	repo := &fakeFundRepo{}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, Policy: NewFundCodePolicy(nil, []string{"110022"})})
+
+	_, err := svc.AddFund(context.Background(), 1, "110022")
+
+	assert.ErrorIs(t, err, ErrFundNotAllowed)
+	assert.Empty(t, repo.lookedUp, "denied code should be rejected before any lookup")
+}
+
+func TestFundService_AddFund_NotInAllowList(t *testing.T) {
+	repo := &fakeFundRepo{}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, Policy: NewFundCodePolicy([]string{"000001"}, nil)})
+
+	_, err := svc.AddFund(context.Background(), 1, "110022")
+
+	assert.ErrorIs(t, err, ErrFundNotAllowed)
+	assert.Empty(t, repo.lookedUp)
+}
+
+func TestFundService_AddFund_Allowed(t *testing.T) {
+	repo := &fakeFundRepo{}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, Policy: NewFundCodePolicy([]string{"000001"}, nil)})
+
+	// 允许的代码会继续进入正常流程（此处基金已存在）
+	_, err := svc.AddFund(context.Background(), 1, "000001")
+
+	assert.ErrorIs(t, err, ErrFundExists)
+	assert.Equal(t, []string{"000001"}, repo.lookedUp)
+}
+
+func TestFundService_AddFund_Unconfigured(t *testing.T) {
+	repo := &fakeFundRepo{}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo})
+
+	_, err := svc.AddFund(context.Background(), 1, "110022")
+
+	assert.ErrorIs(t, err, ErrFundExists)
+	assert.Equal(t, []string{"110022"}, repo.lookedUp)
+}
+
+func TestNormalizeFundCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"plain", "110022", "110022"},
+		{"whitespace", "  000001\t", "000001"},
+		{"leading zeros stripped", "1", "000001"},
+		{"partially padded", "1234", "001234"},
+		{"exchange prefix", "SH000001", "000001"},
+		{"lowercase prefix", "sz159915", "159915"},
+		{"dotted prefix", "SZ.159915", "159915"},
+		{"exchange suffix", "510300.SH", "510300"},
+		{"off-exchange suffix", " 000001.of ", "000001"},
+		{"prefix with stripped zeros", "SH1", "000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeFundCode(tt.code, true)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeFundCode_Invalid(t *testing.T) {
+	for _, code := range []string{"", "   ", "SH", "0000001", "11002A", "000 001", "HK000001", "SHSZ000001", "-00001", "000001.SH.SH"} {
+		t.Run(code, func(t *testing.T) {
+			_, err := normalizeFundCode(code, true)
+			assert.ErrorIs(t, err, ErrInvalidFundCode)
+		})
+	}
+}
+
+func TestNormalizeFundCode_PaddingDisabled(t *testing.T) {
+	_, err := normalizeFundCode("1234", false)
+	assert.ErrorIs(t, err, ErrInvalidFundCode)
+
+	code, err := normalizeFundCode("SH000001", false)
+	require.NoError(t, err)
+	assert.Equal(t, "000001", code)
+}
+
+func TestFundService_NormalizesCodeBeforeLookup(t *testing.T) {
+	repo := &fakeFundRepo{}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, PadShortCodes: true})
+	ctx := context.Background()
+
+	_, err := svc.AddFund(ctx, 1, " sh110022 ")
+	assert.ErrorIs(t, err, ErrFundExists)
+	_, err = svc.GetRelated(ctx, 1, "1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"110022", "000001"}, repo.lookedUp)
+}
+
+func TestFundService_InvalidCodeRejectedBeforeUpstream(t *testing.T) {
+	repo := &fakeFundRepo{}
+	funds := &mockFundCrawler{}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, FundCrawler: funds})
+	ctx := context.Background()
+
+	_, err := svc.AddFund(ctx, 1, "abc")
+	assert.ErrorIs(t, err, ErrInvalidFundCode)
+	assert.ErrorIs(t, svc.DeleteFund(ctx, 1, "1234567"), ErrInvalidFundCode)
+	_, err = svc.SearchFund(ctx, "基金")
+	assert.ErrorIs(t, err, ErrInvalidFundCode)
+	_, err = svc.GetRelated(ctx, 1, "")
+	assert.ErrorIs(t, err, ErrInvalidFundCode)
+
+	assert.Empty(t, repo.lookedUp)
+	assert.Empty(t, funds.counts)
+}
+
+// newValuationTestService 创建使用模拟上游的基金服务，上游调用在 release 关闭前阻塞
+func newValuationTestService(release <-chan struct{}) (*fundService, *sync.Map) {
+	calls := &sync.Map{}
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, Cache: NewMemoryCache()}).(*fundService)
+	svc.fetchValuation = func(ctx context.Context, fundKey string) (*model.FundValuation, error) {
+		n, _ := calls.LoadOrStore(fundKey, new(int32))
+		atomic.AddInt32(n.(*int32), 1)
+		<-release
+		return &model.FundValuation{Code: fundKey, Valuation: "1.2345"}, nil
+	}
+	return svc, calls
+}
+
+func upstreamCalls(calls *sync.Map, fundKey string) int32 {
+	n, ok := calls.Load(fundKey)
+	if !ok {
+		return 0
+	}
+	return atomic.LoadInt32(n.(*int32))
+}
+
+func TestFundService_GetFundValuation_CoalescesConcurrentRequests(t *testing.T) {
+	release := make(chan struct{})
+	svc, calls := newValuationTestService(release)
+
+	keys := []string{"A", "A", "A", "B", "B", "C", "A", "B"}
+	var wg sync.WaitGroup
+	results := make([]*model.FundValuation, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			results[i], errs[i] = svc.GetFundValuation(context.Background(), key)
+		}(i, key)
+	}
+
+	// 等待所有请求进入上游调用或等待合并结果
+	require.Eventually(t, func() bool {
+		return upstreamCalls(calls, "A") == 1 && upstreamCalls(calls, "B") == 1 && upstreamCalls(calls, "C") == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, key := range keys {
+		require.NoError(t, errs[i])
+		assert.Equal(t, key, results[i].Code)
+	}
+	assert.Equal(t, int32(1), upstreamCalls(calls, "A"), "overlapping requests should share one upstream call")
+	assert.Equal(t, int32(1), upstreamCalls(calls, "B"))
+	assert.Equal(t, int32(1), upstreamCalls(calls, "C"), "distinct codes should each be fetched")
+
+	// 之后的请求命中缓存
+	_, err := svc.GetFundValuation(context.Background(), "A")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), upstreamCalls(calls, "A"))
+}
+
+func TestFundService_GetFundValuation_CallerCancelDoesNotFailOthers(t *testing.T) {
+	release := make(chan struct{})
+	svc, calls := newValuationTestService(release)
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := svc.GetFundValuation(cancelCtx, "A")
+		cancelled <- err
+	}()
+	require.Eventually(t, func() bool { return upstreamCalls(calls, "A") == 1 }, time.Second, time.Millisecond)
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := svc.GetFundValuation(context.Background(), "A")
+		waiting <- err
+	}()
+
+	cancel()
+	assert.True(t, errors.Is(<-cancelled, context.Canceled))
+
+	close(release)
+	assert.NoError(t, <-waiting)
+	assert.Equal(t, int32(1), upstreamCalls(calls, "A"))
+}
+
+func newRelatedFundsFixture() (*fakeFundRepo, *fakeSectorService) {
+	repo := &fakeFundRepo{funds: map[string]*model.UserFund{
+		"000001": {FundCode: "000001", Sectors: []string{"半导体", "BK0428"}},
+		"000002": {FundCode: "000002"},
+		"000003": {FundCode: "000003", Sectors: []string{"不存在的板块"}},
+	}}
+	sectors := &fakeSectorService{
+		sectors: []model.Sector{
+			{ID: "BK1036", Name: "半导体"},
+			{ID: "BK0428", Name: "电力"},
+		},
+		funds: map[string][]model.SectorFund{
+			"BK1036": {
+				{Code: "000001", Year1: "50.00"},
+				{Code: "100001", Year1: "12.50"},
+				{Code: "100002", Year1: "30.10"},
+			},
+			"BK0428": {
+				{Code: "100002", Year1: "30.10"},
+				{Code: "200001", Year1: "-3.20"},
+				{Code: "200002", Year1: "18.00"},
+			},
+		},
+	}
+	return repo, sectors
+}
+
+func TestFundService_GetRelated_RankedPeers(t *testing.T) {
+	repo, sectors := newRelatedFundsFixture()
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, SectorService: sectors})
+
+	related, err := svc.GetRelated(context.Background(), 1, "000001")
+
+	require.NoError(t, err)
+	codes := make([]string, len(related))
+	for i, f := range related {
+		codes[i] = f.Code
+	}
+	// 排除自身、跨板块去重、按近一年收益降序
+	assert.Equal(t, []string{"100002", "200002", "100001", "200001"}, codes)
+	assert.Equal(t, []string{"BK1036", "BK0428"}, sectors.queried)
+}
+
+func TestFundService_GetRelated_UntaggedFund(t *testing.T) {
+	repo, sectors := newRelatedFundsFixture()
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, SectorService: sectors})
+
+	for _, code := range []string{"000002", "000003"} {
+		related, err := svc.GetRelated(context.Background(), 1, code)
+
+		require.NoError(t, err)
+		assert.NotNil(t, related)
+		assert.Empty(t, related)
+	}
+	assert.Empty(t, sectors.queried)
+}
+
+func TestFundService_GetRelated_FundNotFound(t *testing.T) {
+	repo, sectors := newRelatedFundsFixture()
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, SectorService: sectors})
+
+	_, err := svc.GetRelated(context.Background(), 1, "999999")
+
+	assert.ErrorIs(t, err, repository.ErrFundNotFound)
+}
+
+// fakeFundListRepo 返回固定的自选基金列表
+type fakeFundListRepo struct {
+	repository.UserFundRepository
+	funds []model.UserFund
+}
+
+func (r *fakeFundListRepo) GetFundsByUserID(ctx context.Context, userID int64) ([]model.UserFund, error) {
+	return r.funds, nil
+}
+
+func TestFundService_GetFundList_AttachesCachedMetadata(t *testing.T) {
+	repo := &fakeFundListRepo{funds: []model.UserFund{
+		{FundCode: "000001", FundKey: "K1"},
+		{FundCode: "000002", FundKey: "K2"}, // 数据源没有元数据
+	}}
+	funds := &mockFundCrawler{
+		valuations: map[string]*model.FundValuation{"K1": {Code: "000001"}, "K2": {Code: "000002"}},
+		metas:      map[string]*model.FundMeta{"K1": {Manager: "张三", Scale: "45.67亿元", InceptionDate: "2001-12-18"}},
+	}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, FundCrawler: funds, Cache: NewMemoryCache(), FetchMetadata: true})
+
+	for i := 0; i < 2; i++ {
+		list, err := svc.GetFundList(context.Background(), 1)
+
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.NotNil(t, list[0].Meta)
+		assert.Equal(t, "张三", list[0].Meta.Manager)
+		assert.Equal(t, "45.67亿元", list[0].Meta.Scale)
+		assert.Nil(t, list[1].Meta)
+		assert.NotNil(t, list[1].Valuation)
+	}
+
+	// 第二次请求命中缓存
+	assert.Equal(t, 1, funds.count("GetFundMeta:K1"))
+}
+
+func TestFundService_GetFundList_MetadataDisabled(t *testing.T) {
+	repo := &fakeFundListRepo{funds: []model.UserFund{{FundCode: "000001", FundKey: "K1"}}}
+	funds := &mockFundCrawler{
+		valuations: map[string]*model.FundValuation{"K1": {Code: "000001"}},
+		metas:      map[string]*model.FundMeta{"K1": {Manager: "张三"}},
+	}
+	svc := NewFundService(FundServiceOptions{FundRepo: repo, FundCrawler: funds, Cache: NewMemoryCache()})
+
+	list, err := svc.GetFundList(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Nil(t, list[0].Meta)
+	assert.Equal(t, 0, funds.count("GetFundMeta:K1"))
+
+	_, err = svc.GetFundMeta(context.Background(), "K1")
+	assert.ErrorIs(t, err, ErrFundMetaDisabled)
+}
+
+// newTenantFundRepo 创建按用户保存自选基金的仓库，用户 1 与用户 2 各有一只基金
+func newTenantFundRepo() *fakeFundRepo {
+	return &fakeFundRepo{userFunds: map[int64]map[string]*model.UserFund{
+		1: {"000001": {UserID: 1, FundCode: "000001", IsHold: true, Sectors: []string{"BK1"}}},
+		2: {"110022": {UserID: 2, FundCode: "110022"}},
+	}}
+}
+
+// find 查找要修改的记录，ignoreUserID 时匹配任意用户
+func (r *fakeFundRepo) find(userID int64, fundCode string) (int64, *model.UserFund) {
+	r.mutations++
+	for owner, funds := range r.userFunds {
+		if fund, ok := funds[fundCode]; ok && (owner == userID || r.ignoreUserID) {
+			return owner, fund
+		}
+	}
+	return 0, nil
+}
+
+func (r *fakeFundRepo) DeleteFund(ctx context.Context, userID int64, fundCode string) error {
+	owner, fund := r.find(userID, fundCode)
+	if fund == nil {
+		return repository.ErrFundNotFound
+	}
+	delete(r.userFunds[owner], fundCode)
+	return nil
+}
+
+func (r *fakeFundRepo) UpdateHoldStatus(ctx context.Context, userID int64, fundCode string, isHold bool) error {
+	_, fund := r.find(userID, fundCode)
+	if fund == nil {
+		return repository.ErrFundNotFound
+	}
+	fund.IsHold = isHold
+	return nil
+}
+
+func (r *fakeFundRepo) UpdateSectors(ctx context.Context, userID int64, fundCode string, sectors []string) error {
+	_, fund := r.find(userID, fundCode)
+	if fund == nil {
+		return repository.ErrFundNotFound
+	}
+	fund.Sectors = sectors
+	return nil
+}
+
+func newTenantFundService(repo repository.UserFundRepository) (FundService, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	return NewFundService(FundServiceOptions{FundRepo: repo, Logger: zap.New(core)}), logs
+}
+
+func TestFundService_CannotModifyOtherUsersFund(t *testing.T) {
+	repo := newTenantFundRepo()
+	repo.ignoreUserID = true
+	svc, logs := newTenantFundService(repo)
+	ctx := context.Background()
+
+	// 用户 2 使用用户 1 的有效基金代码
+	assert.ErrorIs(t, svc.UpdateHoldStatus(ctx, 2, "000001", false), ErrFundNotFound)
+	assert.ErrorIs(t, svc.UpdateSectors(ctx, 2, "000001", []string{"BK9"}), ErrFundNotFound)
+	assert.ErrorIs(t, svc.DeleteFund(ctx, 2, "000001"), ErrFundNotFound)
+
+	// 即使仓库修改语句存在缺陷，也不会执行修改
+	assert.Equal(t, 0, repo.mutations)
+	fund := repo.userFunds[1]["000001"]
+	require.NotNil(t, fund)
+	assert.True(t, fund.IsHold)
+	assert.Equal(t, []string{"BK1"}, []string(fund.Sectors))
+	assert.Equal(t, 3, logs.FilterMessage("Fund mutation rejected: fund not in user's list").FilterField(zap.Int64("userID", 2)).Len())
+
+	// 用户修改自己的基金不受影响
+	require.NoError(t, svc.UpdateHoldStatus(ctx, 1, "000001", false))
+	assert.False(t, repo.userFunds[1]["000001"].IsHold)
+	require.NoError(t, svc.DeleteFund(ctx, 1, "000001"))
+	assert.Empty(t, repo.userFunds[1])
+}
+
+func TestFundService_CrossTenantOwnerMismatchLogged(t *testing.T) {
+	repo := newTenantFundRepo()
+	repo.crossTenantLookup = true
+	svc, logs := newTenantFundService(repo)
+
+	err := svc.DeleteFund(context.Background(), 2, "000001")
+
+	assert.ErrorIs(t, err, ErrFundNotFound)
+	assert.Equal(t, 0, repo.mutations)
+	assert.Contains(t, repo.userFunds[1], "000001")
+
+	entries := logs.FilterMessage("Suspected cross-tenant fund access").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	assert.Equal(t, int64(1), entries[0].ContextMap()["ownerID"])
+}
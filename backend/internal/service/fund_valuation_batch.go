@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"fund-analyzer/internal/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxValuationBatchSize 批量估值单次请求的最大基金数（去重后）
+	MaxValuationBatchSize = 50
+	// valuationBatchConcurrency 批量估值同时访问上游的基金数上限
+	valuationBatchConcurrency = 5
+
+	// cacheKeyFundKey 基金代码到上游 fundKey 的映射，%s = fund code
+	cacheKeyFundKey = "fund:key:%s"
+)
+
+// ErrValuationBatchTooLarge 批量估值请求的基金数超过上限
+var ErrValuationBatchTooLarge = errors.New("too many fund codes in valuation batch")
+
+// ValuationBatch 批量估值结果
+type ValuationBatch struct {
+	// Valuations 规范化后的基金代码到估值的映射
+	Valuations map[string]*model.FundValuation `json:"valuations"`
+	// Failed 获取失败的基金代码（无效代码保留原始输入），按请求顺序排列
+	Failed []string `json:"failed"`
+}
+
+// GetValuationsBatch 批量获取基金估值
+// 代码规范化后去重，以有限并发逐个获取；估值与代码对应的 fundKey 均使用缓存，单个基金失败计入 Failed 不影响其他基金
+func (s *fundService) GetValuationsBatch(ctx context.Context, codes []string) (*ValuationBatch, error) {
+	batch := &ValuationBatch{
+		Valuations: make(map[string]*model.FundValuation),
+		Failed:     make([]string, 0),
+	}
+
+	// 规范化并去重，保留首次出现的顺序
+	unique := make([]string, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, raw := range codes {
+		code, err := normalizeFundCode(raw, s.padShortCodes)
+		if err != nil {
+			batch.Failed = append(batch.Failed, raw)
+			continue
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		unique = append(unique, code)
+	}
+	if len(unique) > MaxValuationBatchSize {
+		return nil, fmt.Errorf("%w: %d > %d", ErrValuationBatchTooLarge, len(unique), MaxValuationBatchSize)
+	}
+
+	valuations := make([]*model.FundValuation, len(unique))
+	sem := make(chan struct{}, valuationBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, code := range unique {
+		wg.Add(1)
+		go func(i int, code string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			valuation, err := s.getValuationByCode(ctx, code)
+			if err != nil {
+				s.logger.Warn("Batch valuation failed", zap.String("code", code), zap.Error(err))
+				return
+			}
+			valuations[i] = valuation
+		}(i, code)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, code := range unique {
+		if valuations[i] == nil {
+			batch.Failed = append(batch.Failed, code)
+			continue
+		}
+		batch.Valuations[code] = valuations[i]
+	}
+	return batch, nil
+}
+
+// getValuationByCode 按规范化后的基金代码获取估值
+func (s *fundService) getValuationByCode(ctx context.Context, code string) (*model.FundValuation, error) {
+	fundKey, err := s.resolveFundKey(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetFundValuation(ctx, fundKey)
+}
+
+// resolveFundKey 获取基金代码对应的上游 fundKey，映射不随行情变化，缓存时间与基金信息一致
+func (s *fundService) resolveFundKey(ctx context.Context, code string) (string, error) {
+	cacheKey := fmt.Sprintf(cacheKeyFundKey, code)
+
+	var fundKey string
+	if err := s.cache.GetJSON(ctx, cacheKey, &fundKey); err == nil && fundKey != "" {
+		return fundKey, nil
+	}
+
+	info, err := s.fundCrawler.SearchFund(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	if info == nil || info.FundKey == "" {
+		return "", fmt.Errorf("%w: %s", ErrFundNotFound, code)
+	}
+
+	_ = s.cache.SetJSON(ctx, cacheKey, info.FundKey, TTLFundInfo)
+	return info.FundKey, nil
+}
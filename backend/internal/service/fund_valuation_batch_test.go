@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchFundCrawler 模拟蚂蚁财富上游，包含 codes 中的每只基金（fundKey 为 "key-" + 代码）
+func newBatchFundCrawler(codes ...string) *mockFundCrawler {
+	crawler := &mockFundCrawler{
+		funds:      make(map[string]*model.FundInfo),
+		valuations: make(map[string]*model.FundValuation),
+	}
+	for _, code := range codes {
+		crawler.funds[code] = &model.FundInfo{Code: code, FundKey: "key-" + code}
+		crawler.valuations["key-"+code] = &model.FundValuation{Code: code, Valuation: "1.2345"}
+	}
+	return crawler
+}
+
+func TestFundService_GetValuationsBatch(t *testing.T) {
+	funds := newBatchFundCrawler("000001", "110022")
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: funds, Cache: NewMemoryCache()})
+	ctx := context.Background()
+
+	batch, err := svc.GetValuationsBatch(ctx, []string{"000001", "110022", " 000001", "abc", "999999"})
+
+	require.NoError(t, err)
+	assert.Len(t, batch.Valuations, 2)
+	assert.Equal(t, "110022", batch.Valuations["110022"].Code)
+	assert.Equal(t, []string{"abc", "999999"}, batch.Failed)
+	// 重复的代码只访问一次上游
+	assert.Equal(t, 1, funds.count("SearchFund:000001"))
+	assert.Equal(t, 1, funds.count("GetFundValuation:key-000001"))
+
+	// 再次请求全部命中缓存
+	batch, err = svc.GetValuationsBatch(ctx, []string{"000001", "110022"})
+	require.NoError(t, err)
+	assert.Len(t, batch.Valuations, 2)
+	assert.Empty(t, batch.Failed)
+	assert.Equal(t, 1, funds.count("SearchFund:000001"))
+	assert.Equal(t, 1, funds.count("GetFundValuation:key-000001"))
+}
+
+func TestFundService_GetValuationsBatch_ValuationFailureReported(t *testing.T) {
+	funds := newBatchFundCrawler("000001", "110022")
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: funds, Cache: NewMemoryCache()}).(*fundService)
+	svc.fetchValuation = func(ctx context.Context, fundKey string) (*model.FundValuation, error) {
+		if fundKey == "key-110022" {
+			return nil, fmt.Errorf("upstream timeout")
+		}
+		return funds.GetFundValuation(ctx, fundKey)
+	}
+
+	batch, err := svc.GetValuationsBatch(context.Background(), []string{"000001", "110022"})
+
+	require.NoError(t, err)
+	assert.Contains(t, batch.Valuations, "000001")
+	assert.Equal(t, []string{"110022"}, batch.Failed)
+}
+
+func TestFundService_GetValuationsBatch_BoundedConcurrency(t *testing.T) {
+	codes := make([]string, 20)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("%06d", i+1)
+	}
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: newBatchFundCrawler(codes...), Cache: NewMemoryCache()}).(*fundService)
+
+	var inFlight, maxInFlight int32
+	svc.fetchValuation = func(ctx context.Context, fundKey string) (*model.FundValuation, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return &model.FundValuation{Code: fundKey}, nil
+	}
+
+	batch, err := svc.GetValuationsBatch(context.Background(), codes)
+
+	require.NoError(t, err)
+	assert.Len(t, batch.Valuations, len(codes))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(valuationBatchConcurrency))
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1), "valuations should be fetched concurrently")
+}
+
+func TestFundService_GetValuationsBatch_TooLarge(t *testing.T) {
+	codes := make([]string, MaxValuationBatchSize+1)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("%06d", i+1)
+	}
+	funds := newBatchFundCrawler()
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: funds, Cache: NewMemoryCache()})
+
+	_, err := svc.GetValuationsBatch(context.Background(), codes)
+
+	assert.ErrorIs(t, err, ErrValuationBatchTooLarge)
+	assert.Empty(t, funds.counts)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+)
+
+// GoldHistoryMode 历史金价天数的计算方式
+type GoldHistoryMode string
+
+const (
+	// GoldHistoryTrading 按交易日计算：返回最近 N 个数据点
+	GoldHistoryTrading GoldHistoryMode = "trading"
+	// GoldHistoryCalendar 按自然日计算：返回最近 N 个自然日内的数据点，周末与节假日没有数据
+	GoldHistoryCalendar GoldHistoryMode = "calendar"
+)
+
+// ErrInvalidGoldHistoryMode 不支持的历史金价天数计算方式
+var ErrInvalidGoldHistoryMode = errors.New("invalid gold history mode")
+
+// goldHistoryDateLayouts 历史金价日期的候选格式
+var goldHistoryDateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"20060102",
+	"2006-01-02 15:04:05",
+}
+
+// ParseGoldHistoryMode 解析历史金价天数的计算方式，为空时按交易日计算
+func ParseGoldHistoryMode(s string) (GoldHistoryMode, error) {
+	mode := GoldHistoryMode(strings.ToLower(strings.TrimSpace(s)))
+	switch mode {
+	case "":
+		return GoldHistoryTrading, nil
+	case GoldHistoryTrading, GoldHistoryCalendar:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q, supported: trading, calendar", ErrInvalidGoldHistoryMode, s)
+	}
+}
+
+// limitGoldHistory 按计算方式截取历史金价，history 按日期升序
+// 交易日模式保留最后 days 个数据点；自然日模式保留 now 所在日期（北京时间）往前 days 个自然日内的数据点
+func limitGoldHistory(history []model.GoldPrice, days int, mode GoldHistoryMode, now time.Time) []model.GoldPrice {
+	if mode != GoldHistoryCalendar {
+		if len(history) > days {
+			return history[len(history)-days:]
+		}
+		return history
+	}
+
+	today := now.In(crawler.ShanghaiLocation)
+	cutoff := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, crawler.ShanghaiLocation).AddDate(0, 0, -(days - 1))
+
+	result := make([]model.GoldPrice, 0, len(history))
+	for _, item := range history {
+		date, ok := parseGoldHistoryDate(item.Date)
+		if !ok || date.Before(cutoff) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// parseGoldHistoryDate 按北京时间解析历史金价日期
+func parseGoldHistoryDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range goldHistoryDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, crawler.ShanghaiLocation); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
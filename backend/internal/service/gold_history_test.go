@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// goldHistoryFixture 2024-03-01（周五）至 2024-03-12（周二）的交易日数据，跨两个周末
+func goldHistoryFixture() []model.GoldPrice {
+	dates := []string{"2024-03-01", "2024-03-04", "2024-03-05", "2024-03-06", "2024-03-07", "2024-03-08", "2024-03-11", "2024-03-12"}
+	history := make([]model.GoldPrice, len(dates))
+	for i, date := range dates {
+		history[i] = model.GoldPrice{Date: date, ChinaGoldPrice: "480.00"}
+	}
+	return history
+}
+
+func goldHistoryDates(history []model.GoldPrice) []string {
+	dates := make([]string, len(history))
+	for i, item := range history {
+		dates[i] = item.Date
+	}
+	return dates
+}
+
+func TestParseGoldHistoryMode(t *testing.T) {
+	for input, want := range map[string]GoldHistoryMode{
+		"":          GoldHistoryTrading,
+		"trading":   GoldHistoryTrading,
+		" Calendar": GoldHistoryCalendar,
+	} {
+		mode, err := ParseGoldHistoryMode(input)
+		require.NoError(t, err)
+		assert.Equal(t, want, mode)
+	}
+
+	_, err := ParseGoldHistoryMode("weekly")
+	assert.ErrorIs(t, err, ErrInvalidGoldHistoryMode)
+}
+
+func TestLimitGoldHistory(t *testing.T) {
+	// UTC 仍是 3 月 11 日，按北京时间（3 月 12 日 07:30）计算自然日
+	now := time.Date(2024, 3, 11, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		days int
+		mode GoldHistoryMode
+		want []string
+	}{
+		{"trading days", 7, GoldHistoryTrading, []string{"2024-03-04", "2024-03-05", "2024-03-06", "2024-03-07", "2024-03-08", "2024-03-11", "2024-03-12"}},
+		{"trading days beyond series", 30, GoldHistoryTrading, goldHistoryDates(goldHistoryFixture())},
+		{"calendar week spans weekend", 7, GoldHistoryCalendar, []string{"2024-03-06", "2024-03-07", "2024-03-08", "2024-03-11", "2024-03-12"}},
+		{"calendar days from monday", 2, GoldHistoryCalendar, []string{"2024-03-11", "2024-03-12"}},
+		{"calendar window starts on sunday", 3, GoldHistoryCalendar, []string{"2024-03-11", "2024-03-12"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := limitGoldHistory(goldHistoryFixture(), tt.days, tt.mode, now)
+			assert.Equal(t, tt.want, goldHistoryDates(got))
+		})
+	}
+}
+
+func TestMarketService_GetGoldHistory_Modes(t *testing.T) {
+	gold := &mockGoldCrawler{history: goldHistoryFixture()}
+	svc := NewMarketService(nil, gold, NewMemoryCache()).(*marketService)
+	svc.now = func() time.Time { return time.Date(2024, 3, 12, 15, 0, 0, 0, crawler.ShanghaiLocation) }
+	ctx := context.Background()
+
+	trading, err := svc.GetGoldHistory(ctx, 7, GoldHistoryTrading)
+	require.NoError(t, err)
+	assert.Len(t, trading, 7)
+
+	// 原始序列已缓存，自然日模式不再请求上游
+	calendar, err := svc.GetGoldHistory(ctx, 7, GoldHistoryCalendar)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-03-06", "2024-03-07", "2024-03-08", "2024-03-11", "2024-03-12"}, goldHistoryDates(calendar))
+	assert.Equal(t, 1, gold.count("GetGoldHistory"))
+}
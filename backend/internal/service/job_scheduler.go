@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultJobStaleIntervals 任务超过多少个执行间隔没有成功视为停滞
+const DefaultJobStaleIntervals = 3
+
+// Job 定期执行的后台任务
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Critical 关键任务停滞时就绪检查返回 503
+	Critical bool
+	// Timeout 单次执行超时，0 表示使用 Interval
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// JobStatus 后台任务的执行状态
+type JobStatus struct {
+	Name           string     `json:"name"`
+	IntervalSec    int64      `json:"intervalSec"`
+	Critical       bool       `json:"critical"`
+	Running        bool       `json:"running"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	LastRun        *time.Time `json:"lastRun,omitempty"`
+	LastSuccess    *time.Time `json:"lastSuccess,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	LastErrorAt    *time.Time `json:"lastErrorAt,omitempty"`
+	LastDurationMs int64      `json:"lastDurationMs"`
+	// Stale 超过 DefaultJobStaleIntervals 个执行间隔没有成功
+	Stale bool `json:"stale"`
+}
+
+// jobState 单个任务的运行记录
+type jobState struct {
+	job         Job
+	running     bool
+	runs        int64
+	failures    int64
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+	lastLatency time.Duration
+}
+
+// JobScheduler 按固定间隔执行后台任务，并记录每个任务最近的执行与成功时间、错误
+// 供 /api/v1/admin/jobs 查看，关键任务停滞时影响就绪检查
+type JobScheduler struct {
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	jobs    map[string]*jobState
+	started time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewJobScheduler 创建后台任务调度器
+func NewJobScheduler(logger *zap.Logger) *JobScheduler {
+	return &JobScheduler{
+		logger: logger,
+		now:    time.Now,
+		jobs:   make(map[string]*jobState),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Add 注册任务，需在 Start 之前调用；同名任务会被替换
+func (s *JobScheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.Name] = &jobState{job: job}
+}
+
+// Start 启动所有任务，每个任务启动时先执行一次，之后按间隔执行
+func (s *JobScheduler) Start() {
+	s.mu.Lock()
+	s.started = s.now()
+	states := make([]*jobState, 0, len(s.jobs))
+	for _, state := range s.jobs {
+		states = append(states, state)
+	}
+	s.mu.Unlock()
+
+	for _, state := range states {
+		s.wg.Add(1)
+		go s.loop(state.job)
+	}
+}
+
+// Stop 停止调度并等待正在执行的任务结束，可重复调用
+func (s *JobScheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.wg.Wait()
+}
+
+// loop 按间隔执行单个任务
+func (s *JobScheduler) loop(job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunNow(job.Name)
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// RunNow 立即执行一次任务并记录结果，任务不存在时返回 false
+func (s *JobScheduler) RunNow(name string) bool {
+	s.mu.Lock()
+	state, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return false
+	}
+	job := state.job
+	state.running = true
+	start := s.now()
+	state.lastRun = start
+	s.mu.Unlock()
+
+	timeout := job.Timeout
+	if timeout <= 0 {
+		timeout = job.Interval
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	err := runJob(ctx, job)
+	cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	end := s.now()
+	state.running = false
+	state.runs++
+	state.lastLatency = end.Sub(start)
+	if err != nil {
+		state.failures++
+		state.lastError = err.Error()
+		state.lastErrorAt = end
+		s.logger.Warn("Background job failed", zap.String("job", job.Name), zap.Error(err))
+		return true
+	}
+	state.lastSuccess = end
+	return true
+}
+
+// runJob 执行任务，panic 作为错误记录
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// Statuses 获取所有任务的执行状态，按名称排序
+func (s *JobScheduler) Statuses() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, state := range s.jobs {
+		status := JobStatus{
+			Name:           state.job.Name,
+			IntervalSec:    int64(state.job.Interval / time.Second),
+			Critical:       state.job.Critical,
+			Running:        state.running,
+			Runs:           state.runs,
+			Failures:       state.failures,
+			LastRun:        optionalTime(state.lastRun),
+			LastSuccess:    optionalTime(state.lastSuccess),
+			LastError:      state.lastError,
+			LastErrorAt:    optionalTime(state.lastErrorAt),
+			LastDurationMs: state.lastLatency.Milliseconds(),
+			Stale:          s.staleLocked(state, now),
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// StaleCriticalJobs 获取停滞的关键任务名称，按名称排序
+func (s *JobScheduler) StaleCriticalJobs() []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var stale []string
+	for name, state := range s.jobs {
+		if state.job.Critical && s.staleLocked(state, now) {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// staleLocked 任务超过 DefaultJobStaleIntervals 个间隔没有成功；从未成功时从调度启动开始计算，未启动时不算停滞
+func (s *JobScheduler) staleLocked(state *jobState, now time.Time) bool {
+	since := state.lastSuccess
+	if since.IsZero() {
+		since = s.started
+	}
+	if since.IsZero() {
+		return false
+	}
+	return now.Sub(since) > DefaultJobStaleIntervals*state.job.Interval
+}
+
+// optionalTime 零值时间返回 nil，JSON 中省略
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
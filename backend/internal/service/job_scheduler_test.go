@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestJobScheduler 使用可控时钟的调度器，视为已在 *now 启动
+func newTestJobScheduler(now *time.Time) *JobScheduler {
+	s := NewJobScheduler(zap.NewNop())
+	s.now = func() time.Time { return *now }
+	s.started = *now
+	return s
+}
+
+func TestJobScheduler_RecordsFailureAndSuccess(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	s := newTestJobScheduler(&now)
+	fail := true
+	s.Add(Job{Name: "blacklist_cleanup", Interval: time.Hour, Run: func(ctx context.Context) error {
+		if fail {
+			return errors.New("connection refused")
+		}
+		return nil
+	}})
+
+	require.True(t, s.RunNow("blacklist_cleanup"))
+	status := s.Statuses()[0]
+	assert.Equal(t, int64(1), status.Runs)
+	assert.Equal(t, int64(1), status.Failures)
+	assert.Equal(t, "connection refused", status.LastError)
+	assert.Equal(t, now, *status.LastRun)
+	assert.Equal(t, now, *status.LastErrorAt)
+	assert.Nil(t, status.LastSuccess)
+	assert.Equal(t, int64(3600), status.IntervalSec)
+
+	fail = false
+	now = now.Add(time.Hour)
+	s.RunNow("blacklist_cleanup")
+	status = s.Statuses()[0]
+	assert.Equal(t, int64(2), status.Runs)
+	assert.Equal(t, int64(1), status.Failures)
+	assert.Equal(t, now, *status.LastSuccess)
+	// 保留最近一次错误便于排查
+	assert.Equal(t, "connection refused", status.LastError)
+
+	assert.False(t, s.RunNow("missing"))
+}
+
+func TestJobScheduler_PanicRecordedAsError(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	s := newTestJobScheduler(&now)
+	s.Add(Job{Name: "snapshot", Interval: time.Minute, Run: func(ctx context.Context) error {
+		panic("nil map")
+	}})
+
+	s.RunNow("snapshot")
+
+	status := s.Statuses()[0]
+	assert.Equal(t, int64(1), status.Failures)
+	assert.Contains(t, status.LastError, "nil map")
+	assert.False(t, status.Running)
+}
+
+func TestJobScheduler_StaleCriticalJobs(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	s := newTestJobScheduler(&now)
+	failing := func(ctx context.Context) error { return errors.New("upstream down") }
+	s.Add(Job{Name: "alerts", Interval: time.Minute, Critical: true, Run: failing})
+	s.Add(Job{Name: "cleanup", Interval: time.Minute, Run: failing})
+	s.Add(Job{Name: "snapshot", Interval: time.Minute, Critical: true, Run: func(ctx context.Context) error { return nil }})
+
+	for _, name := range []string{"alerts", "cleanup", "snapshot"} {
+		s.RunNow(name)
+	}
+	assert.Empty(t, s.StaleCriticalJobs())
+
+	// 超过 3 个间隔没有成功
+	now = now.Add(3*time.Minute + time.Second)
+	s.RunNow("snapshot")
+	assert.Equal(t, []string{"alerts"}, s.StaleCriticalJobs())
+
+	statuses := s.Statuses()
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Stale)
+	assert.True(t, statuses[1].Stale, "non-critical jobs are reported but do not affect readiness")
+	assert.False(t, statuses[2].Stale)
+
+	var nilScheduler *JobScheduler
+	assert.Empty(t, nilScheduler.StaleCriticalJobs())
+}
+
+func TestJobScheduler_StartRunsImmediatelyAndStops(t *testing.T) {
+	s := NewJobScheduler(zap.NewNop())
+	ran := make(chan struct{}, 1)
+	s.Add(Job{Name: "cleanup", Interval: time.Hour, Run: func(ctx context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}})
+
+	s.Start()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job did not run on start")
+	}
+	s.Stop()
+	s.Stop()
+
+	assert.Equal(t, int64(1), s.Statuses()[0].Runs)
+}
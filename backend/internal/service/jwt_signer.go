@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"fund-analyzer/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT 签名算法
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+)
+
+var (
+	// ErrUnsupportedJWTAlgorithm 不支持的 JWT 签名算法
+	ErrUnsupportedJWTAlgorithm = errors.New("unsupported jwt algorithm")
+	// ErrInvalidJWTKey JWT 密钥缺失或无法解析
+	ErrInvalidJWTKey = errors.New("invalid jwt key")
+)
+
+// JWTSigner 按配置的算法签发与校验 Token
+// HS256 使用共享密钥；RS256 使用私钥签名、公钥校验，其他服务只需持有公钥即可校验
+type JWTSigner struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewJWTSigner 根据配置创建签名器，算法为空时使用 HS256
+// RS256 从 PEM 文件加载密钥对，未配置公钥时由私钥推导
+func NewJWTSigner(cfg config.JWTConfig) (*JWTSigner, error) {
+	switch strings.ToUpper(strings.TrimSpace(cfg.Algorithm)) {
+	case "", JWTAlgorithmHS256:
+		return newHMACSigner(cfg.Secret), nil
+	case JWTAlgorithmRS256:
+		return newRSASigner(cfg.PrivateKeyPath, cfg.PublicKeyPath)
+	default:
+		return nil, fmt.Errorf("%w: %q, supported: %s, %s", ErrUnsupportedJWTAlgorithm, cfg.Algorithm, JWTAlgorithmHS256, JWTAlgorithmRS256)
+	}
+}
+
+// newHMACSigner 创建 HS256 签名器
+func newHMACSigner(secret string) *JWTSigner {
+	return &JWTSigner{
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// newRSASigner 从 PEM 文件创建 RS256 签名器
+func newRSASigner(privateKeyPath, publicKeyPath string) (*JWTSigner, error) {
+	if privateKeyPath == "" {
+		return nil, fmt.Errorf("%w: private key path is required for %s", ErrInvalidJWTKey, JWTAlgorithmRS256)
+	}
+	raw, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: read private key: %v", ErrInvalidJWTKey, err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parse private key: %v", ErrInvalidJWTKey, err)
+	}
+
+	publicKey := &privateKey.PublicKey
+	if publicKeyPath != "" {
+		raw, err := os.ReadFile(publicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read public key: %v", ErrInvalidJWTKey, err)
+		}
+		publicKey, err = jwt.ParseRSAPublicKeyFromPEM(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parse public key: %v", ErrInvalidJWTKey, err)
+		}
+		if !publicKey.Equal(&privateKey.PublicKey) {
+			return nil, fmt.Errorf("%w: public key does not match private key", ErrInvalidJWTKey)
+		}
+	}
+
+	return &JWTSigner{
+		method:    jwt.SigningMethodRS256,
+		signKey:   privateKey,
+		verifyKey: publicKey,
+	}, nil
+}
+
+// Algorithm 返回签名算法名称
+func (s *JWTSigner) Algorithm() string {
+	return s.method.Alg()
+}
+
+// Sign 签发 Token
+func (s *JWTSigner) Sign(claims jwt.Claims) (string, error) {
+	return jwt.NewWithClaims(s.method, claims).SignedString(s.signKey)
+}
+
+// Parse 解析并校验 Token
+// 严格校验 alg 头必须与配置的算法一致，防止以公钥作为 HMAC 密钥等算法混淆攻击
+func (s *JWTSigner) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != s.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+		}
+		return s.verifyKey, nil
+	}, jwt.WithValidMethods([]string{s.method.Alg()}))
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRSAKeyPair 生成 RSA 密钥对并写入 PEM 文件，返回私钥与公钥文件路径
+func writeRSAKeyPair(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "jwt.key")
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	require.NoError(t, os.WriteFile(privatePath, privatePEM, 0o600))
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicPath := filepath.Join(dir, "jwt.pub")
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o644))
+	return privatePath, publicPath
+}
+
+func testClaims() *model.Claims {
+	return &model.Claims{
+		UserID: 1,
+		Email:  "user@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestJWTSigner_SignAndParse(t *testing.T) {
+	privatePath, publicPath := writeRSAKeyPair(t)
+
+	tests := []struct {
+		name string
+		cfg  config.JWTConfig
+		alg  string
+	}{
+		{"default", config.JWTConfig{Secret: "test-secret"}, JWTAlgorithmHS256},
+		{"hs256", config.JWTConfig{Algorithm: "hs256", Secret: "test-secret"}, JWTAlgorithmHS256},
+		{"rs256", config.JWTConfig{Algorithm: "RS256", PrivateKeyPath: privatePath, PublicKeyPath: publicPath}, JWTAlgorithmRS256},
+		{"rs256 derived public key", config.JWTConfig{Algorithm: "RS256", PrivateKeyPath: privatePath}, JWTAlgorithmRS256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewJWTSigner(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, signer.Algorithm())
+
+			tokenString, err := signer.Sign(testClaims())
+			require.NoError(t, err)
+
+			claims := &model.Claims{}
+			token, err := signer.Parse(tokenString, claims)
+			require.NoError(t, err)
+			assert.True(t, token.Valid)
+			assert.Equal(t, tt.alg, token.Header["alg"])
+			assert.Equal(t, int64(1), claims.UserID)
+		})
+	}
+}
+
+func TestJWTSigner_RejectsAlgorithmConfusion(t *testing.T) {
+	privatePath, publicPath := writeRSAKeyPair(t)
+	rsaSigner, err := NewJWTSigner(config.JWTConfig{Algorithm: "RS256", PrivateKeyPath: privatePath, PublicKeyPath: publicPath})
+	require.NoError(t, err)
+
+	// 以共享密钥签名的 HS256 Token
+	hsToken, err := newHMACSigner("test-secret").Sign(testClaims())
+	require.NoError(t, err)
+	_, err = rsaSigner.Parse(hsToken, &model.Claims{})
+	assert.Error(t, err)
+
+	// 以公钥内容作为 HMAC 密钥伪造的 Token
+	publicPEM, err := os.ReadFile(publicPath)
+	require.NoError(t, err)
+	forged, err := newHMACSigner(string(publicPEM)).Sign(testClaims())
+	require.NoError(t, err)
+	_, err = rsaSigner.Parse(forged, &model.Claims{})
+	assert.Error(t, err)
+
+	// 未签名的 Token
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = rsaSigner.Parse(unsigned, &model.Claims{})
+	assert.Error(t, err)
+
+	// 配置为 HS256 时拒绝 RS256 Token
+	rsToken, err := rsaSigner.Sign(testClaims())
+	require.NoError(t, err)
+	_, err = newHMACSigner("test-secret").Parse(rsToken, &model.Claims{})
+	assert.Error(t, err)
+}
+
+func TestNewJWTSigner_InvalidConfig(t *testing.T) {
+	privatePath, _ := writeRSAKeyPair(t)
+	_, otherPublicPath := writeRSAKeyPair(t)
+
+	_, err := NewJWTSigner(config.JWTConfig{Algorithm: "none"})
+	assert.ErrorIs(t, err, ErrUnsupportedJWTAlgorithm)
+
+	_, err = NewJWTSigner(config.JWTConfig{Algorithm: "RS256"})
+	assert.ErrorIs(t, err, ErrInvalidJWTKey)
+
+	_, err = NewJWTSigner(config.JWTConfig{Algorithm: "RS256", PrivateKeyPath: filepath.Join(t.TempDir(), "missing.key")})
+	assert.ErrorIs(t, err, ErrInvalidJWTKey)
+
+	_, err = NewJWTSigner(config.JWTConfig{Algorithm: "RS256", PrivateKeyPath: privatePath, PublicKeyPath: otherPublicPath})
+	assert.ErrorIs(t, err, ErrInvalidJWTKey)
+}
+
+func TestAuthService_RS256Tokens(t *testing.T) {
+	privatePath, _ := writeRSAKeyPair(t)
+	jwtConfig := config.JWTConfig{Algorithm: "RS256", PrivateKeyPath: privatePath, AccessExpireMin: 60, RefreshExpireDay: 7, Issuer: "test"}
+	signer, err := NewJWTSigner(jwtConfig)
+	require.NoError(t, err)
+
+	hash, err := HashPassword("password123")
+	require.NoError(t, err)
+	repo := newFakeUserRepo(&model.User{ID: 1, Email: "user@example.com", PasswordHash: hash, Status: model.UserStatusActive})
+	svc := NewAuthService(repo, jwtConfig, signer, config.EmailConfig{}, DefaultCodeFormat(), nil, nil, nil, nil)
+	ctx := context.Background()
+
+	login, err := svc.Login(ctx, "user@example.com", "password123")
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(ctx, login.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+
+	_, err = svc.RefreshToken(ctx, login.RefreshToken)
+	require.NoError(t, err)
+
+	// 共享密钥签发的 Token 不被接受
+	hsToken, err := newHMACSigner("test-secret").Sign(testClaims())
+	require.NoError(t, err)
+	_, err = svc.ValidateToken(ctx, hsToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,50 @@
+package service
+
+import "unicode"
+
+// Language 回复语言
+type Language string
+
+const (
+	LanguageChinese Language = "zh"
+	LanguageEnglish Language = "en"
+)
+
+// hanWeight 单个汉字相对于单个拉丁字母的权重
+// 汉字信息密度更高，"帮我看看 AAPL 和 TSLA" 这类夹杂代码的中文问题仍判定为中文
+const hanWeight = 3
+
+// detectLanguage 根据汉字与拉丁字母的比例判断文本的主要语言
+// 无法判断（如纯数字、符号）时默认中文
+func detectLanguage(s string) Language {
+	han, latin := 0, 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	if latin > han*hanWeight {
+		return LanguageEnglish
+	}
+	return LanguageChinese
+}
+
+// resolveLanguage 优先使用显式指定的语言，否则根据消息内容检测
+func resolveLanguage(explicit, message string) Language {
+	switch Language(explicit) {
+	case LanguageChinese, LanguageEnglish:
+		return Language(explicit)
+	}
+	return detectLanguage(message)
+}
+
+// languageDirective 要求模型使用指定语言回复的系统指令
+func languageDirective(lang Language) string {
+	if lang == LanguageEnglish {
+		return "The user is writing in English. Respond in English, even though the market data above is in Chinese; translate names of indices, sectors and funds where helpful."
+	}
+	return "请使用简体中文回答用户的问题。"
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Language
+	}{
+		{"chinese", "今天大盘走势怎么样？", LanguageChinese},
+		{"english", "How is the market doing today?", LanguageEnglish},
+		{"chinese with tickers", "帮我看看 AAPL 和 TSLA 的走势", LanguageChinese},
+		{"english with chinese term", "What do you think about the 白酒 sector this week?", LanguageEnglish},
+		{"mostly chinese with english phrase", "最近 AI 概念股还能买吗", LanguageChinese},
+		{"empty", "", LanguageChinese},
+		{"digits only", "000001", LanguageChinese},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectLanguage(tt.input))
+		})
+	}
+}
+
+func TestResolveLanguage_ExplicitOverride(t *testing.T) {
+	assert.Equal(t, LanguageChinese, resolveLanguage("zh", "How is the market today?"))
+	assert.Equal(t, LanguageEnglish, resolveLanguage("en", "今天大盘怎么样"))
+	assert.Equal(t, LanguageEnglish, resolveLanguage("", "How is the market today?"))
+	assert.Equal(t, LanguageChinese, resolveLanguage("fr", "今天大盘怎么样"), "unsupported override falls back to detection")
+}
+
+func TestAIService_Chat_LanguageDirective(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       model.ChatRequest
+		directive string
+	}{
+		{"english question", model.ChatRequest{Message: "How is the market doing today?"}, languageDirective(LanguageEnglish)},
+		{"chinese question", model.ChatRequest{Message: "今天大盘走势怎么样？"}, languageDirective(LanguageChinese)},
+		{"explicit override", model.ChatRequest{Message: "今天大盘走势怎么样？", Language: "en"}, languageDirective(LanguageEnglish)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			svc := &aiService{llmClient: newRecordingLLMClient(t, &requests, contentChunk), dataMatcher: noDataMatcher{}}
+
+			stream := make(chan model.ChatChunk, 100)
+			require.NoError(t, svc.Chat(context.Background(), &tt.req, stream))
+			for range stream {
+			}
+
+			require.Len(t, requests, 1)
+			assert.Contains(t, requests[0], tt.directive)
+		})
+	}
+}
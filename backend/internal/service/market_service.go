@@ -3,6 +3,10 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"fund-analyzer/internal/crawler"
 	"fund-analyzer/internal/model"
@@ -12,56 +16,132 @@ import (
 type MarketService interface {
 	GetGlobalIndices(ctx context.Context) ([]model.MarketIndex, error)
 	GetPreciousMetals(ctx context.Context) ([]model.PreciousMetal, error)
-	GetGoldHistory(ctx context.Context, days int) ([]model.GoldPrice, error)
+	GetGoldHistory(ctx context.Context, days int, mode GoldHistoryMode) ([]model.GoldPrice, error)
 	GetVolumeTrend(ctx context.Context, days int) ([]model.VolumeTrend, error)
-	GetMinuteData(ctx context.Context, minutes int) ([]model.MinuteData, error)
+	GetMinuteData(ctx context.Context, minutes int, granularity MinuteGranularity) ([]model.MinuteData, error)
+	// GenerateSummary 按固定规则生成市场概况文本，不调用大模型，供未启用 AI 时使用
+	GenerateSummary(data *model.MarketData) string
 }
 
 type marketService struct {
-	baiduCrawler *crawler.BaiduCrawler
-	goldCrawler  *crawler.GoldCrawler
-	cache        CacheService
+	marketCrawler crawler.MarketDataCrawler
+	goldCrawler   crawler.GoldDataCrawler
+	cache         CacheService
+	degradation   DegradationService // 非 nil 时缓存未命中经 stale-while-revalidate 获取实时行情
+	now           func() time.Time
+}
+
+// globalIndexRegion 全球指数的地区
+type globalIndexRegion struct {
+	name string
+}
+
+// globalIndexRegions 按返回顺序排列的地区
+var globalIndexRegions = []globalIndexRegion{
+	{name: "asia"},
+	{name: "america"},
 }
 
 // NewMarketService 创建市场数据服务
 func NewMarketService(
-	baiduCrawler *crawler.BaiduCrawler,
-	goldCrawler *crawler.GoldCrawler,
+	marketCrawler crawler.MarketDataCrawler,
+	goldCrawler crawler.GoldDataCrawler,
 	cache CacheService,
+) MarketService {
+	return NewMarketServiceWithDegradation(marketCrawler, goldCrawler, cache, nil)
+}
+
+// NewMarketServiceWithDegradation 创建市场数据服务
+// 全球指数与贵金属缓存未命中时经 degradation 获取：数据源缓慢时先返回旧数据并在后台刷新
+// degradation 为 nil 时直接请求数据源
+func NewMarketServiceWithDegradation(
+	marketCrawler crawler.MarketDataCrawler,
+	goldCrawler crawler.GoldDataCrawler,
+	cache CacheService,
+	degradation DegradationService,
 ) MarketService {
 	return &marketService{
-		baiduCrawler: baiduCrawler,
-		goldCrawler:  goldCrawler,
-		cache:        cache,
+		marketCrawler: marketCrawler,
+		goldCrawler:   goldCrawler,
+		cache:         cache,
+		degradation:   degradation,
+		now:           time.Now,
 	}
 }
 
 // GetGlobalIndices 获取全球市场指数
+// 各地区并发获取、分别缓存，按地区顺序合并成功的结果；某地区获取失败不会缓存，下次请求重新获取
+// 仅当所有地区均失败时返回错误
 func (s *marketService) GetGlobalIndices(ctx context.Context) ([]model.MarketIndex, error) {
-	// 尝试从缓存获取
+	results := make([][]model.MarketIndex, len(globalIndexRegions))
+	errs := make([]error, len(globalIndexRegions))
+
+	var wg sync.WaitGroup
+	for i, region := range globalIndexRegions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			results[i], errs[i] = s.getRegionIndices(ctx, region)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("%s: %w", region, errs[i])
+			}
+		}(i, region.name)
+	}
+	wg.Wait()
+
+	indices := make([]model.MarketIndex, 0)
+	failed := 0
+	for i := range globalIndexRegions {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		indices = append(indices, results[i]...)
+	}
+	if failed == len(globalIndexRegions) {
+		return nil, errors.Join(errs...)
+	}
+	return indices, nil
+}
+
+// getRegionIndices 获取单个地区的市场指数，优先使用缓存（缓存的空列表同样视为命中）
+func (s *marketService) getRegionIndices(ctx context.Context, region string) ([]model.MarketIndex, error) {
+	cacheKey := fmt.Sprintf(CacheKeyMarketIndicesRegion, region)
+
 	var indices []model.MarketIndex
-	err := s.cache.GetJSON(ctx, CacheKeyMarketIndices, &indices)
-	if err == nil && len(indices) > 0 {
+	err := s.cache.GetJSON(ctx, cacheKey, &indices)
+	if err == nil && indices != nil {
 		return indices, nil
 	}
 
-	// 获取亚洲市场
-	asiaIndices, err := s.baiduCrawler.GetMarketIndices(ctx, "asia")
-	if err != nil {
-		return nil, err
+	if s.degradation != nil {
+		err = staleWhileRevalidate(ctx, s.degradation, cacheKey, func(ctx context.Context) (interface{}, error) {
+			return s.fetchRegionIndices(ctx, region)
+		}, &indices)
+		if err != nil {
+			return nil, err
+		}
+		return indices, nil
 	}
+	return s.fetchRegionIndices(ctx, region)
+}
 
-	// 获取美洲市场
-	americaIndices, err := s.baiduCrawler.GetMarketIndices(ctx, "america")
-	if err != nil {
-		// 美洲市场获取失败不影响返回亚洲数据
-		indices = asiaIndices
-	} else {
-		indices = append(asiaIndices, americaIndices...)
+// fetchRegionIndices 从数据源获取单个地区的市场指数并写入缓存
+func (s *marketService) fetchRegionIndices(ctx context.Context, region string) ([]model.MarketIndex, error) {
+	indices, err := s.marketCrawler.GetMarketIndices(ctx, region)
+	if err != nil && !errors.Is(err, crawler.ErrMarketIndicesEmpty) {
+		return nil, err
+	}
+	if indices == nil {
+		indices = []model.MarketIndex{}
 	}
 
-	// 缓存结果
-	_ = s.cache.SetJSON(ctx, CacheKeyMarketIndices, indices, TTLMarketIndices)
+	// 缓存结果，休市等无数据时段使用较长的 TTL
+	ttl := TTLMarketIndices
+	if len(indices) == 0 {
+		ttl = TTLMarketIndicesEmpty
+	}
+	_ = s.cache.SetJSON(ctx, fmt.Sprintf(CacheKeyMarketIndicesRegion, region), indices, ttl)
 
 	return indices, nil
 }
@@ -75,8 +155,21 @@ func (s *marketService) GetPreciousMetals(ctx context.Context) ([]model.Precious
 		return metals, nil
 	}
 
-	// 从金投网获取
-	metals, err = s.goldCrawler.GetRealTimeGold(ctx)
+	if s.degradation != nil {
+		err = staleWhileRevalidate(ctx, s.degradation, CacheKeyPreciousMetals, func(ctx context.Context) (interface{}, error) {
+			return s.fetchPreciousMetals(ctx)
+		}, &metals)
+		if err != nil {
+			return nil, err
+		}
+		return metals, nil
+	}
+	return s.fetchPreciousMetals(ctx)
+}
+
+// fetchPreciousMetals 从金投网获取贵金属价格并写入缓存
+func (s *marketService) fetchPreciousMetals(ctx context.Context) ([]model.PreciousMetal, error) {
+	metals, err := s.goldCrawler.GetRealTimeGold(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -88,31 +181,30 @@ func (s *marketService) GetPreciousMetals(ctx context.Context) ([]model.Precious
 }
 
 // GetGoldHistory 获取历史金价
-func (s *marketService) GetGoldHistory(ctx context.Context, days int) ([]model.GoldPrice, error) {
+// 交易日模式返回最近 days 个数据点，自然日模式返回最近 days 个自然日内的数据点
+func (s *marketService) GetGoldHistory(ctx context.Context, days int, mode GoldHistoryMode) ([]model.GoldPrice, error) {
 	if days <= 0 {
 		days = 30
 	}
 
-	// 缓存 key 包含天数
-	cacheKey := CacheKeyPreciousMetals + ":history"
+	// N 个自然日内的交易日不超过 N 个，两种模式向上游请求相同数量的数据点，缓存原始序列
+	cacheKey := fmt.Sprintf("%s:history:%d", CacheKeyPreciousMetals, days)
 
 	// 尝试从缓存获取
 	var history []model.GoldPrice
 	err := s.cache.GetJSON(ctx, cacheKey, &history)
-	if err == nil && len(history) > 0 {
-		return history, nil
-	}
-
-	// 从金投网获取
-	history, err = s.goldCrawler.GetGoldHistory(ctx, days)
-	if err != nil {
-		return nil, err
+	if err != nil || len(history) == 0 {
+		// 从金投网获取
+		history, err = s.goldCrawler.GetGoldHistory(ctx, days)
+		if err != nil {
+			return nil, err
+		}
+
+		// 缓存结果（历史数据缓存时间长一些）
+		_ = s.cache.SetJSON(ctx, cacheKey, history, TTLFundInfo)
 	}
 
-	// 缓存结果（历史数据缓存时间长一些）
-	_ = s.cache.SetJSON(ctx, cacheKey, history, TTLFundInfo)
-
-	return history, nil
+	return limitGoldHistory(history, days, mode, s.now()), nil
 }
 
 // GetVolumeTrend 获取成交量趋势
@@ -127,7 +219,7 @@ func (s *marketService) GetVolumeTrend(ctx context.Context, days int) ([]model.V
 	}
 
 	// 从百度股市通获取
-	volumes, err = s.baiduCrawler.GetVolumeTrend(ctx)
+	volumes, err = s.marketCrawler.GetVolumeTrend(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -139,20 +231,22 @@ func (s *marketService) GetVolumeTrend(ctx context.Context, days int) ([]model.V
 }
 
 // GetMinuteData 获取上证分时数据
-func (s *marketService) GetMinuteData(ctx context.Context, minutes int) ([]model.MinuteData, error) {
+// minutes 限制返回最近的分钟数（<= 0 表示全部），之后按 granularity 聚合
+func (s *marketService) GetMinuteData(ctx context.Context, minutes int, granularity MinuteGranularity) ([]model.MinuteData, error) {
 	cacheKey := "market:minute"
 
-	// 尝试从缓存获取
+	// 尝试从缓存获取（缓存完整的 1 分钟序列，不同参数的请求共用）
 	var data []model.MinuteData
 	err := s.cache.GetJSON(ctx, cacheKey, &data)
-	if err == nil && len(data) > 0 {
-		return data, nil
-	}
-
-	// 从百度股市通获取
-	data, err = s.baiduCrawler.GetMinuteData(ctx, "sh000001")
-	if err != nil {
-		return nil, err
+	if err != nil || len(data) == 0 {
+		// 从百度股市通获取
+		data, err = s.marketCrawler.GetMinuteData(ctx, "sh000001")
+		if err != nil {
+			return nil, err
+		}
+
+		// 缓存结果（分时数据缓存时间短）
+		_ = s.cache.SetJSON(ctx, cacheKey, data, TTLFundValuation)
 	}
 
 	// 限制返回数量
@@ -160,10 +254,7 @@ func (s *marketService) GetMinuteData(ctx context.Context, minutes int) ([]model
 		data = data[len(data)-minutes:]
 	}
 
-	// 缓存结果（分时数据缓存时间短）
-	_ = s.cache.SetJSON(ctx, cacheKey, data, TTLFundValuation)
-
-	return data, nil
+	return DownsampleMinuteData(data, granularity)
 }
 
 // GetChangeStatus 获取涨跌状态
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newMockMarketCrawler 返回亚洲和美洲各一个指数
+func newMockMarketCrawler() *mockMarketCrawler {
+	return &mockMarketCrawler{
+		indices: map[string][]model.MarketIndex{
+			"asia":    {{Name: "上证指数"}},
+			"america": {{Name: "道琼斯"}},
+		},
+		errs: make(map[string]error),
+	}
+}
+
+func indexNames(indices []model.MarketIndex) []string {
+	names := make([]string, len(indices))
+	for i, idx := range indices {
+		names[i] = idx.Name
+	}
+	return names
+}
+
+func TestGetGlobalIndices_FailedRegionRetriedWhileSucceededServedFromCache(t *testing.T) {
+	ctx := context.Background()
+	source := newMockMarketCrawler()
+	source.errs["GetMarketIndices:america"] = errors.New("america upstream timeout")
+	svc := NewMarketService(source, nil, NewMemoryCache())
+
+	// 首次请求：美洲失败，只返回亚洲
+	indices, err := svc.GetGlobalIndices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"上证指数"}, indexNames(indices))
+
+	// 美洲恢复：亚洲命中缓存，美洲重新获取
+	delete(source.errs, "GetMarketIndices:america")
+	indices, err = svc.GetGlobalIndices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"上证指数", "道琼斯"}, indexNames(indices))
+	assert.Equal(t, 1, source.count("GetMarketIndices:asia"), "succeeded region should be served from cache")
+	assert.Equal(t, 2, source.count("GetMarketIndices:america"), "failed region should be re-attempted")
+
+	// 两个地区均已缓存
+	_, err = svc.GetGlobalIndices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, source.count("GetMarketIndices:asia"))
+	assert.Equal(t, 2, source.count("GetMarketIndices:america"))
+}
+
+func TestGetGlobalIndices_BothRegionsSucceed(t *testing.T) {
+	ctx := context.Background()
+	source := newMockMarketCrawler()
+	svc := NewMarketService(source, nil, NewMemoryCache())
+
+	indices, err := svc.GetGlobalIndices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"上证指数", "道琼斯"}, indexNames(indices))
+
+	// 合并前的各地区结果均已缓存
+	indices, err = svc.GetGlobalIndices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"上证指数", "道琼斯"}, indexNames(indices))
+	assert.Equal(t, 1, source.count("GetMarketIndices:asia"))
+	assert.Equal(t, 1, source.count("GetMarketIndices:america"))
+}
+
+func TestGetGlobalIndices_AsiaFailureReturnsAmerica(t *testing.T) {
+	source := newMockMarketCrawler()
+	source.errs["GetMarketIndices:asia"] = errors.New("asia upstream down")
+	svc := NewMarketService(source, nil, NewMemoryCache())
+
+	indices, err := svc.GetGlobalIndices(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"道琼斯"}, indexNames(indices))
+}
+
+func TestGetGlobalIndices_AllRegionsFail(t *testing.T) {
+	errAsia := errors.New("asia upstream down")
+	source := newMockMarketCrawler()
+	source.errs["GetMarketIndices:asia"] = errAsia
+	source.errs["GetMarketIndices:america"] = errors.New("america upstream timeout")
+	svc := NewMarketService(source, nil, NewMemoryCache())
+
+	indices, err := svc.GetGlobalIndices(context.Background())
+
+	assert.Nil(t, indices)
+	assert.ErrorIs(t, err, errAsia)
+	assert.Contains(t, err.Error(), "america: america upstream timeout")
+}
+
+// blockingMarketCrawler 各地区请求都等待另一个地区开始后才返回，串行获取时会超时
+type blockingMarketCrawler struct {
+	started sync.WaitGroup
+}
+
+func (c *blockingMarketCrawler) GetMarketIndices(ctx context.Context, market string) ([]model.MarketIndex, error) {
+	c.started.Done()
+	done := make(chan struct{})
+	go func() {
+		c.started.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return []model.MarketIndex{{Name: market}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *blockingMarketCrawler) GetMinuteData(ctx context.Context, code string) ([]model.MinuteData, error) {
+	return nil, nil
+}
+
+func (c *blockingMarketCrawler) GetVolumeTrend(ctx context.Context) ([]model.VolumeTrend, error) {
+	return nil, nil
+}
+
+func TestGetGlobalIndices_FetchesRegionsConcurrently(t *testing.T) {
+	source := &blockingMarketCrawler{}
+	source.started.Add(len(globalIndexRegions))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	indices, err := NewMarketService(source, nil, NewMemoryCache()).GetGlobalIndices(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asia", "america"}, indexNames(indices))
+}
+
+func TestGetGlobalIndices_EmptyRegionCached(t *testing.T) {
+	ctx := context.Background()
+	source := newMockMarketCrawler()
+	source.errs["GetMarketIndices:america"] = crawler.ErrMarketIndicesEmpty
+	svc := NewMarketService(source, nil, NewMemoryCache())
+
+	for i := 0; i < 2; i++ {
+		indices, err := svc.GetGlobalIndices(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"上证指数"}, indexNames(indices))
+	}
+	assert.Equal(t, 1, source.count("GetMarketIndices:america"), "empty result should be cached")
+}
+
+// TestServices_CachePaths 各服务的缓存命中、未命中与上游失败路径
+func TestServices_CachePaths(t *testing.T) {
+	errUpstream := errors.New("upstream down")
+
+	tests := []struct {
+		name string
+		// run 使用给定的缓存与上游错误调用服务，返回结果数量与上游调用次数
+		run func(cache CacheService, upstreamErr error) (int, int, error)
+		// seed 预先写入缓存
+		seed func(cache CacheService)
+	}{
+		{
+			name: "precious metals",
+			run: func(cache CacheService, upstreamErr error) (int, int, error) {
+				gold := &mockGoldCrawler{metals: []model.PreciousMetal{{Name: "黄金"}, {Name: "白银"}}, err: upstreamErr}
+				metals, err := NewMarketService(nil, gold, cache).GetPreciousMetals(context.Background())
+				return len(metals), gold.count("GetRealTimeGold"), err
+			},
+			seed: func(cache CacheService) {
+				_ = cache.SetJSON(context.Background(), CacheKeyPreciousMetals, []model.PreciousMetal{{Name: "黄金"}}, TTLPreciousMetals)
+			},
+		},
+		{
+			name: "sector list",
+			run: func(cache CacheService, upstreamErr error) (int, int, error) {
+				sectors := &mockSectorCrawler{sectors: []model.Sector{{ID: "BK1", Name: "白酒"}, {ID: "BK2", Name: "半导体"}}, err: upstreamErr}
+				list, err := NewSectorService(sectors, cache).GetSectorList(context.Background())
+				return len(list), sectors.count("GetSectorList"), err
+			},
+			seed: func(cache CacheService) {
+				_ = cache.SetJSON(context.Background(), CacheKeySectorList, []model.Sector{{ID: "BK1"}}, TTLSectorList)
+			},
+		},
+		{
+			name: "news",
+			run: func(cache CacheService, upstreamErr error) (int, int, error) {
+				news := &mockNewsCrawler{news: []model.NewsItem{{ID: "1"}, {ID: "2"}}, err: upstreamErr}
+				page, err := NewNewsService(news, cache).GetNews(context.Background(), NewsQuery{Limit: 1})
+				if err != nil {
+					return 0, news.count("GetNewsFlash"), err
+				}
+				return len(page.Items), news.count("GetNewsFlash"), nil
+			},
+			seed: func(cache CacheService) {
+				_ = cache.SetJSON(context.Background(), CacheKeyNews, []model.NewsItem{{ID: "1"}}, TTLNews)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/cache hit", func(t *testing.T) {
+			cache := NewMemoryCache()
+			tt.seed(cache)
+
+			n, calls, err := tt.run(cache, errUpstream)
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Zero(t, calls, "cache hit should not call the crawler")
+		})
+
+		t.Run(tt.name+"/cache miss", func(t *testing.T) {
+			cache := NewMemoryCache()
+
+			n, calls, err := tt.run(cache, nil)
+			require.NoError(t, err)
+			assert.Positive(t, n)
+			assert.Equal(t, 1, calls)
+
+			// 结果已写入缓存，即使上游失败也能命中
+			_, calls, err = tt.run(cache, errUpstream)
+			require.NoError(t, err)
+			assert.Zero(t, calls)
+		})
+
+		t.Run(tt.name+"/crawler error", func(t *testing.T) {
+			_, calls, err := tt.run(NewMemoryCache(), errUpstream)
+
+			assert.ErrorIs(t, err, errUpstream)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestFundService_SearchFundUsesCrawler(t *testing.T) {
+	funds := &mockFundCrawler{funds: map[string]*model.FundInfo{"000001": {Code: "000001", Name: "华夏成长"}}}
+	svc := NewFundService(FundServiceOptions{FundRepo: &fakeFundRepo{}, FundCrawler: funds, Cache: NewMemoryCache()})
+
+	info, err := svc.SearchFund(context.Background(), "000001")
+
+	require.NoError(t, err)
+	assert.Equal(t, "华夏成长", info.Name)
+	assert.Equal(t, 1, funds.count("SearchFund:000001"))
+}
+
+// slowGoldCrawler 实时金价请求阻塞到 release 关闭
+type slowGoldCrawler struct {
+	mockGoldCrawler
+	release chan struct{}
+}
+
+func (c *slowGoldCrawler) GetRealTimeGold(ctx context.Context) ([]model.PreciousMetal, error) {
+	<-c.release
+	return c.mockGoldCrawler.GetRealTimeGold(ctx)
+}
+
+// slowSectorCrawler 板块列表请求阻塞到 release 关闭
+type slowSectorCrawler struct {
+	mockSectorCrawler
+	release chan struct{}
+}
+
+func (c *slowSectorCrawler) GetSectorList(ctx context.Context) ([]model.Sector, error) {
+	<-c.release
+	return c.mockSectorCrawler.GetSectorList(ctx)
+}
+
+// slowMarketCrawler 指数请求阻塞到 release 关闭
+type slowMarketCrawler struct {
+	*mockMarketCrawler
+	release chan struct{}
+}
+
+func (c *slowMarketCrawler) GetMarketIndices(ctx context.Context, market string) ([]model.MarketIndex, error) {
+	<-c.release
+	return c.mockMarketCrawler.GetMarketIndices(ctx, market)
+}
+
+// newSWRDegradation 快速获取超时很短的降级服务，测试结束时等待后台刷新退出
+func newSWRDegradation(t *testing.T, cache CacheService) DegradationService {
+	t.Helper()
+	degradation := NewDegradationServiceWithConfig(cache, crawler.NewCircuitBreakerManager(crawler.DefaultCircuitBreakerConfig()), zap.NewNop(), config.DegradationConfig{FastPathTimeoutMs: 50})
+	t.Cleanup(func() { _ = degradation.Shutdown(context.Background()) })
+	return degradation
+}
+
+// TestServices_StaleWhileRevalidate 正常缓存过期且数据源缓慢时，立即返回旧数据，后台刷新后写入新数据
+func TestServices_StaleWhileRevalidate(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup 写入旧数据副本并返回调用服务的函数，结果为名称列表
+		setup func(cache CacheService, release chan struct{}) func(ctx context.Context) ([]string, error)
+		// freshKey 后台刷新后应写入的正常缓存键
+		freshKey string
+		stale    []string
+		fresh    []string
+	}{
+		{
+			name: "precious metals",
+			setup: func(cache CacheService, release chan struct{}) func(ctx context.Context) ([]string, error) {
+				_ = cache.SetJSON(context.Background(), CacheKeyPreciousMetals+staleKeySuffix, []model.PreciousMetal{{Name: "旧黄金"}}, TTLStale)
+				gold := &slowGoldCrawler{mockGoldCrawler: mockGoldCrawler{metals: []model.PreciousMetal{{Name: "黄金"}}}, release: release}
+				svc := NewMarketServiceWithDegradation(nil, gold, cache, newSWRDegradation(t, cache))
+				return func(ctx context.Context) ([]string, error) {
+					metals, err := svc.GetPreciousMetals(ctx)
+					names := make([]string, len(metals))
+					for i, m := range metals {
+						names[i] = m.Name
+					}
+					return names, err
+				}
+			},
+			freshKey: CacheKeyPreciousMetals,
+			stale:    []string{"旧黄金"},
+			fresh:    []string{"黄金"},
+		},
+		{
+			name: "sector list",
+			setup: func(cache CacheService, release chan struct{}) func(ctx context.Context) ([]string, error) {
+				_ = cache.SetJSON(context.Background(), CacheKeySectorList+staleKeySuffix, []model.Sector{{Name: "旧板块"}}, TTLStale)
+				sectors := &slowSectorCrawler{mockSectorCrawler: mockSectorCrawler{sectors: []model.Sector{{Name: "半导体"}}}, release: release}
+				svc := NewSectorServiceWithDegradation(sectors, cache, newSWRDegradation(t, cache))
+				return func(ctx context.Context) ([]string, error) {
+					list, err := svc.GetSectorList(ctx)
+					names := make([]string, len(list))
+					for i, s := range list {
+						names[i] = s.Name
+					}
+					return names, err
+				}
+			},
+			freshKey: CacheKeySectorList,
+			stale:    []string{"旧板块"},
+			fresh:    []string{"半导体"},
+		},
+		{
+			name: "global indices",
+			setup: func(cache CacheService, release chan struct{}) func(ctx context.Context) ([]string, error) {
+				for _, region := range []string{"asia", "america"} {
+					_ = cache.SetJSON(context.Background(), fmt.Sprintf(CacheKeyMarketIndicesRegion, region)+staleKeySuffix,
+						[]model.MarketIndex{{Name: "旧" + region}}, TTLStale)
+				}
+				source := &slowMarketCrawler{mockMarketCrawler: newMockMarketCrawler(), release: release}
+				svc := NewMarketServiceWithDegradation(source, nil, cache, newSWRDegradation(t, cache))
+				return func(ctx context.Context) ([]string, error) {
+					indices, err := svc.GetGlobalIndices(ctx)
+					return indexNames(indices), err
+				}
+			},
+			freshKey: fmt.Sprintf(CacheKeyMarketIndicesRegion, "america"),
+			stale:    []string{"旧asia", "旧america"},
+			fresh:    []string{"上证指数", "道琼斯"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMemoryCache()
+			release := make(chan struct{})
+			get := tt.setup(cache, release)
+
+			start := time.Now()
+			names, err := get(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.stale, names)
+			assert.Less(t, time.Since(start), time.Second, "stale data should be served without waiting for the crawler")
+
+			// 数据源恢复后，后台刷新写入正常缓存
+			close(release)
+			require.Eventually(t, func() bool {
+				_, err := cache.Get(context.Background(), tt.freshKey)
+				return err == nil
+			}, 2*time.Second, 10*time.Millisecond)
+
+			names, err = get(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.fresh, names)
+		})
+	}
+}
+
+func TestGetPreciousMetals_StaleWhileRevalidateWithoutStaleCopy(t *testing.T) {
+	errUpstream := errors.New("upstream down")
+	cache := NewMemoryCache()
+	gold := &mockGoldCrawler{err: errUpstream}
+	svc := NewMarketServiceWithDegradation(nil, gold, cache, newSWRDegradation(t, cache))
+
+	_, err := svc.GetPreciousMetals(context.Background())
+	assert.ErrorIs(t, err, errUpstream)
+
+	// 成功获取后同时写入正常缓存与旧数据副本
+	gold.err = nil
+	gold.metals = []model.PreciousMetal{{Name: "黄金"}}
+	metals, err := svc.GetPreciousMetals(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "黄金", metals[0].Name)
+	for _, key := range []string{CacheKeyPreciousMetals, CacheKeyPreciousMetals + staleKeySuffix} {
+		_, err := cache.Get(context.Background(), key)
+		assert.NoError(t, err, key)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"fund-analyzer/internal/model"
+)
+
+// 市场概况的生成规则
+const (
+	// summaryTopSectors 领涨、领跌板块各展示的数量
+	summaryTopSectors = 3
+	// summaryBullishRatio 上涨数占比不低于该值时判断为偏多
+	summaryBullishRatio = 0.6
+	// summaryBearishRatio 上涨数占比不高于该值时判断为偏空
+	summaryBearishRatio = 0.4
+)
+
+// SummaryEmptyNotice 没有可用市场数据时的概况
+const SummaryEmptyNotice = "暂无可用的市场数据，无法生成市场概况。"
+
+// marketBreadth 上涨与下跌数量
+type marketBreadth struct {
+	up   int
+	down int
+}
+
+// add 按涨跌幅计数，持平不计
+func (b *marketBreadth) add(change float64) {
+	switch {
+	case change > 0:
+		b.up++
+	case change < 0:
+		b.down++
+	}
+}
+
+// GenerateSummary 按固定规则生成市场概况
+// 包括市场情绪、领涨领跌板块、主力资金流向、贵金属与自选基金涨跌，不调用大模型
+func (s *marketService) GenerateSummary(data *model.MarketData) string {
+	if data == nil || (len(data.Indices) == 0 && len(data.Sectors) == 0 &&
+		len(data.PreciousMetals) == 0 && len(data.Funds) == 0) {
+		return SummaryEmptyNotice
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## 市场概况\n\n")
+	sb.WriteString(fmt.Sprintf("**市场情绪**：%s\n\n", summarySentiment(data)))
+
+	if len(data.Sectors) > 0 {
+		writeSectorSummary(&sb, data.Sectors)
+	}
+	if len(data.PreciousMetals) > 0 {
+		writeMetalSummary(&sb, data.PreciousMetals)
+	}
+	if len(data.Funds) > 0 {
+		writeFundSummary(&sb, data.Funds)
+	}
+
+	sb.WriteString("*以上内容根据行情数据自动生成，不构成投资建议。*")
+	return sb.String()
+}
+
+// summarySentiment 按指数与板块的上涨数占比判断市场情绪
+func summarySentiment(data *model.MarketData) string {
+	var indices, sectors marketBreadth
+	for _, index := range data.Indices {
+		switch index.Status {
+		case model.StatusUp:
+			indices.up++
+		case model.StatusDown:
+			indices.down++
+		default:
+			indices.add(parsePercentage(index.Change))
+		}
+	}
+	for _, sector := range data.Sectors {
+		sectors.add(parsePercentage(sector.ChangeRate))
+	}
+
+	sentiment := model.SentimentNeutral
+	if total := indices.up + indices.down + sectors.up + sectors.down; total > 0 {
+		ratio := float64(indices.up+sectors.up) / float64(total)
+		switch {
+		case ratio >= summaryBullishRatio:
+			sentiment = model.SentimentBullish
+		case ratio <= summaryBearishRatio:
+			sentiment = model.SentimentBearish
+		}
+	}
+
+	return fmt.Sprintf("%s（指数上涨 %d 个、下跌 %d 个；板块上涨 %d 个、下跌 %d 个）",
+		sentimentLabels[sentiment], indices.up, indices.down, sectors.up, sectors.down)
+}
+
+// writeSectorSummary 写入领涨、领跌板块与主力资金流向
+func writeSectorSummary(sb *strings.Builder, sectors []model.Sector) {
+	sorted := make([]model.Sector, len(sectors))
+	copy(sorted, sectors)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return parsePercentage(sorted[i].ChangeRate) > parsePercentage(sorted[j].ChangeRate)
+	})
+
+	var gainers, losers []string
+	for _, sector := range sorted {
+		if len(gainers) == summaryTopSectors {
+			break
+		}
+		if change := parsePercentage(sector.ChangeRate); change > 0 {
+			gainers = append(gainers, fmt.Sprintf("%s %+.2f%%", sector.Name, change))
+		}
+	}
+	for i := len(sorted) - 1; i >= 0 && len(losers) < summaryTopSectors; i-- {
+		if change := parsePercentage(sorted[i].ChangeRate); change < 0 {
+			losers = append(losers, fmt.Sprintf("%s %+.2f%%", sorted[i].Name, change))
+		}
+	}
+
+	if len(gainers) > 0 {
+		sb.WriteString(fmt.Sprintf("**领涨板块**：%s\n\n", strings.Join(gainers, "、")))
+	}
+	if len(losers) > 0 {
+		sb.WriteString(fmt.Sprintf("**领跌板块**：%s\n\n", strings.Join(losers, "、")))
+	}
+
+	var inflow float64
+	for _, sector := range sectors {
+		inflow += parseMoney(sector.MainNetInflow)
+	}
+	switch {
+	case inflow > 0:
+		sb.WriteString(fmt.Sprintf("**资金面**：板块主力资金合计净流入 %s\n\n", formatYuan(inflow)))
+	case inflow < 0:
+		sb.WriteString(fmt.Sprintf("**资金面**：板块主力资金合计净流出 %s\n\n", formatYuan(-inflow)))
+	}
+}
+
+// writeMetalSummary 写入贵金属价格
+func writeMetalSummary(sb *strings.Builder, metals []model.PreciousMetal) {
+	items := make([]string, 0, len(metals))
+	for _, metal := range metals {
+		item := fmt.Sprintf("%s %.2f", metal.Name, metal.Price)
+		if metal.ChangeRate != "" {
+			item += fmt.Sprintf("（%s）", metal.ChangeRate)
+		}
+		items = append(items, item)
+	}
+	sb.WriteString(fmt.Sprintf("**贵金属**：%s\n\n", strings.Join(items, "、")))
+}
+
+// writeFundSummary 写入自选基金涨跌统计及涨跌幅最大的基金
+func writeFundSummary(sb *strings.Builder, funds []model.FundValuation) {
+	var breadth marketBreadth
+	best, worst := -1, -1
+	for i, fund := range funds {
+		growth := parsePercentage(fund.DayGrowth)
+		breadth.add(growth)
+		if best < 0 || growth > parsePercentage(funds[best].DayGrowth) {
+			best = i
+		}
+		if worst < 0 || growth < parsePercentage(funds[worst].DayGrowth) {
+			worst = i
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("**自选基金**：上涨 %d 只，下跌 %d 只", breadth.up, breadth.down))
+	if growth := parsePercentage(funds[best].DayGrowth); growth > 0 {
+		sb.WriteString(fmt.Sprintf("；涨幅最大 %s %+.2f%%", fundDisplayName(funds[best]), growth))
+	}
+	if growth := parsePercentage(funds[worst].DayGrowth); growth < 0 {
+		sb.WriteString(fmt.Sprintf("；跌幅最大 %s %+.2f%%", fundDisplayName(funds[worst]), growth))
+	}
+	sb.WriteString("\n\n")
+}
+
+// fundDisplayName 基金展示名称，没有名称时使用代码
+func fundDisplayName(fund model.FundValuation) string {
+	if fund.Name != "" {
+		return fund.Name
+	}
+	return fund.Code
+}
+
+// formatYuan 将金额（元）格式化为亿元或万元
+func formatYuan(yuan float64) string {
+	if math.Abs(yuan) >= 1e8 {
+		return fmt.Sprintf("%.2f 亿元", yuan/1e8)
+	}
+	return fmt.Sprintf("%.2f 万元", yuan/1e4)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketService_GenerateSummary_Bullish(t *testing.T) {
+	svc := &marketService{}
+	data := &model.MarketData{
+		Indices: []model.MarketIndex{
+			{Name: "上证指数", Change: "+0.85%", Status: model.StatusUp},
+			{Name: "深证成指", Change: "+1.10%", Status: model.StatusUp},
+			{Name: "创业板指", Change: "-0.20%", Status: model.StatusDown},
+		},
+		Sectors: []model.Sector{
+			{Name: "券商", ChangeRate: "2.10%", MainNetInflow: "12.5亿"},
+			{Name: "半导体", ChangeRate: "3.45%", MainNetInflow: "8.3亿"},
+			{Name: "白酒", ChangeRate: "-1.20%", MainNetInflow: "-3.2亿"},
+			{Name: "光伏", ChangeRate: "0.50%", MainNetInflow: "5000万"},
+		},
+		PreciousMetals: []model.PreciousMetal{{Name: "现货黄金", Price: 2150.5, ChangeRate: "+0.30%"}},
+		Funds: []model.FundValuation{
+			{Code: "000001", Name: "华夏成长", DayGrowth: "1.25"},
+			{Code: "000002", Name: "易方达消费", DayGrowth: "-0.80"},
+		},
+	}
+
+	summary := svc.GenerateSummary(data)
+
+	assert.Contains(t, summary, "**市场情绪**：偏多")
+	assert.Contains(t, summary, "**领涨板块**：半导体 +3.45%、券商 +2.10%、光伏 +0.50%")
+	assert.Contains(t, summary, "**领跌板块**：白酒 -1.20%")
+	assert.Contains(t, summary, "净流入 18.10 亿元")
+	assert.Contains(t, summary, "现货黄金 2150.50（+0.30%）")
+	assert.Contains(t, summary, "上涨 1 只，下跌 1 只；涨幅最大 华夏成长 +1.25%；跌幅最大 易方达消费 -0.80%")
+}
+
+func TestMarketService_GenerateSummary_Bearish(t *testing.T) {
+	svc := &marketService{}
+	data := &model.MarketData{
+		Indices: []model.MarketIndex{
+			{Name: "上证指数", Change: "-1.30%"},
+			{Name: "深证成指", Change: "-1.80%"},
+		},
+		Sectors: []model.Sector{
+			{Name: "银行", ChangeRate: "0.30%", MainNetInflow: "1.2亿"},
+			{Name: "医药", ChangeRate: "-2.40%", MainNetInflow: "-6.8亿"},
+		},
+	}
+
+	summary := svc.GenerateSummary(data)
+
+	assert.Contains(t, summary, "**市场情绪**：偏空（指数上涨 0 个、下跌 2 个；板块上涨 1 个、下跌 1 个）")
+	assert.Contains(t, summary, "**领涨板块**：银行 +0.30%")
+	assert.Contains(t, summary, "**领跌板块**：医药 -2.40%")
+	assert.Contains(t, summary, "净流出 5.60 亿元")
+	assert.NotContains(t, summary, "**自选基金**")
+}
+
+func TestMarketService_GenerateSummary_Empty(t *testing.T) {
+	svc := &marketService{}
+
+	assert.Equal(t, SummaryEmptyNotice, svc.GenerateSummary(nil))
+	assert.Equal(t, SummaryEmptyNotice, svc.GenerateSummary(&model.MarketData{News: []model.NewsItem{{Title: "快讯"}}}))
+}
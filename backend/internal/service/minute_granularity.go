@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fund-analyzer/internal/model"
+)
+
+// MinuteGranularity 分时数据粒度
+type MinuteGranularity string
+
+const (
+	Granularity1Min  MinuteGranularity = "1m"
+	Granularity5Min  MinuteGranularity = "5m"
+	Granularity15Min MinuteGranularity = "15m"
+	Granularity30Min MinuteGranularity = "30m"
+	Granularity60Min MinuteGranularity = "60m"
+	GranularityDay   MinuteGranularity = "1d" // 整个交易日聚合为一根
+)
+
+// granularityPoints 各粒度每根 K 线包含的分钟点数，0 表示全部
+var granularityPoints = map[MinuteGranularity]int{
+	Granularity1Min:  1,
+	Granularity5Min:  5,
+	Granularity15Min: 15,
+	Granularity30Min: 30,
+	Granularity60Min: 60,
+	GranularityDay:   0,
+}
+
+// ErrInvalidGranularity 不支持的分时数据粒度
+var ErrInvalidGranularity = errors.New("invalid minute data granularity")
+
+// ParseMinuteGranularity 解析分时数据粒度，为空时使用 1 分钟
+func ParseMinuteGranularity(s string) (MinuteGranularity, error) {
+	g := MinuteGranularity(strings.ToLower(strings.TrimSpace(s)))
+	if g == "" {
+		return Granularity1Min, nil
+	}
+	if _, ok := granularityPoints[g]; !ok {
+		return "", fmt.Errorf("%w: %q, supported: 1m, 5m, 15m, 30m, 60m, 1d", ErrInvalidGranularity, s)
+	}
+	return g, nil
+}
+
+// DownsampleMinuteData 将 1 分钟数据按粒度聚合为 OHLC
+// 从序列起点起每 N 个点聚合为一根：时间、收盘价、涨跌取最后一个点，成交量与成交额求和
+func DownsampleMinuteData(points []model.MinuteData, granularity MinuteGranularity) ([]model.MinuteData, error) {
+	size, ok := granularityPoints[granularity]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGranularity, granularity)
+	}
+	if size == 1 || len(points) == 0 {
+		return points, nil
+	}
+	if size == 0 {
+		size = len(points)
+	}
+
+	bars := make([]model.MinuteData, 0, (len(points)+size-1)/size)
+	for start := 0; start < len(points); start += size {
+		end := start + size
+		if end > len(points) {
+			end = len(points)
+		}
+		bars = append(bars, aggregateMinuteBar(points[start:end]))
+	}
+	return bars, nil
+}
+
+// aggregateMinuteBar 聚合一组分钟点，价格保留原始字符串，无法解析的价格不参与最高最低价比较
+func aggregateMinuteBar(points []model.MinuteData) model.MinuteData {
+	first, last := points[0], points[len(points)-1]
+	bar := model.MinuteData{
+		Time:       last.Time,
+		Price:      last.Price,
+		Change:     last.Change,
+		ChangeRate: last.ChangeRate,
+		Open:       first.Price,
+		High:       last.Price,
+		Low:        last.Price,
+	}
+
+	var high, low, volume, amount float64
+	hasPrice := false
+	for _, p := range points {
+		if price, err := strconv.ParseFloat(p.Price, 64); err == nil {
+			if !hasPrice || price > high {
+				high, bar.High = price, p.Price
+			}
+			if !hasPrice || price < low {
+				low, bar.Low = price, p.Price
+			}
+			hasPrice = true
+		}
+		v, _ := strconv.ParseFloat(p.Volume, 64)
+		volume += v
+		a, _ := strconv.ParseFloat(p.Amount, 64)
+		amount += a
+	}
+
+	bar.Volume = strconv.FormatFloat(volume, 'f', -1, 64)
+	bar.Amount = strconv.FormatFloat(amount, 'f', -1, 64)
+	return bar
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// minutePoints 生成连续的 1 分钟数据
+func minutePoints(prices ...string) []model.MinuteData {
+	points := make([]model.MinuteData, len(prices))
+	for i, price := range prices {
+		points[i] = model.MinuteData{
+			Time:       fmt.Sprintf("09:%02d", 31+i),
+			Price:      price,
+			Change:     fmt.Sprintf("%d", i),
+			ChangeRate: fmt.Sprintf("0.0%d%%", i),
+			Volume:     "100",
+			Amount:     "1000.5",
+		}
+	}
+	return points
+}
+
+func TestParseMinuteGranularity(t *testing.T) {
+	for _, s := range []string{"1m", "5m", "15m", "30m", "60m", "1d", " 5M "} {
+		_, err := ParseMinuteGranularity(s)
+		assert.NoError(t, err, s)
+	}
+
+	g, err := ParseMinuteGranularity("")
+	require.NoError(t, err)
+	assert.Equal(t, Granularity1Min, g)
+
+	for _, s := range []string{"2m", "1h", "daily", "-5m"} {
+		_, err := ParseMinuteGranularity(s)
+		assert.ErrorIs(t, err, ErrInvalidGranularity, s)
+	}
+}
+
+func TestDownsampleMinuteData_FivePointsIntoOneBar(t *testing.T) {
+	points := minutePoints("3000.10", "3002.50", "2998.00", "3001.00", "3000.80")
+
+	bars, err := DownsampleMinuteData(points, Granularity5Min)
+
+	require.NoError(t, err)
+	require.Len(t, bars, 1)
+	assert.Equal(t, model.MinuteData{
+		Time:       "09:35",
+		Price:      "3000.80",
+		Change:     "4",
+		ChangeRate: "0.04%",
+		Volume:     "500",
+		Amount:     "5002.5",
+		Open:       "3000.10",
+		High:       "3002.50",
+		Low:        "2998.00",
+	}, bars[0])
+}
+
+func TestDownsampleMinuteData_PartialTrailingBar(t *testing.T) {
+	points := minutePoints("10", "11", "12", "13", "14", "15", "9")
+
+	bars, err := DownsampleMinuteData(points, Granularity5Min)
+
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	assert.Equal(t, "09:35", bars[0].Time)
+	assert.Equal(t, "14", bars[0].High)
+	assert.Equal(t, "09:37", bars[1].Time)
+	assert.Equal(t, "15", bars[1].Open)
+	assert.Equal(t, "9", bars[1].Low)
+	assert.Equal(t, "200", bars[1].Volume)
+}
+
+func TestDownsampleMinuteData_DayAndOneMinute(t *testing.T) {
+	points := minutePoints("10", "12", "8", "11")
+
+	day, err := DownsampleMinuteData(points, GranularityDay)
+	require.NoError(t, err)
+	require.Len(t, day, 1)
+	assert.Equal(t, "10", day[0].Open)
+	assert.Equal(t, "12", day[0].High)
+	assert.Equal(t, "8", day[0].Low)
+	assert.Equal(t, "11", day[0].Price)
+
+	raw, err := DownsampleMinuteData(points, Granularity1Min)
+	require.NoError(t, err)
+	assert.Equal(t, points, raw)
+}
+
+func TestDownsampleMinuteData_UnparseablePricesIgnored(t *testing.T) {
+	points := minutePoints("10", "-", "12")
+
+	bars, err := DownsampleMinuteData(points, Granularity5Min)
+
+	require.NoError(t, err)
+	require.Len(t, bars, 1)
+	assert.Equal(t, "12", bars[0].High)
+	assert.Equal(t, "10", bars[0].Low)
+}
+
+func TestDownsampleMinuteData_InvalidGranularity(t *testing.T) {
+	_, err := DownsampleMinuteData(minutePoints("10"), MinuteGranularity("7m"))
+
+	assert.ErrorIs(t, err, ErrInvalidGranularity)
+}
@@ -2,57 +2,230 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"fund-analyzer/internal/crawler"
 	"fund-analyzer/internal/model"
 )
 
+const (
+	// DefaultNewsLimit 未指定条数时每页返回的快讯数
+	DefaultNewsLimit = 50
+	// MaxNewsLimit 每页快讯数上限，超过时按上限返回
+	MaxNewsLimit = 100
+
+	// NewsSortDesc 按发布时间倒序（最新在前），默认排序
+	NewsSortDesc = "desc"
+	// NewsSortAsc 按发布时间正序
+	NewsSortAsc = "asc"
+
+	// NewsEvaluateBullish 利好快讯
+	NewsEvaluateBullish = "利好"
+	// NewsEvaluateBearish 利空快讯
+	NewsEvaluateBearish = "利空"
+
+	// cacheKeyNewsPage 非首页快讯缓存键，%d = offset, limit
+	cacheKeyNewsPage = "news:page:%d:%d"
+)
+
+// ErrInvalidNewsQuery 快讯查询参数无效
+var ErrInvalidNewsQuery = errors.New("invalid news query")
+
+// NewsQuery 快讯分页查询
+// 分页基于数据源的最新在前顺序，Sort 只决定返回页内的排列；筛选条件在分页后应用于本页
+type NewsQuery struct {
+	Offset int    // 跳过的最新快讯条数
+	Limit  int    // 每页条数，<= 0 时使用 DefaultNewsLimit
+	Sort   string // 页内按发布时间排序：asc/desc，为空时 desc
+
+	Evaluate string // 只保留该情绪（利好/利空）的快讯，为空时不筛选
+	Entity   string // 只保留关联股票代码或名称匹配的快讯（代码完全匹配、名称包含，均不区分大小写），为空时不筛选
+}
+
+// NewsPage 快讯分页结果
+type NewsPage struct {
+	Items  []model.NewsItem `json:"items"`
+	Offset int              `json:"offset"`
+	Limit  int              `json:"limit"`
+	Sort   string           `json:"sort"`
+	// HasMore 本页已满，数据源可能还有更早的快讯；数据源不提供总数，仅作为继续翻页的提示
+	HasMore bool `json:"hasMore"`
+}
+
 // NewsService 快讯服务接口
 type NewsService interface {
-	GetNewsList(ctx context.Context, count int) ([]model.NewsItem, error)
+	GetNews(ctx context.Context, query NewsQuery) (*NewsPage, error)
 }
 
 type newsService struct {
-	baiduCrawler *crawler.BaiduCrawler
-	cache        CacheService
+	newsCrawler crawler.NewsCrawler
+	fetchPage   func(ctx context.Context, start, count int) ([]model.NewsItem, error) // 数据源不支持分页时为 nil
+	cache       CacheService
+	content     *ContentStore // 快讯屏蔽词，为 nil 时不过滤
 }
 
 // NewNewsService 创建快讯服务
-func NewNewsService(baiduCrawler *crawler.BaiduCrawler, cache CacheService) NewsService {
-	return &newsService{
-		baiduCrawler: baiduCrawler,
-		cache:        cache,
+func NewNewsService(newsCrawler crawler.NewsCrawler, cache CacheService) NewsService {
+	return NewNewsServiceWithContent(newsCrawler, cache, nil)
+}
+
+// NewNewsServiceWithContent 创建按可热更新屏蔽词过滤的快讯服务
+func NewNewsServiceWithContent(newsCrawler crawler.NewsCrawler, cache CacheService, content *ContentStore) NewsService {
+	s := &newsService{
+		newsCrawler: newsCrawler,
+		cache:       cache,
+		content:     content,
+	}
+	if pager, ok := newsCrawler.(crawler.NewsPageCrawler); ok {
+		s.fetchPage = pager.GetNewsFlashPage
 	}
+	return s
 }
 
-// GetNewsList 获取快讯列表
-func (s *newsService) GetNewsList(ctx context.Context, count int) ([]model.NewsItem, error) {
-	if count <= 0 {
-		count = 50
+// GetNews 分页获取快讯
+// 缓存保存未过滤的快讯，屏蔽词与筛选条件在分页后应用，屏蔽词更新后立即生效；被过滤的快讯不补齐，本页可能少于 Limit 条
+func (s *newsService) GetNews(ctx context.Context, query NewsQuery) (*NewsPage, error) {
+	query, err := normalizeNewsQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	news, err := s.getNewsPage(ctx, query.Offset, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.NewsItem, len(news))
+	copy(items, news)
+	if query.Sort == NewsSortAsc {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].PublishTime < items[j].PublishTime })
+	} else {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].PublishTime > items[j].PublishTime })
 	}
 
+	return &NewsPage{
+		Items:   query.filter(s.content.Load().FilterNews(items)),
+		Offset:  query.Offset,
+		Limit:   query.Limit,
+		Sort:    query.Sort,
+		HasMore: len(news) == query.Limit,
+	}, nil
+}
+
+// normalizeNewsQuery 校验查询参数并填充默认值
+func normalizeNewsQuery(query NewsQuery) (NewsQuery, error) {
+	if query.Offset < 0 {
+		return query, fmt.Errorf("%w: offset must not be negative", ErrInvalidNewsQuery)
+	}
+	switch query.Sort {
+	case "":
+		query.Sort = NewsSortDesc
+	case NewsSortAsc, NewsSortDesc:
+	default:
+		return query, fmt.Errorf("%w: sort must be %s or %s", ErrInvalidNewsQuery, NewsSortAsc, NewsSortDesc)
+	}
+	switch query.Evaluate {
+	case "", NewsEvaluateBullish, NewsEvaluateBearish:
+	default:
+		return query, fmt.Errorf("%w: evaluate must be %s or %s", ErrInvalidNewsQuery, NewsEvaluateBullish, NewsEvaluateBearish)
+	}
+	query.Entity = strings.TrimSpace(query.Entity)
+	if query.Limit <= 0 {
+		query.Limit = DefaultNewsLimit
+	}
+	if query.Limit > MaxNewsLimit {
+		query.Limit = MaxNewsLimit
+	}
+	return query, nil
+}
+
+// filter 按情绪与关联股票筛选快讯，无筛选条件时原样返回
+func (q NewsQuery) filter(news []model.NewsItem) []model.NewsItem {
+	if q.Evaluate == "" && q.Entity == "" {
+		return news
+	}
+
+	filtered := make([]model.NewsItem, 0, len(news))
+	for _, item := range news {
+		if q.Evaluate != "" && item.Evaluate != q.Evaluate {
+			continue
+		}
+		if q.Entity != "" && !newsMentions(item, q.Entity) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+// newsMentions 快讯关联股票中是否有代码等于 entity 或名称包含 entity 的，不区分大小写
+func newsMentions(item model.NewsItem, entity string) bool {
+	entity = strings.ToLower(entity)
+	for _, e := range item.Entities {
+		if strings.ToLower(e.Code) == entity || strings.Contains(strings.ToLower(e.Name), entity) {
+			return true
+		}
+	}
+	return false
+}
+
+// getNewsPage 获取未过滤的一页快讯
+// 最新快讯列表足够覆盖该页时直接从中截取；否则数据源支持分页时按起始位置获取，不支持时获取前 offset+limit 条后截取
+func (s *newsService) getNewsPage(ctx context.Context, offset, limit int) ([]model.NewsItem, error) {
 	// 尝试从缓存获取
 	var news []model.NewsItem
 	err := s.cache.GetJSON(ctx, CacheKeyNews, &news)
-	if err == nil && len(news) > 0 {
-		// 如果缓存数量足够，直接返回
-		if len(news) >= count {
-			return news[:count], nil
-		}
+	if err == nil && len(news) >= offset+limit {
+		return news[offset : offset+limit], nil
+	}
+
+	if offset > 0 && s.fetchPage != nil {
+		return s.getNewsPageFromSource(ctx, offset, limit)
 	}
 
 	// 从百度股市通获取
-	news, err = s.baiduCrawler.GetNewsFlash(ctx, count)
+	latest, err := s.newsCrawler.GetNewsFlash(ctx, offset+limit)
 	if err != nil {
-		// 如果获取失败但有缓存，返回缓存数据
+		// 如果获取失败但有缓存，返回缓存中能覆盖的部分
 		if len(news) > 0 {
-			return news, nil
+			return pageOf(news, offset, limit), nil
 		}
 		return nil, err
 	}
 
 	// 缓存结果
-	_ = s.cache.SetJSON(ctx, CacheKeyNews, news, TTLNews)
+	_ = s.cache.SetJSON(ctx, CacheKeyNews, latest, TTLNews)
+
+	return pageOf(latest, offset, limit), nil
+}
+
+// getNewsPageFromSource 按起始位置从数据源获取一页快讯，按 offset 与 limit 单独缓存
+func (s *newsService) getNewsPageFromSource(ctx context.Context, offset, limit int) ([]model.NewsItem, error) {
+	cacheKey := fmt.Sprintf(cacheKeyNewsPage, offset, limit)
+
+	var news []model.NewsItem
+	if err := s.cache.GetJSON(ctx, cacheKey, &news); err == nil && news != nil {
+		return news, nil
+	}
+
+	news, err := s.fetchPage(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	news = pageOf(news, 0, limit)
+
+	_ = s.cache.SetJSON(ctx, cacheKey, news, TTLNews)
 
 	return news, nil
 }
+
+// pageOf 截取 news[offset:offset+limit]，越界部分忽略
+func pageOf(news []model.NewsItem, offset, limit int) []model.NewsItem {
+	if offset >= len(news) {
+		return []model.NewsItem{}
+	}
+	return news[offset:min(offset+limit, len(news))]
+}
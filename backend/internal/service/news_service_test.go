@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newsFeedCrawler 按条数返回预设快讯流（最新在前）的前若干条，不支持分页
+type newsFeedCrawler struct {
+	crawlerCalls
+	feed []model.NewsItem
+}
+
+func (c *newsFeedCrawler) GetNewsFlash(ctx context.Context, count int) ([]model.NewsItem, error) {
+	c.record(fmt.Sprintf("GetNewsFlash:%d", count))
+	return pageOf(c.feed, 0, count), nil
+}
+
+// pagedNewsCrawler 支持按起始位置分页的快讯流
+type pagedNewsCrawler struct {
+	newsFeedCrawler
+}
+
+func (c *pagedNewsCrawler) GetNewsFlashPage(ctx context.Context, start, count int) ([]model.NewsItem, error) {
+	c.record(fmt.Sprintf("GetNewsFlashPage:%d:%d", start, count))
+	return pageOf(c.feed, start, count), nil
+}
+
+// newsCrawlerWithCalls 记录调用次数的快讯数据源
+type newsCrawlerWithCalls interface {
+	GetNewsFlash(ctx context.Context, count int) ([]model.NewsItem, error)
+	count(name string) int
+}
+
+// newsFeed 生成 n 条快讯，ID 为 n0、n1…，发布时间每条早一分钟
+func newsFeed(n int) []model.NewsItem {
+	feed := make([]model.NewsItem, n)
+	for i := range feed {
+		feed[i] = model.NewsItem{ID: fmt.Sprintf("n%d", i), PublishTime: 1709256600000 - int64(i)*60000}
+	}
+	return feed
+}
+
+func newsIDs(items []model.NewsItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestNewsService_GetNews_Defaults(t *testing.T) {
+	feed := &newsFeedCrawler{feed: newsFeed(120)}
+	svc := NewNewsService(feed, NewMemoryCache())
+
+	page, err := svc.GetNews(context.Background(), NewsQuery{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, page.Offset)
+	assert.Equal(t, DefaultNewsLimit, page.Limit)
+	assert.Equal(t, NewsSortDesc, page.Sort)
+	require.Len(t, page.Items, DefaultNewsLimit)
+	assert.Equal(t, "n0", page.Items[0].ID)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, 1, feed.count("GetNewsFlash:50"))
+
+	// 超过上限时按上限返回
+	page, err = svc.GetNews(context.Background(), NewsQuery{Limit: 500})
+	require.NoError(t, err)
+	assert.Equal(t, MaxNewsLimit, page.Limit)
+	assert.Len(t, page.Items, MaxNewsLimit)
+}
+
+func TestNewsService_GetNews_InvalidQuery(t *testing.T) {
+	svc := NewNewsService(&newsFeedCrawler{feed: newsFeed(10)}, NewMemoryCache())
+
+	for _, query := range []NewsQuery{{Offset: -1}, {Sort: "newest"}, {Sort: "DESC"}} {
+		_, err := svc.GetNews(context.Background(), query)
+		assert.ErrorIs(t, err, ErrInvalidNewsQuery, "%+v", query)
+	}
+}
+
+func TestNewsService_GetNews_PagingBoundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  int
+		ids     []string
+		hasMore bool
+	}{
+		{"first page", 0, []string{"n0", "n1", "n2", "n3", "n4"}, true},
+		{"full last page", 10, []string{"n10", "n11", "n12", "n13", "n14"}, true},
+		{"partial last page", 12, []string{"n12", "n13", "n14"}, false},
+		{"offset at end", 15, []string{}, false},
+		{"offset past end", 40, []string{}, false},
+	}
+
+	crawlers := map[string]func() newsCrawlerWithCalls{
+		"without paging": func() newsCrawlerWithCalls { return &newsFeedCrawler{feed: newsFeed(15)} },
+		"with paging":    func() newsCrawlerWithCalls { return &pagedNewsCrawler{newsFeedCrawler{feed: newsFeed(15)}} },
+	}
+
+	for source, newCrawler := range crawlers {
+		for _, tt := range tests {
+			t.Run(source+"/"+tt.name, func(t *testing.T) {
+				svc := NewNewsService(newCrawler(), NewMemoryCache())
+
+				page, err := svc.GetNews(context.Background(), NewsQuery{Offset: tt.offset, Limit: 5})
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.ids, newsIDs(page.Items))
+				assert.Equal(t, tt.hasMore, page.HasMore)
+			})
+		}
+	}
+}
+
+func TestNewsService_GetNews_PagesFromSourceStartIndex(t *testing.T) {
+	feed := &pagedNewsCrawler{newsFeedCrawler{feed: newsFeed(60)}}
+	svc := NewNewsService(feed, NewMemoryCache())
+	ctx := context.Background()
+
+	_, err := svc.GetNews(ctx, NewsQuery{Limit: 20})
+	require.NoError(t, err)
+
+	// 最新快讯缓存覆盖的页直接截取
+	page, err := svc.GetNews(ctx, NewsQuery{Offset: 10, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "n10", page.Items[0].ID)
+	assert.Equal(t, 1, feed.count("GetNewsFlash:20"))
+
+	// 更早的页按起始位置获取并单独缓存
+	for i := 0; i < 2; i++ {
+		page, err = svc.GetNews(ctx, NewsQuery{Offset: 20, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "n20", page.Items[0].ID)
+		assert.Len(t, page.Items, 10)
+	}
+	assert.Equal(t, 1, feed.count("GetNewsFlashPage:20:10"))
+	assert.Zero(t, feed.count("GetNewsFlash:30"), "paging source should not refetch the whole prefix")
+}
+
+func TestNewsService_GetNews_Sort(t *testing.T) {
+	feed := newsFeed(3)
+	// 上游偶有乱序，默认同样按发布时间倒序
+	feed[0], feed[1] = feed[1], feed[0]
+	svc := NewNewsService(&newsFeedCrawler{feed: feed}, NewMemoryCache())
+
+	page, err := svc.GetNews(context.Background(), NewsQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"n0", "n1", "n2"}, newsIDs(page.Items))
+
+	page, err = svc.GetNews(context.Background(), NewsQuery{Limit: 3, Sort: NewsSortAsc})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"n2", "n1", "n0"}, newsIDs(page.Items))
+
+	// 排序不修改缓存中的顺序
+	page, err = svc.GetNews(context.Background(), NewsQuery{Limit: 1, Sort: NewsSortAsc})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"n1"}, newsIDs(page.Items))
+}
+
+// filterNewsFixture 含不同情绪与关联股票的快讯
+var filterNewsFixture = []model.NewsItem{
+	{ID: "1", Title: "贵州茅台一季度营收增长", Evaluate: "利好", Entities: []model.NewsEntity{{Code: "600519", Name: "贵州茅台"}}},
+	{ID: "2", Title: "某白酒企业遭立案调查", Evaluate: "利空", Entities: []model.NewsEntity{{Code: "000858", Name: "五粮液"}}},
+	{ID: "3", Title: "英伟达发布新一代 GPU", Evaluate: "利好", Entities: []model.NewsEntity{{Code: "NVDA", Name: "NVIDIA"}}},
+	{ID: "4", Title: "央行开展逆回购操作", Evaluate: ""},
+	{ID: "5", Title: "白酒板块午后走弱", Evaluate: "利空", Entities: []model.NewsEntity{
+		{Code: "000858", Name: "五粮液"}, {Code: "600519", Name: "贵州茅台"},
+	}},
+}
+
+func TestNewsService_GetNews_Filter(t *testing.T) {
+	tests := []struct {
+		name  string
+		query NewsQuery
+		ids   []string
+	}{
+		{"no filter", NewsQuery{}, []string{"1", "2", "3", "4", "5"}},
+		{"bullish", NewsQuery{Evaluate: NewsEvaluateBullish}, []string{"1", "3"}},
+		{"bearish", NewsQuery{Evaluate: NewsEvaluateBearish}, []string{"2", "5"}},
+		{"entity code", NewsQuery{Entity: "600519"}, []string{"1", "5"}},
+		{"entity code case-insensitive", NewsQuery{Entity: "nvda"}, []string{"3"}},
+		{"entity name substring", NewsQuery{Entity: " 茅台 "}, []string{"1", "5"}},
+		{"entity name case-insensitive", NewsQuery{Entity: "nvidia"}, []string{"3"}},
+		{"code must match exactly", NewsQuery{Entity: "6005"}, []string{}},
+		{"sentiment and entity", NewsQuery{Evaluate: NewsEvaluateBearish, Entity: "贵州茅台"}, []string{"5"}},
+		{"no match", NewsQuery{Entity: "宁德时代"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewNewsService(&mockNewsCrawler{news: filterNewsFixture}, NewMemoryCache())
+
+			page, err := svc.GetNews(context.Background(), tt.query)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.ids, newsIDs(page.Items))
+		})
+	}
+}
+
+func TestNewsService_GetNews_InvalidEvaluate(t *testing.T) {
+	svc := NewNewsService(&mockNewsCrawler{news: filterNewsFixture}, NewMemoryCache())
+
+	for _, evaluate := range []string{"bullish", "利好 ", "中性"} {
+		_, err := svc.GetNews(context.Background(), NewsQuery{Evaluate: evaluate})
+		assert.ErrorIs(t, err, ErrInvalidNewsQuery, evaluate)
+	}
+}
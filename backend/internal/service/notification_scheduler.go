@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// maxPendingNotifications 单个用户免打扰期间最多暂存的通知数，超出时丢弃最早的通知
+const maxPendingNotifications = 100
+
+// Notifier 通知发送渠道（邮件、Webhook 等）
+type Notifier interface {
+	Send(ctx context.Context, notification model.Notification) error
+}
+
+// pendingDigest 免打扰期间暂存的通知
+type pendingDigest struct {
+	items     []model.Notification
+	ids       []int64   // 与 items 对应的持久化记录 ID，未持久化时为 0
+	deliverAt time.Time // 免打扰结束时刻
+}
+
+// trim 超出 maxPendingNotifications 时丢弃最早的通知，返回被丢弃通知的持久化记录 ID
+func (d *pendingDigest) trim() []int64 {
+	excess := len(d.items) - maxPendingNotifications
+	if excess <= 0 {
+		return nil
+	}
+	dropped := d.ids[:excess]
+	d.items = d.items[excess:]
+	d.ids = d.ids[excess:]
+	return dropped
+}
+
+// NotificationScheduler 按用户免打扰时段发送通知
+// 免打扰期间的通知暂存，结束后合并为一条摘要发送；其余时间立即发送
+// 暂存的通知同时写入 store，服务重启后经 Restore 恢复
+type NotificationScheduler struct {
+	quietHours QuietHoursService
+	notifier   Notifier
+	store      repository.PendingNotificationRepository // 为 nil 时暂存的通知只保存在内存中，重启后丢失
+	logger     *zap.Logger
+	now        func() time.Time
+
+	mu      sync.Mutex
+	pending map[int64]*pendingDigest
+}
+
+// NewNotificationScheduler 创建通知调度器，暂存的通知只保存在内存中
+func NewNotificationScheduler(quietHours QuietHoursService, notifier Notifier, logger *zap.Logger) *NotificationScheduler {
+	return NewNotificationSchedulerWithStore(quietHours, notifier, nil, logger)
+}
+
+// NewNotificationSchedulerWithStore 创建持久化暂存通知的通知调度器
+// store 为 nil 时暂存的通知只保存在内存中，重启后丢失
+func NewNotificationSchedulerWithStore(quietHours QuietHoursService, notifier Notifier, store repository.PendingNotificationRepository, logger *zap.Logger) *NotificationScheduler {
+	return &NotificationScheduler{
+		quietHours: quietHours,
+		notifier:   notifier,
+		store:      store,
+		logger:     logger,
+		now:        time.Now,
+		pending:    make(map[int64]*pendingDigest),
+	}
+}
+
+// Restore 从 store 恢复上次运行时暂存、尚未发送的通知，应在 Dispatch 与 Flush 之前调用
+// 已过免打扰结束时刻的摘要在下次 Flush 时发送
+func (s *NotificationScheduler) Restore(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	stored, err := s.store.ListPendingNotifications(ctx)
+	if err != nil {
+		return err
+	}
+
+	var dropped []int64
+	s.mu.Lock()
+	for _, item := range stored {
+		digest, ok := s.pending[item.UserID]
+		if !ok {
+			digest = &pendingDigest{deliverAt: item.DeliverAt}
+			s.pending[item.UserID] = digest
+		}
+		if item.DeliverAt.Before(digest.deliverAt) {
+			digest.deliverAt = item.DeliverAt
+		}
+		digest.items = append(digest.items, model.Notification{
+			UserID:    item.UserID,
+			Title:     item.Title,
+			Body:      item.Body,
+			CreatedAt: item.CreatedAt,
+		})
+		digest.ids = append(digest.ids, item.ID)
+		dropped = append(dropped, digest.trim()...)
+	}
+	s.mu.Unlock()
+
+	s.deleteStored(ctx, dropped)
+	return nil
+}
+
+// Dispatch 发送通知，处于免打扰时段时暂存并返回 deferred = true
+// 获取免打扰配置失败时直接发送，避免丢失通知
+func (s *NotificationScheduler) Dispatch(ctx context.Context, notification model.Notification) (deferred bool, err error) {
+	return s.dispatch(ctx, notification, s.now())
+}
+
+func (s *NotificationScheduler) dispatch(ctx context.Context, notification model.Notification, now time.Time) (bool, error) {
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = now
+	}
+
+	if window, ok := s.quietWindow(ctx, notification.UserID); ok && window.contains(now) {
+		s.hold(ctx, notification, window.endAfter(now))
+		return true, nil
+	}
+
+	// 免打扰刚结束但摘要尚未发送时，先发送摘要保证顺序
+	if digest := s.takeDue(notification.UserID, now); digest != nil {
+		s.deliverDigest(ctx, notification.UserID, digest)
+	}
+	return false, s.notifier.Send(ctx, notification)
+}
+
+// quietWindow 获取用户已启用的免打扰时段
+func (s *NotificationScheduler) quietWindow(ctx context.Context, userID int64) (quietWindow, bool) {
+	quietHours, err := s.quietHours.Get(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to load quiet hours, sending immediately",
+			zap.Int64("userID", userID), zap.Error(err))
+		return quietWindow{}, false
+	}
+	if !quietHours.Enabled {
+		return quietWindow{}, false
+	}
+	window, err := newQuietWindow(quietHours)
+	if err != nil {
+		s.logger.Warn("Invalid quiet hours, sending immediately",
+			zap.Int64("userID", userID), zap.Error(err))
+		return quietWindow{}, false
+	}
+	return window, true
+}
+
+// hold 暂存通知，摘要在免打扰结束后发送
+// 持久化失败时仍在内存中暂存，只是重启后无法恢复
+func (s *NotificationScheduler) hold(ctx context.Context, notification model.Notification, deliverAt time.Time) {
+	var id int64
+	if s.store != nil {
+		stored := &model.PendingNotification{
+			UserID:    notification.UserID,
+			Title:     notification.Title,
+			Body:      notification.Body,
+			CreatedAt: notification.CreatedAt,
+			DeliverAt: deliverAt,
+		}
+		if err := s.store.AddPendingNotification(ctx, stored); err != nil {
+			s.logger.Warn("Failed to persist deferred notification, keeping it in memory only",
+				zap.Int64("userID", notification.UserID), zap.Error(err))
+		} else {
+			id = stored.ID
+		}
+	}
+
+	s.mu.Lock()
+	digest, ok := s.pending[notification.UserID]
+	if !ok {
+		digest = &pendingDigest{deliverAt: deliverAt}
+		s.pending[notification.UserID] = digest
+	}
+	digest.items = append(digest.items, notification)
+	digest.ids = append(digest.ids, id)
+	dropped := digest.trim()
+	s.mu.Unlock()
+
+	s.deleteStored(ctx, dropped)
+}
+
+// deleteStored 删除已发送或已丢弃通知的持久化记录，失败时只记录日志，重启后可能重复发送
+func (s *NotificationScheduler) deleteStored(ctx context.Context, ids []int64) {
+	if s.store == nil {
+		return
+	}
+	stored := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 {
+			stored = append(stored, id)
+		}
+	}
+	if len(stored) == 0 {
+		return
+	}
+	if err := s.store.DeletePendingNotifications(ctx, stored); err != nil {
+		s.logger.Warn("Failed to delete delivered notifications", zap.Int("count", len(stored)), zap.Error(err))
+	}
+}
+
+// takeDue 取出用户已到期的摘要
+func (s *NotificationScheduler) takeDue(userID int64, now time.Time) *pendingDigest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	digest, ok := s.pending[userID]
+	if !ok || now.Before(digest.deliverAt) {
+		return nil
+	}
+	delete(s.pending, userID)
+	return digest
+}
+
+// Flush 发送所有已到期的摘要，返回发送的摘要数
+func (s *NotificationScheduler) Flush(ctx context.Context) int {
+	return s.flush(ctx, s.now())
+}
+
+func (s *NotificationScheduler) flush(ctx context.Context, now time.Time) int {
+	s.mu.Lock()
+	userIDs := make([]int64, 0, len(s.pending))
+	for userID, digest := range s.pending {
+		if !now.Before(digest.deliverAt) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	sent := 0
+	for _, userID := range userIDs {
+		if digest := s.takeDue(userID, now); digest != nil && s.deliverDigest(ctx, userID, digest) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Run 定期发送到期的摘要，直到 ctx 结束
+func (s *NotificationScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Pending 获取用户暂存的通知数
+func (s *NotificationScheduler) Pending(userID int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if digest, ok := s.pending[userID]; ok {
+		return len(digest.items)
+	}
+	return 0
+}
+
+// deliverDigest 发送摘要，失败时重新暂存等待下次发送
+func (s *NotificationScheduler) deliverDigest(ctx context.Context, userID int64, digest *pendingDigest) bool {
+	if err := s.notifier.Send(ctx, buildDigest(userID, digest.items)); err != nil {
+		s.logger.Warn("Failed to send notification digest",
+			zap.Int64("userID", userID), zap.Int("count", len(digest.items)), zap.Error(err))
+		s.requeue(ctx, userID, digest)
+		return false
+	}
+	s.deleteStored(ctx, digest.ids)
+	return true
+}
+
+// requeue 将发送失败的摘要放回队列，保留期间新暂存的通知
+func (s *NotificationScheduler) requeue(ctx context.Context, userID int64, digest *pendingDigest) {
+	s.mu.Lock()
+	if existing, ok := s.pending[userID]; ok {
+		digest.items = append(digest.items, existing.items...)
+		digest.ids = append(digest.ids, existing.ids...)
+	}
+	dropped := digest.trim()
+	s.pending[userID] = digest
+	s.mu.Unlock()
+
+	s.deleteStored(ctx, dropped)
+}
+
+// buildDigest 将暂存的通知合并为一条摘要，只有一条时原样发送
+func buildDigest(userID int64, items []model.Notification) model.Notification {
+	if len(items) == 1 {
+		return items[0]
+	}
+
+	var sb strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("[%s] %s\n%s", item.CreatedAt.Format("01-02 15:04"), item.Title, item.Body))
+	}
+	return model.Notification{
+		UserID:    userID,
+		Title:     fmt.Sprintf("免打扰期间的 %d 条通知", len(items)),
+		Body:      sb.String(),
+		CreatedAt: items[len(items)-1].CreatedAt,
+	}
+}
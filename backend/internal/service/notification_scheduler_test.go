@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeQuietHoursRepo 内存中的免打扰时段仓库
+type fakeQuietHoursRepo struct {
+	items map[int64]*model.QuietHours
+}
+
+func (r *fakeQuietHoursRepo) GetQuietHours(ctx context.Context, userID int64) (*model.QuietHours, error) {
+	if q, ok := r.items[userID]; ok {
+		return q, nil
+	}
+	return nil, repository.ErrQuietHoursNotFound
+}
+
+func (r *fakeQuietHoursRepo) UpsertQuietHours(ctx context.Context, q *model.QuietHours) error {
+	r.items[q.UserID] = q
+	return nil
+}
+
+// recordingNotifier 记录已发送的通知
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []model.Notification
+	err  error
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, notification model.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+// newTestScheduler 用户 1 设置 22:00-07:00（北京时间）免打扰，用户 2 未设置
+func newTestScheduler(t *testing.T) (*NotificationScheduler, *recordingNotifier) {
+	t.Helper()
+	repo := &fakeQuietHoursRepo{items: make(map[int64]*model.QuietHours)}
+	quietHours := NewQuietHoursService(repo)
+	_, err := quietHours.Update(context.Background(), 1, &model.UpdateQuietHoursRequest{
+		Enabled: true, Start: "22:00", End: "07:00", Timezone: "Asia/Shanghai",
+	})
+	require.NoError(t, err)
+
+	notifier := &recordingNotifier{}
+	return NewNotificationScheduler(quietHours, notifier, zap.NewNop()), notifier
+}
+
+func shanghaiTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	ts, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestNotificationScheduler_DeferredDuringQuietHoursAndDeliveredAsDigest(t *testing.T) {
+	ctx := context.Background()
+	s, notifier := newTestScheduler(t)
+
+	// 凌晨 3 点（UTC 19:00）的两条通知被暂存
+	for i, at := range []string{"2024-03-01 23:30", "2024-03-02 03:00"} {
+		deferred, err := s.dispatch(ctx, model.Notification{UserID: 1, Title: "金价突破", Body: "现货黄金上涨 2%"}, shanghaiTime(t, at))
+		require.NoError(t, err)
+		assert.True(t, deferred)
+		assert.Equal(t, i+1, s.Pending(1))
+	}
+	assert.Empty(t, notifier.sent)
+
+	// 免打扰结束前不发送
+	assert.Equal(t, 0, s.flush(ctx, shanghaiTime(t, "2024-03-02 06:59")))
+	assert.Empty(t, notifier.sent)
+
+	// 结束后合并为一条摘要
+	assert.Equal(t, 1, s.flush(ctx, shanghaiTime(t, "2024-03-02 07:00")))
+	require.Len(t, notifier.sent, 1)
+	digest := notifier.sent[0]
+	assert.Equal(t, int64(1), digest.UserID)
+	assert.Equal(t, "免打扰期间的 2 条通知", digest.Title)
+	assert.Contains(t, digest.Body, "现货黄金上涨 2%")
+	assert.Equal(t, 0, s.Pending(1))
+}
+
+func TestNotificationScheduler_ImmediateOutsideQuietHours(t *testing.T) {
+	ctx := context.Background()
+	s, notifier := newTestScheduler(t)
+
+	deferred, err := s.dispatch(ctx, model.Notification{UserID: 1, Title: "午间提醒"}, shanghaiTime(t, "2024-03-01 12:00"))
+	require.NoError(t, err)
+	assert.False(t, deferred)
+
+	// 未设置免打扰的用户任何时间都立即发送
+	deferred, err = s.dispatch(ctx, model.Notification{UserID: 2, Title: "深夜提醒"}, shanghaiTime(t, "2024-03-01 03:00"))
+	require.NoError(t, err)
+	assert.False(t, deferred)
+
+	require.Len(t, notifier.sent, 2)
+	assert.Equal(t, "午间提醒", notifier.sent[0].Title)
+	assert.Equal(t, "深夜提醒", notifier.sent[1].Title)
+}
+
+func TestNotificationScheduler_PendingDigestSentBeforeNextNotification(t *testing.T) {
+	ctx := context.Background()
+	s, notifier := newTestScheduler(t)
+
+	_, err := s.dispatch(ctx, model.Notification{UserID: 1, Title: "夜间"}, shanghaiTime(t, "2024-03-01 23:00"))
+	require.NoError(t, err)
+
+	// 定时 Flush 之前免打扰已结束，新通知到达时先发送暂存的通知
+	_, err = s.dispatch(ctx, model.Notification{UserID: 1, Title: "早间"}, shanghaiTime(t, "2024-03-02 08:00"))
+	require.NoError(t, err)
+
+	require.Len(t, notifier.sent, 2)
+	assert.Equal(t, "夜间", notifier.sent[0].Title)
+	assert.Equal(t, "早间", notifier.sent[1].Title)
+}
+
+func TestNotificationScheduler_FailedDigestRequeued(t *testing.T) {
+	ctx := context.Background()
+	s, notifier := newTestScheduler(t)
+
+	_, err := s.dispatch(ctx, model.Notification{UserID: 1, Title: "夜间"}, shanghaiTime(t, "2024-03-01 23:00"))
+	require.NoError(t, err)
+
+	notifier.err = errors.New("smtp unavailable")
+	assert.Equal(t, 0, s.flush(ctx, shanghaiTime(t, "2024-03-02 07:30")))
+	assert.Equal(t, 1, s.Pending(1))
+
+	notifier.err = nil
+	assert.Equal(t, 1, s.flush(ctx, shanghaiTime(t, "2024-03-02 07:31")))
+	assert.Equal(t, 0, s.Pending(1))
+}
+
+// fakePendingNotificationStore 内存中的暂存通知仓库
+type fakePendingNotificationStore struct {
+	items  []model.PendingNotification
+	nextID int64
+}
+
+func (r *fakePendingNotificationStore) AddPendingNotification(ctx context.Context, n *model.PendingNotification) error {
+	r.nextID++
+	n.ID = r.nextID
+	r.items = append(r.items, *n)
+	return nil
+}
+
+func (r *fakePendingNotificationStore) ListPendingNotifications(ctx context.Context) ([]model.PendingNotification, error) {
+	return append([]model.PendingNotification(nil), r.items...), nil
+}
+
+func (r *fakePendingNotificationStore) DeletePendingNotifications(ctx context.Context, ids []int64) error {
+	deleted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	kept := r.items[:0]
+	for _, item := range r.items {
+		if !deleted[item.ID] {
+			kept = append(kept, item)
+		}
+	}
+	r.items = kept
+	return nil
+}
+
+func TestNotificationScheduler_DeferredNotificationsSurviveRestart(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestScheduler(t)
+	store := &fakePendingNotificationStore{}
+	s.store = store
+
+	for _, at := range []string{"2024-03-01 23:30", "2024-03-02 03:00"} {
+		deferred, err := s.dispatch(ctx, model.Notification{UserID: 1, Title: "金价突破"}, shanghaiTime(t, at))
+		require.NoError(t, err)
+		assert.True(t, deferred)
+	}
+	require.Len(t, store.items, 2)
+	assert.Equal(t, shanghaiTime(t, "2024-03-02 07:00"), store.items[0].DeliverAt)
+
+	// 重启后从仓库恢复，免打扰结束后发送摘要并删除记录
+	restarted, notifier := newTestScheduler(t)
+	restarted.store = store
+	require.NoError(t, restarted.Restore(ctx))
+	assert.Equal(t, 2, restarted.Pending(1))
+
+	assert.Equal(t, 0, restarted.flush(ctx, shanghaiTime(t, "2024-03-02 06:00")))
+	assert.Equal(t, 1, restarted.flush(ctx, shanghaiTime(t, "2024-03-02 07:00")))
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, "免打扰期间的 2 条通知", notifier.sent[0].Title)
+	assert.Empty(t, store.items)
+}
+
+func TestNotificationScheduler_FailedDigestKeptInStore(t *testing.T) {
+	ctx := context.Background()
+	s, notifier := newTestScheduler(t)
+	store := &fakePendingNotificationStore{}
+	s.store = store
+
+	_, err := s.dispatch(ctx, model.Notification{UserID: 1, Title: "夜间"}, shanghaiTime(t, "2024-03-01 23:00"))
+	require.NoError(t, err)
+
+	notifier.err = errors.New("smtp unavailable")
+	assert.Equal(t, 0, s.flush(ctx, shanghaiTime(t, "2024-03-02 07:30")))
+	assert.Len(t, store.items, 1, "undelivered digest should stay persisted")
+
+	notifier.err = nil
+	assert.Equal(t, 1, s.flush(ctx, shanghaiTime(t, "2024-03-02 07:31")))
+	assert.Empty(t, store.items)
+}
+
+func TestQuietWindow(t *testing.T) {
+	overnight, err := newQuietWindow(&model.QuietHours{Start: "22:00", End: "07:00", Timezone: "Asia/Shanghai"})
+	require.NoError(t, err)
+	daytime, err := newQuietWindow(&model.QuietHours{Start: "12:00", End: "13:30", Timezone: "Asia/Shanghai"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		window quietWindow
+		at     string
+		want   bool
+	}{
+		{overnight, "2024-03-01 21:59", false},
+		{overnight, "2024-03-01 22:00", true},
+		{overnight, "2024-03-02 03:00", true},
+		{overnight, "2024-03-02 07:00", false},
+		{daytime, "2024-03-01 12:30", true},
+		{daytime, "2024-03-01 13:30", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.window.contains(shanghaiTime(t, tt.at)), tt.at)
+	}
+
+	// 时区换算：UTC 19:00 即北京时间凌晨 3 点
+	assert.True(t, overnight.contains(time.Date(2024, 3, 1, 19, 0, 0, 0, time.UTC)))
+	assert.Equal(t, shanghaiTime(t, "2024-03-02 07:00"), overnight.endAfter(shanghaiTime(t, "2024-03-01 23:00")))
+	assert.Equal(t, shanghaiTime(t, "2024-03-02 07:00"), overnight.endAfter(shanghaiTime(t, "2024-03-02 03:00")))
+}
+
+func TestQuietHoursService_UpdateValidates(t *testing.T) {
+	svc := NewQuietHoursService(&fakeQuietHoursRepo{items: make(map[int64]*model.QuietHours)})
+
+	for _, req := range []model.UpdateQuietHoursRequest{
+		{Start: "25:00", End: "07:00"},
+		{Start: "22:00", End: "7am"},
+		{Start: "22:00", End: "22:00"},
+		{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"},
+	} {
+		_, err := svc.Update(context.Background(), 1, &req)
+		assert.ErrorIs(t, err, ErrInvalidQuietHours, req)
+	}
+
+	// 未设置时返回未启用的默认配置
+	q, err := svc.Get(context.Background(), 2)
+	require.NoError(t, err)
+	assert.False(t, q.Enabled)
+	assert.Equal(t, DefaultQuietHoursTimezone, q.Timezone)
+}
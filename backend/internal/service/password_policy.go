@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fund-analyzer/internal/config"
+)
+
+// 密码长度限制
+const (
+	DefaultPasswordMinLength = 8
+	// maxPasswordBytes bcrypt 只接受不超过 72 字节的密码
+	maxPasswordBytes = 72
+)
+
+// WeakPasswordError 密码不满足策略中的某条规则，Reason 说明具体原因
+type WeakPasswordError struct {
+	Reason string
+}
+
+func (e *WeakPasswordError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrWeakPassword, e.Reason)
+}
+
+func (e *WeakPasswordError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// PasswordPolicy 密码强度策略
+type PasswordPolicy struct {
+	MinLength     int  // 最少字符数
+	RequireLetter bool // 至少一个字母（不区分大小写）
+	RequireUpper  bool // 至少一个大写字母
+	RequireLower  bool // 至少一个小写字母
+	RequireDigit  bool // 至少一个数字
+	RequireSymbol bool // 至少一个符号（字母、数字、空白以外的字符）
+
+	// blocklist 禁止使用的常见密码，比较时忽略大小写
+	blocklist map[string]struct{}
+}
+
+// DefaultPasswordPolicy 默认策略：至少 8 位，包含字母和数字
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:     DefaultPasswordMinLength,
+		RequireLetter: true,
+		RequireDigit:  true,
+	}
+}
+
+// NewPasswordPolicy 根据配置创建密码策略
+func NewPasswordPolicy(cfg config.PasswordPolicyConfig) (*PasswordPolicy, error) {
+	if cfg.MinLength < 0 || cfg.MinLength > maxPasswordBytes {
+		return nil, fmt.Errorf("password min_length must be between 0 and %d, got %d", maxPasswordBytes, cfg.MinLength)
+	}
+
+	blocklist := make(map[string]struct{}, len(cfg.Blocklist))
+	for _, password := range cfg.Blocklist {
+		if password = strings.TrimSpace(password); password != "" {
+			blocklist[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	return &PasswordPolicy{
+		MinLength:     cfg.MinLength,
+		RequireLetter: cfg.RequireLetter,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+		blocklist:     blocklist,
+	}, nil
+}
+
+// ValidatePassword 按策略校验密码，返回第一条未满足规则对应的 *WeakPasswordError
+func (p *PasswordPolicy) ValidatePassword(password string) error {
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		return &WeakPasswordError{Reason: fmt.Sprintf("must be at least %d characters", p.MinLength)}
+	}
+	if len(password) > maxPasswordBytes {
+		return &WeakPasswordError{Reason: fmt.Sprintf("must be at most %d bytes", maxPasswordBytes)}
+	}
+
+	var hasLetter, hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+			hasUpper = hasUpper || unicode.IsUpper(r)
+			hasLower = hasLower || unicode.IsLower(r)
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case p.RequireLetter && !hasLetter:
+		return &WeakPasswordError{Reason: "must contain a letter"}
+	case p.RequireUpper && !hasUpper:
+		return &WeakPasswordError{Reason: "must contain an uppercase letter"}
+	case p.RequireLower && !hasLower:
+		return &WeakPasswordError{Reason: "must contain a lowercase letter"}
+	case p.RequireDigit && !hasDigit:
+		return &WeakPasswordError{Reason: "must contain a number"}
+	case p.RequireSymbol && !hasSymbol:
+		return &WeakPasswordError{Reason: "must contain a symbol"}
+	}
+
+	if _, blocked := p.blocklist[strings.ToLower(password)]; blocked {
+		return &WeakPasswordError{Reason: "is too common"}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_ValidatePassword(t *testing.T) {
+	strict := config.PasswordPolicyConfig{
+		MinLength:     12,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		Blocklist:     []string{" Password123!Abc "},
+	}
+	relaxed := config.PasswordPolicyConfig{MinLength: 6}
+
+	tests := []struct {
+		name     string
+		policy   config.PasswordPolicyConfig
+		password string
+		reason   string // 为空表示校验通过
+	}{
+		{"default ok", config.PasswordPolicyConfig{MinLength: 8, RequireLetter: true, RequireDigit: true}, "password123", ""},
+		{"default too short", config.PasswordPolicyConfig{MinLength: 8, RequireLetter: true, RequireDigit: true}, "pass123", "must be at least 8 characters"},
+		{"default no digit", config.PasswordPolicyConfig{MinLength: 8, RequireLetter: true, RequireDigit: true}, "passwordonly", "must contain a number"},
+		{"default no letter", config.PasswordPolicyConfig{MinLength: 8, RequireLetter: true, RequireDigit: true}, "12345678", "must contain a letter"},
+		{"strict ok", strict, "Tr0ub4dor&3xyz", ""},
+		{"strict too short", strict, "Tr0ub4dor&3", "must be at least 12 characters"},
+		{"strict no upper", strict, "tr0ub4dor&3xyz", "must contain an uppercase letter"},
+		{"strict no lower", strict, "TR0UB4DOR&3XYZ", "must contain a lowercase letter"},
+		{"strict no symbol", strict, "Tr0ub4dor33xyz", "must contain a symbol"},
+		{"strict blocklisted ignoring case", strict, "password123!ABC", "is too common"},
+		{"relaxed letters only", relaxed, "abcdef", ""},
+		{"relaxed counts characters not bytes", relaxed, "基金分析助手", ""},
+		{"relaxed too short", relaxed, "abc", "must be at least 6 characters"},
+		{"exceeds bcrypt limit", relaxed, strings.Repeat("a", 73), "must be at most 72 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPasswordPolicy(tt.policy)
+			require.NoError(t, err)
+
+			err = policy.ValidatePassword(tt.password)
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrWeakPassword)
+			var weak *WeakPasswordError
+			require.ErrorAs(t, err, &weak)
+			assert.Equal(t, tt.reason, weak.Reason)
+		})
+	}
+}
+
+func TestNewPasswordPolicy_InvalidMinLength(t *testing.T) {
+	for _, n := range []int{-1, 73} {
+		_, err := NewPasswordPolicy(config.PasswordPolicyConfig{MinLength: n})
+		assert.Error(t, err)
+	}
+}
+
+func TestAuthService_ResetPassword_UsesPolicy(t *testing.T) {
+	svc, _ := newTestAuthService(t, "password123")
+	svc.(*authService).passwordPolicy = &PasswordPolicy{MinLength: 8, RequireSymbol: true}
+
+	err := svc.ResetPassword(context.Background(), "user@example.com", "123456", "password123")
+
+	var weak *WeakPasswordError
+	require.ErrorAs(t, err, &weak)
+	assert.Equal(t, "must contain a symbol", weak.Reason)
+}
+
+func TestAuthService_Register_DefaultPolicy(t *testing.T) {
+	svc, _ := newTestAuthService(t, "password123")
+
+	err := svc.Register(context.Background(), &model.RegisterRequest{Email: "new@example.com", Password: "short1"})
+
+	assert.ErrorIs(t, err, ErrWeakPassword)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fund-analyzer/internal/model"
+	"fund-analyzer/internal/repository"
+)
+
+// DefaultQuietHoursTimezone 未指定时区时使用北京时间
+const DefaultQuietHoursTimezone = "Asia/Shanghai"
+
+// quietHoursLayout 免打扰起止时间格式
+const quietHoursLayout = "15:04"
+
+// ErrInvalidQuietHours 免打扰时段配置无效
+var ErrInvalidQuietHours = errors.New("invalid quiet hours")
+
+// QuietHoursService 免打扰时段服务接口
+type QuietHoursService interface {
+	// Get 获取用户的免打扰时段，未设置时返回未启用的配置
+	Get(ctx context.Context, userID int64) (*model.QuietHours, error)
+	// Update 校验并保存用户的免打扰时段
+	Update(ctx context.Context, userID int64, req *model.UpdateQuietHoursRequest) (*model.QuietHours, error)
+}
+
+type quietHoursService struct {
+	repo repository.QuietHoursRepository
+}
+
+// NewQuietHoursService 创建免打扰时段服务
+func NewQuietHoursService(repo repository.QuietHoursRepository) QuietHoursService {
+	return &quietHoursService{repo: repo}
+}
+
+// Get 获取用户的免打扰时段
+func (s *quietHoursService) Get(ctx context.Context, userID int64) (*model.QuietHours, error) {
+	quietHours, err := s.repo.GetQuietHours(ctx, userID)
+	if errors.Is(err, repository.ErrQuietHoursNotFound) {
+		return &model.QuietHours{UserID: userID, Timezone: DefaultQuietHoursTimezone}, nil
+	}
+	return quietHours, err
+}
+
+// Update 校验并保存用户的免打扰时段
+func (s *quietHoursService) Update(ctx context.Context, userID int64, req *model.UpdateQuietHoursRequest) (*model.QuietHours, error) {
+	quietHours := &model.QuietHours{
+		UserID:   userID,
+		Enabled:  req.Enabled,
+		Start:    req.Start,
+		End:      req.End,
+		Timezone: req.Timezone,
+	}
+	if quietHours.Timezone == "" {
+		quietHours.Timezone = DefaultQuietHoursTimezone
+	}
+	if _, err := newQuietWindow(quietHours); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpsertQuietHours(ctx, quietHours); err != nil {
+		return nil, err
+	}
+	return quietHours, nil
+}
+
+// quietWindow 解析后的免打扰时段
+type quietWindow struct {
+	start, end time.Duration // 距当地零点的时长
+	loc        *time.Location
+}
+
+// newQuietWindow 解析免打扰时段，起止时间相同视为无效
+func newQuietWindow(q *model.QuietHours) (quietWindow, error) {
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil || q.Timezone == "" {
+		return quietWindow{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidQuietHours, q.Timezone)
+	}
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return quietWindow{}, err
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return quietWindow{}, err
+	}
+	if start == end {
+		return quietWindow{}, fmt.Errorf("%w: start and end must differ", ErrInvalidQuietHours)
+	}
+	return quietWindow{start: start, end: end, loc: loc}, nil
+}
+
+// parseClock 解析 HH:MM 为距零点的时长
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse(quietHoursLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time %q must be HH:MM", ErrInvalidQuietHours, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains 判断时刻是否处于免打扰时段内（含起点，不含终点）
+func (w quietWindow) contains(now time.Time) bool {
+	offset := sinceLocalMidnight(now.In(w.loc))
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end
+	}
+	// 跨午夜
+	return offset >= w.start || offset < w.end
+}
+
+// endAfter 获取 now 之后最近一次免打扰结束的时刻
+func (w quietWindow) endAfter(now time.Time) time.Time {
+	local := now.In(w.loc)
+	y, m, d := local.Date()
+	end := time.Date(y, m, d, 0, 0, 0, 0, w.loc).Add(w.end)
+	if !end.After(local) {
+		end = time.Date(y, m, d+1, 0, 0, 0, 0, w.loc).Add(w.end)
+	}
+	return end
+}
+
+// sinceLocalMidnight 当地时间距零点的时长
+func sinceLocalMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
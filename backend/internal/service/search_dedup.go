@@ -0,0 +1,154 @@
+package service
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fund-analyzer/internal/model"
+)
+
+// 近似重复判定阈值，宁可保留重复也不误删不同的报道
+const (
+	// titleSimilarityThreshold 标题字符二元组相似度达到该值即视为同一篇报道
+	titleSimilarityThreshold = 0.9
+	// pathHintSimilarityThreshold URL 末段相同时标题相似度的最低要求
+	pathHintSimilarityThreshold = 0.6
+	// minFuzzyTitleRunes 标题过短时只按完全相同判定
+	minFuzzyTitleRunes = 8
+	// minPathHintLen URL 末段过短（如 index、123）时不作为转载线索
+	minPathHintLen = 12
+	// maxSiteSuffixRunes 标题末尾站点名的最大长度，如 "_新浪财经"、" - 东方财富网"
+	maxSiteSuffixRunes = 12
+)
+
+// titleSiteSeparators 标题与站点名之间常见的分隔符
+var titleSiteSeparators = []string{" - ", " – ", " — ", " | ", "|", "_"}
+
+// searchResultKey 用于近似重复判定的搜索结果特征
+type searchResultKey struct {
+	url     string
+	title   string
+	bigrams map[string]struct{}
+	hint    string
+}
+
+// dedupeSearchResults 合并转载导致的近似重复搜索结果，保留每篇报道首次出现的结果
+// 返回去重后的结果与被合并的数量
+func dedupeSearchResults(results []model.SearchResult) ([]model.SearchResult, int) {
+	kept := make([]model.SearchResult, 0, len(results))
+	keys := make([]searchResultKey, 0, len(results))
+	for _, r := range results {
+		key := newSearchResultKey(r)
+		duplicate := false
+		for _, k := range keys {
+			if isNearDuplicate(key, k) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		kept = append(kept, r)
+		keys = append(keys, key)
+	}
+	return kept, len(results) - len(kept)
+}
+
+func newSearchResultKey(r model.SearchResult) searchResultKey {
+	key := searchResultKey{title: normalizeResultTitle(r.Title), hint: urlPathHint(r.URL)}
+	if normalized, ok := normalizeToolURL(r.URL); ok {
+		key.url = normalized
+	}
+	key.bigrams = runeBigrams(key.title)
+	return key
+}
+
+// isNearDuplicate 判断两条结果是否为同一篇报道
+func isNearDuplicate(a, b searchResultKey) bool {
+	if a.url != "" && a.url == b.url {
+		return true
+	}
+	if a.title == "" || b.title == "" {
+		return false
+	}
+	if a.title == b.title {
+		return true
+	}
+	if utf8.RuneCountInString(a.title) < minFuzzyTitleRunes || utf8.RuneCountInString(b.title) < minFuzzyTitleRunes {
+		return false
+	}
+
+	similarity := jaccard(a.bigrams, b.bigrams)
+	if similarity >= titleSimilarityThreshold {
+		return true
+	}
+	// 不同站点转载常保留原文的 URL 末段（如英文 slug 或稿件编号）
+	return a.hint != "" && a.hint == b.hint && similarity >= pathHintSimilarityThreshold
+}
+
+// normalizeResultTitle 去除站点名后缀、标点与空白并转为小写
+func normalizeResultTitle(title string) string {
+	title = strings.TrimSpace(title)
+	for _, sep := range titleSiteSeparators {
+		idx := strings.LastIndex(title, sep)
+		if idx <= 0 {
+			continue
+		}
+		suffix := title[idx+len(sep):]
+		if utf8.RuneCountInString(strings.TrimSpace(suffix)) <= maxSiteSuffixRunes &&
+			utf8.RuneCountInString(title[:idx]) > utf8.RuneCountInString(suffix) {
+			title = strings.TrimSpace(title[:idx])
+			break
+		}
+	}
+
+	var sb strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// urlPathHint 获取 URL 路径末段（去除扩展名），过短的末段返回空
+func urlPathHint(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	segment := path.Base(strings.TrimSuffix(u.Path, "/"))
+	segment = strings.ToLower(strings.TrimSuffix(segment, path.Ext(segment)))
+	if len(segment) < minPathHintLen {
+		return ""
+	}
+	return segment
+}
+
+// runeBigrams 计算字符二元组集合，适用于不分词的中文标题
+func runeBigrams(s string) map[string]struct{} {
+	runes := []rune(s)
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		set[string(runes[i:i+2])] = struct{}{}
+	}
+	return set
+}
+
+// jaccard 计算两个集合的 Jaccard 相似度
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeSearchResults_SyndicatedCopiesCollapse(t *testing.T) {
+	results := []model.SearchResult{
+		{Title: "央行宣布下调存款准备金率0.5个百分点_新浪财经", URL: "https://finance.sina.com.cn/roll/2024-01-24/doc-inaeqvkm.shtml"},
+		{Title: "央行宣布下调存款准备金率0.5个百分点 - 东方财富网", URL: "https://finance.eastmoney.com/a/202401242972.html"},
+		{Title: "央行宣布：下调存款准备金率0.5个百分点！", URL: "https://www.163.com/dy/article/IPQ8K2.html"},
+		{Title: "Fed holds rates steady, signals three cuts in 2024 | Reuters", URL: "https://www.reuters.com/markets/us/fed-holds-rates-steady-signals-three-cuts-2024-03-20/"},
+		{Title: "Fed holds interest rates steady, still signals three cuts", URL: "https://finance.yahoo.com/news/fed-holds-rates-steady-signals-three-cuts-2024-03-20.html"},
+		// 同一 URL 仅片段不同
+		{Title: "黄金价格再创新高", URL: "https://news.example.com/gold/1#top"},
+		{Title: "黄金价格再创新高（更新）", URL: "https://news.example.com/gold/1"},
+	}
+
+	deduped, merged := dedupeSearchResults(results)
+
+	assert.Equal(t, 4, merged)
+	assert.Equal(t, []model.SearchResult{results[0], results[3], results[5]}, deduped)
+}
+
+func TestDedupeSearchResults_DistinctStoriesKept(t *testing.T) {
+	results := []model.SearchResult{
+		{Title: "央行宣布下调存款准备金率0.5个百分点", URL: "https://a.example.com/news/1.html"},
+		{Title: "央行宣布下调支农支小再贷款利率0.25个百分点", URL: "https://b.example.com/news/2.html"},
+		{Title: "沪指收涨1.2%", URL: "https://a.example.com/market/3.html"},
+		{Title: "深成指收涨1.2%", URL: "https://a.example.com/market/4.html"},
+		// URL 末段相同但标题完全不同
+		{Title: "Gold hits record high on rate cut bets", URL: "https://x.example.com/markets/daily-market-wrap-2024-03-20"},
+		{Title: "Oil falls as US crude inventories rise", URL: "https://y.example.com/energy/daily-market-wrap-2024-03-20"},
+		{Title: "", URL: "https://c.example.com/empty-1"},
+		{Title: "", URL: "https://c.example.com/empty-2"},
+	}
+
+	deduped, merged := dedupeSearchResults(results)
+
+	assert.Equal(t, 0, merged)
+	assert.Equal(t, results, deduped)
+}
+
+func TestNormalizeResultTitle(t *testing.T) {
+	assert.Equal(t, "央行宣布降准", normalizeResultTitle("央行宣布降准_新浪财经"))
+	assert.Equal(t, "央行宣布降准", normalizeResultTitle("央行宣布：降准！ - 东方财富网"))
+	assert.Equal(t, "fedholdsratessteady", normalizeResultTitle("Fed holds rates steady | Reuters"))
+	// 分隔符后的内容过长时不视为站点名
+	assert.Equal(t, "a股三大指数集体收涨成交额突破一万亿元创年内新高",
+		normalizeResultTitle("A股三大指数集体收涨_成交额突破一万亿元创年内新高"))
+}
+
+func TestFormatSearchResults_ReportsMergedDuplicates(t *testing.T) {
+	results := []model.SearchResult{
+		{Title: "央行宣布下调存款准备金率0.5个百分点_新浪财经", URL: "https://finance.sina.com.cn/a.shtml", Snippet: "摘要一"},
+		{Title: "央行宣布下调存款准备金率0.5个百分点 - 东方财富网", URL: "https://finance.eastmoney.com/b.html", Snippet: "摘要二"},
+	}
+
+	out := formatSearchResults("降准", results)
+
+	assert.Contains(t, out, "1. 央行宣布下调存款准备金率0.5个百分点_新浪财经")
+	assert.NotContains(t, out, "2. ")
+	assert.NotContains(t, out, "摘要二")
+	assert.Contains(t, out, "已合并 1 条转载的重复结果")
+}
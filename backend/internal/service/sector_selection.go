@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+)
+
+// 分析使用的板块数量
+const (
+	// DefaultAnalysisSectors 未配置时提供给模型的板块数
+	DefaultAnalysisSectors = 20
+	// MaxAnalysisSectors 提供给模型的板块数上限，避免提示词过长
+	MaxAnalysisSectors = 50
+)
+
+// SectorSelector 从按涨跌幅排序的板块列表中选取提供给模型的板块
+// 配置每个大类的上限后，选取结果分散到多个大类，避免全部来自同一热门主题
+type SectorSelector struct {
+	count          int
+	maxPerCategory int
+}
+
+// NewSectorSelector 创建板块选取器
+func NewSectorSelector(cfg config.SectorSelectionConfig) *SectorSelector {
+	count := cfg.Count
+	if count <= 0 {
+		count = DefaultAnalysisSectors
+	}
+	if count > MaxAnalysisSectors {
+		count = MaxAnalysisSectors
+	}
+	return &SectorSelector{
+		count:          count,
+		maxPerCategory: max(cfg.MaxPerCategory, 0),
+	}
+}
+
+// Select 选取至多 limit 个板块，limit <= 0 或超过配置数量时使用配置数量
+// 结果保持原有排名顺序；选取器为 nil 时按默认数量截取前几个板块
+func (s *SectorSelector) Select(sectors []model.Sector, limit int) []model.Sector {
+	count, maxPerCategory := DefaultAnalysisSectors, 0
+	if s != nil {
+		count, maxPerCategory = s.count, s.maxPerCategory
+	}
+	if limit > 0 && limit < count {
+		count = limit
+	}
+	if len(sectors) <= count {
+		return sectors
+	}
+	if maxPerCategory == 0 {
+		return sectors[:count]
+	}
+
+	// 先按排名选取未超出大类上限的板块，不足时再按排名补足
+	selected := make([]bool, len(sectors))
+	perCategory := make(map[string]int)
+	picked := 0
+	for i, sector := range sectors {
+		if picked == count {
+			break
+		}
+		category := crawler.GetSectorCategory(sector.Name)
+		if perCategory[category] >= maxPerCategory {
+			continue
+		}
+		perCategory[category]++
+		selected[i] = true
+		picked++
+	}
+	for i := range sectors {
+		if picked == count {
+			break
+		}
+		if !selected[i] {
+			selected[i] = true
+			picked++
+		}
+	}
+
+	result := make([]model.Sector, 0, count)
+	for i, sector := range sectors {
+		if selected[i] {
+			result = append(result, sector)
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rankedSectors 按涨跌幅排序的板块，科技板块集中在前面
+func rankedSectors() []model.Sector {
+	names := []string{"半导体", "芯片", "软件开发", "游戏", "人工智能", "云计算", "白酒", "银行", "光伏", "化学制药", "证券", "煤炭"}
+	sectors := make([]model.Sector, len(names))
+	for i, name := range names {
+		sectors[i] = model.Sector{ID: name, Name: name}
+	}
+	return sectors
+}
+
+func sectorNames(sectors []model.Sector) []string {
+	names := make([]string, len(sectors))
+	for i, sector := range sectors {
+		names[i] = sector.Name
+	}
+	return names
+}
+
+func TestSectorSelector_DiversifiesAcrossCategories(t *testing.T) {
+	selector := NewSectorSelector(config.SectorSelectionConfig{Count: 6, MaxPerCategory: 2})
+
+	selected := selector.Select(rankedSectors(), 0)
+
+	assert.Equal(t, []string{"半导体", "芯片", "白酒", "银行", "光伏", "化学制药"}, sectorNames(selected))
+	categories := make(map[string]int)
+	for _, sector := range selected {
+		categories[crawler.GetSectorCategory(sector.Name)]++
+	}
+	assert.Len(t, categories, 5)
+	for category, n := range categories {
+		assert.LessOrEqual(t, n, 2, category)
+	}
+}
+
+func TestSectorSelector_FillsByRankWhenCategoriesRunOut(t *testing.T) {
+	selector := NewSectorSelector(config.SectorSelectionConfig{Count: 8, MaxPerCategory: 1})
+
+	// 只有 科技、消费、金融 三个大类，按排名补足其余名额
+	sectors := rankedSectors()[:8]
+	selected := selector.Select(sectors, 0)
+
+	assert.Equal(t, sectorNames(sectors), sectorNames(selected))
+
+	selected = selector.Select(rankedSectors()[:9], 5)
+	assert.Equal(t, []string{"半导体", "芯片", "白酒", "银行", "光伏"}, sectorNames(selected))
+}
+
+func TestSectorSelector_CountAndDefaults(t *testing.T) {
+	// 不分散时取前几个
+	selected := NewSectorSelector(config.SectorSelectionConfig{Count: 3}).Select(rankedSectors(), 0)
+	assert.Equal(t, []string{"半导体", "芯片", "软件开发"}, sectorNames(selected))
+
+	// limit 不能超过配置数量
+	selected = NewSectorSelector(config.SectorSelectionConfig{Count: 3}).Select(rankedSectors(), 10)
+	assert.Len(t, selected, 3)
+
+	// 未配置与 nil 选取器使用默认数量
+	many := make([]model.Sector, 30)
+	assert.Len(t, NewSectorSelector(config.SectorSelectionConfig{}).Select(many, 0), DefaultAnalysisSectors)
+	var nilSelector *SectorSelector
+	assert.Len(t, nilSelector.Select(many, 0), DefaultAnalysisSectors)
+	assert.Len(t, nilSelector.Select(many, 10), 10)
+
+	// 板块不足时原样返回
+	assert.Len(t, NewSectorSelector(config.SectorSelectionConfig{Count: 20, MaxPerCategory: 1}).Select(rankedSectors(), 0), 12)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -10,24 +11,37 @@ import (
 	"fund-analyzer/internal/model"
 )
 
+// SectorSortFields 板块列表支持的排序字段
+var SectorSortFields = []string{"changeRate", "mainNetInflow", "mainInflowRatio"}
+
 // SectorService 板块服务接口
 type SectorService interface {
 	GetSectorList(ctx context.Context) ([]model.Sector, error)
 	GetSectorFunds(ctx context.Context, sectorID string) ([]model.SectorFund, error)
 	GetSectorCategories() map[string][]string
 	SortSectors(sectors []model.Sector, field string, descending bool) []model.Sector
+	GetHeatmap(ctx context.Context) (*model.SectorHeatmap, error)
 }
 
 type sectorService struct {
-	eastMoneyCrawler *crawler.EastMoneyCrawler
-	cache            CacheService
+	sectorCrawler crawler.SectorDataCrawler
+	cache         CacheService
+	degradation   DegradationService // 非 nil 时板块列表缓存未命中经 stale-while-revalidate 获取
 }
 
 // NewSectorService 创建板块服务
-func NewSectorService(eastMoneyCrawler *crawler.EastMoneyCrawler, cache CacheService) SectorService {
+func NewSectorService(sectorCrawler crawler.SectorDataCrawler, cache CacheService) SectorService {
+	return NewSectorServiceWithDegradation(sectorCrawler, cache, nil)
+}
+
+// NewSectorServiceWithDegradation 创建板块服务
+// 板块列表缓存未命中时经 degradation 获取：数据源缓慢时先返回旧数据并在后台刷新
+// degradation 为 nil 时直接请求数据源
+func NewSectorServiceWithDegradation(sectorCrawler crawler.SectorDataCrawler, cache CacheService, degradation DegradationService) SectorService {
 	return &sectorService{
-		eastMoneyCrawler: eastMoneyCrawler,
-		cache:            cache,
+		sectorCrawler: sectorCrawler,
+		cache:         cache,
+		degradation:   degradation,
 	}
 }
 
@@ -40,8 +54,21 @@ func (s *sectorService) GetSectorList(ctx context.Context) ([]model.Sector, erro
 		return sectors, nil
 	}
 
-	// 从东方财富获取
-	sectors, err = s.eastMoneyCrawler.GetSectorList(ctx)
+	if s.degradation != nil {
+		err = staleWhileRevalidate(ctx, s.degradation, CacheKeySectorList, func(ctx context.Context) (interface{}, error) {
+			return s.fetchSectorList(ctx)
+		}, &sectors)
+		if err != nil {
+			return nil, err
+		}
+		return sectors, nil
+	}
+	return s.fetchSectorList(ctx)
+}
+
+// fetchSectorList 从东方财富获取板块列表并写入缓存
+func (s *sectorService) fetchSectorList(ctx context.Context) ([]model.Sector, error) {
+	sectors, err := s.sectorCrawler.GetSectorList(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +91,7 @@ func (s *sectorService) GetSectorFunds(ctx context.Context, sectorID string) ([]
 	}
 
 	// 从东方财富获取
-	funds, err = s.eastMoneyCrawler.GetSectorFunds(ctx, sectorID)
+	funds, err = s.sectorCrawler.GetSectorFunds(ctx, sectorID)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +107,86 @@ func (s *sectorService) GetSectorCategories() map[string][]string {
 	return crawler.GetSectorCategories()
 }
 
+// GetHeatmap 获取板块热力图
+func (s *sectorService) GetHeatmap(ctx context.Context) (*model.SectorHeatmap, error) {
+	sectors, err := s.GetSectorList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return BuildSectorHeatmap(sectors), nil
+}
+
+// BuildSectorHeatmap 按板块大类分组并计算平均涨跌幅与主力净流入合计
+// 无法归类的板块归入"其他"
+func BuildSectorHeatmap(sectors []model.Sector) *model.SectorHeatmap {
+	groups := make(map[string]*model.SectorHeatmapCategory)
+	var order []string
+
+	for _, sector := range sectors {
+		name := crawler.GetSectorCategory(sector.Name)
+		group, ok := groups[name]
+		if !ok {
+			group = &model.SectorHeatmapCategory{Name: name}
+			groups[name] = group
+			order = append(order, name)
+		}
+
+		change := parsePercentage(sector.ChangeRate)
+		group.ChangeRate += change
+		group.MainNetInflow += parseMoney(sector.MainNetInflow)
+		if change > 0 {
+			group.UpCount++
+		} else if change < 0 {
+			group.DownCount++
+		}
+		group.Sectors = append(group.Sectors, sector)
+	}
+
+	heatmap := &model.SectorHeatmap{
+		Categories: make([]model.SectorHeatmapCategory, 0, len(order)),
+	}
+	for _, name := range order {
+		group := groups[name]
+		group.ChangeRate = math.Round(group.ChangeRate/float64(len(group.Sectors))*100) / 100
+		sort.SliceStable(group.Sectors, func(i, j int) bool {
+			return parsePercentage(group.Sectors[i].ChangeRate) > parsePercentage(group.Sectors[j].ChangeRate)
+		})
+		heatmap.Categories = append(heatmap.Categories, *group)
+	}
+
+	// 按平均涨跌幅降序，相同时按名称排序
+	sort.SliceStable(heatmap.Categories, func(i, j int) bool {
+		ci, cj := heatmap.Categories[i], heatmap.Categories[j]
+		if ci.ChangeRate != cj.ChangeRate {
+			return ci.ChangeRate > cj.ChangeRate
+		}
+		return ci.Name < cj.Name
+	})
+
+	return heatmap
+}
+
+// IsSectorSortField 判断是否为板块列表支持的排序字段
+func IsSectorSortField(field string) bool {
+	for _, f := range SectorSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterSectorsByCategory 筛选属于 category 大类的板块，返回新切片
+func FilterSectorsByCategory(sectors []model.Sector, category string) []model.Sector {
+	result := make([]model.Sector, 0, len(sectors))
+	for _, sector := range sectors {
+		if crawler.GetSectorCategory(sector.Name) == category {
+			result = append(result, sector)
+		}
+	}
+	return result
+}
+
 // SortSectors 排序板块列表
 func (s *sectorService) SortSectors(sectors []model.Sector, field string, descending bool) []model.Sector {
 	result := make([]model.Sector, len(sectors))
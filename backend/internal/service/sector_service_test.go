@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findHeatmapCategory(heatmap *model.SectorHeatmap, name string) *model.SectorHeatmapCategory {
+	for i := range heatmap.Categories {
+		if heatmap.Categories[i].Name == name {
+			return &heatmap.Categories[i]
+		}
+	}
+	return nil
+}
+
+func TestBuildSectorHeatmap_Grouping(t *testing.T) {
+	sectors := []model.Sector{
+		{ID: "BK1", Name: "半导体", ChangeRate: "2.00%", MainNetInflow: "1.00亿"},
+		{ID: "BK2", Name: "白酒", ChangeRate: "-1.00%", MainNetInflow: "-5000.00万"},
+		{ID: "BK3", Name: "软件开发", ChangeRate: "4.00%", MainNetInflow: "3000.00万"},
+		{ID: "BK4", Name: "银行", ChangeRate: "0.00%", MainNetInflow: "0.00"},
+	}
+
+	heatmap := BuildSectorHeatmap(sectors)
+
+	require.Len(t, heatmap.Categories, 3)
+
+	tech := findHeatmapCategory(heatmap, "科技")
+	require.NotNil(t, tech)
+	require.Len(t, tech.Sectors, 2)
+	// 大类内按涨跌幅降序
+	assert.Equal(t, "软件开发", tech.Sectors[0].Name)
+	assert.Equal(t, "半导体", tech.Sectors[1].Name)
+
+	// 大类按平均涨跌幅降序
+	assert.Equal(t, "科技", heatmap.Categories[0].Name)
+	assert.Equal(t, "金融", heatmap.Categories[1].Name)
+	assert.Equal(t, "消费", heatmap.Categories[2].Name)
+}
+
+func TestBuildSectorHeatmap_Aggregation(t *testing.T) {
+	sectors := []model.Sector{
+		{Name: "半导体", ChangeRate: "2.00%", MainNetInflow: "1.00亿"},
+		{Name: "芯片", ChangeRate: "-1.00%", MainNetInflow: "-2000.00万"},
+		{Name: "游戏", ChangeRate: "0.50%", MainNetInflow: "500.00万"},
+	}
+
+	heatmap := BuildSectorHeatmap(sectors)
+
+	require.Len(t, heatmap.Categories, 1)
+	tech := heatmap.Categories[0]
+	assert.Equal(t, "科技", tech.Name)
+	assert.InDelta(t, 0.5, tech.ChangeRate, 0.001)
+	assert.InDelta(t, 85000000.0, tech.MainNetInflow, 0.001)
+	assert.Equal(t, 2, tech.UpCount)
+	assert.Equal(t, 1, tech.DownCount)
+}
+
+func TestBuildSectorHeatmap_UnmappedCategory(t *testing.T) {
+	sectors := []model.Sector{
+		{Name: "未知新板块", ChangeRate: "1.00%", MainNetInflow: "100.00万"},
+		{Name: "物流", ChangeRate: "3.00%", MainNetInflow: "200.00万"},
+	}
+
+	heatmap := BuildSectorHeatmap(sectors)
+
+	require.Len(t, heatmap.Categories, 1)
+	other := findHeatmapCategory(heatmap, "其他")
+	require.NotNil(t, other)
+	assert.Len(t, other.Sectors, 2)
+	assert.InDelta(t, 2.0, other.ChangeRate, 0.001)
+	assert.InDelta(t, 3000000.0, other.MainNetInflow, 0.001)
+}
+
+func TestBuildSectorHeatmap_Empty(t *testing.T) {
+	heatmap := BuildSectorHeatmap(nil)
+
+	require.NotNil(t, heatmap)
+	assert.Empty(t, heatmap.Categories)
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+)
+
+// DefaultSelfTestTimeout 单个子系统自检的默认超时
+const DefaultSelfTestTimeout = 5 * time.Second
+
+// selfTestCacheTTL 自检写入缓存的过期时间，删除失败时也会很快过期
+const selfTestCacheTTL = time.Minute
+
+var (
+	// ErrSelfTestInProgress 已有自检在执行
+	ErrSelfTestInProgress = errors.New("self-test already in progress")
+	// ErrSelfTestSkipped 子系统未启用或被配置跳过
+	ErrSelfTestSkipped = errors.New("self-test skipped")
+)
+
+// SelfTestStatus 单个子系统的自检结果
+type SelfTestStatus string
+
+const (
+	SelfTestStatusPass    SelfTestStatus = "pass"
+	SelfTestStatusFail    SelfTestStatus = "fail"
+	SelfTestStatusSkipped SelfTestStatus = "skipped"
+)
+
+// SelfTestCheck 可自检的子系统
+// Run 返回 ErrSelfTestSkipped 时记为跳过，其他错误记为失败
+type SelfTestCheck struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// SelfTestResult 单个子系统的自检结果
+type SelfTestResult struct {
+	Name       string         `json:"name"`
+	Status     SelfTestStatus `json:"status"`
+	DurationMs int64          `json:"duration_ms"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// SelfTestReport 一次自检的结果，任一子系统失败时 Passed 为 false
+type SelfTestReport struct {
+	Passed     bool             `json:"passed"`
+	Results    []SelfTestResult `json:"results"`
+	DurationMs int64            `json:"duration_ms"`
+}
+
+// SelfTestService 部署自检服务接口
+type SelfTestService interface {
+	// Run 并发执行所有子系统自检，同一时间只允许一次自检
+	Run(ctx context.Context) (*SelfTestReport, error)
+}
+
+type selfTestService struct {
+	checks  []SelfTestCheck
+	skip    map[string]bool
+	timeout time.Duration
+
+	running sync.Mutex
+}
+
+// NewSelfTestService 创建自检服务
+// 每个子系统单独计时，超过 timeout 即记为失败，不影响其他子系统
+func NewSelfTestService(checks []SelfTestCheck, cfg config.SelfTestConfig) SelfTestService {
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = DefaultSelfTestTimeout
+	}
+	skip := make(map[string]bool, len(cfg.Skip))
+	for _, name := range cfg.Skip {
+		skip[name] = true
+	}
+	return &selfTestService{
+		checks:  checks,
+		skip:    skip,
+		timeout: timeout,
+	}
+}
+
+// SelfTestDeps 默认自检项依赖的子系统，为 nil 的子系统记为跳过
+type SelfTestDeps struct {
+	// DBProbe 数据库读写检查，不得修改业务数据
+	DBProbe       func(ctx context.Context) error
+	Cache         CacheService
+	MarketCrawler crawler.MarketDataCrawler
+	FundCrawler   crawler.FundDataCrawler
+	SectorCrawler crawler.SectorDataCrawler
+	GoldCrawler   crawler.GoldDataCrawler
+	AI            AIService
+	Email         config.EmailConfig
+}
+
+// DefaultSelfTestChecks 默认自检项：数据库读写、缓存读写、各数据源（经熔断器）、大模型、邮件
+// 数据源检查项名称与 main 中注册的熔断器名称一致
+func DefaultSelfTestChecks(deps SelfTestDeps) []SelfTestCheck {
+	return []SelfTestCheck{
+		{Name: "database", Run: func(ctx context.Context) error {
+			if deps.DBProbe == nil {
+				return ErrSelfTestSkipped
+			}
+			return deps.DBProbe(ctx)
+		}},
+		{Name: "cache", Run: func(ctx context.Context) error {
+			if deps.Cache == nil {
+				return ErrSelfTestSkipped
+			}
+			return probeCache(ctx, deps.Cache)
+		}},
+		{Name: "crawler:baidu", Run: func(ctx context.Context) error {
+			if deps.MarketCrawler == nil {
+				return ErrSelfTestSkipped
+			}
+			_, err := deps.MarketCrawler.GetMarketIndices(ctx, globalIndexRegions[0].name)
+			return err
+		}},
+		{Name: "crawler:ant", Run: func(ctx context.Context) error {
+			if deps.FundCrawler == nil {
+				return ErrSelfTestSkipped
+			}
+			_, err := deps.FundCrawler.SearchFund(ctx, "000001")
+			return err
+		}},
+		{Name: "crawler:eastmoney", Run: func(ctx context.Context) error {
+			if deps.SectorCrawler == nil {
+				return ErrSelfTestSkipped
+			}
+			_, err := deps.SectorCrawler.GetSectorList(ctx)
+			return err
+		}},
+		{Name: "crawler:gold", Run: func(ctx context.Context) error {
+			if deps.GoldCrawler == nil {
+				return ErrSelfTestSkipped
+			}
+			_, err := deps.GoldCrawler.GetRealTimeGold(ctx)
+			return err
+		}},
+		{Name: "llm", Run: func(ctx context.Context) error {
+			if deps.AI == nil {
+				return ErrSelfTestSkipped
+			}
+			return deps.AI.Ping(ctx)
+		}},
+		{Name: "email", Run: func(ctx context.Context) error {
+			return probeEmail(ctx, deps.Email)
+		}},
+	}
+}
+
+// probeCache 写入、读回并删除一个临时键
+func probeCache(ctx context.Context, cache CacheService) error {
+	token := strconv.FormatInt(time.Now().UnixNano(), 36)
+	key := "selftest:" + token
+	if err := cache.Set(ctx, key, []byte(token), selfTestCacheTTL); err != nil {
+		return fmt.Errorf("set: %w", err)
+	}
+	defer cache.Delete(context.WithoutCancel(ctx), key)
+
+	value, err := cache.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	if string(value) != token {
+		return fmt.Errorf("read back %q, want %q", value, token)
+	}
+	return nil
+}
+
+// probeEmail 邮件演练：校验配置，SMTP 模式下检查服务器可连接，不发送邮件
+func probeEmail(ctx context.Context, cfg config.EmailConfig) error {
+	if err := ValidateEmailConfig(cfg); err != nil {
+		return err
+	}
+	if cfg.Type == "api" {
+		return nil
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)))
+	if err != nil {
+		return fmt.Errorf("dial smtp: %w", err)
+	}
+	return conn.Close()
+}
+
+// Run 并发执行所有自检项，结果按自检项顺序返回
+func (s *selfTestService) Run(ctx context.Context) (*SelfTestReport, error) {
+	if !s.running.TryLock() {
+		return nil, ErrSelfTestInProgress
+	}
+	defer s.running.Unlock()
+
+	start := time.Now()
+	report := &SelfTestReport{Passed: true, Results: make([]SelfTestResult, len(s.checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check SelfTestCheck) {
+			defer wg.Done()
+			report.Results[i] = s.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Results {
+		if result.Status == SelfTestStatusFail {
+			report.Passed = false
+		}
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// run 执行单个自检项
+// 自检项未响应 ctx 取消时同样在超时后返回，panic 记为失败
+func (s *selfTestService) run(ctx context.Context, check SelfTestCheck) SelfTestResult {
+	result := SelfTestResult{Name: check.Name}
+	if s.skip[check.Name] {
+		result.Status = SelfTestStatusSkipped
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	switch {
+	case err == nil:
+		result.Status = SelfTestStatusPass
+	case errors.Is(err, ErrSelfTestSkipped):
+		result.Status = SelfTestStatusSkipped
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Status = SelfTestStatusFail
+		result.Error = fmt.Sprintf("timed out after %s", s.timeout)
+	default:
+		result.Status = SelfTestStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
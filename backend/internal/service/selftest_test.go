@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pingAIService 只实现 Ping 的 AI 服务
+type pingAIService struct {
+	AIService
+	err error
+}
+
+func (s *pingAIService) Ping(ctx context.Context) error {
+	return s.err
+}
+
+func selfTestResults(report *SelfTestReport) map[string]SelfTestResult {
+	results := make(map[string]SelfTestResult, len(report.Results))
+	for _, r := range report.Results {
+		results[r.Name] = r
+	}
+	return results
+}
+
+func TestSelfTest_FailingCheckDoesNotAffectOthers(t *testing.T) {
+	svc := NewSelfTestService([]SelfTestCheck{
+		{Name: "database", Run: func(ctx context.Context) error { return nil }},
+		{Name: "cache", Run: func(ctx context.Context) error { return errors.New("connection refused") }},
+		{Name: "llm", Run: func(ctx context.Context) error { return ErrSelfTestSkipped }},
+		{Name: "crawler:gold", Run: func(ctx context.Context) error { panic("nil pointer") }},
+		{Name: "email", Run: func(ctx context.Context) error { return nil }},
+	}, config.SelfTestConfig{Skip: []string{"email"}})
+
+	report, err := svc.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, report.Passed)
+	var names []string
+	for _, r := range report.Results {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"database", "cache", "llm", "crawler:gold", "email"}, names)
+
+	results := selfTestResults(report)
+	assert.Equal(t, SelfTestStatusPass, results["database"].Status)
+	assert.Empty(t, results["database"].Error)
+	assert.Equal(t, SelfTestStatusFail, results["cache"].Status)
+	assert.Equal(t, "connection refused", results["cache"].Error)
+	assert.Equal(t, SelfTestStatusSkipped, results["llm"].Status)
+	assert.Equal(t, SelfTestStatusFail, results["crawler:gold"].Status)
+	assert.Contains(t, results["crawler:gold"].Error, "panic")
+	assert.Equal(t, SelfTestStatusSkipped, results["email"].Status)
+}
+
+func TestSelfTest_TimesOutPerCheck(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	svc := NewSelfTestService([]SelfTestCheck{
+		// 不响应 ctx 取消的自检项
+		{Name: "llm", Run: func(ctx context.Context) error { <-release; return nil }},
+		{Name: "cache", Run: func(ctx context.Context) error { return nil }},
+	}, config.SelfTestConfig{TimeoutMs: 50})
+
+	start := time.Now()
+	report, err := svc.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, report.Passed)
+	results := selfTestResults(report)
+	assert.Equal(t, SelfTestStatusFail, results["llm"].Status)
+	assert.Contains(t, results["llm"].Error, "timed out")
+	assert.Equal(t, SelfTestStatusPass, results["cache"].Status)
+}
+
+func TestSelfTest_RejectsConcurrentRuns(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	svc := NewSelfTestService([]SelfTestCheck{
+		{Name: "database", Run: func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		}},
+	}, config.SelfTestConfig{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		report, err := svc.Run(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, report.Passed)
+	}()
+	<-started
+
+	_, err := svc.Run(context.Background())
+	assert.ErrorIs(t, err, ErrSelfTestInProgress)
+
+	close(release)
+	<-done
+}
+
+func TestDefaultSelfTestChecks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	market := newMockMarketCrawler()
+	funds := &mockFundCrawler{funds: map[string]*model.FundInfo{"000001": {Code: "000001"}}}
+	gold := &mockGoldCrawler{err: crawler.ErrCircuitOpen}
+	svc := NewSelfTestService(DefaultSelfTestChecks(SelfTestDeps{
+		DBProbe:       func(ctx context.Context) error { return nil },
+		Cache:         NewMemoryCache(),
+		MarketCrawler: market,
+		FundCrawler:   funds,
+		SectorCrawler: &mockSectorCrawler{sectors: []model.Sector{{Name: "半导体"}}},
+		GoldCrawler:   gold,
+		AI:            &pingAIService{},
+		Email: config.EmailConfig{
+			Type:         "smtp",
+			SMTPHost:     addr.IP.String(),
+			SMTPPort:     addr.Port,
+			SMTPUsername: "noreply@example.com",
+			SMTPPassword: "secret",
+		},
+	}), config.SelfTestConfig{})
+
+	report, err := svc.Run(context.Background())
+	require.NoError(t, err)
+
+	results := selfTestResults(report)
+	for _, name := range []string{"database", "cache", "crawler:baidu", "crawler:ant", "crawler:eastmoney", "llm", "email"} {
+		assert.Equal(t, SelfTestStatusPass, results[name].Status, name)
+	}
+	// 熔断器打开的数据源记为失败
+	assert.Equal(t, SelfTestStatusFail, results["crawler:gold"].Status)
+	assert.Equal(t, crawler.ErrCircuitOpen.Error(), results["crawler:gold"].Error)
+	assert.False(t, report.Passed)
+
+	assert.Equal(t, 1, market.count("GetMarketIndices:asia"))
+	assert.Equal(t, 1, funds.count("SearchFund:000001"))
+}
+
+func TestDefaultSelfTestChecks_MissingSubsystems(t *testing.T) {
+	svc := NewSelfTestService(DefaultSelfTestChecks(SelfTestDeps{
+		Email: config.EmailConfig{Type: "smtp", SMTPPort: 465},
+	}), config.SelfTestConfig{})
+
+	report, err := svc.Run(context.Background())
+	require.NoError(t, err)
+
+	results := selfTestResults(report)
+	assert.Equal(t, SelfTestStatusSkipped, results["database"].Status)
+	assert.Equal(t, SelfTestStatusSkipped, results["llm"].Status)
+	assert.Equal(t, SelfTestStatusFail, results["email"].Status)
+	assert.Contains(t, results["email"].Error, ErrEmailMisconfigured.Error())
+	assert.False(t, report.Passed)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultShutdownHookTimeout 单个关闭钩子的默认超时
+const DefaultShutdownHookTimeout = 5 * time.Second
+
+// ErrShutdownHookTimeout 关闭钩子未在超时内完成
+var ErrShutdownHookTimeout = errors.New("shutdown hook timed out")
+
+// ShutdownHook 关闭钩子，应在 ctx 取消后尽快返回
+type ShutdownHook func(ctx context.Context) error
+
+// namedShutdownHook 带名称的关闭钩子，名称用于日志
+type namedShutdownHook struct {
+	name string
+	hook ShutdownHook
+}
+
+// ShutdownRegistry 关闭钩子注册表
+// 组件在初始化后注册关闭钩子，关闭时按注册的逆序执行，与 defer 的顺序一致
+type ShutdownRegistry struct {
+	hookTimeout time.Duration
+	logger      *zap.Logger
+
+	mu    sync.Mutex
+	hooks []namedShutdownHook
+	done  bool
+}
+
+// NewShutdownRegistry 创建关闭钩子注册表，hookTimeout <= 0 时使用 DefaultShutdownHookTimeout
+func NewShutdownRegistry(hookTimeout time.Duration, logger *zap.Logger) *ShutdownRegistry {
+	if hookTimeout <= 0 {
+		hookTimeout = DefaultShutdownHookTimeout
+	}
+	return &ShutdownRegistry{
+		hookTimeout: hookTimeout,
+		logger:      logger,
+	}
+}
+
+// Register 注册关闭钩子
+func (r *ShutdownRegistry) Register(name string, hook ShutdownHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, namedShutdownHook{name: name, hook: hook})
+}
+
+// RegisterFunc 注册不接收 context 的关闭函数，如限流器的 Stop
+func (r *ShutdownRegistry) RegisterFunc(name string, fn func()) {
+	r.Register(name, func(ctx context.Context) error {
+		fn()
+		return nil
+	})
+}
+
+// RegisterCloser 注册返回错误的关闭函数，如数据库连接的 Close
+func (r *ShutdownRegistry) RegisterCloser(name string, fn func() error) {
+	r.Register(name, func(ctx context.Context) error {
+		return fn()
+	})
+}
+
+// Shutdown 按注册的逆序执行所有关闭钩子，只执行一次
+// 每个钩子最多执行 hookTimeout，超时或 ctx 取消时不再等待该钩子，继续执行后续钩子
+// 返回所有失败钩子的错误
+func (r *ShutdownRegistry) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if r.done {
+		r.mu.Unlock()
+		return nil
+	}
+	r.done = true
+	hooks := r.hooks
+	r.hooks = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		start := time.Now()
+		if err := r.runHook(ctx, h.hook); err != nil {
+			r.logger.Warn("Shutdown hook failed",
+				zap.String("hook", h.name),
+				zap.Duration("elapsed", time.Since(start)),
+				zap.Error(err),
+			)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		r.logger.Debug("Shutdown hook completed", zap.String("hook", h.name), zap.Duration("elapsed", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
+
+// runHook 执行单个钩子，钩子不响应 ctx 时超时后直接返回，钩子在后台继续执行
+func (r *ShutdownRegistry) runHook(ctx context.Context, hook ShutdownHook) error {
+	hookCtx, cancel := context.WithTimeout(ctx, r.hookTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(hookCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-hookCtx.Done():
+		return fmt.Errorf("%w: %v", ErrShutdownHookTimeout, hookCtx.Err())
+	}
+}
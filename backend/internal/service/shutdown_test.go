@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestShutdownRegistry_RunsHooksInReverseOrder(t *testing.T) {
+	registry := NewShutdownRegistry(time.Second, zap.NewNop())
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+		}
+	}
+	registry.RegisterFunc("db", record("db"))
+	registry.RegisterCloser("cache", func() error { record("cache")(); return nil })
+	registry.Register("limiter", func(ctx context.Context) error { record("limiter")(); return nil })
+
+	require.NoError(t, registry.Shutdown(context.Background()))
+	assert.Equal(t, []string{"limiter", "cache", "db"}, order)
+
+	// 重复关闭不再执行钩子
+	require.NoError(t, registry.Shutdown(context.Background()))
+	assert.Len(t, order, 3)
+}
+
+func TestShutdownRegistry_HungHookDoesNotBlockShutdown(t *testing.T) {
+	registry := NewShutdownRegistry(20*time.Millisecond, zap.NewNop())
+
+	var closed bool
+	registry.RegisterFunc("db", func() { closed = true })
+	// 不响应 ctx 的钩子
+	block := make(chan struct{})
+	defer close(block)
+	registry.RegisterFunc("stuck", func() { <-block })
+
+	start := time.Now()
+	err := registry.Shutdown(context.Background())
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, ErrShutdownHookTimeout)
+	assert.Contains(t, err.Error(), "stuck")
+	// 超时的钩子之后的钩子仍然执行
+	assert.True(t, closed)
+}
+
+func TestShutdownRegistry_CollectsErrors(t *testing.T) {
+	registry := NewShutdownRegistry(time.Second, zap.NewNop())
+	errFlush := errors.New("flush failed")
+	registry.RegisterCloser("ok", func() error { return nil })
+	registry.RegisterCloser("snapshot", func() error { return errFlush })
+
+	err := registry.Shutdown(context.Background())
+	assert.ErrorIs(t, err, errFlush)
+	assert.Contains(t, err.Error(), "snapshot")
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+)
+
+// CacheKeyMarketSnapshot 市场快照缓存 Key，%d = 用户 ID，%s = 日期
+const CacheKeyMarketSnapshot = "snapshot:market:%d:%s"
+
+// TTLMarketSnapshot 快照保留时长，覆盖最长的对比区间
+const TTLMarketSnapshot = 35 * 24 * time.Hour
+
+// 时段对比的基准天数
+const (
+	DefaultCompareDays = 7  // 默认周环比
+	MaxCompareDays     = 30 // 不超过快照保留时长
+)
+
+// snapshotDateLayout 快照日期格式，按北京时间（交易日所在时区）划分日期
+const snapshotDateLayout = "2006-01-02"
+
+// snapshotDate 返回 t 在北京时间的快照日期
+func snapshotDate(t time.Time) string {
+	return t.In(crawler.ShanghaiLocation).Format(snapshotDateLayout)
+}
+
+// ErrSnapshotNotFound 指定日期及之前的保留期内都没有快照
+var ErrSnapshotNotFound = errors.New("market snapshot not found")
+
+// SnapshotService 市场数据快照服务接口
+// 每次分析时按天保存一份快照，供时段对比分析读取历史数据
+type SnapshotService interface {
+	// Save 保存当天快照，同一天多次保存以最后一次为准
+	Save(ctx context.Context, userID int64, data *model.MarketData) (*model.MarketSnapshot, error)
+	// Get 获取指定日期当天或之前最近一天的快照
+	Get(ctx context.Context, userID int64, date time.Time) (*model.MarketSnapshot, error)
+}
+
+type snapshotService struct {
+	cache CacheService
+	now   func() time.Time
+}
+
+// NewSnapshotService 创建市场快照服务
+func NewSnapshotService(cache CacheService) SnapshotService {
+	return &snapshotService{
+		cache: cache,
+		now:   time.Now,
+	}
+}
+
+// Save 保存当天快照
+func (s *snapshotService) Save(ctx context.Context, userID int64, data *model.MarketData) (*model.MarketSnapshot, error) {
+	now := s.now()
+	snapshot := &model.MarketSnapshot{
+		Date:       snapshotDate(now),
+		CapturedAt: now,
+		Data:       data,
+	}
+
+	key := fmt.Sprintf(CacheKeyMarketSnapshot, userID, snapshot.Date)
+	if err := s.cache.SetJSON(ctx, key, snapshot, TTLMarketSnapshot); err != nil {
+		return nil, fmt.Errorf("save market snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Get 获取指定日期当天或之前最近一天的快照
+// 当天未分析（如周末、节假日）时逐日向前查找，直到超出快照保留时长
+func (s *snapshotService) Get(ctx context.Context, userID int64, date time.Time) (*model.MarketSnapshot, error) {
+	oldest := snapshotDate(s.now().Add(-TTLMarketSnapshot))
+	for day := date; snapshotDate(day) >= oldest; day = day.AddDate(0, 0, -1) {
+		snapshot, err := s.getDay(ctx, userID, snapshotDate(day))
+		if errors.Is(err, ErrSnapshotNotFound) {
+			continue
+		}
+		return snapshot, err
+	}
+	return nil, ErrSnapshotNotFound
+}
+
+// getDay 获取指定日期当天的快照
+func (s *snapshotService) getDay(ctx context.Context, userID int64, date string) (*model.MarketSnapshot, error) {
+	key := fmt.Sprintf(CacheKeyMarketSnapshot, userID, date)
+
+	var snapshot model.MarketSnapshot
+	if err := s.cache.GetJSON(ctx, key, &snapshot); err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+	if snapshot.Data == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// staleKeySuffix 旧数据副本的缓存键后缀，副本在正常缓存过期后仍保留，供数据源缓慢时返回
+	staleKeySuffix = ":stale"
+	// TTLStale 旧数据副本的保留时间，实际可返回的数据年龄另受 degradation.max_staleness 限制
+	TTLStale = 6 * time.Hour
+)
+
+// staleWhileRevalidate 正常缓存未命中时经降级服务获取数据，结果解码到 dest
+// 数据源在快速获取超时内未响应且存在旧数据副本时，立即返回副本并在后台刷新；
+// fetch 负责写入正常缓存，副本由降级服务在获取成功后更新
+// 后台刷新可能在请求结束后才执行，fetch 收到的 context 不随请求取消
+func staleWhileRevalidate(ctx context.Context, degradation DegradationService, cacheKey string, fetch func(ctx context.Context) (interface{}, error), dest interface{}) error {
+	fetchCtx := context.WithoutCancel(ctx)
+	data, _, err := degradation.AsyncRefresh(ctx, func() (interface{}, error) {
+		return fetch(fetchCtx)
+	}, cacheKey+staleKeySuffix, TTLStale)
+	if err != nil {
+		return err
+	}
+
+	// 旧数据副本解码为通用类型，统一经 JSON 转换为目标类型
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dest)
+}
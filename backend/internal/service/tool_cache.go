@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+)
+
+// AI 工具结果缓存键，%s 为规范化参数的 SHA-256
+const (
+	CacheKeyToolSearch  = "ai:search:%s"
+	CacheKeyToolWebpage = "ai:webpage:%s"
+)
+
+// 工具结果缓存的默认配置
+const (
+	DefaultToolSearchTTL     = 5 * time.Minute
+	DefaultToolWebpageTTL    = 10 * time.Minute
+	DefaultToolMaxEntryBytes = 256 * 1024
+)
+
+// toolCacheOptions 工具结果缓存参数
+type toolCacheOptions struct {
+	searchTTL     time.Duration
+	webpageTTL    time.Duration
+	maxEntryBytes int
+}
+
+// newToolCacheOptions 根据配置生成缓存参数，未配置的项使用默认值
+func newToolCacheOptions(cfg config.ToolCacheConfig) toolCacheOptions {
+	opts := toolCacheOptions{
+		searchTTL:     DefaultToolSearchTTL,
+		webpageTTL:    DefaultToolWebpageTTL,
+		maxEntryBytes: DefaultToolMaxEntryBytes,
+	}
+	if cfg.SearchTTL > 0 {
+		opts.searchTTL = time.Duration(cfg.SearchTTL) * time.Second
+	}
+	if cfg.WebpageTTL > 0 {
+		opts.webpageTTL = time.Duration(cfg.WebpageTTL) * time.Second
+	}
+	if cfg.MaxEntryBytes > 0 {
+		opts.maxEntryBytes = cfg.MaxEntryBytes
+	}
+	return opts
+}
+
+// cachedWebpageFetcher 按规范化 URL 缓存网页正文
+// 只缓存成功结果；未命中时交给下层获取器，SSRF 校验与熔断照常生效
+type cachedWebpageFetcher struct {
+	next  crawler.WebpageFetcher
+	cache CacheService
+	opts  toolCacheOptions
+}
+
+// NewCachedWebpageFetcher 创建带缓存的网页内容获取器
+func NewCachedWebpageFetcher(next crawler.WebpageFetcher, cache CacheService, cfg config.ToolCacheConfig) crawler.WebpageFetcher {
+	return &cachedWebpageFetcher{next: next, cache: cache, opts: newToolCacheOptions(cfg)}
+}
+
+// Fetch 获取网页内容，优先使用缓存
+func (f *cachedWebpageFetcher) Fetch(ctx context.Context, rawURL string) (string, error) {
+	normalized, ok := normalizeToolURL(rawURL)
+	if !ok {
+		// 无法解析的 URL 不缓存，由下层获取器返回错误
+		return f.next.Fetch(ctx, rawURL)
+	}
+	cacheKey := fmt.Sprintf(CacheKeyToolWebpage, hashToolKey(normalized))
+
+	if data, err := f.cache.Get(ctx, cacheKey); err == nil {
+		return string(data), nil
+	}
+
+	content, err := f.next.Fetch(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+	if len(content) <= f.opts.maxEntryBytes {
+		_ = f.cache.Set(ctx, cacheKey, []byte(content), f.opts.webpageTTL)
+	}
+	return content, nil
+}
+
+// cachedSearchCrawler 按规范化查询词与结果数缓存搜索结果
+type cachedSearchCrawler struct {
+	next  crawler.DuckDuckGoCrawler
+	cache CacheService
+	opts  toolCacheOptions
+}
+
+// NewCachedSearchCrawler 创建带缓存的搜索爬虫
+func NewCachedSearchCrawler(next crawler.DuckDuckGoCrawler, cache CacheService, cfg config.ToolCacheConfig) crawler.DuckDuckGoCrawler {
+	return &cachedSearchCrawler{next: next, cache: cache, opts: newToolCacheOptions(cfg)}
+}
+
+// Search 搜索，优先使用缓存
+func (c *cachedSearchCrawler) Search(ctx context.Context, query string, count int) ([]model.SearchResult, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	cacheKey := fmt.Sprintf(CacheKeyToolSearch, hashToolKey(fmt.Sprintf("%d\n%s", count, normalized)))
+
+	var results []model.SearchResult
+	if err := c.cache.GetJSON(ctx, cacheKey, &results); err == nil {
+		return results, nil
+	}
+
+	results, err := c.next.Search(ctx, query, count)
+	if err != nil {
+		return nil, err
+	}
+	// 空结果可能是上游临时异常，不缓存
+	if len(results) > 0 {
+		if data, err := json.Marshal(results); err == nil && len(data) <= c.opts.maxEntryBytes {
+			_ = c.cache.Set(ctx, cacheKey, data, c.opts.searchTTL)
+		}
+	}
+	return results, nil
+}
+
+// normalizeToolURL 规范化 URL：协议与主机名小写、去除片段与默认端口
+// 仅用于生成缓存键，请求仍使用原始 URL
+func normalizeToolURL(rawURL string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String(), true
+}
+
+// hashToolKey 计算缓存键的 SHA-256，避免长 URL 与查询词直接进入缓存键
+func hashToolKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
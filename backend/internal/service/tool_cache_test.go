@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/internal/crawler"
+	"fund-analyzer/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingFetcher 记录上游获取次数的网页获取器
+type countingFetcher struct {
+	crawlerCalls
+	content string
+	err     error
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	f.record("total")
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.content, nil
+}
+
+// countingSearchCrawler 记录上游搜索次数的搜索爬虫
+type countingSearchCrawler struct {
+	crawlerCalls
+	results []model.SearchResult
+}
+
+func (c *countingSearchCrawler) Search(ctx context.Context, query string, count int) ([]model.SearchResult, error) {
+	c.record("Search")
+	return c.results, nil
+}
+
+func TestCachedWebpageFetcher_RepeatedURLServedFromCache(t *testing.T) {
+	ctx := context.Background()
+	next := &countingFetcher{content: "正文内容"}
+	fetcher := NewCachedWebpageFetcher(next, NewMemoryCache(), config.ToolCacheConfig{})
+
+	// 仅大小写、默认端口与片段不同的 URL 视为同一网页
+	for _, u := range []string{
+		"https://example.com/news/1",
+		"HTTPS://Example.COM/news/1#comments",
+		"https://example.com:443/news/1",
+	} {
+		content, err := fetcher.Fetch(ctx, u)
+		require.NoError(t, err)
+		assert.Equal(t, "正文内容", content)
+	}
+	assert.Equal(t, 1, next.count("total"))
+
+	// 不同路径或查询参数重新获取
+	_, err := fetcher.Fetch(ctx, "https://example.com/news/1?page=2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.count("total"))
+}
+
+func TestCachedWebpageFetcher_FailuresAndOversizedContentNotCached(t *testing.T) {
+	ctx := context.Background()
+
+	failing := &countingFetcher{err: errors.New("timeout")}
+	fetcher := NewCachedWebpageFetcher(failing, NewMemoryCache(), config.ToolCacheConfig{})
+	for i := 0; i < 2; i++ {
+		_, err := fetcher.Fetch(ctx, "https://example.com/a")
+		require.Error(t, err)
+	}
+	assert.Equal(t, 2, failing.count("total"))
+
+	large := &countingFetcher{content: strings.Repeat("x", 100)}
+	fetcher = NewCachedWebpageFetcher(large, NewMemoryCache(), config.ToolCacheConfig{MaxEntryBytes: 50})
+	for i := 0; i < 2; i++ {
+		_, err := fetcher.Fetch(ctx, "https://example.com/large")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, large.count("total"))
+}
+
+func TestCachedWebpageFetcher_AllowListEnforcedOnMiss(t *testing.T) {
+	ctx := context.Background()
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte("<html><body><p>internal secret</p></body></html>"))
+	}))
+	defer server.Close()
+
+	clientConfig := crawler.DefaultHTTPClientConfig()
+	clientConfig.URLValidator = crawler.ValidatePublicURL
+	breaker := crawler.NewCircuitBreaker(crawler.CircuitBreakerConfig{MaxFailures: 10, Timeout: time.Minute, HalfOpenMaxReqs: 1})
+	next := crawler.NewWebpageFetcher(crawler.NewHTTPClient(clientConfig), breaker)
+	cache := NewMemoryCache()
+	fetcher := NewCachedWebpageFetcher(next, cache, config.ToolCacheConfig{})
+
+	// 测试服务器监听在回环地址，每次未命中都被 SSRF 校验拒绝，且拒绝结果不会被缓存
+	for i := 0; i < 2; i++ {
+		_, err := fetcher.Fetch(ctx, server.URL+"/admin")
+		assert.ErrorIs(t, err, crawler.ErrURLNotAllowed)
+	}
+	assert.Equal(t, 0, hits)
+
+	normalized, ok := normalizeToolURL(server.URL + "/admin")
+	require.True(t, ok)
+	_, err := cache.Get(ctx, fmt.Sprintf(CacheKeyToolWebpage, hashToolKey(normalized)))
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestCachedSearchCrawler_RepeatedQueryServedFromCache(t *testing.T) {
+	ctx := context.Background()
+	next := &countingSearchCrawler{results: []model.SearchResult{{Title: "央行降准", URL: "https://example.com/1"}}}
+	search := NewCachedSearchCrawler(next, NewMemoryCache(), config.ToolCacheConfig{})
+
+	for _, q := range []string{"央行 降准", "  央行   降准 ", "央行 降准"} {
+		results, err := search.Search(ctx, q, 10)
+		require.NoError(t, err)
+		assert.Equal(t, next.results, results)
+	}
+	assert.Equal(t, 1, next.count("Search"))
+
+	// 结果数不同视为不同查询
+	_, err := search.Search(ctx, "央行 降准", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.count("Search"))
+}
+
+func TestCachedSearchCrawler_EmptyResultsNotCached(t *testing.T) {
+	ctx := context.Background()
+	next := &countingSearchCrawler{}
+	search := NewCachedSearchCrawler(next, NewMemoryCache(), config.ToolCacheConfig{})
+
+	for i := 0; i < 2; i++ {
+		_, err := search.Search(ctx, "冷门查询", 10)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, next.count("Search"))
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/pkg/random"
+)
+
+// 验证码字符集
+const (
+	CodeAlphabetNumeric      = "numeric"      // 纯数字
+	CodeAlphabetAlphanumeric = "alphanumeric" // 大写字母与数字，不含易混淆的 0/O、1/I
+)
+
+// 验证码长度
+const (
+	DefaultCodeLength = 6
+	MinCodeLength     = 4
+	MaxCodeLength     = 10 // 与 verification_codes.code 列宽一致
+)
+
+// codeAlphabets 字符集名称对应的字符
+var codeAlphabets = map[string]string{
+	CodeAlphabetNumeric:      "0123456789",
+	CodeAlphabetAlphanumeric: "23456789ABCDEFGHJKLMNPQRSTUVWXYZ",
+}
+
+// ErrInvalidCodeFormat 验证码长度或字符集配置无效
+var ErrInvalidCodeFormat = errors.New("invalid verification code format")
+
+// CodeFormat 验证码格式
+type CodeFormat struct {
+	Length   int
+	Alphabet string // 字符集名称
+}
+
+// DefaultCodeFormat 默认验证码格式：6 位数字
+func DefaultCodeFormat() CodeFormat {
+	return CodeFormat{Length: DefaultCodeLength, Alphabet: CodeAlphabetNumeric}
+}
+
+// NewCodeFormat 根据配置创建验证码格式，未设置的项使用默认值
+func NewCodeFormat(cfg config.VerificationCodeConfig) (CodeFormat, error) {
+	format := DefaultCodeFormat()
+	if cfg.Length != 0 {
+		format.Length = cfg.Length
+	}
+	if alphabet := strings.ToLower(strings.TrimSpace(cfg.Alphabet)); alphabet != "" {
+		format.Alphabet = alphabet
+	}
+	return format, format.validate()
+}
+
+// validate 校验长度范围与字符集名称
+func (f CodeFormat) validate() error {
+	if f.Length < MinCodeLength || f.Length > MaxCodeLength {
+		return fmt.Errorf("%w: length must be between %d and %d, got %d", ErrInvalidCodeFormat, MinCodeLength, MaxCodeLength, f.Length)
+	}
+	if _, ok := codeAlphabets[f.Alphabet]; !ok {
+		return fmt.Errorf("%w: unknown alphabet %q", ErrInvalidCodeFormat, f.Alphabet)
+	}
+	return nil
+}
+
+// codeRandom 验证码使用的随机源，必须为密码学安全的随机源
+var codeRandom io.Reader = random.Crypto
+
+// GenerateCode 使用密码学安全的随机源生成验证码
+func GenerateCode(format CodeFormat) (string, error) {
+	return generateCodeFrom(codeRandom, format)
+}
+
+// generateCodeFrom 从指定随机源生成验证码，每个字符在字符集内均匀分布
+func generateCodeFrom(random io.Reader, format CodeFormat) (string, error) {
+	if err := format.validate(); err != nil {
+		return "", err
+	}
+
+	alphabet := codeAlphabets[format.Alphabet]
+	max := big.NewInt(int64(len(alphabet)))
+
+	code := make([]byte, format.Length)
+	for i := range code {
+		n, err := rand.Int(random, max)
+		if err != nil {
+			return "", fmt.Errorf("generate verification code failed: %w", err)
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// codeMatches 比较验证码，字母不区分大小写
+func codeMatches(expected, actual string) bool {
+	return strings.EqualFold(expected, strings.TrimSpace(actual))
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"fund-analyzer/internal/config"
+	"fund-analyzer/pkg/random"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCodeFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.VerificationCodeConfig
+		want    CodeFormat
+		wantErr bool
+	}{
+		{"defaults", config.VerificationCodeConfig{}, CodeFormat{Length: 6, Alphabet: CodeAlphabetNumeric}, false},
+		{"alphanumeric", config.VerificationCodeConfig{Length: 8, Alphabet: " Alphanumeric "}, CodeFormat{Length: 8, Alphabet: CodeAlphabetAlphanumeric}, false},
+		{"too short", config.VerificationCodeConfig{Length: 3}, CodeFormat{}, true},
+		{"too long", config.VerificationCodeConfig{Length: 11}, CodeFormat{}, true},
+		{"unknown alphabet", config.VerificationCodeConfig{Alphabet: "hex"}, CodeFormat{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := NewCodeFormat(tt.cfg)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCodeFormat)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, format)
+		})
+	}
+}
+
+func TestGenerateCode_LengthAndAlphabet(t *testing.T) {
+	tests := []struct {
+		format  CodeFormat
+		allowed string
+	}{
+		{DefaultCodeFormat(), "0123456789"},
+		{CodeFormat{Length: 4, Alphabet: CodeAlphabetNumeric}, "0123456789"},
+		{CodeFormat{Length: 10, Alphabet: CodeAlphabetAlphanumeric}, "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			code, err := GenerateCode(tt.format)
+			require.NoError(t, err)
+			assert.Len(t, code, tt.format.Length)
+			for _, ch := range code {
+				assert.True(t, strings.ContainsRune(tt.allowed, ch), "unexpected character %q in %q", ch, code)
+			}
+		}
+	}
+}
+
+func TestGenerateCode_UniformAndUnique(t *testing.T) {
+	const n = 5000
+	counts := make(map[rune]int)
+	seen := make(map[string]bool, n)
+
+	for i := 0; i < n; i++ {
+		code, err := GenerateCode(DefaultCodeFormat())
+		require.NoError(t, err)
+		seen[code] = true
+		for _, ch := range code {
+			counts[ch]++
+		}
+	}
+
+	// 6 位数字共 100 万种组合，5000 个验证码几乎不应重复
+	assert.Greater(t, len(seen), n-50)
+
+	// 每个数字期望出现 3000 次，允许较宽的统计波动
+	require.Len(t, counts, 10)
+	for digit, count := range counts {
+		assert.InDelta(t, 3000, count, 400, "digit %q appeared %d times", digit, count)
+	}
+}
+
+func TestGenerateCode_UsesProvidedRandomSource(t *testing.T) {
+	// 随机源耗尽时报错，而不是退回到可预测的伪随机数
+	_, err := generateCodeFrom(bytes.NewReader(nil), DefaultCodeFormat())
+	assert.Error(t, err)
+
+	// 相同的随机字节生成相同的验证码
+	random := bytes.Repeat([]byte{0x03}, 64)
+	a, err := generateCodeFrom(bytes.NewReader(random), DefaultCodeFormat())
+	require.NoError(t, err)
+	b, err := generateCodeFrom(bytes.NewReader(random), DefaultCodeFormat())
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+func TestGenerateCode_UsesCryptoSource(t *testing.T) {
+	// 验证码不可使用可预测的随机源
+	assert.Equal(t, random.Crypto, codeRandom)
+}
+
+func TestGenerateCode_InvalidFormat(t *testing.T) {
+	_, err := GenerateCode(CodeFormat{Length: 6, Alphabet: "emoji"})
+	assert.True(t, errors.Is(err, ErrInvalidCodeFormat))
+}
+
+func TestCodeMatches(t *testing.T) {
+	assert.True(t, codeMatches("123456", "123456"))
+	assert.True(t, codeMatches("AB23CD", "ab23cd "))
+	assert.False(t, codeMatches("123456", "123457"))
+	assert.False(t, codeMatches("123456", ""))
+}
@@ -0,0 +1,6 @@
+-- 用户级 Token 吊销表（不关联 users，账号删除后仍保留，用于拒绝删除前签发的 Token）
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+    user_id BIGINT PRIMARY KEY,
+    revoked_before TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- 用户角色（user: 普通用户, admin: 管理员）
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
@@ -0,0 +1,9 @@
+-- 通知免打扰时段（按用户时区解析，start_time 晚于 end_time 表示跨午夜，如 22:00-07:00）
+CREATE TABLE IF NOT EXISTS user_quiet_hours (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    start_time VARCHAR(5) NOT NULL,  -- HH:MM
+    end_time VARCHAR(5) NOT NULL,    -- HH:MM
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Shanghai',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Token 版本，重置密码时递增，使之前签发的 Token 全部失效
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+-- 基金估值提醒：盘中估值涨跌幅越过阈值时发送邮件，同一提醒每天最多触发一次
+CREATE TABLE IF NOT EXISTS fund_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fund_code VARCHAR(10) NOT NULL,
+    direction VARCHAR(8) NOT NULL,              -- up/down
+    percent_threshold NUMERIC(6, 2) NOT NULL,   -- 涨跌幅阈值（%），正数
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_triggered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fund_alerts_user_code ON fund_alerts(user_id, fund_code);
+CREATE INDEX IF NOT EXISTS idx_fund_alerts_active ON fund_alerts(fund_code) WHERE active;
@@ -0,0 +1,11 @@
+-- 免打扰期间暂存的通知，免打扰结束后合并为摘要发送，发送成功后删除；服务重启后据此恢复
+CREATE TABLE IF NOT EXISTS pending_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    deliver_at TIMESTAMP NOT NULL   -- 免打扰结束时刻
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_notifications_user ON pending_notifications(user_id, id);
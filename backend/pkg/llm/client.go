@@ -20,6 +20,10 @@ type Config struct {
 	APIKey  string        // API key for authentication
 	Model   string        // Model name (e.g., "gpt-4", "gpt-3.5-turbo")
 	Timeout time.Duration // Request timeout
+	Retry   RetryConfig   // Retry policy for 429/5xx responses; the zero value disables retries
+
+	// Provider selects request shaping and stream parsing quirks; the zero value uses the OpenAI profile
+	Provider Provider
 }
 
 // Client is an OpenAI-compatible LLM client with streaming support.
@@ -33,6 +37,12 @@ type Message struct {
 	Role    string `json:"role"`    // "system", "user", "assistant", "tool"
 	Content string `json:"content"` // Message content
 	Name    string `json:"name,omitempty"` // Optional name for the message author
+
+	// ToolCalls holds the tool calls requested by the assistant in a non-streaming response
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID links a "tool" message to the assistant tool call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
 }
 
 // ToolCall represents a tool call from the assistant.
@@ -63,13 +73,29 @@ type Function struct {
 
 // ChatRequest represents a chat completion request.
 type ChatRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Stream      bool      `json:"stream,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Tools       []Tool    `json:"tools,omitempty"`
-	ToolChoice  string    `json:"tool_choice,omitempty"` // "auto", "none", or specific tool
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Stream         bool            `json:"stream,omitempty"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Tools          []Tool          `json:"tools,omitempty"`
+	ToolChoice     string          `json:"tool_choice,omitempty"` // "auto", "none", or specific tool
+	StreamOptions  *StreamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormatJSONObject constrains the model to emit a single valid JSON object.
+// The prompt must still ask for JSON explicitly and describe the expected fields.
+const ResponseFormatJSONObject = "json_object"
+
+// ResponseFormat selects the output format of the model.
+type ResponseFormat struct {
+	Type string `json:"type"` // "text" or "json_object"
+}
+
+// StreamOptions holds options that only apply to streaming requests.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // Ask the provider to send a final chunk with token usage
 }
 
 // ChatResponse represents a non-streaming chat completion response.
@@ -95,7 +121,10 @@ type Choice struct {
 type Delta struct {
 	Role      string     `json:"role,omitempty"`
 	Content   string     `json:"content,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+
+	// ReasoningContent carries the model's reasoning for providers that stream it separately
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 // Usage represents token usage information.
@@ -112,6 +141,7 @@ type StreamChunk struct {
 	Created int64          `json:"created"`
 	Model   string         `json:"model"`
 	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"` // Sent in the final chunk when include_usage is set
 }
 
 // StreamChoice represents a choice in a streaming response.
@@ -119,15 +149,18 @@ type StreamChoice struct {
 	Index        int    `json:"index"`
 	Delta        Delta  `json:"delta"`
 	FinishReason string `json:"finish_reason,omitempty"`
+	Usage        *Usage `json:"usage,omitempty"` // Set by providers that report usage inside the final choice
 }
 
 // StreamEvent represents an event from the streaming response.
 type StreamEvent struct {
 	Content      string     // Text content chunk
+	Reasoning    string     // Reasoning chunk, for providers that stream reasoning separately from content
 	ToolCalls    []ToolCall // Tool calls (if any)
 	FinishReason string     // Finish reason (if done)
 	Error        error      // Error (if any)
 	Done         bool       // Whether the stream is done
+	Usage        *Usage     // Token usage, set on the done event when requested and reported by the provider
 }
 
 // APIError represents an error response from the API.
@@ -162,6 +195,10 @@ func NewClient(cfg Config) (*Client, error) {
 		return nil, ErrEmptyModel
 	}
 
+	if cfg.Provider.Name == "" {
+		cfg.Provider = providers[ProviderOpenAI]
+	}
+
 	// Set default timeout if not specified
 	timeout := cfg.Timeout
 	if timeout == 0 {
@@ -206,6 +243,13 @@ func (c *Client) ChatWithOptions(ctx context.Context, messages []Message, opts *
 		if opts.ToolChoice != "" {
 			req.ToolChoice = opts.ToolChoice
 		}
+		if opts.ResponseFormat != nil {
+			req.ResponseFormat = opts.ResponseFormat
+		}
+	}
+
+	if err := c.config.Provider.shapeRequest(&req); err != nil {
+		return nil, err
 	}
 
 	body, err := json.Marshal(req)
@@ -213,26 +257,12 @@ func (c *Client) ChatWithOptions(ctx context.Context, messages []Message, opts *
 		return nil, fmt.Errorf("llm: failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatEndpoint(), bytes.NewReader(body))
+	resp, err := c.do(ctx, body)
 	if err != nil {
-		return nil, fmt.Errorf("llm: failed to create request: %w", err)
-	}
-
-	c.setHeaders(httpReq)
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		if ctx.Err() != nil {
-			return nil, ErrContextCanceled
-		}
-		return nil, fmt.Errorf("%w: %v", ErrRequestFailed, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, c.parseError(resp)
-	}
-
 	var chatResp ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
 		return nil, fmt.Errorf("llm: failed to decode response: %w", err)
@@ -247,6 +277,12 @@ type ChatOptions struct {
 	MaxTokens   int
 	Tools       []Tool
 	ToolChoice  string
+
+	// ResponseFormat requests a specific output format, e.g. a JSON object
+	ResponseFormat *ResponseFormat
+
+	// IncludeUsage requests token usage for streaming requests; ignored by non-streaming calls
+	IncludeUsage bool
 }
 
 // ChatStream sends a streaming chat completion request.
@@ -268,6 +304,10 @@ func (c *Client) ChatStreamWithOptions(ctx context.Context, messages []Message,
 		Stream:   true,
 	}
 
+	if opts != nil && opts.IncludeUsage {
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+
 	if opts != nil {
 		if opts.Temperature > 0 {
 			req.Temperature = opts.Temperature
@@ -281,6 +321,13 @@ func (c *Client) ChatStreamWithOptions(ctx context.Context, messages []Message,
 		if opts.ToolChoice != "" {
 			req.ToolChoice = opts.ToolChoice
 		}
+		if opts.ResponseFormat != nil {
+			req.ResponseFormat = opts.ResponseFormat
+		}
+	}
+
+	if err := c.config.Provider.shapeRequest(&req); err != nil {
+		return nil, err
 	}
 
 	body, err := json.Marshal(req)
@@ -288,31 +335,61 @@ func (c *Client) ChatStreamWithOptions(ctx context.Context, messages []Message,
 		return nil, fmt.Errorf("llm: failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatEndpoint(), bytes.NewReader(body))
+	// Retries happen only here, before any event is emitted; processStream never retries
+	resp, err := c.do(ctx, body)
 	if err != nil {
-		return nil, fmt.Errorf("llm: failed to create request: %w", err)
+		return nil, err
 	}
 
-	c.setHeaders(httpReq)
+	eventChan := make(chan StreamEvent, 100)
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		if ctx.Err() != nil {
-			return nil, ErrContextCanceled
-		}
-		return nil, fmt.Errorf("%w: %v", ErrRequestFailed, err)
-	}
+	go c.processStream(ctx, resp.Body, eventChan)
 
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		return nil, c.parseError(resp)
+	return eventChan, nil
+}
+
+// do posts the request body to the chat endpoint, retrying retryable statuses
+// according to the configured RetryConfig. On success the caller owns resp.Body.
+func (c *Client) do(ctx context.Context, body []byte) (*http.Response, error) {
+	retry := c.config.Retry
+	if retry.MaxRetries > 0 {
+		retry = retry.withDefaults()
 	}
 
-	eventChan := make(chan StreamEvent, 100)
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatEndpoint(), bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("llm: failed to create request: %w", err)
+		}
 
-	go c.processStream(ctx, resp.Body, eventChan)
+		c.setHeaders(httpReq)
 
-	return eventChan, nil
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ErrContextCanceled
+			}
+			return nil, fmt.Errorf("%w: %v", ErrRequestFailed, err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		if attempt >= retry.MaxRetries || !retry.retryable(resp.StatusCode) {
+			defer resp.Body.Close()
+			return nil, c.parseError(resp)
+		}
+
+		// Drain the error body so the connection can be reused
+		delay := retry.delay(attempt, resp.Header.Get("Retry-After"), time.Now())
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, ErrContextCanceled
+		}
+	}
 }
 
 // processStream reads the SSE stream and sends events to the channel.
@@ -323,7 +400,10 @@ func (c *Client) processStream(ctx context.Context, body io.ReadCloser, eventCha
 	reader := bufio.NewReader(body)
 
 	// Track accumulated tool calls across chunks
-	toolCallsMap := make(map[int]*ToolCall)
+	toolCalls := newToolCallAccumulator()
+
+	// Usage arrives in a final chunk with no choices, before [DONE]
+	var usage *Usage
 
 	for {
 		select {
@@ -336,7 +416,11 @@ func (c *Client) processStream(ctx context.Context, body io.ReadCloser, eventCha
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
-				eventChan <- StreamEvent{Done: true}
+				// Some gateways close the stream without [DONE]; still deliver tool calls
+				if calls := toolCalls.result(); len(calls) > 0 {
+					eventChan <- StreamEvent{ToolCalls: calls}
+				}
+				eventChan <- StreamEvent{Done: true, Usage: usage}
 				return
 			}
 			eventChan <- StreamEvent{Error: fmt.Errorf("llm: failed to read stream: %w", err), Done: true}
@@ -350,24 +434,19 @@ func (c *Client) processStream(ctx context.Context, body io.ReadCloser, eventCha
 			continue
 		}
 
-		// Check for SSE data prefix
-		if !strings.HasPrefix(line, "data: ") {
+		// Only SSE data fields carry chunks; an empty payload carries nothing
+		data, ok := sseData(line)
+		if !ok || data == "" {
 			continue
 		}
 
-		data := strings.TrimPrefix(line, "data: ")
-
 		// Check for stream end
 		if data == "[DONE]" {
 			// Send accumulated tool calls if any
-			if len(toolCallsMap) > 0 {
-				toolCalls := make([]ToolCall, 0, len(toolCallsMap))
-				for _, tc := range toolCallsMap {
-					toolCalls = append(toolCalls, *tc)
-				}
-				eventChan <- StreamEvent{ToolCalls: toolCalls}
+			if calls := toolCalls.result(); len(calls) > 0 {
+				eventChan <- StreamEvent{ToolCalls: calls}
 			}
-			eventChan <- StreamEvent{Done: true}
+			eventChan <- StreamEvent{Done: true, Usage: usage}
 			return
 		}
 
@@ -377,40 +456,34 @@ func (c *Client) processStream(ctx context.Context, body io.ReadCloser, eventCha
 			continue
 		}
 
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
 		if len(chunk.Choices) == 0 {
 			continue
 		}
 
 		choice := chunk.Choices[0]
+		provider := c.config.Provider
+
+		if provider.UsageInChoice && choice.Usage != nil {
+			usage = choice.Usage
+		}
+
+		// Handle reasoning, kept separate so content-only consumers are unaffected
+		if provider.ReasoningContent && choice.Delta.ReasoningContent != "" {
+			eventChan <- StreamEvent{Reasoning: choice.Delta.ReasoningContent}
+		}
 
 		// Handle content
 		if choice.Delta.Content != "" {
 			eventChan <- StreamEvent{Content: choice.Delta.Content}
 		}
 
-		// Handle tool calls (accumulate across chunks)
+		// Handle tool calls (accumulate across chunks by index)
 		for _, tc := range choice.Delta.ToolCalls {
-			idx := tc.ID
-			if idx == "" {
-				// Use index as key if ID is empty
-				idx = fmt.Sprintf("%d", len(toolCallsMap))
-			}
-			
-			existing, ok := toolCallsMap[len(toolCallsMap)]
-			if !ok || tc.ID != "" {
-				// New tool call
-				toolCallsMap[len(toolCallsMap)] = &ToolCall{
-					ID:   tc.ID,
-					Type: tc.Type,
-					Function: FunctionCall{
-						Name:      tc.Function.Name,
-						Arguments: tc.Function.Arguments,
-					},
-				}
-			} else {
-				// Append to existing tool call arguments
-				existing.Function.Arguments += tc.Function.Arguments
-			}
+			toolCalls.add(tc)
 		}
 
 		// Handle finish reason
@@ -420,6 +493,17 @@ func (c *Client) processStream(ctx context.Context, body io.ReadCloser, eventCha
 	}
 }
 
+// sseData extracts the payload of an SSE "data" field line.
+// The single space after the colon is optional per the SSE spec, so both
+// "data: {...}" and "data:{...}" are accepted. ok is false for other fields.
+func sseData(line string) (data string, ok bool) {
+	rest, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, " "), true
+}
+
 // chatEndpoint returns the chat completions endpoint URL.
 func (c *Client) chatEndpoint() string {
 	baseURL := strings.TrimSuffix(c.config.BaseURL, "/")
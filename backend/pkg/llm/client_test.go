@@ -464,3 +464,270 @@ func TestClient_ChatEndpoint(t *testing.T) {
 		}
 	}
 }
+
+func TestClient_ChatStream_DataPrefixWithoutSpace(t *testing.T) {
+	// Some gateways omit the optional space after "data:" and emit empty data lines
+	stream := "data:{\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+		"data:\n\n" +
+		"data: \n\n" +
+		": keep-alive\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\", \"}}]}\n\n" +
+		"data:{\"choices\":[{\"index\":0,\"delta\":{\"content\":\"world\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data:[DONE]\n\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "gpt-4",
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	eventChan, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	var content strings.Builder
+	var finishReason string
+	for event := range eventChan {
+		if event.Error != nil {
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+		content.WriteString(event.Content)
+		if event.FinishReason != "" {
+			finishReason = event.FinishReason
+		}
+	}
+
+	if content.String() != "Hello, world" {
+		t.Errorf("expected content 'Hello, world', got '%s'", content.String())
+	}
+	if finishReason != "stop" {
+		t.Errorf("expected finish_reason 'stop', got '%s'", finishReason)
+	}
+}
+
+func TestClient_ChatStream_IncludeUsage(t *testing.T) {
+	// The usage chunk has empty choices and arrives just before [DONE]
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3,\"total_tokens\":15}}\n\n" +
+		"data: [DONE]\n\n"
+
+	var reqBody ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "gpt-4",
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	eventChan, err := client.ChatStreamWithOptions(context.Background(), []Message{{Role: "user", Content: "Hello"}},
+		&ChatOptions{IncludeUsage: true})
+	if err != nil {
+		t.Fatalf("ChatStreamWithOptions() error = %v", err)
+	}
+
+	var content strings.Builder
+	var usage *Usage
+	for event := range eventChan {
+		if event.Error != nil {
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+		content.WriteString(event.Content)
+		if event.Usage != nil {
+			if !event.Done {
+				t.Error("usage should only be set on the done event")
+			}
+			usage = event.Usage
+		}
+	}
+
+	if reqBody.StreamOptions == nil || !reqBody.StreamOptions.IncludeUsage {
+		t.Errorf("expected stream_options.include_usage in request, got %+v", reqBody.StreamOptions)
+	}
+	if content.String() != "Hi" {
+		t.Errorf("expected content 'Hi', got '%s'", content.String())
+	}
+	want := Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}
+	if usage == nil || *usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, usage)
+	}
+}
+
+func TestClient_ChatStream_UsageNotRequested(t *testing.T) {
+	var raw map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{BaseURL: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	eventChan, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	for event := range eventChan {
+		if event.Usage != nil {
+			t.Errorf("unexpected usage %+v", event.Usage)
+		}
+	}
+
+	if _, ok := raw["stream_options"]; ok {
+		t.Error("stream_options should be omitted when usage is not requested")
+	}
+}
+
+// collectToolCalls streams the given SSE body and returns the emitted tool calls.
+func collectToolCalls(t *testing.T, stream string) []ToolCall {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{BaseURL: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	eventChan, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	var toolCalls []ToolCall
+	for event := range eventChan {
+		if event.Error != nil {
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+		toolCalls = append(toolCalls, event.ToolCalls...)
+	}
+	return toolCalls
+}
+
+func TestClient_ChatStream_ToolCallArgumentsAcrossChunks(t *testing.T) {
+	// The name arrives in the first chunk and the arguments are split across three
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"search_news\",\"arguments\":\"\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"query\\\":\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"央行降准\\\",\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"limit\\\":5}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n" +
+		"data: [DONE]\n\n"
+
+	toolCalls := collectToolCalls(t, stream)
+
+	if len(toolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d: %+v", len(toolCalls), toolCalls)
+	}
+	tc := toolCalls[0]
+	if tc.ID != "call_1" || tc.Function.Name != "search_news" {
+		t.Errorf("unexpected tool call %+v", tc)
+	}
+	if !json.Valid([]byte(tc.Function.Arguments)) {
+		t.Fatalf("arguments are not valid JSON: %s", tc.Function.Arguments)
+	}
+	var args struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+		t.Fatalf("failed to decode arguments: %v", err)
+	}
+	if args.Query != "央行降准" || args.Limit != 5 {
+		t.Errorf("unexpected arguments %+v", args)
+	}
+}
+
+func TestClient_ChatStream_ParallelToolCallsInterleaved(t *testing.T) {
+	// Fragments of two calls interleave; the result follows the index order
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_a\",\"type\":\"function\",\"function\":{\"name\":\"search_news\",\"arguments\":\"{\\\"query\\\":\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":1,\"id\":\"call_b\",\"type\":\"function\",\"function\":{\"name\":\"fetch_webpage\",\"arguments\":\"{\\\"url\\\":\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":1,\"function\":{\"arguments\":\"\\\"https://example.com\\\"}\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"黄金\\\"}\"}}]}}]}\n\n" +
+		"data: [DONE]\n\n"
+
+	toolCalls := collectToolCalls(t, stream)
+
+	want := []ToolCall{
+		{ID: "call_a", Type: "function", Function: FunctionCall{Name: "search_news", Arguments: `{"query":"黄金"}`}},
+		{ID: "call_b", Type: "function", Function: FunctionCall{Name: "fetch_webpage", Arguments: `{"url":"https://example.com"}`}},
+	}
+	if len(toolCalls) != len(want) {
+		t.Fatalf("expected %d tool calls, got %d: %+v", len(want), len(toolCalls), toolCalls)
+	}
+	for i := range want {
+		if toolCalls[i] != want[i] {
+			t.Errorf("tool call %d = %+v, want %+v", i, toolCalls[i], want[i])
+		}
+	}
+}
+
+func TestClient_ChatStream_ToolCallsWithoutIndex(t *testing.T) {
+	// Some gateways omit the index and send each complete call with its own ID
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"id\":\"call_a\",\"type\":\"function\",\"function\":{\"name\":\"search_news\",\"arguments\":\"{}\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"id\":\"call_b\",\"type\":\"function\",\"function\":{\"name\":\"fetch_webpage\",\"arguments\":\"{}\"}}]}}]}\n\n"
+
+	toolCalls := collectToolCalls(t, stream)
+
+	if len(toolCalls) != 2 || toolCalls[0].ID != "call_a" || toolCalls[1].ID != "call_b" {
+		t.Fatalf("unexpected tool calls %+v", toolCalls)
+	}
+	for _, tc := range toolCalls {
+		if tc.Function.Arguments != "{}" {
+			t.Errorf("tool call %s arguments = %q, want %q", tc.ID, tc.Function.Arguments, "{}")
+		}
+	}
+}
+
+func TestSSEData(t *testing.T) {
+	tests := []struct {
+		line   string
+		data   string
+		wantOK bool
+	}{
+		{`data: {"a":1}`, `{"a":1}`, true},
+		{`data:{"a":1}`, `{"a":1}`, true},
+		{`data:  {"a":1}`, ` {"a":1}`, true},
+		{"data:", "", true},
+		{"data:[DONE]", "[DONE]", true},
+		{"event: message", "", false},
+		{": comment", "", false},
+		{"metadata: x", "", false},
+	}
+
+	for _, tt := range tests {
+		data, ok := sseData(tt.line)
+		if ok != tt.wantOK || data != tt.data {
+			t.Errorf("sseData(%q) = (%q, %v), want (%q, %v)", tt.line, data, ok, tt.data, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Provider names accepted by LookupProvider.
+const (
+	ProviderOpenAI   = "openai"
+	ProviderDeepSeek = "deepseek"
+	ProviderMoonshot = "moonshot"
+	ProviderVLLM     = "vllm"
+)
+
+var (
+	ErrUnknownProvider   = errors.New("llm: unknown provider")
+	ErrToolsNotSupported = errors.New("llm: provider does not support tool calling")
+)
+
+// Provider describes how an OpenAI-compatible API deviates from the OpenAI
+// reference behavior. The client shapes requests and parses streams according
+// to it, so callers stay provider-agnostic.
+type Provider struct {
+	Name string
+
+	// StreamOptions reports whether stream_options is accepted; when false,
+	// ChatOptions.IncludeUsage is not sent.
+	StreamOptions bool
+	// UsageInChoice reports whether streamed usage arrives inside the final
+	// choice instead of a separate top-level chunk.
+	UsageInChoice bool
+	// Tools reports whether function calling is available; requests with tools
+	// fail with ErrToolsNotSupported otherwise.
+	Tools bool
+	// ResponseFormat reports whether response_format is accepted; when false it
+	// is dropped and the prompt alone must ask for the format.
+	ResponseFormat bool
+	// ReasoningContent reports whether the model streams its reasoning in
+	// delta.reasoning_content; it is surfaced as StreamEvent.Reasoning.
+	ReasoningContent bool
+	// MaxTemperature caps the sampling temperature; 0 means no cap.
+	MaxTemperature float64
+}
+
+// providers holds the built-in provider profiles.
+var providers = map[string]Provider{
+	ProviderOpenAI: {
+		Name:           ProviderOpenAI,
+		StreamOptions:  true,
+		Tools:          true,
+		ResponseFormat: true,
+	},
+	ProviderDeepSeek: {
+		Name:             ProviderDeepSeek,
+		StreamOptions:    true,
+		Tools:            true,
+		ResponseFormat:   true,
+		ReasoningContent: true,
+	},
+	ProviderMoonshot: {
+		Name:           ProviderMoonshot,
+		UsageInChoice:  true,
+		Tools:          true,
+		ResponseFormat: true,
+		MaxTemperature: 1,
+	},
+	// vLLM only exposes tool calling when started with --enable-auto-tool-choice
+	ProviderVLLM: {
+		Name:             ProviderVLLM,
+		StreamOptions:    true,
+		ResponseFormat:   true,
+		ReasoningContent: true,
+	},
+}
+
+// LookupProvider returns the built-in profile for name (case-insensitive).
+// An empty name selects the OpenAI profile.
+func LookupProvider(name string) (Provider, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = ProviderOpenAI
+	}
+	p, ok := providers[name]
+	if !ok {
+		names := make([]string, 0, len(providers))
+		for n := range providers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Provider{}, fmt.Errorf("%w %q, supported: %s", ErrUnknownProvider, name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// shapeRequest adapts a request to the provider's supported features.
+func (p Provider) shapeRequest(req *ChatRequest) error {
+	if len(req.Tools) > 0 && !p.Tools {
+		return fmt.Errorf("%w: %s", ErrToolsNotSupported, p.Name)
+	}
+	if !p.StreamOptions {
+		req.StreamOptions = nil
+	}
+	if !p.ResponseFormat {
+		req.ResponseFormat = nil
+	}
+	if p.MaxTemperature > 0 && req.Temperature > p.MaxTemperature {
+		req.Temperature = p.MaxTemperature
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newProviderTestClient starts a server that records the raw request body and
+// replies with stream, and returns a client using the named provider profile.
+func newProviderTestClient(t *testing.T, provider, stream string, raw *map[string]interface{}) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(raw); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := LookupProvider(provider)
+	if err != nil {
+		t.Fatalf("LookupProvider(%q) error = %v", provider, err)
+	}
+	client, err := NewClient(Config{
+		BaseURL:  server.URL,
+		APIKey:   "test-key",
+		Model:    "test-model",
+		Timeout:  10 * time.Second,
+		Provider: p,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+// collectStream drains eventChan and returns the content, reasoning and usage.
+func collectStream(t *testing.T, eventChan <-chan StreamEvent) (content, reasoning string, usage *Usage) {
+	t.Helper()
+	var c, r strings.Builder
+	for event := range eventChan {
+		if event.Error != nil {
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+		c.WriteString(event.Content)
+		r.WriteString(event.Reasoning)
+		if event.Usage != nil {
+			usage = event.Usage
+		}
+	}
+	return c.String(), r.String(), usage
+}
+
+func TestProvider_DeepSeekStreamsReasoningSeparately(t *testing.T) {
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"reasoning_content\":\"Think \"}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"reasoning_content\":\"first.\"}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Answer\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}\n\n" +
+		"data: [DONE]\n\n"
+
+	var raw map[string]interface{}
+	client := newProviderTestClient(t, ProviderDeepSeek, stream, &raw)
+
+	eventChan, err := client.ChatStreamWithOptions(context.Background(), []Message{{Role: "user", Content: "Hello"}},
+		&ChatOptions{IncludeUsage: true, Temperature: 1.5, ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject}})
+	if err != nil {
+		t.Fatalf("ChatStreamWithOptions() error = %v", err)
+	}
+	content, reasoning, usage := collectStream(t, eventChan)
+
+	if _, ok := raw["stream_options"]; !ok {
+		t.Error("deepseek request should include stream_options")
+	}
+	if _, ok := raw["response_format"]; !ok {
+		t.Error("deepseek request should include response_format")
+	}
+	if raw["temperature"] != 1.5 {
+		t.Errorf("temperature should be sent unchanged, got %v", raw["temperature"])
+	}
+	if content != "Answer" {
+		t.Errorf("expected content 'Answer', got %q", content)
+	}
+	if reasoning != "Think first." {
+		t.Errorf("expected reasoning 'Think first.', got %q", reasoning)
+	}
+	if usage == nil || usage.TotalTokens != 15 {
+		t.Errorf("expected usage with 15 total tokens, got %+v", usage)
+	}
+}
+
+func TestProvider_MoonshotShapesRequestAndReadsUsageFromChoice(t *testing.T) {
+	// Moonshot reports usage inside the final choice and rejects stream_options
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\",\"usage\":{\"prompt_tokens\":8,\"completion_tokens\":2,\"total_tokens\":10}}]}\n\n" +
+		"data: [DONE]\n\n"
+
+	var raw map[string]interface{}
+	client := newProviderTestClient(t, ProviderMoonshot, stream, &raw)
+
+	eventChan, err := client.ChatStreamWithOptions(context.Background(), []Message{{Role: "user", Content: "Hello"}},
+		&ChatOptions{IncludeUsage: true, Temperature: 1.5})
+	if err != nil {
+		t.Fatalf("ChatStreamWithOptions() error = %v", err)
+	}
+	content, _, usage := collectStream(t, eventChan)
+
+	if _, ok := raw["stream_options"]; ok {
+		t.Error("moonshot request should not include stream_options")
+	}
+	if raw["temperature"] != 1.0 {
+		t.Errorf("temperature should be capped at 1, got %v", raw["temperature"])
+	}
+	if content != "Hi" {
+		t.Errorf("expected content 'Hi', got %q", content)
+	}
+	want := Usage{PromptTokens: 8, CompletionTokens: 2, TotalTokens: 10}
+	if usage == nil || *usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, usage)
+	}
+}
+
+func TestProvider_OpenAIIgnoresReasoningAndChoiceUsage(t *testing.T) {
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"reasoning_content\":\"hidden\",\"content\":\"Hi\"},\"finish_reason\":\"stop\",\"usage\":{\"total_tokens\":99}}]}\n\n" +
+		"data: [DONE]\n\n"
+
+	var raw map[string]interface{}
+	client := newProviderTestClient(t, "", stream, &raw)
+
+	eventChan, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "Hello"}})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	content, reasoning, usage := collectStream(t, eventChan)
+
+	if content != "Hi" || reasoning != "" || usage != nil {
+		t.Errorf("got content %q, reasoning %q, usage %+v", content, reasoning, usage)
+	}
+}
+
+func TestProvider_VLLMRejectsToolsBeforeSending(t *testing.T) {
+	var raw map[string]interface{}
+	client := newProviderTestClient(t, ProviderVLLM, "data: [DONE]\n\n", &raw)
+
+	_, err := client.ChatStreamWithOptions(context.Background(), []Message{{Role: "user", Content: "Hello"}},
+		&ChatOptions{Tools: []Tool{{Type: "function", Function: Function{Name: "search"}}}})
+	if !errors.Is(err, ErrToolsNotSupported) {
+		t.Errorf("expected ErrToolsNotSupported, got %v", err)
+	}
+	if raw != nil {
+		t.Error("request should not be sent")
+	}
+}
+
+func TestLookupProvider(t *testing.T) {
+	for name, want := range map[string]string{"": ProviderOpenAI, " DeepSeek ": ProviderDeepSeek, "vllm": ProviderVLLM} {
+		p, err := LookupProvider(name)
+		if err != nil {
+			t.Fatalf("LookupProvider(%q) error = %v", name, err)
+		}
+		if p.Name != want {
+			t.Errorf("LookupProvider(%q) = %q, want %q", name, p.Name, want)
+		}
+	}
+
+	if _, err := LookupProvider("acme"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default retry settings applied to unset RetryConfig fields when retries are enabled.
+const (
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultRetryMaxDelay  = 10 * time.Second
+)
+
+// DefaultRetryableStatusCodes are the statuses retried when RetryConfig.RetryableStatusCodes is empty.
+var DefaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryConfig controls retries of failed chat requests.
+// The zero value disables retries. Only responses with a retryable status are
+// retried; transport errors and cancellation fail immediately.
+type RetryConfig struct {
+	MaxRetries           int           // Retries after the first attempt; <= 0 disables retries
+	BaseDelay            time.Duration // Delay before the first retry, doubled on each further retry
+	MaxDelay             time.Duration // Upper bound for any single delay, including Retry-After
+	RetryableStatusCodes []int         // Statuses worth retrying; defaults to DefaultRetryableStatusCodes
+}
+
+// DefaultRetryConfig returns a retry policy suitable for most OpenAI-compatible gateways.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries: 2,
+		BaseDelay:  DefaultRetryBaseDelay,
+		MaxDelay:   DefaultRetryMaxDelay,
+	}
+}
+
+// withDefaults fills unset fields of an enabled policy.
+func (r RetryConfig) withDefaults() RetryConfig {
+	if r.BaseDelay <= 0 {
+		r.BaseDelay = DefaultRetryBaseDelay
+	}
+	if r.MaxDelay <= 0 {
+		r.MaxDelay = DefaultRetryMaxDelay
+	}
+	if r.MaxDelay < r.BaseDelay {
+		r.MaxDelay = r.BaseDelay
+	}
+	if len(r.RetryableStatusCodes) == 0 {
+		r.RetryableStatusCodes = DefaultRetryableStatusCodes
+	}
+	return r
+}
+
+// retryable reports whether a response with the given status should be retried.
+func (r RetryConfig) retryable(status int) bool {
+	for _, code := range r.RetryableStatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before retry number attempt (0-based).
+// A valid Retry-After header overrides the exponential backoff; both are capped at MaxDelay.
+func (r RetryConfig) delay(attempt int, retryAfter string, now time.Time) time.Duration {
+	if d, ok := parseRetryAfter(retryAfter, now); ok {
+		return min(d, r.MaxDelay)
+	}
+
+	d := r.BaseDelay
+	for i := 0; i < attempt && d < r.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, r.MaxDelay)
+}
+
+// parseRetryAfter parses a Retry-After header given either as delay seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,218 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer returns 503 for the first failures requests, then serves ok.
+func flakyServer(t *testing.T, failures int32, ok http.HandlerFunc) (*httptest.Server, *int32) {
+	t.Helper()
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"message":"overloaded","type":"server_error"}}`)
+			return
+		}
+		ok(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func newRetryClient(t *testing.T, baseURL string, retry RetryConfig) *Client {
+	t.Helper()
+	client, err := NewClient(Config{BaseURL: baseURL, APIKey: "test-key", Model: "gpt-4", Retry: retry})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func chatOK(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`)
+}
+
+func streamOK(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func TestClient_Chat_RetriesUntilSuccess(t *testing.T) {
+	server, attempts := flakyServer(t, 2, chatOK)
+	client := newRetryClient(t, server.URL, RetryConfig{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	resp, err := client.Chat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Choices[0].Message.Content != "Hello" {
+		t.Errorf("content = %q, want %q", resp.Choices[0].Message.Content, "Hello")
+	}
+	if got := atomic.LoadInt32(attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestClient_ChatStream_RetriesBeforeFirstEvent(t *testing.T) {
+	server, attempts := flakyServer(t, 2, streamOK)
+	client := newRetryClient(t, server.URL, RetryConfig{MaxRetries: 2, BaseDelay: time.Millisecond})
+
+	events, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	var content strings.Builder
+	for e := range events {
+		if e.Error != nil {
+			t.Fatalf("stream error = %v", e.Error)
+		}
+		content.WriteString(e.Content)
+	}
+	if content.String() != "Hello" {
+		t.Errorf("content = %q, want %q", content.String(), "Hello")
+	}
+	if got := atomic.LoadInt32(attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestClient_ChatStream_NoRetryAfterStreamStarts(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		// Abort the connection mid-stream
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+	client := newRetryClient(t, server.URL, RetryConfig{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	events, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	var content strings.Builder
+	for e := range events {
+		content.WriteString(e.Content)
+	}
+	if content.String() != "partial" {
+		t.Errorf("content = %q, want %q", content.String(), "partial")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestClient_Chat_RetryLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32
+		retry        RetryConfig
+		wantAttempts int32
+	}{
+		{"disabled by default", 5, RetryConfig{}, 1},
+		{"exhausted", 5, RetryConfig{MaxRetries: 2, BaseDelay: time.Millisecond}, 3},
+		{"status not retryable", 5, RetryConfig{MaxRetries: 2, BaseDelay: time.Millisecond, RetryableStatusCodes: []int{http.StatusTooManyRequests}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, attempts := flakyServer(t, tt.failures, chatOK)
+			client := newRetryClient(t, server.URL, tt.retry)
+
+			_, err := client.Chat(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+			if err == nil || !strings.Contains(err.Error(), "status 503") {
+				t.Errorf("Chat() error = %v, want status 503 error", err)
+			}
+			if got := atomic.LoadInt32(attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestClient_Chat_CancelDuringBackoff(t *testing.T) {
+	server, attempts := flakyServer(t, 5, chatOK)
+	client := newRetryClient(t, server.URL, RetryConfig{MaxRetries: 3, BaseDelay: 10 * time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Chat(ctx, []Message{{Role: "user", Content: "Hi"}})
+	if !errors.Is(err, ErrContextCanceled) {
+		t.Errorf("Chat() error = %v, want %v", err, ErrContextCanceled)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("backoff not aborted, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestClient_Chat_RetryAfterOverridesBackoff(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		chatOK(w, r)
+	}))
+	defer server.Close()
+	// The computed backoff would far exceed the test timeout
+	client := newRetryClient(t, server.URL, RetryConfig{MaxRetries: 1, BaseDelay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Chat(ctx, []Message{{Role: "user", Content: "Hi"}}); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestRetryConfig_Delay(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	retry := RetryConfig{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}.withDefaults()
+
+	tests := []struct {
+		attempt    int
+		retryAfter string
+		want       time.Duration
+	}{
+		{0, "", 100 * time.Millisecond},
+		{1, "", 200 * time.Millisecond},
+		{3, "", 800 * time.Millisecond},
+		{4, "", time.Second},
+		{0, "invalid", 100 * time.Millisecond},
+		{0, "-1", 100 * time.Millisecond},
+		{3, "0", 0},
+		{0, "1", time.Second},
+		{0, "120", time.Second},
+		{0, now.Add(500 * time.Millisecond).Format(http.TimeFormat), 0}, // HTTP dates have second precision
+		{0, now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		if got := retry.delay(tt.attempt, tt.retryAfter, now); got != tt.want {
+			t.Errorf("delay(%d, %q) = %v, want %v", tt.attempt, tt.retryAfter, got, tt.want)
+		}
+	}
+}
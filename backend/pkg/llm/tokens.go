@@ -0,0 +1,32 @@
+package llm
+
+import "unicode"
+
+// messageOverheadTokens approximates the per-message framing cost (role, separators).
+const messageOverheadTokens = 4
+
+// EstimateTokens returns a rough token count for text without a tokenizer.
+// CJK characters are counted as one token each; other characters are
+// counted at roughly four per token, which is close enough for budgeting.
+func EstimateTokens(text string) int {
+	cjk := 0
+	other := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// EstimateMessagesTokens returns a rough token count for a list of messages.
+func EstimateMessagesTokens(messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += messageOverheadTokens + EstimateTokens(m.Content) + EstimateTokens(m.Name)
+	}
+	return total
+}
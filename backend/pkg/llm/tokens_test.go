@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "ascii", text: "abcdefgh", want: 2},
+		{name: "ascii rounds up", text: "abcde", want: 2},
+		{name: "chinese", text: "上证指数", want: 4},
+		{name: "mixed", text: "黄金gold", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.want {
+				t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateMessagesTokens(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: strings.Repeat("a", 40)},
+		{Role: "tool", Content: "新闻", Name: "search_news"},
+	}
+
+	// 4+10 for system, 4+2+3 for tool
+	if got := EstimateMessagesTokens(messages); got != 23 {
+		t.Errorf("EstimateMessagesTokens() = %d, want 23", got)
+	}
+}
@@ -0,0 +1,74 @@
+package llm
+
+import "sort"
+
+// ToolCallDelta is a fragment of a tool call in a streaming response.
+// Fragments of the same call share an Index; usually only the first one
+// carries the ID and function name, later ones append to the arguments.
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// toolCallAccumulator reassembles streamed tool call fragments by index.
+type toolCallAccumulator struct {
+	calls map[int]*ToolCall
+	next  int // Lowest index not yet used, for gateways that omit the index
+}
+
+func newToolCallAccumulator() *toolCallAccumulator {
+	return &toolCallAccumulator{calls: make(map[int]*ToolCall)}
+}
+
+// add merges a fragment into the call at its index.
+func (a *toolCallAccumulator) add(delta ToolCallDelta) {
+	index := delta.Index
+	// Gateways that omit the index report every call at 0; a new ID starts a new call
+	if existing, ok := a.calls[index]; ok && delta.ID != "" && existing.ID != "" && delta.ID != existing.ID {
+		index = a.next
+	}
+
+	call, ok := a.calls[index]
+	if !ok {
+		call = &ToolCall{}
+		a.calls[index] = call
+		if index >= a.next {
+			a.next = index + 1
+		}
+	}
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	// Some providers repeat the name on every fragment; keep the first one
+	if call.Function.Name == "" {
+		call.Function.Name = delta.Function.Name
+	}
+	call.Function.Arguments += delta.Function.Arguments
+}
+
+// result returns the accumulated calls ordered by index.
+func (a *toolCallAccumulator) result() []ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(a.calls))
+	for index := range a.calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	calls := make([]ToolCall, 0, len(indexes))
+	for _, index := range indexes {
+		call := *a.calls[index]
+		if call.Type == "" {
+			call.Type = "function"
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
@@ -0,0 +1,75 @@
+package random
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSeeded_Reproducible(t *testing.T) {
+	a, b := NewSeeded(7), NewSeeded(7)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, a.Int63n(1000), b.Int63n(1000))
+		assert.Equal(t, a.Float64(), b.Float64())
+	}
+}
+
+func TestJitter(t *testing.T) {
+	a, b := NewSeeded(1), NewSeeded(1)
+	for i := 0; i < 100; i++ {
+		d := Jitter(a, 250*time.Millisecond)
+		assert.Equal(t, d, Jitter(b, 250*time.Millisecond))
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.Less(t, d, 250*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Duration(0), Jitter(Default(), 0))
+	assert.Equal(t, time.Duration(0), Jitter(Default(), -time.Second))
+}
+
+func TestChance(t *testing.T) {
+	src := NewSeeded(3)
+	for i := 0; i < 100; i++ {
+		assert.False(t, Chance(src, 0))
+		assert.True(t, Chance(src, 1))
+	}
+
+	// 相同种子的采样结果一致，且比例接近设定值
+	sample := func(seed int64) []bool {
+		src := NewSeeded(seed)
+		hits := make([]bool, 1000)
+		for i := range hits {
+			hits[i] = Chance(src, 0.2)
+		}
+		return hits
+	}
+	first := sample(42)
+	assert.Equal(t, first, sample(42))
+
+	var n int
+	for _, hit := range first {
+		if hit {
+			n++
+		}
+	}
+	assert.InDelta(t, 200, n, 50)
+}
+
+func TestCrypto(t *testing.T) {
+	buf := make([]byte, 16)
+	n, err := Crypto.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 16, n)
+
+	for i := 0; i < 100; i++ {
+		v := Crypto.Int63n(10)
+		assert.GreaterOrEqual(t, v, int64(0))
+		assert.Less(t, v, int64(10))
+
+		f := Crypto.Float64()
+		assert.GreaterOrEqual(t, f, 0.0)
+		assert.Less(t, f, 1.0)
+	}
+	assert.Panics(t, func() { Crypto.Int63n(0) })
+}
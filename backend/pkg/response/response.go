@@ -14,9 +14,13 @@ const (
 	CodeForbidden          = 403
 	CodeNotFound           = 404
 	CodeConflict           = 409
+	CodePayloadTooLarge    = 413
 	CodeRateLimited        = 429
 	CodeInternalError      = 500
 	CodeServiceUnavailable = 503
+
+	// CodeAnalysisInProgress 用户已有进行中的 AI 分析
+	CodeAnalysisInProgress = 4291
 )
 
 // Response API 统一响应结构
@@ -80,6 +84,11 @@ func Conflict(c *gin.Context, message string) {
 	Error(c, http.StatusConflict, CodeConflict, message)
 }
 
+// PayloadTooLarge 413 错误
+func PayloadTooLarge(c *gin.Context, message string) {
+	Error(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, message)
+}
+
 // RateLimited 429 错误
 func RateLimited(c *gin.Context, message string) {
 	Error(c, http.StatusTooManyRequests, CodeRateLimited, message)